@@ -0,0 +1,120 @@
+package handlers
+
+import (
+    "net/http"
+    "strconv"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/services"
+
+    "github.com/gin-gonic/gin"
+)
+
+type AnalyticsHandler struct {
+    analyticsService *services.AnalyticsService
+}
+
+func NewAnalyticsHandler(analyticsService *services.AnalyticsService) *AnalyticsHandler {
+    return &AnalyticsHandler{analyticsService: analyticsService}
+}
+
+// GetMaterialsOverview godoc
+// @Summary Аналитика по материалам преподавателя
+// @Description Возвращает по каждому материалу преподавателя число завершений, среднюю оценку и среднее время изучения
+// @Tags teacher
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} MaterialsAnalyticsResponse "Статистика материалов"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /teacher/analytics/materials [get]
+func (h *AnalyticsHandler) GetMaterialsOverview(c *gin.Context) {
+    userID := c.GetInt("userID")
+
+    materials, err := h.analyticsService.GetMaterialsOverview(c.Request.Context(), userID)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get analytics"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "materials": materials,
+        "total":     len(materials),
+    })
+}
+
+// GetMaterialAnalytics godoc
+// @Summary Аналитика материала
+// @Description Возвращает завершения, среднюю оценку, распределение времени изучения, успешность ответов на вопросы и отток по блокам. Доступно автору и соавторам с ролью не ниже reviewer
+// @Tags teacher
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID материала"
+// @Success 200 {object} models.MaterialAnalytics "Аналитика материала"
+// @Failure 400 {object} InvalidIDErrorResponse "Неверный ID"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} MaterialNotFoundErrorResponse "Материал не найден"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /teacher/analytics/materials/{id} [get]
+func (h *AnalyticsHandler) GetMaterialAnalytics(c *gin.Context) {
+    userID := c.GetInt("userID")
+    materialID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+        return
+    }
+
+    analytics, err := h.analyticsService.GetMaterialAnalytics(c.Request.Context(), userID, materialID)
+    if err != nil {
+        respondMaterialError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, analytics)
+}
+
+// GetStudentAnalytics godoc
+// @Summary Аналитика ученика
+// @Description Возвращает результаты ученика по материалам преподавателя: созданным им и назначенным в его классах. Доступно только для учеников из классов преподавателя
+// @Tags teacher
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID ученика"
+// @Success 200 {object} models.StudentAnalytics "Аналитика ученика"
+// @Failure 400 {object} InvalidIDErrorResponse "Неверный ID"
+// @Failure 403 {object} ForbiddenErrorResponse "Ученик не состоит в классах преподавателя"
+// @Failure 404 {object} UserNotFoundErrorResponse "Пользователь не найден"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /teacher/analytics/students/{id} [get]
+func (h *AnalyticsHandler) GetStudentAnalytics(c *gin.Context) {
+    userID := c.GetInt("userID")
+    studentID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+        return
+    }
+
+    analytics, err := h.analyticsService.GetStudentAnalytics(c.Request.Context(), userID, studentID)
+    if err != nil {
+        switch err.Error() {
+        case "student not in your classes":
+            c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+        case "user not found":
+            c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+        default:
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get analytics"})
+        }
+        return
+    }
+
+    c.JSON(http.StatusOK, analytics)
+}
+
+// Response models for Swagger
+
+// MaterialsAnalyticsResponse represents teacher materials analytics response
+// @Description Ответ со статистикой материалов преподавателя
+type MaterialsAnalyticsResponse struct {
+    Materials []models.MaterialStats `json:"materials"`
+    Total     int                    `json:"total" example:"8"`
+}
@@ -23,9 +23,12 @@ func NewCatalogHandler(catalogService *services.CatalogService) *CatalogHandler
 // @Tags catalog
 // @Accept json
 // @Produce json
-// @Param search query string false "Поисковый запрос"
+// @Param search query string false "Поисковый запрос (название, описание, автор)"
 // @Param subject query string false "Фильтр по предмету"
-// @Param level query string false "Фильтр по уровню сложности"
+// @Param level query string false "Фильтр по уровню сложности" Enums(beginner, intermediate, advanced)
+// @Param tags query []string false "Фильтр по тегам (материал должен иметь все теги)" collectionFormat(multi)
+// @Param language query string false "Фильтр по языку" example(ru)
+// @Param maxDuration query int false "Максимальная длительность в минутах"
 // @Param page query int false "Номер страницы" default(1)
 // @Param limit query int false "Количество материалов на странице" default(20)
 // @Success 200 {object} MaterialsResponse "Список материалов"
@@ -64,6 +67,50 @@ func (h *CatalogHandler) SearchMaterials(c *gin.Context) {
     })
 }
 
+// SearchCourses godoc
+// @Summary Поиск курсов в каталоге
+// @Description Возвращает опубликованные курсы с фильтрацией и пагинацией
+// @Tags catalog
+// @Produce json
+// @Param search query string false "Поисковый запрос (название, описание, автор)"
+// @Param subject query string false "Фильтр по предмету"
+// @Param level query string false "Фильтр по уровню сложности" Enums(beginner, intermediate, advanced)
+// @Param page query int false "Номер страницы" default(1)
+// @Param limit query int false "Количество курсов на странице" default(20)
+// @Success 200 {object} CoursesResponse "Список курсов"
+// @Failure 400 {object} ErrorResponse "Неверные параметры запроса"
+// @Failure 500 {object} ErrorResponse "Ошибка сервера"
+// @Router /catalog/courses [get]
+func (h *CatalogHandler) SearchCourses(c *gin.Context) {
+    var filters models.CourseFilters
+
+    if err := c.ShouldBindQuery(&filters); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    if filters.Page == 0 {
+        filters.Page = 1
+    }
+    if filters.Limit == 0 {
+        filters.Limit = 20
+    }
+
+    courses, total, err := h.catalogService.SearchCourses(c.Request.Context(), filters)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search courses"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "courses": courses,
+        "total":   total,
+        "page":    filters.Page,
+        "limit":   filters.Limit,
+        "hasMore": (filters.Page * filters.Limit) < total,
+    })
+}
+
 // GetSubjects godoc
 // @Summary Получить список предметов
 // @Description Возвращает все доступные учебные предметы
@@ -88,6 +135,26 @@ func (h *CatalogHandler) GetSubjects(c *gin.Context) {
     })
 }
 
+// GetTags godoc
+// @Summary Получить список тегов
+// @Description Возвращает теги опубликованных материалов с количеством материалов
+// @Tags catalog
+// @Produce json
+// @Success 200 {object} TagsResponse "Список тегов"
+// @Failure 500 {object} ErrorResponse "Ошибка сервера"
+// @Router /catalog/tags [get]
+func (h *CatalogHandler) GetTags(c *gin.Context) {
+    tags, err := h.catalogService.GetTags(c.Request.Context())
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get tags"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "tags": tags,
+    })
+}
+
 // SearchTeachers godoc
 // @Summary Поиск преподавателей
 // @Description Возвращает преподавателей с фильтрацией
@@ -131,6 +198,16 @@ type MaterialsResponse struct {
     HasMore   bool                     `json:"hasMore" example:"true"`
 }
 
+// CoursesResponse represents courses search response
+// @Description Ответ с результатами поиска курсов
+type CoursesResponse struct {
+    Courses []models.CatalogCourse `json:"courses"`
+    Total   int                    `json:"total" example:"12"`
+    Page    int                    `json:"page" example:"1"`
+    Limit   int                    `json:"limit" example:"20"`
+    HasMore bool                   `json:"hasMore" example:"false"`
+}
+
 // SubjectsResponse represents subjects list response
 // @Description Ответ со списком предметов
 type SubjectsResponse struct {
@@ -143,3 +220,8 @@ type TeachersResponse struct {
     Teachers []models.Teacher `json:"teachers"`
 }
 
+// TagsResponse represents tags list response
+// @Description Ответ со списком тегов
+type TagsResponse struct {
+    Tags []models.Tag `json:"tags"`
+}
@@ -0,0 +1,710 @@
+package handlers
+
+import (
+    "net/http"
+    "strconv"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/services"
+
+    "github.com/gin-gonic/gin"
+)
+
+type ClassHandler struct {
+    classService *services.ClassService
+}
+
+func NewClassHandler(classService *services.ClassService) *ClassHandler {
+    return &ClassHandler{classService: classService}
+}
+
+// CreateClass godoc
+// @Summary Создать класс
+// @Description Создает класс преподавателя с кодом вступления для учеников
+// @Tags classes
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param input body models.CreateClassRequest true "Данные класса"
+// @Success 201 {object} ClassResponse "Класс создан"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /classes [post]
+func (h *ClassHandler) CreateClass(c *gin.Context) {
+    userID := c.GetInt("userID")
+
+    var req models.CreateClassRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    class, err := h.classService.CreateClass(c.Request.Context(), userID, &req)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusCreated, gin.H{
+        "message": "Class created successfully",
+        "class":   class,
+    })
+}
+
+// GetTeacherClasses godoc
+// @Summary Получить классы преподавателя
+// @Description Возвращает классы текущего преподавателя с кодами вступления
+// @Tags classes
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} ClassesResponse "Список классов"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /classes [get]
+func (h *ClassHandler) GetTeacherClasses(c *gin.Context) {
+    userID := c.GetInt("userID")
+
+    classes, err := h.classService.GetTeacherClasses(c.Request.Context(), userID)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get classes"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "classes": classes,
+        "total":   len(classes),
+    })
+}
+
+// GetClass godoc
+// @Summary Получить класс
+// @Description Возвращает класс преподавателя
+// @Tags classes
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID класса"
+// @Success 200 {object} models.Class "Класс"
+// @Failure 400 {object} InvalidIDErrorResponse "Неверный ID"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ClassNotFoundErrorResponse "Класс не найден"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /classes/{id} [get]
+func (h *ClassHandler) GetClass(c *gin.Context) {
+    userID := c.GetInt("userID")
+    classID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid class ID"})
+        return
+    }
+
+    class, err := h.classService.GetClass(c.Request.Context(), userID, classID)
+    if err != nil {
+        respondClassError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, class)
+}
+
+// UpdateClass godoc
+// @Summary Обновить класс
+// @Description Обновляет название и описание класса
+// @Tags classes
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID класса"
+// @Param input body models.UpdateClassRequest true "Изменяемые поля"
+// @Success 200 {object} ClassResponse "Класс обновлен"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ClassNotFoundErrorResponse "Класс не найден"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /classes/{id} [put]
+func (h *ClassHandler) UpdateClass(c *gin.Context) {
+    userID := c.GetInt("userID")
+    classID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid class ID"})
+        return
+    }
+
+    var req models.UpdateClassRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    class, err := h.classService.UpdateClass(c.Request.Context(), userID, classID, &req)
+    if err != nil {
+        respondClassError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Class updated successfully",
+        "class":   class,
+    })
+}
+
+// DeleteClass godoc
+// @Summary Удалить класс
+// @Description Удаляет класс вместе с составом и заданиями. Прогресс учеников по материалам сохраняется
+// @Tags classes
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID класса"
+// @Success 200 {object} SuccessResponse "Класс удален"
+// @Failure 400 {object} InvalidIDErrorResponse "Неверный ID"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ClassNotFoundErrorResponse "Класс не найден"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /classes/{id} [delete]
+func (h *ClassHandler) DeleteClass(c *gin.Context) {
+    userID := c.GetInt("userID")
+    classID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid class ID"})
+        return
+    }
+
+    if err := h.classService.DeleteClass(c.Request.Context(), userID, classID); err != nil {
+        respondClassError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"message": "Class deleted successfully"})
+}
+
+// RegenerateJoinCode godoc
+// @Summary Сменить код вступления
+// @Description Генерирует новый код вступления в класс. Старый код перестает действовать
+// @Tags classes
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID класса"
+// @Success 200 {object} JoinCodeResponse "Новый код"
+// @Failure 400 {object} InvalidIDErrorResponse "Неверный ID"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ClassNotFoundErrorResponse "Класс не найден"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /classes/{id}/join-code [post]
+func (h *ClassHandler) RegenerateJoinCode(c *gin.Context) {
+    userID := c.GetInt("userID")
+    classID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid class ID"})
+        return
+    }
+
+    code, err := h.classService.RegenerateJoinCode(c.Request.Context(), userID, classID)
+    if err != nil {
+        respondClassError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message":  "Join code updated successfully",
+        "joinCode": code,
+    })
+}
+
+// GetStudents godoc
+// @Summary Получить состав класса
+// @Description Возвращает учеников класса
+// @Tags classes
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID класса"
+// @Success 200 {object} ClassStudentsResponse "Ученики класса"
+// @Failure 400 {object} InvalidIDErrorResponse "Неверный ID"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ClassNotFoundErrorResponse "Класс не найден"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /classes/{id}/students [get]
+func (h *ClassHandler) GetStudents(c *gin.Context) {
+    userID := c.GetInt("userID")
+    classID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid class ID"})
+        return
+    }
+
+    students, err := h.classService.GetMembers(c.Request.Context(), userID, classID)
+    if err != nil {
+        respondClassError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "students": students,
+        "total":    len(students),
+    })
+}
+
+// AddStudent godoc
+// @Summary Добавить ученика в класс
+// @Description Добавляет ученика в класс по ID или email
+// @Tags classes
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID класса"
+// @Param input body models.AddClassStudentRequest true "Ученик"
+// @Success 201 {object} SuccessResponse "Ученик добавлен"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ClassNotFoundErrorResponse "Класс или пользователь не найден"
+// @Failure 409 {object} ErrorResponse "Ученик уже в классе"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /classes/{id}/students [post]
+func (h *ClassHandler) AddStudent(c *gin.Context) {
+    userID := c.GetInt("userID")
+    classID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid class ID"})
+        return
+    }
+
+    var req models.AddClassStudentRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    if _, err := h.classService.AddStudent(c.Request.Context(), userID, classID, &req); err != nil {
+        respondClassError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusCreated, gin.H{"message": "Student added successfully"})
+}
+
+// RemoveStudent godoc
+// @Summary Исключить ученика из класса
+// @Description Исключает ученика из класса. Прогресс ученика по материалам сохраняется
+// @Tags classes
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID класса"
+// @Param userId path int true "ID ученика"
+// @Success 200 {object} SuccessResponse "Ученик исключен"
+// @Failure 400 {object} InvalidIDErrorResponse "Неверный ID"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ClassNotFoundErrorResponse "Класс или ученик не найден"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /classes/{id}/students/{userId} [delete]
+func (h *ClassHandler) RemoveStudent(c *gin.Context) {
+    userID := c.GetInt("userID")
+    classID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid class ID"})
+        return
+    }
+    studentID, err := strconv.Atoi(c.Param("userId"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+        return
+    }
+
+    if err := h.classService.RemoveStudent(c.Request.Context(), userID, classID, studentID); err != nil {
+        respondClassError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"message": "Student removed successfully"})
+}
+
+// CreateAssignment godoc
+// @Summary Создать задание
+// @Description Назначает классу опубликованный материал или курс со сроком сдачи
+// @Tags classes
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID класса"
+// @Param input body models.CreateAssignmentRequest true "Задание"
+// @Success 201 {object} AssignmentResponse "Задание создано"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ClassNotFoundErrorResponse "Класс, материал или курс не найден"
+// @Failure 409 {object} ErrorResponse "Материал или курс не опубликован"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /classes/{id}/assignments [post]
+func (h *ClassHandler) CreateAssignment(c *gin.Context) {
+    userID := c.GetInt("userID")
+    classID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid class ID"})
+        return
+    }
+
+    var req models.CreateAssignmentRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    assignment, err := h.classService.CreateAssignment(c.Request.Context(), userID, classID, &req)
+    if err != nil {
+        respondClassError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusCreated, gin.H{
+        "message":    "Assignment created successfully",
+        "assignment": assignment,
+    })
+}
+
+// GetAssignments godoc
+// @Summary Получить задания класса
+// @Description Возвращает задания класса, отсортированные по сроку сдачи
+// @Tags classes
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID класса"
+// @Success 200 {object} AssignmentsResponse "Задания"
+// @Failure 400 {object} InvalidIDErrorResponse "Неверный ID"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ClassNotFoundErrorResponse "Класс не найден"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /classes/{id}/assignments [get]
+func (h *ClassHandler) GetAssignments(c *gin.Context) {
+    userID := c.GetInt("userID")
+    classID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid class ID"})
+        return
+    }
+
+    assignments, err := h.classService.GetClassAssignments(c.Request.Context(), userID, classID)
+    if err != nil {
+        respondClassError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "assignments": assignments,
+        "total":       len(assignments),
+    })
+}
+
+// UpdateAssignment godoc
+// @Summary Изменить задание
+// @Description Меняет комментарий и срок сдачи задания
+// @Tags classes
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID класса"
+// @Param assignmentId path int true "ID задания"
+// @Param input body models.UpdateAssignmentRequest true "Изменяемые поля"
+// @Success 200 {object} AssignmentResponse "Задание обновлено"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ClassNotFoundErrorResponse "Класс или задание не найдено"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /classes/{id}/assignments/{assignmentId} [put]
+func (h *ClassHandler) UpdateAssignment(c *gin.Context) {
+    userID := c.GetInt("userID")
+    classID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid class ID"})
+        return
+    }
+    assignmentID, err := strconv.Atoi(c.Param("assignmentId"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assignment ID"})
+        return
+    }
+
+    var req models.UpdateAssignmentRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    assignment, err := h.classService.UpdateAssignment(c.Request.Context(), userID, classID, assignmentID, &req)
+    if err != nil {
+        respondClassError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message":    "Assignment updated successfully",
+        "assignment": assignment,
+    })
+}
+
+// DeleteAssignment godoc
+// @Summary Удалить задание
+// @Description Удаляет задание класса
+// @Tags classes
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID класса"
+// @Param assignmentId path int true "ID задания"
+// @Success 200 {object} SuccessResponse "Задание удалено"
+// @Failure 400 {object} InvalidIDErrorResponse "Неверный ID"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ClassNotFoundErrorResponse "Класс или задание не найдено"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /classes/{id}/assignments/{assignmentId} [delete]
+func (h *ClassHandler) DeleteAssignment(c *gin.Context) {
+    userID := c.GetInt("userID")
+    classID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid class ID"})
+        return
+    }
+    assignmentID, err := strconv.Atoi(c.Param("assignmentId"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assignment ID"})
+        return
+    }
+
+    if err := h.classService.DeleteAssignment(c.Request.Context(), userID, classID, assignmentID); err != nil {
+        respondClassError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"message": "Assignment deleted successfully"})
+}
+
+// GetCompletion godoc
+// @Summary Выполнение заданий классом
+// @Description Возвращает по каждому заданию класса состояние выполнения каждым учеником
+// @Tags classes
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID класса"
+// @Success 200 {object} ClassCompletionResponse "Выполнение заданий"
+// @Failure 400 {object} InvalidIDErrorResponse "Неверный ID"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ClassNotFoundErrorResponse "Класс не найден"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /classes/{id}/completion [get]
+func (h *ClassHandler) GetCompletion(c *gin.Context) {
+    userID := c.GetInt("userID")
+    classID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid class ID"})
+        return
+    }
+
+    reports, err := h.classService.GetClassCompletion(c.Request.Context(), userID, classID)
+    if err != nil {
+        respondClassError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"assignments": reports})
+}
+
+// JoinClass godoc
+// @Summary Вступить в класс
+// @Description Добавляет текущего ученика в класс по коду вступления
+// @Tags student
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param input body models.JoinClassRequest true "Код вступления"
+// @Success 200 {object} ClassResponse "Ученик вступил в класс"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Вступать в классы могут только ученики"
+// @Failure 404 {object} ErrorResponse "Неверный код"
+// @Failure 409 {object} ErrorResponse "Ученик уже в классе"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /student/classes/join [post]
+func (h *ClassHandler) JoinClass(c *gin.Context) {
+    userID := c.GetInt("userID")
+    userRole := c.GetString("userRole")
+
+    var req models.JoinClassRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    class, err := h.classService.JoinClass(c.Request.Context(), userID, userRole, req.Code)
+    if err != nil {
+        respondClassError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Joined class successfully",
+        "class":   class,
+    })
+}
+
+// GetStudentClasses godoc
+// @Summary Получить классы ученика
+// @Description Возвращает классы, в которых состоит текущий ученик
+// @Tags student
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} ClassesResponse "Список классов"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /student/classes [get]
+func (h *ClassHandler) GetStudentClasses(c *gin.Context) {
+    userID := c.GetInt("userID")
+
+    classes, err := h.classService.GetStudentClasses(c.Request.Context(), userID)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get classes"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "classes": classes,
+        "total":   len(classes),
+    })
+}
+
+// LeaveClass godoc
+// @Summary Покинуть класс
+// @Description Выводит текущего ученика из класса
+// @Tags student
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID класса"
+// @Success 200 {object} SuccessResponse "Ученик покинул класс"
+// @Failure 400 {object} InvalidIDErrorResponse "Неверный ID"
+// @Failure 404 {object} ClassNotFoundErrorResponse "Класс не найден"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /student/classes/{id} [delete]
+func (h *ClassHandler) LeaveClass(c *gin.Context) {
+    userID := c.GetInt("userID")
+    classID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid class ID"})
+        return
+    }
+
+    if err := h.classService.LeaveClass(c.Request.Context(), userID, classID); err != nil {
+        respondClassError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"message": "Left class successfully"})
+}
+
+// GetStudentAssignments godoc
+// @Summary Получить задания ученика
+// @Description Возвращает задания из всех классов ученика с состоянием выполнения, ближайшие по сроку - первыми
+// @Tags student
+// @Produce json
+// @Security ApiKeyAuth
+// @Param status query string false "Фильтр по состоянию" Enums(pending, completed, overdue)
+// @Success 200 {object} StudentAssignmentsResponse "Задания"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /student/assignments [get]
+func (h *ClassHandler) GetStudentAssignments(c *gin.Context) {
+    userID := c.GetInt("userID")
+
+    status := c.Query("status")
+    if status != "" && status != "pending" && status != "completed" && status != "overdue" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+        return
+    }
+
+    assignments, err := h.classService.GetStudentAssignments(c.Request.Context(), userID, status)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get assignments"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "assignments": assignments,
+        "total":       len(assignments),
+    })
+}
+
+// respondClassError отвечает статусом, соответствующим ошибке сервиса классов
+func respondClassError(c *gin.Context, err error) {
+    switch err.Error() {
+    case "access denied":
+        c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+    case "user is not a student":
+        c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+    case "class not found":
+        c.JSON(http.StatusNotFound, gin.H{"error": "Class not found"})
+    case "user not found", "student not in class", "assignment not found",
+        "material not found", "course not found", "invalid join code":
+        c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+    case "userId or email is required", "materialId or courseId is required":
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+    case "student already in class", "already joined",
+        "material is not published", "course is not published":
+        c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+    default:
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+    }
+}
+
+// Response models for Swagger
+
+// ClassResponse represents class create/update response
+// @Description Ответ с классом
+type ClassResponse struct {
+    Message string       `json:"message" example:"Class created successfully"`
+    Class   models.Class `json:"class"`
+}
+
+// ClassesResponse represents classes list response
+// @Description Ответ со списком классов
+type ClassesResponse struct {
+    Classes []models.Class `json:"classes"`
+    Total   int            `json:"total" example:"3"`
+}
+
+// JoinCodeResponse represents join code regeneration response
+// @Description Ответ с новым кодом вступления
+type JoinCodeResponse struct {
+    Message  string `json:"message" example:"Join code updated successfully"`
+    JoinCode string `json:"joinCode" example:"K7M2QX9P"`
+}
+
+// ClassStudentsResponse represents class roster response
+// @Description Ответ с составом класса
+type ClassStudentsResponse struct {
+    Students []models.ClassMember `json:"students"`
+    Total    int                  `json:"total" example:"24"`
+}
+
+// AssignmentResponse represents assignment create/update response
+// @Description Ответ с заданием
+type AssignmentResponse struct {
+    Message    string            `json:"message" example:"Assignment created successfully"`
+    Assignment models.Assignment `json:"assignment"`
+}
+
+// AssignmentsResponse represents class assignments response
+// @Description Ответ со списком заданий класса
+type AssignmentsResponse struct {
+    Assignments []models.Assignment `json:"assignments"`
+    Total       int                 `json:"total" example:"5"`
+}
+
+// ClassCompletionResponse represents class completion response
+// @Description Ответ с выполнением заданий учениками класса
+type ClassCompletionResponse struct {
+    Assignments []models.AssignmentReport `json:"assignments"`
+}
+
+// StudentAssignmentsResponse represents student assignments response
+// @Description Ответ со списком заданий ученика
+type StudentAssignmentsResponse struct {
+    Assignments []models.StudentAssignment `json:"assignments"`
+    Total       int                        `json:"total" example:"4"`
+}
+
+// ClassNotFoundErrorResponse represents error response
+// @Description Стандартный ответ с ошибкой
+type ClassNotFoundErrorResponse struct {
+    Error string `json:"error" example:"Class not found"`
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+    "net/http"
+    "strconv"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/services"
+
+    "github.com/gin-gonic/gin"
+)
+
+type CodeHandler struct {
+    codeService *services.CodeService
+}
+
+func NewCodeHandler(codeService *services.CodeService) *CodeHandler {
+    return &CodeHandler{codeService: codeService}
+}
+
+// GetLanguages godoc
+// @Summary Языки для блоков с кодом
+// @Description Возвращает языки, код на которых можно запускать на сервере. Блок можно создать на любом языке из python, javascript, java, но запуск доступен только для перечисленных
+// @Tags materials
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} CodeLanguagesResponse "Доступные языки"
+// @Router /code/languages [get]
+func (h *CodeHandler) GetLanguages(c *gin.Context) {
+    c.JSON(http.StatusOK, gin.H{"languages": h.codeService.Languages()})
+}
+
+// RunCode godoc
+// @Summary Запустить код блока
+// @Description Выполняет код в песочнице без сети, с лимитами памяти и времени, на языке блока и с переданным вводом. Тесты блока не запускаются и результат не сохраняется. У пользователя одновременно выполняется не больше одного запуска или проверки, число запусков в минуту ограничено (CODE_RUNNER_RUNS_PER_MINUTE)
+// @Tags materials
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID материала"
+// @Param blockId path string true "ID блока с кодом"
+// @Param input body models.RunCodeRequest true "Код и ввод"
+// @Success 200 {object} models.CodeRunResult "Результат запуска"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} MaterialNotFoundErrorResponse "Материал или блок не найден"
+// @Failure 429 {object} ErrorResponse "У пользователя уже выполняется код или превышен лимит запусков в минуту"
+// @Failure 503 {object} ErrorResponse "Запуск кода недоступен или все исполнители заняты"
+// @Router /materials/{id}/blocks/{blockId}/run [post]
+func (h *CodeHandler) RunCode(c *gin.Context) {
+    userID := c.GetInt("userID")
+    materialID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+        return
+    }
+
+    var req models.RunCodeRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    result, err := h.codeService.RunCode(c.Request.Context(), userID, materialID, c.Param("blockId"), &req)
+    if err != nil {
+        respondMaterialError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, result)
+}
+
+// SubmitCode godoc
+// @Summary Отправить решение упражнения
+// @Description Проверяет решение всеми тестами блока, включая скрытые, и сохраняет результат. Упражнение засчитывается в прогрессе материала, если пройдены все тесты. По скрытым тестам возвращается только итог. Общее время проверки ограничено (CODE_RUNNER_SUBMISSION_SECONDS): тесты, на которые его не хватило, считаются не пройденными по таймауту
+// @Tags progress
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID материала"
+// @Param blockId path string true "ID блока с кодом"
+// @Param input body models.SubmitCodeRequest true "Решение"
+// @Success 200 {object} models.CodeSubmission "Результат проверки"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса или у блока нет тестов"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} MaterialNotFoundErrorResponse "Материал или блок не найден"
+// @Failure 429 {object} ErrorResponse "У пользователя уже выполняется код или превышен лимит запусков в минуту"
+// @Failure 503 {object} ErrorResponse "Запуск кода недоступен или все исполнители заняты"
+// @Router /student/materials/{id}/blocks/{blockId}/submissions [post]
+func (h *CodeHandler) SubmitCode(c *gin.Context) {
+    userID := c.GetInt("userID")
+    materialID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+        return
+    }
+
+    var req models.SubmitCodeRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    submission, err := h.codeService.SubmitCode(c.Request.Context(), userID, materialID, c.Param("blockId"), &req)
+    if err != nil {
+        respondMaterialError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, submission)
+}
+
+// GetLastSubmission godoc
+// @Summary Последнее решение упражнения
+// @Description Возвращает последнее отправленное учеником решение в блоке с кодом и результаты его проверки
+// @Tags progress
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID материала"
+// @Param blockId path string true "ID блока с кодом"
+// @Success 200 {object} models.CodeSubmission "Решение"
+// @Failure 400 {object} InvalidIDErrorResponse "Неверный ID"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} MaterialNotFoundErrorResponse "Материал, блок или решение не найдены"
+// @Router /student/materials/{id}/blocks/{blockId}/submissions/last [get]
+func (h *CodeHandler) GetLastSubmission(c *gin.Context) {
+    userID := c.GetInt("userID")
+    materialID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+        return
+    }
+
+    submission, err := h.codeService.GetLastSubmission(c.Request.Context(), userID, materialID, c.Param("blockId"))
+    if err != nil {
+        respondMaterialError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, submission)
+}
+
+// Response models for Swagger
+
+// CodeLanguagesResponse represents available languages
+// @Description Языки, код на которых можно запускать
+type CodeLanguagesResponse struct {
+    Languages []string `json:"languages" example:"python,javascript"`
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+    "net/http"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/services"
+
+    "github.com/gin-gonic/gin"
+)
+
+// CodeRunnerHandler - внутренний API сервиса запуска кода (сервер с аргументом services.CodeRunnerCommand).
+// Его вызывает только API по общему токену, наружу сервис не публикуется
+type CodeRunnerHandler struct {
+    runner *services.CodeRunner
+}
+
+func NewCodeRunnerHandler(runner *services.CodeRunner) *CodeRunnerHandler {
+    return &CodeRunnerHandler{runner: runner}
+}
+
+// Run выполняет код в песочнице этого сервиса
+func (h *CodeRunnerHandler) Run(c *gin.Context) {
+    var req models.CodeRunnerRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    result, err := h.runner.Run(c.Request.Context(), req.Language, req.Code, req.Input)
+    if err != nil {
+        switch err.Error() {
+        case "code execution is not available", "language is not available", "code runner is busy":
+            c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+        default:
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return
+    }
+
+    c.JSON(http.StatusOK, result)
+}
+
+// GetLanguages возвращает языки, интерпретаторы которых установлены в сервисе
+func (h *CodeRunnerHandler) GetLanguages(c *gin.Context) {
+    c.JSON(http.StatusOK, gin.H{"languages": h.runner.Languages()})
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+    "context"
+    "fmt"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/services"
+
+    "github.com/gin-gonic/gin"
+    "golang.org/x/net/websocket"
+)
+
+type CollaborationHandler struct {
+    collaborationService *services.CollaborationService
+    allowedOrigins       []string // источники фронтенда, которым разрешено открывать WebSocket
+}
+
+func NewCollaborationHandler(collaborationService *services.CollaborationService, allowedOrigins []string) *CollaborationHandler {
+    origins := make([]string, 0, len(allowedOrigins))
+    for _, origin := range allowedOrigins {
+        if origin = strings.TrimRight(strings.TrimSpace(origin), "/"); origin != "" {
+            origins = append(origins, strings.ToLower(origin))
+        }
+    }
+    return &CollaborationHandler{collaborationService: collaborationService, allowedOrigins: origins}
+}
+
+// checkOrigin не дает чужим сайтам открыть WebSocket от имени пользователя:
+// браузер всегда передает Origin, а токен в query-параметре мог бы утечь на чужую страницу.
+// Клиенты без Origin (не браузеры) пропускаются - для них подделка запроса невозможна
+func (h *CollaborationHandler) checkOrigin(r *http.Request) error {
+    origin := r.Header.Get("Origin")
+    if origin == "" {
+        return nil
+    }
+    origin = strings.ToLower(strings.TrimRight(origin, "/"))
+    for _, allowed := range h.allowedOrigins {
+        if origin == allowed {
+            return nil
+        }
+    }
+    return fmt.Errorf("origin not allowed")
+}
+
+// LiveEdit godoc
+// @Summary Совместное редактирование материала (WebSocket)
+// @Description Открывает WebSocket для совместного редактирования. Токен можно передать в параметре token.
+// @Description Из браузера подключение принимается только с источников фронтенда (WS_ALLOWED_ORIGINS), иначе 403.
+// @Description Первое сообщение сервера - snapshot с блоками, блокировками и номером seq. Клиент отправляет
+// @Description add, update, delete, reorder (операции над блоками), presence (текущий блок), lock/unlock и ping.
+// @Description Операции применяются на сервере по порядку и рассылаются всем редакторам как operation с seq;
+// @Description отправитель дополнительно получает ack. Блокировка блока действует 2 минуты и продлевается повторным lock
+// @Tags materials
+// @Security ApiKeyAuth
+// @Param id path int true "ID материала"
+// @Param token query string false "Access token (для браузерных WebSocket)"
+// @Success 101 {object} models.CollaborationEvent "Переключение на WebSocket"
+// @Failure 400 {object} InvalidIDErrorResponse "Неверный ID"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} MaterialNotFoundErrorResponse "Материал не найден"
+// @Router /materials/{id}/live [get]
+func (h *CollaborationHandler) LiveEdit(c *gin.Context) {
+    userID := c.GetInt("userID")
+    materialID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+        return
+    }
+
+    client, snapshot, err := h.collaborationService.Join(c.Request.Context(), userID, materialID)
+    if err != nil {
+        respondMaterialError(c, err)
+        return
+    }
+
+    started := false
+    server := websocket.Server{
+        Handshake: func(config *websocket.Config, r *http.Request) error { return h.checkOrigin(r) },
+        Handler: func(ws *websocket.Conn) {
+            started = true
+            defer h.collaborationService.Leave(context.Background(), client)
+
+            if err := websocket.JSON.Send(ws, snapshot); err != nil {
+                return
+            }
+
+            // Отправка событий клиенту
+            go func() {
+                defer ws.Close()
+                for {
+                    select {
+                    case event := <-client.Events():
+                        if err := websocket.JSON.Send(ws, event); err != nil {
+                            client.Close()
+                            return
+                        }
+                    case <-client.Done():
+                        return
+                    }
+                }
+            }()
+
+            // Чтение сообщений клиента
+            for {
+                var msg models.CollaborationMessage
+                if err := websocket.JSON.Receive(ws, &msg); err != nil {
+                    return
+                }
+
+                ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+                h.collaborationService.HandleMessage(ctx, client, &msg)
+                cancel()
+            }
+        },
+    }
+
+    server.ServeHTTP(c.Writer, c.Request)
+
+    // Рукопожатие не состоялось - освобождаем подключение
+    if !started {
+        h.collaborationService.Leave(context.Background(), client)
+    }
+}
+
+// GetOperations godoc
+// @Summary Получить журнал операций материала
+// @Description Возвращает операции над блоками после указанного seq (для догоняющих клиентов, до 500 за запрос).
+// @Description Журнал хранится ограниченное время (OPERATION_LOG_RETENTION_DAYS): если нужные операции уже удалены,
+// @Description возвращается 410 и клиент должен заново подключиться и получить снимок
+// @Tags materials
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID материала"
+// @Param since query int false "Номер последней известной операции" default(0)
+// @Success 200 {object} OperationsResponse "Журнал операций"
+// @Failure 400 {object} InvalidIDErrorResponse "Неверный ID"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} MaterialNotFoundErrorResponse "Материал не найден"
+// @Failure 410 {object} ErrorResponse "Операции удалены из журнала"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /materials/{id}/operations [get]
+func (h *CollaborationHandler) GetOperations(c *gin.Context) {
+    userID := c.GetInt("userID")
+    materialID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+        return
+    }
+
+    since, _ := strconv.ParseInt(c.Query("since"), 10, 64)
+
+    operations, err := h.collaborationService.GetOperationsSince(c.Request.Context(), userID, materialID, since)
+    if err != nil {
+        respondMaterialError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "operations": operations,
+        "since":      since,
+    })
+}
+
+// Response models for Swagger
+
+// OperationsResponse represents block operations log response
+// @Description Ответ с журналом операций над блоками
+type OperationsResponse struct {
+    Operations []models.BlockOperation `json:"operations"`
+    Since      int64                   `json:"since" example:"40"`
+}
@@ -0,0 +1,267 @@
+package handlers
+
+import (
+    "net/http"
+    "strconv"
+
+    "paydeya-backend/internal/models"
+
+    "github.com/gin-gonic/gin"
+)
+
+// GetCollaborators godoc
+// @Summary Получить соавторов материала
+// @Description Возвращает список соавторов материала с их ролями
+// @Tags materials
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID материала"
+// @Success 200 {object} CollaboratorsResponse "Список соавторов"
+// @Failure 400 {object} InvalidIDErrorResponse "Неверный ID"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} MaterialNotFoundErrorResponse "Материал не найден"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /materials/{id}/collaborators [get]
+func (h *MaterialHandler) GetCollaborators(c *gin.Context) {
+    userID := c.GetInt("userID")
+    materialID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+        return
+    }
+
+    collaborators, err := h.materialService.GetCollaborators(c.Request.Context(), userID, materialID)
+    if err != nil {
+        respondMaterialError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "collaborators": collaborators,
+        "total":         len(collaborators),
+    })
+}
+
+// AddCollaborator godoc
+// @Summary Пригласить соавтора
+// @Description Приглашает пользователя в материал по ID или email с ролью editor, reviewer или viewer (только для владельца)
+// @Tags materials
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID материала"
+// @Param input body models.AddCollaboratorRequest true "Данные приглашения"
+// @Success 200 {object} AddCollaboratorResponse "Соавтор приглашен"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} UserNotFoundErrorResponse "Пользователь не найден"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /materials/{id}/collaborators [post]
+func (h *MaterialHandler) AddCollaborator(c *gin.Context) {
+    userID := c.GetInt("userID")
+    materialID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+        return
+    }
+
+    var req models.AddCollaboratorRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    invitee, err := h.materialService.AddCollaborator(c.Request.Context(), userID, materialID, &req)
+    if err != nil {
+        respondMaterialError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Collaborator added successfully",
+        "userId":  invitee.ID,
+        "role":    req.Role,
+    })
+}
+
+// UpdateCollaborator godoc
+// @Summary Изменить роль соавтора
+// @Description Меняет роль соавтора в материале (только для владельца)
+// @Tags materials
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID материала"
+// @Param userId path int true "ID соавтора"
+// @Param input body models.UpdateCollaboratorRequest true "Новая роль"
+// @Success 200 {object} SuccessResponse "Роль изменена"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ErrorResponse "Соавтор не найден"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /materials/{id}/collaborators/{userId} [put]
+func (h *MaterialHandler) UpdateCollaborator(c *gin.Context) {
+    userID := c.GetInt("userID")
+    materialID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+        return
+    }
+
+    collaboratorID, err := strconv.Atoi(c.Param("userId"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+        return
+    }
+
+    var req models.UpdateCollaboratorRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    err = h.materialService.UpdateCollaborator(c.Request.Context(), userID, materialID, collaboratorID, req.Role)
+    if err != nil {
+        respondMaterialError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Collaborator updated successfully",
+    })
+}
+
+// RemoveCollaborator godoc
+// @Summary Удалить соавтора
+// @Description Удаляет соавтора из материала. Владелец может удалить любого соавтора, соавтор - только себя
+// @Tags materials
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID материала"
+// @Param userId path int true "ID соавтора"
+// @Success 200 {object} SuccessResponse "Соавтор удален"
+// @Failure 400 {object} InvalidIDErrorResponse "Неверный ID"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ErrorResponse "Соавтор не найден"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /materials/{id}/collaborators/{userId} [delete]
+func (h *MaterialHandler) RemoveCollaborator(c *gin.Context) {
+    userID := c.GetInt("userID")
+    materialID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+        return
+    }
+
+    collaboratorID, err := strconv.Atoi(c.Param("userId"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+        return
+    }
+
+    err = h.materialService.RemoveCollaborator(c.Request.Context(), userID, materialID, collaboratorID)
+    if err != nil {
+        respondMaterialError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Collaborator removed successfully",
+    })
+}
+
+// TransferOwnership godoc
+// @Summary Передать владение материалом
+// @Description Передает материал другому пользователю по ID или email. Прежний владелец остается редактором
+// @Tags materials
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID материала"
+// @Param input body models.TransferOwnershipRequest true "Новый владелец"
+// @Success 200 {object} TransferOwnershipResponse "Владение передано"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} UserNotFoundErrorResponse "Пользователь не найден"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /materials/{id}/transfer [post]
+func (h *MaterialHandler) TransferOwnership(c *gin.Context) {
+    userID := c.GetInt("userID")
+    materialID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+        return
+    }
+
+    var req models.TransferOwnershipRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    newOwner, err := h.materialService.TransferOwnership(c.Request.Context(), userID, materialID, &req)
+    if err != nil {
+        respondMaterialError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Ownership transferred successfully",
+        "ownerId": newOwner.ID,
+    })
+}
+
+// GetSharedMaterials godoc
+// @Summary Получить материалы, доступные как соавтору
+// @Description Возвращает материалы других авторов, в которые приглашен текущий пользователь
+// @Tags materials
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} SharedMaterialsResponse "Материалы соавторства"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /materials/shared [get]
+func (h *MaterialHandler) GetSharedMaterials(c *gin.Context) {
+    userID := c.GetInt("userID")
+
+    materials, err := h.materialService.GetSharedMaterials(c.Request.Context(), userID)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get shared materials"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "materials": materials,
+        "total":     len(materials),
+    })
+}
+
+// Response models for Swagger
+
+// CollaboratorsResponse represents collaborators list response
+// @Description Ответ со списком соавторов
+type CollaboratorsResponse struct {
+    Collaborators []models.Collaborator `json:"collaborators"`
+    Total         int                   `json:"total" example:"2"`
+}
+
+// AddCollaboratorResponse represents add collaborator response
+// @Description Ответ на приглашение соавтора
+type AddCollaboratorResponse struct {
+    Message string `json:"message" example:"Collaborator added successfully"`
+    UserID  int    `json:"userId" example:"42"`
+    Role    string `json:"role" example:"editor"`
+}
+
+// TransferOwnershipResponse represents ownership transfer response
+// @Description Ответ на передачу владения
+type TransferOwnershipResponse struct {
+    Message string `json:"message" example:"Ownership transferred successfully"`
+    OwnerID int    `json:"ownerId" example:"42"`
+}
+
+// SharedMaterialsResponse represents shared materials response
+// @Description Ответ со списком материалов соавторства
+type SharedMaterialsResponse struct {
+    Materials []models.SharedMaterial `json:"materials"`
+    Total     int                     `json:"total" example:"3"`
+}
@@ -0,0 +1,313 @@
+package handlers
+
+import (
+    "net/http"
+    "strconv"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/services"
+
+    "github.com/gin-gonic/gin"
+)
+
+type CommentHandler struct {
+    commentService *services.CommentService
+}
+
+func NewCommentHandler(commentService *services.CommentService) *CommentHandler {
+    return &CommentHandler{commentService: commentService}
+}
+
+// GetComments godoc
+// @Summary Обсуждение материала
+// @Description Возвращает вопросы к материалу с ответами. С blockId - только вопросы к этому блоку; вопросы к удаленным блокам остаются у материала без blockId. Скрытые комментарии видят редакторы материала и администраторы
+// @Tags comments
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID материала"
+// @Param blockId query string false "Вопросы к блоку"
+// @Param sort query string false "Сортировка: new - новые, old - старые, top - по голосам" Enums(new, old, top) default(new)
+// @Param page query int false "Номер страницы" default(1)
+// @Param limit query int false "Количество вопросов на странице" default(20)
+// @Success 200 {object} CommentsResponse "Обсуждение"
+// @Failure 400 {object} ErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} MaterialNotFoundErrorResponse "Материал не найден"
+// @Router /materials/{id}/comments [get]
+func (h *CommentHandler) GetComments(c *gin.Context) {
+    userID := c.GetInt("userID")
+    isAdmin := c.GetString("userRole") == "admin"
+    materialID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+        return
+    }
+
+    var filters models.CommentFilters
+    if err := c.ShouldBindQuery(&filters); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if filters.Page == 0 {
+        filters.Page = 1
+    }
+    if filters.Limit == 0 {
+        filters.Limit = 20
+    }
+
+    comments, total, err := h.commentService.GetComments(c.Request.Context(), userID, isAdmin, materialID, filters)
+    if err != nil {
+        respondCommentError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "comments": comments,
+        "total":    total,
+        "page":     filters.Page,
+        "limit":    filters.Limit,
+        "hasMore":  (filters.Page * filters.Limit) < total,
+    })
+}
+
+// CreateComment godoc
+// @Summary Задать вопрос или ответить
+// @Description Добавляет вопрос к материалу или блоку (blockId) либо ответ на вопрос (parentId). Ответы автора и соавторов материала отмечаются isAuthor. Автор материала получает уведомление, автор вопроса - уведомление об ответе
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID материала"
+// @Param input body models.CreateCommentRequest true "Комментарий"
+// @Success 201 {object} CommentResponse "Комментарий добавлен"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ErrorResponse "Материал, блок или вопрос не найден"
+// @Router /materials/{id}/comments [post]
+func (h *CommentHandler) CreateComment(c *gin.Context) {
+    userID := c.GetInt("userID")
+    isAdmin := c.GetString("userRole") == "admin"
+    materialID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+        return
+    }
+
+    var req models.CreateCommentRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    comment, err := h.commentService.CreateComment(c.Request.Context(), userID, isAdmin, materialID, &req)
+    if err != nil {
+        respondCommentError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusCreated, gin.H{
+        "message": "Comment created successfully",
+        "comment": comment,
+    })
+}
+
+// DeleteComment godoc
+// @Summary Удалить комментарий
+// @Description Удаляет свой комментарий; редакторы материала и администраторы могут удалить любой. Ответы на удаленный вопрос остаются в обсуждении
+// @Tags comments
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID комментария"
+// @Success 200 {object} SuccessResponse "Комментарий удален"
+// @Failure 400 {object} InvalidIDErrorResponse "Неверный ID"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ErrorResponse "Комментарий не найден"
+// @Router /comments/{id} [delete]
+func (h *CommentHandler) DeleteComment(c *gin.Context) {
+    userID := c.GetInt("userID")
+    isAdmin := c.GetString("userRole") == "admin"
+    commentID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
+        return
+    }
+
+    if err := h.commentService.DeleteComment(c.Request.Context(), userID, isAdmin, commentID); err != nil {
+        respondCommentError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"message": "Comment deleted successfully"})
+}
+
+// UpvoteComment godoc
+// @Summary Проголосовать за комментарий
+// @Description Отдает голос за вопрос или ответ. Повторный голос не учитывается, за свой комментарий голосовать нельзя
+// @Tags comments
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID комментария"
+// @Success 200 {object} CommentVoteResponse "Голос учтен"
+// @Failure 400 {object} ErrorResponse "Неверный ID или свой комментарий"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ErrorResponse "Комментарий не найден"
+// @Router /comments/{id}/vote [put]
+func (h *CommentHandler) UpvoteComment(c *gin.Context) {
+    h.vote(c, true)
+}
+
+// RemoveCommentVote godoc
+// @Summary Отозвать голос
+// @Description Снимает голос пользователя за комментарий
+// @Tags comments
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID комментария"
+// @Success 200 {object} CommentVoteResponse "Голос снят"
+// @Failure 400 {object} InvalidIDErrorResponse "Неверный ID"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ErrorResponse "Комментарий не найден"
+// @Router /comments/{id}/vote [delete]
+func (h *CommentHandler) RemoveCommentVote(c *gin.Context) {
+    h.vote(c, false)
+}
+
+func (h *CommentHandler) vote(c *gin.Context, upvote bool) {
+    userID := c.GetInt("userID")
+    isAdmin := c.GetString("userRole") == "admin"
+    commentID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
+        return
+    }
+
+    upvotes, err := h.commentService.Vote(c.Request.Context(), userID, isAdmin, commentID, upvote)
+    if err != nil {
+        respondCommentError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "commentId": commentID,
+        "upvotes":   upvotes,
+        "upvoted":   upvote,
+    })
+}
+
+// SetCommentResolved godoc
+// @Summary Отметить вопрос решенным
+// @Description Отмечает вопрос решенным или снимает отметку. Может автор вопроса, редакторы материала и администраторы
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID вопроса"
+// @Param input body models.ResolveCommentRequest true "Отметка"
+// @Success 200 {object} SuccessResponse "Отметка изменена"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса или это ответ"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ErrorResponse "Комментарий не найден"
+// @Router /comments/{id}/resolved [put]
+func (h *CommentHandler) SetCommentResolved(c *gin.Context) {
+    userID := c.GetInt("userID")
+    isAdmin := c.GetString("userRole") == "admin"
+    commentID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
+        return
+    }
+
+    var req models.ResolveCommentRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    if err := h.commentService.SetResolved(c.Request.Context(), userID, isAdmin, commentID, *req.Resolved); err != nil {
+        respondCommentError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message":  "Comment updated successfully",
+        "resolved": *req.Resolved,
+    })
+}
+
+// SetCommentHidden godoc
+// @Summary Скрыть комментарий
+// @Description Скрывает комментарий от учеников или снова показывает его. Доступно редакторам материала и администраторам
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID комментария"
+// @Param input body models.HideCommentRequest true "Скрытие"
+// @Success 200 {object} SuccessResponse "Комментарий скрыт или показан"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ErrorResponse "Комментарий не найден"
+// @Router /comments/{id}/hidden [put]
+func (h *CommentHandler) SetCommentHidden(c *gin.Context) {
+    userID := c.GetInt("userID")
+    isAdmin := c.GetString("userRole") == "admin"
+    commentID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
+        return
+    }
+
+    var req models.HideCommentRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    if err := h.commentService.SetHidden(c.Request.Context(), userID, isAdmin, commentID, *req.Hidden); err != nil {
+        respondCommentError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Comment updated successfully",
+        "hidden":  *req.Hidden,
+    })
+}
+
+func respondCommentError(c *gin.Context, err error) {
+    switch err.Error() {
+    case "comment not found":
+        c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+    case "content is required", "cannot vote for own comment", "only questions can be resolved":
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+    default:
+        respondMaterialError(c, err)
+    }
+}
+
+// Response models for Swagger
+
+// CommentsResponse represents material discussion
+// @Description Обсуждение материала
+type CommentsResponse struct {
+    Comments []models.MaterialComment `json:"comments"`
+    Total    int                      `json:"total" example:"7"`
+    Page     int                      `json:"page" example:"1"`
+    Limit    int                      `json:"limit" example:"20"`
+    HasMore  bool                     `json:"hasMore" example:"false"`
+}
+
+// CommentResponse represents created comment
+// @Description Ответ с комментарием
+type CommentResponse struct {
+    Message string                 `json:"message" example:"Comment created successfully"`
+    Comment models.MaterialComment `json:"comment"`
+}
+
+// CommentVoteResponse represents vote result
+// @Description Результат голосования за комментарий
+type CommentVoteResponse struct {
+    CommentID int  `json:"commentId" example:"1"`
+    Upvotes   int  `json:"upvotes" example:"4"`
+    Upvoted   bool `json:"upvoted" example:"true"`
+}
@@ -0,0 +1,675 @@
+package handlers
+
+import (
+    "net/http"
+    "strconv"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/services"
+
+    "github.com/gin-gonic/gin"
+)
+
+type CourseHandler struct {
+    courseService *services.CourseService
+}
+
+func NewCourseHandler(courseService *services.CourseService) *CourseHandler {
+    return &CourseHandler{courseService: courseService}
+}
+
+// CreateCourse godoc
+// @Summary Создать курс
+// @Description Создает новый курс (черновик без модулей)
+// @Tags courses
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param input body models.CreateCourseRequest true "Данные курса"
+// @Success 201 {object} CourseResponse "Курс создан"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /courses [post]
+func (h *CourseHandler) CreateCourse(c *gin.Context) {
+    userID := c.GetInt("userID")
+
+    var req models.CreateCourseRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    course, err := h.courseService.CreateCourse(c.Request.Context(), userID, &req)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusCreated, gin.H{
+        "message": "Course created successfully",
+        "course":  course,
+    })
+}
+
+// GetUserCourses godoc
+// @Summary Получить курсы пользователя
+// @Description Возвращает курсы, созданные текущим пользователем
+// @Tags courses
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} UserCoursesResponse "Список курсов"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /courses/my [get]
+func (h *CourseHandler) GetUserCourses(c *gin.Context) {
+    userID := c.GetInt("userID")
+
+    courses, err := h.courseService.GetUserCourses(c.Request.Context(), userID)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get courses"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "courses": courses,
+        "total":   len(courses),
+    })
+}
+
+// GetCourse godoc
+// @Summary Получить курс
+// @Description Возвращает курс с модулями, материалами и условиями доступа. Неопубликованный курс доступен только автору
+// @Tags courses
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID курса"
+// @Success 200 {object} models.Course "Курс"
+// @Failure 400 {object} InvalidIDErrorResponse "Неверный ID"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} CourseNotFoundErrorResponse "Курс не найден"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /courses/{id} [get]
+func (h *CourseHandler) GetCourse(c *gin.Context) {
+    userID := c.GetInt("userID")
+    courseID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid course ID"})
+        return
+    }
+
+    course, err := h.courseService.GetCourse(c.Request.Context(), userID, courseID)
+    if err != nil {
+        respondCourseError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, course)
+}
+
+// UpdateCourse godoc
+// @Summary Обновить курс
+// @Description Обновляет название, предмет, описание, уровень и обложку курса (только для автора)
+// @Tags courses
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID курса"
+// @Param input body models.UpdateCourseRequest true "Данные для обновления"
+// @Success 200 {object} CourseResponse "Курс обновлен"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} CourseNotFoundErrorResponse "Курс не найден"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /courses/{id} [put]
+func (h *CourseHandler) UpdateCourse(c *gin.Context) {
+    userID := c.GetInt("userID")
+    courseID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid course ID"})
+        return
+    }
+
+    var req models.UpdateCourseRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    course, err := h.courseService.UpdateCourse(c.Request.Context(), userID, courseID, &req)
+    if err != nil {
+        respondCourseError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Course updated successfully",
+        "course":  course,
+    })
+}
+
+// DeleteCourse godoc
+// @Summary Удалить курс
+// @Description Удаляет курс с модулями. Материалы курса не удаляются (только для автора)
+// @Tags courses
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID курса"
+// @Success 200 {object} SuccessResponse "Курс удален"
+// @Failure 400 {object} InvalidIDErrorResponse "Неверный ID"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} CourseNotFoundErrorResponse "Курс не найден"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /courses/{id} [delete]
+func (h *CourseHandler) DeleteCourse(c *gin.Context) {
+    userID := c.GetInt("userID")
+    courseID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid course ID"})
+        return
+    }
+
+    if err := h.courseService.DeleteCourse(c.Request.Context(), userID, courseID); err != nil {
+        respondCourseError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Course deleted successfully",
+    })
+}
+
+// PublishCourse godoc
+// @Summary Опубликовать курс
+// @Description Публикует курс с указанными настройками видимости. Все материалы курса должны быть опубликованы
+// @Tags courses
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID курса"
+// @Param input body models.PublishCourseRequest true "Настройки публикации"
+// @Success 200 {object} PublishCourseResponse "Курс опубликован"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} CourseNotFoundErrorResponse "Курс не найден"
+// @Failure 409 {object} ErrorResponse "Курс пуст или содержит неопубликованные материалы"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /courses/{id}/publish [post]
+func (h *CourseHandler) PublishCourse(c *gin.Context) {
+    userID := c.GetInt("userID")
+    courseID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid course ID"})
+        return
+    }
+
+    var req models.PublishCourseRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    // Устанавливаем значения по умолчанию
+    if req.Visibility == "" {
+        req.Visibility = "published"
+    }
+    if req.Access == "" {
+        req.Access = "open"
+    }
+
+    course, err := h.courseService.PublishCourse(c.Request.Context(), userID, courseID, &req)
+    if err != nil {
+        respondCourseError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message":  "Course published successfully",
+        "course":   course,
+        "shareUrl": course.ShareURL,
+    })
+}
+
+// CreateModule godoc
+// @Summary Добавить модуль
+// @Description Добавляет модуль в конец курса (только для автора)
+// @Tags courses
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID курса"
+// @Param input body models.CourseModuleRequest true "Данные модуля"
+// @Success 201 {object} CourseModuleResponse "Модуль добавлен"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} CourseNotFoundErrorResponse "Курс не найден"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /courses/{id}/modules [post]
+func (h *CourseHandler) CreateModule(c *gin.Context) {
+    userID := c.GetInt("userID")
+    courseID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid course ID"})
+        return
+    }
+
+    var req models.CourseModuleRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    module, err := h.courseService.CreateModule(c.Request.Context(), userID, courseID, req.Title)
+    if err != nil {
+        respondCourseError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusCreated, gin.H{
+        "message": "Module created successfully",
+        "module":  module,
+    })
+}
+
+// UpdateModule godoc
+// @Summary Переименовать модуль
+// @Description Меняет название модуля курса (только для автора)
+// @Tags courses
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID курса"
+// @Param moduleId path int true "ID модуля"
+// @Param input body models.CourseModuleRequest true "Данные модуля"
+// @Success 200 {object} SuccessResponse "Модуль обновлен"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ErrorResponse "Курс или модуль не найден"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /courses/{id}/modules/{moduleId} [put]
+func (h *CourseHandler) UpdateModule(c *gin.Context) {
+    userID := c.GetInt("userID")
+    courseID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid course ID"})
+        return
+    }
+
+    moduleID, err := strconv.Atoi(c.Param("moduleId"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid module ID"})
+        return
+    }
+
+    var req models.CourseModuleRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    if err := h.courseService.UpdateModule(c.Request.Context(), userID, courseID, moduleID, req.Title); err != nil {
+        respondCourseError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Module updated successfully",
+    })
+}
+
+// DeleteModule godoc
+// @Summary Удалить модуль
+// @Description Удаляет модуль вместе с его элементами (только для автора)
+// @Tags courses
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID курса"
+// @Param moduleId path int true "ID модуля"
+// @Success 200 {object} SuccessResponse "Модуль удален"
+// @Failure 400 {object} InvalidIDErrorResponse "Неверный ID"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ErrorResponse "Курс или модуль не найден"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /courses/{id}/modules/{moduleId} [delete]
+func (h *CourseHandler) DeleteModule(c *gin.Context) {
+    userID := c.GetInt("userID")
+    courseID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid course ID"})
+        return
+    }
+
+    moduleID, err := strconv.Atoi(c.Param("moduleId"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid module ID"})
+        return
+    }
+
+    if err := h.courseService.DeleteModule(c.Request.Context(), userID, courseID, moduleID); err != nil {
+        respondCourseError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Module deleted successfully",
+    })
+}
+
+// ReorderModules godoc
+// @Summary Изменить порядок модулей
+// @Description Задает порядок модулей курса. Нужно перечислить все модули (только для автора)
+// @Tags courses
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID курса"
+// @Param input body models.ReorderRequest true "ID модулей в новом порядке"
+// @Success 200 {object} SuccessResponse "Порядок изменен"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} CourseNotFoundErrorResponse "Курс не найден"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /courses/{id}/modules/reorder [post]
+func (h *CourseHandler) ReorderModules(c *gin.Context) {
+    userID := c.GetInt("userID")
+    courseID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid course ID"})
+        return
+    }
+
+    var req models.ReorderRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    if err := h.courseService.ReorderModules(c.Request.Context(), userID, courseID, req.IDs); err != nil {
+        respondCourseError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Modules reordered successfully",
+    })
+}
+
+// AddItem godoc
+// @Summary Добавить материал в модуль
+// @Description Добавляет материал в конец модуля. Можно добавить свой материал, материал соавторства
+// @Description или опубликованный материал другого автора. prerequisites - элементы курса, которые нужно пройти раньше
+// @Tags courses
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID курса"
+// @Param moduleId path int true "ID модуля"
+// @Param input body models.AddCourseItemRequest true "Материал и условия доступа"
+// @Success 201 {object} CourseItemResponse "Материал добавлен"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ErrorResponse "Курс, модуль или материал не найден"
+// @Failure 409 {object} ErrorResponse "Материал уже есть в курсе"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /courses/{id}/modules/{moduleId}/items [post]
+func (h *CourseHandler) AddItem(c *gin.Context) {
+    userID := c.GetInt("userID")
+    courseID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid course ID"})
+        return
+    }
+
+    moduleID, err := strconv.Atoi(c.Param("moduleId"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid module ID"})
+        return
+    }
+
+    var req models.AddCourseItemRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    item, err := h.courseService.AddItem(c.Request.Context(), userID, courseID, moduleID, &req)
+    if err != nil {
+        respondCourseError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusCreated, gin.H{
+        "message": "Material added to course",
+        "item":    item,
+    })
+}
+
+// ReorderItems godoc
+// @Summary Изменить порядок материалов модуля
+// @Description Задает порядок элементов модуля. Нужно перечислить все элементы модуля (только для автора)
+// @Tags courses
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID курса"
+// @Param moduleId path int true "ID модуля"
+// @Param input body models.ReorderRequest true "ID элементов в новом порядке"
+// @Success 200 {object} SuccessResponse "Порядок изменен"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} CourseNotFoundErrorResponse "Курс не найден"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /courses/{id}/modules/{moduleId}/items/reorder [post]
+func (h *CourseHandler) ReorderItems(c *gin.Context) {
+    userID := c.GetInt("userID")
+    courseID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid course ID"})
+        return
+    }
+
+    moduleID, err := strconv.Atoi(c.Param("moduleId"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid module ID"})
+        return
+    }
+
+    var req models.ReorderRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    if err := h.courseService.ReorderItems(c.Request.Context(), userID, courseID, moduleID, req.IDs); err != nil {
+        respondCourseError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Items reordered successfully",
+    })
+}
+
+// SetPrerequisites godoc
+// @Summary Изменить условия доступа к материалу курса
+// @Description Задает элементы курса, которые нужно пройти до открытия этого элемента. Циклы запрещены (только для автора)
+// @Tags courses
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID курса"
+// @Param itemId path int true "ID элемента курса"
+// @Param input body models.CoursePrerequisitesRequest true "Условия доступа"
+// @Success 200 {object} PrerequisitesResponse "Условия изменены"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные условия или цикл"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ErrorResponse "Курс или элемент не найден"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /courses/{id}/items/{itemId}/prerequisites [put]
+func (h *CourseHandler) SetPrerequisites(c *gin.Context) {
+    userID := c.GetInt("userID")
+    courseID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid course ID"})
+        return
+    }
+
+    itemID, err := strconv.Atoi(c.Param("itemId"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid item ID"})
+        return
+    }
+
+    var req models.CoursePrerequisitesRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    prerequisites, err := h.courseService.SetPrerequisites(c.Request.Context(), userID, courseID, itemID, req.Prerequisites)
+    if err != nil {
+        respondCourseError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message":       "Prerequisites updated successfully",
+        "prerequisites": prerequisites,
+    })
+}
+
+// DeleteItem godoc
+// @Summary Удалить материал из курса
+// @Description Удаляет элемент курса. Сам материал не удаляется (только для автора)
+// @Tags courses
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID курса"
+// @Param itemId path int true "ID элемента курса"
+// @Success 200 {object} SuccessResponse "Материал удален из курса"
+// @Failure 400 {object} InvalidIDErrorResponse "Неверный ID"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ErrorResponse "Курс или элемент не найден"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /courses/{id}/items/{itemId} [delete]
+func (h *CourseHandler) DeleteItem(c *gin.Context) {
+    userID := c.GetInt("userID")
+    courseID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid course ID"})
+        return
+    }
+
+    itemID, err := strconv.Atoi(c.Param("itemId"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid item ID"})
+        return
+    }
+
+    if err := h.courseService.DeleteItem(c.Request.Context(), userID, courseID, itemID); err != nil {
+        respondCourseError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Material removed from course",
+    })
+}
+
+// GetCourseProgress godoc
+// @Summary Получить прогресс в курсе
+// @Description Возвращает прогресс текущего ученика в курсе: пройденные и закрытые материалы по модулям и следующий доступный материал
+// @Tags progress
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID курса"
+// @Success 200 {object} models.CourseProgress "Прогресс в курсе"
+// @Failure 400 {object} InvalidIDErrorResponse "Неверный ID"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} CourseNotFoundErrorResponse "Курс не найден"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /student/courses/{id}/progress [get]
+func (h *CourseHandler) GetCourseProgress(c *gin.Context) {
+    userID := c.GetInt("userID")
+    courseID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid course ID"})
+        return
+    }
+
+    progress, err := h.courseService.GetCourseProgress(c.Request.Context(), userID, courseID)
+    if err != nil {
+        respondCourseError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, progress)
+}
+
+// respondCourseError отвечает статусом, соответствующим ошибке сервиса курсов
+func respondCourseError(c *gin.Context, err error) {
+    switch err.Error() {
+    case "access denied":
+        c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+    case "course not found":
+        c.JSON(http.StatusNotFound, gin.H{"error": "Course not found"})
+    case "module not found", "item not found", "material not found":
+        c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+    case "invalid prerequisite", "prerequisite cycle",
+        "module order must list every module", "item order must list every item":
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+    case "material already in course", "course has unpublished materials", "course is empty":
+        c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+    default:
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+    }
+}
+
+// Response models for Swagger
+
+// CourseResponse represents course create/update response
+// @Description Ответ с курсом
+type CourseResponse struct {
+    Message string        `json:"message" example:"Course created successfully"`
+    Course  models.Course `json:"course"`
+}
+
+// UserCoursesResponse represents user courses response
+// @Description Ответ со списком курсов пользователя
+type UserCoursesResponse struct {
+    Courses []models.Course `json:"courses"`
+    Total   int             `json:"total" example:"3"`
+}
+
+// PublishCourseResponse represents publish course response
+// @Description Ответ на публикацию курса
+type PublishCourseResponse struct {
+    Message  string        `json:"message" example:"Course published successfully"`
+    Course   models.Course `json:"course"`
+    ShareURL string        `json:"shareUrl" example:"/course/1"`
+}
+
+// CourseModuleResponse represents create module response
+// @Description Ответ на создание модуля
+type CourseModuleResponse struct {
+    Message string              `json:"message" example:"Module created successfully"`
+    Module  models.CourseModule `json:"module"`
+}
+
+// CourseItemResponse represents add course item response
+// @Description Ответ на добавление материала в курс
+type CourseItemResponse struct {
+    Message string            `json:"message" example:"Material added to course"`
+    Item    models.CourseItem `json:"item"`
+}
+
+// PrerequisitesResponse represents prerequisites update response
+// @Description Ответ на изменение условий доступа
+type PrerequisitesResponse struct {
+    Message       string `json:"message" example:"Prerequisites updated successfully"`
+    Prerequisites []int  `json:"prerequisites" example:"10,11"`
+}
+
+// CourseNotFoundErrorResponse represents error response
+// @Description Стандартный ответ с ошибкой
+type CourseNotFoundErrorResponse struct {
+    Error string `json:"error" example:"Course not found"`
+}
@@ -0,0 +1,323 @@
+package handlers
+
+import (
+    "bytes"
+    "errors"
+    "fmt"
+    "io"
+    "log"
+    "net/http"
+    "strconv"
+    "strings"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/services"
+
+    "github.com/gin-gonic/gin"
+)
+
+type ExportHandler struct {
+    exportService *services.ExportService
+}
+
+func NewExportHandler(exportService *services.ExportService) *ExportHandler {
+    return &ExportHandler{exportService: exportService}
+}
+
+// ExportPrintable godoc
+// @Summary Экспорт материала в PDF или HTML для печати
+// @Description Возвращает печатную версию материала со всеми блоками: в шапке предмет, автор и ссылка на материал, формулы рисуются на сервере (в HTML - MathML), тесты - рабочим листом с вариантами для отметки. Загруженные картинки встраиваются в документ. answers=true добавляет отдельный ключ ответов (только для автора и редакторов). Документ собирается на сервере без сторонних сервисов
+// @Tags materials
+// @Produce application/pdf
+// @Produce text/html
+// @Security ApiKeyAuth
+// @Param id path int true "ID материала"
+// @Param format query string false "Формат" Enums(pdf, html) default(pdf)
+// @Param answers query bool false "Добавить ключ ответов"
+// @Success 200 {file} binary "Печатная версия материала"
+// @Failure 400 {object} ErrorResponse "Неверный ID или формат"
+// @Failure 403 {object} ErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ErrorResponse "Материал не найден"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Failure 503 {object} ErrorResponse "Экспорт в PDF недоступен"
+// @Router /materials/{id}/export [get]
+func (h *ExportHandler) ExportPrintable(c *gin.Context) {
+    materialID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+        return
+    }
+
+    format := c.DefaultQuery("format", "pdf")
+    if format != "pdf" && format != "html" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid export format"})
+        return
+    }
+    answers := c.Query("answers") == "true"
+
+    material, err := h.exportService.GetExportMaterial(c.Request.Context(), c.GetInt("userID"), materialID)
+    if err != nil {
+        respondMaterialError(c, err)
+        return
+    }
+
+    data, err := h.exportService.RenderPrintable(c.Request.Context(), c.GetInt("userID"), material, format, answers)
+    if err != nil {
+        if err.Error() == "pdf export unavailable" {
+            c.JSON(http.StatusServiceUnavailable, gin.H{"error": "PDF export is unavailable"})
+            return
+        }
+        respondMaterialError(c, err)
+        return
+    }
+
+    contentType := "application/pdf"
+    if format == "html" {
+        contentType = "text/html; charset=utf-8"
+    }
+    suffix := ""
+    if answers {
+        suffix = "-answers"
+    }
+    fileName := fmt.Sprintf("material-%d%s.%s", material.ID, suffix, format)
+    c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+    c.Data(http.StatusOK, contentType, data)
+}
+
+// ExportSCORM godoc
+// @Summary Экспорт материала в SCORM
+// @Description Возвращает ZIP-пакет SCORM 1.2 или SCORM 2004 (4th Edition): imsmanifest.xml, статическая HTML-страница со всеми блоками, загруженные медиафайлы и JS, который сообщает LMS завершение и балл за тесты. Свои и соавторские материалы выгружаются в любом статусе, чужие - только опубликованные открытые
+// @Tags materials
+// @Produce application/zip
+// @Security ApiKeyAuth
+// @Param id path int true "ID материала"
+// @Param version query string false "Версия SCORM" Enums(1.2, 2004) default(1.2)
+// @Success 200 {file} binary "SCORM-пакет"
+// @Failure 400 {object} ErrorResponse "Неверный ID или версия"
+// @Failure 403 {object} ErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ErrorResponse "Материал не найден"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /materials/{id}/export/scorm [get]
+func (h *ExportHandler) ExportSCORM(c *gin.Context) {
+    materialID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+        return
+    }
+
+    version := c.DefaultQuery("version", "1.2")
+    if version != "1.2" && version != "2004" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid SCORM version"})
+        return
+    }
+
+    material, err := h.exportService.GetExportMaterial(c.Request.Context(), c.GetInt("userID"), materialID)
+    if err != nil {
+        respondMaterialError(c, err)
+        return
+    }
+
+    fileName := fmt.Sprintf("material-%d-scorm-%s.zip", material.ID, version)
+    c.Header("Content-Type", "application/zip")
+    c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+    c.Status(http.StatusOK)
+
+    // Архив пишется прямо в ответ: после начала передачи ошибку можно только записать в лог
+    if err := h.exportService.WriteSCORM(c.Request.Context(), material, version, c.Writer); err != nil {
+        log.Printf("⚠️ Export: failed to write SCORM package for material %d: %v", material.ID, err)
+    }
+}
+
+// ExportBundle godoc
+// @Summary Экспорт материала в переносимый пакет
+// @Description Выгружает материал со всеми блоками и анимациями для переноса между окружениями или резервной копии. format=zip - архив с material.json и загруженными файлами, format=json - только material.json, ссылки на файлы остаются исходными
+// @Tags materials
+// @Produce application/zip
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID материала"
+// @Param format query string false "Формат пакета" Enums(zip, json) default(zip)
+// @Success 200 {object} models.MaterialBundle "Пакет материала (для format=json)"
+// @Failure 400 {object} ErrorResponse "Неверный ID или формат"
+// @Failure 403 {object} ErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ErrorResponse "Материал не найден"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /materials/{id}/export/bundle [get]
+func (h *ExportHandler) ExportBundle(c *gin.Context) {
+    materialID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+        return
+    }
+
+    format := c.DefaultQuery("format", "zip")
+    if format != "zip" && format != "json" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bundle format"})
+        return
+    }
+
+    material, err := h.exportService.GetExportMaterial(c.Request.Context(), c.GetInt("userID"), materialID)
+    if err != nil {
+        respondMaterialError(c, err)
+        return
+    }
+
+    fileName := fmt.Sprintf("material-%d.%s", material.ID, format)
+    c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+
+    if format == "json" {
+        c.JSON(http.StatusOK, h.exportService.BuildBundle(material))
+        return
+    }
+
+    c.Header("Content-Type", "application/zip")
+    c.Status(http.StatusOK)
+
+    if err := h.exportService.WriteBundle(c.Request.Context(), material, c.Writer); err != nil {
+        log.Printf("⚠️ Export: failed to write bundle for material %d: %v", material.ID, err)
+    }
+}
+
+// ImportBundle godoc
+// @Summary Импорт материала из пакета
+// @Description Создает черновик материала у текущего пользователя из пакета экспорта: ZIP-архива или material.json. Пакет передается файлом (multipart, поле file) или телом запроса. Файлы из архива загружаются заново, блоки получают новые ID. Поддерживаются пакеты всех прежних версий схемы
+// @Tags materials
+// @Accept multipart/form-data
+// @Accept json
+// @Accept application/zip
+// @Produce json
+// @Security ApiKeyAuth
+// @Param file formData file false "Пакет материала (ZIP или JSON)"
+// @Success 201 {object} ImportMaterialResponse "Материал импортирован"
+// @Failure 400 {object} ErrorResponse "Неверный пакет, неизвестный предмет или версия схемы"
+// @Failure 413 {object} ErrorResponse "Пакет слишком большой"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /materials/import [post]
+func (h *ExportHandler) ImportBundle(c *gin.Context) {
+    c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBundleSize)
+
+    var reader io.ReaderAt
+    var size int64
+
+    if strings.HasPrefix(c.ContentType(), "multipart/") {
+        fileHeader, err := c.FormFile("file")
+        if err != nil {
+            respondBundleReadError(c, err)
+            return
+        }
+
+        file, err := fileHeader.Open()
+        if err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read bundle"})
+            return
+        }
+        defer file.Close()
+
+        reader, size = file, fileHeader.Size
+    } else {
+        data, err := io.ReadAll(c.Request.Body)
+        if err != nil {
+            respondBundleReadError(c, err)
+            return
+        }
+
+        reader, size = bytes.NewReader(data), int64(len(data))
+    }
+
+    if size == 0 {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Bundle is required"})
+        return
+    }
+
+    material, err := h.exportService.ImportBundle(c.Request.Context(), c.GetInt("userID"), reader, size)
+    if err != nil {
+        respondMaterialError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusCreated, gin.H{
+        "message":  "Material imported successfully",
+        "material": material,
+    })
+}
+
+// ImportDocument godoc
+// @Summary Импорт урока из Markdown или DOCX
+// @Description Создает черновик материала из документа: .md, ZIP с .md и картинками или .docx. Заголовки, абзацы, списки и код становятся текстовыми блоками, картинки - блоками изображений (встроенные файлы загружаются в хранилище), формулы ($...$, $$...$$, формулы Word) - блоками формул. Если название не задано, берется первый заголовок документа или имя файла
+// @Tags materials
+// @Accept multipart/form-data
+// @Produce json
+// @Security ApiKeyAuth
+// @Param file formData file true "Документ (.md, .zip или .docx)"
+// @Param subject formData string true "ID предмета"
+// @Param title formData string false "Название материала"
+// @Param level formData string false "Уровень" Enums(beginner, intermediate, advanced)
+// @Param language formData string false "Язык" default(ru)
+// @Success 201 {object} ImportMaterialResponse "Материал импортирован"
+// @Failure 400 {object} ErrorResponse "Неверный документ, формат или предмет"
+// @Failure 413 {object} ErrorResponse "Документ слишком большой"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /materials/import/document [post]
+func (h *ExportHandler) ImportDocument(c *gin.Context) {
+    c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxDocumentSize)
+
+    var req models.ImportDocumentRequest
+    if err := c.ShouldBind(&req); err != nil {
+        var maxBytesErr *http.MaxBytesError
+        if errors.As(err, &maxBytesErr) {
+            c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Document is too large"})
+            return
+        }
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid import parameters"})
+        return
+    }
+
+    fileHeader, err := c.FormFile("file")
+    if err != nil || fileHeader.Size == 0 {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Document is required"})
+        return
+    }
+
+    file, err := fileHeader.Open()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read document"})
+        return
+    }
+    defer file.Close()
+
+    material, err := h.exportService.ImportDocument(c.Request.Context(), c.GetInt("userID"), &req, fileHeader.Filename, file, fileHeader.Size)
+    if err != nil {
+        respondMaterialError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusCreated, gin.H{
+        "message":  "Material imported successfully",
+        "material": material,
+    })
+}
+
+const (
+    // maxBundleSize - ограничение на размер импортируемого пакета
+    maxBundleSize = 512 << 20
+    // maxDocumentSize - ограничение на размер импортируемого документа вместе с картинками
+    maxDocumentSize = 100 << 20
+)
+
+func respondBundleReadError(c *gin.Context, err error) {
+    var maxBytesErr *http.MaxBytesError
+    if errors.As(err, &maxBytesErr) {
+        c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Bundle is too large"})
+        return
+    }
+    c.JSON(http.StatusBadRequest, gin.H{"error": "Bundle is required"})
+}
+
+// Response models for Swagger
+
+// ImportMaterialResponse represents material import response
+// @Description Ответ на импорт материала
+type ImportMaterialResponse struct {
+    Message  string          `json:"message" example:"Material imported successfully"`
+    Material models.Material `json:"material"`
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+    "net/http"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/services"
+
+    "github.com/gin-gonic/gin"
+)
+
+type FormulaHandler struct {
+    formulaService *services.FormulaService
+}
+
+func NewFormulaHandler(formulaService *services.FormulaService) *FormulaHandler {
+    return &FormulaHandler{formulaService: formulaService}
+}
+
+// RenderFormula godoc
+// @Summary Проверить и отрисовать формулу
+// @Description Разбирает LaTeX так же, как при сохранении блока, и возвращает ошибки с позициями или готовые MathML и SVG. Нужен редактору для предпросмотра формулы до сохранения
+// @Tags materials
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param input body models.RenderFormulaRequest true "Формула"
+// @Success 200 {object} RenderFormulaResponse "Результат проверки"
+// @Failure 400 {object} ErrorResponse "Неверные параметры запроса"
+// @Router /formulas/render [post]
+func (h *FormulaHandler) RenderFormula(c *gin.Context) {
+    var req models.RenderFormulaRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    rendered, errs := h.formulaService.Render(req.Latex, req.Display)
+    if len(errs) > 0 {
+        c.JSON(http.StatusOK, gin.H{"valid": false, "errors": errs})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"valid": true, "rendered": rendered})
+}
+
+// Response models for Swagger
+
+// RenderFormulaResponse represents formula check result
+// @Description Результат проверки формулы: ошибки или отрисовка
+type RenderFormulaResponse struct {
+    Valid    bool                  `json:"valid" example:"true"`
+    Errors   []models.FormulaError `json:"errors,omitempty"`
+    Rendered *models.FormulaRender `json:"rendered,omitempty"`
+}
+
+// InvalidFormulaErrorResponse represents formula validation error
+// @Description Ошибка сохранения блоков с неверными формулами
+type InvalidFormulaErrorResponse struct {
+    Error   string                `json:"error" example:"invalid formula"`
+    Details []models.FormulaError `json:"details"`
+}
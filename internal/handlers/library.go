@@ -0,0 +1,521 @@
+package handlers
+
+import (
+    "net/http"
+    "strconv"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/services"
+
+    "github.com/gin-gonic/gin"
+)
+
+type LibraryHandler struct {
+    libraryService *services.LibraryService
+}
+
+func NewLibraryHandler(libraryService *services.LibraryService) *LibraryHandler {
+    return &LibraryHandler{libraryService: libraryService}
+}
+
+// GetLibraryBlocks godoc
+// @Summary Библиотека блоков
+// @Description Возвращает свои фрагменты и общие фрагменты других преподавателей, без содержимого блоков
+// @Tags library
+// @Produce json
+// @Security ApiKeyAuth
+// @Param scope query string false "my - свои, shared - общие, all - все" Enums(my, shared, all) default(all)
+// @Param search query string false "Поиск по названию и описанию"
+// @Param subject query string false "Фильтр по предмету"
+// @Param page query int false "Номер страницы" default(1)
+// @Param limit query int false "Количество фрагментов на странице" default(20)
+// @Success 200 {object} LibraryBlocksResponse "Список фрагментов"
+// @Failure 400 {object} ErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Только для преподавателей"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /library/blocks [get]
+func (h *LibraryHandler) GetLibraryBlocks(c *gin.Context) {
+    userID := c.GetInt("userID")
+
+    var filters models.LibraryFilters
+    if err := c.ShouldBindQuery(&filters); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if filters.Page == 0 {
+        filters.Page = 1
+    }
+    if filters.Limit == 0 {
+        filters.Limit = 20
+    }
+
+    items, total, err := h.libraryService.GetLibraryBlocks(c.Request.Context(), userID, filters)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get library blocks"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "blocks":  items,
+        "total":   total,
+        "page":    filters.Page,
+        "limit":   filters.Limit,
+        "hasMore": (filters.Page * filters.Limit) < total,
+    })
+}
+
+// SaveLibraryBlock godoc
+// @Summary Сохранить фрагмент в библиотеку
+// @Description Сохраняет один или несколько блоков как фрагмент. Блоки передаются в blocks или берутся из материала, который пользователь может редактировать (materialId и blockIds). Блоки проверяются так же, как при сохранении в материал; ID блоков не сохраняются. Общий фрагмент (shared) видят все преподаватели
+// @Tags library
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param input body models.SaveLibraryBlockRequest true "Фрагмент"
+// @Success 201 {object} LibraryBlockResponse "Фрагмент сохранен"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса, нет блоков или блоки не прошли проверку"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} MaterialNotFoundErrorResponse "Материал или блок не найден"
+// @Router /library/blocks [post]
+func (h *LibraryHandler) SaveLibraryBlock(c *gin.Context) {
+    userID := c.GetInt("userID")
+
+    var req models.SaveLibraryBlockRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    item, err := h.libraryService.SaveLibraryBlock(c.Request.Context(), userID, &req)
+    if err != nil {
+        respondLibraryError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusCreated, gin.H{
+        "message": "Library block saved successfully",
+        "block":   item,
+    })
+}
+
+// GetLibraryBlock godoc
+// @Summary Получить фрагмент
+// @Description Возвращает фрагмент библиотеки с блоками: свой или общий
+// @Tags library
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID фрагмента"
+// @Success 200 {object} models.LibraryBlock "Фрагмент"
+// @Failure 400 {object} InvalidIDErrorResponse "Неверный ID"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ErrorResponse "Фрагмент не найден"
+// @Router /library/blocks/{id} [get]
+func (h *LibraryHandler) GetLibraryBlock(c *gin.Context) {
+    userID := c.GetInt("userID")
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid library block ID"})
+        return
+    }
+
+    item, err := h.libraryService.GetLibraryBlock(c.Request.Context(), userID, id)
+    if err != nil {
+        respondLibraryError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, item)
+}
+
+// UpdateLibraryBlock godoc
+// @Summary Изменить фрагмент
+// @Description Изменяет свой фрагмент. Переданные blocks заменяют блоки фрагмента целиком
+// @Tags library
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID фрагмента"
+// @Param input body models.UpdateLibraryBlockRequest true "Изменения"
+// @Success 200 {object} LibraryBlockResponse "Фрагмент изменен"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ErrorResponse "Фрагмент не найден"
+// @Router /library/blocks/{id} [put]
+func (h *LibraryHandler) UpdateLibraryBlock(c *gin.Context) {
+    userID := c.GetInt("userID")
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid library block ID"})
+        return
+    }
+
+    var req models.UpdateLibraryBlockRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    item, err := h.libraryService.UpdateLibraryBlock(c.Request.Context(), userID, id, &req)
+    if err != nil {
+        respondLibraryError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Library block updated successfully",
+        "block":   item,
+    })
+}
+
+// DeleteLibraryBlock godoc
+// @Summary Удалить фрагмент
+// @Description Удаляет свой фрагмент. Администратор может удалить любой общий фрагмент. Блоки, уже вставленные в материалы, остаются
+// @Tags library
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID фрагмента"
+// @Success 200 {object} SuccessResponse "Фрагмент удален"
+// @Failure 400 {object} InvalidIDErrorResponse "Неверный ID"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ErrorResponse "Фрагмент не найден"
+// @Router /library/blocks/{id} [delete]
+func (h *LibraryHandler) DeleteLibraryBlock(c *gin.Context) {
+    userID := c.GetInt("userID")
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid library block ID"})
+        return
+    }
+
+    isAdmin := c.GetString("userRole") == "admin"
+    if err := h.libraryService.DeleteLibraryBlock(c.Request.Context(), userID, isAdmin, id); err != nil {
+        respondLibraryError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"message": "Library block deleted successfully"})
+}
+
+// InsertLibraryBlock godoc
+// @Summary Вставить фрагмент в материал
+// @Description Добавляет блоки фрагмента в материал так же, как AddBlock: каждый блок получает новый ID и рассылается соавторам. Нужны права редактора материала
+// @Tags library
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID фрагмента"
+// @Param input body models.InsertBlocksRequest true "Материал и позиция"
+// @Success 200 {object} models.InsertBlocksResult "Блоки добавлены"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ErrorResponse "Фрагмент или материал не найден"
+// @Failure 409 {object} ErrorResponse "Блок заблокирован другим редактором"
+// @Router /library/blocks/{id}/insert [post]
+func (h *LibraryHandler) InsertLibraryBlock(c *gin.Context) {
+    userID := c.GetInt("userID")
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid library block ID"})
+        return
+    }
+
+    var req models.InsertBlocksRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    result, err := h.libraryService.InsertLibraryBlock(c.Request.Context(), userID, id, &req)
+    if err != nil {
+        respondLibraryError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, result)
+}
+
+// GetTemplates godoc
+// @Summary Шаблоны материалов
+// @Description Возвращает официальные шаблоны и свои шаблоны, без содержимого блоков. Официальные шаблоны идут первыми
+// @Tags library
+// @Produce json
+// @Security ApiKeyAuth
+// @Param scope query string false "my - свои, official - официальные, all - все" Enums(my, official, all) default(all)
+// @Param search query string false "Поиск по названию и описанию"
+// @Param subject query string false "Фильтр по предмету"
+// @Param page query int false "Номер страницы" default(1)
+// @Param limit query int false "Количество шаблонов на странице" default(20)
+// @Success 200 {object} TemplatesResponse "Список шаблонов"
+// @Failure 400 {object} ErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Только для преподавателей"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /templates [get]
+func (h *LibraryHandler) GetTemplates(c *gin.Context) {
+    userID := c.GetInt("userID")
+
+    var filters models.TemplateFilters
+    if err := c.ShouldBindQuery(&filters); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if filters.Page == 0 {
+        filters.Page = 1
+    }
+    if filters.Limit == 0 {
+        filters.Limit = 20
+    }
+
+    templates, total, err := h.libraryService.GetTemplates(c.Request.Context(), userID, filters)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get templates"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "templates": templates,
+        "total":     total,
+        "page":      filters.Page,
+        "limit":     filters.Limit,
+        "hasMore":   (filters.Page * filters.Limit) < total,
+    })
+}
+
+// CreateTemplate godoc
+// @Summary Создать шаблон
+// @Description Создает личный шаблон из переданных блоков или из блоков материала, который пользователь может редактировать (materialId)
+// @Tags library
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param input body models.SaveTemplateRequest true "Шаблон"
+// @Success 201 {object} TemplateResponse "Шаблон создан"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса, нет блоков или блоки не прошли проверку"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} MaterialNotFoundErrorResponse "Материал не найден"
+// @Router /templates [post]
+func (h *LibraryHandler) CreateTemplate(c *gin.Context) {
+    h.createTemplate(c, false)
+}
+
+// CreateOfficialTemplate godoc
+// @Summary Создать официальный шаблон
+// @Description Создает официальный шаблон по предмету (только для администраторов). Официальные шаблоны видят все преподаватели; изменять и удалять их могут только администраторы
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param input body models.SaveTemplateRequest true "Шаблон (subject обязателен)"
+// @Success 201 {object} TemplateResponse "Шаблон создан"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса, не указан предмет или блоки не прошли проверку"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Router /admin/templates [post]
+func (h *LibraryHandler) CreateOfficialTemplate(c *gin.Context) {
+    h.createTemplate(c, true)
+}
+
+func (h *LibraryHandler) createTemplate(c *gin.Context, official bool) {
+    userID := c.GetInt("userID")
+
+    var req models.SaveTemplateRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    template, err := h.libraryService.CreateTemplate(c.Request.Context(), userID, official, &req)
+    if err != nil {
+        respondLibraryError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusCreated, gin.H{
+        "message":  "Template created successfully",
+        "template": template,
+    })
+}
+
+// GetTemplate godoc
+// @Summary Получить шаблон
+// @Description Возвращает шаблон с блоками: официальный или свой
+// @Tags library
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID шаблона"
+// @Success 200 {object} models.MaterialTemplate "Шаблон"
+// @Failure 400 {object} InvalidIDErrorResponse "Неверный ID"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ErrorResponse "Шаблон не найден"
+// @Router /templates/{id} [get]
+func (h *LibraryHandler) GetTemplate(c *gin.Context) {
+    userID := c.GetInt("userID")
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+        return
+    }
+
+    template, err := h.libraryService.GetTemplate(c.Request.Context(), userID, id)
+    if err != nil {
+        respondLibraryError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, template)
+}
+
+// UpdateTemplate godoc
+// @Summary Изменить шаблон
+// @Description Изменяет шаблон: личный - автор, официальный - администратор. Переданные blocks заменяют блоки шаблона целиком
+// @Tags library
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID шаблона"
+// @Param input body models.UpdateTemplateRequest true "Изменения"
+// @Success 200 {object} TemplateResponse "Шаблон изменен"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ErrorResponse "Шаблон не найден"
+// @Router /templates/{id} [put]
+func (h *LibraryHandler) UpdateTemplate(c *gin.Context) {
+    userID := c.GetInt("userID")
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+        return
+    }
+
+    var req models.UpdateTemplateRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    isAdmin := c.GetString("userRole") == "admin"
+    template, err := h.libraryService.UpdateTemplate(c.Request.Context(), userID, isAdmin, id, &req)
+    if err != nil {
+        respondLibraryError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message":  "Template updated successfully",
+        "template": template,
+    })
+}
+
+// DeleteTemplate godoc
+// @Summary Удалить шаблон
+// @Description Удаляет шаблон: личный - автор, официальный - администратор
+// @Tags library
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID шаблона"
+// @Success 200 {object} SuccessResponse "Шаблон удален"
+// @Failure 400 {object} InvalidIDErrorResponse "Неверный ID"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ErrorResponse "Шаблон не найден"
+// @Router /templates/{id} [delete]
+func (h *LibraryHandler) DeleteTemplate(c *gin.Context) {
+    userID := c.GetInt("userID")
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+        return
+    }
+
+    isAdmin := c.GetString("userRole") == "admin"
+    if err := h.libraryService.DeleteTemplate(c.Request.Context(), userID, isAdmin, id); err != nil {
+        respondLibraryError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"message": "Template deleted successfully"})
+}
+
+// InsertTemplate godoc
+// @Summary Вставить шаблон в материал
+// @Description Добавляет блоки шаблона в материал так же, как AddBlock: каждый блок получает новый ID и рассылается соавторам. Нужны права редактора материала
+// @Tags library
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID шаблона"
+// @Param input body models.InsertBlocksRequest true "Материал и позиция"
+// @Success 200 {object} models.InsertBlocksResult "Блоки добавлены"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ErrorResponse "Шаблон или материал не найден"
+// @Failure 409 {object} ErrorResponse "Блок заблокирован другим редактором"
+// @Router /templates/{id}/insert [post]
+func (h *LibraryHandler) InsertTemplate(c *gin.Context) {
+    userID := c.GetInt("userID")
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+        return
+    }
+
+    var req models.InsertBlocksRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    result, err := h.libraryService.InsertTemplate(c.Request.Context(), userID, id, &req)
+    if err != nil {
+        respondLibraryError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, result)
+}
+
+// respondLibraryError отвечает статусом, соответствующим ошибке библиотеки.
+// Ошибки материалов и проверки блоков обрабатывает respondMaterialError
+func respondLibraryError(c *gin.Context, err error) {
+    switch err.Error() {
+    case "library block not found", "template not found":
+        c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+    case "no blocks", "too many blocks", "subject is required for official template":
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+    default:
+        respondMaterialError(c, err)
+    }
+}
+
+// Response models for Swagger
+
+// LibraryBlocksResponse represents library blocks list
+// @Description Список фрагментов библиотеки
+type LibraryBlocksResponse struct {
+    Blocks  []models.LibraryBlock `json:"blocks"`
+    Total   int                   `json:"total" example:"12"`
+    Page    int                   `json:"page" example:"1"`
+    Limit   int                   `json:"limit" example:"20"`
+    HasMore bool                  `json:"hasMore" example:"false"`
+}
+
+// LibraryBlockResponse represents saved library block
+// @Description Ответ с фрагментом библиотеки
+type LibraryBlockResponse struct {
+    Message string              `json:"message" example:"Library block saved successfully"`
+    Block   models.LibraryBlock `json:"block"`
+}
+
+// TemplatesResponse represents templates list
+// @Description Список шаблонов материалов
+type TemplatesResponse struct {
+    Templates []models.MaterialTemplate `json:"templates"`
+    Total     int                       `json:"total" example:"8"`
+    Page      int                       `json:"page" example:"1"`
+    Limit     int                       `json:"limit" example:"20"`
+    HasMore   bool                      `json:"hasMore" example:"false"`
+}
+
+// TemplateResponse represents saved template
+// @Description Ответ с шаблоном материала
+type TemplateResponse struct {
+    Message  string                  `json:"message" example:"Template created successfully"`
+    Template models.MaterialTemplate `json:"template"`
+}
@@ -0,0 +1,332 @@
+package handlers
+
+import (
+    "net/http"
+    "strconv"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/services"
+
+    "github.com/gin-gonic/gin"
+)
+
+type LTIHandler struct {
+    ltiService *services.LTIService
+}
+
+func NewLTIHandler(ltiService *services.LTIService) *LTIHandler {
+    return &LTIHandler{ltiService: ltiService}
+}
+
+// Login godoc
+// @Summary Инициация OIDC-входа LTI 1.3
+// @Description Точка входа для платформы (Moodle): сохраняет state и nonce, привязывает state к браузеру cookie и перенаправляет браузер на авторизацию платформы. Параметры принимаются из query или формы
+// @Tags lti
+// @Accept x-www-form-urlencoded
+// @Param iss query string true "Issuer платформы"
+// @Param login_hint query string true "Подсказка платформы о пользователе"
+// @Param target_link_uri query string false "Адрес запуска"
+// @Param lti_message_hint query string false "Подсказка платформы о сообщении"
+// @Param client_id query string false "Client ID инструмента на платформе"
+// @Param lti_deployment_id query string false "ID развертывания"
+// @Success 302 "Перенаправление на авторизацию платформы"
+// @Failure 400 {object} ErrorResponse "Неверные параметры или платформа не зарегистрирована"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /lti/login [get]
+// @Router /lti/login [post]
+func (h *LTIHandler) Login(c *gin.Context) {
+    var req models.LTILoginRequest
+    if err := c.ShouldBind(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid login request"})
+        return
+    }
+
+    redirectURL, state, err := h.ltiService.Login(c.Request.Context(), &req)
+    if err != nil {
+        respondLTIError(c, err)
+        return
+    }
+
+    // Запуск приходит POST-запросом со страницы платформы - cookie нужна с SameSite=None
+    c.SetSameSite(http.SameSiteNoneMode)
+    c.SetCookie(services.LTIStateCookie(state), state, int(services.LTILoginTTL.Seconds()), ltiLaunchPath, "", true, true)
+
+    c.Redirect(http.StatusFound, redirectURL)
+}
+
+// Launch godoc
+// @Summary Запуск LTI 1.3
+// @Description Принимает подписанный id_token от платформы, проверяет state (в том числе cookie браузера, начавшего вход), подпись, issuer, audience, nonce и развертывание, заводит пользователя и перенаправляет на материал или на выбор материалов (deep linking). Токены доступа передаются во фрагменте адреса: #accessToken=...&refreshToken=...
+// @Tags lti
+// @Accept x-www-form-urlencoded
+// @Param id_token formData string true "Подписанный id_token"
+// @Param state formData string true "State из инициации входа"
+// @Success 302 "Перенаправление на фронтенд"
+// @Failure 400 {object} ErrorResponse "Неверный запуск"
+// @Failure 401 {object} ErrorResponse "Подпись или state не прошли проверку"
+// @Failure 403 {object} ErrorResponse "Аккаунт заблокирован или нет прав на deep linking"
+// @Failure 404 {object} ErrorResponse "Материал не найден"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /lti/launch [post]
+func (h *LTIHandler) Launch(c *gin.Context) {
+    if platformError := c.PostForm("error"); platformError != "" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": platformError, "description": c.PostForm("error_description")})
+        return
+    }
+
+    idToken := c.PostForm("id_token")
+    state := c.PostForm("state")
+    if idToken == "" || state == "" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "id_token and state are required"})
+        return
+    }
+
+    // Отсутствующая cookie - пустое значение, запуск будет отклонен
+    browserState, _ := c.Cookie(services.LTIStateCookie(state))
+
+    // State одноразовый - cookie больше не нужна
+    c.SetSameSite(http.SameSiteNoneMode)
+    c.SetCookie(services.LTIStateCookie(state), "", -1, ltiLaunchPath, "", true, true)
+
+    redirectURL, err := h.ltiService.Launch(c.Request.Context(), idToken, state, browserState)
+    if err != nil {
+        respondLTIError(c, err)
+        return
+    }
+
+    c.Redirect(http.StatusFound, redirectURL)
+}
+
+// GetJWKS godoc
+// @Summary Публичные ключи инструмента
+// @Description JWKS для проверки платформой ответов deep linking и client assertion
+// @Tags lti
+// @Produce json
+// @Success 200 {object} models.LTIJWKS "Ключи"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /lti/jwks [get]
+func (h *LTIHandler) GetJWKS(c *gin.Context) {
+    jwks, err := h.ltiService.GetJWKS(c.Request.Context())
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get keys"})
+        return
+    }
+
+    c.JSON(http.StatusOK, jwks)
+}
+
+// GetDeepLinkMaterials godoc
+// @Summary Материалы для deep linking
+// @Description Возвращает сессию выбора и опубликованные материалы каталога с фильтрами как у /catalog/materials
+// @Tags lti
+// @Produce json
+// @Security ApiKeyAuth
+// @Param sessionId path string true "ID сессии deep linking"
+// @Param search query string false "Поисковый запрос (название, описание, автор)"
+// @Param subject query string false "Фильтр по предмету"
+// @Param level query string false "Фильтр по уровню сложности" Enums(beginner, intermediate, advanced)
+// @Param page query int false "Номер страницы" default(1)
+// @Param limit query int false "Количество материалов на странице" default(20)
+// @Success 200 {object} LTIDeepLinkMaterialsResponse "Сессия и материалы"
+// @Failure 400 {object} ErrorResponse "Неверные параметры запроса"
+// @Failure 401 {object} AuthErrorResponse "Требуется авторизация"
+// @Failure 404 {object} ErrorResponse "Сессия не найдена или истекла"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /lti/deep-linking/{sessionId} [get]
+func (h *LTIHandler) GetDeepLinkMaterials(c *gin.Context) {
+    var filters models.CatalogFilters
+    if err := c.ShouldBindQuery(&filters); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if filters.Page == 0 {
+        filters.Page = 1
+    }
+    if filters.Limit == 0 {
+        filters.Limit = 20
+    }
+
+    session, materials, total, err := h.ltiService.SearchDeepLinkMaterials(c.Request.Context(), c.GetInt("userID"), c.Param("sessionId"), filters)
+    if err != nil {
+        respondLTIError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "session":   session,
+        "materials": materials,
+        "total":     total,
+        "page":      filters.Page,
+        "limit":     filters.Limit,
+        "hasMore":   (filters.Page * filters.Limit) < total,
+    })
+}
+
+// CompleteDeepLinking godoc
+// @Summary Завершить deep linking
+// @Description Формирует подписанный ответ с выбранными материалами. Фронтенд отправляет jwt формой (POST, поле JWT) на returnUrl платформы. Сессия закрывается
+// @Tags lti
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param sessionId path string true "ID сессии deep linking"
+// @Param request body models.LTIDeepLinkRequest true "Выбранные материалы"
+// @Success 200 {object} models.LTIDeepLinkResponse "Подписанный ответ"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 401 {object} AuthErrorResponse "Требуется авторизация"
+// @Failure 404 {object} ErrorResponse "Сессия или материал не найдены"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /lti/deep-linking/{sessionId} [post]
+func (h *LTIHandler) CompleteDeepLinking(c *gin.Context) {
+    var req models.LTIDeepLinkRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid parameters"})
+        return
+    }
+
+    response, err := h.ltiService.CompleteDeepLinking(c.Request.Context(), c.GetInt("userID"), c.Param("sessionId"), req.MaterialIDs)
+    if err != nil {
+        respondLTIError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, response)
+}
+
+// GetPlatforms godoc
+// @Summary Список LTI платформ
+// @Description Возвращает зарегистрированные LMS-платформы
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} LTIPlatformsResponse "Платформы"
+// @Failure 401 {object} AuthErrorResponse "Требуется авторизация"
+// @Failure 403 {object} ErrorResponse "Доступ запрещен"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /admin/lti/platforms [get]
+func (h *LTIHandler) GetPlatforms(c *gin.Context) {
+    platforms, err := h.ltiService.GetPlatforms(c.Request.Context())
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get platforms"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "items": platforms,
+        "total": len(platforms),
+    })
+}
+
+// CreatePlatform godoc
+// @Summary Зарегистрировать LTI платформу
+// @Description Регистрирует LMS-платформу по данным из ее настроек внешнего инструмента. В настройках платформы указываются адреса инструмента: /api/v1/lti/login, /api/v1/lti/launch и /api/v1/lti/jwks
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body models.CreateLTIPlatformRequest true "Данные платформы"
+// @Success 201 {object} LTIPlatformResponse "Платформа зарегистрирована"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 401 {object} AuthErrorResponse "Требуется авторизация"
+// @Failure 403 {object} ErrorResponse "Доступ запрещен"
+// @Failure 409 {object} ErrorResponse "Платформа уже зарегистрирована"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /admin/lti/platforms [post]
+func (h *LTIHandler) CreatePlatform(c *gin.Context) {
+    var req models.CreateLTIPlatformRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid parameters"})
+        return
+    }
+
+    platform, err := h.ltiService.CreatePlatform(c.Request.Context(), &req)
+    if err != nil {
+        respondLTIError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusCreated, gin.H{
+        "message":  "Platform registered successfully",
+        "platform": platform,
+    })
+}
+
+// DeletePlatform godoc
+// @Summary Удалить LTI платформу
+// @Description Удаляет платформу вместе со связями пользователей и ссылками на материалы. Заведенные пользователи остаются
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID платформы"
+// @Success 200 {object} SuccessResponse "Платформа удалена"
+// @Failure 400 {object} ErrorResponse "Неверный ID"
+// @Failure 401 {object} AuthErrorResponse "Требуется авторизация"
+// @Failure 403 {object} ErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ErrorResponse "Платформа не найдена"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /admin/lti/platforms/{id} [delete]
+func (h *LTIHandler) DeletePlatform(c *gin.Context) {
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid platform ID"})
+        return
+    }
+
+    if err := h.ltiService.DeletePlatform(c.Request.Context(), id); err != nil {
+        respondLTIError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"message": "Platform deleted successfully"})
+}
+
+// Путь запуска - cookie со state отправляется только на него
+const ltiLaunchPath = "/api/v1/lti/launch"
+
+func respondLTIError(c *gin.Context, err error) {
+    switch err.Error() {
+    case "invalid state", "invalid nonce", "invalid id_token":
+        c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+    case "access denied":
+        c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+    case "account is blocked":
+        c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+    case "platform not found", "material not found", "deep linking session not found":
+        c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+    case "platform not registered", "deployment not registered", "unsupported LTI version",
+        "unsupported message type", "anonymous launch not supported", "material not linked",
+        "resource links not accepted", "multiple materials not accepted":
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+    case "platform already registered":
+        c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+    default:
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+    }
+}
+
+// Response models for Swagger
+
+// LTIPlatformResponse represents LTI platform registration response
+// @Description Ответ с зарегистрированной платформой
+type LTIPlatformResponse struct {
+    Message  string             `json:"message" example:"Platform registered successfully"`
+    Platform models.LTIPlatform `json:"platform"`
+}
+
+// LTIPlatformsResponse represents LTI platforms list response
+// @Description Список зарегистрированных платформ
+type LTIPlatformsResponse struct {
+    Items []models.LTIPlatform `json:"items"`
+    Total int                  `json:"total" example:"1"`
+}
+
+// LTIDeepLinkMaterialsResponse represents deep linking picker response
+// @Description Сессия deep linking и материалы каталога для выбора
+type LTIDeepLinkMaterialsResponse struct {
+    Session   models.LTIDeepLinkSession `json:"session"`
+    Materials []models.CatalogMaterial  `json:"materials"`
+    Total     int                       `json:"total" example:"150"`
+    Page      int                       `json:"page" example:"1"`
+    Limit     int                       `json:"limit" example:"20"`
+    HasMore   bool                      `json:"hasMore" example:"true"`
+}
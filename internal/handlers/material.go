@@ -1,6 +1,7 @@
 package handlers
 
 import (
+    "errors"
     "net/http"
     "strconv"
     "crypto/rand"
@@ -56,7 +57,7 @@ func (h *MaterialHandler) CreateMaterial(c *gin.Context) {
 
 // GetMaterial godoc
 // @Summary Получить материал
-// @Description Возвращает материал по ID
+// @Description Возвращает материал по ID. Неопубликованные материалы доступны автору и соавторам, архивные - также ученикам, которые их прошли
 // @Tags materials
 // @Accept json
 // @Produce json
@@ -65,6 +66,7 @@ func (h *MaterialHandler) CreateMaterial(c *gin.Context) {
 // @Success 200 {object} models.Material "Материал"
 // @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
 // @Failure 401 {object} InvalidIDErrorResponse "Неверный ID"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
 // @Failure 404 {object} MaterialNotFoundErrorResponse "Материал не найден"
 // @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
 // @Router /materials/{id} [get]
@@ -75,9 +77,11 @@ func (h *MaterialHandler) GetMaterial(c *gin.Context) {
         return
     }
 
-    material, err := h.materialService.GetMaterial(c.Request.Context(), materialID)
+    userID := c.GetInt("userID")
+
+    material, err := h.materialService.GetMaterial(c.Request.Context(), userID, materialID)
     if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        respondMaterialError(c, err)
         return
     }
 
@@ -91,7 +95,7 @@ func (h *MaterialHandler) GetMaterial(c *gin.Context) {
 
 // UpdateMaterial godoc
 // @Summary Обновить материал
-// @Description Обновляет материал (только для автора)
+// @Description Обновляет материал (только для автора). Текстовый блок хранится как документ rich text в content.doc (models.RichTextNode); старый формат {text, level} и HTML переводятся в документ с очисткой разметки и ссылок. Блок с кодом (type=code): content {language: python|javascript|java, starterCode, instructions, tests: [models.CodeTest]}; скрытые тесты ученикам не отдаются. Формулы и узлы math в тексте проверяются: при ошибке возвращается 400 {"error": "invalid formula", "details": [...]} с позициями, иначе в content блока сохраняются отрисованные MathML и SVG. Анимация блока (animation) проверяется по содержимому: element - один из элементов блока (см. preview анимации), action - show, hide или highlight, trigger - click или auto; при ошибке возвращается 400 {"error": "invalid animation", "details": [...]}. Если включена модерация, правка опубликованного или запланированного материала пользователем без доверенного статуса возвращает его в pending_review до нового одобрения модератором
 // @Tags materials
 // @Accept json
 // @Produce json
@@ -119,11 +123,7 @@ func (h *MaterialHandler) UpdateMaterial(c *gin.Context) {
 
     err = h.materialService.UpdateMaterial(c.Request.Context(), userID, materialID, &req)
     if err != nil {
-        if err.Error() == "access denied" {
-            c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
-        } else {
-            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-        }
+        respondMaterialError(c, err)
         return
     }
 
@@ -139,7 +139,7 @@ func (h *MaterialHandler) UpdateMaterial(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
-// @Param status query string false "Фильтр по статусу" Enums(draft, published, archived)
+// @Param status query string false "Фильтр по статусу" Enums(draft, pending_review, published, archived)
 // @Success 200 {object} models.UserMaterialsResponse "Материалы пользователя"
 // @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
 // @Router /materials/my [get]
@@ -163,7 +163,7 @@ func (h *MaterialHandler) GetUserMaterials(c *gin.Context) {
 
 // PublishMaterial godoc
 // @Summary Опубликовать материал
-// @Description Публикует материал с указанными настройками видимости
+// @Description Публикует материал с указанными настройками видимости. С publishAt в будущем материал остается черновиком и публикуется автоматически в это время; с unpublishAt материал автоматически переносится в архив по окончании окна доступа (например, экзамена). До начала и после окончания окна материала нет в каталоге и он недоступен ученикам. Если включена модерация, материал автора без доверенного статуса переходит в pending_review и публикуется (или начинает ждать publishAt) только после одобрения модератором
 // @Tags materials
 // @Accept json
 // @Produce json
@@ -199,12 +199,19 @@ func (h *MaterialHandler) PublishMaterial(c *gin.Context) {
     // Вызываем настоящую логику публикации
     material, err := h.materialService.PublishMaterial(c.Request.Context(), userID, materialID, &req)
     if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        respondMaterialError(c, err)
         return
     }
 
+    message := "Material published successfully"
+    if material.Status == "pending_review" {
+        message = "Material submitted for review"
+    } else if material.PublishAt != nil {
+        message = "Material scheduled for publishing"
+    }
+
     c.JSON(http.StatusOK, gin.H{
-        "message": "Material published successfully",
+        "message": message,
         "material": material,
         "shareUrl": material.ShareURL,
     })
@@ -212,7 +219,7 @@ func (h *MaterialHandler) PublishMaterial(c *gin.Context) {
 
 // AddBlock godoc
 // @Summary Добавить блок
-// @Description Добавляет блок к материалу
+// @Description Добавляет блок к материалу. Текстовый блок хранится как документ rich text в content.doc (models.RichTextNode); старый формат {text, level} и HTML переводятся в документ с очисткой разметки и ссылок. Блок с кодом (type=code): content {language: python|javascript|java, starterCode, instructions, tests: [models.CodeTest]}; скрытые тесты ученикам не отдаются. Формулы и узлы math в тексте проверяются: при ошибке возвращается 400 {"error": "invalid formula", "details": [...]} с позициями, иначе в content блока сохраняются отрисованные MathML и SVG. Анимация блока (animation) проверяется по содержимому: element - один из элементов блока (см. preview анимации), action - show, hide или highlight, trigger - click или auto; при ошибке возвращается 400 {"error": "invalid animation", "details": [...]}
 // @Tags materials
 // @Accept json
 // @Produce json
@@ -239,7 +246,7 @@ func (h *MaterialHandler) AddBlock(c *gin.Context) {
 
     err = h.materialService.AddBlock(c.Request.Context(), userID, materialID, &block)
     if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        respondMaterialError(c, err)
         return
     }
 
@@ -251,7 +258,7 @@ func (h *MaterialHandler) AddBlock(c *gin.Context) {
 
 // UpdateBlock godoc
 // @Summary Обновить блок
-// @Description Обновляет блок материала
+// @Description Обновляет блок материала. Текстовый блок хранится как документ rich text в content.doc (models.RichTextNode); старый формат {text, level} и HTML переводятся в документ с очисткой разметки и ссылок. Блок с кодом (type=code): content {language: python|javascript|java, starterCode, instructions, tests: [models.CodeTest]}; скрытые тесты ученикам не отдаются. Формулы и узлы math в тексте проверяются: при ошибке возвращается 400 {"error": "invalid formula", "details": [...]} с позициями, иначе в content блока сохраняются отрисованные MathML и SVG. Анимация блока (animation) проверяется по содержимому: element - один из элементов блока (см. preview анимации), action - show, hide или highlight, trigger - click или auto; при ошибке возвращается 400 {"error": "invalid animation", "details": [...]}
 // @Tags materials
 // @Accept json
 // @Produce json
@@ -283,7 +290,7 @@ func (h *MaterialHandler) UpdateBlock(c *gin.Context) {
 
     err = h.materialService.UpdateBlock(c.Request.Context(), userID, materialID, blockID, &block)
     if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        respondMaterialError(c, err)
         return
     }
 
@@ -292,6 +299,36 @@ func (h *MaterialHandler) UpdateBlock(c *gin.Context) {
     })
 }
 
+// GetAnimationPreview godoc
+// @Summary Предпросмотр анимации блока
+// @Description Раскладывает анимацию блока по кадрам: какие элементы видны и подсвечены до анимации и после каждого шага. Редактор и плеер используют одни правила: элементы, первое действие с которыми show, изначально скрыты; show и hide действуют до следующего шага с тем же элементом; highlight показывает элемент и подсвечивает его только в своем кадре. Неверные шаги сохраненной анимации пропускаются и перечисляются в errors
+// @Tags materials
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID материала"
+// @Param blockId path string true "ID блока"
+// @Success 200 {object} models.AnimationTimeline "Кадры анимации"
+// @Failure 400 {object} InvalidIDErrorResponse "Неверный ID"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} MaterialNotFoundErrorResponse "Материал или блок не найден"
+// @Router /materials/{id}/blocks/{blockId}/animation/preview [get]
+func (h *MaterialHandler) GetAnimationPreview(c *gin.Context) {
+    userID := c.GetInt("userID")
+    materialID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+        return
+    }
+
+    timeline, err := h.materialService.PreviewAnimation(c.Request.Context(), userID, materialID, c.Param("blockId"))
+    if err != nil {
+        respondMaterialError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, timeline)
+}
+
 // DeleteBlock godoc
 // @Summary Удалить блок
 // @Description Удаляет блок из материала
@@ -317,7 +354,7 @@ func (h *MaterialHandler) DeleteBlock(c *gin.Context) {
 
     err = h.materialService.DeleteBlock(c.Request.Context(), userID, materialID, blockID)
     if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        respondMaterialError(c, err)
         return
     }
 
@@ -355,7 +392,7 @@ func (h *MaterialHandler) ReorderBlocks(c *gin.Context) {
 
     err = h.materialService.ReorderBlocks(c.Request.Context(), userID, materialID, req.Blocks)
     if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        respondMaterialError(c, err)
         return
     }
 
@@ -364,7 +401,121 @@ func (h *MaterialHandler) ReorderBlocks(c *gin.Context) {
         "newOrder": req.Blocks,
     })
 }
+// DuplicateMaterial godoc
+// @Summary Дублировать материал
+// @Description Создает черновик-копию собственного материала со всеми блоками (новые ID блоков, медиа не копируются)
+// @Tags materials
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID материала"
+// @Success 201 {object} CreateMaterialResponse "Копия создана"
+// @Failure 400 {object} InvalidIDErrorResponse "Неверный ID"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} MaterialNotFoundErrorResponse "Материал не найден"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /materials/{id}/duplicate [post]
+func (h *MaterialHandler) DuplicateMaterial(c *gin.Context) {
+    userID := c.GetInt("userID")
+    materialID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+        return
+    }
+
+    material, err := h.materialService.DuplicateMaterial(c.Request.Context(), userID, materialID)
+    if err != nil {
+        respondMaterialError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusCreated, gin.H{
+        "message":   "Material duplicated successfully",
+        "material":  material,
+        "editorUrl": "/editor/" + strconv.Itoa(material.ID),
+    })
+}
+
+// ForkMaterial godoc
+// @Summary Создать копию чужого материала
+// @Description Копирует опубликованный открытый материал другого автора в черновики текущего пользователя.
+// @Description Копия хранит ссылку на исходный материал (forkedFrom), автор оригинала указывается в каталоге
+// @Tags materials
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID материала"
+// @Success 201 {object} CreateMaterialResponse "Копия создана"
+// @Failure 400 {object} InvalidIDErrorResponse "Неверный ID"
+// @Failure 403 {object} ForbiddenErrorResponse "Материал нельзя копировать"
+// @Failure 404 {object} MaterialNotFoundErrorResponse "Материал не найден"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /materials/{id}/fork [post]
+func (h *MaterialHandler) ForkMaterial(c *gin.Context) {
+    userID := c.GetInt("userID")
+    materialID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+        return
+    }
+
+    material, err := h.materialService.ForkMaterial(c.Request.Context(), userID, materialID)
+    if err != nil {
+        respondMaterialError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusCreated, gin.H{
+        "message":   "Material forked successfully",
+        "material":  material,
+        "editorUrl": "/editor/" + strconv.Itoa(material.ID),
+    })
+}
+
+// respondMaterialError отвечает статусом, соответствующим ошибке сервиса материалов
+func respondMaterialError(c *gin.Context, err error) {
+    var formulaErr *services.FormulaValidationError
+    if errors.As(err, &formulaErr) {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid formula", "details": formulaErr.Errors})
+        return
+    }
+    var animationErr *services.AnimationValidationError
+    if errors.As(err, &animationErr) {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid animation", "details": animationErr.Errors})
+        return
+    }
 
+    switch err.Error() {
+    case "access denied":
+        c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+    case "material not found":
+        c.JSON(http.StatusNotFound, gin.H{"error": "Material not found"})
+    case "user not found", "collaborator not found", "block not found", "submission not found":
+        c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+    case "userId or email is required", "owner cannot be a collaborator", "user already owns material",
+        "block is required", "block order must list every block":
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+    case "use duplicate for own materials":
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+    case "material cannot be forked":
+        c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+    case "material is not archived":
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+    case "invalid bundle", "unsupported bundle version", "unknown subject", "unsupported block type",
+        "invalid document", "unsupported document format", "document has no content", "invalid rich text",
+        "unsupported code language", "invalid code block", "block has no tests",
+        "unpublishAt must be in the future", "unpublishAt must be after publishAt":
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+    case "code execution is not available", "language is not available", "code runner is busy":
+        c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+    case "code is already running", "too many code runs":
+        c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+    case "block is locked", "block already exists", "material has completions", "exercises not passed":
+        c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+    case "operations expired":
+        c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+    default:
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+    }
+}
 
 // Вспомогательная функция для генерации хеша
 func generateUniqueHash() string {
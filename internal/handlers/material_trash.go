@@ -0,0 +1,189 @@
+package handlers
+
+import (
+    "net/http"
+    "strconv"
+
+    "paydeya-backend/internal/models"
+
+    "github.com/gin-gonic/gin"
+)
+
+// ArchiveMaterial godoc
+// @Summary Архивировать материал
+// @Description Убирает материал из каталога, сохраняя его для учеников, которые его уже прошли (только для владельца)
+// @Tags materials
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID материала"
+// @Success 200 {object} MaterialStatusResponse "Материал архивирован"
+// @Failure 400 {object} InvalidIDErrorResponse "Неверный ID"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} MaterialNotFoundErrorResponse "Материал не найден"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /materials/{id}/archive [post]
+func (h *MaterialHandler) ArchiveMaterial(c *gin.Context) {
+    userID := c.GetInt("userID")
+    materialID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+        return
+    }
+
+    material, err := h.materialService.ArchiveMaterial(c.Request.Context(), userID, materialID)
+    if err != nil {
+        respondMaterialError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Material archived successfully",
+        "status":  material.Status,
+    })
+}
+
+// UnarchiveMaterial godoc
+// @Summary Вернуть материал из архива
+// @Description Возвращает архивированный материал в черновики (только для владельца)
+// @Tags materials
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID материала"
+// @Success 200 {object} MaterialStatusResponse "Материал возвращен в черновики"
+// @Failure 400 {object} ErrorResponse "Материал не в архиве"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} MaterialNotFoundErrorResponse "Материал не найден"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /materials/{id}/unarchive [post]
+func (h *MaterialHandler) UnarchiveMaterial(c *gin.Context) {
+    userID := c.GetInt("userID")
+    materialID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+        return
+    }
+
+    material, err := h.materialService.UnarchiveMaterial(c.Request.Context(), userID, materialID)
+    if err != nil {
+        respondMaterialError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Material unarchived successfully",
+        "status":  material.Status,
+    })
+}
+
+// DeleteMaterial godoc
+// @Summary Удалить материал
+// @Description Перемещает материал в корзину, откуда его можно восстановить до окончания срока хранения.
+// @Description С permanent=true материал (в том числе из корзины) удаляется сразу вместе с неиспользуемыми файлами.
+// @Description Материалы, которые уже проходили ученики, удалить нельзя - только архивировать
+// @Tags materials
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID материала"
+// @Param permanent query bool false "Удалить окончательно" default(false)
+// @Success 200 {object} SuccessResponse "Материал удален"
+// @Failure 400 {object} InvalidIDErrorResponse "Неверный ID"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} MaterialNotFoundErrorResponse "Материал не найден"
+// @Failure 409 {object} ErrorResponse "Материал проходили ученики"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /materials/{id} [delete]
+func (h *MaterialHandler) DeleteMaterial(c *gin.Context) {
+    userID := c.GetInt("userID")
+    materialID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+        return
+    }
+
+    permanent := c.Query("permanent") == "true"
+
+    if err := h.materialService.DeleteMaterial(c.Request.Context(), userID, materialID, permanent); err != nil {
+        respondMaterialError(c, err)
+        return
+    }
+
+    message := "Material moved to trash"
+    if permanent {
+        message = "Material deleted permanently"
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": message,
+    })
+}
+
+// GetTrash godoc
+// @Summary Получить корзину
+// @Description Возвращает удаленные материалы пользователя с датой окончательного удаления
+// @Tags materials
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} TrashResponse "Материалы в корзине"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /materials/trash [get]
+func (h *MaterialHandler) GetTrash(c *gin.Context) {
+    userID := c.GetInt("userID")
+
+    materials, err := h.materialService.GetTrash(c.Request.Context(), userID)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get trash"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "materials": materials,
+        "total":     len(materials),
+    })
+}
+
+// RestoreMaterial godoc
+// @Summary Восстановить материал из корзины
+// @Description Возвращает удаленный материал из корзины (только для владельца)
+// @Tags materials
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID материала"
+// @Success 200 {object} SuccessResponse "Материал восстановлен"
+// @Failure 400 {object} InvalidIDErrorResponse "Неверный ID"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} MaterialNotFoundErrorResponse "Материал не найден в корзине"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /materials/{id}/restore [post]
+func (h *MaterialHandler) RestoreMaterial(c *gin.Context) {
+    userID := c.GetInt("userID")
+    materialID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+        return
+    }
+
+    if err := h.materialService.RestoreMaterial(c.Request.Context(), userID, materialID); err != nil {
+        respondMaterialError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Material restored successfully",
+    })
+}
+
+// Response models for Swagger
+
+// MaterialStatusResponse represents material status change response
+// @Description Ответ на изменение статуса материала
+type MaterialStatusResponse struct {
+    Message string `json:"message" example:"Material archived successfully"`
+    Status  string `json:"status" example:"archived"`
+}
+
+// TrashResponse represents trash list response
+// @Description Ответ со списком материалов в корзине
+type TrashResponse struct {
+    Materials []models.TrashedMaterial `json:"materials"`
+    Total     int                      `json:"total" example:"2"`
+}
@@ -0,0 +1,289 @@
+package handlers
+
+import (
+    "net/http"
+    "strconv"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/services"
+
+    "github.com/gin-gonic/gin"
+)
+
+type ModerationHandler struct {
+    moderationService *services.ModerationService
+}
+
+func NewModerationHandler(moderationService *services.ModerationService) *ModerationHandler {
+    return &ModerationHandler{moderationService: moderationService}
+}
+
+// GetQueue godoc
+// @Summary Очередь модерации
+// @Description Возвращает материалы, ожидающие проверки, начиная с самых старых заявок
+// @Tags moderation
+// @Produce json
+// @Security ApiKeyAuth
+// @Param subject query string false "Фильтр по предмету"
+// @Param page query int false "Номер страницы" default(1)
+// @Param limit query int false "Количество заявок на странице" default(20)
+// @Success 200 {object} ModerationQueueResponse "Очередь модерации"
+// @Failure 400 {object} ErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /admin/moderation/queue [get]
+func (h *ModerationHandler) GetQueue(c *gin.Context) {
+    var filters models.ModerationQueueFilters
+    if err := c.ShouldBindQuery(&filters); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if filters.Page == 0 {
+        filters.Page = 1
+    }
+    if filters.Limit == 0 {
+        filters.Limit = 20
+    }
+
+    reviews, total, err := h.moderationService.GetQueue(c.Request.Context(), filters)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get moderation queue"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "reviews": reviews,
+        "total":   total,
+        "page":    filters.Page,
+        "limit":   filters.Limit,
+        "hasMore": (filters.Page * filters.Limit) < total,
+    })
+}
+
+// GetReview godoc
+// @Summary Получить заявку на публикацию
+// @Description Возвращает заявку и материал с блоками в том виде, в котором он отправлен на проверку
+// @Tags moderation
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID заявки"
+// @Success 200 {object} ReviewDetailsResponse "Заявка и материал"
+// @Failure 400 {object} InvalidIDErrorResponse "Неверный ID"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ErrorResponse "Заявка не найдена"
+// @Router /admin/moderation/reviews/{id} [get]
+func (h *ModerationHandler) GetReview(c *gin.Context) {
+    reviewID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review ID"})
+        return
+    }
+
+    review, material, err := h.moderationService.GetReview(c.Request.Context(), reviewID)
+    if err != nil {
+        respondModerationError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "review":   review,
+        "material": material,
+    })
+}
+
+// ApproveReview godoc
+// @Summary Одобрить материал
+// @Description Одобряет заявку: материал публикуется, а если задано будущее время публикации - публикуется по расписанию. Комментарии необязательны
+// @Tags moderation
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID заявки"
+// @Param input body models.ReviewDecisionRequest false "Комментарии модератора"
+// @Success 200 {object} ReviewDecisionResponse "Материал одобрен"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ErrorResponse "Заявка или блок не найдены"
+// @Failure 409 {object} ErrorResponse "Заявка уже рассмотрена или отозвана"
+// @Router /admin/moderation/reviews/{id}/approve [post]
+func (h *ModerationHandler) ApproveReview(c *gin.Context) {
+    moderatorID := c.GetInt("userID")
+    reviewID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review ID"})
+        return
+    }
+
+    var req models.ReviewDecisionRequest
+    if c.Request.ContentLength > 0 {
+        if err := c.ShouldBindJSON(&req); err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+            return
+        }
+    }
+
+    review, err := h.moderationService.ApproveReview(c.Request.Context(), moderatorID, reviewID, &req)
+    if err != nil {
+        respondModerationError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Material approved",
+        "review":  review,
+    })
+}
+
+// RequestChanges godoc
+// @Summary Вернуть материал на доработку
+// @Description Возвращает материал автору в черновики. Нужен общий комментарий или хотя бы одно замечание к блоку
+// @Tags moderation
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID заявки"
+// @Param input body models.ReviewDecisionRequest true "Комментарии модератора"
+// @Success 200 {object} ReviewDecisionResponse "Материал возвращен на доработку"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса или нет комментария"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ErrorResponse "Заявка или блок не найдены"
+// @Failure 409 {object} ErrorResponse "Заявка уже рассмотрена или отозвана"
+// @Router /admin/moderation/reviews/{id}/request-changes [post]
+func (h *ModerationHandler) RequestChanges(c *gin.Context) {
+    moderatorID := c.GetInt("userID")
+    reviewID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review ID"})
+        return
+    }
+
+    var req models.ReviewDecisionRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    review, err := h.moderationService.RequestChanges(c.Request.Context(), moderatorID, reviewID, &req)
+    if err != nil {
+        respondModerationError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Changes requested",
+        "review":  review,
+    })
+}
+
+// SetTrustedAuthor godoc
+// @Summary Доверенный автор
+// @Description Включает или отключает публикацию без модерации для автора
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID пользователя"
+// @Param input body models.TrustedAuthorRequest true "Доверие к автору"
+// @Success 200 {object} SuccessResponse "Доверие изменено"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} UserNotFoundErrorResponse "Пользователь не найден"
+// @Router /admin/users/{id}/trusted [put]
+func (h *ModerationHandler) SetTrustedAuthor(c *gin.Context) {
+    userID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+        return
+    }
+
+    var req models.TrustedAuthorRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    if err := h.moderationService.SetTrustedAuthor(c.Request.Context(), userID, *req.Trusted); err != nil {
+        respondModerationError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Trusted author updated",
+        "userId":  userID,
+        "trusted": *req.Trusted,
+    })
+}
+
+// GetMaterialReviews godoc
+// @Summary История модерации материала
+// @Description Возвращает заявки на публикацию материала с решениями и замечаниями модераторов, начиная с последней
+// @Tags materials
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID материала"
+// @Success 200 {object} MaterialReviewsResponse "История модерации"
+// @Failure 400 {object} InvalidIDErrorResponse "Неверный ID"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} MaterialNotFoundErrorResponse "Материал не найден"
+// @Router /materials/{id}/reviews [get]
+func (h *ModerationHandler) GetMaterialReviews(c *gin.Context) {
+    userID := c.GetInt("userID")
+    materialID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+        return
+    }
+
+    reviews, err := h.moderationService.GetMaterialReviews(c.Request.Context(), userID, materialID)
+    if err != nil {
+        respondModerationError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"reviews": reviews})
+}
+
+func respondModerationError(c *gin.Context, err error) {
+    switch err.Error() {
+    case "review not found":
+        c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+    case "comment is required":
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+    case "review is not pending":
+        c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+    default:
+        respondMaterialError(c, err)
+    }
+}
+
+// Response models for Swagger
+
+// ModerationQueueResponse represents moderation queue
+// @Description Очередь модерации
+type ModerationQueueResponse struct {
+    Reviews []models.MaterialReview `json:"reviews"`
+    Total   int                     `json:"total" example:"5"`
+    Page    int                     `json:"page" example:"1"`
+    Limit   int                     `json:"limit" example:"20"`
+    HasMore bool                    `json:"hasMore" example:"false"`
+}
+
+// ReviewDetailsResponse represents review with material
+// @Description Заявка на публикацию и материал с блоками
+type ReviewDetailsResponse struct {
+    Review   models.MaterialReview `json:"review"`
+    Material models.Material       `json:"material"`
+}
+
+// ReviewDecisionResponse represents moderator decision response
+// @Description Ответ на решение модератора
+type ReviewDecisionResponse struct {
+    Message string                `json:"message" example:"Material approved"`
+    Review  models.MaterialReview `json:"review"`
+}
+
+// MaterialReviewsResponse represents material review history
+// @Description История модерации материала
+type MaterialReviewsResponse struct {
+    Reviews []models.MaterialReview `json:"reviews"`
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+    "net/http"
+    "strconv"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/services"
+
+    "github.com/gin-gonic/gin"
+)
+
+type NotificationHandler struct {
+    notificationService *services.NotificationService
+}
+
+func NewNotificationHandler(notificationService *services.NotificationService) *NotificationHandler {
+    return &NotificationHandler{notificationService: notificationService}
+}
+
+// GetNotifications godoc
+// @Summary Уведомления
+// @Description Возвращает уведомления пользователя, начиная с новых, и количество непрочитанных
+// @Tags notifications
+// @Produce json
+// @Security ApiKeyAuth
+// @Param unread query bool false "Только непрочитанные"
+// @Param page query int false "Номер страницы" default(1)
+// @Param limit query int false "Количество уведомлений на странице" default(20)
+// @Success 200 {object} NotificationsResponse "Уведомления"
+// @Failure 400 {object} ErrorResponse "Неверные параметры запроса"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /notifications [get]
+func (h *NotificationHandler) GetNotifications(c *gin.Context) {
+    userID := c.GetInt("userID")
+
+    var filters models.NotificationFilters
+    if err := c.ShouldBindQuery(&filters); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if filters.Page == 0 {
+        filters.Page = 1
+    }
+    if filters.Limit == 0 {
+        filters.Limit = 20
+    }
+
+    notifications, total, unread, err := h.notificationService.GetNotifications(c.Request.Context(), userID, filters)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get notifications"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "notifications": notifications,
+        "unread":        unread,
+        "total":         total,
+        "page":          filters.Page,
+        "limit":         filters.Limit,
+        "hasMore":       (filters.Page * filters.Limit) < total,
+    })
+}
+
+// MarkNotificationRead godoc
+// @Summary Прочитать уведомление
+// @Description Отмечает уведомление прочитанным
+// @Tags notifications
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID уведомления"
+// @Success 200 {object} SuccessResponse "Уведомление прочитано"
+// @Failure 400 {object} InvalidIDErrorResponse "Неверный ID"
+// @Failure 404 {object} ErrorResponse "Уведомление не найдено"
+// @Router /notifications/{id}/read [post]
+func (h *NotificationHandler) MarkNotificationRead(c *gin.Context) {
+    userID := c.GetInt("userID")
+    notificationID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification ID"})
+        return
+    }
+
+    if err := h.notificationService.MarkRead(c.Request.Context(), userID, notificationID); err != nil {
+        if err.Error() == "notification not found" {
+            c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+            return
+        }
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark notification as read"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"message": "Notification marked as read"})
+}
+
+// MarkAllNotificationsRead godoc
+// @Summary Прочитать все уведомления
+// @Description Отмечает прочитанными все уведомления пользователя
+// @Tags notifications
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} MarkAllReadResponse "Уведомления прочитаны"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /notifications/read-all [post]
+func (h *NotificationHandler) MarkAllNotificationsRead(c *gin.Context) {
+    userID := c.GetInt("userID")
+
+    count, err := h.notificationService.MarkAllRead(c.Request.Context(), userID)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark notifications as read"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Notifications marked as read",
+        "count":   count,
+    })
+}
+
+// Response models for Swagger
+
+// NotificationsResponse represents notifications list
+// @Description Список уведомлений
+type NotificationsResponse struct {
+    Notifications []models.Notification `json:"notifications"`
+    Unread        int                   `json:"unread" example:"2"`
+    Total         int                   `json:"total" example:"10"`
+    Page          int                   `json:"page" example:"1"`
+    Limit         int                   `json:"limit" example:"20"`
+    HasMore       bool                  `json:"hasMore" example:"false"`
+}
+
+// MarkAllReadResponse represents mark all read response
+// @Description Ответ на отметку всех уведомлений прочитанными
+type MarkAllReadResponse struct {
+    Message string `json:"message" example:"Notifications marked as read"`
+    Count   int    `json:"count" example:"3"`
+}
@@ -4,6 +4,7 @@ import (
     "net/http"
     "strconv"
 
+    "paydeya-backend/internal/models"
     "paydeya-backend/internal/services"
 
     "github.com/gin-gonic/gin"
@@ -41,7 +42,7 @@ func (h *ProgressHandler) GetProgress(c *gin.Context) {
 
 // MarkMaterialComplete godoc
 // @Summary Отметить материал как завершенный
-// @Description Отмечает материал как завершенный с оценкой и временем изучения
+// @Description Отмечает материал как завершенный со временем изучения и ответами на вопросы (номерами выбранных вариантов: правильность проверяет сервер по вариантам quiz-блока). Оценку 1-5 вычисляет сервер по доле верно решенных вопросов и упражнений; в материале без них оценки нет. Ответы на упражнения с кодом здесь не принимаются: они засчитываются проверкой решения, и пока не решены все упражнения материала, возвращается 409
 // @Tags progress
 // @Accept json
 // @Produce json
@@ -50,6 +51,9 @@ func (h *ProgressHandler) GetProgress(c *gin.Context) {
 // @Param input body MarkCompleteRequest true "Данные завершения"
 // @Success 200 {object} MarkCompleteResponse "Материал отмечен как завершенный"
 // @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} MaterialNotFoundErrorResponse "Материал не найден"
+// @Failure 409 {object} ErrorResponse "Не решены упражнения с кодом"
 // @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
 // @Router /student/materials/{id}/complete [post]
 func (h *ProgressHandler) MarkMaterialComplete(c *gin.Context) {
@@ -66,9 +70,9 @@ func (h *ProgressHandler) MarkMaterialComplete(c *gin.Context) {
         return
     }
 
-    err = h.progressService.MarkMaterialComplete(c.Request.Context(), userID, materialID, req.TimeSpent, req.Grade)
+    err = h.progressService.MarkMaterialComplete(c.Request.Context(), userID, materialID, req.TimeSpent, req.Answers)
     if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark material as complete"})
+        respondMaterialError(c, err)
         return
     }
 
@@ -78,6 +82,74 @@ func (h *ProgressHandler) MarkMaterialComplete(c *gin.Context) {
     })
 }
 
+// RecordBlockProgress godoc
+// @Summary Сохранить прогресс внутри материала
+// @Description Сохраняет последний просмотренный блок (позицию для продолжения) и время, проведенное на блоках. Блоки, которых нет в материале, игнорируются
+// @Tags progress
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID материала"
+// @Param input body BlockProgressRequest true "Просмотренные блоки"
+// @Success 200 {object} models.MaterialProgress "Прогресс внутри материала"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} MaterialNotFoundErrorResponse "Материал или блок не найден"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /student/materials/{id}/progress [post]
+func (h *ProgressHandler) RecordBlockProgress(c *gin.Context) {
+    userID := c.GetInt("userID")
+    materialID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+        return
+    }
+
+    var req BlockProgressRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    progress, err := h.progressService.RecordBlockProgress(c.Request.Context(), userID, materialID, req.LastBlockID, req.Blocks)
+    if err != nil {
+        respondMaterialError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, progress)
+}
+
+// GetMaterialProgress godoc
+// @Summary Получить прогресс внутри материала
+// @Description Возвращает долю просмотренных блоков материала и блок, с которого продолжить изучение. Упражнение с кодом учитывается, когда решение прошло все тесты
+// @Tags progress
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID материала"
+// @Success 200 {object} models.MaterialProgress "Прогресс внутри материала"
+// @Failure 400 {object} InvalidIDErrorResponse "Неверный ID"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} MaterialNotFoundErrorResponse "Материал не найден"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /student/materials/{id}/progress [get]
+func (h *ProgressHandler) GetMaterialProgress(c *gin.Context) {
+    userID := c.GetInt("userID")
+    materialID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+        return
+    }
+
+    progress, err := h.progressService.GetMaterialProgress(c.Request.Context(), userID, materialID)
+    if err != nil {
+        respondMaterialError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, progress)
+}
+
 // GetFavorites godoc
 // @Summary Получить избранные материалы
 // @Description Возвращает список избранных материалов пользователя
@@ -148,8 +220,15 @@ func (h *ProgressHandler) ToggleFavorite(c *gin.Context) {
 // MarkCompleteRequest represents mark material complete request
 // @Description Запрос на отметку материала как завершенного
 type MarkCompleteRequest struct {
-    TimeSpent int     `json:"timeSpent" binding:"required" example:"3600"`
-    Grade     float64 `json:"grade" binding:"required,min=1,max=5" example:"4.5"`
+    TimeSpent int                 `json:"timeSpent" binding:"required" example:"3600"`
+    Answers   []models.QuizAnswer `json:"answers" binding:"omitempty,dive"` // ответы на вопросы материала
+}
+
+// BlockProgressRequest represents block progress request
+// @Description Запрос на сохранение прогресса внутри материала
+type BlockProgressRequest struct {
+    LastBlockID string             `json:"lastBlockId" binding:"required" example:"block_123"` // позиция для продолжения
+    Blocks      []models.BlockTime `json:"blocks" binding:"omitempty,max=500,dive"`            // время на просмотренных блоках
 }
 
 // MarkCompleteResponse represents mark material complete response
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+    "net/http"
+    "strconv"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/services"
+
+    "github.com/gin-gonic/gin"
+)
+
+type RatingHandler struct {
+    ratingService *services.RatingService
+}
+
+func NewRatingHandler(ratingService *services.RatingService) *RatingHandler {
+    return &RatingHandler{ratingService: ratingService}
+}
+
+// RateMaterial godoc
+// @Summary Оценить материал
+// @Description Сохраняет оценку материала (1-5) и необязательный отзыв. Доступно только после завершения материала; повторная отправка заменяет оценку и отзыв
+// @Tags progress
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID материала"
+// @Param input body models.RateMaterialRequest true "Оценка"
+// @Success 200 {object} RateMaterialResponse "Оценка сохранена"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса или свой материал"
+// @Failure 403 {object} ForbiddenErrorResponse "Материал не завершен"
+// @Failure 404 {object} MaterialNotFoundErrorResponse "Материал не найден"
+// @Router /student/materials/{id}/rating [put]
+func (h *RatingHandler) RateMaterial(c *gin.Context) {
+    userID := c.GetInt("userID")
+    materialID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+        return
+    }
+
+    var req models.RateMaterialRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    rating, summary, err := h.ratingService.RateMaterial(c.Request.Context(), userID, materialID, &req)
+    if err != nil {
+        respondRatingError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Rating saved successfully",
+        "rating":  rating,
+        "summary": summary,
+    })
+}
+
+// GetReviews godoc
+// @Summary Отзывы о материале
+// @Description Возвращает отзывы учеников об опубликованном материале, начиная с последних, и сводку оценок (включая оценки без отзыва)
+// @Tags catalog
+// @Produce json
+// @Param id path int true "ID материала"
+// @Param page query int false "Номер страницы" default(1)
+// @Param limit query int false "Количество отзывов на странице" default(20)
+// @Success 200 {object} ReviewsResponse "Отзывы"
+// @Failure 400 {object} ErrorResponse "Неверные параметры запроса"
+// @Failure 404 {object} MaterialNotFoundErrorResponse "Материал не найден"
+// @Router /catalog/materials/{id}/reviews [get]
+func (h *RatingHandler) GetReviews(c *gin.Context) {
+    materialID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+        return
+    }
+
+    var filters models.ReviewFilters
+    if err := c.ShouldBindQuery(&filters); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if filters.Page == 0 {
+        filters.Page = 1
+    }
+    if filters.Limit == 0 {
+        filters.Limit = 20
+    }
+
+    reviews, total, summary, err := h.ratingService.GetReviews(c.Request.Context(), materialID, filters)
+    if err != nil {
+        respondRatingError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "reviews": reviews,
+        "summary": summary,
+        "total":   total,
+        "page":    filters.Page,
+        "limit":   filters.Limit,
+        "hasMore": (filters.Page * filters.Limit) < total,
+    })
+}
+
+// RespondToReview godoc
+// @Summary Ответить на отзыв
+// @Description Сохраняет ответ владельца материала на отзыв ученика (повторный ответ заменяет предыдущий). Ученик получает уведомление
+// @Tags materials
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID материала"
+// @Param ratingId path int true "ID оценки"
+// @Param input body models.AuthorResponseRequest true "Ответ"
+// @Success 200 {object} RatingResponse "Ответ сохранен"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса или оценка без отзыва"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ErrorResponse "Материал или оценка не найдены"
+// @Router /materials/{id}/ratings/{ratingId}/response [put]
+func (h *RatingHandler) RespondToReview(c *gin.Context) {
+    userID := c.GetInt("userID")
+    materialID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+        return
+    }
+    ratingID, err := strconv.Atoi(c.Param("ratingId"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rating ID"})
+        return
+    }
+
+    var req models.AuthorResponseRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    rating, err := h.ratingService.RespondToReview(c.Request.Context(), userID, materialID, ratingID, &req)
+    if err != nil {
+        respondRatingError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Response saved successfully",
+        "rating":  rating,
+    })
+}
+
+func respondRatingError(c *gin.Context, err error) {
+    switch err.Error() {
+    case "rating not found":
+        c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+    case "cannot rate own material", "rating has no review", "response is required":
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+    case "material is not completed":
+        c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+    default:
+        respondMaterialError(c, err)
+    }
+}
+
+// Response models for Swagger
+
+// RateMaterialResponse represents saved rating
+// @Description Ответ на оценку материала
+type RateMaterialResponse struct {
+    Message string                `json:"message" example:"Rating saved successfully"`
+    Rating  models.MaterialRating `json:"rating"`
+    Summary models.RatingSummary  `json:"summary"`
+}
+
+// ReviewsResponse represents material reviews
+// @Description Отзывы о материале
+type ReviewsResponse struct {
+    Reviews []models.MaterialRating `json:"reviews"`
+    Summary models.RatingSummary    `json:"summary"`
+    Total   int                     `json:"total" example:"12"`
+    Page    int                     `json:"page" example:"1"`
+    Limit   int                     `json:"limit" example:"20"`
+    HasMore bool                    `json:"hasMore" example:"false"`
+}
+
+// RatingResponse represents rating with author response
+// @Description Ответ с оценкой и ответом автора
+type RatingResponse struct {
+    Message string                `json:"message" example:"Response saved successfully"`
+    Rating  models.MaterialRating `json:"rating"`
+}
@@ -0,0 +1,208 @@
+package handlers
+
+import (
+    "net/http"
+    "strconv"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/services"
+
+    "github.com/gin-gonic/gin"
+)
+
+type ReportHandler struct {
+    reportService *services.ReportService
+}
+
+func NewReportHandler(reportService *services.ReportService) *ReportHandler {
+    return &ReportHandler{reportService: reportService}
+}
+
+// CreateReport godoc
+// @Summary Пожаловаться на материал
+// @Description Отправляет модераторам жалобу на ошибку или недопустимое содержание материала либо отдельного блока. На свой материал пожаловаться нельзя; открытая жалоба на материал у пользователя может быть только одна, число жалоб в час ограничено
+// @Tags materials
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID материала"
+// @Param input body models.CreateReportRequest true "Жалоба"
+// @Success 201 {object} ReportResponse "Жалоба отправлена"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} MaterialNotFoundErrorResponse "Материал или блок не найден"
+// @Failure 409 {object} ErrorResponse "Жалоба на материал уже отправлена"
+// @Failure 429 {object} ErrorResponse "Слишком много жалоб"
+// @Router /materials/{id}/reports [post]
+func (h *ReportHandler) CreateReport(c *gin.Context) {
+    userID := c.GetInt("userID")
+    materialID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+        return
+    }
+
+    var req models.CreateReportRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    report, err := h.reportService.CreateReport(c.Request.Context(), userID, materialID, &req)
+    if err != nil {
+        respondReportError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusCreated, gin.H{
+        "message": "Report submitted",
+        "report":  report,
+    })
+}
+
+// GetReports godoc
+// @Summary Очередь жалоб
+// @Description Возвращает жалобы с указанным статусом: открытые - начиная со старых, рассмотренные - с последних. openReports - число открытых жалоб на тот же материал
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param status query string false "Статус жалоб" Enums(open, dismissed, resolved) default(open)
+// @Param category query string false "Категория" Enums(error, inappropriate, copyright, spam, other)
+// @Param materialId query int false "Жалобы на материал"
+// @Param page query int false "Номер страницы" default(1)
+// @Param limit query int false "Количество жалоб на странице" default(20)
+// @Success 200 {object} ReportsResponse "Список жалоб"
+// @Failure 400 {object} ErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /admin/reports [get]
+func (h *ReportHandler) GetReports(c *gin.Context) {
+    var filters models.ReportFilters
+    if err := c.ShouldBindQuery(&filters); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if filters.Page == 0 {
+        filters.Page = 1
+    }
+    if filters.Limit == 0 {
+        filters.Limit = 20
+    }
+
+    reports, total, err := h.reportService.GetReports(c.Request.Context(), filters)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get reports"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "reports": reports,
+        "total":   total,
+        "page":    filters.Page,
+        "limit":   filters.Limit,
+        "hasMore": (filters.Page * filters.Limit) < total,
+    })
+}
+
+// GetReport godoc
+// @Summary Получить жалобу
+// @Description Возвращает жалобу с решением модератора
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID жалобы"
+// @Success 200 {object} models.MaterialReport "Жалоба"
+// @Failure 400 {object} InvalidIDErrorResponse "Неверный ID"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ErrorResponse "Жалоба не найдена"
+// @Router /admin/reports/{id} [get]
+func (h *ReportHandler) GetReport(c *gin.Context) {
+    reportID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid report ID"})
+        return
+    }
+
+    report, err := h.reportService.GetReport(c.Request.Context(), reportID)
+    if err != nil {
+        respondReportError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, report)
+}
+
+// ResolveReport godoc
+// @Summary Рассмотреть жалобу
+// @Description Выполняет действие по жалобе: dismiss - отклонить, unpublish - вернуть материал автору в черновики, warn_author - отправить автору предупреждение (нужен comment), block_author - заблокировать автора (comment становится причиной блокировки). unpublish и block_author закрывают все открытые жалобы на материал. Авторы жалоб получают уведомление о решении
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID жалобы"
+// @Param input body models.ResolveReportRequest true "Решение"
+// @Success 200 {object} ReportResponse "Жалоба рассмотрена"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 403 {object} ForbiddenErrorResponse "Доступ запрещен"
+// @Failure 404 {object} ErrorResponse "Жалоба не найдена"
+// @Failure 409 {object} ErrorResponse "Жалоба уже рассмотрена"
+// @Router /admin/reports/{id}/resolve [post]
+func (h *ReportHandler) ResolveReport(c *gin.Context) {
+    moderatorID := c.GetInt("userID")
+    reportID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid report ID"})
+        return
+    }
+
+    var req models.ResolveReportRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    report, err := h.reportService.ResolveReport(c.Request.Context(), moderatorID, reportID, &req)
+    if err != nil {
+        respondReportError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Report resolved",
+        "report":  report,
+    })
+}
+
+func respondReportError(c *gin.Context, err error) {
+    switch err.Error() {
+    case "report not found":
+        c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+    case "cannot report own material", "comment is required":
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+    case "report already exists", "report is not open":
+        c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+    case "too many reports":
+        c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+    default:
+        respondMaterialError(c, err)
+    }
+}
+
+// Response models for Swagger
+
+// ReportResponse represents report response
+// @Description Ответ с жалобой
+type ReportResponse struct {
+    Message string                `json:"message" example:"Report submitted"`
+    Report  models.MaterialReport `json:"report"`
+}
+
+// ReportsResponse represents reports list
+// @Description Список жалоб
+type ReportsResponse struct {
+    Reports []models.MaterialReport `json:"reports"`
+    Total   int                     `json:"total" example:"4"`
+    Page    int                     `json:"page" example:"1"`
+    Limit   int                     `json:"limit" example:"20"`
+    HasMore bool                    `json:"hasMore" example:"false"`
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+    "encoding/json"
+    "net/http"
+    "strconv"
+    "time"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/services"
+
+    "github.com/gin-gonic/gin"
+)
+
+type XAPIHandler struct {
+    xapiService *services.XAPIService
+}
+
+func NewXAPIHandler(xapiService *services.XAPIService) *XAPIHandler {
+    return &XAPIHandler{xapiService: xapiService}
+}
+
+// GetStatements godoc
+// @Summary Получить xAPI-выражения
+// @Description Встроенное хранилище xAPI (LRS): возвращает выражения experienced, answered, completed и favorited. Администратор видит все выражения, остальные - свои и о своих материалах
+// @Tags xapi
+// @Produce json
+// @Security ApiKeyAuth
+// @Param statementId query string false "ID выражения - вернуть одно выражение"
+// @Param verb query string false "IRI глагола" example(http://adlnet.gov/expapi/verbs/completed)
+// @Param activity query string false "IRI объекта" example(https://paydeya.ru/materials/42)
+// @Param agent query string false "Участник в формате xAPI Agent" example({"mbox":"mailto:petr@example.com"})
+// @Param since query string false "Сохраненные после (RFC 3339)"
+// @Param until query string false "Сохраненные не позже (RFC 3339)"
+// @Param limit query int false "Количество выражений (до 500)" default(100)
+// @Param ascending query bool false "Сначала старые" default(false)
+// @Success 200 {object} XAPIStatementsResponse "Выражения"
+// @Failure 400 {object} InvalidParametersErrorResponse "Неверные параметры запроса"
+// @Failure 404 {object} ErrorResponse "Выражение не найдено"
+// @Failure 500 {object} InternalErrorResponse "Ошибка сервера"
+// @Router /xapi/statements [get]
+func (h *XAPIHandler) GetStatements(c *gin.Context) {
+    c.Header("X-Experience-API-Version", "1.0.3")
+
+    filter := &models.XAPIStatementFilter{
+        StatementID: c.Query("statementId"),
+        Verb:        c.Query("verb"),
+        Activity:    c.Query("activity"),
+        ViewerID:    c.GetInt("userID"),
+        All:         c.GetString("userRole") == "admin",
+        Ascending:   c.Query("ascending") == "true",
+    }
+    filter.Limit, _ = strconv.Atoi(c.Query("limit"))
+
+    if agent := c.Query("agent"); agent != "" {
+        var actor models.XAPIActor
+        if err := json.Unmarshal([]byte(agent), &actor); err != nil || actor.Mbox == "" {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent"})
+            return
+        }
+        filter.AgentMbox = actor.Mbox
+    }
+
+    for param, target := range map[string]**time.Time{"since": &filter.Since, "until": &filter.Until} {
+        if value := c.Query(param); value != "" {
+            t, err := time.Parse(time.RFC3339, value)
+            if err != nil {
+                c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + param})
+                return
+            }
+            *target = &t
+        }
+    }
+
+    statements, err := h.xapiService.QueryStatements(c.Request.Context(), filter)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get statements"})
+        return
+    }
+
+    if filter.StatementID != "" {
+        if len(statements) == 0 {
+            c.JSON(http.StatusNotFound, gin.H{"error": "Statement not found"})
+            return
+        }
+        c.JSON(http.StatusOK, statements[0])
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "statements": statements,
+        "more":       "",
+    })
+}
+
+// Response models for Swagger
+
+// XAPIStatementsResponse represents xAPI StatementResult
+// @Description Результат запроса выражений (xAPI StatementResult)
+type XAPIStatementsResponse struct {
+    Statements []models.XAPIStatement `json:"statements"`
+    More       string                 `json:"more" example:""`
+}
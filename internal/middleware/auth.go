@@ -13,6 +13,12 @@ func AuthMiddleware(authService *services.AuthService) gin.HandlerFunc {
     return func(c *gin.Context) {
         // Получаем токен из заголовка
         authHeader := c.GetHeader("Authorization")
+
+        // Браузерный WebSocket не умеет передавать заголовки - принимаем токен из query
+        if authHeader == "" && strings.EqualFold(c.GetHeader("Upgrade"), "websocket") && c.Query("token") != "" {
+            authHeader = "Bearer " + c.Query("token")
+        }
+
         if authHeader == "" {
             c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
             c.Abort()
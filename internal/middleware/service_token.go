@@ -0,0 +1,24 @@
+package middleware
+
+import (
+    "crypto/subtle"
+    "net/http"
+
+    "github.com/gin-gonic/gin"
+)
+
+// ServiceTokenMiddleware пускает только запросы с общим секретом внутреннего сервиса
+// (API -> сервис запуска кода) в заголовке Authorization: Bearer <token>
+func ServiceTokenMiddleware(token string) gin.HandlerFunc {
+    expected := []byte("Bearer " + token)
+
+    return func(c *gin.Context) {
+        if subtle.ConstantTimeCompare([]byte(c.GetHeader("Authorization")), expected) != 1 {
+            c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid service token"})
+            c.Abort()
+            return
+        }
+
+        c.Next()
+    }
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+    "net/http"
+
+    "github.com/gin-gonic/gin"
+)
+
+// TeacherMiddleware пропускает только преподавателей и администраторов
+func TeacherMiddleware() gin.HandlerFunc {
+    return func(c *gin.Context) {
+        userRole := c.GetString("userRole")
+
+        if userRole != "teacher" && userRole != "admin" {
+            c.JSON(http.StatusForbidden, gin.H{
+                "error": "Access denied. Teacher rights required",
+            })
+            c.Abort()
+            return
+        }
+
+        c.Next()
+    }
+}
@@ -0,0 +1,95 @@
+package models
+
+import "time"
+
+// QuizAnswer represents student's answer to quiz block
+// @Description Ответ ученика на вопрос (quiz-блок) материала
+type QuizAnswer struct {
+    BlockID  string `json:"blockId" binding:"required" example:"block_123"`
+    Selected []int  `json:"selected" binding:"required,dive,min=0" example:"1"` // номера выбранных вариантов, начиная с 0
+}
+
+// QuizResult - ответ на вопрос, проверенный сервером по правильным вариантам quiz-блока
+// (или решение упражнения с кодом, проверенное тестами)
+type QuizResult struct {
+    BlockID string
+    Correct bool
+}
+
+// MaterialStats represents material completion summary
+// @Description Сводная статистика прохождения материала
+type MaterialStats struct {
+    MaterialID       int        `json:"materialId" example:"1"`
+    Title            string     `json:"title" example:"Основы алгебры"`
+    Status           string     `json:"status" example:"published"`
+    Completions      int        `json:"completions" example:"42"`
+    AverageGrade     *float64   `json:"averageGrade,omitempty" example:"4.3"`
+    AverageTimeSpent int        `json:"averageTimeSpent" example:"1260"` // в секундах
+    LastCompletedAt  *time.Time `json:"lastCompletedAt,omitempty" example:"2023-09-14T18:30:00Z"`
+}
+
+// MaterialAnalytics represents detailed material analytics
+// @Description Подробная аналитика материала для преподавателя
+type MaterialAnalytics struct {
+    MaterialStats
+    StartedCount int               `json:"startedCount" example:"50"` // приступили к материалу
+    TimeSpent    []TimeSpentBucket `json:"timeSpent"`
+    Questions    []QuestionStats   `json:"questions"`
+    DropOff      []BlockDropOff    `json:"dropOff"`
+}
+
+// TimeSpentBucket represents time spent distribution bucket
+// @Description Интервал распределения времени изучения
+type TimeSpentBucket struct {
+    Label      string `json:"label" example:"5-15 мин"`
+    MinSeconds int    `json:"minSeconds" example:"300"`
+    MaxSeconds *int   `json:"maxSeconds,omitempty" example:"900"` // нет - без верхней границы
+    Count      int    `json:"count" example:"12"`
+}
+
+// QuestionStats represents quiz question success rate
+// @Description Успешность ответов на вопрос материала
+type QuestionStats struct {
+    BlockID     string  `json:"blockId" example:"block_123"`
+    Position    int     `json:"position" example:"4"`
+    Question    string  `json:"question,omitempty" example:"Чему равен x в уравнении 2x = 6?"`
+    Attempts    int     `json:"attempts" example:"40"`
+    Correct     int     `json:"correct" example:"31"`
+    SuccessRate float64 `json:"successRate" example:"77.5"` // 0-100%
+}
+
+// BlockDropOff represents how many students reached block
+// @Description Доля учеников, дошедших до блока материала
+type BlockDropOff struct {
+    BlockID     string  `json:"blockId" example:"block_123"`
+    Type        string  `json:"type" example:"quiz"`
+    Position    int     `json:"position" example:"4"`
+    Reached     int     `json:"reached" example:"38"`
+    ReachedRate float64 `json:"reachedRate" example:"76"` // 0-100% от приступивших
+}
+
+// StudentAnalytics represents student drill-down for teacher
+// @Description Аналитика ученика для преподавателя
+type StudentAnalytics struct {
+    UserID         int                    `json:"userId" example:"42"`
+    FullName       string                 `json:"fullName" example:"Петр Сидоров"`
+    Email          string                 `json:"email" example:"petr@example.com"`
+    Classes        []string               `json:"classes" example:"8Б алгебра"`
+    CompletedCount int                    `json:"completedCount" example:"12"`
+    AverageGrade   *float64               `json:"averageGrade,omitempty" example:"4.1"`
+    TimeSpent      int                    `json:"timeSpent" example:"15400"` // в секундах
+    Materials      []StudentMaterialStats `json:"materials"`
+}
+
+// StudentMaterialStats represents student result on material
+// @Description Результат ученика по материалу
+type StudentMaterialStats struct {
+    MaterialID   int        `json:"materialId" example:"1"`
+    Title        string     `json:"title" example:"Основы алгебры"`
+    Completed    bool       `json:"completed" example:"true"`
+    Grade        *float64   `json:"grade,omitempty" example:"4.5"`
+    TimeSpent    int        `json:"timeSpent" example:"1200"` // в секундах
+    CompletedAt  *time.Time `json:"completedAt,omitempty" example:"2023-09-14T18:30:00Z"`
+    QuizAnswered int        `json:"quizAnswered" example:"5"`
+    QuizCorrect  int        `json:"quizCorrect" example:"4"`
+}
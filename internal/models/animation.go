@@ -0,0 +1,39 @@
+package models
+
+// AnimationError represents invalid animation step
+// @Description Ошибка в анимации блока. Step - номер шага с нуля, -1 - ошибка в настройках анимации
+type AnimationError struct {
+    BlockID string `json:"blockId,omitempty" example:"block_123"`
+    Step    int    `json:"step" example:"0"`
+    Field   string `json:"field" example:"element"`
+    Message string `json:"message" example:"unknown element option-5"`
+}
+
+// AnimationTimeline represents resolved block animation
+// @Description Анимация блока, разложенная по кадрам: состояние каждого элемента до анимации (кадр 0) и после каждого шага
+type AnimationTimeline struct {
+    BlockID  string           `json:"blockId" example:"block_123"`
+    Trigger  string           `json:"trigger" example:"click"` // click - шаг по щелчку, auto - шаги сами с интервалом delay
+    Delay    int              `json:"delay" example:"1000"`
+    Elements []string         `json:"elements" example:"block,question,option-1,option-2"` // элементы блока, доступные анимации
+    Frames   []AnimationFrame `json:"frames"`
+    Errors   []AnimationError `json:"errors,omitempty"` // шаги сохраненной анимации, пропущенные как неверные
+}
+
+// AnimationFrame represents animation state after step
+// @Description Кадр анимации. At - время кадра от начала в миллисекундах (только для trigger=auto)
+type AnimationFrame struct {
+    Index  int                     `json:"index" example:"1"`
+    At     *int                    `json:"at,omitempty" example:"1000"`
+    Step   *AnimationStep          `json:"step,omitempty"`
+    States []AnimationElementState `json:"states"`
+}
+
+// AnimationElementState represents element state in frame
+// @Description Состояние элемента в кадре. Подсветка действует только в кадре своего шага
+type AnimationElementState struct {
+    Element     string                 `json:"element" example:"option-2"`
+    Visible     bool                   `json:"visible" example:"true"`
+    Highlighted bool                   `json:"highlighted" example:"false"`
+    Style       map[string]interface{} `json:"style,omitempty"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// MaterialBundle represents portable material bundle
+// @Description Переносимый пакет материала (material.json в ZIP-архиве или отдельный JSON)
+type MaterialBundle struct {
+    SchemaVersion int            `json:"schemaVersion" example:"1"`
+    ExportedAt    time.Time      `json:"exportedAt" example:"2023-09-01T10:00:00Z"`
+    Material      BundleMaterial `json:"material"`
+    Media         []BundleMedia  `json:"media,omitempty"`
+}
+
+// BundleMaterial represents material data inside bundle
+// @Description Материал в пакете. Ссылки на файлы из архива заменены на пути вида media/images/1.png
+type BundleMaterial struct {
+    Title       string   `json:"title" example:"Основы алгебры"`
+    Subject     string   `json:"subject" example:"math"`
+    Description string   `json:"description" example:"Переменные, выражения и линейные уравнения"`
+    Level       *string  `json:"level,omitempty" example:"beginner"`
+    Tags        []string `json:"tags" example:"уравнения,8 класс"`
+    CoverURL    string   `json:"coverUrl,omitempty" example:"media/images/1.png"`
+    Language    string   `json:"language" example:"ru"`
+    Duration    int      `json:"duration" example:"45"`
+    Blocks      []Block  `json:"blocks"`
+}
+
+// BundleMedia represents media file inside bundle
+// @Description Файл в архиве пакета
+type BundleMedia struct {
+    Path        string `json:"path" example:"media/images/1.png"`
+    OriginalURL string `json:"originalUrl" example:"https://paydeya-media.storage.yandexcloud.net/images/7/3f2a.png"`
+}
+
+// ImportDocumentRequest represents document import form fields
+// @Description Параметры импорта урока из Markdown или DOCX
+type ImportDocumentRequest struct {
+    Subject  string  `form:"subject" binding:"required" example:"math"`
+    Title    string  `form:"title" example:"Линейные уравнения"`
+    Level    *string `form:"level" binding:"omitempty,oneof=beginner intermediate advanced" example:"beginner"`
+    Language string  `form:"language" binding:"omitempty,max=10" example:"ru"`
+}
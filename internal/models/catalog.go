@@ -6,9 +6,25 @@ type CatalogMaterial struct {
     ID            int     `json:"id"`
     Title         string  `json:"title"`
     Subject       string  `json:"subject"`  // ← строка, а не int
+    Description   string   `json:"description"`
+    Level         *string  `json:"level,omitempty"`
+    Tags          []string `json:"tags"`
+    CoverURL      string   `json:"coverUrl,omitempty"`
+    Language      string   `json:"language"`
+    Duration      int      `json:"duration"` // в минутах
     Author        Author  `json:"author"`
     Rating        float64 `json:"rating"`
-    StudentsCount int     `json:"studentsCount"`
+    RatingsCount  int     `json:"ratingsCount"`
+    StudentsCount int     `json:"studentsCount"` // начали или завершили изучение
+    ForkedFrom    *MaterialOrigin `json:"forkedFrom,omitempty"`
+}
+
+// MaterialOrigin represents original material of a fork
+// @Description Исходный материал, на основе которого создана копия
+type MaterialOrigin struct {
+    ID     int    `json:"id" example:"7"`
+    Title  string `json:"title" example:"Python: первые шаги в программировании"`
+    Author Author `json:"author"`
 }
 // Author represents material author
 // @Description Автор материала
@@ -42,6 +58,9 @@ type CatalogFilters struct {
     Search  string `form:"search" example:"алгебра"`
     Subject string `form:"subject" example:"math"`
     Level   string `form:"level" example:"beginner"`
+    Tags    []string `form:"tags" example:"уравнения"` // материал должен иметь все указанные теги
+    Language    string `form:"language" example:"ru"`
+    MaxDuration int    `form:"maxDuration" example:"60"` // в минутах
     Page    int    `form:"page" example:"1"`
     Limit   int    `form:"limit" example:"20"`
 }
@@ -51,4 +70,11 @@ type CatalogFilters struct {
 type TeacherFilters struct {
     Search  string `form:"search" example:"математика"`
     Subject string `form:"subject" example:"math"`
-}
\ No newline at end of file
+}
+
+// Tag represents material tag with usage count
+// @Description Тег материалов
+type Tag struct {
+    Name           string `json:"name" example:"уравнения"`
+    MaterialsCount int    `json:"materialsCount" example:"12"`
+}
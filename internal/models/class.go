@@ -0,0 +1,116 @@
+package models
+
+import "time"
+
+// Class represents teacher-owned group of students
+// @Description Класс (учебная группа) преподавателя
+type Class struct {
+    ID            int       `json:"id" example:"1"`
+    Name          string    `json:"name" example:"8Б алгебра"`
+    Description   string    `json:"description" example:"Группа углубленного изучения"`
+    TeacherID     int       `json:"teacherId" example:"12"`
+    TeacherName   string    `json:"teacherName,omitempty" example:"Мария Петрова"`
+    JoinCode      string    `json:"joinCode,omitempty" example:"K7M2QX9P"` // виден только преподавателю
+    StudentsCount int       `json:"studentsCount" example:"24"`
+    CreatedAt     time.Time `json:"createdAt" example:"2023-09-01T09:00:00Z"`
+    UpdatedAt     time.Time `json:"updatedAt" example:"2023-09-01T09:00:00Z"`
+}
+
+// ClassMember represents student in class roster
+// @Description Ученик класса
+type ClassMember struct {
+    UserID    int       `json:"userId" example:"42"`
+    FullName  string    `json:"fullName" example:"Петр Сидоров"`
+    Email     string    `json:"email" example:"petr@example.com"`
+    AvatarURL *string   `json:"avatarUrl,omitempty" example:"/uploads/avatars/avatar_42.jpg"`
+    JoinedAt  time.Time `json:"joinedAt" example:"2023-09-02T10:00:00Z"`
+}
+
+// Assignment represents material or course assigned to class
+// @Description Задание класса: материал или курс со сроком сдачи
+type Assignment struct {
+    ID         int        `json:"id" example:"7"`
+    ClassID    int        `json:"classId" example:"1"`
+    ClassName  string     `json:"className,omitempty" example:"8Б алгебра"`
+    MaterialID *int       `json:"materialId,omitempty" example:"42"`
+    CourseID   *int       `json:"courseId,omitempty"`
+    Title      string     `json:"title" example:"Решение линейных уравнений"`
+    Note       string     `json:"note,omitempty" example:"Обратите внимание на задачи 3-5"`
+    DueAt      *time.Time `json:"dueAt,omitempty" example:"2023-09-15T20:00:00Z"`
+    CreatedAt  time.Time  `json:"createdAt" example:"2023-09-05T09:00:00Z"`
+}
+
+// StudentAssignment represents assignment with student's completion state
+// @Description Задание с состоянием выполнения учеником
+type StudentAssignment struct {
+    Assignment
+    Status      string     `json:"status" example:"pending"` // pending, completed, overdue
+    Progress    float64    `json:"progress" example:"50"`     // 0-100%, для курса - доля пройденных материалов
+    CompletedAt *time.Time `json:"completedAt,omitempty" example:"2023-09-14T18:30:00Z"`
+    Late        bool       `json:"late" example:"false"` // выполнено после срока
+}
+
+// AssignmentReport represents assignment completion across class roster
+// @Description Выполнение задания учениками класса
+type AssignmentReport struct {
+    Assignment
+    StudentsCount  int                 `json:"studentsCount" example:"24"`
+    CompletedCount int                 `json:"completedCount" example:"18"`
+    Students       []StudentCompletion `json:"students"`
+}
+
+// StudentCompletion represents student completion of assignment
+// @Description Выполнение задания учеником
+type StudentCompletion struct {
+    UserID      int        `json:"userId" example:"42"`
+    FullName    string     `json:"fullName" example:"Петр Сидоров"`
+    Status      string     `json:"status" example:"completed"` // pending, completed, overdue
+    Progress    float64    `json:"progress" example:"100"`
+    Grade       *float64   `json:"grade,omitempty" example:"4.5"`
+    CompletedAt *time.Time `json:"completedAt,omitempty" example:"2023-09-14T18:30:00Z"`
+    Late        bool       `json:"late" example:"false"`
+}
+
+// CreateClassRequest represents create class request
+// @Description Запрос на создание класса
+type CreateClassRequest struct {
+    Name        string `json:"name" binding:"required,max=200" example:"8Б алгебра"`
+    Description string `json:"description" example:"Группа углубленного изучения"`
+}
+
+// UpdateClassRequest represents update class request
+// @Description Запрос на обновление класса. Не переданные поля не изменяются
+type UpdateClassRequest struct {
+    Name        *string `json:"name" binding:"omitempty,min=1,max=200" example:"8Б алгебра"`
+    Description *string `json:"description" example:"Группа углубленного изучения"`
+}
+
+// JoinClassRequest represents join class by code request
+// @Description Запрос на вступление в класс по коду
+type JoinClassRequest struct {
+    Code string `json:"code" binding:"required" example:"K7M2QX9P"`
+}
+
+// AddClassStudentRequest represents add student to class request
+// @Description Запрос на добавление ученика в класс (по ID или email)
+type AddClassStudentRequest struct {
+    UserID int    `json:"userId" example:"42"`
+    Email  string `json:"email" example:"petr@example.com"`
+}
+
+// CreateAssignmentRequest represents create assignment request
+// @Description Запрос на создание задания. Указывается либо материал, либо курс
+type CreateAssignmentRequest struct {
+    MaterialID *int       `json:"materialId" example:"42"`
+    CourseID   *int       `json:"courseId"`
+    Note       string     `json:"note" example:"Обратите внимание на задачи 3-5"`
+    DueAt      *time.Time `json:"dueAt" example:"2023-09-15T20:00:00Z"`
+}
+
+// UpdateAssignmentRequest represents update assignment request
+// @Description Запрос на изменение задания. Не переданные поля не изменяются, clearDueAt снимает срок
+type UpdateAssignmentRequest struct {
+    Note       *string    `json:"note" example:"Срок продлен"`
+    DueAt      *time.Time `json:"dueAt" example:"2023-09-20T20:00:00Z"`
+    ClearDueAt bool       `json:"clearDueAt" example:"false"`
+}
@@ -0,0 +1,72 @@
+package models
+
+import "time"
+
+// CodeTest represents code block test
+// @Description Тест блока с кодом: программа получает input на stdin и должна вывести expectedOutput. Скрытые тесты видят только автор и соавторы, ученику сообщается лишь, пройден ли тест
+type CodeTest struct {
+    Name           string `json:"name" example:"Сумма двух чисел"`
+    Input          string `json:"input" example:"2 3\n"`
+    ExpectedOutput string `json:"expectedOutput" example:"5\n"`
+    Hidden         bool   `json:"hidden" example:"false"`
+}
+
+// RunCodeRequest represents code run request
+// @Description Запуск кода блока с произвольным вводом
+type RunCodeRequest struct {
+    Code  string `json:"code" binding:"required,max=65536" example:"print(sum(map(int, input().split())))"`
+    Input string `json:"input" binding:"max=65536" example:"2 3\n"`
+}
+
+// SubmitCodeRequest represents code submission
+// @Description Решение упражнения для проверки тестами блока
+type SubmitCodeRequest struct {
+    Code string `json:"code" binding:"required,max=65536" example:"print(sum(map(int, input().split())))"`
+}
+
+// CodeRunnerRequest represents run request to the code runner service
+// @Description Запуск кода в отдельном сервисе запуска кода (внутренний запрос API)
+type CodeRunnerRequest struct {
+    Language string `json:"language" binding:"required" example:"python"`
+    Code     string `json:"code" binding:"required,max=65536" example:"print(sum(map(int, input().split())))"`
+    Input    string `json:"input" binding:"max=65536" example:"2 3\n"`
+}
+
+// CodeRunResult represents sandboxed run result
+// @Description Результат запуска кода в песочнице. Вывод обрезается до лимита (truncated)
+type CodeRunResult struct {
+    Stdout    string `json:"stdout" example:"5\n"`
+    Stderr    string `json:"stderr" example:""`
+    ExitCode  int    `json:"exitCode" example:"0"`
+    TimedOut  bool   `json:"timedOut" example:"false"`
+    Truncated bool   `json:"truncated" example:"false"`
+    Duration  int    `json:"duration" example:"48"` // в миллисекундах
+}
+
+// CodeTestResult represents single test result
+// @Description Результат теста. У скрытых тестов ввод, ожидаемый и фактический вывод не возвращаются
+type CodeTestResult struct {
+    Name           string `json:"name" example:"Сумма двух чисел"`
+    Hidden         bool   `json:"hidden" example:"false"`
+    Passed         bool   `json:"passed" example:"true"`
+    Input          string `json:"input,omitempty" example:"2 3\n"`
+    ExpectedOutput string `json:"expectedOutput,omitempty" example:"5\n"`
+    ActualOutput   string `json:"actualOutput,omitempty" example:"5\n"`
+    Stderr         string `json:"stderr,omitempty" example:""`
+    TimedOut       bool   `json:"timedOut,omitempty" example:"false"`
+}
+
+// CodeSubmission represents checked solution
+// @Description Решение упражнения и результаты его проверки
+type CodeSubmission struct {
+    ID          int              `json:"id" example:"1"`
+    MaterialID  int              `json:"materialId" example:"1"`
+    BlockID     string           `json:"blockId" example:"block_123"`
+    Language    string           `json:"language" example:"python"`
+    Code        string           `json:"code" example:"print(sum(map(int, input().split())))"`
+    Passed      bool             `json:"passed" example:"true"` // пройдены все тесты
+    TestsPassed int              `json:"testsPassed" example:"3"`
+    TestsTotal  int              `json:"testsTotal" example:"3"`
+    Results     []CodeTestResult `json:"results"`
+    CreatedAt   time.Time        `json:"createdAt" example:"2023-01-15T10:30:00Z"`
+}
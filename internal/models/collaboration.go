@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// BlockOperation represents ordered operation on material blocks
+// @Description Операция над блоками материала (совместное редактирование)
+type BlockOperation struct {
+    Seq        int64     `json:"seq" example:"42"`
+    MaterialID int       `json:"materialId" example:"1"`
+    UserID     int       `json:"userId" example:"3"`
+    Type       string    `json:"type" example:"update"` // add, update, delete, reorder, replace
+    BlockID    string    `json:"blockId,omitempty" example:"block_123"`
+    Block      *Block    `json:"block,omitempty"`
+    BlockIDs   []string  `json:"blockIds,omitempty" example:"block_1,block_2"`
+    Blocks     []Block   `json:"blocks,omitempty"`
+    Position   *int      `json:"position,omitempty" example:"2"`
+    CreatedAt  time.Time `json:"createdAt" example:"2023-01-15T10:30:00Z"`
+}
+
+// BlockLock represents block editing lock
+// @Description Блокировка блока на время редактирования
+type BlockLock struct {
+    BlockID   string    `json:"blockId" example:"block_123"`
+    UserID    int       `json:"userId" example:"3"`
+    UserName  string    `json:"userName" example:"Петрова Мария Ивановна"`
+    ExpiresAt time.Time `json:"expiresAt" example:"2023-01-15T10:32:00Z"`
+}
+
+// CollaborationMessage represents message sent by editor client over WebSocket
+// @Description Сообщение клиента редактора по WebSocket
+type CollaborationMessage struct {
+    Type     string   `json:"type" example:"update"` // add, update, delete, reorder, presence, lock, unlock, ping
+    BlockID  string   `json:"blockId,omitempty" example:"block_123"`
+    Block    *Block   `json:"block,omitempty"`
+    BlockIDs []string `json:"blockIds,omitempty" example:"block_1,block_2"`
+    Position *int     `json:"position,omitempty" example:"2"`
+}
+
+// CollaborationEvent represents event sent to editor clients and between backend instances
+// @Description Событие совместного редактирования
+type CollaborationEvent struct {
+    Type       string          `json:"type" example:"operation"` // snapshot, operation, ack, join, leave, presence, lock, unlock, error, pong; access_changed - только между инстансами
+    MaterialID int             `json:"materialId,omitempty" example:"1"`
+    Seq        int64           `json:"seq,omitempty" example:"42"`
+    UserID     int             `json:"userId,omitempty" example:"3"`
+    UserName   string          `json:"userName,omitempty" example:"Петрова Мария Ивановна"`
+    ClientID   string          `json:"clientId,omitempty" example:"c3f1a2b4"`
+    BlockID    string          `json:"blockId,omitempty" example:"block_123"`
+    Operation  *BlockOperation `json:"operation,omitempty"`
+    Blocks     []Block         `json:"blocks,omitempty"`
+    Locks      []BlockLock     `json:"locks,omitempty"`
+    Error      string          `json:"error,omitempty"`
+    InstanceID string          `json:"instanceId,omitempty"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Collaborator represents material co-author
+// @Description Соавтор материала
+type Collaborator struct {
+    MaterialID int       `json:"materialId" example:"1"`
+    UserID     int       `json:"userId" example:"42"`
+    Email      string    `json:"email" example:"petrova.math@school.ru"`
+    FullName   string    `json:"fullName" example:"Петрова Мария Ивановна"`
+    Role       string    `json:"role" example:"editor"` // editor, reviewer, viewer
+    InvitedBy  *int      `json:"invitedBy,omitempty" example:"3"`
+    CreatedAt  time.Time `json:"createdAt" example:"2023-01-15T10:30:00Z"`
+}
+
+// AddCollaboratorRequest represents invite collaborator request
+// @Description Запрос на приглашение соавтора (по ID или email)
+type AddCollaboratorRequest struct {
+    UserID int    `json:"userId" example:"42"`
+    Email  string `json:"email" example:"petrova.math@school.ru"`
+    Role   string `json:"role" binding:"required,oneof=editor reviewer viewer" example:"editor"`
+}
+
+// UpdateCollaboratorRequest represents change collaborator role request
+// @Description Запрос на изменение роли соавтора
+type UpdateCollaboratorRequest struct {
+    Role string `json:"role" binding:"required,oneof=editor reviewer viewer" example:"reviewer"`
+}
+
+// TransferOwnershipRequest represents ownership transfer request
+// @Description Запрос на передачу владения материалом (по ID или email)
+type TransferOwnershipRequest struct {
+    UserID int    `json:"userId" example:"42"`
+    Email  string `json:"email" example:"petrova.math@school.ru"`
+}
+
+// SharedMaterial represents material shared with the user
+// @Description Материал, к которому пользователь приглашен соавтором
+type SharedMaterial struct {
+    Material
+    Role string `json:"role" example:"editor"` // editor, reviewer, viewer
+}
@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// MaterialComment represents comment in material discussion
+// @Description Вопрос или ответ в обсуждении материала. У вопроса (parentId пуст) есть ответы в replies.
+// @Description blockId пуст у обсуждений всего материала и у обсуждений удаленных блоков
+type MaterialComment struct {
+    ID         int               `json:"id" example:"1"`
+    MaterialID int               `json:"materialId" example:"42"`
+    BlockID    *string           `json:"blockId,omitempty" example:"block_123"`
+    ParentID   *int              `json:"parentId,omitempty" example:"1"`
+    UserID     int               `json:"userId,omitempty" example:"456"`
+    UserName   string            `json:"userName,omitempty" example:"Петр Сидоров"`
+    UserAvatar string            `json:"userAvatar,omitempty" example:"/uploads/avatars/456.png"`
+    Content    string            `json:"content" example:"Почему во втором шаге меняется знак?"`
+    IsAuthor   bool              `json:"isAuthor" example:"false"` // ответ автора или соавтора материала
+    Upvotes    int               `json:"upvotes" example:"3"`
+    Upvoted    bool              `json:"upvoted" example:"false"` // текущий пользователь проголосовал
+    Resolved   bool              `json:"resolved" example:"false"`
+    Hidden     bool              `json:"hidden" example:"false"`
+    Deleted    bool              `json:"deleted" example:"false"`
+    CreatedAt  time.Time         `json:"createdAt" example:"2023-01-15T10:30:00Z"`
+    Replies    []MaterialComment `json:"replies,omitempty"`
+}
+
+// CreateCommentRequest represents new comment
+// @Description Новый вопрос (к материалу или блоку) или ответ (parentId)
+type CreateCommentRequest struct {
+    Content  string  `json:"content" binding:"required,max=5000" example:"Почему во втором шаге меняется знак?"`
+    BlockID  *string `json:"blockId" example:"block_123"`
+    ParentID *int    `json:"parentId" example:"1"`
+}
+
+// CommentFilters represents discussion list filters
+// @Description Параметры списка обсуждений
+type CommentFilters struct {
+    BlockID string `form:"blockId" example:"block_123"`
+    Sort    string `form:"sort" binding:"omitempty,oneof=new old top" example:"top"`
+    Page    int    `form:"page" example:"1"`
+    Limit   int    `form:"limit" example:"20"`
+}
+
+// ResolveCommentRequest represents resolved flag update
+// @Description Отметка вопроса решенным
+type ResolveCommentRequest struct {
+    Resolved *bool `json:"resolved" binding:"required" example:"true"`
+}
+
+// HideCommentRequest represents hidden flag update
+// @Description Скрытие комментария модератором
+type HideCommentRequest struct {
+    Hidden *bool `json:"hidden" binding:"required" example:"true"`
+}
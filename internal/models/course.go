@@ -0,0 +1,153 @@
+package models
+
+import "time"
+
+// Course represents course made of modules with ordered materials
+// @Description Курс - упорядоченный набор материалов, разбитый на модули
+type Course struct {
+    ID          int            `json:"id" example:"1"`
+    Title       string         `json:"title" example:"Алгебра 8 класс"`
+    Subject     string         `json:"subject" example:"algebra"`
+    Description string         `json:"description" example:"Полный курс алгебры за 8 класс"`
+    Level       *string        `json:"level,omitempty" example:"beginner"` // beginner, intermediate, advanced
+    CoverURL    string         `json:"coverUrl,omitempty" example:"https://example.com/images/cover.jpg"`
+    AuthorID    int            `json:"authorId" example:"123"`
+    AuthorName  string         `json:"authorName,omitempty" example:"Иван Иванов"`
+    Status      string         `json:"status" example:"published"` // draft, published, archived
+    Access      string         `json:"access" example:"open"`      // open, link
+    ShareURL    string         `json:"shareUrl,omitempty" example:"/course/1"`
+    Modules     []CourseModule `json:"modules,omitempty"`
+    CreatedAt   time.Time      `json:"createdAt" example:"2023-01-15T10:30:00Z"`
+    UpdatedAt   time.Time      `json:"updatedAt" example:"2023-01-15T10:30:00Z"`
+}
+
+// CourseModule represents course module
+// @Description Модуль курса
+type CourseModule struct {
+    ID       int          `json:"id" example:"5"`
+    CourseID int          `json:"courseId" example:"1"`
+    Title    string       `json:"title" example:"Линейные уравнения"`
+    Position int          `json:"position" example:"1"`
+    Items    []CourseItem `json:"items"`
+}
+
+// CourseItem represents material in course module
+// @Description Материал в модуле курса
+type CourseItem struct {
+    ID             int    `json:"id" example:"12"`
+    ModuleID       int    `json:"moduleId" example:"5"`
+    MaterialID     int    `json:"materialId" example:"42"`
+    Title          string `json:"title" example:"Решение линейных уравнений"`
+    MaterialStatus string `json:"materialStatus" example:"published"`
+    Position       int    `json:"position" example:"1"`
+    Prerequisites  []int  `json:"prerequisites"` // ID элементов курса, которые нужно пройти раньше
+}
+
+// CreateCourseRequest represents create course request
+// @Description Запрос на создание курса
+type CreateCourseRequest struct {
+    Title       string  `json:"title" binding:"required" example:"Алгебра 8 класс"`
+    Subject     string  `json:"subject" binding:"required" example:"algebra"`
+    Description string  `json:"description" example:"Полный курс алгебры за 8 класс"`
+    Level       *string `json:"level" binding:"omitempty,oneof=beginner intermediate advanced" example:"beginner"`
+    CoverURL    string  `json:"coverUrl" example:"https://example.com/images/cover.jpg"`
+}
+
+// UpdateCourseRequest represents update course request
+// @Description Запрос на обновление курса. Не переданные поля не изменяются
+type UpdateCourseRequest struct {
+    Title       *string `json:"title" binding:"omitempty,min=1" example:"Алгебра 8 класс"`
+    Subject     *string `json:"subject" binding:"omitempty,min=1" example:"algebra"`
+    Description *string `json:"description" example:"Полный курс алгебры за 8 класс"`
+    Level       *string `json:"level" binding:"omitempty,oneof=beginner intermediate advanced" example:"intermediate"`
+    CoverURL    *string `json:"coverUrl" example:"https://example.com/images/cover.jpg"`
+}
+
+// PublishCourseRequest represents publish course request
+// @Description Запрос на публикацию курса
+type PublishCourseRequest struct {
+    Visibility string `json:"visibility" binding:"omitempty,oneof=draft published archived" example:"published"`
+    Access     string `json:"access" binding:"omitempty,oneof=open link" example:"open"`
+}
+
+// CourseModuleRequest represents create/update module request
+// @Description Запрос на создание или переименование модуля
+type CourseModuleRequest struct {
+    Title string `json:"title" binding:"required" example:"Линейные уравнения"`
+}
+
+// AddCourseItemRequest represents add material to module request
+// @Description Запрос на добавление материала в модуль
+type AddCourseItemRequest struct {
+    MaterialID    int   `json:"materialId" binding:"required" example:"42"`
+    Prerequisites []int `json:"prerequisites" example:"10,11"`
+}
+
+// CoursePrerequisitesRequest represents set item prerequisites request
+// @Description Запрос на изменение условий доступа к элементу курса
+type CoursePrerequisitesRequest struct {
+    Prerequisites []int `json:"prerequisites" example:"10,11"`
+}
+
+// ReorderRequest represents reorder request
+// @Description Запрос на изменение порядка (полный список ID в новом порядке)
+type ReorderRequest struct {
+    IDs []int `json:"ids" binding:"required" example:"3,1,2"`
+}
+
+// CatalogCourse represents course in catalog
+// @Description Курс в каталоге
+type CatalogCourse struct {
+    ID             int     `json:"id" example:"1"`
+    Title          string  `json:"title" example:"Алгебра 8 класс"`
+    Subject        string  `json:"subject" example:"algebra"`
+    Description    string  `json:"description" example:"Полный курс алгебры за 8 класс"`
+    Level          *string `json:"level,omitempty" example:"beginner"`
+    CoverURL       string  `json:"coverUrl,omitempty" example:"https://example.com/images/cover.jpg"`
+    Author         Author  `json:"author"`
+    ModulesCount   int     `json:"modulesCount" example:"4"`
+    MaterialsCount int     `json:"materialsCount" example:"16"`
+}
+
+// CourseFilters represents filters for courses search
+// @Description Фильтры для поиска курсов
+type CourseFilters struct {
+    Search  string `form:"search" example:"алгебра"`
+    Subject string `form:"subject" example:"algebra"`
+    Level   string `form:"level" example:"beginner"`
+    Page    int    `form:"page" example:"1"`
+    Limit   int    `form:"limit" example:"20"`
+}
+
+// CourseProgress represents student progress in course
+// @Description Прогресс ученика в курсе
+type CourseProgress struct {
+    CourseID       int                    `json:"courseId" example:"1"`
+    TotalItems     int                    `json:"totalItems" example:"16"`
+    CompletedItems int                    `json:"completedItems" example:"6"`
+    Percent        float64                `json:"percent" example:"37.5"`
+    NextItemID     *int                   `json:"nextItemId,omitempty" example:"19"` // первый доступный непройденный элемент
+    Modules        []CourseModuleProgress `json:"modules"`
+}
+
+// CourseModuleProgress represents student progress in course module
+// @Description Прогресс ученика в модуле курса
+type CourseModuleProgress struct {
+    ModuleID       int                  `json:"moduleId" example:"5"`
+    Title          string               `json:"title" example:"Линейные уравнения"`
+    TotalItems     int                  `json:"totalItems" example:"4"`
+    CompletedItems int                  `json:"completedItems" example:"2"`
+    Items          []CourseItemProgress `json:"items"`
+}
+
+// CourseItemProgress represents student progress for course item
+// @Description Состояние элемента курса для ученика
+type CourseItemProgress struct {
+    ItemID     int        `json:"itemId" example:"12"`
+    MaterialID int        `json:"materialId" example:"42"`
+    Title      string     `json:"title" example:"Решение линейных уравнений"`
+    Completed  bool       `json:"completed" example:"true"`
+    Locked     bool       `json:"locked" example:"false"` // не пройдены предварительные элементы
+    Grade      *float64   `json:"grade,omitempty" example:"4.5"`
+    CompletedAt *time.Time `json:"completedAt,omitempty" example:"2023-01-15T10:30:00Z"`
+}
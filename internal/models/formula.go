@@ -0,0 +1,28 @@
+package models
+
+// FormulaError represents LaTeX syntax error
+// @Description Ошибка в формуле. Position - номер символа (с нуля) в формуле: в поле latex блока формулы или в узле math документа текстового блока (field=doc, latex - текст этой формулы)
+type FormulaError struct {
+    BlockID  string `json:"blockId,omitempty" example:"block_123"`
+    Field    string `json:"field,omitempty" example:"latex"`
+    Latex    string `json:"latex,omitempty" example:"\\fracc{1}{2}"`
+    Position int    `json:"position" example:"5"`
+    Message  string `json:"message" example:"unknown command \\fracc"`
+}
+
+// FormulaRender represents pre-rendered formula
+// @Description Формула, заранее отрисованная на сервере. Размеры в em; depth - насколько SVG опускается ниже базовой линии текста
+type FormulaRender struct {
+    MathML string  `json:"mathml" example:"<math xmlns=\"http://www.w3.org/1998/Math/MathML\"><mi>x</mi></math>"`
+    SVG    string  `json:"svg" example:"<svg xmlns=\"http://www.w3.org/2000/svg\" ...></svg>"`
+    Width  float64 `json:"width" example:"2.4"`
+    Height float64 `json:"height" example:"1.2"`
+    Depth  float64 `json:"depth" example:"0.3"`
+}
+
+// RenderFormulaRequest represents formula preview request
+// @Description Запрос на проверку и отрисовку формулы
+type RenderFormulaRequest struct {
+    Latex   string `json:"latex" binding:"required,max=10000" example:"\\frac{a}{b}"`
+    Display bool   `json:"display" example:"true"`
+}
@@ -0,0 +1,110 @@
+package models
+
+import "time"
+
+// LibraryBlock represents saved block snippet
+// @Description Фрагмент библиотеки блоков: один или несколько блоков для повторного использования
+type LibraryBlock struct {
+    ID          int       `json:"id" example:"1"`
+    OwnerID     int       `json:"ownerId" example:"123"`
+    OwnerName   string    `json:"ownerName,omitempty" example:"Иван Иванов"`
+    Title       string    `json:"title" example:"Вводный блок урока"`
+    Description string    `json:"description" example:"Цели урока и план"`
+    Subject     string    `json:"subject,omitempty" example:"math"`
+    Shared      bool      `json:"shared" example:"false"` // фрагмент виден всем преподавателям
+    BlocksCount int       `json:"blocksCount" example:"2"`
+    Blocks      []Block   `json:"blocks,omitempty"` // только при получении одного фрагмента
+    CreatedAt   time.Time `json:"createdAt" example:"2023-01-15T10:30:00Z"`
+    UpdatedAt   time.Time `json:"updatedAt" example:"2023-01-15T10:30:00Z"`
+}
+
+// SaveLibraryBlockRequest represents save snippet request
+// @Description Запрос на сохранение фрагмента. Блоки передаются в blocks или берутся из материала: materialId и blockIds
+type SaveLibraryBlockRequest struct {
+    Title       string   `json:"title" binding:"required,max=500" example:"Вводный блок урока"`
+    Description string   `json:"description" example:"Цели урока и план"`
+    Subject     string   `json:"subject" example:"math"`
+    Shared      bool     `json:"shared" example:"false"`
+    Blocks      []Block  `json:"blocks"`
+    MaterialID  int      `json:"materialId" example:"42"`
+    BlockIDs    []string `json:"blockIds" example:"block_1,block_2"`
+}
+
+// UpdateLibraryBlockRequest represents update snippet request
+// @Description Запрос на изменение фрагмента. Не переданные поля не изменяются
+type UpdateLibraryBlockRequest struct {
+    Title       *string `json:"title" binding:"omitempty,min=1,max=500" example:"Вводный блок урока"`
+    Description *string `json:"description" example:"Цели урока и план"`
+    Subject     *string `json:"subject" example:"math"` // пустая строка убирает предмет
+    Shared      *bool   `json:"shared" example:"true"`
+    Blocks      []Block `json:"blocks"`
+}
+
+// LibraryFilters represents filters for snippets
+// @Description Фильтры библиотеки блоков
+type LibraryFilters struct {
+    Scope   string `form:"scope" example:"my"` // my - свои, shared - общие, all - свои и общие (по умолчанию)
+    Search  string `form:"search" example:"введение"`
+    Subject string `form:"subject" example:"math"`
+    Page    int    `form:"page" example:"1"`
+    Limit   int    `form:"limit" example:"20"`
+}
+
+// MaterialTemplate represents material template
+// @Description Шаблон материала: готовый набор блоков. Официальные шаблоны по предметам ведут администраторы
+type MaterialTemplate struct {
+    ID          int       `json:"id" example:"1"`
+    AuthorID    int       `json:"authorId" example:"123"`
+    AuthorName  string    `json:"authorName,omitempty" example:"Иван Иванов"`
+    Title       string    `json:"title" example:"Урок решения задач"`
+    Description string    `json:"description" example:"Теория, разбор примера, задачи для самопроверки"`
+    Subject     string    `json:"subject,omitempty" example:"math"`
+    Official    bool      `json:"official" example:"false"`
+    BlocksCount int       `json:"blocksCount" example:"6"`
+    Blocks      []Block   `json:"blocks,omitempty"` // только при получении одного шаблона
+    CreatedAt   time.Time `json:"createdAt" example:"2023-01-15T10:30:00Z"`
+    UpdatedAt   time.Time `json:"updatedAt" example:"2023-01-15T10:30:00Z"`
+}
+
+// SaveTemplateRequest represents save template request
+// @Description Запрос на создание шаблона. Блоки передаются в blocks или копируются из материала materialId
+type SaveTemplateRequest struct {
+    Title       string  `json:"title" binding:"required,max=500" example:"Урок решения задач"`
+    Description string  `json:"description" example:"Теория, разбор примера, задачи для самопроверки"`
+    Subject     string  `json:"subject" example:"math"` // обязателен для официального шаблона
+    Blocks      []Block `json:"blocks"`
+    MaterialID  int     `json:"materialId" example:"42"`
+}
+
+// UpdateTemplateRequest represents update template request
+// @Description Запрос на изменение шаблона. Не переданные поля не изменяются
+type UpdateTemplateRequest struct {
+    Title       *string `json:"title" binding:"omitempty,min=1,max=500" example:"Урок решения задач"`
+    Description *string `json:"description" example:"Теория, разбор примера, задачи для самопроверки"`
+    Subject     *string `json:"subject" example:"math"`
+    Blocks      []Block `json:"blocks"`
+}
+
+// TemplateFilters represents filters for templates
+// @Description Фильтры шаблонов
+type TemplateFilters struct {
+    Scope   string `form:"scope" example:"official"` // my - свои, official - официальные, all - свои и официальные (по умолчанию)
+    Search  string `form:"search" example:"задачи"`
+    Subject string `form:"subject" example:"math"`
+    Page    int    `form:"page" example:"1"`
+    Limit   int    `form:"limit" example:"20"`
+}
+
+// InsertBlocksRequest represents insert snippet or template into material request
+// @Description Запрос на вставку блоков фрагмента или шаблона в материал
+type InsertBlocksRequest struct {
+    MaterialID int  `json:"materialId" binding:"required" example:"42"`
+    Position   *int `json:"position" example:"3"` // позиция первого блока, по умолчанию - в конец
+}
+
+// InsertBlocksResult represents inserted blocks
+// @Description Блоки, добавленные в материал, с новыми ID в порядке вставки
+type InsertBlocksResult struct {
+    MaterialID int      `json:"materialId" example:"42"`
+    BlockIDs   []string `json:"blockIds" example:"block_a1b2c3d4e5f60708,block_1122334455667788"`
+}
@@ -0,0 +1,92 @@
+package models
+
+import "time"
+
+// LTIPlatform represents registered LTI 1.3 platform
+// @Description Зарегистрированная LTI 1.3 платформа (LMS)
+type LTIPlatform struct {
+    ID            int       `json:"id" example:"1"`
+    Name          string    `json:"name" example:"Moodle школы №57"`
+    Issuer        string    `json:"issuer" example:"https://moodle.school57.ru"`
+    ClientID      string    `json:"clientId" example:"aBcD1234"`
+    DeploymentIDs []string  `json:"deploymentIds" example:"1"`
+    AuthLoginURL  string    `json:"authLoginUrl" example:"https://moodle.school57.ru/mod/lti/auth.php"`
+    AuthTokenURL  string    `json:"authTokenUrl" example:"https://moodle.school57.ru/mod/lti/token.php"`
+    JWKSURL       string    `json:"jwksUrl" example:"https://moodle.school57.ru/mod/lti/certs.php"`
+    CreatedAt     time.Time `json:"createdAt" example:"2023-09-01T09:00:00Z"`
+}
+
+// CreateLTIPlatformRequest represents LTI platform registration request
+// @Description Запрос на регистрацию LTI платформы
+type CreateLTIPlatformRequest struct {
+    Name          string   `json:"name" binding:"required,max=200" example:"Moodle школы №57"`
+    Issuer        string   `json:"issuer" binding:"required,url" example:"https://moodle.school57.ru"`
+    ClientID      string   `json:"clientId" binding:"required" example:"aBcD1234"`
+    DeploymentIDs []string `json:"deploymentIds" binding:"required,min=1" example:"1"`
+    AuthLoginURL  string   `json:"authLoginUrl" binding:"required,url" example:"https://moodle.school57.ru/mod/lti/auth.php"`
+    AuthTokenURL  string   `json:"authTokenUrl" binding:"required,url" example:"https://moodle.school57.ru/mod/lti/token.php"`
+    JWKSURL       string   `json:"jwksUrl" binding:"required,url" example:"https://moodle.school57.ru/mod/lti/certs.php"`
+}
+
+// LTILoginRequest represents OIDC third-party login initiation
+// @Description Параметры инициации OIDC-входа от платформы
+type LTILoginRequest struct {
+    Issuer          string `form:"iss" binding:"required"`
+    LoginHint       string `form:"login_hint" binding:"required"`
+    TargetLinkURI   string `form:"target_link_uri"`
+    LTIMessageHint  string `form:"lti_message_hint"`
+    ClientID        string `form:"client_id"`
+    LTIDeploymentID string `form:"lti_deployment_id"`
+}
+
+// LTIDeepLinkSession represents deep linking content selection session
+// @Description Сессия выбора материалов для вставки в курс LMS
+type LTIDeepLinkSession struct {
+    ID             string    `json:"id" example:"5b0a6c1e-8d2f-4c3b-9a7e-1f2d3c4b5a69"`
+    PlatformID     int       `json:"-"`
+    PlatformName   string    `json:"platformName" example:"Moodle школы №57"`
+    DeploymentID   string    `json:"-"`
+    UserID         int       `json:"-"`
+    ReturnURL      string    `json:"-"`
+    Data           string    `json:"-"`
+    AcceptMultiple bool      `json:"acceptMultiple" example:"true"`
+    ExpiresAt      time.Time `json:"expiresAt" example:"2023-09-01T10:00:00Z"`
+}
+
+// LTIDeepLinkRequest represents selected materials for deep linking
+// @Description Выбранные материалы для вставки в курс LMS
+type LTIDeepLinkRequest struct {
+    MaterialIDs []int `json:"materialIds" binding:"required,min=1" example:"42"`
+}
+
+// LTIDeepLinkResponse represents signed deep linking response
+// @Description Подписанный ответ deep linking: фронтенд отправляет jwt формой (POST, поле JWT) на returnUrl
+type LTIDeepLinkResponse struct {
+    ReturnURL string `json:"returnUrl" example:"https://moodle.school57.ru/mod/lti/contentitem_return.php"`
+    JWT       string `json:"jwt" example:"eyJhbGciOiJSUzI1NiIsImtpZCI6..."`
+}
+
+// LTIJWKS represents tool public key set
+// @Description Публичные ключи инструмента (JWKS) для проверки подписи платформой
+type LTIJWKS struct {
+    Keys []LTIJWK `json:"keys"`
+}
+
+// LTIJWK represents RSA public key in JWK format
+type LTIJWK struct {
+    Kty string `json:"kty" example:"RSA"`
+    Kid string `json:"kid" example:"3f2a9c1b7d4e8f60"`
+    Use string `json:"use" example:"sig"`
+    Alg string `json:"alg" example:"RS256"`
+    N   string `json:"n" example:"0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw"`
+    E   string `json:"e" example:"AQAB"`
+}
+
+// LTIGradeTarget represents line item to receive student's grade
+type LTIGradeTarget struct {
+    PlatformID  int
+    Sub         string
+    LineItemURL string
+    Grade       *float64
+    CompletedAt time.Time
+}
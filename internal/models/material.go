@@ -10,11 +10,21 @@ type Material struct {
     ID          int       `json:"id" example:"1"`
     Title       string    `json:"title" example:"Основы алгебры"`
     Subject     string    `json:"subject" example:"math"`
+    Description string    `json:"description" example:"Переменные, выражения и линейные уравнения"`
+    Level       *string   `json:"level,omitempty" example:"beginner"` // beginner, intermediate, advanced
+    Tags        []string  `json:"tags" example:"уравнения,8 класс"`
+    CoverURL    string    `json:"coverUrl,omitempty" example:"https://example.com/images/cover.jpg"`
+    Language    string    `json:"language" example:"ru"`
+    Duration    int       `json:"duration" example:"45"` // ожидаемое время изучения в минутах
     AuthorID    int       `json:"authorId" example:"123"`
     AuthorName  string    `json:"authorName,omitempty" example:"Иван Иванов"`
-    Status      string    `json:"status" example:"published"` // draft, published, archived
+    Status      string    `json:"status" example:"published"` // draft, pending_review, published, archived
     Access      string    `json:"access" example:"open"` // open, link
     ShareURL    string    `json:"shareUrl,omitempty" example:"https://paydeya.com/share/abc123"`
+    ForkedFrom  *int      `json:"forkedFrom,omitempty" example:"7"` // ID исходного материала для копий
+    DeletedAt   *time.Time `json:"deletedAt,omitempty" example:"2023-02-01T12:00:00Z"` // время перемещения в корзину
+    PublishAt   *time.Time `json:"publishAt,omitempty" example:"2023-09-01T08:00:00Z"` // запланированная публикация черновика
+    UnpublishAt *time.Time `json:"unpublishAt,omitempty" example:"2023-09-01T10:00:00Z"` // запланированный перенос в архив
     Blocks      []Block   `json:"blocks,omitempty"`
     CreatedAt   time.Time `json:"createdAt" example:"2023-01-15T10:30:00Z"`
     UpdatedAt   time.Time `json:"updatedAt" example:"2023-01-15T10:30:00Z"`
@@ -24,7 +34,7 @@ type Material struct {
 // @Description Блок контента в материале
 type Block struct {
     ID        string                 `json:"id" example:"block_123"`
-    Type      string                 `json:"type" example:"text"` // text, image, video, formula, quiz
+    Type      string                 `json:"type" example:"text"` // text, image, video, formula, quiz, code
     Content   map[string]interface{} `json:"content"`
     Styles    map[string]interface{} `json:"styles,omitempty"`
     Position  int                    `json:"position" example:"1"`
@@ -50,22 +60,44 @@ type AnimationStep struct {
 // CreateMaterialRequest represents create material request
 // @Description Запрос на создание материала
 type CreateMaterialRequest struct {
-    Title   string `json:"title" binding:"required" example:"Новый материал"`
-    Subject string `json:"subject" binding:"required" example:"math"`
+    Title       string   `json:"title" binding:"required" example:"Новый материал"`
+    Subject     string   `json:"subject" binding:"required" example:"math"`
+    Description string   `json:"description" example:"Переменные, выражения и линейные уравнения"`
+    Level       *string  `json:"level" binding:"omitempty,oneof=beginner intermediate advanced" example:"beginner"`
+    Tags        []string `json:"tags" binding:"max=20" example:"уравнения,8 класс"`
+    CoverURL    string   `json:"coverUrl" example:"https://example.com/images/cover.jpg"`
+    Language    string   `json:"language" binding:"omitempty,max=10" example:"ru"`
+    Duration    int      `json:"duration" binding:"min=0" example:"45"`
 }
 
 // UpdateMaterialRequest represents update material request
 // @Description Запрос на обновление материала
+// Поля, которые не переданы (null), не изменяются
 type UpdateMaterialRequest struct {
-    Title  string  `json:"title" example:"Обновленное название"`
-    Blocks []Block `json:"blocks"`
+    Title       string   `json:"title" example:"Обновленное название"`
+    Description *string  `json:"description" example:"Новое описание"`
+    Level       *string  `json:"level" binding:"omitempty,oneof=beginner intermediate advanced" example:"intermediate"`
+    Tags        []string `json:"tags" binding:"max=20" example:"уравнения,9 класс"`
+    CoverURL    *string  `json:"coverUrl" example:"https://example.com/images/cover.jpg"`
+    Language    *string  `json:"language" binding:"omitempty,max=10" example:"en"`
+    Duration    *int     `json:"duration" binding:"omitempty,min=0" example:"60"`
+    Blocks      []Block  `json:"blocks"`
 }
 
 // PublishMaterialRequest represents publish material request
 // @Description Запрос на публикацию материала
 type PublishMaterialRequest struct {
-    Visibility string `json:"visibility" example:"published"` // draft, published, archived
-    Access     string `json:"access" example:"open"`     // open, link
+    Visibility  string     `json:"visibility" binding:"omitempty,oneof=draft published" example:"published"` // draft, published (для архива - /archive)
+    Access      string     `json:"access" example:"open"`     // open, link
+    PublishAt   *time.Time `json:"publishAt" example:"2023-09-01T08:00:00Z"`   // опубликовать в это время; до него материал остается черновиком
+    UnpublishAt *time.Time `json:"unpublishAt" example:"2023-09-01T10:00:00Z"` // перенести в архив в это время (конец окна доступа)
+}
+
+// TrashedMaterial represents material in trash
+// @Description Материал в корзине
+type TrashedMaterial struct {
+    Material
+    PurgeAt time.Time `json:"purgeAt" example:"2023-03-03T12:00:00Z"` // время окончательного удаления
 }
 
 // UserMaterialsResponse represents user materials response
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// MaterialReview represents material publication review
+// @Description Заявка на публикацию материала и решение модератора
+type MaterialReview struct {
+    ID            int             `json:"id" example:"1"`
+    MaterialID    int             `json:"materialId" example:"42"`
+    MaterialTitle string          `json:"materialTitle" example:"Основы алгебры"`
+    AuthorID      int             `json:"authorId" example:"123"`
+    AuthorName    string          `json:"authorName" example:"Иван Иванов"`
+    Status        string          `json:"status" example:"pending"` // pending, approved, changes_requested, withdrawn
+    SubmittedAt   time.Time       `json:"submittedAt" example:"2023-01-15T10:30:00Z"`
+    ModeratorID   *int            `json:"moderatorId,omitempty" example:"1"`
+    ModeratorName string          `json:"moderatorName,omitempty" example:"Мария Петрова"`
+    DecidedAt     *time.Time      `json:"decidedAt,omitempty" example:"2023-01-16T09:00:00Z"`
+    Comment       string          `json:"comment,omitempty" example:"Добавьте разбор второго примера"`
+    BlockComments []ReviewComment `json:"blockComments"`
+}
+
+// ReviewComment represents moderator comment on block
+// @Description Замечание модератора к блоку материала
+type ReviewComment struct {
+    BlockID string `json:"blockId" binding:"required" example:"block_123"`
+    Comment string `json:"comment" binding:"required" example:"Ошибка в знаке во второй строке"`
+}
+
+// ReviewDecisionRequest represents moderator decision
+// @Description Решение модератора: общий комментарий и замечания к блокам
+type ReviewDecisionRequest struct {
+    Comment       string          `json:"comment" example:"Добавьте разбор второго примера"`
+    BlockComments []ReviewComment `json:"blockComments" binding:"dive"`
+}
+
+// ModerationQueueFilters represents moderation queue filters
+// @Description Параметры очереди модерации
+type ModerationQueueFilters struct {
+    Subject string `form:"subject" example:"math"`
+    Page    int    `form:"page" example:"1"`
+    Limit   int    `form:"limit" example:"20"`
+}
+
+// TrustedAuthorRequest represents trusted author flag update
+// @Description Запрос на изменение доверия к автору: доверенные авторы публикуют без модерации
+type TrustedAuthorRequest struct {
+    Trusted *bool `json:"trusted" binding:"required" example:"true"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Notification represents user notification
+// @Description Уведомление пользователя
+type Notification struct {
+    ID         int       `json:"id" example:"1"`
+    Type       string    `json:"type" example:"report_resolved"` // report_resolved, author_warning, material_unpublished, comment_new, comment_reply, review_response
+    Message    string    `json:"message" example:"Ваша жалоба на материал «Основы алгебры» рассмотрена"`
+    MaterialID *int      `json:"materialId,omitempty" example:"42"`
+    Read       bool      `json:"read" example:"false"`
+    CreatedAt  time.Time `json:"createdAt" example:"2023-01-15T10:30:00Z"`
+}
+
+// NotificationFilters represents notifications list filters
+// @Description Параметры списка уведомлений
+type NotificationFilters struct {
+    Unread bool `form:"unread" example:"true"`
+    Page   int  `form:"page" example:"1"`
+    Limit  int  `form:"limit" example:"20"`
+}
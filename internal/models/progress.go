@@ -19,6 +19,7 @@ type ProgressMaterial struct {
     Title        string    `json:"title" example:"Основы алгебры"`
     Subject      string    `json:"subject" example:"math"`
     Progress     float64   `json:"progress" example:"75.5"` // 0-100%
+    LastBlockID  string    `json:"lastBlockId,omitempty" example:"block_123"` // блок, с которого продолжить
     LastActivity time.Time `json:"lastActivity" example:"2023-01-15T10:30:00Z"`
 }
 
@@ -38,4 +39,25 @@ type FavoriteMaterial struct {
     MaterialID int       `json:"materialId" example:"1"`
     UserID     int       `json:"userId" example:"123"`
     AddedAt    time.Time `json:"addedAt" example:"2023-01-15T10:30:00Z"`
-}
\ No newline at end of file
+}
+// BlockTime represents time spent on material block
+// @Description Время, проведенное на блоке материала
+type BlockTime struct {
+    BlockID   string `json:"blockId" binding:"required" example:"block_123"`
+    TimeSpent int    `json:"timeSpent" binding:"min=0,max=3600" example:"45"` // в секундах
+}
+
+// MaterialProgress represents student progress inside material
+// @Description Прогресс ученика внутри материала и позиция для продолжения
+type MaterialProgress struct {
+    MaterialID      int        `json:"materialId" example:"1"`
+    LastBlockID     string     `json:"lastBlockId,omitempty" example:"block_123"` // блок, с которого продолжить
+    ViewedBlocks    int        `json:"viewedBlocks" example:"6"`                  // просмотренные блоки и решенные упражнения
+    TotalBlocks     int        `json:"totalBlocks" example:"8"`
+    Exercises       int        `json:"exercises" example:"2"` // упражнения с кодом и тестами
+    ExercisesPassed int        `json:"exercisesPassed" example:"1"`
+    Progress        float64    `json:"progress" example:"75"`   // 0-100%
+    TimeSpent       int        `json:"timeSpent" example:"640"` // в секундах
+    Completed       bool       `json:"completed" example:"false"`
+    LastActivity    *time.Time `json:"lastActivity,omitempty" example:"2023-01-15T10:30:00Z"`
+}
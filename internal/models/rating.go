@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// MaterialRating represents student rating and review
+// @Description Оценка материала учеником с отзывом и ответом автора
+type MaterialRating struct {
+    ID             int        `json:"id" example:"1"`
+    MaterialID     int        `json:"materialId" example:"42"`
+    UserID         int        `json:"userId" example:"456"`
+    UserName       string     `json:"userName" example:"Петр Сидоров"`
+    UserAvatar     string     `json:"userAvatar,omitempty" example:"/uploads/avatars/456.png"`
+    Rating         int        `json:"rating" example:"5"`
+    Review         string     `json:"review,omitempty" example:"Понятные объяснения и хорошие задачи"`
+    AuthorResponse string     `json:"authorResponse,omitempty" example:"Спасибо за отзыв!"`
+    RespondedAt    *time.Time `json:"respondedAt,omitempty" example:"2023-01-16T09:00:00Z"`
+    CreatedAt      time.Time  `json:"createdAt" example:"2023-01-15T10:30:00Z"`
+    UpdatedAt      time.Time  `json:"updatedAt" example:"2023-01-15T10:30:00Z"`
+}
+
+// RatingSummary represents material rating summary
+// @Description Средняя оценка материала и распределение оценок
+type RatingSummary struct {
+    Average      float64     `json:"average" example:"4.6"`
+    Count        int         `json:"count" example:"25"`
+    Distribution map[int]int `json:"distribution"` // количество оценок 1-5
+}
+
+// RateMaterialRequest represents rating submission
+// @Description Оценка материала (1-5) и необязательный отзыв. Повторная отправка заменяет оценку
+type RateMaterialRequest struct {
+    Rating int    `json:"rating" binding:"required,min=1,max=5" example:"5"`
+    Review string `json:"review" binding:"max=5000" example:"Понятные объяснения и хорошие задачи"`
+}
+
+// ReviewFilters represents reviews list filters
+// @Description Параметры списка отзывов
+type ReviewFilters struct {
+    Page  int `form:"page" example:"1"`
+    Limit int `form:"limit" example:"20"`
+}
+
+// AuthorResponseRequest represents author response to review
+// @Description Ответ автора на отзыв
+type AuthorResponseRequest struct {
+    Response string `json:"response" binding:"required,max=5000" example:"Спасибо за отзыв!"`
+}
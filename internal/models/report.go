@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// MaterialReport represents content report
+// @Description Жалоба на материал или блок материала
+type MaterialReport struct {
+    ID            int        `json:"id" example:"1"`
+    MaterialID    int        `json:"materialId" example:"42"`
+    MaterialTitle string     `json:"materialTitle" example:"Основы алгебры"`
+    AuthorID      int        `json:"authorId" example:"123"`
+    AuthorName    string     `json:"authorName" example:"Иван Иванов"`
+    BlockID       *string    `json:"blockId,omitempty" example:"block_123"`
+    ReporterID    int        `json:"reporterId" example:"456"`
+    ReporterName  string     `json:"reporterName" example:"Петр Сидоров"`
+    Category      string     `json:"category" example:"error"` // error, inappropriate, copyright, spam, other
+    Comment       string     `json:"comment,omitempty" example:"В ответе к задаче 3 ошибка"`
+    Status        string     `json:"status" example:"open"` // open, dismissed, resolved
+    Action        *string    `json:"action,omitempty" example:"unpublish"`
+    ModeratorID   *int       `json:"moderatorId,omitempty" example:"1"`
+    Resolution    string     `json:"resolution,omitempty" example:"Материал снят с публикации до исправления"`
+    ResolvedAt    *time.Time `json:"resolvedAt,omitempty" example:"2023-01-16T09:00:00Z"`
+    CreatedAt     time.Time  `json:"createdAt" example:"2023-01-15T10:30:00Z"`
+    OpenReports   int        `json:"openReports" example:"3"` // открытых жалоб на материал
+}
+
+// CreateReportRequest represents content report request
+// @Description Запрос на жалобу. blockId указывает на конкретный блок материала
+type CreateReportRequest struct {
+    Category string  `json:"category" binding:"required,oneof=error inappropriate copyright spam other" example:"error"`
+    BlockID  *string `json:"blockId" example:"block_123"`
+    Comment  string  `json:"comment" binding:"max=2000" example:"В ответе к задаче 3 ошибка"`
+}
+
+// ReportFilters represents report queue filters
+// @Description Параметры очереди жалоб
+type ReportFilters struct {
+    Status     string `form:"status" binding:"omitempty,oneof=open dismissed resolved" example:"open"`
+    Category   string `form:"category" example:"error"`
+    MaterialID int    `form:"materialId" example:"42"`
+    Page       int    `form:"page" example:"1"`
+    Limit      int    `form:"limit" example:"20"`
+}
+
+// ResolveReportRequest represents moderator action on report
+// @Description Действие модератора по жалобе: dismiss - отклонить, unpublish - снять материал с публикации,
+// @Description warn_author - предупредить автора, block_author - заблокировать автора
+type ResolveReportRequest struct {
+    Action  string `json:"action" binding:"required,oneof=dismiss unpublish warn_author block_author" example:"unpublish"`
+    Comment string `json:"comment" example:"Материал снят с публикации до исправления"`
+}
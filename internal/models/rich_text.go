@@ -0,0 +1,29 @@
+package models
+
+// RichTextNode represents node of text block document
+// @Description Узел документа текстового блока (content.doc). Блочные узлы: doc, paragraph, heading, bullet_list, ordered_list, list_item, code_block; строчные: text, math, hard_break
+type RichTextNode struct {
+    Type    string         `json:"type" example:"paragraph"`
+    Attrs   *RichTextAttrs `json:"attrs,omitempty"`
+    Content []RichTextNode `json:"content,omitempty"`
+    Text    string         `json:"text,omitempty" example:"Теорема Пифагора"`
+    Marks   []RichTextMark `json:"marks,omitempty"`
+}
+
+// RichTextAttrs represents node attributes
+// @Description Атрибуты узла. Заполняются только нужные типу узла, остальные отбрасываются при сохранении
+type RichTextAttrs struct {
+    Level    int            `json:"level,omitempty" example:"2"`                  // heading: 1-6
+    Start    int            `json:"start,omitempty" example:"1"`                  // ordered_list: номер первого пункта
+    Language string         `json:"language,omitempty" example:"python"`          // code_block
+    Href     string         `json:"href,omitempty" example:"https://example.com"` // link
+    Latex    string         `json:"latex,omitempty" example:"a^2 + b^2 = c^2"`    // math
+    Rendered *FormulaRender `json:"rendered,omitempty"`                           // math: отрисовка сервера
+}
+
+// RichTextMark represents text formatting
+// @Description Оформление текста: bold, italic, underline, strike, code, subscript, superscript, link (attrs.href)
+type RichTextMark struct {
+    Type  string         `json:"type" example:"bold"`
+    Attrs *RichTextAttrs `json:"attrs,omitempty"`
+}
@@ -0,0 +1,93 @@
+package models
+
+import "time"
+
+// XAPIStatement represents xAPI (Tin Can) statement
+// @Description xAPI-выражение о действии ученика (спецификация xAPI 1.0.3)
+type XAPIStatement struct {
+    ID        string       `json:"id" example:"f9c5b2d4-6a1e-4b8c-9d3f-2e7a1c0b5d48"`
+    Actor     XAPIActor    `json:"actor"`
+    Verb      XAPIVerb     `json:"verb"`
+    Object    XAPIActivity `json:"object"`
+    Result    *XAPIResult  `json:"result,omitempty"`
+    Context   *XAPIContext `json:"context,omitempty"`
+    Timestamp time.Time    `json:"timestamp" example:"2023-09-14T18:30:00Z"`
+    Stored    *time.Time   `json:"stored,omitempty" example:"2023-09-14T18:30:01Z"`
+    Version   string       `json:"version,omitempty" example:"1.0.3"`
+}
+
+// XAPIActor represents xAPI agent
+// @Description Участник (ученик)
+type XAPIActor struct {
+    ObjectType string `json:"objectType" example:"Agent"`
+    Name       string `json:"name,omitempty" example:"Петр Сидоров"`
+    Mbox       string `json:"mbox" example:"mailto:petr@example.com"`
+}
+
+// XAPIVerb represents xAPI verb
+// @Description Глагол действия
+type XAPIVerb struct {
+    ID      string            `json:"id" example:"http://adlnet.gov/expapi/verbs/completed"`
+    Display map[string]string `json:"display"`
+}
+
+// XAPIActivity represents xAPI activity object
+// @Description Объект действия: материал или его блок
+type XAPIActivity struct {
+    ObjectType string                  `json:"objectType" example:"Activity"`
+    ID         string                  `json:"id" example:"https://paydeya.ru/materials/42"`
+    Definition *XAPIActivityDefinition `json:"definition,omitempty"`
+}
+
+// XAPIActivityDefinition represents xAPI activity definition
+// @Description Описание объекта
+type XAPIActivityDefinition struct {
+    Name map[string]string `json:"name,omitempty"`
+    Type string            `json:"type,omitempty" example:"http://adlnet.gov/expapi/activities/lesson"`
+}
+
+// XAPIResult represents xAPI statement result
+// @Description Результат действия
+type XAPIResult struct {
+    Score      *XAPIScore `json:"score,omitempty"`
+    Success    *bool      `json:"success,omitempty" example:"true"`
+    Completion *bool      `json:"completion,omitempty" example:"true"`
+    Duration   string     `json:"duration,omitempty" example:"PT1260S"` // ISO 8601
+}
+
+// XAPIScore represents xAPI score
+// @Description Оценка
+type XAPIScore struct {
+    Scaled float64 `json:"scaled" example:"0.9"`
+    Raw    float64 `json:"raw" example:"4.5"`
+    Min    float64 `json:"min" example:"1"`
+    Max    float64 `json:"max" example:"5"`
+}
+
+// XAPIContext represents xAPI statement context
+// @Description Контекст действия
+type XAPIContext struct {
+    Platform          string                 `json:"platform,omitempty" example:"Paydeya"`
+    Language          string                 `json:"language,omitempty" example:"ru"`
+    ContextActivities *XAPIContextActivities `json:"contextActivities,omitempty"`
+}
+
+// XAPIContextActivities represents xAPI context activities
+// @Description Связанные объекты
+type XAPIContextActivities struct {
+    Parent []XAPIActivity `json:"parent,omitempty"`
+}
+
+// XAPIStatementFilter represents xAPI statements query
+type XAPIStatementFilter struct {
+    StatementID string
+    Verb        string
+    Activity    string
+    AgentMbox   string
+    Since       *time.Time
+    Until       *time.Time
+    Limit       int
+    Ascending   bool
+    ViewerID    int  // пользователь, запрашивающий выражения
+    All         bool // доступны все выражения (администратор)
+}
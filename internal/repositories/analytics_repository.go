@@ -0,0 +1,275 @@
+package repositories
+
+import (
+    "context"
+
+    "paydeya-backend/internal/models"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AnalyticsRepository struct {
+    db *pgxpool.Pool
+}
+
+func NewAnalyticsRepository(db *pgxpool.Pool) *AnalyticsRepository {
+    return &AnalyticsRepository{db: db}
+}
+
+const materialStatsColumns = `
+    m.id, m.title, m.status,
+    COUNT(mc.id) as completions,
+    AVG(mc.grade)::float8 as average_grade,
+    COALESCE(AVG(mc.time_spent), 0)::int as average_time_spent,
+    MAX(mc.completed_at) as last_completed_at`
+
+func scanMaterialStats(row pgx.Row, stats *models.MaterialStats) error {
+    return row.Scan(
+        &stats.MaterialID, &stats.Title, &stats.Status, &stats.Completions,
+        &stats.AverageGrade, &stats.AverageTimeSpent, &stats.LastCompletedAt,
+    )
+}
+
+// GetAuthorMaterialStats возвращает сводную статистику по всем материалам автора
+func (r *AnalyticsRepository) GetAuthorMaterialStats(ctx context.Context, authorID int) ([]models.MaterialStats, error) {
+    query := `
+        SELECT ` + materialStatsColumns + `
+        FROM materials m
+        LEFT JOIN material_completions mc ON mc.material_id = m.id
+        WHERE m.author_id = $1 AND m.deleted_at IS NULL
+        GROUP BY m.id
+        ORDER BY completions DESC, m.title
+    `
+
+    rows, err := r.db.Query(ctx, query, authorID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    stats := []models.MaterialStats{}
+    for rows.Next() {
+        var s models.MaterialStats
+        if err := scanMaterialStats(rows, &s); err != nil {
+            return nil, err
+        }
+        stats = append(stats, s)
+    }
+
+    return stats, rows.Err()
+}
+
+// GetMaterialStats возвращает сводную статистику материала
+func (r *AnalyticsRepository) GetMaterialStats(ctx context.Context, materialID int) (*models.MaterialStats, error) {
+    var stats models.MaterialStats
+
+    query := `
+        SELECT ` + materialStatsColumns + `
+        FROM materials m
+        LEFT JOIN material_completions mc ON mc.material_id = m.id
+        WHERE m.id = $1 AND m.deleted_at IS NULL
+        GROUP BY m.id
+    `
+
+    err := scanMaterialStats(r.db.QueryRow(ctx, query, materialID), &stats)
+    if err == pgx.ErrNoRows {
+        return nil, nil
+    }
+
+    return &stats, err
+}
+
+// GetTimeSpentDistribution возвращает время изучения материала всеми завершившими его учениками
+func (r *AnalyticsRepository) GetTimeSpentDistribution(ctx context.Context, materialID int) ([]int, error) {
+    rows, err := r.db.Query(ctx, `SELECT time_spent FROM material_completions WHERE material_id = $1`, materialID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var times []int
+    for rows.Next() {
+        var t int
+        if err := rows.Scan(&t); err != nil {
+            return nil, err
+        }
+        times = append(times, t)
+    }
+
+    return times, rows.Err()
+}
+
+// GetQuestionStats возвращает успешность ответов на каждый вопрос и упражнение с кодом материала
+func (r *AnalyticsRepository) GetQuestionStats(ctx context.Context, materialID int) ([]models.QuestionStats, error) {
+    query := `
+        SELECT b.block_id, b.position, COALESCE(b.content->>'question', b.content->>'instructions', ''),
+               COUNT(qa.user_id) as attempts,
+               COUNT(qa.user_id) FILTER (WHERE qa.correct) as correct
+        FROM material_blocks b
+        LEFT JOIN quiz_answers qa ON qa.material_id = b.material_id AND qa.block_id = b.block_id
+        WHERE b.material_id = $1 AND (b.type = 'quiz' OR b.type = 'code' AND jsonb_array_length(COALESCE(b.content->'tests', '[]')) > 0)
+        GROUP BY b.id
+        ORDER BY b.position
+    `
+
+    rows, err := r.db.Query(ctx, query, materialID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    questions := []models.QuestionStats{}
+    for rows.Next() {
+        var q models.QuestionStats
+        if err := rows.Scan(&q.BlockID, &q.Position, &q.Question, &q.Attempts, &q.Correct); err != nil {
+            return nil, err
+        }
+        if q.Attempts > 0 {
+            q.SuccessRate = float64(q.Correct) * 100 / float64(q.Attempts)
+        }
+        questions = append(questions, q)
+    }
+
+    return questions, rows.Err()
+}
+
+// materialLearnersQuery - ученики, приступившие к материалу $1, и самая дальняя
+// позиция блока, до которой они дошли (по просмотрам блоков и ответам на вопросы).
+// Завершившие материал дошли до конца
+const materialLearnersQuery = `
+    SELECT user_id, MAX(position) as reached_position
+    FROM (
+        SELECT mc.user_id, 2147483647 as position
+        FROM material_completions mc
+        WHERE mc.material_id = $1
+        UNION ALL
+        SELECT qa.user_id, b.position
+        FROM quiz_answers qa
+        JOIN material_blocks b ON b.material_id = qa.material_id AND b.block_id = qa.block_id
+        WHERE qa.material_id = $1
+        UNION ALL
+        SELECT v.user_id, b.position
+        FROM material_block_views v
+        JOIN material_blocks b ON b.material_id = v.material_id AND b.block_id = v.block_id
+        WHERE v.material_id = $1
+    ) activity
+    GROUP BY user_id`
+
+// GetBlockDropOff возвращает число приступивших к материалу и число дошедших до каждого блока
+func (r *AnalyticsRepository) GetBlockDropOff(ctx context.Context, materialID int) (int, []models.BlockDropOff, error) {
+    var started int
+    err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM (`+materialLearnersQuery+`) l`, materialID).Scan(&started)
+    if err != nil {
+        return 0, nil, err
+    }
+
+    query := `
+        WITH learners AS (` + materialLearnersQuery + `)
+        SELECT b.block_id, b.type, b.position,
+               (SELECT COUNT(*) FROM learners l WHERE l.reached_position >= b.position) as reached
+        FROM material_blocks b
+        WHERE b.material_id = $1
+        ORDER BY b.position
+    `
+
+    rows, err := r.db.Query(ctx, query, materialID)
+    if err != nil {
+        return 0, nil, err
+    }
+    defer rows.Close()
+
+    dropOff := []models.BlockDropOff{}
+    for rows.Next() {
+        var block models.BlockDropOff
+        if err := rows.Scan(&block.BlockID, &block.Type, &block.Position, &block.Reached); err != nil {
+            return 0, nil, err
+        }
+        if started > 0 {
+            block.ReachedRate = float64(block.Reached) * 100 / float64(started)
+        }
+        dropOff = append(dropOff, block)
+    }
+
+    return started, dropOff, rows.Err()
+}
+
+// GetStudentClasses возвращает названия классов преподавателя, в которых состоит ученик
+func (r *AnalyticsRepository) GetStudentClasses(ctx context.Context, teacherID, studentID int) ([]string, error) {
+    query := `
+        SELECT c.name
+        FROM classes c
+        JOIN class_members cm ON cm.class_id = c.id
+        WHERE c.teacher_id = $1 AND cm.user_id = $2
+        ORDER BY c.name
+    `
+
+    rows, err := r.db.Query(ctx, query, teacherID, studentID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    classes := []string{}
+    for rows.Next() {
+        var name string
+        if err := rows.Scan(&name); err != nil {
+            return nil, err
+        }
+        classes = append(classes, name)
+    }
+
+    return classes, rows.Err()
+}
+
+// GetStudentMaterialStats возвращает результаты ученика по материалам преподавателя:
+// созданным им и назначенным в его классах
+func (r *AnalyticsRepository) GetStudentMaterialStats(ctx context.Context, teacherID, studentID int) ([]models.StudentMaterialStats, error) {
+    query := `
+        WITH teacher_materials AS (
+            SELECT id as material_id FROM materials WHERE author_id = $1
+            UNION
+            SELECT a.material_id FROM class_assignments a
+            JOIN classes c ON a.class_id = c.id
+            WHERE c.teacher_id = $1 AND a.material_id IS NOT NULL
+            UNION
+            SELECT ci.material_id FROM class_assignments a
+            JOIN classes c ON a.class_id = c.id
+            JOIN course_items ci ON ci.course_id = a.course_id
+            WHERE c.teacher_id = $1
+        )
+        SELECT m.id, m.title, mc.id IS NOT NULL, mc.grade::float8,
+               COALESCE(mc.time_spent, mp.time_spent, 0), mc.completed_at,
+               (SELECT COUNT(*) FROM quiz_answers qa WHERE qa.material_id = m.id AND qa.user_id = $2),
+               (SELECT COUNT(*) FROM quiz_answers qa WHERE qa.material_id = m.id AND qa.user_id = $2 AND qa.correct)
+        FROM teacher_materials tm
+        JOIN materials m ON m.id = tm.material_id
+        LEFT JOIN material_completions mc ON mc.material_id = m.id AND mc.user_id = $2
+        LEFT JOIN material_progress mp ON mp.material_id = m.id AND mp.user_id = $2
+        WHERE m.deleted_at IS NULL
+          AND (mc.id IS NOT NULL OR mp.user_id IS NOT NULL OR EXISTS (
+              SELECT 1 FROM quiz_answers qa WHERE qa.material_id = m.id AND qa.user_id = $2
+          ))
+        ORDER BY mc.completed_at DESC NULLS LAST, m.title
+    `
+
+    rows, err := r.db.Query(ctx, query, teacherID, studentID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    materials := []models.StudentMaterialStats{}
+    for rows.Next() {
+        var m models.StudentMaterialStats
+        if err := rows.Scan(
+            &m.MaterialID, &m.Title, &m.Completed, &m.Grade, &m.TimeSpent, &m.CompletedAt,
+            &m.QuizAnswered, &m.QuizCorrect,
+        ); err != nil {
+            return nil, err
+        }
+        materials = append(materials, m)
+    }
+
+    return materials, rows.Err()
+}
@@ -6,10 +6,23 @@ import (
 
     "paydeya-backend/internal/models"
 
-    //"github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgconn"
     "github.com/jackc/pgx/v5/pgxpool"
 )
 
+// querier - общее подмножество pgxpool.Pool и pgx.Tx
+type querier interface {
+    Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+    Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// rowQuerier - querier с выборкой одной строки
+type rowQuerier interface {
+    querier
+    QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
 type BlockRepository struct {
     db *pgxpool.Pool
 }
@@ -27,8 +40,17 @@ func (r *BlockRepository) SaveBlocks(ctx context.Context, materialID int, blocks
     }
     defer tx.Rollback(ctx)
 
+    if err := saveBlocks(ctx, tx, materialID, blocks); err != nil {
+        return err
+    }
+
+    return tx.Commit(ctx)
+}
+
+// saveBlocks перезаписывает блоки материала в рамках переданной транзакции
+func saveBlocks(ctx context.Context, tx pgx.Tx, materialID int, blocks []models.Block) error {
     // Удаляем старые блоки
-    _, err = tx.Exec(ctx, "DELETE FROM material_blocks WHERE material_id = $1", materialID)
+    _, err := tx.Exec(ctx, "DELETE FROM material_blocks WHERE material_id = $1", materialID)
     if err != nil {
         return err
     }
@@ -63,11 +85,16 @@ func (r *BlockRepository) SaveBlocks(ctx context.Context, materialID int, blocks
         }
     }
 
-    return tx.Commit(ctx)
+    return nil
 }
 
 // GetBlocks возвращает блоки материала
 func (r *BlockRepository) GetBlocks(ctx context.Context, materialID int) ([]models.Block, error) {
+    return getBlocks(ctx, r.db, materialID)
+}
+
+// getBlocks читает блоки материала через пул или транзакцию
+func getBlocks(ctx context.Context, q querier, materialID int) ([]models.Block, error) {
     query := `
         SELECT block_id, type, content, styles, animation, position
         FROM material_blocks
@@ -75,7 +102,7 @@ func (r *BlockRepository) GetBlocks(ctx context.Context, materialID int) ([]mode
         ORDER BY position
     `
 
-    rows, err := r.db.Query(ctx, query, materialID)
+    rows, err := q.Query(ctx, query, materialID)
     if err != nil {
         return nil, err
     }
@@ -18,6 +18,23 @@ func NewCatalogRepository(db *pgxpool.Pool) *CatalogRepository {
     return &CatalogRepository{db: db}
 }
 
+// liveMaterialCondition - материал опубликован, не в корзине и доступен по расписанию.
+// Планировщик переводит статусы с задержкой, поэтому окно публикации проверяется и в запросе
+const liveMaterialCondition = `m.status = 'published' AND m.deleted_at IS NULL
+            AND (m.publish_at IS NULL OR m.publish_at <= NOW()) AND (m.unpublish_at IS NULL OR m.unpublish_at > NOW())`
+
+// Средняя оценка, число оценок и число учеников, начавших или завершивших материал.
+// Общие для всех запросов, возвращающих CatalogMaterial
+const (
+    materialRatingColumn       = `COALESCE((SELECT AVG(r.rating) FROM material_ratings r WHERE r.material_id = m.id), 0)`
+    materialRatingsCountColumn = `(SELECT COUNT(*) FROM material_ratings r WHERE r.material_id = m.id)`
+    materialStudentsColumn     = `(SELECT COUNT(*) FROM (
+                SELECT p.user_id FROM material_progress p WHERE p.material_id = m.id
+                UNION
+                SELECT c.user_id FROM material_completions c WHERE c.material_id = m.id
+            ) s)`
+)
+
 // SearchMaterials поиск материалов с фильтрацией
 func (r *CatalogRepository) SearchMaterials(ctx context.Context, filters models.CatalogFilters) ([]models.CatalogMaterial, int, error) {
     // Объявляем переменные здесь, в начале функции
@@ -32,12 +49,24 @@ func (r *CatalogRepository) SearchMaterials(ctx context.Context, filters models.
             m.subject_id as subject,
             u.id as author_id,
             u.full_name as author_name,
-            COALESCE(AVG(mr.rating), 0) as rating,
-            COUNT(DISTINCT mr.user_id) as students_count
+            ` + materialRatingColumn + ` as rating,
+            ` + materialRatingsCountColumn + ` as ratings_count,
+            ` + materialStudentsColumn + ` as students_count,
+            m.description,
+            m.level,
+            m.cover_url,
+            m.language,
+            m.duration_minutes,
+            ` + materialTagsColumn + ` as tags,
+            o.id as origin_id,
+            o.title as origin_title,
+            ou.id as origin_author_id,
+            ou.full_name as origin_author_name
         FROM materials m
         JOIN users u ON m.author_id = u.id
-        LEFT JOIN material_ratings mr ON m.id = mr.material_id
-        WHERE m.status = 'published'
+        LEFT JOIN materials o ON m.forked_from = o.id AND o.author_id <> m.author_id AND o.deleted_at IS NULL
+        LEFT JOIN users ou ON o.author_id = ou.id
+        WHERE ` + liveMaterialCondition + `
     `
 
     var conditions []string
@@ -46,7 +75,10 @@ func (r *CatalogRepository) SearchMaterials(ctx context.Context, filters models.
 
     // Добавляем условия фильтрации
     if filters.Search != "" {
-        conditions = append(conditions, fmt.Sprintf("(m.title ILIKE $%d OR u.full_name ILIKE $%d)", argIndex, argIndex))
+        // content.text текстовых блоков - простой текст, выведенный сервером из документа rich text
+        conditions = append(conditions, fmt.Sprintf(`(m.title ILIKE $%d OR m.description ILIKE $%d OR u.full_name ILIKE $%d
+            OR EXISTS (SELECT 1 FROM material_blocks sb WHERE sb.material_id = m.id AND sb.type = 'text' AND sb.content->>'text' ILIKE $%d))`,
+            argIndex, argIndex, argIndex, argIndex))
         args = append(args, "%"+filters.Search+"%")
         argIndex++
     }
@@ -57,28 +89,46 @@ func (r *CatalogRepository) SearchMaterials(ctx context.Context, filters models.
         argIndex++
     }
 
-    // УБЕРИТЕ фильтр по level - его нет в таблице!
-    // if filters.Level != "" {
-    //     conditions = append(conditions, fmt.Sprintf("m.level = $%d", argIndex))
-    //     args = append(args, filters.Level)
-    //     argIndex++
-    // }
+    if filters.Level != "" {
+        conditions = append(conditions, fmt.Sprintf("m.level = $%d", argIndex))
+        args = append(args, filters.Level)
+        argIndex++
+    }
+
+    // Материал должен иметь все указанные теги
+    if tags := normalizeFilterTags(filters.Tags); len(tags) > 0 {
+        conditions = append(conditions, fmt.Sprintf(`(
+            SELECT COUNT(DISTINCT t.name)
+            FROM material_tags mt JOIN tags t ON mt.tag_id = t.id
+            WHERE mt.material_id = m.id AND t.name = ANY($%d)
+        ) = $%d`, argIndex, argIndex+1))
+        args = append(args, tags, len(tags))
+        argIndex += 2
+    }
+
+    if filters.Language != "" {
+        conditions = append(conditions, fmt.Sprintf("m.language = $%d", argIndex))
+        args = append(args, filters.Language)
+        argIndex++
+    }
+
+    if filters.MaxDuration > 0 {
+        conditions = append(conditions, fmt.Sprintf("m.duration_minutes <= $%d", argIndex))
+        args = append(args, filters.MaxDuration)
+        argIndex++
+    }
 
     // Добавляем условия в запрос
     if len(conditions) > 0 {
         baseQuery += " AND " + strings.Join(conditions, " AND ")
     }
 
-    // Добавляем GROUP BY
-    baseQuery += " GROUP BY m.id, m.title, m.subject_id, u.id, u.full_name"
-
     // Запрос для общего количества - исправленная версия
     countQuery := `
-        SELECT COUNT(DISTINCT m.id)
+        SELECT COUNT(*)
         FROM materials m
         JOIN users u ON m.author_id = u.id
-        LEFT JOIN material_ratings mr ON m.id = mr.material_id
-        WHERE m.status = 'published'
+        WHERE ` + liveMaterialCondition + `
     `
 
     if len(conditions) > 0 {
@@ -118,6 +168,8 @@ func (r *CatalogRepository) SearchMaterials(ctx context.Context, filters models.
     for rows.Next() {
         var material models.CatalogMaterial
         var author models.Author
+        var originID, originAuthorID *int
+        var originTitle, originAuthorName *string
 
         err := rows.Scan(
             &material.ID,
@@ -126,7 +178,18 @@ func (r *CatalogRepository) SearchMaterials(ctx context.Context, filters models.
             &author.ID,
             &author.Name,
             &material.Rating,
+            &material.RatingsCount,
             &material.StudentsCount,
+            &material.Description,
+            &material.Level,
+            &material.CoverURL,
+            &material.Language,
+            &material.Duration,
+            &material.Tags,
+            &originID,
+            &originTitle,
+            &originAuthorID,
+            &originAuthorName,
         )
         if err != nil {
             log.Printf("❌ Error scanning material row: %v", err)
@@ -134,6 +197,15 @@ func (r *CatalogRepository) SearchMaterials(ctx context.Context, filters models.
         }
 
         material.Author = author
+
+        // Указываем автора оригинала для копий чужих материалов
+        if originID != nil && originAuthorID != nil {
+            material.ForkedFrom = &models.MaterialOrigin{
+                ID:     *originID,
+                Title:  *originTitle,
+                Author: models.Author{ID: *originAuthorID, Name: *originAuthorName},
+            }
+        }
         materials = append(materials, material)
     }
 
@@ -147,6 +219,144 @@ func (r *CatalogRepository) SearchMaterials(ctx context.Context, filters models.
     return materials, total, nil
 }
 
+// SearchCourses поиск опубликованных курсов с фильтрацией
+func (r *CatalogRepository) SearchCourses(ctx context.Context, filters models.CourseFilters) ([]models.CatalogCourse, int, error) {
+    var conditions []string
+    var args []interface{}
+    argIndex := 1
+
+    if filters.Search != "" {
+        conditions = append(conditions, fmt.Sprintf("(c.title ILIKE $%d OR c.description ILIKE $%d OR u.full_name ILIKE $%d)", argIndex, argIndex, argIndex))
+        args = append(args, "%"+filters.Search+"%")
+        argIndex++
+    }
+
+    if filters.Subject != "" {
+        conditions = append(conditions, fmt.Sprintf("c.subject_id = $%d", argIndex))
+        args = append(args, filters.Subject)
+        argIndex++
+    }
+
+    if filters.Level != "" {
+        conditions = append(conditions, fmt.Sprintf("c.level = $%d", argIndex))
+        args = append(args, filters.Level)
+        argIndex++
+    }
+
+    where := "WHERE c.status = 'published'"
+    if len(conditions) > 0 {
+        where += " AND " + strings.Join(conditions, " AND ")
+    }
+
+    var total int
+    countQuery := `SELECT COUNT(*) FROM courses c JOIN users u ON c.author_id = u.id ` + where
+    if err := r.db.QueryRow(ctx, countQuery, args...).Scan(&total); err != nil {
+        log.Printf("❌ Error counting courses: %v", err)
+        return nil, 0, err
+    }
+
+    query := `
+        SELECT c.id, c.title, c.subject_id, c.description, c.level, c.cover_url,
+               u.id, u.full_name,
+               (SELECT COUNT(*) FROM course_modules cm WHERE cm.course_id = c.id) as modules_count,
+               (SELECT COUNT(*) FROM course_items ci
+                JOIN materials m ON ci.material_id = m.id
+                WHERE ci.course_id = c.id AND m.deleted_at IS NULL) as materials_count
+        FROM courses c
+        JOIN users u ON c.author_id = u.id
+    ` + where + " ORDER BY c.updated_at DESC"
+
+    if filters.Limit > 0 {
+        query += fmt.Sprintf(" LIMIT $%d", argIndex)
+        args = append(args, filters.Limit)
+        argIndex++
+
+        if filters.Page > 0 {
+            query += fmt.Sprintf(" OFFSET $%d", argIndex)
+            args = append(args, (filters.Page-1)*filters.Limit)
+        }
+    }
+
+    rows, err := r.db.Query(ctx, query, args...)
+    if err != nil {
+        log.Printf("❌ Error querying courses: %v", err)
+        return nil, 0, err
+    }
+    defer rows.Close()
+
+    courses := []models.CatalogCourse{}
+    for rows.Next() {
+        var course models.CatalogCourse
+        if err := rows.Scan(
+            &course.ID, &course.Title, &course.Subject, &course.Description, &course.Level, &course.CoverURL,
+            &course.Author.ID, &course.Author.Name, &course.ModulesCount, &course.MaterialsCount,
+        ); err != nil {
+            log.Printf("❌ Error scanning course row: %v", err)
+            return nil, 0, err
+        }
+        courses = append(courses, course)
+    }
+
+    if err := rows.Err(); err != nil {
+        return nil, 0, err
+    }
+
+    log.Printf("✅ SearchCourses: found %d courses (total: %d)", len(courses), total)
+    return courses, total, nil
+}
+
+// normalizeFilterTags приводит теги фильтра к виду, в котором они хранятся
+func normalizeFilterTags(tags []string) []string {
+    var normalized []string
+    seen := make(map[string]bool)
+
+    for _, tag := range tags {
+        // Поддерживаем как ?tags=a&tags=b, так и ?tags=a,b
+        for _, part := range strings.Split(tag, ",") {
+            part = strings.ToLower(strings.TrimSpace(part))
+            if part == "" || seen[part] {
+                continue
+            }
+            seen[part] = true
+            normalized = append(normalized, part)
+        }
+    }
+
+    return normalized
+}
+
+// GetTags возвращает теги опубликованных материалов с количеством материалов
+func (r *CatalogRepository) GetTags(ctx context.Context) ([]models.Tag, error) {
+    query := `
+        SELECT t.name, COUNT(DISTINCT m.id) as materials_count
+        FROM tags t
+        JOIN material_tags mt ON t.id = mt.tag_id
+        JOIN materials m ON mt.material_id = m.id
+        WHERE ` + liveMaterialCondition + `
+        GROUP BY t.name
+        ORDER BY materials_count DESC, t.name
+    `
+
+    rows, err := r.db.Query(ctx, query)
+    if err != nil {
+        log.Printf("❌ Error querying tags: %v", err)
+        return nil, err
+    }
+    defer rows.Close()
+
+    tags := []models.Tag{}
+    for rows.Next() {
+        var tag models.Tag
+        if err := rows.Scan(&tag.Name, &tag.MaterialsCount); err != nil {
+            log.Printf("❌ Error scanning tag row: %v", err)
+            return nil, err
+        }
+        tags = append(tags, tag)
+    }
+
+    return tags, rows.Err()
+}
+
 // GetSubjects возвращает список предметов
 func (r *CatalogRepository) GetSubjects(ctx context.Context) ([]models.Subject, error) {
     // Получаем уникальные предметы из материалов
@@ -156,7 +366,7 @@ func (r *CatalogRepository) GetSubjects(ctx context.Context) ([]models.Subject,
             COALESCE(s.name, m.subject_id) as name
         FROM materials m
         LEFT JOIN subjects s ON m.subject_id = s.id
-        WHERE m.status = 'published'
+        WHERE ` + liveMaterialCondition + `
         ORDER BY name
     `
 
@@ -195,7 +405,7 @@ func (r *CatalogRepository) SearchTeachers(ctx context.Context, filters models.T
                COUNT(DISTINCT m.id) as materials_count,
                COALESCE(AVG(mr.rating), 0) as rating
         FROM users u
-        LEFT JOIN materials m ON u.id = m.author_id AND m.status = 'published'
+        LEFT JOIN materials m ON u.id = m.author_id AND ` + liveMaterialCondition + `
         LEFT JOIN material_ratings mr ON m.id = mr.material_id
         WHERE u.role = 'teacher'
     `
@@ -0,0 +1,397 @@
+package repositories
+
+import (
+    "context"
+    "time"
+
+    "paydeya-backend/internal/models"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ClassRepository struct {
+    db *pgxpool.Pool
+}
+
+func NewClassRepository(db *pgxpool.Pool) *ClassRepository {
+    return &ClassRepository{db: db}
+}
+
+// CreateClass создает класс. false - код вступления уже занят
+func (r *ClassRepository) CreateClass(ctx context.Context, class *models.Class) (bool, error) {
+    query := `
+        INSERT INTO classes (name, description, teacher_id, join_code)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (join_code) DO NOTHING
+        RETURNING id, created_at, updated_at
+    `
+
+    err := r.db.QueryRow(ctx, query, class.Name, class.Description, class.TeacherID, class.JoinCode).
+        Scan(&class.ID, &class.CreatedAt, &class.UpdatedAt)
+    if err == pgx.ErrNoRows {
+        return false, nil
+    }
+    if err != nil {
+        return false, err
+    }
+
+    return true, nil
+}
+
+const classColumns = `
+    c.id, c.name, c.description, c.teacher_id, u.full_name, c.join_code,
+    (SELECT COUNT(*) FROM class_members cm WHERE cm.class_id = c.id) as students_count,
+    c.created_at, c.updated_at`
+
+func scanClass(row pgx.Row, class *models.Class) error {
+    return row.Scan(
+        &class.ID, &class.Name, &class.Description, &class.TeacherID, &class.TeacherName, &class.JoinCode,
+        &class.StudentsCount, &class.CreatedAt, &class.UpdatedAt,
+    )
+}
+
+// GetClass возвращает класс по ID
+func (r *ClassRepository) GetClass(ctx context.Context, id int) (*models.Class, error) {
+    var class models.Class
+
+    query := `SELECT ` + classColumns + ` FROM classes c JOIN users u ON c.teacher_id = u.id WHERE c.id = $1`
+
+    err := scanClass(r.db.QueryRow(ctx, query, id), &class)
+    if err == pgx.ErrNoRows {
+        return nil, nil
+    }
+
+    return &class, err
+}
+
+// GetClassByCode возвращает класс по коду вступления
+func (r *ClassRepository) GetClassByCode(ctx context.Context, code string) (*models.Class, error) {
+    var class models.Class
+
+    query := `SELECT ` + classColumns + ` FROM classes c JOIN users u ON c.teacher_id = u.id WHERE c.join_code = $1`
+
+    err := scanClass(r.db.QueryRow(ctx, query, code), &class)
+    if err == pgx.ErrNoRows {
+        return nil, nil
+    }
+
+    return &class, err
+}
+
+// GetTeacherClasses возвращает классы преподавателя
+func (r *ClassRepository) GetTeacherClasses(ctx context.Context, teacherID int) ([]*models.Class, error) {
+    query := `
+        SELECT ` + classColumns + `
+        FROM classes c
+        JOIN users u ON c.teacher_id = u.id
+        WHERE c.teacher_id = $1
+        ORDER BY c.name
+    `
+
+    return r.queryClasses(ctx, query, teacherID)
+}
+
+// GetStudentClasses возвращает классы, в которых состоит ученик
+func (r *ClassRepository) GetStudentClasses(ctx context.Context, userID int) ([]*models.Class, error) {
+    query := `
+        SELECT ` + classColumns + `
+        FROM classes c
+        JOIN users u ON c.teacher_id = u.id
+        JOIN class_members m ON m.class_id = c.id
+        WHERE m.user_id = $1
+        ORDER BY c.name
+    `
+
+    return r.queryClasses(ctx, query, userID)
+}
+
+func (r *ClassRepository) queryClasses(ctx context.Context, query string, args ...any) ([]*models.Class, error) {
+    rows, err := r.db.Query(ctx, query, args...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    classes := []*models.Class{}
+    for rows.Next() {
+        var class models.Class
+        if err := scanClass(rows, &class); err != nil {
+            return nil, err
+        }
+        classes = append(classes, &class)
+    }
+
+    return classes, rows.Err()
+}
+
+// UpdateClass обновляет название и описание класса
+func (r *ClassRepository) UpdateClass(ctx context.Context, class *models.Class) error {
+    query := `
+        UPDATE classes
+        SET name = $1, description = $2, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $3
+    `
+
+    _, err := r.db.Exec(ctx, query, class.Name, class.Description, class.ID)
+    return err
+}
+
+// SetJoinCode меняет код вступления. false - код уже занят другим классом
+func (r *ClassRepository) SetJoinCode(ctx context.Context, classID int, code string) (bool, error) {
+    query := `
+        UPDATE classes SET join_code = $1, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $2 AND NOT EXISTS (SELECT 1 FROM classes WHERE join_code = $1)
+    `
+
+    tag, err := r.db.Exec(ctx, query, code, classID)
+    if err != nil {
+        return false, err
+    }
+    return tag.RowsAffected() > 0, nil
+}
+
+// DeleteClass удаляет класс вместе с составом и заданиями
+func (r *ClassRepository) DeleteClass(ctx context.Context, classID int) error {
+    _, err := r.db.Exec(ctx, "DELETE FROM classes WHERE id = $1", classID)
+    return err
+}
+
+// IsMember проверяет, состоит ли ученик в классе
+func (r *ClassRepository) IsMember(ctx context.Context, classID, userID int) (bool, error) {
+    var exists bool
+    query := `SELECT EXISTS(SELECT 1 FROM class_members WHERE class_id = $1 AND user_id = $2)`
+    err := r.db.QueryRow(ctx, query, classID, userID).Scan(&exists)
+    return exists, err
+}
+
+// AddMember добавляет ученика в класс. false - ученик уже в классе
+func (r *ClassRepository) AddMember(ctx context.Context, classID, userID int) (bool, error) {
+    query := `
+        INSERT INTO class_members (class_id, user_id)
+        VALUES ($1, $2)
+        ON CONFLICT DO NOTHING
+    `
+
+    tag, err := r.db.Exec(ctx, query, classID, userID)
+    if err != nil {
+        return false, err
+    }
+    return tag.RowsAffected() > 0, nil
+}
+
+// RemoveMember удаляет ученика из класса
+func (r *ClassRepository) RemoveMember(ctx context.Context, classID, userID int) (bool, error) {
+    tag, err := r.db.Exec(ctx, "DELETE FROM class_members WHERE class_id = $1 AND user_id = $2", classID, userID)
+    if err != nil {
+        return false, err
+    }
+    return tag.RowsAffected() > 0, nil
+}
+
+// GetMembers возвращает состав класса
+func (r *ClassRepository) GetMembers(ctx context.Context, classID int) ([]models.ClassMember, error) {
+    query := `
+        SELECT u.id, u.full_name, u.email, u.avatar_url, m.joined_at
+        FROM class_members m
+        JOIN users u ON m.user_id = u.id
+        WHERE m.class_id = $1
+        ORDER BY u.full_name
+    `
+
+    rows, err := r.db.Query(ctx, query, classID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    members := []models.ClassMember{}
+    for rows.Next() {
+        var member models.ClassMember
+        if err := rows.Scan(&member.UserID, &member.FullName, &member.Email, &member.AvatarURL, &member.JoinedAt); err != nil {
+            return nil, err
+        }
+        members = append(members, member)
+    }
+
+    return members, rows.Err()
+}
+
+// CreateAssignment создает задание класса
+func (r *ClassRepository) CreateAssignment(ctx context.Context, assignment *models.Assignment, createdBy int) error {
+    query := `
+        INSERT INTO class_assignments (class_id, material_id, course_id, note, due_at, created_by)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING id, created_at
+    `
+
+    return r.db.QueryRow(ctx, query,
+        assignment.ClassID, assignment.MaterialID, assignment.CourseID, assignment.Note, assignment.DueAt, createdBy,
+    ).Scan(&assignment.ID, &assignment.CreatedAt)
+}
+
+// Задания материалов из корзины не показываются
+const assignmentFrom = `
+    FROM class_assignments a
+    JOIN classes cl ON a.class_id = cl.id
+    LEFT JOIN materials am ON a.material_id = am.id
+    LEFT JOIN courses ac ON a.course_id = ac.id`
+
+const assignmentWhere = `(a.material_id IS NULL OR am.deleted_at IS NULL)`
+
+const assignmentColumns = `
+    a.id, a.class_id, cl.name, a.material_id, a.course_id, COALESCE(am.title, ac.title, ''),
+    a.note, a.due_at, a.created_at`
+
+func scanAssignment(row pgx.Row, assignment *models.Assignment, extra ...any) error {
+    dest := []any{
+        &assignment.ID, &assignment.ClassID, &assignment.ClassName, &assignment.MaterialID, &assignment.CourseID,
+        &assignment.Title, &assignment.Note, &assignment.DueAt, &assignment.CreatedAt,
+    }
+    return row.Scan(append(dest, extra...)...)
+}
+
+// assignmentProgressJoin считает прохождение задания учеником cm.user_id:
+// для материала - сам материал, для курса - все материалы курса
+const assignmentProgressJoin = `
+    LEFT JOIN LATERAL (
+        SELECT COUNT(*) as total, COUNT(mc.id) as done,
+               MAX(mc.completed_at) as last_completed_at, AVG(mc.grade)::float8 as grade
+        FROM (
+            SELECT a.material_id as material_id WHERE a.material_id IS NOT NULL
+            UNION ALL
+            SELECT ci.material_id FROM course_items ci
+            JOIN materials cim ON ci.material_id = cim.id
+            WHERE ci.course_id = a.course_id AND cim.deleted_at IS NULL
+        ) t
+        LEFT JOIN material_completions mc ON mc.material_id = t.material_id AND mc.user_id = cm.user_id
+    ) p ON true`
+
+// GetAssignment возвращает задание класса
+func (r *ClassRepository) GetAssignment(ctx context.Context, classID, assignmentID int) (*models.Assignment, error) {
+    var assignment models.Assignment
+
+    query := `SELECT ` + assignmentColumns + assignmentFrom + `
+        WHERE a.class_id = $1 AND a.id = $2 AND ` + assignmentWhere
+
+    err := scanAssignment(r.db.QueryRow(ctx, query, classID, assignmentID), &assignment)
+    if err == pgx.ErrNoRows {
+        return nil, nil
+    }
+
+    return &assignment, err
+}
+
+// GetClassAssignments возвращает задания класса
+func (r *ClassRepository) GetClassAssignments(ctx context.Context, classID int) ([]models.Assignment, error) {
+    query := `SELECT ` + assignmentColumns + assignmentFrom + `
+        WHERE a.class_id = $1 AND ` + assignmentWhere + `
+        ORDER BY a.due_at NULLS LAST, a.created_at`
+
+    rows, err := r.db.Query(ctx, query, classID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    assignments := []models.Assignment{}
+    for rows.Next() {
+        var assignment models.Assignment
+        if err := scanAssignment(rows, &assignment); err != nil {
+            return nil, err
+        }
+        assignments = append(assignments, assignment)
+    }
+
+    return assignments, rows.Err()
+}
+
+// UpdateAssignment обновляет комментарий и срок задания
+func (r *ClassRepository) UpdateAssignment(ctx context.Context, assignment *models.Assignment) error {
+    query := `UPDATE class_assignments SET note = $1, due_at = $2 WHERE id = $3 AND class_id = $4`
+
+    _, err := r.db.Exec(ctx, query, assignment.Note, assignment.DueAt, assignment.ID, assignment.ClassID)
+    return err
+}
+
+// DeleteAssignment удаляет задание класса
+func (r *ClassRepository) DeleteAssignment(ctx context.Context, classID, assignmentID int) (bool, error) {
+    tag, err := r.db.Exec(ctx, "DELETE FROM class_assignments WHERE id = $1 AND class_id = $2", assignmentID, classID)
+    if err != nil {
+        return false, err
+    }
+    return tag.RowsAffected() > 0, nil
+}
+
+// AssignmentProgress - прохождение задания учеником
+type AssignmentProgress struct {
+    Total           int
+    Done            int
+    LastCompletedAt *time.Time
+    Grade           *float64
+}
+
+// GetStudentAssignments возвращает задания всех классов ученика с его прогрессом
+func (r *ClassRepository) GetStudentAssignments(ctx context.Context, userID int) ([]models.Assignment, []AssignmentProgress, error) {
+    query := `SELECT ` + assignmentColumns + `, p.total, p.done, p.last_completed_at, p.grade` + assignmentFrom + `
+        JOIN class_members cm ON cm.class_id = a.class_id AND cm.user_id = $1` + assignmentProgressJoin + `
+        WHERE ` + assignmentWhere + `
+        ORDER BY a.due_at NULLS LAST, a.created_at`
+
+    rows, err := r.db.Query(ctx, query, userID)
+    if err != nil {
+        return nil, nil, err
+    }
+    defer rows.Close()
+
+    var assignments []models.Assignment
+    var progress []AssignmentProgress
+    for rows.Next() {
+        var assignment models.Assignment
+        var p AssignmentProgress
+        if err := scanAssignment(rows, &assignment, &p.Total, &p.Done, &p.LastCompletedAt, &p.Grade); err != nil {
+            return nil, nil, err
+        }
+        assignments = append(assignments, assignment)
+        progress = append(progress, p)
+    }
+
+    return assignments, progress, rows.Err()
+}
+
+// MemberProgress - прохождение задания учеником класса
+type MemberProgress struct {
+    AssignmentID int
+    UserID       int
+    FullName     string
+    AssignmentProgress
+}
+
+// GetClassProgress возвращает прохождение всех заданий класса всеми учениками
+func (r *ClassRepository) GetClassProgress(ctx context.Context, classID int) ([]MemberProgress, error) {
+    query := `
+        SELECT a.id, u.id, u.full_name, p.total, p.done, p.last_completed_at, p.grade` + assignmentFrom + `
+        JOIN class_members cm ON cm.class_id = a.class_id
+        JOIN users u ON cm.user_id = u.id` + assignmentProgressJoin + `
+        WHERE a.class_id = $1 AND ` + assignmentWhere + `
+        ORDER BY a.id, u.full_name`
+
+    rows, err := r.db.Query(ctx, query, classID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var progress []MemberProgress
+    for rows.Next() {
+        var p MemberProgress
+        if err := rows.Scan(
+            &p.AssignmentID, &p.UserID, &p.FullName, &p.Total, &p.Done, &p.LastCompletedAt, &p.Grade,
+        ); err != nil {
+            return nil, err
+        }
+        progress = append(progress, p)
+    }
+
+    return progress, rows.Err()
+}
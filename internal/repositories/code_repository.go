@@ -0,0 +1,93 @@
+package repositories
+
+import (
+    "context"
+    "encoding/json"
+    "time"
+
+    "paydeya-backend/internal/models"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgxpool"
+)
+
+type CodeRepository struct {
+    db *pgxpool.Pool
+}
+
+func NewCodeRepository(db *pgxpool.Pool) *CodeRepository {
+    return &CodeRepository{db: db}
+}
+
+// SaveSubmission сохраняет проверенное решение и записывает его итог в ответы на вопросы
+// материала: пройденное упражнение учитывается в прогрессе и аналитике как верный ответ
+func (r *CodeRepository) SaveSubmission(ctx context.Context, userID int, submission *models.CodeSubmission) error {
+    tx, err := r.db.Begin(ctx)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback(ctx)
+
+    resultsJSON, err := json.Marshal(submission.Results)
+    if err != nil {
+        return err
+    }
+
+    query := `
+        INSERT INTO code_submissions (user_id, material_id, block_id, language, code, passed, tests_passed, tests_total, results, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+        RETURNING id
+    `
+
+    submission.CreatedAt = time.Now()
+    err = tx.QueryRow(ctx, query,
+        userID, submission.MaterialID, submission.BlockID, submission.Language, submission.Code,
+        submission.Passed, submission.TestsPassed, submission.TestsTotal, resultsJSON, submission.CreatedAt,
+    ).Scan(&submission.ID)
+    if err != nil {
+        return err
+    }
+
+    answerQuery := `
+        INSERT INTO quiz_answers (user_id, material_id, block_id, correct, answered_at)
+        VALUES ($1, $2, $3, $4, $5)
+        ON CONFLICT (user_id, material_id, block_id)
+        DO UPDATE SET correct = EXCLUDED.correct, answered_at = EXCLUDED.answered_at
+    `
+
+    if _, err := tx.Exec(ctx, answerQuery, userID, submission.MaterialID, submission.BlockID, submission.Passed, submission.CreatedAt); err != nil {
+        return err
+    }
+
+    return tx.Commit(ctx)
+}
+
+// GetLastSubmission возвращает последнее решение ученика в блоке или nil
+func (r *CodeRepository) GetLastSubmission(ctx context.Context, userID, materialID int, blockID string) (*models.CodeSubmission, error) {
+    query := `
+        SELECT id, material_id, block_id, language, code, passed, tests_passed, tests_total, results, created_at
+        FROM code_submissions
+        WHERE user_id = $1 AND material_id = $2 AND block_id = $3
+        ORDER BY created_at DESC
+        LIMIT 1
+    `
+
+    var submission models.CodeSubmission
+    var resultsJSON []byte
+    err := r.db.QueryRow(ctx, query, userID, materialID, blockID).Scan(
+        &submission.ID, &submission.MaterialID, &submission.BlockID, &submission.Language, &submission.Code,
+        &submission.Passed, &submission.TestsPassed, &submission.TestsTotal, &resultsJSON, &submission.CreatedAt,
+    )
+    if err == pgx.ErrNoRows {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    if err := json.Unmarshal(resultsJSON, &submission.Results); err != nil {
+        return nil, err
+    }
+
+    return &submission, nil
+}
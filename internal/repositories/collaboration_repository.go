@@ -0,0 +1,390 @@
+package repositories
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "time"
+
+    "paydeya-backend/internal/models"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgxpool"
+)
+
+// CollaborationChannel - канал Postgres LISTEN/NOTIFY для событий совместного редактирования
+const CollaborationChannel = "material_collaboration"
+
+type CollaborationRepository struct {
+    db *pgxpool.Pool
+}
+
+func NewCollaborationRepository(db *pgxpool.Pool) *CollaborationRepository {
+    return &CollaborationRepository{db: db}
+}
+
+// canEditQuery проверяет, что пользователь $2 - автор материала $1 или его соавтор-редактор.
+// Роль перечитывается в транзакции изменения: пониженный или удаленный соавтор
+// теряет права сразу, даже если его подключение открыто с прежней ролью
+const canEditQuery = `
+    SELECT EXISTS(SELECT 1 FROM materials WHERE id = $1 AND author_id = $2)
+        OR EXISTS(SELECT 1 FROM material_collaborators WHERE material_id = $1 AND user_id = $2 AND role = 'editor')
+`
+
+// ApplyOperation применяет операцию к блокам материала под блокировкой строки материала.
+// Операции одного материала получают последовательные номера независимо от инстанса бэкенда,
+// а уведомление об операции уходит подписчикам только после коммита
+func (r *CollaborationRepository) ApplyOperation(ctx context.Context, op *models.BlockOperation, apply func([]models.Block) ([]models.Block, error)) error {
+    tx, err := r.db.Begin(ctx)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback(ctx)
+
+    var seq int64
+    err = tx.QueryRow(ctx, "SELECT op_seq FROM materials WHERE id = $1 FOR UPDATE", op.MaterialID).Scan(&seq)
+    if err == pgx.ErrNoRows {
+        return fmt.Errorf("material not found")
+    }
+    if err != nil {
+        return err
+    }
+
+    var canEdit bool
+    if err := tx.QueryRow(ctx, canEditQuery, op.MaterialID, op.UserID).Scan(&canEdit); err != nil {
+        return err
+    }
+    if !canEdit {
+        return fmt.Errorf("access denied")
+    }
+
+    if err := checkLocks(ctx, tx, op); err != nil {
+        return err
+    }
+
+    blocks, err := getBlocks(ctx, tx, op.MaterialID)
+    if err != nil {
+        return err
+    }
+
+    newBlocks, err := apply(blocks)
+    if err != nil {
+        return err
+    }
+
+    if err := saveBlocks(ctx, tx, op.MaterialID, newBlocks); err != nil {
+        return err
+    }
+
+    op.Seq = seq + 1
+    op.CreatedAt = time.Now()
+
+    _, err = tx.Exec(ctx,
+        "UPDATE materials SET op_seq = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2",
+        op.Seq, op.MaterialID,
+    )
+    if err != nil {
+        return err
+    }
+
+    payload, err := json.Marshal(op)
+    if err != nil {
+        return err
+    }
+
+    var blockID *string
+    if op.BlockID != "" {
+        blockID = &op.BlockID
+    }
+
+    _, err = tx.Exec(ctx, `
+        INSERT INTO material_operations (material_id, seq, user_id, type, block_id, payload, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+    `, op.MaterialID, op.Seq, op.UserID, op.Type, blockID, payload, op.CreatedAt)
+    if err != nil {
+        return err
+    }
+
+    // В уведомление кладем только ссылку на операцию: payload NOTIFY ограничен 8000 байт
+    event, err := json.Marshal(models.CollaborationEvent{
+        Type:       "operation",
+        MaterialID: op.MaterialID,
+        Seq:        op.Seq,
+        UserID:     op.UserID,
+    })
+    if err != nil {
+        return err
+    }
+
+    if _, err := tx.Exec(ctx, "SELECT pg_notify($1, $2)", CollaborationChannel, string(event)); err != nil {
+        return err
+    }
+
+    return tx.Commit(ctx)
+}
+
+// checkLocks запрещает менять блоки, заблокированные другим пользователем
+func checkLocks(ctx context.Context, tx pgx.Tx, op *models.BlockOperation) error {
+    var lockedBy int
+
+    switch op.Type {
+    case "update", "delete":
+        err := tx.QueryRow(ctx, `
+            SELECT user_id FROM material_block_locks
+            WHERE material_id = $1 AND block_id = $2 AND user_id <> $3 AND expires_at > CURRENT_TIMESTAMP
+        `, op.MaterialID, op.BlockID, op.UserID).Scan(&lockedBy)
+        if err == pgx.ErrNoRows {
+            return nil
+        }
+        if err != nil {
+            return err
+        }
+        return fmt.Errorf("block is locked")
+    case "replace":
+        err := tx.QueryRow(ctx, `
+            SELECT user_id FROM material_block_locks
+            WHERE material_id = $1 AND user_id <> $2 AND expires_at > CURRENT_TIMESTAMP
+            LIMIT 1
+        `, op.MaterialID, op.UserID).Scan(&lockedBy)
+        if err == pgx.ErrNoRows {
+            return nil
+        }
+        if err != nil {
+            return err
+        }
+        return fmt.Errorf("block is locked")
+    }
+
+    return nil
+}
+
+// GetSeq возвращает номер последней операции материала
+func (r *CollaborationRepository) GetSeq(ctx context.Context, materialID int) (int64, error) {
+    var seq int64
+    err := r.db.QueryRow(ctx, "SELECT op_seq FROM materials WHERE id = $1", materialID).Scan(&seq)
+    return seq, err
+}
+
+// GetSnapshot согласованно читает блоки материала и номер последней операции
+func (r *CollaborationRepository) GetSnapshot(ctx context.Context, materialID int) (int64, []models.Block, error) {
+    tx, err := r.db.Begin(ctx)
+    if err != nil {
+        return 0, nil, err
+    }
+    defer tx.Rollback(ctx)
+
+    // FOR SHARE не дает операции закоммититься между чтением номера и блоков
+    var seq int64
+    err = tx.QueryRow(ctx, "SELECT op_seq FROM materials WHERE id = $1 FOR SHARE", materialID).Scan(&seq)
+    if err == pgx.ErrNoRows {
+        return 0, nil, fmt.Errorf("material not found")
+    }
+    if err != nil {
+        return 0, nil, err
+    }
+
+    blocks, err := getBlocks(ctx, tx, materialID)
+    if err != nil {
+        return 0, nil, err
+    }
+
+    return seq, blocks, tx.Commit(ctx)
+}
+
+// GetOperation возвращает операцию по номеру
+func (r *CollaborationRepository) GetOperation(ctx context.Context, materialID int, seq int64) (*models.BlockOperation, error) {
+    var payload []byte
+
+    query := `SELECT payload FROM material_operations WHERE material_id = $1 AND seq = $2`
+    err := r.db.QueryRow(ctx, query, materialID, seq).Scan(&payload)
+    if err == pgx.ErrNoRows {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    var op models.BlockOperation
+    if err := json.Unmarshal(payload, &op); err != nil {
+        return nil, err
+    }
+
+    return &op, nil
+}
+
+// GetOperationsSince возвращает операции материала после указанного номера
+func (r *CollaborationRepository) GetOperationsSince(ctx context.Context, materialID int, since int64, limit int) ([]models.BlockOperation, error) {
+    query := `
+        SELECT payload FROM material_operations
+        WHERE material_id = $1 AND seq > $2
+        ORDER BY seq
+        LIMIT $3
+    `
+
+    rows, err := r.db.Query(ctx, query, materialID, since, limit)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    operations := []models.BlockOperation{}
+    for rows.Next() {
+        var payload []byte
+        if err := rows.Scan(&payload); err != nil {
+            return nil, err
+        }
+
+        var op models.BlockOperation
+        if err := json.Unmarshal(payload, &op); err != nil {
+            return nil, err
+        }
+        operations = append(operations, op)
+    }
+
+    return operations, rows.Err()
+}
+
+// PruneOperations удаляет из журнала операции старше указанного момента и возвращает их число
+func (r *CollaborationRepository) PruneOperations(ctx context.Context, before time.Time) (int64, error) {
+    tag, err := r.db.Exec(ctx, "DELETE FROM material_operations WHERE created_at < $1", before)
+    if err != nil {
+        return 0, err
+    }
+    return tag.RowsAffected(), nil
+}
+
+// AcquireLock захватывает блок для редактирования, если пользователь может редактировать материал.
+// Истекшую блокировку можно перехватить
+func (r *CollaborationRepository) AcquireLock(ctx context.Context, materialID int, blockID string, userID int, ttl time.Duration) (bool, error) {
+    tx, err := r.db.Begin(ctx)
+    if err != nil {
+        return false, err
+    }
+    defer tx.Rollback(ctx)
+
+    var canEdit bool
+    if err := tx.QueryRow(ctx, canEditQuery, materialID, userID).Scan(&canEdit); err != nil {
+        return false, err
+    }
+    if !canEdit {
+        return false, fmt.Errorf("access denied")
+    }
+
+    query := `
+        INSERT INTO material_block_locks (material_id, block_id, user_id, acquired_at, expires_at)
+        VALUES ($1, $2, $3, CURRENT_TIMESTAMP, $4)
+        ON CONFLICT (material_id, block_id)
+        DO UPDATE SET user_id = EXCLUDED.user_id, acquired_at = EXCLUDED.acquired_at, expires_at = EXCLUDED.expires_at
+        WHERE material_block_locks.user_id = EXCLUDED.user_id
+           OR material_block_locks.expires_at <= CURRENT_TIMESTAMP
+    `
+
+    tag, err := tx.Exec(ctx, query, materialID, blockID, userID, time.Now().Add(ttl))
+    if err != nil {
+        return false, err
+    }
+    return tag.RowsAffected() > 0, tx.Commit(ctx)
+}
+
+// ReleaseLock снимает блокировку блока, принадлежащую пользователю
+func (r *CollaborationRepository) ReleaseLock(ctx context.Context, materialID int, blockID string, userID int) (bool, error) {
+    query := `DELETE FROM material_block_locks WHERE material_id = $1 AND block_id = $2 AND user_id = $3`
+
+    tag, err := r.db.Exec(ctx, query, materialID, blockID, userID)
+    if err != nil {
+        return false, err
+    }
+    return tag.RowsAffected() > 0, nil
+}
+
+// ReleaseUserLocks снимает все блокировки пользователя в материале и возвращает ID освобожденных блоков
+func (r *CollaborationRepository) ReleaseUserLocks(ctx context.Context, materialID, userID int) ([]string, error) {
+    query := `DELETE FROM material_block_locks WHERE material_id = $1 AND user_id = $2 RETURNING block_id`
+
+    rows, err := r.db.Query(ctx, query, materialID, userID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var blockIDs []string
+    for rows.Next() {
+        var blockID string
+        if err := rows.Scan(&blockID); err != nil {
+            return nil, err
+        }
+        blockIDs = append(blockIDs, blockID)
+    }
+
+    return blockIDs, rows.Err()
+}
+
+// GetLocks возвращает действующие блокировки блоков материала
+func (r *CollaborationRepository) GetLocks(ctx context.Context, materialID int) ([]models.BlockLock, error) {
+    query := `
+        SELECT l.block_id, l.user_id, u.full_name, l.expires_at
+        FROM material_block_locks l
+        JOIN users u ON l.user_id = u.id
+        WHERE l.material_id = $1 AND l.expires_at > CURRENT_TIMESTAMP
+        ORDER BY l.acquired_at
+    `
+
+    rows, err := r.db.Query(ctx, query, materialID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    locks := []models.BlockLock{}
+    for rows.Next() {
+        var lock models.BlockLock
+        if err := rows.Scan(&lock.BlockID, &lock.UserID, &lock.UserName, &lock.ExpiresAt); err != nil {
+            return nil, err
+        }
+        locks = append(locks, lock)
+    }
+
+    return locks, rows.Err()
+}
+
+// Notify рассылает событие всем инстансам бэкенда
+func (r *CollaborationRepository) Notify(ctx context.Context, event *models.CollaborationEvent) error {
+    payload, err := json.Marshal(event)
+    if err != nil {
+        return err
+    }
+
+    _, err = r.db.Exec(ctx, "SELECT pg_notify($1, $2)", CollaborationChannel, string(payload))
+    return err
+}
+
+// Listen подписывается на канал совместного редактирования и вызывает handle для каждого события.
+// Блокирует выполнение до ошибки соединения или отмены контекста
+func (r *CollaborationRepository) Listen(ctx context.Context, handle func(*models.CollaborationEvent)) error {
+    conn, err := r.db.Acquire(ctx)
+    if err != nil {
+        return err
+    }
+    defer func() {
+        // Соединение возвращается в пул, поэтому снимаем подписку
+        conn.Exec(context.Background(), "UNLISTEN "+CollaborationChannel)
+        conn.Release()
+    }()
+
+    if _, err := conn.Exec(ctx, "LISTEN "+CollaborationChannel); err != nil {
+        return err
+    }
+
+    for {
+        notification, err := conn.Conn().WaitForNotification(ctx)
+        if err != nil {
+            return err
+        }
+
+        var event models.CollaborationEvent
+        if err := json.Unmarshal([]byte(notification.Payload), &event); err != nil {
+            continue
+        }
+        handle(&event)
+    }
+}
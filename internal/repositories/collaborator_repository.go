@@ -0,0 +1,172 @@
+package repositories
+
+import (
+    "context"
+
+    "paydeya-backend/internal/models"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgxpool"
+)
+
+type CollaboratorRepository struct {
+    db *pgxpool.Pool
+}
+
+func NewCollaboratorRepository(db *pgxpool.Pool) *CollaboratorRepository {
+    return &CollaboratorRepository{db: db}
+}
+
+// GetCollaboratorRole возвращает роль пользователя в материале (пустая строка, если он не соавтор)
+func (r *CollaboratorRepository) GetCollaboratorRole(ctx context.Context, materialID, userID int) (string, error) {
+    var role string
+
+    query := `SELECT role FROM material_collaborators WHERE material_id = $1 AND user_id = $2`
+    err := r.db.QueryRow(ctx, query, materialID, userID).Scan(&role)
+    if err == pgx.ErrNoRows {
+        return "", nil
+    }
+
+    return role, err
+}
+
+// GetCollaborators возвращает соавторов материала
+func (r *CollaboratorRepository) GetCollaborators(ctx context.Context, materialID int) ([]models.Collaborator, error) {
+    query := `
+        SELECT mc.material_id, u.id, u.email, u.full_name, mc.role, mc.invited_by, mc.created_at
+        FROM material_collaborators mc
+        JOIN users u ON mc.user_id = u.id
+        WHERE mc.material_id = $1
+        ORDER BY mc.created_at
+    `
+
+    rows, err := r.db.Query(ctx, query, materialID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    collaborators := []models.Collaborator{}
+    for rows.Next() {
+        var collaborator models.Collaborator
+        if err := rows.Scan(
+            &collaborator.MaterialID, &collaborator.UserID, &collaborator.Email, &collaborator.FullName,
+            &collaborator.Role, &collaborator.InvitedBy, &collaborator.CreatedAt,
+        ); err != nil {
+            return nil, err
+        }
+        collaborators = append(collaborators, collaborator)
+    }
+
+    return collaborators, rows.Err()
+}
+
+// AddCollaborator добавляет соавтора (или меняет роль, если он уже приглашен)
+func (r *CollaboratorRepository) AddCollaborator(ctx context.Context, materialID, userID int, role string, invitedBy int) error {
+    query := `
+        INSERT INTO material_collaborators (material_id, user_id, role, invited_by)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (material_id, user_id)
+        DO UPDATE SET role = EXCLUDED.role, updated_at = CURRENT_TIMESTAMP
+    `
+
+    _, err := r.db.Exec(ctx, query, materialID, userID, role, invitedBy)
+    return err
+}
+
+// UpdateCollaboratorRole меняет роль соавтора
+func (r *CollaboratorRepository) UpdateCollaboratorRole(ctx context.Context, materialID, userID int, role string) (bool, error) {
+    query := `
+        UPDATE material_collaborators
+        SET role = $1, updated_at = CURRENT_TIMESTAMP
+        WHERE material_id = $2 AND user_id = $3
+    `
+
+    tag, err := r.db.Exec(ctx, query, role, materialID, userID)
+    if err != nil {
+        return false, err
+    }
+    return tag.RowsAffected() > 0, nil
+}
+
+// RemoveCollaborator удаляет соавтора из материала
+func (r *CollaboratorRepository) RemoveCollaborator(ctx context.Context, materialID, userID int) (bool, error) {
+    query := `DELETE FROM material_collaborators WHERE material_id = $1 AND user_id = $2`
+
+    tag, err := r.db.Exec(ctx, query, materialID, userID)
+    if err != nil {
+        return false, err
+    }
+    return tag.RowsAffected() > 0, nil
+}
+
+// TransferOwnership передает материал другому пользователю, прежний владелец становится редактором
+func (r *CollaboratorRepository) TransferOwnership(ctx context.Context, materialID, fromUserID, toUserID int) error {
+    tx, err := r.db.Begin(ctx)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback(ctx)
+
+    _, err = tx.Exec(ctx,
+        "UPDATE materials SET author_id = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2 AND author_id = $3",
+        toUserID, materialID, fromUserID,
+    )
+    if err != nil {
+        return err
+    }
+
+    // Новый владелец больше не числится соавтором
+    _, err = tx.Exec(ctx,
+        "DELETE FROM material_collaborators WHERE material_id = $1 AND user_id = $2",
+        materialID, toUserID,
+    )
+    if err != nil {
+        return err
+    }
+
+    _, err = tx.Exec(ctx, `
+        INSERT INTO material_collaborators (material_id, user_id, role, invited_by)
+        VALUES ($1, $2, 'editor', $3)
+        ON CONFLICT (material_id, user_id)
+        DO UPDATE SET role = 'editor', updated_at = CURRENT_TIMESTAMP
+    `, materialID, fromUserID, toUserID)
+    if err != nil {
+        return err
+    }
+
+    return tx.Commit(ctx)
+}
+
+// GetSharedMaterials возвращает материалы, в которых пользователь является соавтором
+func (r *CollaboratorRepository) GetSharedMaterials(ctx context.Context, userID int) ([]*models.SharedMaterial, error) {
+    query := `
+        SELECT m.id, m.title, m.subject_id, m.author_id, u.full_name, m.status, m.access,
+               m.created_at, m.updated_at, mc.role
+        FROM material_collaborators mc
+        JOIN materials m ON mc.material_id = m.id
+        JOIN users u ON m.author_id = u.id
+        WHERE mc.user_id = $1 AND m.deleted_at IS NULL
+        ORDER BY m.updated_at DESC
+    `
+
+    rows, err := r.db.Query(ctx, query, userID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    materials := []*models.SharedMaterial{}
+    for rows.Next() {
+        var material models.SharedMaterial
+        if err := rows.Scan(
+            &material.ID, &material.Title, &material.Subject, &material.AuthorID, &material.AuthorName,
+            &material.Status, &material.Access, &material.CreatedAt, &material.UpdatedAt, &material.Role,
+        ); err != nil {
+            return nil, err
+        }
+        materials = append(materials, &material)
+    }
+
+    return materials, rows.Err()
+}
@@ -0,0 +1,205 @@
+package repositories
+
+import (
+    "context"
+    "fmt"
+
+    "paydeya-backend/internal/models"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgxpool"
+)
+
+type CommentRepository struct {
+    db *pgxpool.Pool
+}
+
+func NewCommentRepository(db *pgxpool.Pool) *CommentRepository {
+    return &CommentRepository{db: db}
+}
+
+// CreateComment сохраняет комментарий
+func (r *CommentRepository) CreateComment(ctx context.Context, comment *models.MaterialComment) error {
+    query := `
+        INSERT INTO material_comments (material_id, block_id, parent_id, user_id, content, is_author)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING id, created_at
+    `
+
+    return r.db.QueryRow(ctx, query,
+        comment.MaterialID, comment.BlockID, comment.ParentID, comment.UserID, comment.Content, comment.IsAuthor,
+    ).Scan(&comment.ID, &comment.CreatedAt)
+}
+
+// commentColumns - поля комментария; viewerParam - номер параметра с пользователем,
+// для которого отмечаются его голоса. Блок обсуждения берется из material_blocks:
+// у удаленного блока он пуст, и обсуждение остается у материала
+func commentColumns(viewerParam int) string {
+    return fmt.Sprintf(`
+        c.id, c.material_id, b.block_id, c.parent_id, c.user_id, u.full_name, COALESCE(u.avatar_url, ''),
+        c.content, c.is_author, c.upvotes,
+        EXISTS (SELECT 1 FROM material_comment_votes v WHERE v.comment_id = c.id AND v.user_id = $%d),
+        c.resolved, c.hidden, c.deleted_at IS NOT NULL, c.created_at
+    `, viewerParam)
+}
+
+const commentJoins = `
+    FROM material_comments c
+    JOIN users u ON c.user_id = u.id
+    LEFT JOIN material_blocks b ON b.material_id = c.material_id AND b.block_id = c.block_id
+`
+
+func scanComment(row pgx.Row) (*models.MaterialComment, error) {
+    var comment models.MaterialComment
+    err := row.Scan(
+        &comment.ID, &comment.MaterialID, &comment.BlockID, &comment.ParentID, &comment.UserID, &comment.UserName,
+        &comment.UserAvatar, &comment.Content, &comment.IsAuthor, &comment.Upvotes, &comment.Upvoted,
+        &comment.Resolved, &comment.Hidden, &comment.Deleted, &comment.CreatedAt,
+    )
+    return &comment, err
+}
+
+func scanComments(rows pgx.Rows, err error) ([]models.MaterialComment, error) {
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    comments := []models.MaterialComment{}
+    for rows.Next() {
+        comment, err := scanComment(rows)
+        if err != nil {
+            return nil, err
+        }
+        comments = append(comments, *comment)
+    }
+    return comments, rows.Err()
+}
+
+// GetComment возвращает комментарий (в том числе удаленный) или nil
+func (r *CommentRepository) GetComment(ctx context.Context, commentID, viewerID int) (*models.MaterialComment, error) {
+    comment, err := scanComment(r.db.QueryRow(ctx, `SELECT `+commentColumns(2)+commentJoins+` WHERE c.id = $1`, commentID, viewerID))
+    if err == pgx.ErrNoRows {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    return comment, nil
+}
+
+// GetThreads возвращает вопросы к материалу (без ответов) и их общее количество.
+// Удаленный вопрос, а без withHidden и скрытый, остается в списке, пока у него есть видимые ответы
+func (r *CommentRepository) GetThreads(ctx context.Context, materialID, viewerID int, withHidden bool, filters models.CommentFilters) ([]models.MaterialComment, int, error) {
+    args := []interface{}{materialID, withHidden}
+    where := `
+        WHERE c.material_id = $1 AND c.parent_id IS NULL
+          AND ((c.deleted_at IS NULL AND (NOT c.hidden OR $2)) OR EXISTS (
+              SELECT 1 FROM material_comments rc
+              WHERE rc.parent_id = c.id AND rc.deleted_at IS NULL AND (NOT rc.hidden OR $2)
+          ))
+    `
+    if filters.BlockID != "" {
+        args = append(args, filters.BlockID)
+        where += fmt.Sprintf(" AND b.block_id = $%d", len(args))
+    }
+
+    var total int
+    if err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM material_comments c
+        LEFT JOIN material_blocks b ON b.material_id = c.material_id AND b.block_id = c.block_id`+where, args...).Scan(&total); err != nil {
+        return nil, 0, err
+    }
+
+    order := ` ORDER BY c.created_at DESC, c.id DESC`
+    switch filters.Sort {
+    case "old":
+        order = ` ORDER BY c.created_at, c.id`
+    case "top":
+        order = ` ORDER BY c.upvotes DESC, c.created_at DESC, c.id DESC`
+    }
+
+    args = append(args, viewerID)
+    columns := commentColumns(len(args))
+    query := `SELECT ` + columns + commentJoins + where + order + pageClause(&args, filters.Page, filters.Limit)
+    threads, err := scanComments(r.db.Query(ctx, query, args...))
+    if err != nil {
+        return nil, 0, err
+    }
+    return threads, total, nil
+}
+
+// GetReplies возвращает неудаленные ответы на вопросы по порядку, сгруппированные по вопросу
+func (r *CommentRepository) GetReplies(ctx context.Context, threadIDs []int, viewerID int) (map[int][]models.MaterialComment, error) {
+    replies := make(map[int][]models.MaterialComment)
+    if len(threadIDs) == 0 {
+        return replies, nil
+    }
+
+    query := `SELECT ` + commentColumns(2) + commentJoins + `
+        WHERE c.parent_id = ANY($1) AND c.deleted_at IS NULL
+        ORDER BY c.created_at, c.id
+    `
+    comments, err := scanComments(r.db.Query(ctx, query, threadIDs, viewerID))
+    if err != nil {
+        return nil, err
+    }
+    for _, comment := range comments {
+        replies[*comment.ParentID] = append(replies[*comment.ParentID], comment)
+    }
+    return replies, nil
+}
+
+// SetVote ставит или снимает голос пользователя и возвращает число голосов
+func (r *CommentRepository) SetVote(ctx context.Context, commentID, userID int, upvote bool) (int, error) {
+    tx, err := r.db.Begin(ctx)
+    if err != nil {
+        return 0, err
+    }
+    defer tx.Rollback(ctx)
+
+    query := `INSERT INTO material_comment_votes (comment_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+    delta := 1
+    if !upvote {
+        query = `DELETE FROM material_comment_votes WHERE comment_id = $1 AND user_id = $2`
+        delta = -1
+    }
+    tag, err := tx.Exec(ctx, query, commentID, userID)
+    if err != nil {
+        return 0, err
+    }
+    if tag.RowsAffected() == 0 {
+        delta = 0
+    }
+
+    var upvotes int
+    err = tx.QueryRow(ctx, `
+        UPDATE material_comments SET upvotes = upvotes + $1 WHERE id = $2 RETURNING upvotes
+    `, delta, commentID).Scan(&upvotes)
+    if err != nil {
+        return 0, err
+    }
+
+    return upvotes, tx.Commit(ctx)
+}
+
+// SetResolved отмечает вопрос решенным или снимает отметку
+func (r *CommentRepository) SetResolved(ctx context.Context, commentID int, resolved bool) error {
+    _, err := r.db.Exec(ctx, `UPDATE material_comments SET resolved = $1 WHERE id = $2`, resolved, commentID)
+    return err
+}
+
+// SetHidden скрывает комментарий или снова показывает его
+func (r *CommentRepository) SetHidden(ctx context.Context, commentID, moderatorID int, hidden bool) error {
+    _, err := r.db.Exec(ctx, `
+        UPDATE material_comments SET hidden = $1, moderated_by = $2 WHERE id = $3
+    `, hidden, moderatorID, commentID)
+    return err
+}
+
+// DeleteComment помечает комментарий удаленным: ответы на удаленный вопрос остаются в обсуждении
+func (r *CommentRepository) DeleteComment(ctx context.Context, commentID int) error {
+    _, err := r.db.Exec(ctx, `
+        UPDATE material_comments SET deleted_at = CURRENT_TIMESTAMP WHERE id = $1 AND deleted_at IS NULL
+    `, commentID)
+    return err
+}
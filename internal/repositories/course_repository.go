@@ -0,0 +1,455 @@
+package repositories
+
+import (
+    "context"
+    "fmt"
+    "time"
+
+    "paydeya-backend/internal/models"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgxpool"
+)
+
+type CourseRepository struct {
+    db *pgxpool.Pool
+}
+
+func NewCourseRepository(db *pgxpool.Pool) *CourseRepository {
+    return &CourseRepository{db: db}
+}
+
+// CreateCourse создает новый курс
+func (r *CourseRepository) CreateCourse(ctx context.Context, course *models.Course) error {
+    query := `
+        INSERT INTO courses (title, subject_id, description, level, cover_url, author_id, status, access)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        RETURNING id, created_at, updated_at
+    `
+
+    return r.db.QueryRow(ctx, query,
+        course.Title, course.Subject, course.Description, course.Level, course.CoverURL,
+        course.AuthorID, course.Status, course.Access,
+    ).Scan(&course.ID, &course.CreatedAt, &course.UpdatedAt)
+}
+
+// GetCourse возвращает курс по ID (без модулей)
+func (r *CourseRepository) GetCourse(ctx context.Context, id int) (*models.Course, error) {
+    var course models.Course
+
+    query := `
+        SELECT c.id, c.title, c.subject_id, c.description, c.level, c.cover_url,
+               c.author_id, u.full_name, c.status, c.access, COALESCE(c.share_url, ''),
+               c.created_at, c.updated_at
+        FROM courses c
+        JOIN users u ON c.author_id = u.id
+        WHERE c.id = $1
+    `
+
+    err := r.db.QueryRow(ctx, query, id).Scan(
+        &course.ID, &course.Title, &course.Subject, &course.Description, &course.Level, &course.CoverURL,
+        &course.AuthorID, &course.AuthorName, &course.Status, &course.Access, &course.ShareURL,
+        &course.CreatedAt, &course.UpdatedAt,
+    )
+
+    if err == pgx.ErrNoRows {
+        return nil, nil
+    }
+
+    return &course, err
+}
+
+// GetUserCourses возвращает курсы автора
+func (r *CourseRepository) GetUserCourses(ctx context.Context, userID int) ([]*models.Course, error) {
+    query := `
+        SELECT id, title, subject_id, description, level, cover_url, status, access,
+               COALESCE(share_url, ''), created_at, updated_at
+        FROM courses
+        WHERE author_id = $1
+        ORDER BY updated_at DESC
+    `
+
+    rows, err := r.db.Query(ctx, query, userID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    courses := []*models.Course{}
+    for rows.Next() {
+        var course models.Course
+        if err := rows.Scan(
+            &course.ID, &course.Title, &course.Subject, &course.Description, &course.Level, &course.CoverURL,
+            &course.Status, &course.Access, &course.ShareURL, &course.CreatedAt, &course.UpdatedAt,
+        ); err != nil {
+            return nil, err
+        }
+        course.AuthorID = userID
+        courses = append(courses, &course)
+    }
+
+    return courses, rows.Err()
+}
+
+// UpdateCourse обновляет курс
+func (r *CourseRepository) UpdateCourse(ctx context.Context, course *models.Course) error {
+    query := `
+        UPDATE courses
+        SET title = $1, subject_id = $2, description = $3, level = $4, cover_url = $5,
+            status = $6, access = $7, share_url = $8, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $9
+    `
+
+    _, err := r.db.Exec(ctx, query,
+        course.Title, course.Subject, course.Description, course.Level, course.CoverURL,
+        course.Status, course.Access, course.ShareURL, course.ID,
+    )
+    return err
+}
+
+// DeleteCourse удаляет курс вместе с модулями (сами материалы не удаляются)
+func (r *CourseRepository) DeleteCourse(ctx context.Context, id int) error {
+    _, err := r.db.Exec(ctx, "DELETE FROM courses WHERE id = $1", id)
+    return err
+}
+
+// touchCourse обновляет время изменения курса
+func touchCourse(ctx context.Context, q querier, courseID int) error {
+    _, err := q.Exec(ctx, "UPDATE courses SET updated_at = CURRENT_TIMESTAMP WHERE id = $1", courseID)
+    return err
+}
+
+// GetCourseModules возвращает модули курса с материалами и условиями доступа.
+// Материалы из корзины не показываются
+func (r *CourseRepository) GetCourseModules(ctx context.Context, courseID int) ([]models.CourseModule, error) {
+    rows, err := r.db.Query(ctx, `
+        SELECT id, title, position FROM course_modules WHERE course_id = $1 ORDER BY position, id
+    `, courseID)
+    if err != nil {
+        return nil, err
+    }
+
+    modules := []models.CourseModule{}
+    moduleIndex := make(map[int]int)
+    for rows.Next() {
+        module := models.CourseModule{CourseID: courseID, Items: []models.CourseItem{}}
+        if err := rows.Scan(&module.ID, &module.Title, &module.Position); err != nil {
+            rows.Close()
+            return nil, err
+        }
+        moduleIndex[module.ID] = len(modules)
+        modules = append(modules, module)
+    }
+    rows.Close()
+    if err := rows.Err(); err != nil {
+        return nil, err
+    }
+
+    prerequisites, err := r.getPrerequisites(ctx, courseID)
+    if err != nil {
+        return nil, err
+    }
+
+    rows, err = r.db.Query(ctx, `
+        SELECT ci.id, ci.module_id, ci.material_id, m.title, m.status, ci.position
+        FROM course_items ci
+        JOIN materials m ON ci.material_id = m.id
+        WHERE ci.course_id = $1 AND m.deleted_at IS NULL
+        ORDER BY ci.position, ci.id
+    `, courseID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    for rows.Next() {
+        var item models.CourseItem
+        if err := rows.Scan(
+            &item.ID, &item.ModuleID, &item.MaterialID, &item.Title, &item.MaterialStatus, &item.Position,
+        ); err != nil {
+            return nil, err
+        }
+
+        item.Prerequisites = prerequisites[item.ID]
+        if item.Prerequisites == nil {
+            item.Prerequisites = []int{}
+        }
+
+        if index, ok := moduleIndex[item.ModuleID]; ok {
+            modules[index].Items = append(modules[index].Items, item)
+        }
+    }
+
+    return modules, rows.Err()
+}
+
+func (r *CourseRepository) getPrerequisites(ctx context.Context, courseID int) (map[int][]int, error) {
+    query := `
+        SELECT p.item_id, p.prerequisite_id
+        FROM course_item_prerequisites p
+        JOIN course_items ci ON p.item_id = ci.id
+        WHERE ci.course_id = $1
+        ORDER BY p.item_id, p.prerequisite_id
+    `
+
+    rows, err := r.db.Query(ctx, query, courseID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    prerequisites := make(map[int][]int)
+    for rows.Next() {
+        var itemID, prerequisiteID int
+        if err := rows.Scan(&itemID, &prerequisiteID); err != nil {
+            return nil, err
+        }
+        prerequisites[itemID] = append(prerequisites[itemID], prerequisiteID)
+    }
+
+    return prerequisites, rows.Err()
+}
+
+// CreateModule добавляет модуль в конец курса
+func (r *CourseRepository) CreateModule(ctx context.Context, module *models.CourseModule) error {
+    tx, err := r.db.Begin(ctx)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback(ctx)
+
+    // Блокируем курс, чтобы параллельные вставки не получили одинаковую позицию
+    if _, err := tx.Exec(ctx, "SELECT id FROM courses WHERE id = $1 FOR UPDATE", module.CourseID); err != nil {
+        return err
+    }
+
+    err = tx.QueryRow(ctx, `
+        INSERT INTO course_modules (course_id, title, position)
+        SELECT $1, $2, COALESCE(MAX(position), 0) + 1 FROM course_modules WHERE course_id = $1
+        RETURNING id, position
+    `, module.CourseID, module.Title).Scan(&module.ID, &module.Position)
+    if err != nil {
+        return err
+    }
+
+    if err := touchCourse(ctx, tx, module.CourseID); err != nil {
+        return err
+    }
+
+    return tx.Commit(ctx)
+}
+
+// UpdateModule переименовывает модуль
+func (r *CourseRepository) UpdateModule(ctx context.Context, courseID, moduleID int, title string) (bool, error) {
+    tag, err := r.db.Exec(ctx,
+        "UPDATE course_modules SET title = $1 WHERE id = $2 AND course_id = $3",
+        title, moduleID, courseID,
+    )
+    if err != nil {
+        return false, err
+    }
+    return tag.RowsAffected() > 0, touchCourse(ctx, r.db, courseID)
+}
+
+// DeleteModule удаляет модуль вместе с его элементами
+func (r *CourseRepository) DeleteModule(ctx context.Context, courseID, moduleID int) (bool, error) {
+    tag, err := r.db.Exec(ctx, "DELETE FROM course_modules WHERE id = $1 AND course_id = $2", moduleID, courseID)
+    if err != nil {
+        return false, err
+    }
+    return tag.RowsAffected() > 0, touchCourse(ctx, r.db, courseID)
+}
+
+// ReorderModules задает порядок модулей курса
+func (r *CourseRepository) ReorderModules(ctx context.Context, courseID int, moduleIDs []int) error {
+    return r.reorder(ctx, courseID, moduleIDs,
+        "UPDATE course_modules SET position = $1 WHERE id = $2",
+        "module order must list every module",
+        "SELECT id FROM course_modules WHERE course_id = $1", courseID,
+    )
+}
+
+// ReorderItems задает порядок элементов модуля
+func (r *CourseRepository) ReorderItems(ctx context.Context, courseID, moduleID int, itemIDs []int) error {
+    return r.reorder(ctx, courseID, itemIDs,
+        "UPDATE course_items SET position = $1 WHERE id = $2",
+        "item order must list every item",
+        "SELECT id FROM course_items WHERE course_id = $1 AND module_id = $2", courseID, moduleID,
+    )
+}
+
+// reorder проверяет, что ids перечисляют ровно все строки selectQuery, и проставляет позиции
+func (r *CourseRepository) reorder(ctx context.Context, courseID int, ids []int, updateQuery, mismatchError, selectQuery string, selectArgs ...any) error {
+    tx, err := r.db.Begin(ctx)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback(ctx)
+
+    if _, err := tx.Exec(ctx, "SELECT id FROM courses WHERE id = $1 FOR UPDATE", courseID); err != nil {
+        return err
+    }
+
+    rows, err := tx.Query(ctx, selectQuery, selectArgs...)
+    if err != nil {
+        return err
+    }
+
+    existing := make(map[int]bool)
+    for rows.Next() {
+        var id int
+        if err := rows.Scan(&id); err != nil {
+            rows.Close()
+            return err
+        }
+        existing[id] = true
+    }
+    rows.Close()
+    if err := rows.Err(); err != nil {
+        return err
+    }
+
+    seen := make(map[int]bool)
+    for _, id := range ids {
+        if !existing[id] || seen[id] {
+            return fmt.Errorf("%s", mismatchError)
+        }
+        seen[id] = true
+    }
+    if len(seen) != len(existing) {
+        return fmt.Errorf("%s", mismatchError)
+    }
+
+    for position, id := range ids {
+        if _, err := tx.Exec(ctx, updateQuery, position+1, id); err != nil {
+            return err
+        }
+    }
+
+    if err := touchCourse(ctx, tx, courseID); err != nil {
+        return err
+    }
+
+    return tx.Commit(ctx)
+}
+
+// AddItem добавляет материал в конец модуля вместе с условиями доступа
+func (r *CourseRepository) AddItem(ctx context.Context, courseID int, item *models.CourseItem) error {
+    tx, err := r.db.Begin(ctx)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback(ctx)
+
+    if _, err := tx.Exec(ctx, "SELECT id FROM courses WHERE id = $1 FOR UPDATE", courseID); err != nil {
+        return err
+    }
+
+    err = tx.QueryRow(ctx, `
+        INSERT INTO course_items (course_id, module_id, material_id, position)
+        SELECT $1, $2, $3, COALESCE(MAX(position), 0) + 1 FROM course_items WHERE module_id = $2
+        ON CONFLICT (course_id, material_id) DO NOTHING
+        RETURNING id, position
+    `, courseID, item.ModuleID, item.MaterialID).Scan(&item.ID, &item.Position)
+    if err == pgx.ErrNoRows {
+        return fmt.Errorf("material already in course")
+    }
+    if err != nil {
+        return err
+    }
+
+    if err := setPrerequisites(ctx, tx, item.ID, item.Prerequisites); err != nil {
+        return err
+    }
+
+    if err := touchCourse(ctx, tx, courseID); err != nil {
+        return err
+    }
+
+    return tx.Commit(ctx)
+}
+
+// SetPrerequisites заменяет условия доступа элемента курса
+func (r *CourseRepository) SetPrerequisites(ctx context.Context, courseID, itemID int, prerequisites []int) error {
+    tx, err := r.db.Begin(ctx)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback(ctx)
+
+    if _, err := tx.Exec(ctx, "SELECT id FROM courses WHERE id = $1 FOR UPDATE", courseID); err != nil {
+        return err
+    }
+
+    if err := setPrerequisites(ctx, tx, itemID, prerequisites); err != nil {
+        return err
+    }
+
+    if err := touchCourse(ctx, tx, courseID); err != nil {
+        return err
+    }
+
+    return tx.Commit(ctx)
+}
+
+func setPrerequisites(ctx context.Context, tx pgx.Tx, itemID int, prerequisites []int) error {
+    if _, err := tx.Exec(ctx, "DELETE FROM course_item_prerequisites WHERE item_id = $1", itemID); err != nil {
+        return err
+    }
+
+    for _, prerequisiteID := range prerequisites {
+        _, err := tx.Exec(ctx, `
+            INSERT INTO course_item_prerequisites (item_id, prerequisite_id)
+            VALUES ($1, $2)
+            ON CONFLICT DO NOTHING
+        `, itemID, prerequisiteID)
+        if err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
+
+// DeleteItem удаляет материал из курса
+func (r *CourseRepository) DeleteItem(ctx context.Context, courseID, itemID int) (bool, error) {
+    tag, err := r.db.Exec(ctx, "DELETE FROM course_items WHERE id = $1 AND course_id = $2", itemID, courseID)
+    if err != nil {
+        return false, err
+    }
+    return tag.RowsAffected() > 0, touchCourse(ctx, r.db, courseID)
+}
+
+// CourseCompletion - прохождение материала курса учеником
+type CourseCompletion struct {
+    Grade       *float64
+    CompletedAt time.Time
+}
+
+// GetCourseCompletions возвращает прохождения материалов курса учеником по ID материала
+func (r *CourseRepository) GetCourseCompletions(ctx context.Context, courseID, userID int) (map[int]CourseCompletion, error) {
+    query := `
+        SELECT mc.material_id, mc.grade::float8, mc.completed_at
+        FROM material_completions mc
+        JOIN course_items ci ON mc.material_id = ci.material_id
+        WHERE ci.course_id = $1 AND mc.user_id = $2
+    `
+
+    rows, err := r.db.Query(ctx, query, courseID, userID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    completions := make(map[int]CourseCompletion)
+    for rows.Next() {
+        var materialID int
+        var completion CourseCompletion
+        if err := rows.Scan(&materialID, &completion.Grade, &completion.CompletedAt); err != nil {
+            return nil, err
+        }
+        completions[materialID] = completion
+    }
+
+    return completions, rows.Err()
+}
@@ -0,0 +1,303 @@
+package repositories
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "strings"
+
+    "paydeya-backend/internal/models"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgxpool"
+)
+
+type LibraryRepository struct {
+    db *pgxpool.Pool
+}
+
+func NewLibraryRepository(db *pgxpool.Pool) *LibraryRepository {
+    return &LibraryRepository{db: db}
+}
+
+// CreateLibraryBlock сохраняет фрагмент в библиотеку
+func (r *LibraryRepository) CreateLibraryBlock(ctx context.Context, item *models.LibraryBlock) error {
+    blocksJSON, err := json.Marshal(item.Blocks)
+    if err != nil {
+        return err
+    }
+
+    query := `
+        INSERT INTO library_blocks (owner_id, title, description, subject_id, shared, blocks)
+        VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6)
+        RETURNING id, created_at, updated_at
+    `
+
+    item.BlocksCount = len(item.Blocks)
+    return r.db.QueryRow(ctx, query,
+        item.OwnerID, item.Title, item.Description, item.Subject, item.Shared, blocksJSON,
+    ).Scan(&item.ID, &item.CreatedAt, &item.UpdatedAt)
+}
+
+// GetLibraryBlock возвращает фрагмент с блоками или nil
+func (r *LibraryRepository) GetLibraryBlock(ctx context.Context, id int) (*models.LibraryBlock, error) {
+    query := `
+        SELECT lb.id, lb.owner_id, u.full_name, lb.title, lb.description, COALESCE(lb.subject_id, ''),
+               lb.shared, lb.blocks, lb.created_at, lb.updated_at
+        FROM library_blocks lb
+        JOIN users u ON lb.owner_id = u.id
+        WHERE lb.id = $1
+    `
+
+    var item models.LibraryBlock
+    var blocksJSON []byte
+    err := r.db.QueryRow(ctx, query, id).Scan(
+        &item.ID, &item.OwnerID, &item.OwnerName, &item.Title, &item.Description, &item.Subject,
+        &item.Shared, &blocksJSON, &item.CreatedAt, &item.UpdatedAt,
+    )
+    if err == pgx.ErrNoRows {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    if err := json.Unmarshal(blocksJSON, &item.Blocks); err != nil {
+        return nil, err
+    }
+    item.BlocksCount = len(item.Blocks)
+    return &item, nil
+}
+
+// GetLibraryBlocks возвращает фрагменты пользователя и общие фрагменты без самих блоков
+func (r *LibraryRepository) GetLibraryBlocks(ctx context.Context, userID int, filters models.LibraryFilters) ([]models.LibraryBlock, int, error) {
+    args := []interface{}{userID}
+    var conditions []string
+
+    switch filters.Scope {
+    case "my":
+        conditions = append(conditions, "lb.owner_id = $1")
+    case "shared":
+        conditions = append(conditions, "lb.shared AND lb.owner_id <> $1")
+    default:
+        conditions = append(conditions, "(lb.owner_id = $1 OR lb.shared)")
+    }
+
+    if filters.Search != "" {
+        args = append(args, "%"+filters.Search+"%")
+        conditions = append(conditions, fmt.Sprintf("(lb.title ILIKE $%d OR lb.description ILIKE $%d)", len(args), len(args)))
+    }
+    if filters.Subject != "" {
+        args = append(args, filters.Subject)
+        conditions = append(conditions, fmt.Sprintf("lb.subject_id = $%d", len(args)))
+    }
+
+    where := " WHERE " + strings.Join(conditions, " AND ")
+
+    var total int
+    if err := r.db.QueryRow(ctx, "SELECT COUNT(*) FROM library_blocks lb"+where, args...).Scan(&total); err != nil {
+        return nil, 0, err
+    }
+
+    query := `
+        SELECT lb.id, lb.owner_id, u.full_name, lb.title, lb.description, COALESCE(lb.subject_id, ''),
+               lb.shared, jsonb_array_length(lb.blocks), lb.created_at, lb.updated_at
+        FROM library_blocks lb
+        JOIN users u ON lb.owner_id = u.id
+    ` + where + " ORDER BY lb.updated_at DESC, lb.id DESC" + pageClause(&args, filters.Page, filters.Limit)
+
+    rows, err := r.db.Query(ctx, query, args...)
+    if err != nil {
+        return nil, 0, err
+    }
+    defer rows.Close()
+
+    items := []models.LibraryBlock{}
+    for rows.Next() {
+        var item models.LibraryBlock
+        if err := rows.Scan(
+            &item.ID, &item.OwnerID, &item.OwnerName, &item.Title, &item.Description, &item.Subject,
+            &item.Shared, &item.BlocksCount, &item.CreatedAt, &item.UpdatedAt,
+        ); err != nil {
+            return nil, 0, err
+        }
+        items = append(items, item)
+    }
+
+    return items, total, rows.Err()
+}
+
+// UpdateLibraryBlock обновляет фрагмент
+func (r *LibraryRepository) UpdateLibraryBlock(ctx context.Context, item *models.LibraryBlock) error {
+    blocksJSON, err := json.Marshal(item.Blocks)
+    if err != nil {
+        return err
+    }
+
+    query := `
+        UPDATE library_blocks
+        SET title = $1, description = $2, subject_id = NULLIF($3, ''), shared = $4, blocks = $5,
+            updated_at = CURRENT_TIMESTAMP
+        WHERE id = $6
+        RETURNING updated_at
+    `
+
+    item.BlocksCount = len(item.Blocks)
+    return r.db.QueryRow(ctx, query,
+        item.Title, item.Description, item.Subject, item.Shared, blocksJSON, item.ID,
+    ).Scan(&item.UpdatedAt)
+}
+
+// DeleteLibraryBlock удаляет фрагмент
+func (r *LibraryRepository) DeleteLibraryBlock(ctx context.Context, id int) error {
+    _, err := r.db.Exec(ctx, "DELETE FROM library_blocks WHERE id = $1", id)
+    return err
+}
+
+// CreateTemplate сохраняет шаблон материала
+func (r *LibraryRepository) CreateTemplate(ctx context.Context, template *models.MaterialTemplate) error {
+    blocksJSON, err := json.Marshal(template.Blocks)
+    if err != nil {
+        return err
+    }
+
+    query := `
+        INSERT INTO material_templates (author_id, title, description, subject_id, official, blocks)
+        VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6)
+        RETURNING id, created_at, updated_at
+    `
+
+    template.BlocksCount = len(template.Blocks)
+    return r.db.QueryRow(ctx, query,
+        template.AuthorID, template.Title, template.Description, template.Subject, template.Official, blocksJSON,
+    ).Scan(&template.ID, &template.CreatedAt, &template.UpdatedAt)
+}
+
+// GetTemplate возвращает шаблон с блоками или nil
+func (r *LibraryRepository) GetTemplate(ctx context.Context, id int) (*models.MaterialTemplate, error) {
+    query := `
+        SELECT t.id, t.author_id, u.full_name, t.title, t.description, COALESCE(t.subject_id, ''),
+               t.official, t.blocks, t.created_at, t.updated_at
+        FROM material_templates t
+        JOIN users u ON t.author_id = u.id
+        WHERE t.id = $1
+    `
+
+    var template models.MaterialTemplate
+    var blocksJSON []byte
+    err := r.db.QueryRow(ctx, query, id).Scan(
+        &template.ID, &template.AuthorID, &template.AuthorName, &template.Title, &template.Description,
+        &template.Subject, &template.Official, &blocksJSON, &template.CreatedAt, &template.UpdatedAt,
+    )
+    if err == pgx.ErrNoRows {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    if err := json.Unmarshal(blocksJSON, &template.Blocks); err != nil {
+        return nil, err
+    }
+    template.BlocksCount = len(template.Blocks)
+    return &template, nil
+}
+
+// GetTemplates возвращает шаблоны пользователя и официальные шаблоны без самих блоков.
+// Официальные шаблоны идут первыми
+func (r *LibraryRepository) GetTemplates(ctx context.Context, userID int, filters models.TemplateFilters) ([]models.MaterialTemplate, int, error) {
+    args := []interface{}{userID}
+    var conditions []string
+
+    switch filters.Scope {
+    case "my":
+        conditions = append(conditions, "t.author_id = $1 AND NOT t.official")
+    case "official":
+        conditions = append(conditions, "t.official")
+    default:
+        conditions = append(conditions, "(t.author_id = $1 OR t.official)")
+    }
+
+    if filters.Search != "" {
+        args = append(args, "%"+filters.Search+"%")
+        conditions = append(conditions, fmt.Sprintf("(t.title ILIKE $%d OR t.description ILIKE $%d)", len(args), len(args)))
+    }
+    if filters.Subject != "" {
+        args = append(args, filters.Subject)
+        conditions = append(conditions, fmt.Sprintf("t.subject_id = $%d", len(args)))
+    }
+
+    where := " WHERE " + strings.Join(conditions, " AND ")
+
+    var total int
+    if err := r.db.QueryRow(ctx, "SELECT COUNT(*) FROM material_templates t"+where, args...).Scan(&total); err != nil {
+        return nil, 0, err
+    }
+
+    query := `
+        SELECT t.id, t.author_id, u.full_name, t.title, t.description, COALESCE(t.subject_id, ''),
+               t.official, jsonb_array_length(t.blocks), t.created_at, t.updated_at
+        FROM material_templates t
+        JOIN users u ON t.author_id = u.id
+    ` + where + " ORDER BY t.official DESC, t.updated_at DESC, t.id DESC" + pageClause(&args, filters.Page, filters.Limit)
+
+    rows, err := r.db.Query(ctx, query, args...)
+    if err != nil {
+        return nil, 0, err
+    }
+    defer rows.Close()
+
+    templates := []models.MaterialTemplate{}
+    for rows.Next() {
+        var template models.MaterialTemplate
+        if err := rows.Scan(
+            &template.ID, &template.AuthorID, &template.AuthorName, &template.Title, &template.Description,
+            &template.Subject, &template.Official, &template.BlocksCount, &template.CreatedAt, &template.UpdatedAt,
+        ); err != nil {
+            return nil, 0, err
+        }
+        templates = append(templates, template)
+    }
+
+    return templates, total, rows.Err()
+}
+
+// UpdateTemplate обновляет шаблон
+func (r *LibraryRepository) UpdateTemplate(ctx context.Context, template *models.MaterialTemplate) error {
+    blocksJSON, err := json.Marshal(template.Blocks)
+    if err != nil {
+        return err
+    }
+
+    query := `
+        UPDATE material_templates
+        SET title = $1, description = $2, subject_id = NULLIF($3, ''), blocks = $4, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $5
+        RETURNING updated_at
+    `
+
+    template.BlocksCount = len(template.Blocks)
+    return r.db.QueryRow(ctx, query,
+        template.Title, template.Description, template.Subject, blocksJSON, template.ID,
+    ).Scan(&template.UpdatedAt)
+}
+
+// DeleteTemplate удаляет шаблон
+func (r *LibraryRepository) DeleteTemplate(ctx context.Context, id int) error {
+    _, err := r.db.Exec(ctx, "DELETE FROM material_templates WHERE id = $1", id)
+    return err
+}
+
+// pageClause добавляет к запросу LIMIT и OFFSET страницы
+func pageClause(args *[]interface{}, page, limit int) string {
+    if limit <= 0 {
+        return ""
+    }
+    *args = append(*args, limit)
+    clause := fmt.Sprintf(" LIMIT $%d", len(*args))
+    if page > 1 {
+        *args = append(*args, (page-1)*limit)
+        clause += fmt.Sprintf(" OFFSET $%d", len(*args))
+    }
+    return clause
+}
@@ -0,0 +1,351 @@
+package repositories
+
+import (
+    "context"
+    "time"
+
+    "paydeya-backend/internal/models"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgxpool"
+)
+
+type LTIRepository struct {
+    db *pgxpool.Pool
+}
+
+func NewLTIRepository(db *pgxpool.Pool) *LTIRepository {
+    return &LTIRepository{db: db}
+}
+
+// LTIToolKey - ключ инструмента для подписи JWT
+type LTIToolKey struct {
+    KID           string
+    PrivateKeyPEM string
+}
+
+// LTILoginState - state и nonce OIDC-входа
+type LTILoginState struct {
+    Nonce      string
+    PlatformID int
+}
+
+const ltiPlatformColumns = `id, name, issuer, client_id, deployment_ids, auth_login_url, auth_token_url, jwks_url, created_at`
+
+func scanLTIPlatform(row pgx.Row, p *models.LTIPlatform) error {
+    return row.Scan(
+        &p.ID, &p.Name, &p.Issuer, &p.ClientID, &p.DeploymentIDs,
+        &p.AuthLoginURL, &p.AuthTokenURL, &p.JWKSURL, &p.CreatedAt,
+    )
+}
+
+// CreatePlatform регистрирует платформу. false - платформа с таким issuer и client_id уже есть
+func (r *LTIRepository) CreatePlatform(ctx context.Context, p *models.LTIPlatform) (bool, error) {
+    query := `
+        INSERT INTO lti_platforms (name, issuer, client_id, deployment_ids, auth_login_url, auth_token_url, jwks_url)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        ON CONFLICT (issuer, client_id) DO NOTHING
+        RETURNING id, created_at
+    `
+
+    err := r.db.QueryRow(ctx, query,
+        p.Name, p.Issuer, p.ClientID, p.DeploymentIDs, p.AuthLoginURL, p.AuthTokenURL, p.JWKSURL,
+    ).Scan(&p.ID, &p.CreatedAt)
+    if err == pgx.ErrNoRows {
+        return false, nil
+    }
+    if err != nil {
+        return false, err
+    }
+
+    return true, nil
+}
+
+// GetPlatforms возвращает все зарегистрированные платформы
+func (r *LTIRepository) GetPlatforms(ctx context.Context) ([]models.LTIPlatform, error) {
+    rows, err := r.db.Query(ctx, `SELECT `+ltiPlatformColumns+` FROM lti_platforms ORDER BY id`)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    platforms := []models.LTIPlatform{}
+    for rows.Next() {
+        var p models.LTIPlatform
+        if err := scanLTIPlatform(rows, &p); err != nil {
+            return nil, err
+        }
+        platforms = append(platforms, p)
+    }
+
+    return platforms, rows.Err()
+}
+
+// GetPlatform возвращает платформу по ID
+func (r *LTIRepository) GetPlatform(ctx context.Context, id int) (*models.LTIPlatform, error) {
+    var p models.LTIPlatform
+
+    err := scanLTIPlatform(r.db.QueryRow(ctx, `SELECT `+ltiPlatformColumns+` FROM lti_platforms WHERE id = $1`, id), &p)
+    if err == pgx.ErrNoRows {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    return &p, nil
+}
+
+// FindPlatform ищет платформу по issuer и, если передан, client_id
+func (r *LTIRepository) FindPlatform(ctx context.Context, issuer, clientID string) (*models.LTIPlatform, error) {
+    var p models.LTIPlatform
+
+    query := `
+        SELECT ` + ltiPlatformColumns + ` FROM lti_platforms
+        WHERE issuer = $1 AND ($2 = '' OR client_id = $2)
+        ORDER BY id
+        LIMIT 1
+    `
+
+    err := scanLTIPlatform(r.db.QueryRow(ctx, query, issuer, clientID), &p)
+    if err == pgx.ErrNoRows {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    return &p, nil
+}
+
+// DeletePlatform удаляет платформу вместе со связями
+func (r *LTIRepository) DeletePlatform(ctx context.Context, id int) (bool, error) {
+    result, err := r.db.Exec(ctx, "DELETE FROM lti_platforms WHERE id = $1", id)
+    if err != nil {
+        return false, err
+    }
+
+    return result.RowsAffected() > 0, nil
+}
+
+// GetToolKeys возвращает ключи инструмента, новые первыми
+func (r *LTIRepository) GetToolKeys(ctx context.Context) ([]LTIToolKey, error) {
+    rows, err := r.db.Query(ctx, "SELECT kid, private_key_pem FROM lti_tool_keys ORDER BY created_at DESC")
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var keys []LTIToolKey
+    for rows.Next() {
+        var k LTIToolKey
+        if err := rows.Scan(&k.KID, &k.PrivateKeyPEM); err != nil {
+            return nil, err
+        }
+        keys = append(keys, k)
+    }
+
+    return keys, rows.Err()
+}
+
+// CreateToolKey сохраняет новый ключ инструмента
+func (r *LTIRepository) CreateToolKey(ctx context.Context, key LTIToolKey) error {
+    _, err := r.db.Exec(ctx, "INSERT INTO lti_tool_keys (kid, private_key_pem) VALUES ($1, $2)", key.KID, key.PrivateKeyPEM)
+    return err
+}
+
+// SaveLoginState сохраняет state и nonce OIDC-входа
+func (r *LTIRepository) SaveLoginState(ctx context.Context, state, nonce string, platformID int, expiresAt time.Time) error {
+    // Попутно чистим просроченные входы
+    if _, err := r.db.Exec(ctx, "DELETE FROM lti_login_states WHERE expires_at < CURRENT_TIMESTAMP"); err != nil {
+        return err
+    }
+
+    query := `INSERT INTO lti_login_states (state, nonce, platform_id, expires_at) VALUES ($1, $2, $3, $4)`
+
+    _, err := r.db.Exec(ctx, query, state, nonce, platformID, expiresAt)
+    return err
+}
+
+// ConsumeLoginState забирает state: повторный запуск с тем же state не пройдет
+func (r *LTIRepository) ConsumeLoginState(ctx context.Context, state string) (*LTILoginState, error) {
+    var s LTILoginState
+
+    query := `
+        DELETE FROM lti_login_states
+        WHERE state = $1 AND expires_at > CURRENT_TIMESTAMP
+        RETURNING nonce, platform_id
+    `
+
+    err := r.db.QueryRow(ctx, query, state).Scan(&s.Nonce, &s.PlatformID)
+    if err == pgx.ErrNoRows {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    return &s, nil
+}
+
+// GetLinkedUserID возвращает пользователя, заведенного для sub платформы. 0 - связи нет
+func (r *LTIRepository) GetLinkedUserID(ctx context.Context, platformID int, sub string) (int, error) {
+    var userID int
+
+    err := r.db.QueryRow(ctx, "SELECT user_id FROM lti_users WHERE platform_id = $1 AND sub = $2", platformID, sub).Scan(&userID)
+    if err == pgx.ErrNoRows {
+        return 0, nil
+    }
+
+    return userID, err
+}
+
+// CreateLinkedUser создает пользователя и связывает его с sub платформы.
+// false - связь успел создать параллельный запуск, пользователь не создан
+func (r *LTIRepository) CreateLinkedUser(ctx context.Context, platformID int, sub string, user *models.User) (bool, error) {
+    tx, err := r.db.Begin(ctx)
+    if err != nil {
+        return false, err
+    }
+    defer tx.Rollback(ctx)
+
+    query := `
+        INSERT INTO users (email, password_hash, full_name, role, avatar_url, is_verified)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING id, created_at, updated_at
+    `
+
+    err = tx.QueryRow(ctx, query,
+        user.Email, user.PasswordHash, user.FullName, user.Role, user.AvatarURL, user.IsVerified,
+    ).Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
+    if err != nil {
+        return false, err
+    }
+
+    result, err := tx.Exec(ctx, `
+        INSERT INTO lti_users (platform_id, sub, user_id) VALUES ($1, $2, $3)
+        ON CONFLICT (platform_id, sub) DO NOTHING
+    `, platformID, sub, user.ID)
+    if err != nil {
+        return false, err
+    }
+    if result.RowsAffected() == 0 {
+        return false, nil
+    }
+
+    return true, tx.Commit(ctx)
+}
+
+// SaveLaunch запоминает ссылку на материал и запуск ее пользователем
+func (r *LTIRepository) SaveLaunch(ctx context.Context, platformID int, resourceLinkID string, materialID int, contextID, lineItemURL *string, userID int) error {
+    tx, err := r.db.Begin(ctx)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback(ctx)
+
+    var linkID int
+    query := `
+        INSERT INTO lti_resource_links (platform_id, resource_link_id, material_id, context_id, lineitem_url)
+        VALUES ($1, $2, $3, $4, $5)
+        ON CONFLICT (platform_id, resource_link_id) DO UPDATE
+        SET material_id = EXCLUDED.material_id,
+            context_id = EXCLUDED.context_id,
+            lineitem_url = COALESCE(EXCLUDED.lineitem_url, lti_resource_links.lineitem_url),
+            updated_at = CURRENT_TIMESTAMP
+        RETURNING id
+    `
+
+    err = tx.QueryRow(ctx, query, platformID, resourceLinkID, materialID, contextID, lineItemURL).Scan(&linkID)
+    if err != nil {
+        return err
+    }
+
+    _, err = tx.Exec(ctx, `
+        INSERT INTO lti_launches (resource_link_id, user_id) VALUES ($1, $2)
+        ON CONFLICT (resource_link_id, user_id) DO UPDATE SET last_launch_at = CURRENT_TIMESTAMP
+    `, linkID, userID)
+    if err != nil {
+        return err
+    }
+
+    return tx.Commit(ctx)
+}
+
+// GetGradeTargets возвращает завершение материала и колонки журналов, в которые пользователь его запускал
+func (r *LTIRepository) GetGradeTargets(ctx context.Context, userID, materialID int) ([]models.LTIGradeTarget, error) {
+    query := `
+        SELECT rl.platform_id, lu.sub, rl.lineitem_url, mc.grade::float8, mc.completed_at
+        FROM lti_launches l
+        JOIN lti_resource_links rl ON rl.id = l.resource_link_id
+        JOIN lti_users lu ON lu.platform_id = rl.platform_id AND lu.user_id = l.user_id
+        JOIN material_completions mc ON mc.user_id = l.user_id AND mc.material_id = rl.material_id
+        WHERE l.user_id = $1 AND rl.material_id = $2 AND rl.lineitem_url IS NOT NULL
+    `
+
+    rows, err := r.db.Query(ctx, query, userID, materialID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var targets []models.LTIGradeTarget
+    for rows.Next() {
+        var t models.LTIGradeTarget
+        if err := rows.Scan(&t.PlatformID, &t.Sub, &t.LineItemURL, &t.Grade, &t.CompletedAt); err != nil {
+            return nil, err
+        }
+        targets = append(targets, t)
+    }
+
+    return targets, rows.Err()
+}
+
+// CreateDeepLinkSession сохраняет сессию выбора материалов
+func (r *LTIRepository) CreateDeepLinkSession(ctx context.Context, s *models.LTIDeepLinkSession) error {
+    // Попутно чистим просроченные сессии
+    if _, err := r.db.Exec(ctx, "DELETE FROM lti_deep_link_sessions WHERE expires_at < CURRENT_TIMESTAMP"); err != nil {
+        return err
+    }
+
+    query := `
+        INSERT INTO lti_deep_link_sessions (id, platform_id, deployment_id, user_id, return_url, data, accept_multiple, expires_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+    `
+
+    _, err := r.db.Exec(ctx, query,
+        s.ID, s.PlatformID, s.DeploymentID, s.UserID, s.ReturnURL, s.Data, s.AcceptMultiple, s.ExpiresAt,
+    )
+    return err
+}
+
+// GetDeepLinkSession возвращает действующую сессию выбора материалов пользователя
+func (r *LTIRepository) GetDeepLinkSession(ctx context.Context, id string, userID int) (*models.LTIDeepLinkSession, error) {
+    var s models.LTIDeepLinkSession
+
+    query := `
+        SELECT s.id::text, s.platform_id, p.name, s.deployment_id, s.user_id, s.return_url, s.data, s.accept_multiple, s.expires_at
+        FROM lti_deep_link_sessions s
+        JOIN lti_platforms p ON p.id = s.platform_id
+        WHERE s.id::text = $1 AND s.user_id = $2 AND s.expires_at > CURRENT_TIMESTAMP
+    `
+
+    err := r.db.QueryRow(ctx, query, id, userID).Scan(
+        &s.ID, &s.PlatformID, &s.PlatformName, &s.DeploymentID, &s.UserID,
+        &s.ReturnURL, &s.Data, &s.AcceptMultiple, &s.ExpiresAt,
+    )
+    if err == pgx.ErrNoRows {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    return &s, nil
+}
+
+// DeleteDeepLinkSession закрывает сессию выбора материалов
+func (r *LTIRepository) DeleteDeepLinkSession(ctx context.Context, id string) error {
+    _, err := r.db.Exec(ctx, "DELETE FROM lti_deep_link_sessions WHERE id::text = $1", id)
+    return err
+}
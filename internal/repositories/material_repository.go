@@ -2,6 +2,7 @@ package repositories
 
 import (
     "context"
+    "time"
 
     "paydeya-backend/internal/models"
 
@@ -19,18 +20,101 @@ func NewMaterialRepository(db *pgxpool.Pool) *MaterialRepository {
 
 // CreateMaterial создает новый материал
 func (r *MaterialRepository) CreateMaterial(ctx context.Context, material *models.Material) error {
+    return r.CreateMaterialWithBlocks(ctx, material, nil)
+}
+
+// CreateMaterialWithBlocks создает материал вместе с блоками в одной транзакции
+func (r *MaterialRepository) CreateMaterialWithBlocks(ctx context.Context, material *models.Material, blocks []models.Block) error {
+    tx, err := r.db.Begin(ctx)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback(ctx)
+
+    if err := createMaterial(ctx, tx, material); err != nil {
+        return err
+    }
+
+    if err := saveBlocks(ctx, tx, material.ID, blocks); err != nil {
+        return err
+    }
+
+    return tx.Commit(ctx)
+}
+
+func createMaterial(ctx context.Context, tx pgx.Tx, material *models.Material) error {
     query := `
-        INSERT INTO materials (title, subject_id, author_id, status, access, share_url)
-        VALUES ($1, $2, $3, $4, $5, $6)
+        INSERT INTO materials (title, subject_id, author_id, status, access, share_url, forked_from,
+                               description, level, cover_url, language, duration_minutes)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
         RETURNING id, created_at, updated_at
     `
 
-    err := r.db.QueryRow(ctx, query,
+    err := tx.QueryRow(ctx, query,
         material.Title, material.Subject, material.AuthorID,
-        material.Status, material.Access, material.ShareURL,
+        material.Status, material.Access, material.ShareURL, material.ForkedFrom,
+        material.Description, material.Level, material.CoverURL, material.Language, material.Duration,
     ).Scan(&material.ID, &material.CreatedAt, &material.UpdatedAt)
+    if err != nil {
+        return err
+    }
 
-    return err
+    return setMaterialTags(ctx, tx, material.ID, material.Tags)
+}
+
+// setMaterialTags заменяет теги материала, создавая отсутствующие теги
+func setMaterialTags(ctx context.Context, tx pgx.Tx, materialID int, tags []string) error {
+    _, err := tx.Exec(ctx, "DELETE FROM material_tags WHERE material_id = $1", materialID)
+    if err != nil {
+        return err
+    }
+
+    for _, tag := range tags {
+        _, err = tx.Exec(ctx, `
+            WITH tag AS (
+                INSERT INTO tags (name) VALUES ($2)
+                ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+                RETURNING id
+            )
+            INSERT INTO material_tags (material_id, tag_id)
+            SELECT $1, id FROM tag
+            ON CONFLICT DO NOTHING
+        `, materialID, tag)
+        if err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
+
+// materialTagsColumn - подзапрос тегов материала m в виде массива
+const materialTagsColumn = `
+    COALESCE((SELECT array_agg(t.name ORDER BY t.name)
+              FROM material_tags mt JOIN tags t ON mt.tag_id = t.id
+              WHERE mt.material_id = m.id), '{}')`
+
+// SubjectExists проверяет, что предмет есть в справочнике
+func (r *MaterialRepository) SubjectExists(ctx context.Context, subjectID string) (bool, error) {
+    var exists bool
+    err := r.db.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM subjects WHERE id = $1)", subjectID).Scan(&exists)
+    return exists, err
+}
+
+// GetMaterialAttribution возвращает название предмета и имя автора материала
+func (r *MaterialRepository) GetMaterialAttribution(ctx context.Context, materialID int) (string, string, error) {
+    var subject, author string
+    err := r.db.QueryRow(ctx, `
+        SELECT COALESCE(s.name, m.subject_id), COALESCE(u.full_name, '')
+        FROM materials m
+        LEFT JOIN subjects s ON m.subject_id = s.id
+        LEFT JOIN users u ON m.author_id = u.id
+        WHERE m.id = $1
+    `, materialID).Scan(&subject, &author)
+    if err == pgx.ErrNoRows {
+        return "", "", nil
+    }
+    return subject, author, err
 }
 
 // GetMaterial возвращает материал по ID
@@ -38,15 +122,18 @@ func (r *MaterialRepository) GetMaterial(ctx context.Context, id int) (*models.M
     var material models.Material
 
     query := `
-        SELECT id, title, subject_id, author_id, status, access, share_url, created_at, updated_at
-        FROM materials
-        WHERE id = $1
+        SELECT m.id, m.title, m.subject_id, m.author_id, m.status, m.access, COALESCE(m.share_url, ''), m.forked_from,
+               m.description, m.level, m.cover_url, m.language, m.duration_minutes, ` + materialTagsColumn + `,
+               m.publish_at, m.unpublish_at, m.created_at, m.updated_at
+        FROM materials m
+        WHERE m.id = $1 AND m.deleted_at IS NULL
     `
 
     err := r.db.QueryRow(ctx, query, id).Scan(
         &material.ID, &material.Title, &material.Subject, &material.AuthorID,
-        &material.Status, &material.Access, &material.ShareURL,
-        &material.CreatedAt, &material.UpdatedAt,
+        &material.Status, &material.Access, &material.ShareURL, &material.ForkedFrom,
+        &material.Description, &material.Level, &material.CoverURL, &material.Language, &material.Duration, &material.Tags,
+        &material.PublishAt, &material.UnpublishAt, &material.CreatedAt, &material.UpdatedAt,
     )
 
     if err == pgx.ErrNoRows {
@@ -58,17 +145,19 @@ func (r *MaterialRepository) GetMaterial(ctx context.Context, id int) (*models.M
 
 // GetUserMaterials возвращает материалы пользователя
 func (r *MaterialRepository) GetUserMaterials(ctx context.Context, userID int, status string) ([]*models.Material, error) {
-    var query string
     var rows pgx.Rows
     var err error
 
+    query := `SELECT m.id, m.title, m.subject_id, m.status, m.access,
+                     m.description, m.level, m.cover_url, m.language, m.duration_minutes, ` + materialTagsColumn + `,
+                     m.publish_at, m.unpublish_at, m.created_at, m.updated_at
+              FROM materials m WHERE m.author_id = $1 AND m.deleted_at IS NULL`
+
     if status == "" {
-        query = `SELECT id, title, subject_id, status, access, created_at, updated_at
-                 FROM materials WHERE author_id = $1 ORDER BY updated_at DESC`
+        query += ` ORDER BY m.updated_at DESC`
         rows, err = r.db.Query(ctx, query, userID)
     } else {
-        query = `SELECT id, title, subject_id, status, access, created_at, updated_at
-                 FROM materials WHERE author_id = $1 AND status = $2 ORDER BY updated_at DESC`
+        query += ` AND m.status = $2 ORDER BY m.updated_at DESC`
         rows, err = r.db.Query(ctx, query, userID, status)
     }
 
@@ -82,7 +171,9 @@ func (r *MaterialRepository) GetUserMaterials(ctx context.Context, userID int, s
         var material models.Material
         if err := rows.Scan(
             &material.ID, &material.Title, &material.Subject,
-            &material.Status, &material.Access, &material.CreatedAt, &material.UpdatedAt,
+            &material.Status, &material.Access,
+            &material.Description, &material.Level, &material.CoverURL, &material.Language, &material.Duration, &material.Tags,
+            &material.PublishAt, &material.UnpublishAt, &material.CreatedAt, &material.UpdatedAt,
         ); err != nil {
             return nil, err
         }
@@ -95,16 +186,261 @@ func (r *MaterialRepository) GetUserMaterials(ctx context.Context, userID int, s
 
 // UpdateMaterial обновляет материал
 func (r *MaterialRepository) UpdateMaterial(ctx context.Context, material *models.Material) error {
+    tx, err := r.db.Begin(ctx)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback(ctx)
+
     query := `
         UPDATE materials
-        SET title = $1, subject = $2, status = $3, access = $4, share_url = $5, updated_at = CURRENT_TIMESTAMP
-        WHERE id = $6 AND author_id = $7
+        SET title = $1, subject_id = $2, status = $3, access = $4, share_url = $5,
+            description = $6, level = $7, cover_url = $8, language = $9, duration_minutes = $10,
+            publish_at = $11, unpublish_at = $12, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $13 AND author_id = $14
     `
 
-    _, err := r.db.Exec(ctx, query,
-        material.Title, material.Subject, material.Status, material.Access,
-        material.ShareURL, material.ID, material.AuthorID,
+    _, err = tx.Exec(ctx, query,
+        material.Title, material.Subject, material.Status, material.Access, material.ShareURL,
+        material.Description, material.Level, material.CoverURL, material.Language, material.Duration,
+        material.PublishAt, material.UnpublishAt, material.ID, material.AuthorID,
     )
+    if err != nil {
+        return err
+    }
+
+    if err := setMaterialTags(ctx, tx, material.ID, material.Tags); err != nil {
+        return err
+    }
+
+    return tx.Commit(ctx)
+}
+
+// HasCompletions проверяет, проходили ли материал ученики
+func (r *MaterialRepository) HasCompletions(ctx context.Context, materialID int) (bool, error) {
+    var exists bool
+    query := `SELECT EXISTS(SELECT 1 FROM material_completions WHERE material_id = $1)`
+    err := r.db.QueryRow(ctx, query, materialID).Scan(&exists)
+    return exists, err
+}
+
+// HasCompleted проверяет, что ученик завершил материал
+func (r *MaterialRepository) HasCompleted(ctx context.Context, userID, materialID int) (bool, error) {
+    var completed bool
+    query := `SELECT EXISTS(SELECT 1 FROM material_completions WHERE user_id = $1 AND material_id = $2)`
+    err := r.db.QueryRow(ctx, query, userID, materialID).Scan(&completed)
+    return completed, err
+}
+
+// MoveToTrash перемещает материал в корзину
+func (r *MaterialRepository) MoveToTrash(ctx context.Context, materialID int) error {
+    query := `
+        UPDATE materials
+        SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND deleted_at IS NULL
+    `
+
+    _, err := r.db.Exec(ctx, query, materialID)
     return err
 }
 
+// RestoreFromTrash возвращает материал из корзины
+func (r *MaterialRepository) RestoreFromTrash(ctx context.Context, materialID int) (bool, error) {
+    query := `
+        UPDATE materials
+        SET deleted_at = NULL, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND deleted_at IS NOT NULL
+    `
+
+    tag, err := r.db.Exec(ctx, query, materialID)
+    if err != nil {
+        return false, err
+    }
+    return tag.RowsAffected() > 0, nil
+}
+
+// GetTrashedMaterial возвращает материал из корзины по ID
+func (r *MaterialRepository) GetTrashedMaterial(ctx context.Context, id int) (*models.Material, error) {
+    var material models.Material
+
+    query := `
+        SELECT m.id, m.title, m.subject_id, m.author_id, m.status, m.access, m.deleted_at, m.created_at, m.updated_at
+        FROM materials m
+        WHERE m.id = $1 AND m.deleted_at IS NOT NULL
+    `
+
+    err := r.db.QueryRow(ctx, query, id).Scan(
+        &material.ID, &material.Title, &material.Subject, &material.AuthorID,
+        &material.Status, &material.Access, &material.DeletedAt, &material.CreatedAt, &material.UpdatedAt,
+    )
+
+    if err == pgx.ErrNoRows {
+        return nil, nil
+    }
+
+    return &material, err
+}
+
+// GetTrashedMaterials возвращает материалы пользователя в корзине
+func (r *MaterialRepository) GetTrashedMaterials(ctx context.Context, userID int) ([]*models.Material, error) {
+    query := `
+        SELECT m.id, m.title, m.subject_id, m.status, m.access, m.deleted_at, m.created_at, m.updated_at
+        FROM materials m
+        WHERE m.author_id = $1 AND m.deleted_at IS NOT NULL
+        ORDER BY m.deleted_at DESC
+    `
+
+    rows, err := r.db.Query(ctx, query, userID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var materials []*models.Material
+    for rows.Next() {
+        var material models.Material
+        if err := rows.Scan(
+            &material.ID, &material.Title, &material.Subject, &material.Status, &material.Access,
+            &material.DeletedAt, &material.CreatedAt, &material.UpdatedAt,
+        ); err != nil {
+            return nil, err
+        }
+        material.AuthorID = userID
+        materials = append(materials, &material)
+    }
+
+    return materials, rows.Err()
+}
+
+// GetExpiredTrash возвращает ID материалов, попавших в корзину раньше before
+func (r *MaterialRepository) GetExpiredTrash(ctx context.Context, before time.Time) ([]int, error) {
+    query := `SELECT id FROM materials WHERE deleted_at IS NOT NULL AND deleted_at < $1 ORDER BY deleted_at`
+
+    rows, err := r.db.Query(ctx, query, before)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var ids []int
+    for rows.Next() {
+        var id int
+        if err := rows.Scan(&id); err != nil {
+            return nil, err
+        }
+        ids = append(ids, id)
+    }
+
+    return ids, rows.Err()
+}
+
+// publishScheduleLock - ключ advisory-блокировки планировщика публикаций
+const publishScheduleLock = 4602001
+
+// ApplyPublishSchedule публикует черновики, время публикации которых наступило, и переносит
+// в архив материалы с истекшим окном доступа. Несколько инстансов не мешают друг другу:
+// переходы выполняет тот, кто взял блокировку, остальные пропускают проход.
+// Возвращает ID опубликованных и перенесенных в архив материалов
+func (r *MaterialRepository) ApplyPublishSchedule(ctx context.Context, now time.Time) ([]int, []int, error) {
+    tx, err := r.db.Begin(ctx)
+    if err != nil {
+        return nil, nil, err
+    }
+    defer tx.Rollback(ctx)
+
+    var locked bool
+    if err := tx.QueryRow(ctx, "SELECT pg_try_advisory_xact_lock($1)", publishScheduleLock).Scan(&locked); err != nil {
+        return nil, nil, err
+    }
+    if !locked {
+        return nil, nil, nil
+    }
+
+    published, err := collectIDs(tx.Query(ctx, `
+        UPDATE materials
+        SET status = 'published', publish_at = NULL, updated_at = CURRENT_TIMESTAMP
+        WHERE publish_at <= $1 AND deleted_at IS NULL AND status = 'draft'
+          AND (unpublish_at IS NULL OR unpublish_at > $1)
+        RETURNING id
+    `, now))
+    if err != nil {
+        return nil, nil, err
+    }
+
+    // Окно, закончившееся раньше публикации, тоже закрывается: такой черновик сразу уходит в архив
+    archived, err := collectIDs(tx.Query(ctx, `
+        UPDATE materials
+        SET status = 'archived', publish_at = NULL, unpublish_at = NULL, updated_at = CURRENT_TIMESTAMP
+        WHERE unpublish_at <= $1 AND deleted_at IS NULL AND status IN ('draft', 'published')
+        RETURNING id
+    `, now))
+    if err != nil {
+        return nil, nil, err
+    }
+
+    return published, archived, tx.Commit(ctx)
+}
+
+// collectIDs читает ID из результата запроса
+func collectIDs(rows pgx.Rows, err error) ([]int, error) {
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var ids []int
+    for rows.Next() {
+        var id int
+        if err := rows.Scan(&id); err != nil {
+            return nil, err
+        }
+        ids = append(ids, id)
+    }
+    return ids, rows.Err()
+}
+
+// PurgeMaterial окончательно удаляет материал и возвращает его обложку и блоки
+// для очистки медиа. nil - материал уже удален
+func (r *MaterialRepository) PurgeMaterial(ctx context.Context, materialID int) (*models.Material, error) {
+    tx, err := r.db.Begin(ctx)
+    if err != nil {
+        return nil, err
+    }
+    defer tx.Rollback(ctx)
+
+    var material models.Material
+    err = tx.QueryRow(ctx,
+        "SELECT id, author_id, cover_url FROM materials WHERE id = $1 FOR UPDATE", materialID,
+    ).Scan(&material.ID, &material.AuthorID, &material.CoverURL)
+    if err == pgx.ErrNoRows {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    material.Blocks, err = getBlocks(ctx, tx, materialID)
+    if err != nil {
+        return nil, err
+    }
+
+    // Блоки, соавторы, теги, журнал операций и прогресс удаляются каскадно
+    if _, err := tx.Exec(ctx, "DELETE FROM materials WHERE id = $1", materialID); err != nil {
+        return nil, err
+    }
+
+    return &material, tx.Commit(ctx)
+}
+
+// IsMediaReferenced проверяет, используется ли файл в материалах или профилях
+func (r *MaterialRepository) IsMediaReferenced(ctx context.Context, url string) (bool, error) {
+    query := `
+        SELECT EXISTS(SELECT 1 FROM materials WHERE cover_url = $1)
+            OR EXISTS(SELECT 1 FROM material_blocks WHERE strpos(content::text, $1) > 0)
+            OR EXISTS(SELECT 1 FROM users WHERE avatar_url = $1)
+    `
+
+    var referenced bool
+    err := r.db.QueryRow(ctx, query, url).Scan(&referenced)
+    return referenced, err
+}
@@ -0,0 +1,278 @@
+package repositories
+
+import (
+    "context"
+    "fmt"
+
+    "paydeya-backend/internal/models"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ModerationRepository struct {
+    db *pgxpool.Pool
+}
+
+func NewModerationRepository(db *pgxpool.Pool) *ModerationRepository {
+    return &ModerationRepository{db: db}
+}
+
+// IsTrustedAuthor проверяет, может ли пользователь публиковать без модерации:
+// доверенные авторы и администраторы
+func (r *ModerationRepository) IsTrustedAuthor(ctx context.Context, userID int) (bool, error) {
+    var trusted bool
+    err := r.db.QueryRow(ctx, `SELECT trusted_author OR role = 'admin' FROM users WHERE id = $1`, userID).Scan(&trusted)
+    if err == pgx.ErrNoRows {
+        return false, nil
+    }
+    return trusted, err
+}
+
+// SetTrustedAuthor изменяет доверие к автору. false - пользователь не найден
+func (r *ModerationRepository) SetTrustedAuthor(ctx context.Context, userID int, trusted bool) (bool, error) {
+    tag, err := r.db.Exec(ctx, `UPDATE users SET trusted_author = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`, trusted, userID)
+    if err != nil {
+        return false, err
+    }
+    return tag.RowsAffected() > 0, nil
+}
+
+// SubmitReview ставит материал в очередь модерации. Заявка на рассмотрении у материала
+// одна: повторная публикация до решения модератора новую заявку не создает
+func (r *ModerationRepository) SubmitReview(ctx context.Context, materialID, userID int) error {
+    query := `
+        INSERT INTO material_reviews (material_id, submitted_by)
+        VALUES ($1, $2)
+        ON CONFLICT (material_id) WHERE status = 'pending' DO NOTHING
+    `
+
+    _, err := r.db.Exec(ctx, query, materialID, userID)
+    return err
+}
+
+// ResubmitEdited возвращает на модерацию опубликованный или запланированный материал, содержимое
+// которого изменилось после одобрения, и ставит его в очередь. false - материал не был одобрен
+// (черновик без расписания, уже на модерации или в архиве)
+func (r *ModerationRepository) ResubmitEdited(ctx context.Context, materialID, userID int) (bool, error) {
+    tx, err := r.db.Begin(ctx)
+    if err != nil {
+        return false, err
+    }
+    defer tx.Rollback(ctx)
+
+    tag, err := tx.Exec(ctx, `
+        UPDATE materials SET status = 'pending_review', updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND (status = 'published' OR (status = 'draft' AND publish_at IS NOT NULL))
+    `, materialID)
+    if err != nil {
+        return false, err
+    }
+    if tag.RowsAffected() == 0 {
+        return false, nil
+    }
+
+    if _, err := tx.Exec(ctx, `
+        INSERT INTO material_reviews (material_id, submitted_by)
+        VALUES ($1, $2)
+        ON CONFLICT (material_id) WHERE status = 'pending' DO NOTHING
+    `, materialID, userID); err != nil {
+        return false, err
+    }
+
+    return true, tx.Commit(ctx)
+}
+
+// WithdrawReview отзывает заявку на рассмотрении (автор вернул материал в черновики или архив)
+func (r *ModerationRepository) WithdrawReview(ctx context.Context, materialID int) error {
+    query := `
+        UPDATE material_reviews SET status = 'withdrawn', decided_at = CURRENT_TIMESTAMP
+        WHERE material_id = $1 AND status = 'pending'
+    `
+
+    _, err := r.db.Exec(ctx, query, materialID)
+    return err
+}
+
+const reviewColumns = `
+    mr.id, mr.material_id, m.title, m.author_id, u.full_name, mr.status, mr.submitted_at,
+    mr.moderator_id, COALESCE(mu.full_name, ''), mr.decided_at, mr.comment
+`
+
+const reviewJoins = `
+    FROM material_reviews mr
+    JOIN materials m ON mr.material_id = m.id
+    JOIN users u ON m.author_id = u.id
+    LEFT JOIN users mu ON mr.moderator_id = mu.id
+`
+
+func scanReview(row pgx.Row) (*models.MaterialReview, error) {
+    review := models.MaterialReview{BlockComments: []models.ReviewComment{}}
+    err := row.Scan(
+        &review.ID, &review.MaterialID, &review.MaterialTitle, &review.AuthorID, &review.AuthorName,
+        &review.Status, &review.SubmittedAt, &review.ModeratorID, &review.ModeratorName, &review.DecidedAt,
+        &review.Comment,
+    )
+    return &review, err
+}
+
+// GetQueue возвращает заявки на рассмотрении, начиная с самых старых.
+// Материалы из корзины в очередь не попадают
+func (r *ModerationRepository) GetQueue(ctx context.Context, filters models.ModerationQueueFilters) ([]models.MaterialReview, int, error) {
+    where := ` WHERE mr.status = 'pending' AND m.status = 'pending_review' AND m.deleted_at IS NULL`
+    args := []interface{}{}
+    if filters.Subject != "" {
+        args = append(args, filters.Subject)
+        where += fmt.Sprintf(" AND m.subject_id = $%d", len(args))
+    }
+
+    var total int
+    if err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM material_reviews mr JOIN materials m ON mr.material_id = m.id`+where, args...).Scan(&total); err != nil {
+        return nil, 0, err
+    }
+
+    query := `SELECT ` + reviewColumns + reviewJoins + where + ` ORDER BY mr.submitted_at, mr.id` + pageClause(&args, filters.Page, filters.Limit)
+
+    rows, err := r.db.Query(ctx, query, args...)
+    if err != nil {
+        return nil, 0, err
+    }
+    defer rows.Close()
+
+    reviews := []models.MaterialReview{}
+    for rows.Next() {
+        review, err := scanReview(rows)
+        if err != nil {
+            return nil, 0, err
+        }
+        reviews = append(reviews, *review)
+    }
+
+    return reviews, total, rows.Err()
+}
+
+// GetReview возвращает заявку с замечаниями к блокам или nil
+func (r *ModerationRepository) GetReview(ctx context.Context, reviewID int) (*models.MaterialReview, error) {
+    review, err := scanReview(r.db.QueryRow(ctx, `SELECT `+reviewColumns+reviewJoins+` WHERE mr.id = $1`, reviewID))
+    if err == pgx.ErrNoRows {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    comments, err := r.getBlockComments(ctx, []int{reviewID})
+    if err != nil {
+        return nil, err
+    }
+    if c, ok := comments[reviewID]; ok {
+        review.BlockComments = c
+    }
+    return review, nil
+}
+
+// GetMaterialReviews возвращает историю заявок материала, начиная с последней
+func (r *ModerationRepository) GetMaterialReviews(ctx context.Context, materialID int) ([]models.MaterialReview, error) {
+    rows, err := r.db.Query(ctx, `SELECT `+reviewColumns+reviewJoins+` WHERE mr.material_id = $1 ORDER BY mr.submitted_at DESC, mr.id DESC`, materialID)
+    if err != nil {
+        return nil, err
+    }
+
+    reviews := []models.MaterialReview{}
+    var ids []int
+    for rows.Next() {
+        review, err := scanReview(rows)
+        if err != nil {
+            rows.Close()
+            return nil, err
+        }
+        reviews = append(reviews, *review)
+        ids = append(ids, review.ID)
+    }
+    rows.Close()
+    if err := rows.Err(); err != nil {
+        return nil, err
+    }
+
+    comments, err := r.getBlockComments(ctx, ids)
+    if err != nil {
+        return nil, err
+    }
+    for i := range reviews {
+        if c, ok := comments[reviews[i].ID]; ok {
+            reviews[i].BlockComments = c
+        }
+    }
+    return reviews, nil
+}
+
+func (r *ModerationRepository) getBlockComments(ctx context.Context, reviewIDs []int) (map[int][]models.ReviewComment, error) {
+    comments := make(map[int][]models.ReviewComment)
+    if len(reviewIDs) == 0 {
+        return comments, nil
+    }
+
+    rows, err := r.db.Query(ctx, `
+        SELECT review_id, block_id, comment FROM material_review_comments
+        WHERE review_id = ANY($1) ORDER BY id
+    `, reviewIDs)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    for rows.Next() {
+        var reviewID int
+        var comment models.ReviewComment
+        if err := rows.Scan(&reviewID, &comment.BlockID, &comment.Comment); err != nil {
+            return nil, err
+        }
+        comments[reviewID] = append(comments[reviewID], comment)
+    }
+    return comments, rows.Err()
+}
+
+// DecideReview записывает решение модератора и переводит материал в materialStatus
+// в одной транзакции. false - заявка уже рассмотрена или отозвана
+func (r *ModerationRepository) DecideReview(ctx context.Context, reviewID, moderatorID int, status string, req *models.ReviewDecisionRequest, materialStatus string) (bool, error) {
+    tx, err := r.db.Begin(ctx)
+    if err != nil {
+        return false, err
+    }
+    defer tx.Rollback(ctx)
+
+    var materialID int
+    err = tx.QueryRow(ctx, `
+        UPDATE material_reviews
+        SET status = $1, moderator_id = $2, decided_at = CURRENT_TIMESTAMP, comment = $3
+        WHERE id = $4 AND status = 'pending'
+        RETURNING material_id
+    `, status, moderatorID, req.Comment, reviewID).Scan(&materialID)
+    if err == pgx.ErrNoRows {
+        return false, nil
+    }
+    if err != nil {
+        return false, err
+    }
+
+    for _, comment := range req.BlockComments {
+        if _, err := tx.Exec(ctx, `
+            INSERT INTO material_review_comments (review_id, block_id, comment) VALUES ($1, $2, $3)
+        `, reviewID, comment.BlockID, comment.Comment); err != nil {
+            return false, err
+        }
+    }
+
+    tag, err := tx.Exec(ctx, `
+        UPDATE materials SET status = $1, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $2 AND status = 'pending_review'
+    `, materialStatus, materialID)
+    if err != nil {
+        return false, err
+    }
+    if tag.RowsAffected() == 0 {
+        return false, nil
+    }
+
+    return true, tx.Commit(ctx)
+}
@@ -0,0 +1,89 @@
+package repositories
+
+import (
+    "context"
+
+    "paydeya-backend/internal/models"
+
+    "github.com/jackc/pgx/v5/pgxpool"
+)
+
+type NotificationRepository struct {
+    db *pgxpool.Pool
+}
+
+func NewNotificationRepository(db *pgxpool.Pool) *NotificationRepository {
+    return &NotificationRepository{db: db}
+}
+
+// CreateNotification сохраняет уведомление пользователю
+func (r *NotificationRepository) CreateNotification(ctx context.Context, userID int, kind, message string, materialID *int) error {
+    query := `INSERT INTO notifications (user_id, type, message, material_id) VALUES ($1, $2, $3, $4)`
+    _, err := r.db.Exec(ctx, query, userID, kind, message, materialID)
+    return err
+}
+
+// GetNotifications возвращает уведомления пользователя, начиная с новых,
+// общее количество по фильтру и количество непрочитанных
+func (r *NotificationRepository) GetNotifications(ctx context.Context, userID int, filters models.NotificationFilters) ([]models.Notification, int, int, error) {
+    where := ` WHERE user_id = $1`
+    if filters.Unread {
+        where += ` AND read_at IS NULL`
+    }
+    args := []interface{}{userID}
+
+    var total, unread int
+    err := r.db.QueryRow(ctx, `
+        SELECT COUNT(*), COUNT(*) FILTER (WHERE read_at IS NULL) FROM notifications WHERE user_id = $1
+    `, userID).Scan(&total, &unread)
+    if err != nil {
+        return nil, 0, 0, err
+    }
+    if filters.Unread {
+        total = unread
+    }
+
+    query := `SELECT id, type, message, material_id, read_at IS NOT NULL, created_at FROM notifications` +
+        where + ` ORDER BY created_at DESC, id DESC` + pageClause(&args, filters.Page, filters.Limit)
+
+    rows, err := r.db.Query(ctx, query, args...)
+    if err != nil {
+        return nil, 0, 0, err
+    }
+    defer rows.Close()
+
+    notifications := []models.Notification{}
+    for rows.Next() {
+        var n models.Notification
+        if err := rows.Scan(&n.ID, &n.Type, &n.Message, &n.MaterialID, &n.Read, &n.CreatedAt); err != nil {
+            return nil, 0, 0, err
+        }
+        notifications = append(notifications, n)
+    }
+
+    return notifications, total, unread, rows.Err()
+}
+
+// MarkRead отмечает уведомление прочитанным. false - уведомление не найдено
+func (r *NotificationRepository) MarkRead(ctx context.Context, userID, notificationID int) (bool, error) {
+    tag, err := r.db.Exec(ctx, `
+        UPDATE notifications SET read_at = COALESCE(read_at, CURRENT_TIMESTAMP)
+        WHERE id = $1 AND user_id = $2
+    `, notificationID, userID)
+    if err != nil {
+        return false, err
+    }
+    return tag.RowsAffected() > 0, nil
+}
+
+// MarkAllRead отмечает прочитанными все уведомления пользователя и возвращает их количество
+func (r *NotificationRepository) MarkAllRead(ctx context.Context, userID int) (int, error) {
+    tag, err := r.db.Exec(ctx, `
+        UPDATE notifications SET read_at = CURRENT_TIMESTAMP
+        WHERE user_id = $1 AND read_at IS NULL
+    `, userID)
+    if err != nil {
+        return 0, err
+    }
+    return int(tag.RowsAffected()), nil
+}
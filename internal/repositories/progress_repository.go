@@ -48,36 +48,78 @@ func (r *ProgressRepository) GetStudentProgress(ctx context.Context, userID int)
         progress.SuccessRate = progress.AverageGrade / 5 * 100
     }
 
-    // Получаем текущие материалы (последние 5)
+    // Получаем материалы в процессе изучения (последние 5) с долей просмотренных блоков
     query = `
-        SELECT m.id, m.title, m.subject, mc.last_activity
-        FROM materials m
-        JOIN material_completions mc ON m.id = mc.material_id
-        WHERE mc.user_id = $1
-        ORDER BY mc.last_activity DESC
+        SELECT m.id, m.title, m.subject_id, mp.last_block_id, mp.last_activity,
+               (SELECT COUNT(*) FROM material_block_views v
+                JOIN material_blocks b ON b.material_id = v.material_id AND b.block_id = v.block_id
+                WHERE v.user_id = mp.user_id AND v.material_id = m.id) as viewed_blocks,
+               (SELECT COUNT(*) FROM material_blocks b WHERE b.material_id = m.id) as total_blocks
+        FROM material_progress mp
+        JOIN materials m ON m.id = mp.material_id
+        LEFT JOIN material_completions mc ON mc.material_id = mp.material_id AND mc.user_id = mp.user_id
+        WHERE mp.user_id = $1 AND mc.id IS NULL AND m.deleted_at IS NULL
+        ORDER BY mp.last_activity DESC
         LIMIT 5
     `
     rows, err := r.db.Query(ctx, query, userID)
-    if err == nil {
-        defer rows.Close()
-
-        for rows.Next() {
-            var material models.ProgressMaterial
-            var lastActivity time.Time
-
-            if err := rows.Scan(&material.ID, &material.Title, &material.Subject, &lastActivity); err == nil {
-                material.LastActivity = lastActivity
-                material.Progress = 100.0 // если в completion, значит завершен
-                progress.CurrentMaterials = append(progress.CurrentMaterials, material)
-            }
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    progress.CurrentMaterials = []models.ProgressMaterial{}
+    for rows.Next() {
+        var material models.ProgressMaterial
+        var viewedBlocks, totalBlocks int
+
+        err := rows.Scan(
+            &material.ID, &material.Title, &material.Subject, &material.LastBlockID, &material.LastActivity,
+            &viewedBlocks, &totalBlocks,
+        )
+        if err != nil {
+            return nil, err
         }
+
+        material.Progress = blocksPercent(viewedBlocks, totalBlocks)
+        progress.CurrentMaterials = append(progress.CurrentMaterials, material)
+    }
+    if err := rows.Err(); err != nil {
+        return nil, err
     }
 
     return &progress, nil
 }
 
-// MarkMaterialComplete отмечает материал как завершенный
-func (r *ProgressRepository) MarkMaterialComplete(ctx context.Context, userID, materialID int, timeSpent int, grade float64) error {
+// codeExerciseCondition - блок b является упражнением с кодом: у него есть тесты
+const codeExerciseCondition = `(b.type = 'code' AND jsonb_array_length(COALESCE(b.content->'tests', '[]')) > 0)`
+
+// CountUnpassedExercises возвращает число упражнений с кодом в материале, которые ученик еще не решил
+func (r *ProgressRepository) CountUnpassedExercises(ctx context.Context, userID, materialID int) (int, error) {
+    query := `
+        SELECT COUNT(*)
+        FROM material_blocks b
+        WHERE b.material_id = $2 AND ` + codeExerciseCondition + `
+          AND NOT EXISTS (
+              SELECT 1 FROM quiz_answers qa
+              WHERE qa.user_id = $1 AND qa.material_id = b.material_id AND qa.block_id = b.block_id AND qa.correct
+          )
+    `
+
+    var count int
+    err := r.db.QueryRow(ctx, query, userID, materialID).Scan(&count)
+    return count, err
+}
+
+// MarkMaterialComplete отмечает материал как завершенный с оценкой, вычисленной сервером (nil - оценки нет),
+// и сохраняет проверенные ответы на вопросы. Учитываются только ответы на quiz-блоки этого материала
+func (r *ProgressRepository) MarkMaterialComplete(ctx context.Context, userID, materialID int, timeSpent int, grade *float64, answers []models.QuizResult) error {
+    tx, err := r.db.Begin(ctx)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback(ctx)
+
     query := `
         INSERT INTO material_completions (user_id, material_id, time_spent, grade, completed_at, last_activity)
         VALUES ($1, $2, $3, $4, $5, $6)
@@ -86,20 +128,144 @@ func (r *ProgressRepository) MarkMaterialComplete(ctx context.Context, userID, m
     `
 
     now := time.Now()
-    _, err := r.db.Exec(ctx, query, userID, materialID, timeSpent, grade, now, now)
-    return err
+    if _, err := tx.Exec(ctx, query, userID, materialID, timeSpent, grade, now, now); err != nil {
+        return err
+    }
+
+    answerQuery := `
+        INSERT INTO quiz_answers (user_id, material_id, block_id, correct, answered_at)
+        SELECT $1, $2, block_id, $4, $5
+        FROM material_blocks
+        WHERE material_id = $2 AND block_id = $3 AND type = 'quiz'
+        ON CONFLICT (user_id, material_id, block_id)
+        DO UPDATE SET correct = EXCLUDED.correct, answered_at = EXCLUDED.answered_at
+    `
+
+    for _, answer := range answers {
+        if _, err := tx.Exec(ctx, answerQuery, userID, materialID, answer.BlockID, answer.Correct, now); err != nil {
+            return err
+        }
+    }
+
+    return tx.Commit(ctx)
+}
+
+// RecordBlockProgress сохраняет просмотр блоков материала и позицию для продолжения.
+// Блоки, которых нет в материале, игнорируются. false - в материале нет блока lastBlockID
+func (r *ProgressRepository) RecordBlockProgress(ctx context.Context, userID, materialID int, lastBlockID string, blocks []models.BlockTime) (bool, error) {
+    tx, err := r.db.Begin(ctx)
+    if err != nil {
+        return false, err
+    }
+    defer tx.Rollback(ctx)
+
+    viewQuery := `
+        INSERT INTO material_block_views (user_id, material_id, block_id, time_spent, first_viewed_at, last_viewed_at)
+        SELECT $1, $2, block_id, $4, $5, $5
+        FROM material_blocks
+        WHERE material_id = $2 AND block_id = $3
+        ON CONFLICT (user_id, material_id, block_id)
+        DO UPDATE SET time_spent = material_block_views.time_spent + EXCLUDED.time_spent,
+                      last_viewed_at = EXCLUDED.last_viewed_at
+    `
+
+    now := time.Now()
+
+    // Последний просмотренный блок тоже считается просмотренным
+    tag, err := tx.Exec(ctx, viewQuery, userID, materialID, lastBlockID, 0, now)
+    if err != nil {
+        return false, err
+    }
+    if tag.RowsAffected() == 0 {
+        return false, nil
+    }
+
+    timeSpent := 0
+    for _, block := range blocks {
+        tag, err := tx.Exec(ctx, viewQuery, userID, materialID, block.BlockID, block.TimeSpent, now)
+        if err != nil {
+            return false, err
+        }
+        if tag.RowsAffected() > 0 {
+            timeSpent += block.TimeSpent
+        }
+    }
+
+    progressQuery := `
+        INSERT INTO material_progress (user_id, material_id, last_block_id, time_spent, started_at, last_activity)
+        VALUES ($1, $2, $3, $4, $5, $5)
+        ON CONFLICT (user_id, material_id)
+        DO UPDATE SET last_block_id = EXCLUDED.last_block_id,
+                      time_spent = material_progress.time_spent + EXCLUDED.time_spent,
+                      last_activity = EXCLUDED.last_activity
+    `
+
+    if _, err := tx.Exec(ctx, progressQuery, userID, materialID, lastBlockID, timeSpent, now); err != nil {
+        return false, err
+    }
+
+    return true, tx.Commit(ctx)
+}
+
+// GetMaterialProgress возвращает прогресс ученика внутри материала
+func (r *ProgressRepository) GetMaterialProgress(ctx context.Context, userID, materialID int) (*models.MaterialProgress, error) {
+    progress := models.MaterialProgress{MaterialID: materialID}
+
+    // Упражнение с кодом считается пройденным не по просмотру, а когда решение прошло все тесты
+    query := `
+        SELECT COALESCE(mp.last_block_id, ''), COALESCE(mp.time_spent, mc.time_spent, 0), mc.id IS NOT NULL,
+               GREATEST(mp.last_activity, mc.last_activity),
+               (SELECT COUNT(*) FROM material_block_views v
+                JOIN material_blocks b ON b.material_id = v.material_id AND b.block_id = v.block_id
+                WHERE v.user_id = $1 AND v.material_id = $2 AND NOT ` + codeExerciseCondition + `),
+               (SELECT COUNT(*) FROM material_blocks b WHERE b.material_id = $2),
+               (SELECT COUNT(*) FROM material_blocks b WHERE b.material_id = $2 AND ` + codeExerciseCondition + `),
+               (SELECT COUNT(*) FROM quiz_answers qa
+                JOIN material_blocks b ON b.material_id = qa.material_id AND b.block_id = qa.block_id
+                WHERE qa.user_id = $1 AND qa.material_id = $2 AND qa.correct AND ` + codeExerciseCondition + `)
+        FROM (SELECT $1::int as user_id, $2::int as material_id) k
+        LEFT JOIN material_progress mp ON mp.user_id = k.user_id AND mp.material_id = k.material_id
+        LEFT JOIN material_completions mc ON mc.user_id = k.user_id AND mc.material_id = k.material_id
+    `
+
+    err := r.db.QueryRow(ctx, query, userID, materialID).Scan(
+        &progress.LastBlockID, &progress.TimeSpent, &progress.Completed, &progress.LastActivity,
+        &progress.ViewedBlocks, &progress.TotalBlocks, &progress.Exercises, &progress.ExercisesPassed,
+    )
+    if err != nil {
+        return nil, err
+    }
+    progress.ViewedBlocks += progress.ExercisesPassed
+
+    if progress.Completed {
+        progress.Progress = 100
+    } else {
+        progress.Progress = blocksPercent(progress.ViewedBlocks, progress.TotalBlocks)
+    }
+
+    return &progress, nil
+}
+
+// blocksPercent возвращает долю просмотренных блоков в процентах
+func blocksPercent(viewed, total int) float64 {
+    if total == 0 {
+        return 0
+    }
+    return float64(viewed) * 100 / float64(total)
 }
 
 // GetFavoriteMaterials возвращает избранные материалы
 func (r *ProgressRepository) GetFavoriteMaterials(ctx context.Context, userID int) ([]models.CatalogMaterial, error) {
     query := `
-        SELECT m.id, m.title, m.subject,
+        SELECT m.id, m.title, m.subject_id,
                u.id as author_id, u.full_name as author_name,
-               4.5 as rating, 10 as students_count
+               ` + materialRatingColumn + ` as rating,
+               ` + materialRatingsCountColumn + ` as ratings_count,
+               ` + materialStudentsColumn + ` as students_count
         FROM materials m
         JOIN users u ON m.author_id = u.id
         JOIN favorite_materials fm ON m.id = fm.material_id
-        WHERE fm.user_id = $1 AND m.status = 'published'
+        WHERE fm.user_id = $1 AND m.status = 'published' AND m.deleted_at IS NULL
         ORDER BY fm.created_at DESC
     `
 
@@ -116,7 +282,7 @@ func (r *ProgressRepository) GetFavoriteMaterials(ctx context.Context, userID in
 
         err := rows.Scan(
             &material.ID, &material.Title, &material.Subject,
-            &author.ID, &author.Name, &material.Rating, &material.StudentsCount,
+            &author.ID, &author.Name, &material.Rating, &material.RatingsCount, &material.StudentsCount,
         )
         if err != nil {
             return nil, err
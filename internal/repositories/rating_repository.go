@@ -0,0 +1,132 @@
+package repositories
+
+import (
+    "context"
+
+    "paydeya-backend/internal/models"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgxpool"
+)
+
+type RatingRepository struct {
+    db *pgxpool.Pool
+}
+
+func NewRatingRepository(db *pgxpool.Pool) *RatingRepository {
+    return &RatingRepository{db: db}
+}
+
+// SaveRating сохраняет оценку ученика; повторная оценка заменяет предыдущую,
+// ответ автора на отзыв сохраняется
+func (r *RatingRepository) SaveRating(ctx context.Context, rating *models.MaterialRating) error {
+    query := `
+        INSERT INTO material_ratings (material_id, user_id, rating, review)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (material_id, user_id) DO UPDATE
+        SET rating = EXCLUDED.rating, review = EXCLUDED.review, updated_at = CURRENT_TIMESTAMP
+        RETURNING id, author_response, responded_at, created_at, updated_at
+    `
+
+    return r.db.QueryRow(ctx, query, rating.MaterialID, rating.UserID, rating.Rating, rating.Review).Scan(
+        &rating.ID, &rating.AuthorResponse, &rating.RespondedAt, &rating.CreatedAt, &rating.UpdatedAt,
+    )
+}
+
+// GetSummary возвращает среднюю оценку материала и распределение оценок
+func (r *RatingRepository) GetSummary(ctx context.Context, materialID int) (*models.RatingSummary, error) {
+    rows, err := r.db.Query(ctx, `
+        SELECT rating, COUNT(*) FROM material_ratings WHERE material_id = $1 GROUP BY rating
+    `, materialID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    summary := &models.RatingSummary{Distribution: map[int]int{1: 0, 2: 0, 3: 0, 4: 0, 5: 0}}
+    sum := 0
+    for rows.Next() {
+        var rating, count int
+        if err := rows.Scan(&rating, &count); err != nil {
+            return nil, err
+        }
+        summary.Distribution[rating] = count
+        summary.Count += count
+        sum += rating * count
+    }
+    if summary.Count > 0 {
+        summary.Average = float64(sum) / float64(summary.Count)
+    }
+
+    return summary, rows.Err()
+}
+
+const ratingColumns = `
+    mr.id, mr.material_id, mr.user_id, u.full_name, COALESCE(u.avatar_url, ''), mr.rating, mr.review,
+    mr.author_response, mr.responded_at, mr.created_at, COALESCE(mr.updated_at, mr.created_at)
+`
+
+func scanRating(row pgx.Row) (*models.MaterialRating, error) {
+    var rating models.MaterialRating
+    err := row.Scan(
+        &rating.ID, &rating.MaterialID, &rating.UserID, &rating.UserName, &rating.UserAvatar, &rating.Rating,
+        &rating.Review, &rating.AuthorResponse, &rating.RespondedAt, &rating.CreatedAt, &rating.UpdatedAt,
+    )
+    return &rating, err
+}
+
+// GetReviews возвращает оценки с текстом отзыва, начиная с последних, и их общее количество
+func (r *RatingRepository) GetReviews(ctx context.Context, materialID int, filters models.ReviewFilters) ([]models.MaterialRating, int, error) {
+    var total int
+    err := r.db.QueryRow(ctx, `
+        SELECT COUNT(*) FROM material_ratings WHERE material_id = $1 AND review <> ''
+    `, materialID).Scan(&total)
+    if err != nil {
+        return nil, 0, err
+    }
+
+    args := []interface{}{materialID}
+    query := `SELECT ` + ratingColumns + `
+        FROM material_ratings mr JOIN users u ON mr.user_id = u.id
+        WHERE mr.material_id = $1 AND mr.review <> ''
+        ORDER BY COALESCE(mr.updated_at, mr.created_at) DESC, mr.id DESC` + pageClause(&args, filters.Page, filters.Limit)
+
+    rows, err := r.db.Query(ctx, query, args...)
+    if err != nil {
+        return nil, 0, err
+    }
+    defer rows.Close()
+
+    reviews := []models.MaterialRating{}
+    for rows.Next() {
+        review, err := scanRating(rows)
+        if err != nil {
+            return nil, 0, err
+        }
+        reviews = append(reviews, *review)
+    }
+
+    return reviews, total, rows.Err()
+}
+
+// GetRating возвращает оценку или nil
+func (r *RatingRepository) GetRating(ctx context.Context, ratingID int) (*models.MaterialRating, error) {
+    rating, err := scanRating(r.db.QueryRow(ctx, `SELECT `+ratingColumns+`
+        FROM material_ratings mr JOIN users u ON mr.user_id = u.id
+        WHERE mr.id = $1`, ratingID))
+    if err == pgx.ErrNoRows {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    return rating, nil
+}
+
+// SetAuthorResponse сохраняет ответ автора на отзыв
+func (r *RatingRepository) SetAuthorResponse(ctx context.Context, ratingID int, response string) error {
+    _, err := r.db.Exec(ctx, `
+        UPDATE material_ratings SET author_response = $1, responded_at = CURRENT_TIMESTAMP WHERE id = $2
+    `, response, ratingID)
+    return err
+}
@@ -0,0 +1,140 @@
+package repositories
+
+import (
+    "context"
+    "fmt"
+    "time"
+
+    "paydeya-backend/internal/models"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ReportRepository struct {
+    db *pgxpool.Pool
+}
+
+func NewReportRepository(db *pgxpool.Pool) *ReportRepository {
+    return &ReportRepository{db: db}
+}
+
+// CountReportsSince возвращает количество жалоб пользователя начиная с since
+func (r *ReportRepository) CountReportsSince(ctx context.Context, reporterID int, since time.Time) (int, error) {
+    var count int
+    err := r.db.QueryRow(ctx, `
+        SELECT COUNT(*) FROM material_reports WHERE reporter_id = $1 AND created_at >= $2
+    `, reporterID, since).Scan(&count)
+    return count, err
+}
+
+// CreateReport сохраняет жалобу. false - у пользователя уже есть открытая жалоба на этот материал
+func (r *ReportRepository) CreateReport(ctx context.Context, report *models.MaterialReport) (bool, error) {
+    query := `
+        INSERT INTO material_reports (material_id, block_id, reporter_id, category, comment)
+        VALUES ($1, $2, $3, $4, $5)
+        ON CONFLICT (material_id, reporter_id) WHERE status = 'open' DO NOTHING
+        RETURNING id, status, created_at
+    `
+
+    err := r.db.QueryRow(ctx, query,
+        report.MaterialID, report.BlockID, report.ReporterID, report.Category, report.Comment,
+    ).Scan(&report.ID, &report.Status, &report.CreatedAt)
+    if err == pgx.ErrNoRows {
+        return false, nil
+    }
+    return err == nil, err
+}
+
+const reportColumns = `
+    mr.id, mr.material_id, m.title, m.author_id, au.full_name, mr.block_id, mr.reporter_id, ru.full_name,
+    mr.category, mr.comment, mr.status, mr.action, mr.moderator_id, mr.resolution, mr.resolved_at, mr.created_at,
+    (SELECT COUNT(*) FROM material_reports o WHERE o.material_id = mr.material_id AND o.status = 'open')
+`
+
+const reportJoins = `
+    FROM material_reports mr
+    JOIN materials m ON mr.material_id = m.id
+    JOIN users au ON m.author_id = au.id
+    JOIN users ru ON mr.reporter_id = ru.id
+`
+
+func scanReport(row pgx.Row) (*models.MaterialReport, error) {
+    var report models.MaterialReport
+    err := row.Scan(
+        &report.ID, &report.MaterialID, &report.MaterialTitle, &report.AuthorID, &report.AuthorName,
+        &report.BlockID, &report.ReporterID, &report.ReporterName, &report.Category, &report.Comment,
+        &report.Status, &report.Action, &report.ModeratorID, &report.Resolution, &report.ResolvedAt,
+        &report.CreatedAt, &report.OpenReports,
+    )
+    return &report, err
+}
+
+// GetReports возвращает очередь жалоб: открытые - начиная со старых, рассмотренные - с последних
+func (r *ReportRepository) GetReports(ctx context.Context, filters models.ReportFilters) ([]models.MaterialReport, int, error) {
+    args := []interface{}{filters.Status}
+    where := ` WHERE mr.status = $1`
+    if filters.Category != "" {
+        args = append(args, filters.Category)
+        where += fmt.Sprintf(" AND mr.category = $%d", len(args))
+    }
+    if filters.MaterialID != 0 {
+        args = append(args, filters.MaterialID)
+        where += fmt.Sprintf(" AND mr.material_id = $%d", len(args))
+    }
+
+    var total int
+    if err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM material_reports mr`+where, args...).Scan(&total); err != nil {
+        return nil, 0, err
+    }
+
+    order := ` ORDER BY mr.created_at, mr.id`
+    if filters.Status != "open" {
+        order = ` ORDER BY mr.resolved_at DESC, mr.id DESC`
+    }
+    query := `SELECT ` + reportColumns + reportJoins + where + order + pageClause(&args, filters.Page, filters.Limit)
+
+    rows, err := r.db.Query(ctx, query, args...)
+    if err != nil {
+        return nil, 0, err
+    }
+    defer rows.Close()
+
+    reports := []models.MaterialReport{}
+    for rows.Next() {
+        report, err := scanReport(rows)
+        if err != nil {
+            return nil, 0, err
+        }
+        reports = append(reports, *report)
+    }
+
+    return reports, total, rows.Err()
+}
+
+// GetReport возвращает жалобу или nil
+func (r *ReportRepository) GetReport(ctx context.Context, reportID int) (*models.MaterialReport, error) {
+    report, err := scanReport(r.db.QueryRow(ctx, `SELECT `+reportColumns+reportJoins+` WHERE mr.id = $1`, reportID))
+    if err == pgx.ErrNoRows {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    return report, nil
+}
+
+// ResolveReports закрывает открытую жалобу, а с allForMaterial - и все открытые жалобы на тот же материал.
+// Возвращает ID авторов закрытых жалоб
+func (r *ReportRepository) ResolveReports(ctx context.Context, reportID, moderatorID int, status string, req *models.ResolveReportRequest, allForMaterial bool) ([]int, error) {
+    query := `
+        UPDATE material_reports
+        SET status = $1, action = $2, moderator_id = $3, resolution = $4, resolved_at = CURRENT_TIMESTAMP
+        WHERE status = 'open'
+          AND (id = $5 OR ($6 AND material_id = (SELECT material_id FROM material_reports WHERE id = $5)))
+        RETURNING reporter_id
+    `
+
+    rows, err := r.db.Query(ctx, query, status, req.Action, moderatorID, req.Comment, reportID, allForMaterial)
+    return collectIDs(rows, err)
+}
@@ -0,0 +1,201 @@
+package repositories
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "strings"
+    "time"
+
+    "paydeya-backend/internal/models"
+
+    "github.com/jackc/pgx/v5/pgxpool"
+)
+
+type XAPIRepository struct {
+    db *pgxpool.Pool
+}
+
+func NewXAPIRepository(db *pgxpool.Pool) *XAPIRepository {
+    return &XAPIRepository{db: db}
+}
+
+// OutboxStatement - выражение из очереди отправки во внешний LRS
+type OutboxStatement struct {
+    ID          int
+    StatementID string
+    Statement   json.RawMessage
+    Attempts    int
+}
+
+// SaveStatement сохраняет выражение и при необходимости ставит его в очередь отправки
+func (r *XAPIRepository) SaveStatement(ctx context.Context, userID, materialID int, statement *models.XAPIStatement, enqueue bool) error {
+    tx, err := r.db.Begin(ctx)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback(ctx)
+
+    statementJSON, err := json.Marshal(statement)
+    if err != nil {
+        return err
+    }
+
+    query := `
+        INSERT INTO xapi_statements (id, user_id, material_id, verb, activity_id, statement, timestamp, stored)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+    `
+
+    _, err = tx.Exec(ctx, query,
+        statement.ID, userID, materialID, statement.Verb.ID, statement.Object.ID,
+        statementJSON, statement.Timestamp, statement.Stored,
+    )
+    if err != nil {
+        return err
+    }
+
+    if enqueue {
+        if _, err := tx.Exec(ctx, "INSERT INTO xapi_outbox (statement_id) VALUES ($1)", statement.ID); err != nil {
+            return err
+        }
+    }
+
+    return tx.Commit(ctx)
+}
+
+// ClaimOutbox забирает выражения, которые пора отправить. Забранные строки откладываются
+// на lease, чтобы другие инстансы не отправили их одновременно
+func (r *XAPIRepository) ClaimOutbox(ctx context.Context, limit int, lease time.Duration) ([]OutboxStatement, error) {
+    query := `
+        UPDATE xapi_outbox o
+        SET next_attempt_at = CURRENT_TIMESTAMP + $2 * INTERVAL '1 second'
+        FROM xapi_statements s
+        WHERE s.id = o.statement_id AND o.id IN (
+            SELECT id FROM xapi_outbox
+            WHERE NOT failed AND next_attempt_at <= CURRENT_TIMESTAMP
+            ORDER BY next_attempt_at
+            LIMIT $1
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING o.id, o.statement_id::text, s.statement, o.attempts
+    `
+
+    rows, err := r.db.Query(ctx, query, limit, int(lease.Seconds()))
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var statements []OutboxStatement
+    for rows.Next() {
+        var s OutboxStatement
+        if err := rows.Scan(&s.ID, &s.StatementID, &s.Statement, &s.Attempts); err != nil {
+            return nil, err
+        }
+        statements = append(statements, s)
+    }
+
+    return statements, rows.Err()
+}
+
+// MarkDelivered убирает доставленное выражение из очереди
+func (r *XAPIRepository) MarkDelivered(ctx context.Context, outboxID int) error {
+    _, err := r.db.Exec(ctx, "DELETE FROM xapi_outbox WHERE id = $1", outboxID)
+    return err
+}
+
+// MarkAttemptFailed откладывает повторную отправку. nextAttemptAt == nil - попытки исчерпаны
+func (r *XAPIRepository) MarkAttemptFailed(ctx context.Context, outboxID int, nextAttemptAt *time.Time, lastError string) error {
+    query := `
+        UPDATE xapi_outbox
+        SET attempts = attempts + 1,
+            last_error = $2,
+            next_attempt_at = COALESCE($3, next_attempt_at),
+            failed = $3 IS NULL
+        WHERE id = $1
+    `
+
+    _, err := r.db.Exec(ctx, query, outboxID, lastError, nextAttemptAt)
+    return err
+}
+
+// QueryStatements возвращает сохраненные выражения по фильтру
+func (r *XAPIRepository) QueryStatements(ctx context.Context, filter *models.XAPIStatementFilter) ([]json.RawMessage, error) {
+    var conditions []string
+    var args []interface{}
+    argIndex := 1
+
+    if !filter.All {
+        // Пользователь видит свои выражения и выражения о своих материалах
+        conditions = append(conditions, fmt.Sprintf(
+            "(s.user_id = $%d OR s.material_id IN (SELECT id FROM materials WHERE author_id = $%d))", argIndex, argIndex,
+        ))
+        args = append(args, filter.ViewerID)
+        argIndex++
+    }
+
+    if filter.StatementID != "" {
+        conditions = append(conditions, fmt.Sprintf("s.id::text = $%d", argIndex))
+        args = append(args, filter.StatementID)
+        argIndex++
+    }
+
+    if filter.Verb != "" {
+        conditions = append(conditions, fmt.Sprintf("s.verb = $%d", argIndex))
+        args = append(args, filter.Verb)
+        argIndex++
+    }
+
+    if filter.Activity != "" {
+        conditions = append(conditions, fmt.Sprintf("s.activity_id = $%d", argIndex))
+        args = append(args, filter.Activity)
+        argIndex++
+    }
+
+    if filter.AgentMbox != "" {
+        conditions = append(conditions, fmt.Sprintf("s.statement->'actor'->>'mbox' = $%d", argIndex))
+        args = append(args, filter.AgentMbox)
+        argIndex++
+    }
+
+    if filter.Since != nil {
+        conditions = append(conditions, fmt.Sprintf("s.stored > $%d", argIndex))
+        args = append(args, *filter.Since)
+        argIndex++
+    }
+
+    if filter.Until != nil {
+        conditions = append(conditions, fmt.Sprintf("s.stored <= $%d", argIndex))
+        args = append(args, *filter.Until)
+        argIndex++
+    }
+
+    query := `SELECT s.statement FROM xapi_statements s`
+    if len(conditions) > 0 {
+        query += " WHERE " + strings.Join(conditions, " AND ")
+    }
+
+    order := "DESC"
+    if filter.Ascending {
+        order = "ASC"
+    }
+    query += fmt.Sprintf(" ORDER BY s.stored %s, s.id LIMIT $%d", order, argIndex)
+    args = append(args, filter.Limit)
+
+    rows, err := r.db.Query(ctx, query, args...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    statements := []json.RawMessage{}
+    for rows.Next() {
+        var statement json.RawMessage
+        if err := rows.Scan(&statement); err != nil {
+            return nil, err
+        }
+        statements = append(statements, statement)
+    }
+
+    return statements, rows.Err()
+}
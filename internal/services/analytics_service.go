@@ -0,0 +1,149 @@
+package services
+
+import (
+    "context"
+    "fmt"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/repositories"
+)
+
+// timeSpentBuckets - интервалы распределения времени изучения материала (в секундах)
+var timeSpentBuckets = []struct {
+    label string
+    min   int
+    max   int // 0 - без верхней границы
+}{
+    {"до 5 мин", 0, 5 * 60},
+    {"5-15 мин", 5 * 60, 15 * 60},
+    {"15-30 мин", 15 * 60, 30 * 60},
+    {"30-60 мин", 30 * 60, 60 * 60},
+    {"более 60 мин", 60 * 60, 0},
+}
+
+type AnalyticsService struct {
+    analyticsRepo   *repositories.AnalyticsRepository
+    userRepo        *repositories.UserRepository
+    materialService *MaterialService
+}
+
+func NewAnalyticsService(analyticsRepo *repositories.AnalyticsRepository, userRepo *repositories.UserRepository, materialService *MaterialService) *AnalyticsService {
+    return &AnalyticsService{
+        analyticsRepo:   analyticsRepo,
+        userRepo:        userRepo,
+        materialService: materialService,
+    }
+}
+
+// GetMaterialsOverview возвращает сводную статистику по материалам преподавателя
+func (s *AnalyticsService) GetMaterialsOverview(ctx context.Context, teacherID int) ([]models.MaterialStats, error) {
+    return s.analyticsRepo.GetAuthorMaterialStats(ctx, teacherID)
+}
+
+// GetMaterialAnalytics возвращает подробную аналитику материала.
+// Доступна автору и соавторам с ролью не ниже reviewer
+func (s *AnalyticsService) GetMaterialAnalytics(ctx context.Context, userID, materialID int) (*models.MaterialAnalytics, error) {
+    if _, _, err := s.materialService.CheckAccess(ctx, userID, materialID, "reviewer"); err != nil {
+        return nil, err
+    }
+
+    stats, err := s.analyticsRepo.GetMaterialStats(ctx, materialID)
+    if err != nil {
+        return nil, err
+    }
+    if stats == nil {
+        return nil, fmt.Errorf("material not found")
+    }
+
+    analytics := &models.MaterialAnalytics{MaterialStats: *stats}
+
+    times, err := s.analyticsRepo.GetTimeSpentDistribution(ctx, materialID)
+    if err != nil {
+        return nil, err
+    }
+    analytics.TimeSpent = distributeTimeSpent(times)
+
+    analytics.Questions, err = s.analyticsRepo.GetQuestionStats(ctx, materialID)
+    if err != nil {
+        return nil, err
+    }
+
+    analytics.StartedCount, analytics.DropOff, err = s.analyticsRepo.GetBlockDropOff(ctx, materialID)
+    if err != nil {
+        return nil, err
+    }
+
+    return analytics, nil
+}
+
+// GetStudentAnalytics возвращает результаты ученика по материалам преподавателя.
+// Доступна только для учеников из классов преподавателя
+func (s *AnalyticsService) GetStudentAnalytics(ctx context.Context, teacherID, studentID int) (*models.StudentAnalytics, error) {
+    classes, err := s.analyticsRepo.GetStudentClasses(ctx, teacherID, studentID)
+    if err != nil {
+        return nil, err
+    }
+    if len(classes) == 0 {
+        return nil, fmt.Errorf("student not in your classes")
+    }
+
+    user, err := s.userRepo.GetUserByID(ctx, studentID)
+    if err != nil || user == nil {
+        return nil, fmt.Errorf("user not found")
+    }
+
+    materials, err := s.analyticsRepo.GetStudentMaterialStats(ctx, teacherID, studentID)
+    if err != nil {
+        return nil, err
+    }
+
+    analytics := &models.StudentAnalytics{
+        UserID:    user.ID,
+        FullName:  user.FullName,
+        Email:     user.Email,
+        Classes:   classes,
+        Materials: materials,
+    }
+
+    var gradeSum float64
+    var graded int
+    for _, m := range materials {
+        analytics.TimeSpent += m.TimeSpent
+        if m.Completed {
+            analytics.CompletedCount++
+        }
+        if m.Grade != nil {
+            gradeSum += *m.Grade
+            graded++
+        }
+    }
+    if graded > 0 {
+        average := gradeSum / float64(graded)
+        analytics.AverageGrade = &average
+    }
+
+    return analytics, nil
+}
+
+// distributeTimeSpent раскладывает время изучения по интервалам
+func distributeTimeSpent(times []int) []models.TimeSpentBucket {
+    buckets := make([]models.TimeSpentBucket, len(timeSpentBuckets))
+    for i, b := range timeSpentBuckets {
+        buckets[i] = models.TimeSpentBucket{Label: b.label, MinSeconds: b.min}
+        if b.max > 0 {
+            max := b.max
+            buckets[i].MaxSeconds = &max
+        }
+    }
+
+    for _, t := range times {
+        for i, b := range timeSpentBuckets {
+            if t >= b.min && (b.max == 0 || t < b.max) {
+                buckets[i].Count++
+                break
+            }
+        }
+    }
+
+    return buckets
+}
@@ -0,0 +1,235 @@
+package services
+
+import (
+    "fmt"
+    "regexp"
+    "strconv"
+    "strings"
+
+    "paydeya-backend/internal/models"
+)
+
+const (
+    maxAnimationSteps = 50
+    maxAnimationDelay = 60000 // мс
+)
+
+var (
+    animationActions  = map[string]bool{"show": true, "hide": true, "highlight": true}
+    animationTriggers = map[string]bool{"click": true, "auto": true}
+    // Свойства оформления подсвеченного элемента; значения - цвета, размеры и ключевые слова CSS
+    animationStyleKeys = map[string]bool{"color": true, "backgroundColor": true, "borderColor": true,
+        "outlineColor": true, "fontWeight": true, "textDecoration": true, "opacity": true}
+    animationStyleValue = regexp.MustCompile(`^[#a-zA-Z0-9 .,%()-]{1,64}$`)
+)
+
+// AnimationValidationError - ошибки в анимациях блоков
+type AnimationValidationError struct {
+    Errors []models.AnimationError
+}
+
+func (e *AnimationValidationError) Error() string {
+    first := e.Errors[0]
+    return fmt.Sprintf("invalid animation in block %s at step %d: %s", first.BlockID, first.Step, first.Message)
+}
+
+// animationElements - элементы блока, на которые могут ссылаться шаги анимации:
+// block - весь блок; у текста node-N - абзацы и другие узлы верхнего уровня документа
+// и node-N.M - пункты списков; у вопроса - question и option-N; у медиа - image/video и caption;
+// у формулы - formula; у кода - instructions и code. Нумерация с единицы
+func animationElements(block *models.Block) []string {
+    elements := []string{"block"}
+    switch block.Type {
+    case "text":
+        for i, node := range richTextDocument(block.Content).Content {
+            id := "node-" + strconv.Itoa(i+1)
+            elements = append(elements, id)
+            if node.Type == "bullet_list" || node.Type == "ordered_list" {
+                for j := range node.Content {
+                    elements = append(elements, id+"."+strconv.Itoa(j+1))
+                }
+            }
+        }
+    case "image", "video":
+        elements = append(elements, block.Type)
+        if contentString(block.Content, "caption") != "" || (block.Type == "video" && contentString(block.Content, "title") != "") {
+            elements = append(elements, "caption")
+        }
+    case "formula":
+        elements = append(elements, "formula")
+    case "quiz":
+        elements = append(elements, "question")
+        options, _ := quizOptions(block.Content)
+        for i := range options {
+            elements = append(elements, "option-"+strconv.Itoa(i+1))
+        }
+    case "code":
+        if contentString(block.Content, "instructions") != "" {
+            elements = append(elements, "instructions")
+        }
+        elements = append(elements, "code")
+    }
+    return elements
+}
+
+// normalizeBlockAnimation проверяет анимацию блока по его содержимому и приводит ее
+// к каноническому виду: триггер по умолчанию click, действия в нижнем регистре, только
+// разрешенные свойства оформления. Неверные шаги убираются из анимации и возвращаются ошибками
+func normalizeBlockAnimation(block *models.Block) []models.AnimationError {
+    animation := block.Animation
+    if animation == nil {
+        return nil
+    }
+
+    var errs []models.AnimationError
+    fail := func(step int, field, message string) {
+        errs = append(errs, models.AnimationError{BlockID: block.ID, Step: step, Field: field, Message: message})
+    }
+
+    animation.Trigger = strings.ToLower(strings.TrimSpace(animation.Trigger))
+    if animation.Trigger == "" {
+        animation.Trigger = "click"
+    }
+    if !animationTriggers[animation.Trigger] {
+        fail(-1, "trigger", "unknown trigger "+animation.Trigger)
+        animation.Trigger = "click"
+    }
+    if animation.Delay < 0 || animation.Delay > maxAnimationDelay {
+        fail(-1, "delay", fmt.Sprintf("delay must be between 0 and %d", maxAnimationDelay))
+        animation.Delay = 0
+    }
+    if len(animation.Steps) > maxAnimationSteps {
+        fail(-1, "steps", fmt.Sprintf("too many steps (max %d)", maxAnimationSteps))
+        animation.Steps = animation.Steps[:maxAnimationSteps]
+    }
+
+    known := make(map[string]bool)
+    for _, element := range animationElements(block) {
+        known[element] = true
+    }
+
+    steps := make([]models.AnimationStep, 0, len(animation.Steps))
+    for i, step := range animation.Steps {
+        step.Element = strings.TrimSpace(step.Element)
+        step.Action = strings.ToLower(strings.TrimSpace(step.Action))
+
+        valid := true
+        if !known[step.Element] {
+            fail(i, "element", "unknown element "+step.Element)
+            valid = false
+        }
+        if !animationActions[step.Action] {
+            fail(i, "action", "unknown action "+step.Action)
+            valid = false
+        }
+        for key, value := range step.Style {
+            if message := animationStyleError(key, value); message != "" {
+                fail(i, "style."+key, message)
+                valid = false
+            }
+        }
+        if len(step.Style) == 0 {
+            step.Style = nil
+        }
+        if valid {
+            steps = append(steps, step)
+        }
+    }
+    animation.Steps = steps
+
+    return errs
+}
+
+// normalizeBlockAnimations проверяет анимации всех блоков списка
+func normalizeBlockAnimations(blocks []models.Block) []models.AnimationError {
+    var errs []models.AnimationError
+    for i := range blocks {
+        errs = append(errs, normalizeBlockAnimation(&blocks[i])...)
+    }
+    return errs
+}
+
+// animationStyleError возвращает описание ошибки в свойстве оформления шага или пустую строку
+func animationStyleError(key string, value interface{}) string {
+    if !animationStyleKeys[key] {
+        return "unsupported style property"
+    }
+    switch v := value.(type) {
+    case float64, int:
+        return ""
+    case string:
+        lower := strings.ToLower(v)
+        if !animationStyleValue.MatchString(v) || strings.Contains(lower, "url(") || strings.Contains(lower, "expression(") {
+            return "invalid style value"
+        }
+        return ""
+    }
+    return "invalid style value"
+}
+
+// animationTimeline раскладывает анимацию блока по кадрам. Кадр 0 - состояние до анимации:
+// элементы, первое действие с которыми show, скрыты, остальные видны. show и hide действуют
+// до следующего шага с тем же элементом, highlight - только в кадре своего шага
+func animationTimeline(block *models.Block) *models.AnimationTimeline {
+    timeline := &models.AnimationTimeline{
+        BlockID:  block.ID,
+        Trigger:  "click",
+        Elements: animationElements(block),
+        Frames:   []models.AnimationFrame{},
+    }
+
+    // Сохраненную анимацию проверяем на копии: неверные шаги пропускаются и попадают в errors
+    var steps []models.AnimationStep
+    if block.Animation != nil {
+        animation := *block.Animation
+        animation.Steps = append([]models.AnimationStep(nil), block.Animation.Steps...)
+        copied := *block
+        copied.Animation = &animation
+        timeline.Errors = normalizeBlockAnimation(&copied)
+        timeline.Trigger, timeline.Delay, steps = animation.Trigger, animation.Delay, animation.Steps
+    }
+
+    visible := make(map[string]bool, len(timeline.Elements))
+    for _, element := range timeline.Elements {
+        visible[element] = true
+    }
+    seen := make(map[string]bool)
+    for _, step := range steps {
+        if !seen[step.Element] {
+            seen[step.Element] = true
+            if step.Action == "show" {
+                visible[step.Element] = false
+            }
+        }
+    }
+
+    frame := func(index int, step *models.AnimationStep) models.AnimationFrame {
+        f := models.AnimationFrame{Index: index, Step: step, States: make([]models.AnimationElementState, 0, len(timeline.Elements))}
+        if timeline.Trigger == "auto" {
+            at := index * timeline.Delay
+            f.At = &at
+        }
+        for _, element := range timeline.Elements {
+            state := models.AnimationElementState{Element: element, Visible: visible[element]}
+            if step != nil && step.Action == "highlight" && step.Element == element {
+                state.Highlighted, state.Style = true, step.Style
+            }
+            f.States = append(f.States, state)
+        }
+        return f
+    }
+
+    timeline.Frames = append(timeline.Frames, frame(0, nil))
+    for i := range steps {
+        step := &steps[i]
+        switch step.Action {
+        case "show", "highlight":
+            visible[step.Element] = true
+        case "hide":
+            visible[step.Element] = false
+        }
+        timeline.Frames = append(timeline.Frames, frame(i+1, step))
+    }
+
+    return timeline
+}
@@ -20,11 +20,21 @@ func (s *CatalogService) SearchMaterials(ctx context.Context, filters models.Cat
     return s.catalogRepo.SearchMaterials(ctx, filters)
 }
 
+// SearchCourses поиск курсов с фильтрацией
+func (s *CatalogService) SearchCourses(ctx context.Context, filters models.CourseFilters) ([]models.CatalogCourse, int, error) {
+    return s.catalogRepo.SearchCourses(ctx, filters)
+}
+
 // GetSubjects возвращает список предметов
 func (s *CatalogService) GetSubjects(ctx context.Context) ([]models.Subject, error) {
     return s.catalogRepo.GetSubjects(ctx)
 }
 
+// GetTags возвращает теги материалов каталога
+func (s *CatalogService) GetTags(ctx context.Context) ([]models.Tag, error) {
+    return s.catalogRepo.GetTags(ctx)
+}
+
 // SearchTeachers поиск преподавателей
 func (s *CatalogService) SearchTeachers(ctx context.Context, filters models.TeacherFilters) ([]models.Teacher, error) {
     return s.catalogRepo.SearchTeachers(ctx, filters)
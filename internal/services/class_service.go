@@ -0,0 +1,451 @@
+package services
+
+import (
+    "context"
+    "crypto/rand"
+    "fmt"
+    "strings"
+    "time"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/repositories"
+)
+
+// joinCodeAlphabet - символы кода вступления без похожих друг на друга (0/O, 1/I/L)
+const joinCodeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+const joinCodeLength = 8
+
+type ClassService struct {
+    classRepo    *repositories.ClassRepository
+    materialRepo *repositories.MaterialRepository
+    courseRepo   *repositories.CourseRepository
+    userRepo     *repositories.UserRepository
+}
+
+func NewClassService(
+    classRepo *repositories.ClassRepository,
+    materialRepo *repositories.MaterialRepository,
+    courseRepo *repositories.CourseRepository,
+    userRepo *repositories.UserRepository,
+) *ClassService {
+    return &ClassService{
+        classRepo:    classRepo,
+        materialRepo: materialRepo,
+        courseRepo:   courseRepo,
+        userRepo:     userRepo,
+    }
+}
+
+// CreateClass создает класс с уникальным кодом вступления
+func (s *ClassService) CreateClass(ctx context.Context, teacherID int, req *models.CreateClassRequest) (*models.Class, error) {
+    class := &models.Class{
+        Name:        req.Name,
+        Description: req.Description,
+        TeacherID:   teacherID,
+    }
+
+    for attempt := 0; attempt < 5; attempt++ {
+        class.JoinCode = generateJoinCode()
+
+        created, err := s.classRepo.CreateClass(ctx, class)
+        if err != nil {
+            return nil, fmt.Errorf("failed to create class: %w", err)
+        }
+        if created {
+            return s.classRepo.GetClass(ctx, class.ID)
+        }
+    }
+
+    return nil, fmt.Errorf("failed to generate join code")
+}
+
+// GetTeacherClasses возвращает классы преподавателя
+func (s *ClassService) GetTeacherClasses(ctx context.Context, teacherID int) ([]*models.Class, error) {
+    return s.classRepo.GetTeacherClasses(ctx, teacherID)
+}
+
+// GetClass возвращает класс преподавателя
+func (s *ClassService) GetClass(ctx context.Context, teacherID, classID int) (*models.Class, error) {
+    return s.getOwnedClass(ctx, teacherID, classID)
+}
+
+// UpdateClass обновляет название и описание класса
+func (s *ClassService) UpdateClass(ctx context.Context, teacherID, classID int, req *models.UpdateClassRequest) (*models.Class, error) {
+    class, err := s.getOwnedClass(ctx, teacherID, classID)
+    if err != nil {
+        return nil, err
+    }
+
+    if req.Name != nil {
+        class.Name = *req.Name
+    }
+    if req.Description != nil {
+        class.Description = *req.Description
+    }
+
+    if err := s.classRepo.UpdateClass(ctx, class); err != nil {
+        return nil, fmt.Errorf("failed to update class: %w", err)
+    }
+
+    return class, nil
+}
+
+// DeleteClass удаляет класс вместе с составом и заданиями
+func (s *ClassService) DeleteClass(ctx context.Context, teacherID, classID int) error {
+    if _, err := s.getOwnedClass(ctx, teacherID, classID); err != nil {
+        return err
+    }
+
+    return s.classRepo.DeleteClass(ctx, classID)
+}
+
+// RegenerateJoinCode выдает классу новый код вступления, старый перестает действовать
+func (s *ClassService) RegenerateJoinCode(ctx context.Context, teacherID, classID int) (string, error) {
+    if _, err := s.getOwnedClass(ctx, teacherID, classID); err != nil {
+        return "", err
+    }
+
+    for attempt := 0; attempt < 5; attempt++ {
+        code := generateJoinCode()
+
+        updated, err := s.classRepo.SetJoinCode(ctx, classID, code)
+        if err != nil {
+            return "", fmt.Errorf("failed to update join code: %w", err)
+        }
+        if updated {
+            return code, nil
+        }
+    }
+
+    return "", fmt.Errorf("failed to generate join code")
+}
+
+// GetMembers возвращает состав класса
+func (s *ClassService) GetMembers(ctx context.Context, teacherID, classID int) ([]models.ClassMember, error) {
+    if _, err := s.getOwnedClass(ctx, teacherID, classID); err != nil {
+        return nil, err
+    }
+
+    return s.classRepo.GetMembers(ctx, classID)
+}
+
+// AddStudent добавляет ученика в класс по ID или email
+func (s *ClassService) AddStudent(ctx context.Context, teacherID, classID int, req *models.AddClassStudentRequest) (*models.User, error) {
+    if _, err := s.getOwnedClass(ctx, teacherID, classID); err != nil {
+        return nil, err
+    }
+
+    var user *models.User
+    var err error
+    switch {
+    case req.UserID != 0:
+        user, err = s.userRepo.GetUserByID(ctx, req.UserID)
+    case req.Email != "":
+        user, err = s.userRepo.GetUserByEmail(ctx, strings.TrimSpace(req.Email))
+    default:
+        return nil, fmt.Errorf("userId or email is required")
+    }
+    if err != nil || user == nil {
+        return nil, fmt.Errorf("user not found")
+    }
+
+    if user.Role != "student" {
+        return nil, fmt.Errorf("user is not a student")
+    }
+
+    added, err := s.classRepo.AddMember(ctx, classID, user.ID)
+    if err != nil {
+        return nil, err
+    }
+    if !added {
+        return nil, fmt.Errorf("student already in class")
+    }
+
+    return user, nil
+}
+
+// RemoveStudent исключает ученика из класса
+func (s *ClassService) RemoveStudent(ctx context.Context, teacherID, classID, userID int) error {
+    if _, err := s.getOwnedClass(ctx, teacherID, classID); err != nil {
+        return err
+    }
+
+    removed, err := s.classRepo.RemoveMember(ctx, classID, userID)
+    if err != nil {
+        return err
+    }
+    if !removed {
+        return fmt.Errorf("student not in class")
+    }
+
+    return nil
+}
+
+// JoinClass добавляет ученика в класс по коду вступления
+func (s *ClassService) JoinClass(ctx context.Context, userID int, role, code string) (*models.Class, error) {
+    if role != "student" {
+        return nil, fmt.Errorf("user is not a student")
+    }
+
+    class, err := s.classRepo.GetClassByCode(ctx, strings.ToUpper(strings.TrimSpace(code)))
+    if err != nil || class == nil {
+        return nil, fmt.Errorf("invalid join code")
+    }
+
+    added, err := s.classRepo.AddMember(ctx, class.ID, userID)
+    if err != nil {
+        return nil, err
+    }
+    if !added {
+        return nil, fmt.Errorf("already joined")
+    }
+
+    class.JoinCode = ""
+    class.StudentsCount++
+    return class, nil
+}
+
+// GetStudentClasses возвращает классы ученика
+func (s *ClassService) GetStudentClasses(ctx context.Context, userID int) ([]*models.Class, error) {
+    classes, err := s.classRepo.GetStudentClasses(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    // Код вступления видит только преподаватель
+    for _, class := range classes {
+        class.JoinCode = ""
+    }
+
+    return classes, nil
+}
+
+// LeaveClass выводит ученика из класса
+func (s *ClassService) LeaveClass(ctx context.Context, userID, classID int) error {
+    removed, err := s.classRepo.RemoveMember(ctx, classID, userID)
+    if err != nil {
+        return err
+    }
+    if !removed {
+        return fmt.Errorf("class not found")
+    }
+
+    return nil
+}
+
+// CreateAssignment назначает классу опубликованный материал или курс
+func (s *ClassService) CreateAssignment(ctx context.Context, teacherID, classID int, req *models.CreateAssignmentRequest) (*models.Assignment, error) {
+    if _, err := s.getOwnedClass(ctx, teacherID, classID); err != nil {
+        return nil, err
+    }
+
+    if (req.MaterialID == nil) == (req.CourseID == nil) {
+        return nil, fmt.Errorf("materialId or courseId is required")
+    }
+
+    if req.MaterialID != nil {
+        material, err := s.materialRepo.GetMaterial(ctx, *req.MaterialID)
+        if err != nil || material == nil {
+            return nil, fmt.Errorf("material not found")
+        }
+        if material.Status != "published" {
+            return nil, fmt.Errorf("material is not published")
+        }
+    } else {
+        course, err := s.courseRepo.GetCourse(ctx, *req.CourseID)
+        if err != nil || course == nil {
+            return nil, fmt.Errorf("course not found")
+        }
+        if course.Status != "published" {
+            return nil, fmt.Errorf("course is not published")
+        }
+    }
+
+    assignment := &models.Assignment{
+        ClassID:    classID,
+        MaterialID: req.MaterialID,
+        CourseID:   req.CourseID,
+        Note:       req.Note,
+        DueAt:      req.DueAt,
+    }
+
+    if err := s.classRepo.CreateAssignment(ctx, assignment, teacherID); err != nil {
+        return nil, fmt.Errorf("failed to create assignment: %w", err)
+    }
+
+    return s.classRepo.GetAssignment(ctx, classID, assignment.ID)
+}
+
+// GetClassAssignments возвращает задания класса
+func (s *ClassService) GetClassAssignments(ctx context.Context, teacherID, classID int) ([]models.Assignment, error) {
+    if _, err := s.getOwnedClass(ctx, teacherID, classID); err != nil {
+        return nil, err
+    }
+
+    return s.classRepo.GetClassAssignments(ctx, classID)
+}
+
+// UpdateAssignment меняет комментарий и срок задания
+func (s *ClassService) UpdateAssignment(ctx context.Context, teacherID, classID, assignmentID int, req *models.UpdateAssignmentRequest) (*models.Assignment, error) {
+    if _, err := s.getOwnedClass(ctx, teacherID, classID); err != nil {
+        return nil, err
+    }
+
+    assignment, err := s.classRepo.GetAssignment(ctx, classID, assignmentID)
+    if err != nil || assignment == nil {
+        return nil, fmt.Errorf("assignment not found")
+    }
+
+    if req.Note != nil {
+        assignment.Note = *req.Note
+    }
+    if req.ClearDueAt {
+        assignment.DueAt = nil
+    } else if req.DueAt != nil {
+        assignment.DueAt = req.DueAt
+    }
+
+    if err := s.classRepo.UpdateAssignment(ctx, assignment); err != nil {
+        return nil, fmt.Errorf("failed to update assignment: %w", err)
+    }
+
+    return assignment, nil
+}
+
+// DeleteAssignment удаляет задание класса
+func (s *ClassService) DeleteAssignment(ctx context.Context, teacherID, classID, assignmentID int) error {
+    if _, err := s.getOwnedClass(ctx, teacherID, classID); err != nil {
+        return err
+    }
+
+    deleted, err := s.classRepo.DeleteAssignment(ctx, classID, assignmentID)
+    if err != nil {
+        return err
+    }
+    if !deleted {
+        return fmt.Errorf("assignment not found")
+    }
+
+    return nil
+}
+
+// GetStudentAssignments возвращает задания ученика из всех его классов.
+// status фильтрует по состоянию: pending, completed, overdue (пусто - все)
+func (s *ClassService) GetStudentAssignments(ctx context.Context, userID int, status string) ([]models.StudentAssignment, error) {
+    assignments, progress, err := s.classRepo.GetStudentAssignments(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    now := time.Now()
+    result := []models.StudentAssignment{}
+    for i, assignment := range assignments {
+        item := models.StudentAssignment{Assignment: assignment}
+        item.Status, item.Progress, item.CompletedAt, item.Late = assignmentState(assignment.DueAt, progress[i], now)
+
+        if status != "" && item.Status != status {
+            continue
+        }
+        result = append(result, item)
+    }
+
+    return result, nil
+}
+
+// GetClassCompletion возвращает выполнение каждого задания каждым учеником класса
+func (s *ClassService) GetClassCompletion(ctx context.Context, teacherID, classID int) ([]models.AssignmentReport, error) {
+    if _, err := s.getOwnedClass(ctx, teacherID, classID); err != nil {
+        return nil, err
+    }
+
+    assignments, err := s.classRepo.GetClassAssignments(ctx, classID)
+    if err != nil {
+        return nil, err
+    }
+
+    progress, err := s.classRepo.GetClassProgress(ctx, classID)
+    if err != nil {
+        return nil, err
+    }
+
+    byAssignment := make(map[int][]repositories.MemberProgress)
+    for _, p := range progress {
+        byAssignment[p.AssignmentID] = append(byAssignment[p.AssignmentID], p)
+    }
+
+    now := time.Now()
+    reports := make([]models.AssignmentReport, 0, len(assignments))
+    for _, assignment := range assignments {
+        report := models.AssignmentReport{
+            Assignment: assignment,
+            Students:   []models.StudentCompletion{},
+        }
+
+        for _, p := range byAssignment[assignment.ID] {
+            student := models.StudentCompletion{
+                UserID:   p.UserID,
+                FullName: p.FullName,
+                Grade:    p.Grade,
+            }
+            student.Status, student.Progress, student.CompletedAt, student.Late =
+                assignmentState(assignment.DueAt, p.AssignmentProgress, now)
+
+            if student.Status == "completed" {
+                report.CompletedCount++
+            }
+            report.Students = append(report.Students, student)
+        }
+        report.StudentsCount = len(report.Students)
+
+        reports = append(reports, report)
+    }
+
+    return reports, nil
+}
+
+// assignmentState вычисляет состояние задания по прохождению его материалов:
+// задание выполнено, когда пройдены все материалы, просрочено - если срок истек раньше
+func assignmentState(dueAt *time.Time, p repositories.AssignmentProgress, now time.Time) (string, float64, *time.Time, bool) {
+    progress := 0.0
+    if p.Total > 0 {
+        progress = float64(p.Done) * 100 / float64(p.Total)
+    }
+
+    if p.Total > 0 && p.Done == p.Total {
+        late := dueAt != nil && p.LastCompletedAt != nil && p.LastCompletedAt.After(*dueAt)
+        return "completed", progress, p.LastCompletedAt, late
+    }
+
+    if dueAt != nil && now.After(*dueAt) {
+        return "overdue", progress, nil, false
+    }
+
+    return "pending", progress, nil, false
+}
+
+// getOwnedClass возвращает класс, если пользователь - его преподаватель
+func (s *ClassService) getOwnedClass(ctx context.Context, teacherID, classID int) (*models.Class, error) {
+    class, err := s.classRepo.GetClass(ctx, classID)
+    if err != nil || class == nil {
+        return nil, fmt.Errorf("class not found")
+    }
+
+    if class.TeacherID != teacherID {
+        return nil, fmt.Errorf("access denied")
+    }
+
+    return class, nil
+}
+
+// generateJoinCode генерирует код вступления в класс
+func generateJoinCode() string {
+    bytes := make([]byte, joinCodeLength)
+    rand.Read(bytes)
+
+    code := make([]byte, joinCodeLength)
+    for i, b := range bytes {
+        code[i] = joinCodeAlphabet[int(b)%len(joinCodeAlphabet)]
+    }
+    return string(code)
+}
@@ -0,0 +1,277 @@
+package services
+
+import (
+    "bytes"
+    "context"
+    "errors"
+    "fmt"
+    "log"
+    "net/http"
+    "os"
+    "os/exec"
+    "path/filepath"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+
+    "paydeya-backend/internal/models"
+)
+
+// CodeSandboxCommand - аргумент, с которым сервер перезапускает сам себя процессом песочницы
+const CodeSandboxCommand = "code-sandbox"
+
+type CodeRunnerConfig struct {
+    Workers     int           // одновременных запусков; остальные ждут в очереди
+    Timeout     time.Duration // время работы одного запуска
+    MemoryMB    int           // память процесса решения
+    OutputLimit int           // байт stdout и stderr, остальное отбрасывается
+    Python      string        // интерпретаторы; пустая строка - язык недоступен
+    Node        string
+    Java        string
+    UID         int      // пользователь (и группа), под которым выполняется код, если сервер запущен от root
+    RootPaths   []string // каталоги и файлы сервера, видимые коду только для чтения (интерпретаторы и их библиотеки)
+    URL         string   // адрес сервиса запуска кода (CodeRunnerCommand); пустой - код запускается в этом процессе
+    Token       string   // общий секрет API и сервиса запуска кода
+}
+
+// codeLanguage - как запускать решение на языке: файл с кодом и команда интерпретатора.
+// Компилируемым языкам (Java) нужно больше памяти и времени на компиляцию
+type codeLanguage struct {
+    file       string
+    args       []string
+    memoryMult int
+    timeMult   int
+}
+
+// CodeRunner запускает код учеников в песочнице: отдельный процесс без сети и без capabilities,
+// в своем пространстве имен пользователей и остальных пространствах имен, с минимальным корнем
+// только для чтения, фильтром seccomp, лимитами ресурсов и временем работы. Привилегии серверу
+// не нужны. Число одновременных запусков ограничено. С config.URL запуски передаются отдельному
+// сервису запуска кода, и интерпретаторы на сервере API не нужны
+type CodeRunner struct {
+    config      CodeRunnerConfig
+    executable  string
+    languages   map[string]codeLanguage
+    slots       chan struct{}
+    unavailable error
+    hostUID     int // пользователь и группа, на которых снаружи отображается пользователь песочницы
+    hostGID     int
+    client      *http.Client // запросы к сервису запуска кода, если задан config.URL
+}
+
+func NewCodeRunner(config CodeRunnerConfig) *CodeRunner {
+    if config.Workers <= 0 {
+        config.Workers = 2
+    }
+    if config.Timeout <= 0 {
+        config.Timeout = 5 * time.Second
+    }
+    if config.MemoryMB <= 0 {
+        config.MemoryMB = 256
+    }
+    if config.OutputLimit <= 0 {
+        config.OutputLimit = 64 << 10
+    }
+    if len(config.RootPaths) == 0 {
+        config.RootPaths = []string{"/usr", "/lib", "/lib64", "/bin", "/etc/alternatives", "/etc/ld.so.cache"}
+    }
+
+    r := &CodeRunner{
+        config:    config,
+        languages: make(map[string]codeLanguage),
+        slots:     make(chan struct{}, config.Workers),
+    }
+
+    // Песочница и интерпретаторы - в отдельном сервисе, здесь только его клиент
+    if config.URL != "" {
+        r.client = &http.Client{}
+        return r
+    }
+
+    // Снаружи песочницы код работает от пользователя сервера. Сервер, запущенный от root,
+    // отображает его на отдельного пользователя config.UID, а не на root
+    r.hostUID, r.hostGID = os.Geteuid(), os.Getegid()
+    executable, err := os.Executable()
+    if sandboxErr := codeSandboxCheck(); sandboxErr != nil {
+        err = sandboxErr
+    }
+    if r.hostUID == 0 {
+        if config.UID <= 0 {
+            err = fmt.Errorf("code runner uid must be a dedicated unprivileged user when the server runs as root")
+        }
+        r.hostUID, r.hostGID = config.UID, config.UID
+    }
+    switch {
+    case err != nil:
+        log.Printf("⚠️ Code runner disabled: %v", err)
+        r.unavailable = fmt.Errorf("code execution is not available")
+    default:
+        r.executable = executable
+    }
+
+    for name, language := range map[string]struct {
+        command string
+        lang    codeLanguage
+    }{
+        "python":     {config.Python, codeLanguage{file: "main.py", args: []string{"-I", "-B", "main.py"}, memoryMult: 1, timeMult: 1}},
+        "javascript": {config.Node, codeLanguage{file: "main.js", args: []string{"--max-old-space-size=" + strconv.Itoa(config.MemoryMB/2), "--stack-size=2048", "main.js"}, memoryMult: 2, timeMult: 1}},
+        "java":       {config.Java, codeLanguage{file: "Main.java", args: []string{"-Xmx" + strconv.Itoa(config.MemoryMB/2) + "m", "-Xss16m", "-XX:+UseSerialGC", "-XX:TieredStopAtLevel=1", "-XX:-UsePerfData", "-Dfile.encoding=UTF-8", "Main.java"}, memoryMult: 4, timeMult: 3}},
+    } {
+        if language.command == "" {
+            continue
+        }
+        path, err := exec.LookPath(language.command)
+        if err != nil {
+            continue
+        }
+        language.lang.args = append([]string{path}, language.lang.args...)
+        r.languages[name] = language.lang
+    }
+
+    return r
+}
+
+// codeLanguageNames - языки, для которых можно создавать блоки с кодом, независимо от того,
+// установлен ли интерпретатор на этом сервере
+var codeLanguageNames = map[string]bool{"python": true, "javascript": true, "java": true}
+
+// Languages возвращает языки, которые можно запускать (здесь или в сервисе запуска кода)
+func (r *CodeRunner) Languages() []string {
+    if r.client != nil {
+        return r.remoteLanguages()
+    }
+    if r.unavailable != nil {
+        return nil
+    }
+    names := make([]string, 0, len(r.languages))
+    for name := range r.languages {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    return names
+}
+
+// Run выполняет программу с вводом input. Ошибка возвращается только если запустить
+// программу не удалось; ошибки самой программы - в ExitCode и Stderr результата
+func (r *CodeRunner) Run(ctx context.Context, language, code, input string) (*models.CodeRunResult, error) {
+    if r.client != nil {
+        return r.runRemote(ctx, language, code, input)
+    }
+    if r.unavailable != nil {
+        return nil, r.unavailable
+    }
+    lang, ok := r.languages[language]
+    if !ok {
+        return nil, fmt.Errorf("language is not available")
+    }
+
+    // Ждем свободного исполнителя, но не дольше одного запуска с запасом
+    wait, cancel := context.WithTimeout(ctx, 2*r.config.Timeout*time.Duration(lang.timeMult))
+    defer cancel()
+    select {
+    case r.slots <- struct{}{}:
+        defer func() { <-r.slots }()
+    case <-wait.Done():
+        return nil, fmt.Errorf("code runner is busy")
+    }
+
+    dir, err := os.MkdirTemp("", "paydeya-code-")
+    if err != nil {
+        return nil, err
+    }
+    defer os.RemoveAll(dir)
+
+    // Каталог доступен только серверу и пользователю песочницы: песочница собирает в нем
+    // свой корень и копирует код в /work
+    source := filepath.Join(dir, lang.file)
+    if err := os.WriteFile(source, []byte(code), 0o600); err != nil {
+        return nil, err
+    }
+    if r.hostUID != os.Geteuid() {
+        for _, path := range []string{dir, source} {
+            if err := os.Chown(path, r.hostUID, r.hostGID); err != nil {
+                return nil, err
+            }
+        }
+    }
+
+    timeout := r.config.Timeout * time.Duration(lang.timeMult)
+    runCtx, stop := context.WithTimeout(ctx, timeout)
+    defer stop()
+
+    cpuSeconds := int(timeout/time.Second) + 1
+    memory := int64(r.config.MemoryMB*lang.memoryMult) << 20
+    args := append([]string{
+        CodeSandboxCommand, strconv.Itoa(cpuSeconds), strconv.FormatInt(memory, 10),
+        source, strings.Join(r.config.RootPaths, ","),
+    }, lang.args...)
+
+    cmd := exec.CommandContext(runCtx, r.executable, args...)
+    cmd.Dir = dir
+    // Процесс песочницы - тот же сервер, и инициализация его пакетов может писать в кэш
+    // в домашнем каталоге: до сборки корня это временный каталог запуска. Окружение
+    // интерпретатора песочница задает сама
+    cmd.Env = []string{"HOME=" + dir}
+    cmd.Stdin = strings.NewReader(input)
+    stdout := &limitedBuffer{limit: r.config.OutputLimit}
+    stderr := &limitedBuffer{limit: r.config.OutputLimit}
+    cmd.Stdout, cmd.Stderr = stdout, stderr
+    cmd.SysProcAttr = codeSandboxAttr(r.hostUID, r.hostGID)
+    cmd.WaitDelay = time.Second
+
+    started := time.Now()
+    err = cmd.Run()
+    result := &models.CodeRunResult{
+        Stdout:    stdout.String(),
+        Stderr:    stderr.String(),
+        Truncated: stdout.truncated || stderr.truncated,
+        Duration:  int(time.Since(started) / time.Millisecond),
+        TimedOut:  errors.Is(runCtx.Err(), context.DeadlineExceeded),
+    }
+
+    var exitErr *exec.ExitError
+    if err != nil && !errors.As(err, &exitErr) {
+        log.Printf("⚠️ Code sandbox failed: %v", err)
+        return nil, fmt.Errorf("code execution is not available")
+    }
+    if exitErr != nil {
+        result.ExitCode = exitErr.ExitCode()
+        if result.ExitCode == codeSandboxFailed && strings.HasPrefix(result.Stderr, CodeSandboxCommand+": ") {
+            log.Printf("⚠️ Code sandbox failed to start %s: %s", language, result.Stderr)
+            return nil, fmt.Errorf("code execution is not available")
+        }
+    }
+    if result.TimedOut {
+        result.ExitCode = -1
+    }
+
+    return result, nil
+}
+
+// Код выхода процесса песочницы, если не удалось собрать корень, поставить лимиты
+// или запустить интерпретатор.
+// Сообщение об ошибке в stderr начинается с CodeSandboxCommand
+const codeSandboxFailed = 126
+
+// limitedBuffer хранит только первые limit байт вывода, не блокируя пишущий процесс
+type limitedBuffer struct {
+    buf       bytes.Buffer
+    limit     int
+    truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+    if room := b.limit - b.buf.Len(); room < len(p) {
+        b.truncated = true
+        if room > 0 {
+            b.buf.Write(p[:room])
+        }
+        return len(p), nil
+    }
+    return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) String() string {
+    return strings.ToValidUTF8(b.buf.String(), "�")
+}
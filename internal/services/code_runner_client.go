@@ -0,0 +1,99 @@
+package services
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "io"
+    "log"
+    "net/http"
+    "strings"
+    "time"
+
+    "paydeya-backend/internal/models"
+)
+
+// CodeRunnerCommand - аргумент, с которым сервер запускается отдельным сервисом запуска кода.
+// Интерпретаторы и песочница нужны только этому сервису, API передает ему запуски по CODE_RUNNER_URL
+const CodeRunnerCommand = "code-runner"
+
+// Ответ сервиса запуска кода больше вывода программы из-за экранирования JSON
+const codeRunnerResponseLimit = 4 << 20
+
+// runRemote передает запуск сервису запуска кода. Ошибки, которые понимает API
+// (исполнители заняты, язык недоступен), возвращаются как есть, остальные - как недоступность
+func (r *CodeRunner) runRemote(ctx context.Context, language, code, input string) (*models.CodeRunResult, error) {
+    body, err := json.Marshal(models.CodeRunnerRequest{Language: language, Code: code, Input: input})
+    if err != nil {
+        return nil, err
+    }
+
+    resp, err := r.remote(ctx, http.MethodPost, "/run", body)
+    if err != nil {
+        if ctx.Err() == nil {
+            log.Printf("⚠️ Code runner service failed: %v", err)
+        }
+        return nil, fmt.Errorf("code execution is not available")
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode != http.StatusOK {
+        var failure struct {
+            Error string `json:"error"`
+        }
+        json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&failure)
+        switch failure.Error {
+        case "code runner is busy", "language is not available", "code execution is not available":
+            return nil, fmt.Errorf("%s", failure.Error)
+        }
+        log.Printf("⚠️ Code runner service returned %d: %s", resp.StatusCode, failure.Error)
+        return nil, fmt.Errorf("code execution is not available")
+    }
+
+    var result models.CodeRunResult
+    if err := json.NewDecoder(io.LimitReader(resp.Body, codeRunnerResponseLimit)).Decode(&result); err != nil {
+        log.Printf("⚠️ Code runner service returned invalid result: %v", err)
+        return nil, fmt.Errorf("code execution is not available")
+    }
+    return &result, nil
+}
+
+// remoteLanguages спрашивает у сервиса запуска кода доступные языки. Недоступный сервис - языков нет
+func (r *CodeRunner) remoteLanguages() []string {
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+
+    resp, err := r.remote(ctx, http.MethodGet, "/languages", nil)
+    if err != nil {
+        log.Printf("⚠️ Code runner service is not available: %v", err)
+        return nil
+    }
+    defer resp.Body.Close()
+
+    var languages struct {
+        Languages []string `json:"languages"`
+    }
+    if resp.StatusCode != http.StatusOK {
+        log.Printf("⚠️ Code runner service returned %d for languages", resp.StatusCode)
+        return nil
+    }
+    if err := json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&languages); err != nil {
+        log.Printf("⚠️ Code runner service returned invalid languages: %v", err)
+        return nil
+    }
+    return languages.Languages
+}
+
+// remote выполняет запрос к сервису запуска кода с общим токеном
+func (r *CodeRunner) remote(ctx context.Context, method, path string, body []byte) (*http.Response, error) {
+    req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(r.config.URL, "/")+path, bytes.NewReader(body))
+    if err != nil {
+        return nil, err
+    }
+    if body != nil {
+        req.Header.Set("Content-Type", "application/json")
+    }
+    req.Header.Set("Authorization", "Bearer "+r.config.Token)
+    return r.client.Do(req)
+}
@@ -0,0 +1,304 @@
+//go:build linux
+
+package services
+
+import (
+    "fmt"
+    "os"
+    "os/exec"
+    "path/filepath"
+    "runtime"
+    "strconv"
+    "strings"
+    "syscall"
+    "unsafe"
+)
+
+// RLIMIT_NPROC и управления capabilities нет в пакете syscall
+const (
+    rlimitNproc             = 6
+    capSysAdmin             = 21
+    prCapAmbient            = 47
+    prCapAmbientClearAll    = 4
+    linuxCapabilityVersion3 = 0x20080522
+    statfsRelatime          = 0x1000
+)
+
+// Пользователь и группа решения внутри пространства имен пользователей песочницы.
+// Снаружи они отображаются на пользователя, от которого работает сервер
+const codeSandboxID = 1000
+
+// Окружение интерпретатора
+var codeSandboxEnv = []string{"PATH=/usr/local/bin:/usr/bin:/bin", "HOME=/work", "LANG=C.UTF-8", "TMPDIR=/tmp"}
+
+// Устройства, доступные решению в /dev
+var codeSandboxDevices = []string{"/dev/null", "/dev/zero", "/dev/random", "/dev/urandom"}
+
+// codeSandboxCheck проверяет, что код можно изолировать без привилегий сервера:
+// нужны фильтр seccomp для архитектуры и непривилегированные пространства имен пользователей
+func codeSandboxCheck() error {
+    if !codeSeccompSupported {
+        return fmt.Errorf("seccomp filter is not available on %s", runtime.GOARCH)
+    }
+    if data, err := os.ReadFile("/proc/sys/user/max_user_namespaces"); err == nil && strings.TrimSpace(string(data)) == "0" {
+        return fmt.Errorf("user namespaces are disabled (user.max_user_namespaces = 0)")
+    }
+    return nil
+}
+
+// codeSandboxAttr - изоляция процесса решения: свое пространство имен пользователей, в котором
+// решение - пользователь codeSandboxID, снаружи отображенный на hostUID:hostGID, и принадлежащие
+// ему пространства имен монтирования, сети (без интерфейсов), процессов, IPC и имени хоста.
+// Привилегий сервера не нужно: CAP_SYS_ADMIN действует только внутри этих пространств имен
+// и нужен процессу песочницы, чтобы собрать корень; перед запуском решения он его сбрасывает.
+// Процесс завершается вместе с сервером
+func codeSandboxAttr(hostUID, hostGID int) *syscall.SysProcAttr {
+    return &syscall.SysProcAttr{
+        Cloneflags:  syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS | syscall.CLONE_NEWNET | syscall.CLONE_NEWPID | syscall.CLONE_NEWIPC | syscall.CLONE_NEWUTS,
+        UidMappings: []syscall.SysProcIDMap{{ContainerID: codeSandboxID, HostID: hostUID, Size: 1}},
+        GidMappings: []syscall.SysProcIDMap{{ContainerID: codeSandboxID, HostID: hostGID, Size: 1}},
+        Credential:  &syscall.Credential{Uid: codeSandboxID, Gid: codeSandboxID, NoSetGroups: true},
+        AmbientCaps: []uintptr{capSysAdmin},
+        Setpgid:     true,
+        Pdeathsig:   syscall.SIGKILL,
+    }
+}
+
+// RunCodeSandbox - процесс песочницы (сервер, запущенный с аргументом CodeSandboxCommand):
+// собирает корень из каталогов интерпретатора (только чтение), пустых /work и /tmp и нового /proc,
+// ставит лимиты ресурсов, сбрасывает capabilities, включает фильтр seccomp
+// и заменяет себя интерпретатором.
+// Аргументы: секунды процессора, байты памяти, файл с кодом, каталоги корня через запятую,
+// команда интерпретатора
+func RunCodeSandbox(args []string) {
+    fail := func(err error) {
+        fmt.Fprintf(os.Stderr, "%s: %v\n", CodeSandboxCommand, err)
+        os.Exit(codeSandboxFailed)
+    }
+    if len(args) < 5 {
+        fail(fmt.Errorf("not enough arguments"))
+    }
+
+    cpuSeconds, err := strconv.ParseUint(args[0], 10, 64)
+    if err != nil {
+        fail(err)
+    }
+    memory, err := strconv.ParseUint(args[1], 10, 64)
+    if err != nil {
+        fail(err)
+    }
+    if err := codeSandboxCheck(); err != nil {
+        fail(err)
+    }
+
+    // Фильтр seccomp и capabilities действуют на поток, который выполнит exec
+    runtime.LockOSThread()
+
+    if err := setupCodeSandboxRoot(args[2], strings.Split(args[3], ",")); err != nil {
+        fail(err)
+    }
+
+    limits := []struct {
+        resource int
+        value    uint64
+    }{
+        {syscall.RLIMIT_CPU, cpuSeconds},
+        {syscall.RLIMIT_DATA, memory},
+        {syscall.RLIMIT_FSIZE, 1 << 20},
+        {syscall.RLIMIT_NOFILE, 64},
+        {syscall.RLIMIT_CORE, 0},
+        {rlimitNproc, 64},
+    }
+    for _, limit := range limits {
+        if err := syscall.Setrlimit(limit.resource, &syscall.Rlimit{Cur: limit.value, Max: limit.value}); err != nil {
+            fail(fmt.Errorf("setrlimit %d: %w", limit.resource, err))
+        }
+    }
+
+    if os.Geteuid() != codeSandboxID || os.Getegid() != codeSandboxID {
+        fail(fmt.Errorf("sandbox is not running as the sandbox user"))
+    }
+    if err := dropCapabilities(); err != nil {
+        fail(err)
+    }
+
+    os.Clearenv()
+    for _, variable := range codeSandboxEnv {
+        name, value, _ := strings.Cut(variable, "=")
+        os.Setenv(name, value)
+    }
+    path, err := exec.LookPath(args[4])
+    if err != nil {
+        fail(err)
+    }
+    if err := installSeccomp(); err != nil {
+        fail(err)
+    }
+    fail(syscall.Exec(path, args[4:], codeSandboxEnv))
+}
+
+// dropCapabilities сбрасывает capabilities потока, включая ambient: решение запускается
+// пользователем codeSandboxID (не root) и при exec их не получит
+func dropCapabilities() error {
+    if _, _, errno := syscall.RawSyscall6(syscall.SYS_PRCTL, prCapAmbient, prCapAmbientClearAll, 0, 0, 0, 0); errno != 0 {
+        return fmt.Errorf("clear ambient capabilities: %w", errno)
+    }
+
+    header := struct {
+        version uint32
+        pid     int32
+    }{version: linuxCapabilityVersion3}
+    var data [2]struct{ effective, permitted, inheritable uint32 }
+    if _, _, errno := syscall.RawSyscall(syscall.SYS_CAPSET, uintptr(unsafe.Pointer(&header)), uintptr(unsafe.Pointer(&data[0])), 0); errno != 0 {
+        return fmt.Errorf("capset: %w", errno)
+    }
+    return nil
+}
+
+// setupCodeSandboxRoot собирает новый корень рядом с файлом кода и переходит в него.
+// Файловая система сервера (конфигурация, .env, /proc сервера) в песочнице не видна
+func setupCodeSandboxRoot(source string, rootPaths []string) error {
+    // Монтирования песочницы не должны попасть в пространство имен сервера
+    if err := syscall.Mount("", "/", "", syscall.MS_REC|syscall.MS_PRIVATE, ""); err != nil {
+        return fmt.Errorf("make mounts private: %w", err)
+    }
+
+    root := filepath.Join(filepath.Dir(source), "root")
+    if err := os.Mkdir(root, 0o755); err != nil {
+        return err
+    }
+    if err := syscall.Mount("tmpfs", root, "tmpfs", syscall.MS_NOSUID|syscall.MS_NODEV, "size=1m,mode=755"); err != nil {
+        return fmt.Errorf("mount root: %w", err)
+    }
+
+    for _, path := range rootPaths {
+        path = filepath.Clean(strings.TrimSpace(path))
+        if !filepath.IsAbs(path) || path == "/" {
+            continue
+        }
+        if err := bindReadOnly(path, filepath.Join(root, path)); err != nil {
+            return err
+        }
+    }
+
+    for _, device := range codeSandboxDevices {
+        if err := bindPath(device, filepath.Join(root, device), syscall.MS_NOSUID|syscall.MS_NOEXEC); err != nil {
+            return err
+        }
+    }
+
+    mounts := []struct {
+        target string
+        fstype string
+        flags  uintptr
+        data   string
+    }{
+        {"proc", "proc", syscall.MS_NOSUID | syscall.MS_NODEV | syscall.MS_NOEXEC, ""},
+        {"work", "tmpfs", syscall.MS_NOSUID | syscall.MS_NODEV, fmt.Sprintf("size=16m,mode=700,uid=%d,gid=%d", codeSandboxID, codeSandboxID)},
+        {"tmp", "tmpfs", syscall.MS_NOSUID | syscall.MS_NODEV, "size=16m,mode=1777"},
+    }
+    for _, m := range mounts {
+        target := filepath.Join(root, m.target)
+        if err := os.MkdirAll(target, 0o755); err != nil {
+            return err
+        }
+        if err := syscall.Mount(m.fstype, target, m.fstype, m.flags, m.data); err != nil {
+            return fmt.Errorf("mount /%s: %w", m.target, err)
+        }
+    }
+
+    code, err := os.ReadFile(source)
+    if err != nil {
+        return err
+    }
+    file := filepath.Join(root, "work", filepath.Base(source))
+    if err := os.WriteFile(file, code, 0o644); err != nil {
+        return err
+    }
+
+    // Старый корень отмонтируется целиком, в песочнице остается только собранное дерево
+    if err := os.Mkdir(filepath.Join(root, ".old"), 0o700); err != nil {
+        return err
+    }
+    if err := syscall.PivotRoot(root, filepath.Join(root, ".old")); err != nil {
+        return fmt.Errorf("pivot_root: %w", err)
+    }
+    if err := syscall.Chdir("/"); err != nil {
+        return err
+    }
+    if err := syscall.Unmount("/.old", syscall.MNT_DETACH); err != nil {
+        return fmt.Errorf("unmount old root: %w", err)
+    }
+    if err := os.Remove("/.old"); err != nil {
+        return err
+    }
+    if err := syscall.Mount("", "/", "", syscall.MS_REMOUNT|syscall.MS_RDONLY|syscall.MS_NOSUID|syscall.MS_NODEV, ""); err != nil {
+        return fmt.Errorf("remount root read-only: %w", err)
+    }
+
+    return syscall.Chdir("/work")
+}
+
+// bindReadOnly переносит путь в корень песочницы только для чтения.
+// Символические ссылки (/bin -> usr/bin) воссоздаются, отсутствующие пути пропускаются
+func bindReadOnly(source, target string) error {
+    info, err := os.Lstat(source)
+    if os.IsNotExist(err) {
+        return nil
+    }
+    if err != nil {
+        return err
+    }
+
+    if info.Mode()&os.ModeSymlink != 0 {
+        link, err := os.Readlink(source)
+        if err != nil {
+            return err
+        }
+        if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+            return err
+        }
+        return os.Symlink(link, target)
+    }
+
+    return bindPath(source, target, syscall.MS_RDONLY|syscall.MS_NOSUID|syscall.MS_NODEV)
+}
+
+// bindPath монтирует файл или каталог в target и перемонтирует его с флагами flags.
+// Ограничения исходного монтирования (только чтение, nosuid, noexec, atime) в пространстве имен
+// пользователей сняты быть не могут - они сохраняются, иначе перемонтирование запрещено
+func bindPath(source, target string, flags uintptr) error {
+    info, err := os.Stat(source)
+    if err != nil {
+        return err
+    }
+
+    if info.IsDir() {
+        if err := os.MkdirAll(target, 0o755); err != nil {
+            return err
+        }
+    } else {
+        if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+            return err
+        }
+        if err := os.WriteFile(target, nil, 0o644); err != nil {
+            return err
+        }
+    }
+
+    if err := syscall.Mount(source, target, "", syscall.MS_BIND|syscall.MS_REC, ""); err != nil {
+        return fmt.Errorf("bind %s: %w", source, err)
+    }
+    var stat syscall.Statfs_t
+    if err := syscall.Statfs(target, &stat); err != nil {
+        return fmt.Errorf("statfs %s: %w", source, err)
+    }
+    flags |= uintptr(stat.Flags) & (syscall.MS_RDONLY | syscall.MS_NOSUID | syscall.MS_NODEV | syscall.MS_NOEXEC | syscall.MS_NOATIME | syscall.MS_NODIRATIME)
+    if stat.Flags&statfsRelatime != 0 {
+        flags |= syscall.MS_RELATIME
+    }
+    if err := syscall.Mount("", target, "", syscall.MS_BIND|syscall.MS_REMOUNT|flags, ""); err != nil {
+        return fmt.Errorf("remount %s: %w", source, err)
+    }
+    return nil
+}
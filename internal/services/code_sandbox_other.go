@@ -0,0 +1,24 @@
+//go:build !linux
+
+package services
+
+import (
+    "fmt"
+    "os"
+    "syscall"
+)
+
+// Изоляция процессов решения (пространства имен, корень, seccomp) есть только в Linux,
+// на других системах запуск кода отключен
+func codeSandboxCheck() error {
+    return fmt.Errorf("code sandbox is not supported on this system")
+}
+
+func codeSandboxAttr(hostUID, hostGID int) *syscall.SysProcAttr {
+    return nil
+}
+
+func RunCodeSandbox(args []string) {
+    fmt.Fprintf(os.Stderr, "%s: not supported on this system\n", CodeSandboxCommand)
+    os.Exit(codeSandboxFailed)
+}
@@ -0,0 +1,100 @@
+//go:build linux
+
+package services
+
+import (
+    "fmt"
+    "runtime"
+    "syscall"
+    "unsafe"
+)
+
+// Фильтр seccomp для процесса решения. Запрещает вызовы, которые нужны только для выхода
+// из песочницы или влияния на систему: монтирование, новые пространства имен, отладку других
+// процессов, загрузку модулей и eBPF. Остальное разрешено - интерпретаторам нужно много вызовов.
+// Номера вызовов зависят от архитектуры (code_seccomp_linux_<arch>.go)
+
+const (
+    seccompRetKillProcess = 0x80000000
+    seccompRetErrno       = 0x00050000
+    seccompRetAllow       = 0x7fff0000
+
+    prSetNoNewPrivs = 38
+    prSetSeccomp    = 22
+    seccompModeBPF  = 2
+
+    // Смещения полей struct seccomp_data
+    seccompDataNr   = 0
+    seccompDataArch = 4
+    seccompDataArg0 = 16
+
+    // Флаги clone, создающие пространства имен (включая CLONE_NEWUSER и CLONE_NEWNS)
+    cloneNamespaceFlags = syscall.CLONE_NEWNS | syscall.CLONE_NEWUTS | syscall.CLONE_NEWIPC |
+        syscall.CLONE_NEWUSER | syscall.CLONE_NEWPID | syscall.CLONE_NEWNET | 0x02000000 // CLONE_NEWCGROUP
+)
+
+func bpfStmt(code uint16, k uint32) syscall.SockFilter {
+    return syscall.SockFilter{Code: code, K: k}
+}
+
+func bpfJump(code uint16, k uint32, jt, jf uint8) syscall.SockFilter {
+    return syscall.SockFilter{Code: code, Jt: jt, Jf: jf, K: k}
+}
+
+// seccompFilter собирает программу BPF: чужая архитектура (и x32 на amd64) - завершение
+// процесса, запрещенные вызовы - EPERM, clone3 и io_uring - ENOSYS (интерпретаторы
+// переходят на clone и обычный ввод-вывод), clone с флагами пространств имен - EPERM
+func seccompFilter() []syscall.SockFilter {
+    filter := []syscall.SockFilter{
+        bpfStmt(syscall.BPF_LD|syscall.BPF_W|syscall.BPF_ABS, seccompDataArch),
+        bpfJump(syscall.BPF_JMP|syscall.BPF_JEQ|syscall.BPF_K, seccompAuditArch, 1, 0),
+        bpfStmt(syscall.BPF_RET|syscall.BPF_K, seccompRetKillProcess),
+        bpfStmt(syscall.BPF_LD|syscall.BPF_W|syscall.BPF_ABS, seccompDataNr),
+    }
+    if seccompSyscallLimit > 0 {
+        filter = append(filter,
+            bpfJump(syscall.BPF_JMP|syscall.BPF_JGE|syscall.BPF_K, seccompSyscallLimit, 0, 1),
+            bpfStmt(syscall.BPF_RET|syscall.BPF_K, seccompRetKillProcess),
+        )
+    }
+
+    deny := func(nr uint32, errno syscall.Errno) {
+        filter = append(filter,
+            bpfJump(syscall.BPF_JMP|syscall.BPF_JEQ|syscall.BPF_K, nr, 0, 1),
+            bpfStmt(syscall.BPF_RET|syscall.BPF_K, seccompRetErrno|uint32(errno)),
+        )
+    }
+    for _, nr := range seccompDenied {
+        deny(nr, syscall.EPERM)
+    }
+    for _, nr := range seccompUnsupported {
+        deny(nr, syscall.ENOSYS)
+    }
+
+    return append(filter,
+        bpfJump(syscall.BPF_JMP|syscall.BPF_JEQ|syscall.BPF_K, seccompClone, 0, 3),
+        bpfStmt(syscall.BPF_LD|syscall.BPF_W|syscall.BPF_ABS, seccompDataArg0),
+        bpfJump(syscall.BPF_JMP|syscall.BPF_JSET|syscall.BPF_K, cloneNamespaceFlags, 0, 1),
+        bpfStmt(syscall.BPF_RET|syscall.BPF_K, seccompRetErrno|uint32(syscall.EPERM)),
+        bpfStmt(syscall.BPF_RET|syscall.BPF_K, seccompRetAllow),
+    )
+}
+
+// installSeccomp включает no_new_privs и фильтр для текущего потока. Поток должен быть
+// закреплен (runtime.LockOSThread) до exec интерпретатора: фильтр наследует только он
+func installSeccomp() error {
+    if !codeSeccompSupported {
+        return fmt.Errorf("seccomp filter is not available on %s", runtime.GOARCH)
+    }
+
+    if _, _, errno := syscall.RawSyscall6(syscall.SYS_PRCTL, prSetNoNewPrivs, 1, 0, 0, 0, 0); errno != 0 {
+        return fmt.Errorf("no_new_privs: %w", errno)
+    }
+
+    filter := seccompFilter()
+    program := syscall.SockFprog{Len: uint16(len(filter)), Filter: &filter[0]}
+    if _, _, errno := syscall.RawSyscall6(syscall.SYS_PRCTL, prSetSeccomp, seccompModeBPF, uintptr(unsafe.Pointer(&program)), 0, 0, 0); errno != 0 {
+        return fmt.Errorf("seccomp: %w", errno)
+    }
+    return nil
+}
@@ -0,0 +1,56 @@
+//go:build linux
+
+package services
+
+const codeSeccompSupported = true
+
+// AUDIT_ARCH_X86_64
+const seccompAuditArch = 0xc000003e
+
+// Вызовы x32 ABI (номер с битом __X32_SYSCALL_BIT) запрещены целиком
+const seccompSyscallLimit = 0x40000000
+
+const seccompClone = 56
+
+var seccompDenied = []uint32{
+    101,      // ptrace
+    103,      // syslog
+    134,      // uselib
+    135,      // personality
+    153,      // vhangup
+    155,      // pivot_root
+    159,      // adjtimex
+    161,      // chroot
+    163,      // acct
+    164,      // settimeofday
+    165, 166, // mount, umount2
+    167, 168, // swapon, swapoff
+    169,      // reboot
+    172, 173, // iopl, ioperm
+    175, 176, // init_module, delete_module
+    179,           // quotactl
+    212,           // lookup_dcookie
+    227,           // clock_settime
+    246,           // kexec_load
+    248, 249, 250, // add_key, request_key, keyctl
+    272,      // unshare
+    298,      // perf_event_open
+    303, 304, // name_to_handle_at, open_by_handle_at
+    305,      // clock_adjtime
+    308,      // setns
+    310, 311, // process_vm_readv, process_vm_writev
+    313,      // finit_module
+    320,      // kexec_file_load
+    321,      // bpf
+    323,      // userfaultfd
+    428, 429, // open_tree, move_mount
+    430, 431, 432, // fsopen, fsconfig, fsmount
+    433, // fspick
+    438, // pidfd_getfd
+    442, // mount_setattr
+}
+
+var seccompUnsupported = []uint32{
+    425, 426, 427, // io_uring_setup, io_uring_enter, io_uring_register
+    435, // clone3
+}
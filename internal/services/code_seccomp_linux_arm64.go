@@ -0,0 +1,53 @@
+//go:build linux
+
+package services
+
+const codeSeccompSupported = true
+
+// AUDIT_ARCH_AARCH64
+const seccompAuditArch = 0xc00000b7
+
+const seccompSyscallLimit = 0
+
+const seccompClone = 220
+
+var seccompDenied = []uint32{
+    18,     // lookup_dcookie
+    39, 40, // umount2, mount
+    41,       // pivot_root
+    51,       // chroot
+    58,       // vhangup
+    60,       // quotactl
+    89,       // acct
+    92,       // personality
+    97,       // unshare
+    104,      // kexec_load
+    105, 106, // init_module, delete_module
+    112,           // clock_settime
+    116,           // syslog
+    117,           // ptrace
+    142,           // reboot
+    170,           // settimeofday
+    171,           // adjtimex
+    217, 218, 219, // add_key, request_key, keyctl
+    224, 225, // swapon, swapoff
+    241,      // perf_event_open
+    264, 265, // name_to_handle_at, open_by_handle_at
+    266,      // clock_adjtime
+    268,      // setns
+    270, 271, // process_vm_readv, process_vm_writev
+    273,      // finit_module
+    280,      // bpf
+    282,      // userfaultfd
+    294,      // kexec_file_load
+    428, 429, // open_tree, move_mount
+    430, 431, 432, // fsopen, fsconfig, fsmount
+    433, // fspick
+    438, // pidfd_getfd
+    442, // mount_setattr
+}
+
+var seccompUnsupported = []uint32{
+    425, 426, 427, // io_uring_setup, io_uring_enter, io_uring_register
+    435, // clone3
+}
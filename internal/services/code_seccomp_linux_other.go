@@ -0,0 +1,14 @@
+//go:build linux && !amd64 && !arm64
+
+package services
+
+// Для остальных архитектур таблицы номеров вызовов нет: запуск кода отключен
+const codeSeccompSupported = false
+
+const (
+    seccompAuditArch    = 0
+    seccompSyscallLimit = 0
+    seccompClone        = 0
+)
+
+var seccompDenied, seccompUnsupported []uint32
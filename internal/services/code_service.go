@@ -0,0 +1,344 @@
+package services
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/repositories"
+)
+
+const (
+    maxCodeTests      = 30
+    maxCodeTestLength = 64 << 10
+    maxCodeLength     = 64 << 10
+)
+
+// CodeLimitsConfig - ограничения запусков кода для одного пользователя
+type CodeLimitsConfig struct {
+    RunsPerMinute     int           // запусков и проверок решения в минуту (0 - без ограничения)
+    SubmissionTimeout time.Duration // общее время проверки решения всеми тестами
+}
+
+type CodeService struct {
+    codeRepo        *repositories.CodeRepository
+    blockRepo       *repositories.BlockRepository
+    materialService *MaterialService
+    xapiService     *XAPIService
+    runner          *CodeRunner
+    limits          CodeLimitsConfig
+    throttle        *codeThrottle
+}
+
+func NewCodeService(codeRepo *repositories.CodeRepository, blockRepo *repositories.BlockRepository, materialService *MaterialService, xapiService *XAPIService, runner *CodeRunner, limits CodeLimitsConfig) *CodeService {
+    return &CodeService{
+        codeRepo:        codeRepo,
+        blockRepo:       blockRepo,
+        materialService: materialService,
+        xapiService:     xapiService,
+        runner:          runner,
+        limits:          limits,
+        throttle:        newCodeThrottle(limits.RunsPerMinute),
+    }
+}
+
+// RunCode запускает код в блоке материала с произвольным вводом, без проверки тестами
+func (s *CodeService) RunCode(ctx context.Context, userID, materialID int, blockID string, req *models.RunCodeRequest) (*models.CodeRunResult, error) {
+    block, err := s.codeBlock(ctx, userID, materialID, blockID)
+    if err != nil {
+        return nil, err
+    }
+
+    release, err := s.throttle.acquire(userID)
+    if err != nil {
+        return nil, err
+    }
+    defer release()
+
+    language, _ := block.Content["language"].(string)
+    return s.runner.Run(ctx, language, req.Code, req.Input)
+}
+
+// SubmitCode проверяет решение всеми тестами блока, включая скрытые, и сохраняет результат.
+// Упражнение засчитывается, если пройдены все тесты. Тесты, на которые не хватило
+// общего времени проверки, считаются не пройденными по таймауту
+func (s *CodeService) SubmitCode(ctx context.Context, userID, materialID int, blockID string, req *models.SubmitCodeRequest) (*models.CodeSubmission, error) {
+    block, err := s.codeBlock(ctx, userID, materialID, blockID)
+    if err != nil {
+        return nil, err
+    }
+
+    tests, err := codeTests(block.Content)
+    if err != nil {
+        return nil, err
+    }
+    if len(tests) == 0 {
+        return nil, fmt.Errorf("block has no tests")
+    }
+
+    language, _ := block.Content["language"].(string)
+    submission := &models.CodeSubmission{
+        MaterialID: materialID,
+        BlockID:    blockID,
+        Language:   language,
+        Code:       req.Code,
+        TestsTotal: len(tests),
+        Results:    make([]models.CodeTestResult, 0, len(tests)),
+    }
+
+    release, err := s.throttle.acquire(userID)
+    if err != nil {
+        return nil, err
+    }
+    defer release()
+
+    submitCtx, cancel := context.WithTimeout(ctx, s.limits.SubmissionTimeout)
+    defer cancel()
+    // Время проверки вышло, а запрос ученика еще активен
+    expired := func() bool { return submitCtx.Err() != nil && ctx.Err() == nil }
+
+    for _, test := range tests {
+        run := &models.CodeRunResult{ExitCode: -1, TimedOut: true}
+        if !expired() {
+            run, err = s.runner.Run(submitCtx, language, req.Code, test.Input)
+            if err != nil && !expired() {
+                return nil, err
+            }
+            if err != nil {
+                run = &models.CodeRunResult{ExitCode: -1, TimedOut: true}
+            }
+        }
+
+        result := models.CodeTestResult{
+            Name:     test.Name,
+            Hidden:   test.Hidden,
+            Passed:   run.ExitCode == 0 && !run.TimedOut && sameCodeOutput(run.Stdout, test.ExpectedOutput),
+            TimedOut: run.TimedOut,
+        }
+        // Для скрытых тестов ученик узнает только итог
+        if !test.Hidden {
+            result.Input, result.ExpectedOutput, result.ActualOutput = test.Input, test.ExpectedOutput, run.Stdout
+            result.Stderr = run.Stderr
+        }
+        if result.Passed {
+            submission.TestsPassed++
+        }
+        submission.Results = append(submission.Results, result)
+    }
+    submission.Passed = submission.TestsPassed == submission.TestsTotal
+
+    if err := s.codeRepo.SaveSubmission(ctx, userID, submission); err != nil {
+        return nil, err
+    }
+
+    s.xapiService.QuestionsAnswered(ctx, userID, materialID, []models.QuizResult{{BlockID: blockID, Correct: submission.Passed}})
+    return submission, nil
+}
+
+// codeThrottle ограничивает запуски кода одного пользователя: не больше одного одновременно
+// и не больше perMinute за минуту. Состояние хранится в памяти инстанса
+type codeThrottle struct {
+    perMinute int
+
+    mu      sync.Mutex
+    running map[int]bool
+    recent  map[int][]time.Time // начала запусков за последнюю минуту
+    swept   time.Time
+}
+
+func newCodeThrottle(perMinute int) *codeThrottle {
+    return &codeThrottle{
+        perMinute: perMinute,
+        running:   make(map[int]bool),
+        recent:    make(map[int][]time.Time),
+    }
+}
+
+// acquire занимает запуск для пользователя. release нужно вызвать по его завершении
+func (t *codeThrottle) acquire(userID int) (func(), error) {
+    t.mu.Lock()
+    defer t.mu.Unlock()
+
+    if t.running[userID] {
+        return nil, fmt.Errorf("code is already running")
+    }
+
+    now := time.Now()
+    since := now.Add(-time.Minute)
+    // Раз в минуту забываем пользователей, которые больше не запускают код
+    if now.Sub(t.swept) > time.Minute {
+        for id, runs := range t.recent {
+            if runs = runsSince(runs, since); len(runs) == 0 {
+                delete(t.recent, id)
+            } else {
+                t.recent[id] = runs
+            }
+        }
+        t.swept = now
+    }
+
+    runs := runsSince(t.recent[userID], since)
+    if t.perMinute > 0 && len(runs) >= t.perMinute {
+        t.recent[userID] = runs
+        return nil, fmt.Errorf("too many code runs")
+    }
+    t.recent[userID] = append(runs, now)
+    t.running[userID] = true
+
+    return func() {
+        t.mu.Lock()
+        delete(t.running, userID)
+        t.mu.Unlock()
+    }, nil
+}
+
+// runsSince оставляет запуски, начатые после since (список упорядочен по времени)
+func runsSince(runs []time.Time, since time.Time) []time.Time {
+    for i, started := range runs {
+        if started.After(since) {
+            return runs[i:]
+        }
+    }
+    return nil
+}
+
+// GetLastSubmission возвращает последнее решение ученика в блоке
+func (s *CodeService) GetLastSubmission(ctx context.Context, userID, materialID int, blockID string) (*models.CodeSubmission, error) {
+    if _, err := s.codeBlock(ctx, userID, materialID, blockID); err != nil {
+        return nil, err
+    }
+
+    submission, err := s.codeRepo.GetLastSubmission(ctx, userID, materialID, blockID)
+    if err != nil {
+        return nil, err
+    }
+    if submission == nil {
+        return nil, fmt.Errorf("submission not found")
+    }
+    return submission, nil
+}
+
+// Languages возвращает языки, которые можно запускать на сервере
+func (s *CodeService) Languages() []string {
+    return s.runner.Languages()
+}
+
+// codeBlock возвращает блок с кодом со всеми тестами, если пользователь может открыть материал
+func (s *CodeService) codeBlock(ctx context.Context, userID, materialID int, blockID string) (*models.Block, error) {
+    material, err := s.materialService.GetMaterial(ctx, userID, materialID)
+    if err != nil {
+        return nil, err
+    }
+    if material == nil {
+        return nil, fmt.Errorf("material not found")
+    }
+
+    // В материале для ученика скрытых тестов нет, поэтому блок читается заново
+    blocks, err := s.blockRepo.GetBlocks(ctx, materialID)
+    if err != nil {
+        return nil, err
+    }
+    for i := range blocks {
+        if blocks[i].ID == blockID && blocks[i].Type == "code" {
+            return &blocks[i], nil
+        }
+    }
+    return nil, fmt.Errorf("block not found")
+}
+
+// normalizeCodeBlock проверяет блок с кодом и оставляет в содержимом только известные поля:
+// language, starterCode, instructions и tests
+func normalizeCodeBlock(block *models.Block) error {
+    language, _ := block.Content["language"].(string)
+    language = strings.ToLower(strings.TrimSpace(language))
+    if !codeLanguageNames[language] {
+        return fmt.Errorf("unsupported code language")
+    }
+
+    starterCode, _ := block.Content["starterCode"].(string)
+    instructions, _ := block.Content["instructions"].(string)
+    if len(starterCode) > maxCodeLength || len(instructions) > maxCodeLength {
+        return fmt.Errorf("invalid code block")
+    }
+
+    tests, err := codeTests(block.Content)
+    if err != nil || len(tests) > maxCodeTests {
+        return fmt.Errorf("invalid code block")
+    }
+    for i := range tests {
+        test := &tests[i]
+        if len(test.Input) > maxCodeTestLength || len(test.ExpectedOutput) > maxCodeTestLength {
+            return fmt.Errorf("invalid code block")
+        }
+        if test.Name = strings.TrimSpace(test.Name); test.Name == "" {
+            test.Name = "Тест " + strconv.Itoa(i+1)
+        }
+    }
+
+    block.Content = map[string]interface{}{
+        "language":     language,
+        "starterCode":  starterCode,
+        "instructions": instructions,
+        "tests":        tests,
+    }
+    return nil
+}
+
+// codeTests читает тесты блока: структуры после сохранения или JSON из базы и запросов
+func codeTests(content map[string]interface{}) ([]models.CodeTest, error) {
+    switch raw := content["tests"].(type) {
+    case nil:
+        return []models.CodeTest{}, nil
+    case []models.CodeTest:
+        return raw, nil
+    default:
+        data, err := json.Marshal(raw)
+        if err != nil {
+            return nil, err
+        }
+        var tests []models.CodeTest
+        if err := json.Unmarshal(data, &tests); err != nil {
+            return nil, err
+        }
+        return tests, nil
+    }
+}
+
+// hideCodeTests убирает скрытые тесты из блоков с кодом: их видят только автор и соавторы
+func hideCodeTests(blocks []models.Block) {
+    for i := range blocks {
+        if blocks[i].Type != "code" {
+            continue
+        }
+        tests, err := codeTests(blocks[i].Content)
+        if err != nil {
+            delete(blocks[i].Content, "tests")
+            continue
+        }
+        visible := make([]models.CodeTest, 0, len(tests))
+        for _, test := range tests {
+            if !test.Hidden {
+                visible = append(visible, test)
+            }
+        }
+        blocks[i].Content["tests"] = visible
+    }
+}
+
+// sameCodeOutput сравнивает вывод программы с ожидаемым без учета пробелов в конце строк,
+// пустых строк в конце и переводов строк Windows
+func sameCodeOutput(actual, expected string) bool {
+    normalize := func(s string) string {
+        lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+        for i, line := range lines {
+            lines[i] = strings.TrimRight(line, " \t\r")
+        }
+        return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+    }
+    return normalize(actual) == normalize(expected)
+}
@@ -0,0 +1,436 @@
+package services
+
+import (
+    "context"
+    "crypto/rand"
+    "encoding/hex"
+    "fmt"
+    "log"
+    "sync"
+    "time"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/repositories"
+)
+
+// Время жизни блокировки блока. Клиент продлевает ее повторным сообщением lock
+const blockLockTTL = 2 * time.Minute
+
+// CollaborationClient - подключение редактора к материалу
+type CollaborationClient struct {
+    ID         string
+    UserID     int
+    UserName   string
+    MaterialID int
+    Role       string
+
+    mu      sync.Mutex
+    blockID string // блок, на котором сейчас находится пользователь
+
+    send      chan *models.CollaborationEvent
+    done      chan struct{}
+    closeOnce sync.Once
+}
+
+// Events возвращает очередь событий для отправки клиенту
+func (c *CollaborationClient) Events() <-chan *models.CollaborationEvent {
+    return c.send
+}
+
+// Done закрывается, когда клиент отключен
+func (c *CollaborationClient) Done() <-chan struct{} {
+    return c.done
+}
+
+// Close отключает клиента
+func (c *CollaborationClient) Close() {
+    c.closeOnce.Do(func() { close(c.done) })
+}
+
+// deliver ставит событие в очередь клиента. Не успевающий клиент отключается:
+// после переподключения он получит свежий снимок материала
+func (c *CollaborationClient) deliver(event *models.CollaborationEvent) {
+    select {
+    case <-c.done:
+    case c.send <- event:
+    default:
+        c.Close()
+    }
+}
+
+type CollaborationService struct {
+    materialService   *MaterialService
+    collaborationRepo *repositories.CollaborationRepository
+    userRepo          *repositories.UserRepository
+    instanceID        string
+
+    mu    sync.RWMutex
+    rooms map[int]map[*CollaborationClient]struct{}
+}
+
+func NewCollaborationService(
+    materialService *MaterialService,
+    collaborationRepo *repositories.CollaborationRepository,
+    userRepo *repositories.UserRepository,
+) *CollaborationService {
+    return &CollaborationService{
+        materialService:   materialService,
+        collaborationRepo: collaborationRepo,
+        userRepo:          userRepo,
+        instanceID:        randomID(),
+        rooms:             make(map[int]map[*CollaborationClient]struct{}),
+    }
+}
+
+// Start подписывается на события других инстансов через Postgres LISTEN/NOTIFY.
+// При обрыве соединения подписка восстанавливается
+func (s *CollaborationService) Start(ctx context.Context) {
+    go func() {
+        for {
+            err := s.collaborationRepo.Listen(ctx, s.dispatch)
+            if ctx.Err() != nil {
+                return
+            }
+            log.Printf("⚠️ Collaboration listener stopped: %v, reconnecting", err)
+            time.Sleep(3 * time.Second)
+        }
+    }()
+}
+
+// Join подключает пользователя к совместному редактированию материала
+// и возвращает снимок блоков. Операции с seq не больше снимка клиент должен игнорировать
+func (s *CollaborationService) Join(ctx context.Context, userID, materialID int) (*CollaborationClient, *models.CollaborationEvent, error) {
+    _, role, err := s.materialService.CheckAccess(ctx, userID, materialID, "viewer")
+    if err != nil {
+        return nil, nil, err
+    }
+
+    user, err := s.userRepo.GetUserByID(ctx, userID)
+    if err != nil || user == nil {
+        return nil, nil, fmt.Errorf("user not found")
+    }
+
+    client := &CollaborationClient{
+        ID:         randomID(),
+        UserID:     userID,
+        UserName:   user.FullName,
+        MaterialID: materialID,
+        Role:       role,
+        send:       make(chan *models.CollaborationEvent, 256),
+        done:       make(chan struct{}),
+    }
+
+    // Регистрируем клиента до снимка, чтобы не пропустить операции
+    s.mu.Lock()
+    if s.rooms[materialID] == nil {
+        s.rooms[materialID] = make(map[*CollaborationClient]struct{})
+    }
+    s.rooms[materialID][client] = struct{}{}
+    s.mu.Unlock()
+
+    seq, blocks, err := s.collaborationRepo.GetSnapshot(ctx, materialID)
+    if err != nil {
+        s.removeClient(client)
+        return nil, nil, err
+    }
+
+    locks, err := s.collaborationRepo.GetLocks(ctx, materialID)
+    if err != nil {
+        s.removeClient(client)
+        return nil, nil, err
+    }
+
+    s.publish(ctx, &models.CollaborationEvent{
+        Type:       "join",
+        MaterialID: materialID,
+        UserID:     userID,
+        UserName:   client.UserName,
+        ClientID:   client.ID,
+    })
+
+    return client, &models.CollaborationEvent{
+        Type:       "snapshot",
+        MaterialID: materialID,
+        Seq:        seq,
+        ClientID:   client.ID,
+        Blocks:     blocks,
+        Locks:      locks,
+    }, nil
+}
+
+// Leave отключает клиента и снимает его блокировки
+func (s *CollaborationService) Leave(ctx context.Context, client *CollaborationClient) {
+    client.Close()
+    stillConnected := s.removeClient(client)
+
+    // Блокировки принадлежат пользователю, а не подключению
+    if !stillConnected {
+        blockIDs, err := s.collaborationRepo.ReleaseUserLocks(ctx, client.MaterialID, client.UserID)
+        if err != nil {
+            log.Printf("⚠️ Failed to release locks of user %d: %v", client.UserID, err)
+        }
+        for _, blockID := range blockIDs {
+            s.publish(ctx, &models.CollaborationEvent{
+                Type:       "unlock",
+                MaterialID: client.MaterialID,
+                UserID:     client.UserID,
+                BlockID:    blockID,
+            })
+        }
+    }
+
+    s.publish(ctx, &models.CollaborationEvent{
+        Type:       "leave",
+        MaterialID: client.MaterialID,
+        UserID:     client.UserID,
+        UserName:   client.UserName,
+        ClientID:   client.ID,
+    })
+}
+
+// HandleMessage обрабатывает сообщение клиента
+func (s *CollaborationService) HandleMessage(ctx context.Context, client *CollaborationClient, msg *models.CollaborationMessage) {
+    switch msg.Type {
+    case "ping":
+        client.deliver(&models.CollaborationEvent{Type: "pong"})
+
+    case "presence":
+        client.mu.Lock()
+        client.blockID = msg.BlockID
+        client.mu.Unlock()
+
+        s.publish(ctx, s.presenceEvent(client))
+
+    case "lock":
+        if materialRoleRank[client.Role] < materialRoleRank["editor"] {
+            s.replyError(client, "access denied")
+            return
+        }
+
+        acquired, err := s.collaborationRepo.AcquireLock(ctx, client.MaterialID, msg.BlockID, client.UserID, blockLockTTL)
+        if err != nil {
+            s.replyError(client, err.Error())
+            return
+        }
+        if !acquired {
+            s.replyError(client, "block is locked")
+            return
+        }
+
+        s.publish(ctx, &models.CollaborationEvent{
+            Type:       "lock",
+            MaterialID: client.MaterialID,
+            UserID:     client.UserID,
+            UserName:   client.UserName,
+            BlockID:    msg.BlockID,
+        })
+
+    case "unlock":
+        released, err := s.collaborationRepo.ReleaseLock(ctx, client.MaterialID, msg.BlockID, client.UserID)
+        if err != nil {
+            s.replyError(client, err.Error())
+            return
+        }
+        if released {
+            s.publish(ctx, &models.CollaborationEvent{
+                Type:       "unlock",
+                MaterialID: client.MaterialID,
+                UserID:     client.UserID,
+                BlockID:    msg.BlockID,
+            })
+        }
+
+    case "add", "update", "delete", "reorder":
+        op, err := s.materialService.ApplyBlockOperation(ctx, client.UserID, &models.BlockOperation{
+            MaterialID: client.MaterialID,
+            Type:       msg.Type,
+            BlockID:    msg.BlockID,
+            Block:      msg.Block,
+            BlockIDs:   msg.BlockIDs,
+            Position:   msg.Position,
+        })
+        if err != nil {
+            s.replyError(client, err.Error())
+            return
+        }
+
+        // Сама операция придет всем редакторам через NOTIFY
+        client.deliver(&models.CollaborationEvent{
+            Type:       "ack",
+            MaterialID: client.MaterialID,
+            Seq:        op.Seq,
+            BlockID:    op.BlockID,
+        })
+
+    default:
+        s.replyError(client, "unknown message type: "+msg.Type)
+    }
+}
+
+// GetOperationsSince возвращает пропущенные операции для догоняющего клиента
+func (s *CollaborationService) GetOperationsSince(ctx context.Context, userID, materialID int, since int64) ([]models.BlockOperation, error) {
+    if _, _, err := s.materialService.CheckAccess(ctx, userID, materialID, "viewer"); err != nil {
+        return nil, err
+    }
+
+    operations, err := s.collaborationRepo.GetOperationsSince(ctx, materialID, since, 500)
+    if err != nil {
+        return nil, err
+    }
+
+    // Журнал хранится ограниченное время: если нужные клиенту операции уже удалены,
+    // догнать материал по журналу нельзя - клиент должен заново загрузить снимок
+    if len(operations) > 0 && operations[0].Seq > since+1 {
+        return nil, fmt.Errorf("operations expired")
+    }
+    if len(operations) == 0 {
+        seq, err := s.collaborationRepo.GetSeq(ctx, materialID)
+        if err != nil {
+            return nil, err
+        }
+        if seq > since {
+            return nil, fmt.Errorf("operations expired")
+        }
+    }
+
+    return operations, nil
+}
+
+// StartOperationLogCleanup периодически удаляет из журнала операции старше retention.
+// Подключенные клиенты получают операции сразу, журнал нужен только для догоняющих
+func (s *CollaborationService) StartOperationLogCleanup(ctx context.Context, retention time.Duration) {
+    go func() {
+        ticker := time.NewTicker(time.Hour)
+        defer ticker.Stop()
+
+        for {
+            pruned, err := s.collaborationRepo.PruneOperations(ctx, time.Now().Add(-retention))
+            if err != nil {
+                log.Printf("⚠️ Failed to prune operation log: %v", err)
+            } else if pruned > 0 {
+                log.Printf("🧹 Pruned %d old block operations", pruned)
+            }
+
+            select {
+            case <-ctx.Done():
+                return
+            case <-ticker.C:
+            }
+        }
+    }()
+}
+
+// dispatch раздает событие из NOTIFY клиентам этого инстанса
+func (s *CollaborationService) dispatch(event *models.CollaborationEvent) {
+    clients := s.roomClients(event.MaterialID)
+    if len(clients) == 0 {
+        return
+    }
+
+    switch event.Type {
+    case "operation":
+        op, err := s.collaborationRepo.GetOperation(context.Background(), event.MaterialID, event.Seq)
+        if err != nil || op == nil {
+            log.Printf("⚠️ Failed to load operation %d of material %d: %v", event.Seq, event.MaterialID, err)
+            return
+        }
+        event.Operation = op
+
+    case "join":
+        // Новому участнику нужно знать, кто уже редактирует материал
+        go s.announcePresence(event.MaterialID, event.ClientID)
+
+    case "access_changed":
+        // Роль пользователя изменилась или он больше не соавтор: закрываем его подключения,
+        // при переподключении Join проверит доступ заново. Остальным событие не нужно
+        for _, client := range clients {
+            if client.UserID == event.UserID {
+                client.Close()
+            }
+        }
+        return
+    }
+
+    for _, client := range clients {
+        if event.ClientID != "" && client.ID == event.ClientID {
+            continue
+        }
+        client.deliver(event)
+    }
+}
+
+// announcePresence повторно рассылает присутствие локальных клиентов материала
+func (s *CollaborationService) announcePresence(materialID int, exceptClientID string) {
+    for _, client := range s.roomClients(materialID) {
+        if client.ID == exceptClientID {
+            continue
+        }
+        s.publish(context.Background(), s.presenceEvent(client))
+    }
+}
+
+func (s *CollaborationService) presenceEvent(client *CollaborationClient) *models.CollaborationEvent {
+    client.mu.Lock()
+    defer client.mu.Unlock()
+
+    return &models.CollaborationEvent{
+        Type:       "presence",
+        MaterialID: client.MaterialID,
+        UserID:     client.UserID,
+        UserName:   client.UserName,
+        ClientID:   client.ID,
+        BlockID:    client.blockID,
+    }
+}
+
+// publish рассылает событие всем инстансам (включая текущий) через NOTIFY
+func (s *CollaborationService) publish(ctx context.Context, event *models.CollaborationEvent) {
+    event.InstanceID = s.instanceID
+    if err := s.collaborationRepo.Notify(ctx, event); err != nil {
+        log.Printf("⚠️ Failed to publish collaboration event %s: %v", event.Type, err)
+    }
+}
+
+func (s *CollaborationService) replyError(client *CollaborationClient, message string) {
+    client.deliver(&models.CollaborationEvent{
+        Type:       "error",
+        MaterialID: client.MaterialID,
+        Error:      message,
+    })
+}
+
+func (s *CollaborationService) roomClients(materialID int) []*CollaborationClient {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+
+    clients := make([]*CollaborationClient, 0, len(s.rooms[materialID]))
+    for client := range s.rooms[materialID] {
+        clients = append(clients, client)
+    }
+    return clients
+}
+
+// removeClient удаляет клиента из комнаты и сообщает, остались ли у пользователя другие подключения
+func (s *CollaborationService) removeClient(client *CollaborationClient) bool {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    room := s.rooms[client.MaterialID]
+    delete(room, client)
+    if len(room) == 0 {
+        delete(s.rooms, client.MaterialID)
+    }
+
+    for other := range room {
+        if other.UserID == client.UserID {
+            return true
+        }
+    }
+    return false
+}
+
+func randomID() string {
+    bytes := make([]byte, 8)
+    rand.Read(bytes)
+    return hex.EncodeToString(bytes)
+}
@@ -0,0 +1,261 @@
+package services
+
+import (
+    "context"
+    "fmt"
+    "strings"
+    "time"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/repositories"
+)
+
+// Длина фрагмента комментария в уведомлении
+const commentExcerptLength = 100
+
+type CommentService struct {
+    commentRepo         *repositories.CommentRepository
+    materialRepo        *repositories.MaterialRepository
+    blockRepo           *repositories.BlockRepository
+    materialService     *MaterialService
+    notificationService *NotificationService
+}
+
+func NewCommentService(
+    commentRepo *repositories.CommentRepository,
+    materialRepo *repositories.MaterialRepository,
+    blockRepo *repositories.BlockRepository,
+    materialService *MaterialService,
+    notificationService *NotificationService,
+) *CommentService {
+    return &CommentService{
+        commentRepo:         commentRepo,
+        materialRepo:        materialRepo,
+        blockRepo:           blockRepo,
+        materialService:     materialService,
+        notificationService: notificationService,
+    }
+}
+
+// access проверяет, что пользователь может открыть материал, и возвращает его роль.
+// Модерировать обсуждение могут редакторы материала и администраторы
+func (s *CommentService) access(ctx context.Context, userID int, isAdmin bool, materialID int) (*models.Material, string, bool, error) {
+    material, err := s.materialRepo.GetMaterial(ctx, materialID)
+    if err != nil || material == nil {
+        return nil, "", false, fmt.Errorf("material not found")
+    }
+
+    role, err := s.materialService.GetMaterialRole(ctx, userID, material)
+    if err != nil {
+        return nil, "", false, err
+    }
+    if role == "" && !isAdmin && !isMaterialLive(material, time.Now()) {
+        return nil, "", false, fmt.Errorf("access denied")
+    }
+
+    return material, role, isAdmin || materialRoleRank[role] >= materialRoleRank["editor"], nil
+}
+
+// GetComments возвращает вопросы к материалу с ответами. Скрытые и удаленные комментарии
+// видны модераторам; остальным скрытые ответы не показываются, а скрытый или удаленный
+// вопрос с ответами показывается без текста и автора
+func (s *CommentService) GetComments(ctx context.Context, userID int, isAdmin bool, materialID int, filters models.CommentFilters) ([]models.MaterialComment, int, error) {
+    _, _, canModerate, err := s.access(ctx, userID, isAdmin, materialID)
+    if err != nil {
+        return nil, 0, err
+    }
+
+    threads, total, err := s.commentRepo.GetThreads(ctx, materialID, userID, canModerate, filters)
+    if err != nil {
+        return nil, 0, err
+    }
+
+    ids := make([]int, len(threads))
+    for i := range threads {
+        ids[i] = threads[i].ID
+    }
+    replies, err := s.commentRepo.GetReplies(ctx, ids, userID)
+    if err != nil {
+        return nil, 0, err
+    }
+
+    for i := range threads {
+        thread := &threads[i]
+        thread.Replies = []models.MaterialComment{}
+        for _, reply := range replies[thread.ID] {
+            if reply.Hidden && !canModerate {
+                continue
+            }
+            thread.Replies = append(thread.Replies, reply)
+        }
+
+        if thread.Deleted || (thread.Hidden && !canModerate) {
+            maskComment(thread)
+        }
+    }
+
+    return threads, total, nil
+}
+
+// CreateComment добавляет вопрос к материалу или блоку либо ответ на вопрос.
+// Ответ на ответ попадает в тот же вопрос. Автор материала получает уведомление
+// о новых вопросах и ответах, автор вопроса - об ответах на него
+func (s *CommentService) CreateComment(ctx context.Context, userID int, isAdmin bool, materialID int, req *models.CreateCommentRequest) (*models.MaterialComment, error) {
+    material, role, _, err := s.access(ctx, userID, isAdmin, materialID)
+    if err != nil {
+        return nil, err
+    }
+
+    content := strings.TrimSpace(req.Content)
+    if content == "" {
+        return nil, fmt.Errorf("content is required")
+    }
+
+    comment := &models.MaterialComment{
+        MaterialID: materialID,
+        UserID:     userID,
+        Content:    content,
+        IsAuthor:   materialRoleRank[role] >= materialRoleRank["editor"],
+    }
+
+    var thread *models.MaterialComment
+    if req.ParentID != nil {
+        thread, err = s.commentRepo.GetComment(ctx, *req.ParentID, userID)
+        if err != nil {
+            return nil, err
+        }
+        if thread != nil && thread.ParentID != nil {
+            thread, err = s.commentRepo.GetComment(ctx, *thread.ParentID, userID)
+            if err != nil {
+                return nil, err
+            }
+        }
+        if thread == nil || thread.MaterialID != materialID || thread.Deleted {
+            return nil, fmt.Errorf("comment not found")
+        }
+        comment.ParentID = &thread.ID
+    } else if req.BlockID != nil && *req.BlockID != "" {
+        blocks, err := s.blockRepo.GetBlocks(ctx, materialID)
+        if err != nil {
+            return nil, err
+        }
+        if !hasBlock(blocks, *req.BlockID) {
+            return nil, fmt.Errorf("block not found")
+        }
+        comment.BlockID = req.BlockID
+    }
+
+    if err := s.commentRepo.CreateComment(ctx, comment); err != nil {
+        return nil, fmt.Errorf("failed to create comment: %w", err)
+    }
+
+    excerpt := commentExcerpt(content)
+    if thread != nil && thread.UserID != userID {
+        s.notificationService.Notify(ctx, thread.UserID, "comment_reply",
+            fmt.Sprintf("Ответ на ваш вопрос к материалу «%s»: %s", material.Title, excerpt), &materialID)
+    }
+    if material.AuthorID != userID && (thread == nil || thread.UserID != material.AuthorID) {
+        message := fmt.Sprintf("Новый вопрос к материалу «%s»: %s", material.Title, excerpt)
+        if thread != nil {
+            message = fmt.Sprintf("Новый ответ в обсуждении материала «%s»: %s", material.Title, excerpt)
+        }
+        s.notificationService.Notify(ctx, material.AuthorID, "comment_new", message, &materialID)
+    }
+
+    created, err := s.commentRepo.GetComment(ctx, comment.ID, userID)
+    if err != nil || created == nil {
+        return comment, nil
+    }
+    return created, nil
+}
+
+// Vote ставит или снимает голос за комментарий и возвращает число голосов
+func (s *CommentService) Vote(ctx context.Context, userID int, isAdmin bool, commentID int, upvote bool) (int, error) {
+    comment, _, err := s.getComment(ctx, userID, isAdmin, commentID)
+    if err != nil {
+        return 0, err
+    }
+    if comment.UserID == userID {
+        return 0, fmt.Errorf("cannot vote for own comment")
+    }
+
+    return s.commentRepo.SetVote(ctx, commentID, userID, upvote)
+}
+
+// SetResolved отмечает вопрос решенным. Может автор вопроса или модератор обсуждения
+func (s *CommentService) SetResolved(ctx context.Context, userID int, isAdmin bool, commentID int, resolved bool) error {
+    comment, canModerate, err := s.getComment(ctx, userID, isAdmin, commentID)
+    if err != nil {
+        return err
+    }
+    if comment.ParentID != nil {
+        return fmt.Errorf("only questions can be resolved")
+    }
+    if comment.UserID != userID && !canModerate {
+        return fmt.Errorf("access denied")
+    }
+
+    return s.commentRepo.SetResolved(ctx, commentID, resolved)
+}
+
+// SetHidden скрывает комментарий или снова показывает его. Только для модераторов обсуждения
+func (s *CommentService) SetHidden(ctx context.Context, userID int, isAdmin bool, commentID int, hidden bool) error {
+    _, canModerate, err := s.getComment(ctx, userID, isAdmin, commentID)
+    if err != nil {
+        return err
+    }
+    if !canModerate {
+        return fmt.Errorf("access denied")
+    }
+
+    return s.commentRepo.SetHidden(ctx, commentID, userID, hidden)
+}
+
+// DeleteComment удаляет комментарий. Может автор комментария или модератор обсуждения
+func (s *CommentService) DeleteComment(ctx context.Context, userID int, isAdmin bool, commentID int) error {
+    comment, canModerate, err := s.getComment(ctx, userID, isAdmin, commentID)
+    if err != nil {
+        return err
+    }
+    if comment.UserID != userID && !canModerate {
+        return fmt.Errorf("access denied")
+    }
+
+    return s.commentRepo.DeleteComment(ctx, commentID)
+}
+
+// getComment возвращает неудаленный комментарий, видимый пользователю
+func (s *CommentService) getComment(ctx context.Context, userID int, isAdmin bool, commentID int) (*models.MaterialComment, bool, error) {
+    comment, err := s.commentRepo.GetComment(ctx, commentID, userID)
+    if err != nil {
+        return nil, false, err
+    }
+    if comment == nil || comment.Deleted {
+        return nil, false, fmt.Errorf("comment not found")
+    }
+
+    _, _, canModerate, err := s.access(ctx, userID, isAdmin, comment.MaterialID)
+    if err != nil {
+        return nil, false, err
+    }
+    if comment.Hidden && !canModerate {
+        return nil, false, fmt.Errorf("comment not found")
+    }
+
+    return comment, canModerate, nil
+}
+
+func maskComment(comment *models.MaterialComment) {
+    comment.Content = ""
+    comment.UserID = 0
+    comment.UserName = ""
+    comment.UserAvatar = ""
+}
+
+func commentExcerpt(content string) string {
+    runes := []rune(content)
+    if len(runes) <= commentExcerptLength {
+        return content
+    }
+    return string(runes[:commentExcerptLength]) + "…"
+}
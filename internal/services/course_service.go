@@ -0,0 +1,486 @@
+package services
+
+import (
+    "context"
+    "fmt"
+    "strconv"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/repositories"
+)
+
+type CourseService struct {
+    courseRepo      *repositories.CourseRepository
+    materialService *MaterialService
+}
+
+func NewCourseService(courseRepo *repositories.CourseRepository, materialService *MaterialService) *CourseService {
+    return &CourseService{
+        courseRepo:      courseRepo,
+        materialService: materialService,
+    }
+}
+
+// CreateCourse создает новый курс
+func (s *CourseService) CreateCourse(ctx context.Context, userID int, req *models.CreateCourseRequest) (*models.Course, error) {
+    course := &models.Course{
+        Title:       req.Title,
+        Subject:     req.Subject,
+        Description: req.Description,
+        Level:       req.Level,
+        CoverURL:    req.CoverURL,
+        AuthorID:    userID,
+        Status:      "draft",
+        Access:      "open",
+        Modules:     []models.CourseModule{},
+    }
+
+    if err := s.courseRepo.CreateCourse(ctx, course); err != nil {
+        return nil, fmt.Errorf("failed to create course: %w", err)
+    }
+
+    return course, nil
+}
+
+// GetCourse возвращает курс с модулями
+// Опубликованный курс доступен всем, остальные - только автору
+func (s *CourseService) GetCourse(ctx context.Context, userID, courseID int) (*models.Course, error) {
+    course, err := s.getVisibleCourse(ctx, userID, courseID)
+    if err != nil {
+        return nil, err
+    }
+
+    course.Modules, err = s.courseRepo.GetCourseModules(ctx, courseID)
+    if err != nil {
+        return nil, err
+    }
+
+    return course, nil
+}
+
+// GetUserCourses возвращает курсы автора
+func (s *CourseService) GetUserCourses(ctx context.Context, userID int) ([]*models.Course, error) {
+    return s.courseRepo.GetUserCourses(ctx, userID)
+}
+
+// UpdateCourse обновляет описание курса
+func (s *CourseService) UpdateCourse(ctx context.Context, userID, courseID int, req *models.UpdateCourseRequest) (*models.Course, error) {
+    course, err := s.getOwnedCourse(ctx, userID, courseID)
+    if err != nil {
+        return nil, err
+    }
+
+    if req.Title != nil {
+        course.Title = *req.Title
+    }
+    if req.Subject != nil {
+        course.Subject = *req.Subject
+    }
+    if req.Description != nil {
+        course.Description = *req.Description
+    }
+    if req.Level != nil {
+        // Пустая строка сбрасывает уровень
+        course.Level = req.Level
+        if *req.Level == "" {
+            course.Level = nil
+        }
+    }
+    if req.CoverURL != nil {
+        course.CoverURL = *req.CoverURL
+    }
+
+    if err := s.courseRepo.UpdateCourse(ctx, course); err != nil {
+        return nil, fmt.Errorf("failed to update course: %w", err)
+    }
+
+    return course, nil
+}
+
+// DeleteCourse удаляет курс. Материалы курса остаются у авторов
+func (s *CourseService) DeleteCourse(ctx context.Context, userID, courseID int) error {
+    if _, err := s.getOwnedCourse(ctx, userID, courseID); err != nil {
+        return err
+    }
+
+    return s.courseRepo.DeleteCourse(ctx, courseID)
+}
+
+// PublishCourse публикует курс. Опубликовать можно только непустой курс,
+// все материалы которого опубликованы - иначе ученики не смогут их открыть
+func (s *CourseService) PublishCourse(ctx context.Context, userID, courseID int, req *models.PublishCourseRequest) (*models.Course, error) {
+    course, err := s.getOwnedCourse(ctx, userID, courseID)
+    if err != nil {
+        return nil, err
+    }
+
+    if req.Visibility == "published" {
+        modules, err := s.courseRepo.GetCourseModules(ctx, courseID)
+        if err != nil {
+            return nil, err
+        }
+
+        itemsCount := 0
+        for _, module := range modules {
+            for _, item := range module.Items {
+                if item.MaterialStatus != "published" {
+                    return nil, fmt.Errorf("course has unpublished materials")
+                }
+                itemsCount++
+            }
+        }
+        if itemsCount == 0 {
+            return nil, fmt.Errorf("course is empty")
+        }
+    }
+
+    course.Status = req.Visibility
+    course.Access = req.Access
+
+    // Генерируем share URL если нужно
+    if req.Access == "link" {
+        course.ShareURL = "/c/" + s.materialService.generateShareURL()
+    } else {
+        course.ShareURL = "/course/" + strconv.Itoa(courseID)
+    }
+
+    if err := s.courseRepo.UpdateCourse(ctx, course); err != nil {
+        return nil, fmt.Errorf("failed to publish course: %w", err)
+    }
+
+    return course, nil
+}
+
+// CreateModule добавляет модуль в конец курса
+func (s *CourseService) CreateModule(ctx context.Context, userID, courseID int, title string) (*models.CourseModule, error) {
+    if _, err := s.getOwnedCourse(ctx, userID, courseID); err != nil {
+        return nil, err
+    }
+
+    module := &models.CourseModule{
+        CourseID: courseID,
+        Title:    title,
+        Items:    []models.CourseItem{},
+    }
+
+    if err := s.courseRepo.CreateModule(ctx, module); err != nil {
+        return nil, fmt.Errorf("failed to create module: %w", err)
+    }
+
+    return module, nil
+}
+
+// UpdateModule переименовывает модуль
+func (s *CourseService) UpdateModule(ctx context.Context, userID, courseID, moduleID int, title string) error {
+    if _, err := s.getOwnedCourse(ctx, userID, courseID); err != nil {
+        return err
+    }
+
+    updated, err := s.courseRepo.UpdateModule(ctx, courseID, moduleID, title)
+    if err != nil {
+        return err
+    }
+    if !updated {
+        return fmt.Errorf("module not found")
+    }
+
+    return nil
+}
+
+// DeleteModule удаляет модуль вместе с его элементами
+func (s *CourseService) DeleteModule(ctx context.Context, userID, courseID, moduleID int) error {
+    if _, err := s.getOwnedCourse(ctx, userID, courseID); err != nil {
+        return err
+    }
+
+    deleted, err := s.courseRepo.DeleteModule(ctx, courseID, moduleID)
+    if err != nil {
+        return err
+    }
+    if !deleted {
+        return fmt.Errorf("module not found")
+    }
+
+    return nil
+}
+
+// ReorderModules меняет порядок модулей курса
+func (s *CourseService) ReorderModules(ctx context.Context, userID, courseID int, moduleIDs []int) error {
+    if _, err := s.getOwnedCourse(ctx, userID, courseID); err != nil {
+        return err
+    }
+
+    return s.courseRepo.ReorderModules(ctx, courseID, moduleIDs)
+}
+
+// AddItem добавляет материал в модуль. Добавить можно свой материал, материал соавторства
+// или опубликованный материал другого автора
+func (s *CourseService) AddItem(ctx context.Context, userID, courseID, moduleID int, req *models.AddCourseItemRequest) (*models.CourseItem, error) {
+    if _, err := s.getOwnedCourse(ctx, userID, courseID); err != nil {
+        return nil, err
+    }
+
+    modules, err := s.courseRepo.GetCourseModules(ctx, courseID)
+    if err != nil {
+        return nil, err
+    }
+    if findModule(modules, moduleID) == nil {
+        return nil, fmt.Errorf("module not found")
+    }
+
+    material, err := s.materialService.GetMaterial(ctx, userID, req.MaterialID)
+    if err != nil {
+        return nil, err
+    }
+    if material == nil {
+        return nil, fmt.Errorf("material not found")
+    }
+
+    prerequisites, err := validatePrerequisites(modules, 0, req.Prerequisites)
+    if err != nil {
+        return nil, err
+    }
+
+    item := &models.CourseItem{
+        ModuleID:       moduleID,
+        MaterialID:     material.ID,
+        Title:          material.Title,
+        MaterialStatus: material.Status,
+        Prerequisites:  prerequisites,
+    }
+
+    if err := s.courseRepo.AddItem(ctx, courseID, item); err != nil {
+        return nil, err
+    }
+
+    return item, nil
+}
+
+// SetPrerequisites заменяет условия доступа элемента курса
+func (s *CourseService) SetPrerequisites(ctx context.Context, userID, courseID, itemID int, prerequisiteIDs []int) ([]int, error) {
+    if _, err := s.getOwnedCourse(ctx, userID, courseID); err != nil {
+        return nil, err
+    }
+
+    modules, err := s.courseRepo.GetCourseModules(ctx, courseID)
+    if err != nil {
+        return nil, err
+    }
+    if findItem(modules, itemID) == nil {
+        return nil, fmt.Errorf("item not found")
+    }
+
+    prerequisites, err := validatePrerequisites(modules, itemID, prerequisiteIDs)
+    if err != nil {
+        return nil, err
+    }
+
+    if err := s.courseRepo.SetPrerequisites(ctx, courseID, itemID, prerequisites); err != nil {
+        return nil, err
+    }
+
+    return prerequisites, nil
+}
+
+// DeleteItem удаляет материал из курса
+func (s *CourseService) DeleteItem(ctx context.Context, userID, courseID, itemID int) error {
+    if _, err := s.getOwnedCourse(ctx, userID, courseID); err != nil {
+        return err
+    }
+
+    deleted, err := s.courseRepo.DeleteItem(ctx, courseID, itemID)
+    if err != nil {
+        return err
+    }
+    if !deleted {
+        return fmt.Errorf("item not found")
+    }
+
+    return nil
+}
+
+// ReorderItems меняет порядок материалов в модуле
+func (s *CourseService) ReorderItems(ctx context.Context, userID, courseID, moduleID int, itemIDs []int) error {
+    if _, err := s.getOwnedCourse(ctx, userID, courseID); err != nil {
+        return err
+    }
+
+    return s.courseRepo.ReorderItems(ctx, courseID, moduleID, itemIDs)
+}
+
+// GetCourseProgress возвращает прогресс ученика в курсе по завершенным материалам
+func (s *CourseService) GetCourseProgress(ctx context.Context, userID, courseID int) (*models.CourseProgress, error) {
+    if _, err := s.getVisibleCourse(ctx, userID, courseID); err != nil {
+        return nil, err
+    }
+
+    modules, err := s.courseRepo.GetCourseModules(ctx, courseID)
+    if err != nil {
+        return nil, err
+    }
+
+    completions, err := s.courseRepo.GetCourseCompletions(ctx, courseID, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    // Элемент пройден, если завершен его материал
+    completedItems := make(map[int]bool)
+    for _, module := range modules {
+        for _, item := range module.Items {
+            if _, ok := completions[item.MaterialID]; ok {
+                completedItems[item.ID] = true
+            }
+        }
+    }
+
+    progress := &models.CourseProgress{
+        CourseID: courseID,
+        Modules:  make([]models.CourseModuleProgress, 0, len(modules)),
+    }
+
+    for _, module := range modules {
+        moduleProgress := models.CourseModuleProgress{
+            ModuleID:   module.ID,
+            Title:      module.Title,
+            TotalItems: len(module.Items),
+            Items:      make([]models.CourseItemProgress, 0, len(module.Items)),
+        }
+
+        for _, item := range module.Items {
+            itemProgress := models.CourseItemProgress{
+                ItemID:     item.ID,
+                MaterialID: item.MaterialID,
+                Title:      item.Title,
+                Completed:  completedItems[item.ID],
+            }
+
+            if completion, ok := completions[item.MaterialID]; ok {
+                completedAt := completion.CompletedAt
+                itemProgress.Grade = completion.Grade
+                itemProgress.CompletedAt = &completedAt
+                moduleProgress.CompletedItems++
+            }
+
+            for _, prerequisiteID := range item.Prerequisites {
+                if !completedItems[prerequisiteID] {
+                    itemProgress.Locked = true
+                    break
+                }
+            }
+
+            if progress.NextItemID == nil && !itemProgress.Completed && !itemProgress.Locked {
+                itemID := item.ID
+                progress.NextItemID = &itemID
+            }
+
+            moduleProgress.Items = append(moduleProgress.Items, itemProgress)
+        }
+
+        progress.TotalItems += moduleProgress.TotalItems
+        progress.CompletedItems += moduleProgress.CompletedItems
+        progress.Modules = append(progress.Modules, moduleProgress)
+    }
+
+    if progress.TotalItems > 0 {
+        progress.Percent = float64(progress.CompletedItems) / float64(progress.TotalItems) * 100
+    }
+
+    return progress, nil
+}
+
+// getOwnedCourse возвращает курс, если пользователь - его автор
+func (s *CourseService) getOwnedCourse(ctx context.Context, userID, courseID int) (*models.Course, error) {
+    course, err := s.courseRepo.GetCourse(ctx, courseID)
+    if err != nil || course == nil {
+        return nil, fmt.Errorf("course not found")
+    }
+
+    if course.AuthorID != userID {
+        return nil, fmt.Errorf("access denied")
+    }
+
+    return course, nil
+}
+
+// getVisibleCourse возвращает курс, если он опубликован или пользователь - его автор
+func (s *CourseService) getVisibleCourse(ctx context.Context, userID, courseID int) (*models.Course, error) {
+    course, err := s.courseRepo.GetCourse(ctx, courseID)
+    if err != nil || course == nil {
+        return nil, fmt.Errorf("course not found")
+    }
+
+    if course.Status != "published" && course.AuthorID != userID {
+        return nil, fmt.Errorf("access denied")
+    }
+
+    return course, nil
+}
+
+func findModule(modules []models.CourseModule, moduleID int) *models.CourseModule {
+    for i := range modules {
+        if modules[i].ID == moduleID {
+            return &modules[i]
+        }
+    }
+    return nil
+}
+
+func findItem(modules []models.CourseModule, itemID int) *models.CourseItem {
+    for i := range modules {
+        for j := range modules[i].Items {
+            if modules[i].Items[j].ID == itemID {
+                return &modules[i].Items[j]
+            }
+        }
+    }
+    return nil
+}
+
+// validatePrerequisites проверяет, что условия ссылаются на элементы того же курса
+// и не образуют цикл. itemID = 0 - новый элемент, от которого еще никто не зависит
+func validatePrerequisites(modules []models.CourseModule, itemID int, prerequisiteIDs []int) ([]int, error) {
+    graph := make(map[int][]int)
+    for _, module := range modules {
+        for _, item := range module.Items {
+            graph[item.ID] = item.Prerequisites
+        }
+    }
+
+    prerequisites := []int{}
+    seen := make(map[int]bool)
+    for _, id := range prerequisiteIDs {
+        if seen[id] {
+            continue
+        }
+        if _, ok := graph[id]; !ok || id == itemID {
+            return nil, fmt.Errorf("invalid prerequisite")
+        }
+        seen[id] = true
+        prerequisites = append(prerequisites, id)
+    }
+
+    if itemID == 0 {
+        return prerequisites, nil
+    }
+
+    // Цикл возникает, если элемент достижим из своих же условий
+    graph[itemID] = prerequisites
+    visited := make(map[int]bool)
+    stack := append([]int{}, prerequisites...)
+    for len(stack) > 0 {
+        current := stack[len(stack)-1]
+        stack = stack[:len(stack)-1]
+
+        if current == itemID {
+            return nil, fmt.Errorf("prerequisite cycle")
+        }
+        if visited[current] {
+            continue
+        }
+        visited[current] = true
+        stack = append(stack, graph[current]...)
+    }
+
+    return prerequisites, nil
+}
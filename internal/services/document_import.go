@@ -0,0 +1,510 @@
+package services
+
+import (
+    "archive/zip"
+    "bytes"
+    "context"
+    "encoding/base64"
+    "fmt"
+    "io"
+    "log"
+    "net/url"
+    "path"
+    "regexp"
+    "strings"
+    "unicode/utf8"
+
+    "paydeya-backend/internal/models"
+)
+
+const (
+    maxDocumentSize      = 10 << 20 // текст документа: .md или word/document.xml
+    maxDocumentImageSize = 20 << 20
+)
+
+// ImportDocument создает черновик материала из Markdown (.md или ZIP с .md и картинками) или DOCX.
+// Заголовки, абзацы, списки и код становятся текстовыми блоками, картинки - блоками изображений
+// (встроенные файлы загружаются заново), формулы - блоками формул
+func (s *ExportService) ImportDocument(ctx context.Context, userID int, req *models.ImportDocumentRequest, fileName string, r io.ReaderAt, size int64) (*models.Material, error) {
+    exists, err := s.materialRepo.SubjectExists(ctx, req.Subject)
+    if err != nil {
+        return nil, err
+    }
+    if !exists {
+        return nil, fmt.Errorf("unknown subject")
+    }
+
+    images := &documentImages{
+        ctx:         ctx,
+        fileService: s.fileService,
+        userID:      userID,
+        uploaded:    make(map[string]string),
+    }
+    imported := false
+    defer func() {
+        if !imported {
+            images.cleanup()
+        }
+    }()
+
+    var blocks []models.Block
+
+    header := make([]byte, 4)
+    if _, err := r.ReadAt(header, 0); err == nil && bytes.Equal(header, []byte("PK\x03\x04")) {
+        archive, err := zip.NewReader(r, size)
+        if err != nil {
+            return nil, fmt.Errorf("invalid document")
+        }
+        images.files = make(map[string]*zip.File)
+        for _, file := range archive.File {
+            images.files[file.Name] = file
+        }
+
+        if _, ok := images.files["word/document.xml"]; ok {
+            blocks, err = docxBlocks(images)
+        } else {
+            blocks, err = archiveMarkdownBlocks(images)
+        }
+        if err != nil {
+            return nil, err
+        }
+    } else {
+        switch strings.ToLower(path.Ext(fileName)) {
+        case ".md", ".markdown", ".txt", "":
+        default:
+            return nil, fmt.Errorf("unsupported document format")
+        }
+
+        data, err := io.ReadAll(io.NewSectionReader(r, 0, size))
+        if err != nil {
+            return nil, err
+        }
+        if len(data) > maxDocumentSize || !utf8.Valid(data) {
+            return nil, fmt.Errorf("invalid document")
+        }
+
+        if blocks, err = markdownBlocks(string(data), images.markdownImage); err != nil {
+            return nil, err
+        }
+    }
+
+    // Первый заголовок документа становится названием материала, если оно не задано
+    title := strings.TrimSpace(req.Title)
+    if title == "" && len(blocks) > 0 && blocks[0].Type == "text" && blocks[0].Content["level"] == "h1" {
+        title = contentString(blocks[0].Content, "text")
+        blocks = blocks[1:]
+    }
+    if title == "" {
+        title = strings.TrimSuffix(path.Base(fileName), path.Ext(fileName))
+    }
+    if title == "" || title == "." {
+        return nil, fmt.Errorf("invalid document")
+    }
+    if len(blocks) == 0 {
+        return nil, fmt.Errorf("document has no content")
+    }
+
+    for i := range blocks {
+        blocks[i].ID = newBlockID()
+        blocks[i].Position = i
+    }
+
+    material := &models.Material{
+        Title:    title,
+        Subject:  req.Subject,
+        Level:    req.Level,
+        Tags:     []string{},
+        Language: req.Language,
+        AuthorID: userID,
+        Status:   "draft",
+        Access:   "open",
+        Blocks:   blocks,
+    }
+    if material.Language == "" {
+        material.Language = "ru"
+    }
+
+    if err := normalizeBlocks(blocks); err != nil {
+        return nil, fmt.Errorf("invalid document")
+    }
+    // Импорт создает черновик: неверные формулы не мешают импорту, ошибки автор увидит при сохранении
+    _ = s.formulaService.PrepareBlocks(blocks)
+
+    if err := s.materialRepo.CreateMaterialWithBlocks(ctx, material, blocks); err != nil {
+        return nil, fmt.Errorf("failed to import material: %w", err)
+    }
+
+    imported = true
+    return material, nil
+}
+
+// documentImages загружает картинки импортируемого документа, каждую - один раз.
+// Если импорт сорвется, загруженные файлы удаляются
+type documentImages struct {
+    ctx         context.Context
+    fileService *FileService
+    userID      int
+    files       map[string]*zip.File // содержимое архива, если документ пришел архивом
+    base        string               // каталог .md внутри архива для относительных ссылок
+    uploaded    map[string]string
+}
+
+// upload загружает картинку под ключом key. Пустой URL означает, что картинку пропустили:
+// формат не поддерживается хранилищем или файл слишком большой
+func (d *documentImages) upload(key, fileName string, open func() ([]byte, error)) (string, error) {
+    if url, ok := d.uploaded[key]; ok {
+        return url, nil
+    }
+    if !isValidImageExt(path.Ext(fileName)) {
+        log.Printf("⚠️ Import: skipped image %s: unsupported format", fileName)
+        d.uploaded[key] = ""
+        return "", nil
+    }
+
+    data, err := open()
+    if err != nil {
+        log.Printf("⚠️ Import: skipped image %s: %v", fileName, err)
+        d.uploaded[key] = ""
+        return "", nil
+    }
+
+    result, err := d.fileService.UploadImage(d.ctx, bytes.NewReader(data), fileName, d.userID)
+    if err != nil {
+        return "", err
+    }
+
+    d.uploaded[key] = result.URL
+    return result.URL, nil
+}
+
+// archiveImage загружает картинку из архива документа
+func (d *documentImages) archiveImage(name string) (string, bool, error) {
+    file, ok := d.files[name]
+    if !ok {
+        return "", false, nil
+    }
+    url, err := d.upload(name, path.Base(name), func() ([]byte, error) {
+        return readZipFile(file, maxDocumentImageSize)
+    })
+    return url, true, err
+}
+
+// markdownImage разрешает ссылку на картинку из Markdown: data URI и файлы рядом с .md в архиве
+// загружаются, внешние ссылки остаются как есть
+func (d *documentImages) markdownImage(ref string) (string, error) {
+    if strings.HasPrefix(ref, "data:") {
+        mediaType, data, ok := strings.Cut(strings.TrimPrefix(ref, "data:"), ",")
+        if !ok || !strings.HasSuffix(mediaType, ";base64") {
+            return "", nil
+        }
+        ext := imageExtensions[strings.TrimSuffix(mediaType, ";base64")]
+        return d.upload(ref, "image"+ext, func() ([]byte, error) {
+            if base64.StdEncoding.DecodedLen(len(data)) > maxDocumentImageSize {
+                return nil, fmt.Errorf("file too large")
+            }
+            return base64.StdEncoding.DecodeString(data)
+        })
+    }
+
+    if d.files == nil || strings.Contains(ref, "://") || strings.HasPrefix(ref, "/") {
+        return ref, nil
+    }
+
+    name := ref
+    if unescaped, err := url.PathUnescape(ref); err == nil {
+        name = unescaped
+    }
+    link, found, err := d.archiveImage(path.Join(d.base, name))
+    if !found {
+        return ref, err
+    }
+    return link, err
+}
+
+func (d *documentImages) cleanup() {
+    for _, url := range d.uploaded {
+        if url == "" {
+            continue
+        }
+        if err := d.fileService.DeleteMedia(context.Background(), url); err != nil {
+            log.Printf("⚠️ Import: failed to delete media %s: %v", url, err)
+        }
+    }
+}
+
+var imageExtensions = map[string]string{
+    "image/png":  ".png",
+    "image/jpeg": ".jpg",
+    "image/gif":  ".gif",
+    "image/webp": ".webp",
+}
+
+// archiveMarkdownBlocks разбирает ZIP с одним .md и картинками, на которые он ссылается
+func archiveMarkdownBlocks(images *documentImages) ([]models.Block, error) {
+    var document *zip.File
+    for name, file := range images.files {
+        ext := strings.ToLower(path.Ext(name))
+        if ext != ".md" && ext != ".markdown" || strings.HasPrefix(path.Base(name), ".") {
+            continue
+        }
+        if document != nil {
+            return nil, fmt.Errorf("invalid document")
+        }
+        document = file
+    }
+    if document == nil {
+        return nil, fmt.Errorf("unsupported document format")
+    }
+
+    data, err := readZipFile(document, maxDocumentSize)
+    if err != nil || !utf8.Valid(data) {
+        return nil, fmt.Errorf("invalid document")
+    }
+
+    images.base = path.Dir(document.Name)
+    return markdownBlocks(string(data), images.markdownImage)
+}
+
+func textBlock(text, level string) models.Block {
+    return models.Block{Type: "text", Content: map[string]interface{}{"text": text, "level": level}}
+}
+
+func imageBlock(url, alt, caption string) models.Block {
+    content := map[string]interface{}{"url": url}
+    if alt != "" {
+        content["alt"] = alt
+    }
+    if caption != "" {
+        content["caption"] = caption
+    }
+    return models.Block{Type: "image", Content: content}
+}
+
+func formulaBlock(latex string) models.Block {
+    return models.Block{Type: "formula", Content: map[string]interface{}{"latex": latex}}
+}
+
+var (
+    mdHeading    = regexp.MustCompile(`^\s{0,3}(#{1,6})\s+(.*?)(?:\s+#+)?\s*$`)
+    mdSetext     = regexp.MustCompile(`^\s{0,3}(=+|-+)\s*$`)
+    mdRule       = regexp.MustCompile(`^\s{0,3}(?:(?:-\s*){3,}|(?:\*\s*){3,}|(?:_\s*){3,})$`)
+    mdFence      = regexp.MustCompile("^\\s{0,3}(`{3,}|~{3,})\\s*([\\w+#.-]*)")
+    mdListItem   = regexp.MustCompile(`^(\s*)([-*+]|\d{1,9}[.)])\s+(.*)$`)
+    mdImage      = regexp.MustCompile(`!\[([^\]]*)\]\(\s*<?([^)\s>]+)>?(?:\s+"([^"]*)")?\s*\)`)
+    mdLink       = regexp.MustCompile(`\[([^\]]+)\]\(\s*<?([^)\s>]+)>?(?:\s+"[^"]*")?\s*\)`)
+    mdAutoLink   = regexp.MustCompile(`<((?:https?|mailto):[^>\s]+)>`)
+    mdEmphasis   = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__|~~([^~]+)~~|\*([^*\s][^*]*)\*|` + "`([^`]+)`")
+    mdEscape     = regexp.MustCompile(`\\([\\` + "`" + `*_{}\[\]()#+\-.!|~<>])`)
+    mdInlineMath = regexp.MustCompile(`\$[^$\n]+\$`)
+    mdSoleMath   = regexp.MustCompile(`^\$\$?([^$]+)\$?\$$`)
+)
+
+// markdownParser собирает блоки из Markdown построчно
+type markdownParser struct {
+    blocks    []models.Block
+    paragraph []string
+    list      []string
+    image     func(ref string) (string, error)
+}
+
+// markdownBlocks превращает Markdown в блоки. Абзац из одной формулы ($...$ или $$...$$)
+// становится блоком формулы, формулы внутри текста остаются в тексте
+func markdownBlocks(text string, image func(ref string) (string, error)) ([]models.Block, error) {
+    p := &markdownParser{image: image}
+    lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+
+    for i := 0; i < len(lines); i++ {
+        line := lines[i]
+        trimmed := strings.TrimSpace(line)
+
+        if fence := mdFence.FindStringSubmatch(line); fence != nil {
+            if err := p.flush(); err != nil {
+                return nil, err
+            }
+            var code []string
+            for i++; i < len(lines); i++ {
+                if strings.HasPrefix(strings.TrimSpace(lines[i]), fence[1]) {
+                    break
+                }
+                code = append(code, lines[i])
+            }
+            block := textBlock(strings.Join(code, "\n"), "code")
+            if fence[2] != "" {
+                block.Content["language"] = fence[2]
+            }
+            p.blocks = append(p.blocks, block)
+            continue
+        }
+
+        if opening, closing, ok := displayMath(trimmed); ok {
+            if err := p.flush(); err != nil {
+                return nil, err
+            }
+            latex := strings.TrimPrefix(trimmed, opening)
+            if len(trimmed) > len(opening) && strings.HasSuffix(latex, closing) {
+                latex = strings.TrimSuffix(latex, closing)
+            } else {
+                formula := []string{latex}
+                for i++; i < len(lines); i++ {
+                    current := strings.TrimSpace(lines[i])
+                    if strings.HasSuffix(current, closing) {
+                        formula = append(formula, strings.TrimSuffix(current, closing))
+                        break
+                    }
+                    formula = append(formula, current)
+                }
+                latex = strings.Join(formula, "\n")
+            }
+            if latex = strings.TrimSpace(latex); latex != "" {
+                p.blocks = append(p.blocks, formulaBlock(latex))
+            }
+            continue
+        }
+
+        switch {
+        case trimmed == "":
+            if err := p.flush(); err != nil {
+                return nil, err
+            }
+        case len(p.paragraph) > 0 && mdSetext.MatchString(line):
+            heading := markdownInline(strings.Join(p.paragraph, " "))
+            p.paragraph = nil
+            level := "h2"
+            if strings.HasPrefix(trimmed, "=") {
+                level = "h1"
+            }
+            p.blocks = append(p.blocks, textBlock(heading, level))
+        case mdRule.MatchString(line):
+            if err := p.flush(); err != nil {
+                return nil, err
+            }
+        case mdHeading.MatchString(line):
+            if err := p.flush(); err != nil {
+                return nil, err
+            }
+            heading := mdHeading.FindStringSubmatch(line)
+            p.blocks = append(p.blocks, textBlock(markdownInline(heading[2]), fmt.Sprintf("h%d", len(heading[1]))))
+        case mdListItem.MatchString(line):
+            if err := p.flushParagraph(); err != nil {
+                return nil, err
+            }
+            item := mdListItem.FindStringSubmatch(line)
+            marker := "•"
+            if item[2] != "-" && item[2] != "*" && item[2] != "+" {
+                marker = strings.TrimRight(item[2], ".)") + "."
+            }
+            indent := strings.Repeat("  ", len(strings.ReplaceAll(item[1], "\t", "    "))/2)
+            p.list = append(p.list, indent+marker+" "+markdownInline(item[3]))
+        case len(p.list) > 0 && line != trimmed:
+            // Продолжение пункта списка на следующей строке
+            p.list[len(p.list)-1] += " " + markdownInline(trimmed)
+        default:
+            if len(p.list) > 0 {
+                if err := p.flush(); err != nil {
+                    return nil, err
+                }
+            }
+            if strings.HasPrefix(trimmed, ">") {
+                trimmed = strings.TrimSpace(strings.TrimLeft(trimmed, ">"))
+            }
+            if strings.HasSuffix(line, "  ") || strings.HasSuffix(line, "\\") {
+                trimmed = strings.TrimSuffix(trimmed, "\\") + "\n"
+            }
+            p.paragraph = append(p.paragraph, trimmed)
+        }
+    }
+
+    if err := p.flush(); err != nil {
+        return nil, err
+    }
+    return p.blocks, nil
+}
+
+// displayMath распознает начало выносной формулы: $$ или \[
+func displayMath(line string) (string, string, bool) {
+    switch {
+    case strings.HasPrefix(line, "$$"):
+        return "$$", "$$", true
+    case strings.HasPrefix(line, `\[`):
+        return `\[`, `\]`, true
+    }
+    return "", "", false
+}
+
+// flush завершает накопленный список или абзац
+func (p *markdownParser) flush() error {
+    if len(p.list) > 0 {
+        p.blocks = append(p.blocks, textBlock(strings.Join(p.list, "\n"), "p"))
+        p.list = nil
+    }
+    return p.flushParagraph()
+}
+
+func (p *markdownParser) flushParagraph() error {
+    if len(p.paragraph) == 0 {
+        return nil
+    }
+
+    text := strings.TrimSpace(strings.ReplaceAll(strings.Join(p.paragraph, " "), "\n ", "\n"))
+    p.paragraph = nil
+
+    if formula := mdSoleMath.FindStringSubmatch(text); formula != nil {
+        p.blocks = append(p.blocks, formulaBlock(strings.TrimSpace(formula[1])))
+        return nil
+    }
+
+    // Картинки внутри абзаца разбивают его на текст и блоки изображений
+    last := 0
+    for _, match := range mdImage.FindAllStringSubmatchIndex(text, -1) {
+        p.addText(text[last:match[0]])
+        last = match[1]
+
+        url, err := p.image(text[match[4]:match[5]])
+        if err != nil {
+            return err
+        }
+        if url == "" {
+            continue
+        }
+        caption := ""
+        if match[6] >= 0 {
+            caption = text[match[6]:match[7]]
+        }
+        p.blocks = append(p.blocks, imageBlock(url, text[match[2]:match[3]], caption))
+    }
+    p.addText(text[last:])
+
+    return nil
+}
+
+func (p *markdownParser) addText(text string) {
+    if text = strings.TrimSpace(markdownInline(text)); text != "" {
+        p.blocks = append(p.blocks, textBlock(text, "p"))
+    }
+}
+
+// markdownInline убирает разметку выделения и ссылок, не трогая формулы внутри $...$
+func markdownInline(text string) string {
+    var result strings.Builder
+    last := 0
+    for _, match := range mdInlineMath.FindAllStringIndex(text, -1) {
+        result.WriteString(markdownPlain(text[last:match[0]]))
+        result.WriteString(text[match[0]:match[1]])
+        last = match[1]
+    }
+    result.WriteString(markdownPlain(text[last:]))
+    return strings.TrimSpace(result.String())
+}
+
+func markdownPlain(text string) string {
+    text = mdLink.ReplaceAllStringFunc(text, func(link string) string {
+        parts := mdLink.FindStringSubmatch(link)
+        if parts[1] == parts[2] {
+            return parts[2]
+        }
+        return parts[1] + " (" + parts[2] + ")"
+    })
+    text = mdAutoLink.ReplaceAllString(text, "$1")
+    text = mdEmphasis.ReplaceAllString(text, "$1$2$3$4$5")
+    return mdEscape.ReplaceAllString(text, "$1")
+}
@@ -0,0 +1,718 @@
+package services
+
+import (
+    "bytes"
+    "encoding/xml"
+    "fmt"
+    "io"
+    "path"
+    "strconv"
+    "strings"
+
+    "paydeya-backend/internal/models"
+)
+
+// Пространства имен Office Open XML, которые нужны для разбора DOCX
+const (
+    docxWordNS    = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+    docxMathNS    = "http://schemas.openxmlformats.org/officeDocument/2006/math"
+    docxRelNS     = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+    docxDrawingNS = "http://schemas.openxmlformats.org/drawingml/2006/main"
+    docxWPNS      = "http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing"
+    docxVMLNS     = "urn:schemas-microsoft-com:vml"
+    docxCompatNS  = "http://schemas.openxmlformats.org/markup-compatibility/2006"
+)
+
+// xmlNode - элемент XML-дерева документа
+type xmlNode struct {
+    Name     xml.Name
+    Attr     []xml.Attr
+    Children []*xmlNode
+    Text     string
+}
+
+func parseXMLTree(data []byte) (*xmlNode, error) {
+    decoder := xml.NewDecoder(bytes.NewReader(data))
+    root := &xmlNode{}
+    stack := []*xmlNode{root}
+
+    for {
+        token, err := decoder.Token()
+        if err == io.EOF {
+            break
+        }
+        if err != nil {
+            return nil, err
+        }
+
+        switch t := token.(type) {
+        case xml.StartElement:
+            node := &xmlNode{Name: t.Name, Attr: t.Attr}
+            parent := stack[len(stack)-1]
+            parent.Children = append(parent.Children, node)
+            stack = append(stack, node)
+        case xml.EndElement:
+            stack = stack[:len(stack)-1]
+        case xml.CharData:
+            stack[len(stack)-1].Text += string(t)
+        }
+    }
+
+    return root, nil
+}
+
+func (n *xmlNode) is(space, local string) bool {
+    return n.Name.Space == space && n.Name.Local == local
+}
+
+func (n *xmlNode) child(space, local string) *xmlNode {
+    if n == nil {
+        return nil
+    }
+    for _, child := range n.Children {
+        if child.is(space, local) {
+            return child
+        }
+    }
+    return nil
+}
+
+// find ищет первый вложенный элемент на любой глубине
+func (n *xmlNode) find(space, local string) *xmlNode {
+    if n == nil {
+        return nil
+    }
+    for _, child := range n.Children {
+        if child.is(space, local) {
+            return child
+        }
+        if found := child.find(space, local); found != nil {
+            return found
+        }
+    }
+    return nil
+}
+
+// attr возвращает значение атрибута; space пустой для атрибутов без префикса
+func (n *xmlNode) attr(space, local string) (string, bool) {
+    if n == nil {
+        return "", false
+    }
+    for _, attr := range n.Attr {
+        if attr.Name.Space == space && attr.Name.Local == local {
+            return attr.Value, true
+        }
+    }
+    return "", false
+}
+
+// plainText собирает текст абзацев внутри элемента, например ячейки таблицы
+func (n *xmlNode) plainText() string {
+    var parts []string
+    var collect func(node *xmlNode, text *strings.Builder)
+    collect = func(node *xmlNode, text *strings.Builder) {
+        for _, child := range node.Children {
+            switch {
+            case child.is(docxWordNS, "t"):
+                text.WriteString(child.Text)
+            case child.is(docxWordNS, "tab"):
+                text.WriteString(" ")
+            case child.is(docxWordNS, "p"):
+                var paragraph strings.Builder
+                collect(child, &paragraph)
+                if value := strings.TrimSpace(paragraph.String()); value != "" {
+                    parts = append(parts, value)
+                }
+            case child.is(docxWordNS, "del"):
+            default:
+                collect(child, text)
+            }
+        }
+    }
+
+    var text strings.Builder
+    collect(n, &text)
+    if value := strings.TrimSpace(text.String()); value != "" {
+        parts = append(parts, value)
+    }
+    return strings.Join(parts, " ")
+}
+
+// docxConverter превращает тело документа Word в блоки
+type docxConverter struct {
+    images    *documentImages
+    rels      map[string]string // ID связи -> путь картинки внутри архива
+    styles    map[string]string // ID стиля -> имя стиля в нижнем регистре
+    numFormat map[string]string // numId:ilvl -> формат нумерации
+    counters  map[string]int
+    blocks    []models.Block
+    list      []string
+    code      []string
+}
+
+// docxPiece - часть абзаца: текст, картинка или выносная формула
+type docxPiece struct {
+    text    string
+    image   string
+    alt     string
+    formula string
+}
+
+// docxBlocks разбирает DOCX: заголовки и абзацы по стилям Word, списки, таблицы,
+// встроенные картинки и формулы (OMML переводится в LaTeX)
+func docxBlocks(images *documentImages) ([]models.Block, error) {
+    data, err := readZipFile(images.files["word/document.xml"], maxDocumentSize)
+    if err != nil {
+        return nil, fmt.Errorf("invalid document")
+    }
+    document, err := parseXMLTree(data)
+    if err != nil {
+        return nil, fmt.Errorf("invalid document")
+    }
+    body := document.find(docxWordNS, "body")
+    if body == nil {
+        return nil, fmt.Errorf("invalid document")
+    }
+
+    c := &docxConverter{
+        images:    images,
+        rels:      make(map[string]string),
+        styles:    make(map[string]string),
+        numFormat: make(map[string]string),
+        counters:  make(map[string]int),
+    }
+    c.loadParts()
+
+    if err := c.convert(body); err != nil {
+        return nil, err
+    }
+    c.flush()
+
+    return c.blocks, nil
+}
+
+// loadParts читает связи, стили и нумерацию. Без них документ все равно разбирается,
+// только без картинок, заголовков по локализованным стилям и номеров списков
+func (c *docxConverter) loadParts() {
+    if rels := c.part("word/_rels/document.xml.rels"); rels != nil {
+        for _, rel := range rels.Children {
+            for _, item := range rel.Children {
+                id, _ := item.attr("", "Id")
+                target, _ := item.attr("", "Target")
+                if mode, _ := item.attr("", "TargetMode"); mode == "External" || id == "" {
+                    continue
+                }
+                if strings.HasPrefix(target, "/") {
+                    c.rels[id] = strings.TrimPrefix(target, "/")
+                } else {
+                    c.rels[id] = path.Join("word", target)
+                }
+            }
+        }
+    }
+
+    if styles := c.part("word/styles.xml"); styles != nil {
+        for _, root := range styles.Children {
+            for _, style := range root.Children {
+                id, _ := style.attr(docxWordNS, "styleId")
+                name, _ := style.child(docxWordNS, "name").attr(docxWordNS, "val")
+                if id != "" {
+                    c.styles[id] = strings.ToLower(name)
+                }
+            }
+        }
+    }
+
+    if numbering := c.part("word/numbering.xml"); numbering != nil {
+        abstract := make(map[string]map[string]string)
+        for _, root := range numbering.Children {
+            for _, item := range root.Children {
+                if item.is(docxWordNS, "abstractNum") {
+                    id, _ := item.attr(docxWordNS, "abstractNumId")
+                    levels := make(map[string]string)
+                    for _, level := range item.Children {
+                        if level.is(docxWordNS, "lvl") {
+                            ilvl, _ := level.attr(docxWordNS, "ilvl")
+                            levels[ilvl], _ = level.child(docxWordNS, "numFmt").attr(docxWordNS, "val")
+                        }
+                    }
+                    abstract[id] = levels
+                }
+            }
+            for _, item := range root.Children {
+                if item.is(docxWordNS, "num") {
+                    numID, _ := item.attr(docxWordNS, "numId")
+                    abstractID, _ := item.child(docxWordNS, "abstractNumId").attr(docxWordNS, "val")
+                    for ilvl, format := range abstract[abstractID] {
+                        c.numFormat[numID+":"+ilvl] = format
+                    }
+                }
+            }
+        }
+    }
+}
+
+func (c *docxConverter) part(name string) *xmlNode {
+    file, ok := c.images.files[name]
+    if !ok {
+        return nil
+    }
+    data, err := readZipFile(file, maxDocumentSize)
+    if err != nil {
+        return nil
+    }
+    root, err := parseXMLTree(data)
+    if err != nil {
+        return nil
+    }
+    return root
+}
+
+func (c *docxConverter) convert(body *xmlNode) error {
+    for _, node := range body.Children {
+        switch {
+        case node.is(docxWordNS, "p"):
+            if err := c.paragraph(node); err != nil {
+                return err
+            }
+        case node.is(docxWordNS, "tbl"):
+            c.flush()
+            c.table(node)
+        case node.is(docxWordNS, "sdt"):
+            if err := c.convert(node.child(docxWordNS, "sdtContent")); err != nil {
+                return err
+            }
+        case node.is(docxCompatNS, "AlternateContent"):
+            if err := c.convert(node.child(docxCompatNS, "Choice")); err != nil {
+                return err
+            }
+        }
+    }
+    return nil
+}
+
+func (c *docxConverter) paragraph(p *xmlNode) error {
+    props := p.child(docxWordNS, "pPr")
+    style := ""
+    if id, ok := props.child(docxWordNS, "pStyle").attr(docxWordNS, "val"); ok {
+        style = c.styles[id]
+        if style == "" {
+            style = strings.ToLower(id)
+        }
+    }
+
+    pieces, err := c.inline(p, nil, &strings.Builder{})
+    if err != nil {
+        return err
+    }
+
+    for _, piece := range pieces {
+        switch {
+        case piece.image != "":
+            c.flush()
+            c.blocks = append(c.blocks, imageBlock(piece.image, piece.alt, ""))
+        case piece.formula != "":
+            c.flush()
+            c.blocks = append(c.blocks, formulaBlock(piece.formula))
+        default:
+            if err := c.text(piece.text, style, props); err != nil {
+                return err
+            }
+        }
+    }
+    return nil
+}
+
+// inline обходит содержимое абзаца по порядку. Текст копится в text, картинки и выносные
+// формулы завершают накопленный кусок
+func (c *docxConverter) inline(node *xmlNode, pieces []docxPiece, text *strings.Builder) ([]docxPiece, error) {
+    flushText := func() {
+        if value := strings.TrimSpace(text.String()); value != "" {
+            pieces = append(pieces, docxPiece{text: value})
+        }
+        text.Reset()
+    }
+
+    for _, child := range node.Children {
+        var err error
+        switch {
+        case child.is(docxWordNS, "t"):
+            text.WriteString(child.Text)
+        case child.is(docxWordNS, "tab"):
+            text.WriteString(" ")
+        case child.is(docxWordNS, "br"), child.is(docxWordNS, "cr"):
+            text.WriteString("\n")
+        case child.is(docxWordNS, "drawing"), child.is(docxWordNS, "pict"):
+            url, alt, err := c.image(child)
+            if err != nil {
+                return nil, err
+            }
+            if url != "" {
+                flushText()
+                pieces = append(pieces, docxPiece{image: url, alt: alt})
+            }
+        case child.is(docxMathNS, "oMathPara"):
+            flushText()
+            var rows []string
+            for _, math := range child.Children {
+                if math.is(docxMathNS, "oMath") {
+                    rows = append(rows, strings.TrimSpace(ommlLatex(math)))
+                }
+            }
+            if latex := strings.Join(rows, ` \\ `); latex != "" {
+                pieces = append(pieces, docxPiece{formula: latex})
+            }
+        case child.is(docxMathNS, "oMath"):
+            if latex := strings.TrimSpace(ommlLatex(child)); latex != "" {
+                text.WriteString("$" + latex + "$")
+            }
+        case child.is(docxCompatNS, "AlternateContent"):
+            pieces, err = c.inline(child.child(docxCompatNS, "Choice"), pieces, text)
+        case child.is(docxWordNS, "del"), child.is(docxWordNS, "pPr"), child.is(docxWordNS, "rPr"),
+            child.is(docxWordNS, "instrText"):
+        case child.Name.Space == docxWordNS:
+            // Прогоны, ссылки, исправления и поля содержат текст глубже
+            pieces, err = c.inline(child, pieces, text)
+        }
+        if err != nil {
+            return nil, err
+        }
+    }
+
+    if node.is(docxWordNS, "p") {
+        flushText()
+    }
+    return pieces, nil
+}
+
+// image загружает картинку из w:drawing (DrawingML) или w:pict (VML)
+func (c *docxConverter) image(node *xmlNode) (string, string, error) {
+    id, ok := node.find(docxDrawingNS, "blip").attr(docxRelNS, "embed")
+    if !ok {
+        id, _ = node.find(docxVMLNS, "imagedata").attr(docxRelNS, "id")
+    }
+    target, ok := c.rels[id]
+    if !ok {
+        return "", "", nil
+    }
+
+    alt, _ := node.find(docxWPNS, "docPr").attr("", "descr")
+    url, _, err := c.images.archiveImage(target)
+    return url, alt, err
+}
+
+// text добавляет кусок текста абзаца с учетом стиля: заголовок, код, подпись к картинке или пункт списка
+func (c *docxConverter) text(text, style string, props *xmlNode) error {
+    switch {
+    case style == "title":
+        c.flush()
+        c.blocks = append(c.blocks, textBlock(text, "h1"))
+        return nil
+    case style == "subtitle":
+        c.flush()
+        c.blocks = append(c.blocks, textBlock(text, "h2"))
+        return nil
+    case strings.HasPrefix(style, "heading "):
+        c.flush()
+        level, err := strconv.Atoi(strings.TrimPrefix(style, "heading "))
+        if err != nil || level < 1 || level > 6 {
+            level = 6
+        }
+        c.blocks = append(c.blocks, textBlock(text, fmt.Sprintf("h%d", level)))
+        return nil
+    case strings.Contains(style, "code") || strings.Contains(style, "preformatted"):
+        if len(c.list) > 0 {
+            c.flush()
+        }
+        c.code = append(c.code, text)
+        return nil
+    case style == "caption" && len(c.list) == 0 && len(c.code) == 0 && len(c.blocks) > 0 && c.blocks[len(c.blocks)-1].Type == "image":
+        c.blocks[len(c.blocks)-1].Content["caption"] = text
+        return nil
+    }
+
+    if numbering := props.child(docxWordNS, "numPr"); numbering != nil {
+        if len(c.code) > 0 {
+            c.flush()
+        }
+        numID, _ := numbering.child(docxWordNS, "numId").attr(docxWordNS, "val")
+        ilvl, _ := numbering.child(docxWordNS, "ilvl").attr(docxWordNS, "val")
+        depth, _ := strconv.Atoi(ilvl)
+
+        marker := "•"
+        if format := c.numFormat[numID+":"+ilvl]; format != "" && format != "bullet" && format != "none" {
+            key := numID + ":" + ilvl
+            c.counters[key]++
+            marker = strconv.Itoa(c.counters[key]) + "."
+            // Более глубокие уровни начинают нумерацию заново
+            for level := depth + 1; level < 9; level++ {
+                delete(c.counters, numID+":"+strconv.Itoa(level))
+            }
+        }
+        c.list = append(c.list, strings.Repeat("  ", depth)+marker+" "+text)
+        return nil
+    }
+
+    c.flush()
+    if formula := mdSoleMath.FindStringSubmatch(text); formula != nil {
+        c.blocks = append(c.blocks, formulaBlock(strings.TrimSpace(formula[1])))
+        return nil
+    }
+    c.blocks = append(c.blocks, textBlock(text, "p"))
+    return nil
+}
+
+// table превращает таблицу в текст: строка таблицы - строка текста, ячейки через " | "
+func (c *docxConverter) table(table *xmlNode) {
+    var rows []string
+    for _, row := range table.Children {
+        if !row.is(docxWordNS, "tr") {
+            continue
+        }
+        var cells []string
+        for _, cell := range row.Children {
+            if cell.is(docxWordNS, "tc") {
+                cells = append(cells, cell.plainText())
+            }
+        }
+        if line := strings.TrimSpace(strings.Join(cells, " | ")); strings.Trim(line, "| ") != "" {
+            rows = append(rows, line)
+        }
+    }
+    if len(rows) > 0 {
+        c.blocks = append(c.blocks, textBlock(strings.Join(rows, "\n"), "p"))
+    }
+}
+
+// flush завершает накопленный список или фрагмент кода
+func (c *docxConverter) flush() {
+    if len(c.list) > 0 {
+        c.blocks = append(c.blocks, textBlock(strings.Join(c.list, "\n"), "p"))
+        c.list = nil
+        c.counters = make(map[string]int)
+    }
+    if len(c.code) > 0 {
+        c.blocks = append(c.blocks, textBlock(strings.Join(c.code, "\n"), "code"))
+        c.code = nil
+    }
+}
+
+// ommlLatex переводит формулу Word (Office Math Markup) в LaTeX
+func ommlLatex(node *xmlNode) string {
+    if node == nil || node.Name.Space != docxMathNS {
+        return ""
+    }
+
+    arg := func(local string) string {
+        return strings.TrimSpace(ommlChildren(node.child(docxMathNS, local)))
+    }
+    property := func(local, fallback string) string {
+        props := node.child(docxMathNS, node.Name.Local+"Pr")
+        value, ok := props.child(docxMathNS, local).attr(docxMathNS, "val")
+        if !ok {
+            return fallback
+        }
+        return value
+    }
+
+    switch node.Name.Local {
+    case "t":
+        return latexText(node.Text)
+    case "f":
+        return `\frac{` + arg("num") + "}{" + arg("den") + "}"
+    case "sSup":
+        return latexGroup(arg("e")) + "^{" + arg("sup") + "}"
+    case "sSub":
+        return latexGroup(arg("e")) + "_{" + arg("sub") + "}"
+    case "sSubSup":
+        return latexGroup(arg("e")) + "_{" + arg("sub") + "}^{" + arg("sup") + "}"
+    case "sPre":
+        return "{}_{" + arg("sub") + "}^{" + arg("sup") + "}" + latexGroup(arg("e"))
+    case "rad":
+        if degree := arg("deg"); degree != "" {
+            return `\sqrt[` + degree + "]{" + arg("e") + "}"
+        }
+        return `\sqrt{` + arg("e") + "}"
+    case "d":
+        var items []string
+        for _, child := range node.Children {
+            if child.is(docxMathNS, "e") {
+                items = append(items, strings.TrimSpace(ommlChildren(child)))
+            }
+        }
+        return `\left` + latexDelimiter(property("begChr", "(")) + strings.Join(items, latexText(property("sepChr", "|"))) +
+            `\right` + latexDelimiter(property("endChr", ")"))
+    case "nary":
+        operator, ok := naryOperators[property("chr", "∫")]
+        if !ok {
+            operator = latexText(property("chr", "∫"))
+        }
+        if sub := arg("sub"); sub != "" {
+            operator += "_{" + sub + "}"
+        }
+        if sup := arg("sup"); sup != "" {
+            operator += "^{" + sup + "}"
+        }
+        return operator + " " + latexGroup(arg("e"))
+    case "func":
+        name := arg("fName")
+        if latexFunctions[name] {
+            name = `\` + name
+        }
+        return name + " " + latexGroup(arg("e"))
+    case "limLow", "limUpp":
+        base := arg("e")
+        if latexFunctions[base] {
+            base = `\` + base
+        }
+        if node.Name.Local == "limLow" {
+            return base + "_{" + arg("lim") + "}"
+        }
+        return base + "^{" + arg("lim") + "}"
+    case "acc":
+        command, ok := accentCommands[property("chr", "̂")]
+        if !ok {
+            command = `\hat`
+        }
+        return command + "{" + arg("e") + "}"
+    case "bar":
+        if property("pos", "bot") == "top" {
+            return `\overline{` + arg("e") + "}"
+        }
+        return `\underline{` + arg("e") + "}"
+    case "groupChr":
+        switch property("chr", "⏟") {
+        case "⏟":
+            return `\underbrace{` + arg("e") + "}"
+        case "⏞":
+            return `\overbrace{` + arg("e") + "}"
+        }
+        return arg("e")
+    case "eqArr":
+        var rows []string
+        for _, child := range node.Children {
+            if child.is(docxMathNS, "e") {
+                rows = append(rows, strings.TrimSpace(ommlChildren(child)))
+            }
+        }
+        return `\begin{gathered}` + strings.Join(rows, ` \\ `) + `\end{gathered}`
+    case "m":
+        var rows []string
+        for _, row := range node.Children {
+            if !row.is(docxMathNS, "mr") {
+                continue
+            }
+            var cells []string
+            for _, cell := range row.Children {
+                if cell.is(docxMathNS, "e") {
+                    cells = append(cells, strings.TrimSpace(ommlChildren(cell)))
+                }
+            }
+            rows = append(rows, strings.Join(cells, " & "))
+        }
+        return `\begin{matrix}` + strings.Join(rows, ` \\ `) + `\end{matrix}`
+    }
+
+    // Свойства (*Pr) не выводятся, остальные контейнеры (e, num, r, box...) - содержимым
+    if strings.HasSuffix(node.Name.Local, "Pr") {
+        return ""
+    }
+    return ommlChildren(node)
+}
+
+func ommlChildren(node *xmlNode) string {
+    if node == nil {
+        return ""
+    }
+    var result strings.Builder
+    for _, child := range node.Children {
+        result.WriteString(ommlLatex(child))
+    }
+    return result.String()
+}
+
+func latexGroup(value string) string {
+    if len([]rune(value)) == 1 {
+        return value
+    }
+    return "{" + value + "}"
+}
+
+func latexDelimiter(value string) string {
+    switch value {
+    case "":
+        return "."
+    case "{":
+        return `\{`
+    case "}":
+        return `\}`
+    case "⟨", "〈":
+        return `\langle`
+    case "⟩", "〉":
+        return `\rangle`
+    case "‖":
+        return `\|`
+    case "⌊":
+        return `\lfloor`
+    case "⌋":
+        return `\rfloor`
+    case "⌈":
+        return `\lceil`
+    case "⌉":
+        return `\rceil`
+    }
+    return value
+}
+
+// latexText экранирует служебные символы LaTeX и заменяет символы Unicode командами
+func latexText(text string) string {
+    var result strings.Builder
+    for _, r := range text {
+        if command, ok := latexSymbols[r]; ok {
+            result.WriteString(command)
+            continue
+        }
+        switch r {
+        case '{', '}', '%', '#', '&', '$', '_':
+            result.WriteString(`\` + string(r))
+        case '\\':
+            result.WriteString(`\backslash `)
+        default:
+            result.WriteRune(r)
+        }
+    }
+    return result.String()
+}
+
+var latexSymbols = map[rune]string{
+    'α': `\alpha `, 'β': `\beta `, 'γ': `\gamma `, 'δ': `\delta `, 'ε': `\varepsilon `, 'ζ': `\zeta `,
+    'η': `\eta `, 'θ': `\theta `, 'ι': `\iota `, 'κ': `\kappa `, 'λ': `\lambda `, 'μ': `\mu `,
+    'ν': `\nu `, 'ξ': `\xi `, 'π': `\pi `, 'ρ': `\rho `, 'σ': `\sigma `, 'τ': `\tau `,
+    'υ': `\upsilon `, 'φ': `\varphi `, 'χ': `\chi `, 'ψ': `\psi `, 'ω': `\omega `,
+    'Γ': `\Gamma `, 'Δ': `\Delta `, 'Θ': `\Theta `, 'Λ': `\Lambda `, 'Ξ': `\Xi `, 'Π': `\Pi `,
+    'Σ': `\Sigma `, 'Φ': `\Phi `, 'Ψ': `\Psi `, 'Ω': `\Omega `,
+    '≤': `\le `, '≥': `\ge `, '≠': `\ne `, '≈': `\approx `, '≡': `\equiv `, '±': `\pm `, '∓': `\mp `,
+    '×': `\times `, '÷': `\div `, '·': `\cdot `, '⋅': `\cdot `, '∞': `\infty `, '∂': `\partial `,
+    '∇': `\nabla `, '∈': `\in `, '∉': `\notin `, '⊂': `\subset `, '⊆': `\subseteq `, '∪': `\cup `,
+    '∩': `\cap `, '∅': `\varnothing `, '∀': `\forall `, '∃': `\exists `, '¬': `\neg `,
+    '→': `\to `, '←': `\leftarrow `, '↔': `\leftrightarrow `, '⇒': `\Rightarrow `, '⇔': `\Leftrightarrow `,
+    '∠': `\angle `, '°': `^{\circ}`, '′': `'`, '…': `\ldots `, '⋯': `\cdots `, '∥': `\parallel `, '⊥': `\perp `,
+    '−': `-`,
+}
+
+var naryOperators = map[string]string{
+    "∑": `\sum`, "∏": `\prod`, "∐": `\coprod`, "∫": `\int`, "∬": `\iint`, "∭": `\iiint`,
+    "∮": `\oint`, "⋃": `\bigcup`, "⋂": `\bigcap`,
+}
+
+var accentCommands = map[string]string{
+    "̂": `\hat`, "̃": `\tilde`, "̄": `\bar`, "̅": `\bar`, "̇": `\dot`,
+    "̈": `\ddot`, "⃗": `\vec`, "→": `\vec`, "̌": `\check`, "̆": `\breve`,
+}
+
+var latexFunctions = map[string]bool{
+    "sin": true, "cos": true, "tan": true, "tg": true, "cot": true, "ctg": true, "sec": true, "csc": true,
+    "arcsin": true, "arccos": true, "arctan": true, "sinh": true, "cosh": true, "tanh": true,
+    "log": true, "lg": true, "ln": true, "exp": true, "lim": true, "max": true, "min": true,
+    "sup": true, "inf": true, "det": true, "gcd": true, "deg": true,
+}
@@ -0,0 +1,692 @@
+package services
+
+import (
+    "archive/zip"
+    "bytes"
+    "context"
+    "encoding/json"
+    "encoding/xml"
+    "fmt"
+    "io"
+    "log"
+    "path"
+    "sort"
+    "strings"
+    "sync"
+    "text/template"
+    "time"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/repositories"
+)
+
+// materialBundleVersion - текущая версия схемы material.json
+const materialBundleVersion = 1
+
+// bundleUpgrades переводят material.json из версии схемы в следующую. При изменении формата
+// увеличиваем materialBundleVersion и добавляем шаг для предыдущей версии - старые пакеты
+// продолжают импортироваться
+var bundleUpgrades = map[int]func(bundle map[string]interface{}) error{}
+
+// ExportConfig - настройки экспорта
+type ExportConfig struct {
+    FrontendURL string // адрес фронтенда для ссылки на материал в печатной версии
+    FontDir     string // каталог со шрифтами DejaVu для PDF
+}
+
+type ExportService struct {
+    materialService *MaterialService
+    materialRepo    *repositories.MaterialRepository
+    fileService     *FileService
+    formulaService  *FormulaService
+    config          ExportConfig
+
+    // Шрифты PDF загружаются при первом экспорте
+    fontsOnce sync.Once
+    fonts     *pdfFontSet
+    fontsErr  error
+}
+
+func NewExportService(materialService *MaterialService, materialRepo *repositories.MaterialRepository, fileService *FileService, formulaService *FormulaService, config ExportConfig) *ExportService {
+    config.FrontendURL = strings.TrimRight(config.FrontendURL, "/")
+
+    return &ExportService{
+        materialService: materialService,
+        materialRepo:    materialRepo,
+        fileService:     fileService,
+        formulaService:  formulaService,
+        config:          config,
+    }
+}
+
+// GetExportMaterial возвращает материал с блоками, если пользователь может его выгрузить:
+// свои и соавторские материалы - в любом статусе, чужие - опубликованные открытые
+func (s *ExportService) GetExportMaterial(ctx context.Context, userID, materialID int) (*models.Material, error) {
+    material, err := s.materialService.GetMaterial(ctx, userID, materialID)
+    if err != nil {
+        return nil, err
+    }
+    if material == nil {
+        return nil, fmt.Errorf("material not found")
+    }
+
+    role, err := s.materialService.GetMaterialRole(ctx, userID, material)
+    if err != nil {
+        return nil, err
+    }
+    if role == "" && material.Access != "open" {
+        return nil, fmt.Errorf("access denied")
+    }
+
+    return material, nil
+}
+
+// WriteSCORM пишет SCORM-пакет материала (version: "1.2" или "2004") в виде ZIP-архива
+func (s *ExportService) WriteSCORM(ctx context.Context, material *models.Material, version string, w io.Writer) error {
+    archive := zip.NewWriter(w)
+
+    media, mediaFiles, err := s.bundleMedia(ctx, archive, material)
+    if err != nil {
+        return err
+    }
+    files := append([]string{"index.html", "scorm.js"}, mediaFiles...)
+
+    page := buildMaterialPage(material, func(url string) string {
+        if file, ok := media[url]; ok {
+            return file
+        }
+        return url
+    })
+    page.Scripts = []string{"scorm.js"}
+
+    var html bytes.Buffer
+    if err := renderMaterialHTML(&html, page); err != nil {
+        return err
+    }
+    if err := writeZipFile(archive, "index.html", html.Bytes()); err != nil {
+        return err
+    }
+    if err := writeZipFile(archive, "scorm.js", []byte(scormRuntime)); err != nil {
+        return err
+    }
+
+    manifestTemplate := scormManifest12
+    if version == "2004" {
+        manifestTemplate = scormManifest2004
+    }
+
+    var manifest bytes.Buffer
+    err = manifestTemplate.Execute(&manifest, map[string]interface{}{
+        "Identifier": fmt.Sprintf("paydeya-material-%d", material.ID),
+        "Title":      material.Title,
+        "Files":      files,
+    })
+    if err != nil {
+        return err
+    }
+    if err := writeZipFile(archive, "imsmanifest.xml", manifest.Bytes()); err != nil {
+        return err
+    }
+
+    return archive.Close()
+}
+
+// bundleMedia кладет загруженные файлы материала в архив и возвращает их пути внутри архива
+// по исходным ссылкам. Недоступный файл пропускается - на странице останется исходная ссылка
+func (s *ExportService) bundleMedia(ctx context.Context, archive *zip.Writer, material *models.Material) (map[string]string, []string, error) {
+    media := make(map[string]string)
+    var files []string
+
+    for i, url := range s.materialService.collectMediaURLs(material) {
+        file, err := s.fileService.OpenMedia(ctx, url)
+        if err != nil {
+            log.Printf("⚠️ Export: failed to open media %s: %v", url, err)
+            continue
+        }
+
+        dir := "images"
+        if strings.Contains(url, "/videos/") {
+            dir = "videos"
+        }
+
+        name := fmt.Sprintf("media/%s/%d%s", dir, i+1, strings.ToLower(path.Ext(url)))
+        entry, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store})
+        if err != nil {
+            file.Close()
+            return nil, nil, err
+        }
+
+        _, err = io.Copy(entry, file)
+        file.Close()
+        if err != nil {
+            return nil, nil, err
+        }
+
+        media[url] = name
+        files = append(files, name)
+    }
+
+    return media, files, nil
+}
+
+// WriteBundle пишет переносимый пакет материала: material.json и загруженные файлы в ZIP-архиве
+func (s *ExportService) WriteBundle(ctx context.Context, material *models.Material, w io.Writer) error {
+    archive := zip.NewWriter(w)
+
+    media, _, err := s.bundleMedia(ctx, archive, material)
+    if err != nil {
+        return err
+    }
+
+    data, err := json.MarshalIndent(newMaterialBundle(material, media), "", "  ")
+    if err != nil {
+        return err
+    }
+    if err := writeZipFile(archive, "material.json", data); err != nil {
+        return err
+    }
+
+    return archive.Close()
+}
+
+// BuildBundle возвращает пакет материала без файлов: ссылки на них остаются исходными
+func (s *ExportService) BuildBundle(material *models.Material) *models.MaterialBundle {
+    return newMaterialBundle(material, nil)
+}
+
+func newMaterialBundle(material *models.Material, media map[string]string) *models.MaterialBundle {
+    rewrite := func(value string) string {
+        if file, ok := media[value]; ok {
+            return file
+        }
+        return value
+    }
+
+    bundle := &models.MaterialBundle{
+        SchemaVersion: materialBundleVersion,
+        ExportedAt:    time.Now().UTC(),
+        Material: models.BundleMaterial{
+            Title:       material.Title,
+            Subject:     material.Subject,
+            Description: material.Description,
+            Level:       material.Level,
+            Tags:        material.Tags,
+            CoverURL:    rewrite(material.CoverURL),
+            Language:    material.Language,
+            Duration:    material.Duration,
+            Blocks:      rewriteBlocks(material.Blocks, rewrite),
+        },
+    }
+
+    for url, file := range media {
+        bundle.Media = append(bundle.Media, models.BundleMedia{Path: file, OriginalURL: url})
+    }
+    sort.Slice(bundle.Media, func(i, j int) bool { return bundle.Media[i].Path < bundle.Media[j].Path })
+
+    return bundle
+}
+
+// ImportBundle создает у пользователя черновик материала из пакета - ZIP-архива с material.json
+// или отдельного JSON. Файлы из архива загружаются заново, ссылки на них в блоках подменяются
+func (s *ExportService) ImportBundle(ctx context.Context, userID int, r io.ReaderAt, size int64) (*models.Material, error) {
+    files := make(map[string]*zip.File)
+    var data []byte
+
+    header := make([]byte, 4)
+    if _, err := r.ReadAt(header, 0); err == nil && bytes.Equal(header, []byte("PK\x03\x04")) {
+        archive, err := zip.NewReader(r, size)
+        if err != nil {
+            return nil, fmt.Errorf("invalid bundle")
+        }
+        for _, file := range archive.File {
+            files[file.Name] = file
+        }
+
+        manifest, ok := files["material.json"]
+        if !ok {
+            return nil, fmt.Errorf("invalid bundle")
+        }
+        if data, err = readZipFile(manifest, 10<<20); err != nil {
+            return nil, fmt.Errorf("invalid bundle")
+        }
+    } else {
+        var err error
+        if data, err = io.ReadAll(io.NewSectionReader(r, 0, size)); err != nil {
+            return nil, err
+        }
+    }
+
+    bundle, err := decodeBundle(data)
+    if err != nil {
+        return nil, err
+    }
+
+    source := bundle.Material
+    if strings.TrimSpace(source.Title) == "" {
+        return nil, fmt.Errorf("invalid bundle")
+    }
+    exists, err := s.materialRepo.SubjectExists(ctx, source.Subject)
+    if err != nil {
+        return nil, err
+    }
+    if !exists {
+        return nil, fmt.Errorf("unknown subject")
+    }
+    for _, block := range source.Blocks {
+        if !blockTypes[block.Type] {
+            return nil, fmt.Errorf("unsupported block type")
+        }
+    }
+
+    // Загружаем файлы; если импорт сорвется, загруженное удаляем
+    uploaded := make(map[string]string)
+    imported := false
+    defer func() {
+        if imported {
+            return
+        }
+        for _, url := range uploaded {
+            if err := s.fileService.DeleteMedia(context.Background(), url); err != nil {
+                log.Printf("⚠️ Import: failed to delete media %s: %v", url, err)
+            }
+        }
+    }()
+
+    for _, media := range bundle.Media {
+        file, ok := files[media.Path]
+        if !ok {
+            // Файла нет в архиве (пакет в виде JSON) - оставляем исходную ссылку
+            uploaded[media.Path] = media.OriginalURL
+            continue
+        }
+
+        url, err := s.uploadBundleMedia(ctx, userID, file)
+        if err != nil {
+            return nil, err
+        }
+        uploaded[media.Path] = url
+    }
+
+    rewrite := func(value string) string {
+        if url, ok := uploaded[value]; ok {
+            return url
+        }
+        return value
+    }
+
+    blocks := rewriteBlocks(source.Blocks, rewrite)
+    sort.SliceStable(blocks, func(i, j int) bool { return blocks[i].Position < blocks[j].Position })
+    for i := range blocks {
+        blocks[i].ID = newBlockID()
+        blocks[i].Position = i
+    }
+
+    material := &models.Material{
+        Title:       source.Title,
+        Subject:     source.Subject,
+        Description: source.Description,
+        Level:       source.Level,
+        Tags:        normalizeTags(source.Tags),
+        CoverURL:    rewrite(source.CoverURL),
+        Language:    source.Language,
+        Duration:    source.Duration,
+        AuthorID:    userID,
+        Status:      "draft",
+        Access:      "open",
+        Blocks:      blocks,
+    }
+    if material.Language == "" {
+        material.Language = "ru"
+    }
+
+    if err := normalizeBlocks(blocks); err != nil {
+        return nil, fmt.Errorf("invalid bundle")
+    }
+    // Импорт создает черновик: неверные формулы не мешают импорту, ошибки автор увидит при сохранении.
+    // Неверные шаги анимаций отбрасываются
+    _ = normalizeBlockAnimations(blocks)
+    _ = s.formulaService.PrepareBlocks(blocks)
+
+    if err := s.materialRepo.CreateMaterialWithBlocks(ctx, material, blocks); err != nil {
+        return nil, fmt.Errorf("failed to import material: %w", err)
+    }
+
+    imported = true
+    return material, nil
+}
+
+// decodeBundle разбирает material.json и доводит его до текущей версии схемы
+func decodeBundle(data []byte) (*models.MaterialBundle, error) {
+    var raw map[string]interface{}
+    if err := json.Unmarshal(data, &raw); err != nil {
+        return nil, fmt.Errorf("invalid bundle")
+    }
+
+    version, ok := raw["schemaVersion"].(float64)
+    if !ok || version < 1 || int(version) > materialBundleVersion {
+        return nil, fmt.Errorf("unsupported bundle version")
+    }
+
+    for v := int(version); v < materialBundleVersion; v++ {
+        if err := bundleUpgrades[v](raw); err != nil {
+            return nil, fmt.Errorf("invalid bundle")
+        }
+        raw["schemaVersion"] = v + 1
+    }
+
+    upgraded, err := json.Marshal(raw)
+    if err != nil {
+        return nil, err
+    }
+
+    var bundle models.MaterialBundle
+    if err := json.Unmarshal(upgraded, &bundle); err != nil {
+        return nil, fmt.Errorf("invalid bundle")
+    }
+
+    return &bundle, nil
+}
+
+// uploadBundleMedia загружает файл из архива через FileService и возвращает новую ссылку
+func (s *ExportService) uploadBundleMedia(ctx context.Context, userID int, file *zip.File) (string, error) {
+    reader, err := file.Open()
+    if err != nil {
+        return "", fmt.Errorf("invalid bundle")
+    }
+    defer reader.Close()
+
+    var result *UploadResult
+    if strings.HasPrefix(file.Name, "media/videos/") {
+        result, err = s.fileService.UploadVideo(ctx, reader, path.Base(file.Name), userID, int64(file.UncompressedSize64))
+    } else {
+        result, err = s.fileService.UploadImage(ctx, reader, path.Base(file.Name), userID)
+    }
+    if err != nil {
+        return "", err
+    }
+
+    return result.URL, nil
+}
+
+// rewriteBlocks возвращает копии блоков с подмененными строками в содержимом
+func rewriteBlocks(blocks []models.Block, rewrite func(string) string) []models.Block {
+    result := make([]models.Block, len(blocks))
+    for i, block := range blocks {
+        if block.Content != nil {
+            block.Content = rewriteStrings(block.Content, rewrite).(map[string]interface{})
+        }
+        result[i] = block
+    }
+    return result
+}
+
+func rewriteStrings(value interface{}, rewrite func(string) string) interface{} {
+    switch v := value.(type) {
+    case string:
+        return rewrite(v)
+    case map[string]interface{}:
+        result := make(map[string]interface{}, len(v))
+        for key, item := range v {
+            result[key] = rewriteStrings(item, rewrite)
+        }
+        return result
+    case []interface{}:
+        result := make([]interface{}, len(v))
+        for i, item := range v {
+            result[i] = rewriteStrings(item, rewrite)
+        }
+        return result
+    }
+    return value
+}
+
+func readZipFile(file *zip.File, limit int64) ([]byte, error) {
+    reader, err := file.Open()
+    if err != nil {
+        return nil, err
+    }
+    defer reader.Close()
+
+    data, err := io.ReadAll(io.LimitReader(reader, limit+1))
+    if err != nil {
+        return nil, err
+    }
+    if int64(len(data)) > limit {
+        return nil, fmt.Errorf("file too large")
+    }
+    return data, nil
+}
+
+func writeZipFile(archive *zip.Writer, name string, data []byte) error {
+    entry, err := archive.Create(name)
+    if err != nil {
+        return err
+    }
+    _, err = entry.Write(data)
+    return err
+}
+
+func xmlEscape(value string) string {
+    var buf bytes.Buffer
+    xml.EscapeText(&buf, []byte(value))
+    return buf.String()
+}
+
+var scormManifest12 = template.Must(template.New("manifest12").Funcs(template.FuncMap{"xml": xmlEscape}).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<manifest identifier="{{.Identifier}}" version="1.0"
+    xmlns="http://www.imsproject.org/xsd/imscp_rootv1p1p2"
+    xmlns:adlcp="http://www.adlnet.org/xsd/adlcp_rootv1p2"
+    xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"
+    xsi:schemaLocation="http://www.imsproject.org/xsd/imscp_rootv1p1p2 imscp_rootv1p1p2.xsd http://www.imsglobal.org/xsd/imsmd_rootv1p2p1 imsmd_rootv1p2p1.xsd http://www.adlnet.org/xsd/adlcp_rootv1p2 adlcp_rootv1p2.xsd">
+    <metadata>
+        <schema>ADL SCORM</schema>
+        <schemaversion>1.2</schemaversion>
+    </metadata>
+    <organizations default="organization">
+        <organization identifier="organization">
+            <title>{{xml .Title}}</title>
+            <item identifier="item" identifierref="resource">
+                <title>{{xml .Title}}</title>
+            </item>
+        </organization>
+    </organizations>
+    <resources>
+        <resource identifier="resource" type="webcontent" adlcp:scormtype="sco" href="index.html">
+{{- range .Files}}
+            <file href="{{xml .}}"/>
+{{- end}}
+        </resource>
+    </resources>
+</manifest>
+`))
+
+var scormManifest2004 = template.Must(template.New("manifest2004").Funcs(template.FuncMap{"xml": xmlEscape}).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<manifest identifier="{{.Identifier}}" version="1.0"
+    xmlns="http://www.imsglobal.org/xsd/imscp_v1p1"
+    xmlns:adlcp="http://www.adlnet.org/xsd/adlcp_v1p3"
+    xmlns:adlseq="http://www.adlnet.org/xsd/adlseq_v1p3"
+    xmlns:adlnav="http://www.adlnet.org/xsd/adlnav_v1p3"
+    xmlns:imsss="http://www.imsglobal.org/xsd/imsss"
+    xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"
+    xsi:schemaLocation="http://www.imsglobal.org/xsd/imscp_v1p1 imscp_v1p1.xsd http://www.adlnet.org/xsd/adlcp_v1p3 adlcp_v1p3.xsd http://www.adlnet.org/xsd/adlseq_v1p3 adlseq_v1p3.xsd http://www.adlnet.org/xsd/adlnav_v1p3 adlnav_v1p3.xsd http://www.imsglobal.org/xsd/imsss imsss_v1p0.xsd">
+    <metadata>
+        <schema>ADL SCORM</schema>
+        <schemaversion>2004 4th Edition</schemaversion>
+    </metadata>
+    <organizations default="organization">
+        <organization identifier="organization">
+            <title>{{xml .Title}}</title>
+            <item identifier="item" identifierref="resource">
+                <title>{{xml .Title}}</title>
+            </item>
+        </organization>
+    </organizations>
+    <resources>
+        <resource identifier="resource" type="webcontent" adlcp:scormType="sco" href="index.html">
+{{- range .Files}}
+            <file href="{{xml .}}"/>
+{{- end}}
+        </resource>
+    </resources>
+</manifest>
+`))
+
+// scormRuntime находит API LMS (API_1484_11 для SCORM 2004, API для SCORM 1.2),
+// проверяет тесты на странице и сообщает завершение, балл и время сессии
+const scormRuntime = `(function () {
+    "use strict";
+
+    function findAPI(win, name) {
+        for (var i = 0; win && i < 10; i++) {
+            if (win[name]) {
+                return win[name];
+            }
+            if (!win.parent || win.parent === win) {
+                break;
+            }
+            win = win.parent;
+        }
+        return null;
+    }
+
+    function locateAPI(name) {
+        return findAPI(window, name) || (window.opener ? findAPI(window.opener, name) : null);
+    }
+
+    var api2004 = locateAPI("API_1484_11");
+    var api12 = api2004 ? null : locateAPI("API");
+    var startedAt = new Date();
+    var terminated = false;
+
+    function get(key12, key2004) {
+        if (api2004) {
+            return api2004.GetValue(key2004);
+        }
+        if (api12) {
+            return api12.LMSGetValue(key12);
+        }
+        return "";
+    }
+
+    function set(key12, key2004, value) {
+        if (api2004 && key2004) {
+            api2004.SetValue(key2004, String(value));
+        } else if (api12 && key12) {
+            api12.LMSSetValue(key12, String(value));
+        }
+    }
+
+    function commit() {
+        if (api2004) {
+            api2004.Commit("");
+        } else if (api12) {
+            api12.LMSCommit("");
+        }
+    }
+
+    function pad(value) {
+        return (value < 10 ? "0" : "") + value;
+    }
+
+    function sessionTime() {
+        var seconds = Math.round((new Date() - startedAt) / 1000);
+        if (api2004) {
+            set(null, "cmi.session_time", "PT" + seconds + "S");
+        } else {
+            var hours = Math.floor(seconds / 3600);
+            var minutes = Math.floor(seconds % 3600 / 60);
+            set("cmi.core.session_time", null, pad(hours) + ":" + pad(minutes) + ":" + pad(seconds % 60));
+        }
+    }
+
+    function initialize() {
+        if (api2004) {
+            api2004.Initialize("");
+            if (get(null, "cmi.completion_status") !== "completed") {
+                set(null, "cmi.completion_status", "incomplete");
+            }
+        } else if (api12) {
+            api12.LMSInitialize("");
+            var status = get("cmi.core.lesson_status");
+            if (status === "" || status === "not attempted") {
+                set("cmi.core.lesson_status", null, "incomplete");
+            }
+        }
+    }
+
+    function terminate() {
+        if (terminated || (!api2004 && !api12)) {
+            return;
+        }
+        terminated = true;
+        sessionTime();
+        commit();
+        if (api2004) {
+            api2004.Terminate("");
+        } else {
+            api12.LMSFinish("");
+        }
+    }
+
+    // Проходной балл задается в LMS, по умолчанию - 60%
+    function passingScore() {
+        var value = api2004 ? parseFloat(get(null, "cmi.scaled_passing_score")) : parseFloat(get("cmi.student_data.mastery_score", null)) / 100;
+        return isNaN(value) ? 0.6 : value;
+    }
+
+    function checkQuizzes() {
+        var quizzes = document.querySelectorAll(".quiz");
+        var correct = 0;
+
+        for (var i = 0; i < quizzes.length; i++) {
+            var quiz = quizzes[i];
+            var expected = quiz.getAttribute("data-correct").split(",").filter(Boolean).sort().join(",");
+            var inputs = quiz.querySelectorAll("input:checked");
+            var chosen = [];
+            for (var j = 0; j < inputs.length; j++) {
+                chosen.push(inputs[j].value);
+            }
+
+            var ok = expected !== "" && chosen.sort().join(",") === expected;
+            quiz.className = "quiz " + (ok ? "correct" : "incorrect");
+            if (ok) {
+                correct++;
+            }
+        }
+
+        return { total: quizzes.length, correct: correct };
+    }
+
+    function finish() {
+        var result = checkQuizzes();
+        var message = "Материал завершен";
+
+        if (result.total > 0) {
+            var scaled = result.correct / result.total;
+            var raw = Math.round(scaled * 100);
+            var passed = scaled >= passingScore();
+
+            set("cmi.core.score.min", "cmi.score.min", 0);
+            set("cmi.core.score.max", "cmi.score.max", 100);
+            set("cmi.core.score.raw", "cmi.score.raw", raw);
+            set(null, "cmi.score.scaled", scaled.toFixed(2));
+            set("cmi.core.lesson_status", "cmi.success_status", passed ? "passed" : "failed");
+
+            message = "Правильных ответов: " + result.correct + " из " + result.total;
+        } else {
+            set("cmi.core.lesson_status", null, "completed");
+        }
+        set(null, "cmi.completion_status", "completed");
+        commit();
+
+        document.getElementById("result").textContent = message;
+    }
+
+    window.addEventListener("load", function () {
+        initialize();
+        document.getElementById("finish").addEventListener("click", finish);
+    });
+    window.addEventListener("pagehide", terminate);
+    window.addEventListener("beforeunload", terminate);
+})();
+`
@@ -202,4 +202,58 @@ func (s *FileService) DeleteAvatar(avatarURL string) error {
     // Убираем первый слэш для создания правильного пути
     filePath := strings.TrimPrefix(avatarURL, "/")
     return os.Remove(filePath)
-}
\ No newline at end of file
+}
+// IsManagedMedia проверяет, что URL указывает на загруженный через сервис файл материалов
+func (s *FileService) IsManagedMedia(url string) bool {
+    if s.storageService != nil {
+        if key, ok := s.storageService.KeyFromURL(url); ok {
+            return strings.HasPrefix(key, "images/") || strings.HasPrefix(key, "videos/")
+        }
+    }
+    return strings.HasPrefix(url, "/uploads/images/") || strings.HasPrefix(url, "/uploads/videos/")
+}
+
+// OpenMedia открывает загруженный файл материалов из облачного или локального хранилища
+func (s *FileService) OpenMedia(ctx context.Context, url string) (io.ReadCloser, error) {
+    if !s.IsManagedMedia(url) {
+        return nil, fmt.Errorf("not managed media")
+    }
+
+    if s.storageService != nil {
+        if key, ok := s.storageService.KeyFromURL(url); ok {
+            return s.storageService.OpenFile(ctx, key)
+        }
+    }
+
+    relPath := filepath.Clean(strings.TrimPrefix(url, "/uploads/"))
+    if strings.HasPrefix(relPath, "..") {
+        return nil, fmt.Errorf("invalid media path")
+    }
+
+    return os.Open(filepath.Join(s.uploadPath, relPath))
+}
+
+// DeleteMedia удаляет загруженный файл материалов из облачного или локального хранилища
+func (s *FileService) DeleteMedia(ctx context.Context, url string) error {
+    if !s.IsManagedMedia(url) {
+        return nil
+    }
+
+    if s.storageService != nil {
+        if key, ok := s.storageService.KeyFromURL(url); ok {
+            return s.storageService.DeleteFile(ctx, key)
+        }
+    }
+
+    // Локальный файл: путь внутри uploadPath без выхода за его пределы
+    relPath := filepath.Clean(strings.TrimPrefix(url, "/uploads/"))
+    if strings.HasPrefix(relPath, "..") {
+        return fmt.Errorf("invalid media path")
+    }
+
+    err := os.Remove(filepath.Join(s.uploadPath, relPath))
+    if os.IsNotExist(err) {
+        return nil
+    }
+    return err
+}
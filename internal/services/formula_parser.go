@@ -0,0 +1,511 @@
+package services
+
+import (
+    "fmt"
+    "strings"
+    "unicode"
+
+    "paydeya-backend/internal/models"
+)
+
+// mathNode - узел разобранной формулы
+type mathNode struct {
+    kind     string // row, ident, number, op, text, func, frac, sqrt, scripts, fenced, accent, style, table, space
+    text     string
+    children []*mathNode // row - элементы; frac - числитель и знаменатель; sqrt - подкоренное и степень;
+    // scripts - основание, нижний и верхний индексы; fenced, accent, style - содержимое
+    rows    [][]*mathNode // ячейки таблицы
+    open    string        // левая скобка fenced и окружений-матриц
+    close   string
+    variant string  // style: normal, bold, double-struck, script
+    under   bool    // accent: знак под основанием
+    width   float64 // space: ширина в em
+    large   bool    // op: большой оператор (сумма, интеграл)
+    limits  bool    // индексы большого оператора над и под ним
+    align   string  // table: выравнивание столбцов
+}
+
+// Ограничение вложенности, чтобы злонамеренная формула не переполнила стек
+const maxFormulaDepth = 64
+
+// latexParser разбирает LaTeX в дерево и собирает ошибки с позициями
+type latexParser struct {
+    src    []rune
+    pos    int
+    depth  int
+    errors []models.FormulaError
+}
+
+// parseLatex разбирает формулу. Ошибки не останавливают разбор, чтобы сообщить обо всех сразу
+func parseLatex(latex string) (*mathNode, []models.FormulaError) {
+    p := &latexParser{src: []rune(latex)}
+    root := p.expression("")
+    for p.pos < len(p.src) {
+        // expression вернулся на закрывающем токене без пары
+        switch {
+        case p.src[p.pos] == '}':
+            p.fail(p.pos, "unexpected '}'")
+            p.pos++
+        case p.src[p.pos] == '&':
+            p.fail(p.pos, "unexpected '&' outside of environment")
+            p.pos++
+        case p.peekCommand("right"):
+            p.fail(p.pos, `\right without \left`)
+            p.pos += len("right") + 1
+        case p.peekCommand("end"):
+            p.fail(p.pos, `\end without \begin`)
+            p.pos += len("end") + 1
+            p.groupText()
+        default:
+            p.pos++
+        }
+        root.children = append(root.children, p.expression("").children...)
+    }
+    return root, p.errors
+}
+
+func (p *latexParser) fail(pos int, format string, args ...interface{}) {
+    if len(p.errors) < 20 {
+        p.errors = append(p.errors, models.FormulaError{Position: pos, Message: fmt.Sprintf(format, args...)})
+    }
+}
+
+func (p *latexParser) skipSpaces() {
+    for p.pos < len(p.src) && unicode.IsSpace(p.src[p.pos]) {
+        p.pos++
+    }
+}
+
+// peekCommand проверяет, что с текущей позиции идет команда name
+func (p *latexParser) peekCommand(name string) bool {
+    if p.pos >= len(p.src) || p.src[p.pos] != '\\' {
+        return false
+    }
+    end := p.pos + 1 + len(name)
+    if end > len(p.src) || string(p.src[p.pos+1:end]) != name {
+        return false
+    }
+    return end == len(p.src) || !isASCIILetter(p.src[end])
+}
+
+func isASCIILetter(r rune) bool {
+    return r < unicode.MaxASCII && unicode.IsLetter(r)
+}
+
+// expression читает последовательность атомов до закрывающего токена контекста:
+// "}" - группа, "]" - необязательный аргумент, "right" - содержимое \left, "table" - ячейка окружения
+func (p *latexParser) expression(context string) *mathNode {
+    row := &mathNode{kind: "row"}
+
+    p.depth++
+    defer func() { p.depth-- }()
+    if p.depth > maxFormulaDepth {
+        p.fail(p.pos, "formula is nested too deeply")
+        p.pos = len(p.src)
+        return row
+    }
+
+    for {
+        p.skipSpaces()
+        if p.pos >= len(p.src) {
+            return row
+        }
+        c := p.src[p.pos]
+
+        switch {
+        case c == '}' || c == '&':
+            return row
+        case c == ']' && context == "]":
+            return row
+        case p.peekCommand("right") || p.peekCommand("end"):
+            return row
+        case c == '\\' && p.pos+1 < len(p.src) && p.src[p.pos+1] == '\\':
+            if context == "table" {
+                return row
+            }
+            // Перенос строки вне окружения ничего не делает
+            p.pos += 2
+        case c == '^' || c == '_':
+            p.script(row)
+        default:
+            if node := p.atom(); node != nil {
+                row.children = append(row.children, node)
+            }
+        }
+    }
+}
+
+// script присоединяет индекс к последнему атому строки
+func (p *latexParser) script(row *mathNode) {
+    start := p.pos
+    superscript := p.src[p.pos] == '^'
+    p.pos++
+
+    var base *mathNode
+    if len(row.children) > 0 {
+        base = row.children[len(row.children)-1]
+        row.children = row.children[:len(row.children)-1]
+    } else {
+        base = &mathNode{kind: "row"}
+    }
+    if base.kind != "scripts" {
+        base = &mathNode{kind: "scripts", children: []*mathNode{base, nil, nil}, limits: base.limits}
+    }
+    row.children = append(row.children, base)
+
+    argument := p.argument()
+    if argument == nil {
+        p.fail(start, "missing argument after '%c'", p.src[start])
+        return
+    }
+
+    slot, name := 1, "subscript"
+    if superscript {
+        slot, name = 2, "superscript"
+    }
+    if base.children[slot] != nil {
+        p.fail(start, "double %s", name)
+        return
+    }
+    base.children[slot] = argument
+}
+
+// argument читает аргумент команды или индекса: группу, команду или один символ
+func (p *latexParser) argument() *mathNode {
+    p.skipSpaces()
+    if p.pos >= len(p.src) {
+        return nil
+    }
+    switch c := p.src[p.pos]; {
+    case c == '{':
+        return p.group()
+    case c == '}' || c == '&' || c == '^' || c == '_':
+        return nil
+    case p.peekCommand("right") || p.peekCommand("end"):
+        return nil
+    case c >= '0' && c <= '9':
+        // В аргументе цифры идут по одной: x^23 - это x² и 3
+        p.pos++
+        return &mathNode{kind: "number", text: string(c)}
+    }
+    return p.atom()
+}
+
+// group читает {...}
+func (p *latexParser) group() *mathNode {
+    open := p.pos
+    p.pos++
+    node := p.expression("}")
+    if p.pos < len(p.src) && p.src[p.pos] == '}' {
+        p.pos++
+    } else {
+        p.fail(open, "missing '}'")
+    }
+    return node
+}
+
+// groupText читает {...} как обычный текст без разбора формулы
+func (p *latexParser) groupText() (string, bool) {
+    p.skipSpaces()
+    if p.pos >= len(p.src) || p.src[p.pos] != '{' {
+        return "", false
+    }
+    open := p.pos
+    depth := 0
+    var text strings.Builder
+    for ; p.pos < len(p.src); p.pos++ {
+        c := p.src[p.pos]
+        switch {
+        case c == '\\' && p.pos+1 < len(p.src):
+            p.pos++
+            text.WriteRune(p.src[p.pos])
+            continue
+        case c == '{':
+            depth++
+            if depth == 1 {
+                continue
+            }
+        case c == '}':
+            depth--
+            if depth == 0 {
+                p.pos++
+                return text.String(), true
+            }
+        }
+        text.WriteRune(c)
+    }
+    p.fail(open, "missing '}'")
+    return text.String(), true
+}
+
+func (p *latexParser) atom() *mathNode {
+    c := p.src[p.pos]
+    switch {
+    case c == '{':
+        return p.group()
+    case c == '\\':
+        return p.command()
+    case c >= '0' && c <= '9' || c == '.' && p.pos+1 < len(p.src) && unicode.IsDigit(p.src[p.pos+1]):
+        start := p.pos
+        for p.pos < len(p.src) && (unicode.IsDigit(p.src[p.pos]) || p.src[p.pos] == '.' && p.pos+1 < len(p.src) && unicode.IsDigit(p.src[p.pos+1])) {
+            p.pos++
+        }
+        return &mathNode{kind: "number", text: string(p.src[start:p.pos])}
+    case unicode.IsLetter(c):
+        p.pos++
+        return &mathNode{kind: "ident", text: string(c)}
+    case c == '%':
+        // Комментарий до конца строки
+        for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+            p.pos++
+        }
+        return nil
+    case c == '$' || c == '#':
+        p.fail(p.pos, "unexpected '%c'", c)
+        p.pos++
+        return nil
+    case c == '~':
+        p.pos++
+        return &mathNode{kind: "space", width: 0.33}
+    case c == '\'':
+        p.pos++
+        return &mathNode{kind: "op", text: "′"}
+    case c == '-':
+        p.pos++
+        return &mathNode{kind: "op", text: "−"}
+    case c == '*':
+        p.pos++
+        return &mathNode{kind: "op", text: "∗"}
+    }
+    p.pos++
+    return &mathNode{kind: "op", text: string(c)}
+}
+
+var (
+    formulaBigOperators = map[string]bool{"sum": true, "prod": true, "coprod": true, "bigcup": true, "bigcap": true,
+        "int": false, "iint": false, "iiint": false, "oint": false} // значение - индексы над и под оператором
+    formulaLimitFunctions = map[string]bool{"lim": true, "max": true, "min": true, "sup": true, "inf": true, "det": true, "gcd": true}
+    formulaVariants       = map[string]string{"mathrm": "normal", "operatorname": "normal", "mathbf": "bold", "boldsymbol": "bold",
+        "mathbb": "double-struck", "mathcal": "script", "mathit": "italic", "mathsf": "sans-serif", "mathtt": "monospace"}
+    formulaSpaces = map[string]float64{",": 0.17, ":": 0.22, ";": 0.28, " ": 0.33, "enspace": 0.5, "quad": 1, "qquad": 2, "!": -0.17}
+    formulaFences = map[string][2]string{"matrix": {"", ""}, "smallmatrix": {"", ""}, "pmatrix": {"(", ")"},
+        "bmatrix": {"[", "]"}, "Bmatrix": {"{", "}"}, "vmatrix": {"|", "|"}, "Vmatrix": {"‖", "‖"}, "cases": {"{", ""},
+        "aligned": {"", ""}, "align*": {"", ""}, "gathered": {"", ""}, "split": {"", ""}, "array": {"", ""}}
+    formulaIdentSymbols = "∞∅∂∇ℏℓℜℑℵ"
+    formulaDelimiters   = map[string]string{"(": "(", ")": ")", "[": "[", "]": "]", "|": "|", "/": "/", ".": "", "<": "⟨", ">": "⟩",
+        `\{`: "{", `\}`: "}", `\|`: "‖", `\vert`: "|", `\lvert`: "|", `\rvert`: "|", `\Vert`: "‖", `\lVert`: "‖", `\rVert`: "‖",
+        `\langle`: "⟨", `\rangle`: "⟩", `\lfloor`: "⌊", `\rfloor`: "⌋", `\lceil`: "⌈", `\rceil`: "⌉"}
+)
+
+func (p *latexParser) command() *mathNode {
+    start := p.pos
+    p.pos++
+    nameStart := p.pos
+    for p.pos < len(p.src) && isASCIILetter(p.src[p.pos]) {
+        p.pos++
+    }
+    if p.pos == nameStart {
+        if p.pos >= len(p.src) {
+            p.fail(start, `incomplete command '\'`)
+            return nil
+        }
+        p.pos++
+    }
+    name := string(p.src[nameStart:p.pos])
+
+    requireArgument := func() *mathNode {
+        argument := p.argument()
+        if argument == nil {
+            p.fail(start, `missing argument for \%s`, name)
+            return &mathNode{kind: "row"}
+        }
+        return argument
+    }
+
+    switch name {
+    case "frac", "dfrac", "tfrac", "cfrac":
+        return &mathNode{kind: "frac", children: []*mathNode{requireArgument(), requireArgument()}}
+    case "binom":
+        frac := &mathNode{kind: "frac", text: "binom", children: []*mathNode{requireArgument(), requireArgument()}}
+        return &mathNode{kind: "fenced", open: "(", close: ")", children: []*mathNode{frac}}
+    case "sqrt":
+        var index *mathNode
+        p.skipSpaces()
+        if p.pos < len(p.src) && p.src[p.pos] == '[' {
+            open := p.pos
+            p.pos++
+            index = p.expression("]")
+            if p.pos < len(p.src) && p.src[p.pos] == ']' {
+                p.pos++
+            } else {
+                p.fail(open, "missing ']'")
+            }
+        }
+        return &mathNode{kind: "sqrt", children: []*mathNode{requireArgument(), index}}
+    case "text", "textrm", "textbf", "textit", "mbox":
+        text, ok := p.groupText()
+        if !ok {
+            p.fail(start, `missing argument for \%s`, name)
+        }
+        return &mathNode{kind: "text", text: text}
+    case "left":
+        return p.fenced(start)
+    case "big", "Big", "bigg", "Bigg", "bigl", "bigr", "Bigl", "Bigr", "biggl", "biggr":
+        delimiter, ok := p.delimiter()
+        if !ok {
+            p.fail(start, `missing delimiter after \%s`, name)
+            return nil
+        }
+        return &mathNode{kind: "op", text: delimiter}
+    case "begin":
+        return p.environment(start)
+    case "limits", "nolimits", "displaystyle", "textstyle", "scriptstyle", "nonumber":
+        return nil
+    case "overline", "underline", "overbrace", "underbrace":
+        return &mathNode{kind: "accent", text: name, under: strings.HasPrefix(name, "under"), children: []*mathNode{requireArgument()}}
+    case "{", "}", "%", "$", "#", "&", "_", "|":
+        symbol := name
+        if name == "|" {
+            symbol = "‖"
+        }
+        return &mathNode{kind: "op", text: symbol}
+    }
+
+    if width, ok := formulaSpaces[name]; ok {
+        return &mathNode{kind: "space", width: width}
+    }
+    if variant, ok := formulaVariants[name]; ok {
+        return &mathNode{kind: "style", variant: variant, children: []*mathNode{requireArgument()}}
+    }
+    if accent, ok := latexAccents[name]; ok {
+        return &mathNode{kind: "accent", text: accent, children: []*mathNode{requireArgument()}}
+    }
+    if limits, ok := formulaBigOperators[name]; ok {
+        return &mathNode{kind: "op", text: latexCommandSymbols[name], large: true, limits: limits}
+    }
+    if latexFunctions[name] {
+        return &mathNode{kind: "func", text: name, limits: formulaLimitFunctions[name]}
+    }
+    if symbol, ok := latexCommandSymbols[name]; ok {
+        r := []rune(symbol)[0]
+        if unicode.IsLetter(r) || strings.ContainsRune(formulaIdentSymbols, r) {
+            return &mathNode{kind: "ident", text: symbol}
+        }
+        return &mathNode{kind: "op", text: symbol}
+    }
+
+    p.fail(start, `unknown command \%s`, name)
+    return &mathNode{kind: "text", text: `\` + name}
+}
+
+// delimiter читает скобку после \left, \right или \big: символ, команду или "." (без скобки)
+func (p *latexParser) delimiter() (string, bool) {
+    p.skipSpaces()
+    if p.pos >= len(p.src) {
+        return "", false
+    }
+    if c := p.src[p.pos]; c != '\\' {
+        if !strings.ContainsRune("()[]|/.<>", c) {
+            return "", false
+        }
+        p.pos++
+        return formulaDelimiters[string(c)], true
+    }
+
+    start := p.pos
+    p.pos++
+    nameStart := p.pos
+    for p.pos < len(p.src) && isASCIILetter(p.src[p.pos]) {
+        p.pos++
+    }
+    if p.pos == nameStart && p.pos < len(p.src) {
+        p.pos++
+    }
+    if delimiter, ok := formulaDelimiters[`\`+string(p.src[nameStart:p.pos])]; ok {
+        return delimiter, true
+    }
+    p.pos = start
+    return "", false
+}
+
+func (p *latexParser) fenced(start int) *mathNode {
+    open, ok := p.delimiter()
+    if !ok {
+        p.fail(start, `missing delimiter after \left`)
+    }
+    body := p.expression("right")
+    node := &mathNode{kind: "fenced", open: open, children: []*mathNode{body}}
+
+    if !p.peekCommand("right") {
+        p.fail(start, `\left without \right`)
+        return node
+    }
+    rightPos := p.pos
+    p.pos += len("right") + 1
+    if node.close, ok = p.delimiter(); !ok {
+        p.fail(rightPos, `missing delimiter after \right`)
+    }
+    return node
+}
+
+func (p *latexParser) environment(start int) *mathNode {
+    name, ok := p.groupText()
+    if !ok {
+        p.fail(start, `missing environment name for \begin`)
+        return nil
+    }
+    fences, known := formulaFences[name]
+    if !known {
+        p.fail(start, "unknown environment %s", name)
+    }
+    if name == "array" {
+        // Описание столбцов {lcr} на отрисовку не влияет
+        p.groupText()
+    }
+
+    table := &mathNode{kind: "table", open: fences[0], close: fences[1], align: "center"}
+    switch name {
+    case "cases":
+        table.align = "left"
+    case "aligned", "align*", "split":
+        table.align = "aligned"
+    }
+
+    cells := []*mathNode{}
+    for {
+        cells = append(cells, p.expression("table"))
+        if p.pos >= len(p.src) {
+            p.fail(start, `\begin{%s} without \end`, name)
+            table.rows = append(table.rows, cells)
+            return table
+        }
+
+        switch {
+        case p.src[p.pos] == '&':
+            p.pos++
+            continue
+        case p.src[p.pos] == '\\':
+            if p.peekCommand("end") {
+                endPos := p.pos
+                p.pos += len("end") + 1
+                if endName, _ := p.groupText(); endName != name {
+                    p.fail(endPos, `\begin{%s} ended by \end{%s}`, name, endName)
+                }
+                table.rows = append(table.rows, cells)
+                return table
+            }
+            if p.peekCommand("right") {
+                p.fail(p.pos, `\right without \left`)
+                p.pos += len("right") + 1
+                continue
+            }
+            // Перенос строки \\ завершает строку таблицы
+            p.pos += 2
+            table.rows = append(table.rows, cells)
+            cells = []*mathNode{}
+        case p.src[p.pos] == '}':
+            p.fail(p.pos, "unexpected '}'")
+            p.pos++
+        }
+    }
+}
@@ -0,0 +1,700 @@
+package services
+
+import (
+    "fmt"
+    "html"
+    "math"
+    "strings"
+    "unicode"
+)
+
+// mathAccentMarks - отдельные знаки для диакритик из latexAccents: в MathML и SVG
+// они рисуются над основанием, а не комбинируются с последней буквой
+var mathAccentMarks = map[string]string{
+    "⃗": "→", "̂": "ˆ", "̄": "¯", "̅": "¯", "̇": "˙", "̈": "¨", "̃": "˜",
+    "overline": "‾", "underline": "_", "overbrace": "⏞", "underbrace": "⏟",
+}
+
+// Буквы, для которых есть готовые знаки \mathbb в основной плоскости Unicode
+var doubleStruckLetters = map[rune]rune{'C': 'ℂ', 'H': 'ℍ', 'N': 'ℕ', 'P': 'ℙ', 'Q': 'ℚ', 'R': 'ℝ', 'Z': 'ℤ'}
+
+// formulaMathML записывает формулу в Presentation MathML, исходный LaTeX сохраняется в аннотации
+func formulaMathML(root *mathNode, latex string, display bool) string {
+    var b strings.Builder
+    b.WriteString(`<math xmlns="http://www.w3.org/1998/Math/MathML"`)
+    if display {
+        b.WriteString(` display="block"`)
+    }
+    b.WriteString(`><semantics>`)
+    writeMathML(&b, root, "")
+    b.WriteString(`<annotation encoding="application/x-tex">`)
+    b.WriteString(html.EscapeString(latex))
+    b.WriteString(`</annotation></semantics></math>`)
+    return b.String()
+}
+
+func writeMathML(b *strings.Builder, node *mathNode, variant string) {
+    if node == nil {
+        b.WriteString("<mrow></mrow>")
+        return
+    }
+
+    token := func(tag, text string, attrs string) {
+        if variant != "" && tag != "mtext" {
+            attrs += fmt.Sprintf(` mathvariant="%s"`, variant)
+        }
+        fmt.Fprintf(b, "<%s%s>%s</%s>", tag, attrs, html.EscapeString(text), tag)
+    }
+
+    switch node.kind {
+    case "row":
+        if len(node.children) == 1 {
+            writeMathML(b, node.children[0], variant)
+            return
+        }
+        b.WriteString("<mrow>")
+        for _, child := range node.children {
+            writeMathML(b, child, variant)
+        }
+        b.WriteString("</mrow>")
+    case "ident":
+        if variant == "double-struck" && doubleStruckLetters[[]rune(node.text)[0]] != 0 {
+            token("mi", string(doubleStruckLetters[[]rune(node.text)[0]]), "")
+            return
+        }
+        token("mi", node.text, "")
+    case "func":
+        token("mi", node.text, "")
+    case "number":
+        token("mn", node.text, "")
+    case "op":
+        attrs := ""
+        if node.large {
+            attrs = ` largeop="true"`
+        }
+        token("mo", node.text, attrs)
+    case "text":
+        token("mtext", node.text, "")
+    case "space":
+        fmt.Fprintf(b, `<mspace width="%sem"/>`, formatEm(node.width))
+    case "frac":
+        if node.text == "binom" {
+            b.WriteString(`<mfrac linethickness="0">`)
+        } else {
+            b.WriteString("<mfrac>")
+        }
+        writeMathML(b, node.children[0], variant)
+        writeMathML(b, node.children[1], variant)
+        b.WriteString("</mfrac>")
+    case "sqrt":
+        if node.children[1] == nil {
+            b.WriteString("<msqrt>")
+            writeMathML(b, node.children[0], variant)
+            b.WriteString("</msqrt>")
+            return
+        }
+        b.WriteString("<mroot>")
+        writeMathML(b, node.children[0], variant)
+        writeMathML(b, node.children[1], variant)
+        b.WriteString("</mroot>")
+    case "scripts":
+        base, sub, sup := node.children[0], node.children[1], node.children[2]
+        tags := [3]string{"msub", "msup", "msubsup"}
+        if node.limits {
+            tags = [3]string{"munder", "mover", "munderover"}
+        }
+        tag := tags[2]
+        switch {
+        case sup == nil:
+            tag = tags[0]
+        case sub == nil:
+            tag = tags[1]
+        }
+        fmt.Fprintf(b, "<%s>", tag)
+        writeMathML(b, base, variant)
+        if sub != nil {
+            writeMathML(b, sub, variant)
+        }
+        if sup != nil {
+            writeMathML(b, sup, variant)
+        }
+        fmt.Fprintf(b, "</%s>", tag)
+    case "fenced":
+        b.WriteString("<mrow>")
+        if node.open != "" {
+            fmt.Fprintf(b, `<mo fence="true" stretchy="true">%s</mo>`, html.EscapeString(node.open))
+        }
+        for _, child := range node.children {
+            writeMathML(b, child, variant)
+        }
+        if node.close != "" {
+            fmt.Fprintf(b, `<mo fence="true" stretchy="true">%s</mo>`, html.EscapeString(node.close))
+        }
+        b.WriteString("</mrow>")
+    case "accent":
+        mark := mathAccentMarks[node.text]
+        if node.under {
+            b.WriteString(`<munder accentunder="true">`)
+        } else {
+            b.WriteString(`<mover accent="true">`)
+        }
+        writeMathML(b, node.children[0], variant)
+        fmt.Fprintf(b, `<mo stretchy="true">%s</mo>`, html.EscapeString(mark))
+        if node.under {
+            b.WriteString("</munder>")
+        } else {
+            b.WriteString("</mover>")
+        }
+    case "style":
+        writeMathML(b, node.children[0], node.variant)
+    case "table":
+        if node.open != "" || node.close != "" {
+            b.WriteString("<mrow>")
+            if node.open != "" {
+                fmt.Fprintf(b, `<mo fence="true" stretchy="true">%s</mo>`, html.EscapeString(node.open))
+            }
+        }
+        b.WriteString("<mtable")
+        switch node.align {
+        case "left":
+            b.WriteString(` columnalign="left"`)
+        case "aligned":
+            b.WriteString(` columnalign="right left" columnspacing="0em"`)
+        }
+        b.WriteString(">")
+        for _, row := range node.rows {
+            b.WriteString("<mtr>")
+            for _, cell := range row {
+                b.WriteString("<mtd>")
+                writeMathML(b, cell, variant)
+                b.WriteString("</mtd>")
+            }
+            b.WriteString("</mtr>")
+        }
+        b.WriteString("</mtable>")
+        if node.open != "" || node.close != "" {
+            if node.close != "" {
+                fmt.Fprintf(b, `<mo fence="true" stretchy="true">%s</mo>`, html.EscapeString(node.close))
+            }
+            b.WriteString("</mrow>")
+        }
+    }
+}
+
+// formulaItem - элемент раскладки формулы. Координаты в em от левого края и базовой линии бокса, y вверх
+type formulaItem struct {
+    kind    string // text, rule, path
+    x, y    float64
+    size    float64
+    text    string
+    width   float64 // text - ширина, в которую вписывается знак; rule - длина
+    height  float64 // rule - толщина, path - толщина линии
+    stretch float64 // text - растяжение по вертикали (большие скобки)
+    italic  bool
+    bold    bool
+    points  []float64 // path - ломаная x1 y1 x2 y2 ...
+}
+
+// formulaBox - прямоугольник раскладки: ширина, высота над базовой линией и глубина под ней
+type formulaBox struct {
+    width  float64
+    height float64
+    depth  float64
+    items  []formulaItem
+}
+
+// place переносит элементы другого бокса со сдвигом
+func (b *formulaBox) place(other *formulaBox, dx, dy float64) {
+    for _, item := range other.items {
+        item.x += dx
+        item.y += dy
+        if item.kind == "path" {
+            points := make([]float64, len(item.points))
+            for i := range item.points {
+                if i%2 == 0 {
+                    points[i] = item.points[i] + dx
+                } else {
+                    points[i] = item.points[i] + dy
+                }
+            }
+            item.points = points
+        }
+        b.items = append(b.items, item)
+    }
+    b.height = math.Max(b.height, other.height+dy)
+    b.depth = math.Max(b.depth, other.depth-dy)
+}
+
+// Высота математической оси (середина знаков + и =), от нее считаются дроби и скобки
+const formulaAxis = 0.25
+
+var (
+    formulaRelations = "=<>≤≥≠≈≡∼≃≅∝∈∉∋⊂⊃⊆⊇→←↔⇒⇐⇔↦⟶≪≫∥⊥:"
+    formulaBinaries  = "+−±∓×÷·∗∘∪∩∧∨⊕⊗∖"
+    formulaPunct     = ",;"
+)
+
+// formulaLayout раскладывает дерево формулы. Метрики приблизительные: при выводе
+// каждый знак подгоняется под рассчитанную ширину, поэтому раскладка не зависит от шрифта клиента
+type formulaLayout struct {
+    variant string
+}
+
+func (l *formulaLayout) layout(node *mathNode, size float64, display bool) *formulaBox {
+    if node == nil {
+        return &formulaBox{}
+    }
+
+    switch node.kind {
+    case "row":
+        return l.row(node.children, size, display)
+    case "ident":
+        text := node.text
+        r := []rune(text)[0]
+        if l.variant == "double-struck" && doubleStruckLetters[r] != 0 {
+            return l.glyph(string(doubleStruckLetters[r]), size, false)
+        }
+        italic := l.variant == "" || l.variant == "italic"
+        italic = italic && len([]rune(text)) == 1 && (isASCIILetter(r) || unicode.Is(unicode.Greek, r) && unicode.IsLower(r))
+        return l.glyph(text, size, italic)
+    case "number", "func", "text":
+        return l.glyph(node.text, size, false)
+    case "op":
+        if node.large {
+            box := l.glyph(node.text, size*1.4, false)
+            // Большой оператор центрируется по оси
+            shift := formulaAxis*size - (box.height-box.depth)/2
+            centered := &formulaBox{width: box.width}
+            centered.place(box, 0, shift)
+            return centered
+        }
+        return l.glyph(node.text, size, false)
+    case "space":
+        return &formulaBox{width: node.width * size}
+    case "style":
+        saved := l.variant
+        l.variant = node.variant
+        box := l.layout(node.children[0], size, display)
+        l.variant = saved
+        return box
+    case "frac":
+        return l.fraction(node, size, display)
+    case "sqrt":
+        return l.sqrt(node, size, display)
+    case "scripts":
+        return l.scripts(node, size, display)
+    case "fenced":
+        return l.fence(l.row(node.children, size, display), node.open, node.close, size)
+    case "accent":
+        return l.accent(node, size, display)
+    case "table":
+        return l.fence(l.table(node, size), node.open, node.close, size)
+    }
+    return &formulaBox{}
+}
+
+func scriptSize(size float64) float64 {
+    return math.Max(size*0.7, 0.5)
+}
+
+// glyph - строка знаков одним элементом
+func (l *formulaLayout) glyph(text string, size float64, italic bool) *formulaBox {
+    box := &formulaBox{}
+    for _, r := range text {
+        width, height, depth := formulaCharMetrics(r)
+        box.width += width * size
+        box.height = math.Max(box.height, height*size)
+        box.depth = math.Max(box.depth, depth*size)
+    }
+    box.items = append(box.items, formulaItem{kind: "text", size: size, text: text, width: box.width,
+        italic: italic, bold: l.variant == "bold"})
+    return box
+}
+
+// formulaCharMetrics возвращает приблизительные ширину, высоту и глубину знака в em
+func formulaCharMetrics(r rune) (width, height, depth float64) {
+    height, depth = 0.72, 0.02
+    switch {
+    case strings.ContainsRune("gjpqyβγζημξρςφχψ", r):
+        height, depth = 0.5, 0.22
+    case r >= 'a' && r <= 'z' && !strings.ContainsRune("bdfhklit", r), unicode.Is(unicode.Greek, r) && unicode.IsLower(r) && !strings.ContainsRune("βδζθλξ", r):
+        height = 0.5
+    case strings.ContainsRune("()[]{}|‖/⟨⟩⌊⌋⌈⌉", r):
+        height, depth = 0.75, 0.25
+    case strings.ContainsRune("∑∏∐⋃⋂∫∬∭∮", r):
+        height, depth = 0.75, 0.25
+    case strings.ContainsRune(formulaRelations, r) || strings.ContainsRune(formulaBinaries, r):
+        height, depth = 0.6, 0.1
+    case strings.ContainsRune(",;", r):
+        height, depth = 0.1, 0.18
+    }
+
+    switch {
+    case strings.ContainsRune("il.,;:!'′|`ı·", r):
+        width = 0.28
+    case strings.ContainsRune("∘∗", r):
+        width = 0.5
+    case strings.ContainsRune("jtfr()[]{}/⟨⟩⌊⌋⌈⌉‖", r):
+        width = 0.38
+    case r == ' ':
+        width = 0.3
+    case strings.ContainsRune("mwMWЖШЩЮмшщжю", r):
+        width = 0.85
+    case r >= '0' && r <= '9':
+        width = 0.56
+    case strings.ContainsRune(formulaRelations, r) || strings.ContainsRune(formulaBinaries, r):
+        width = 0.78
+    case strings.ContainsRune("∑∏∐⋃⋂", r):
+        width = 0.8
+    case strings.ContainsRune("∫∬∭∮", r):
+        width = 0.45 * float64(strings.IndexRune("∫∬∭∮", r)/3+1)
+    case unicode.IsUpper(r):
+        width = 0.68
+    case unicode.IsLetter(r):
+        width = 0.54
+    default:
+        width = 0.65
+    }
+    return width, height, depth
+}
+
+// row раскладывает атомы в строку, добавляя отступы вокруг бинарных операций и отношений
+func (l *formulaLayout) row(children []*mathNode, size float64, display bool) *formulaBox {
+    box := &formulaBox{}
+    x := 0.0
+    previous := ""
+    for i, child := range children {
+        if child == nil {
+            continue
+        }
+        space := 0.0
+        if child.kind == "op" && !child.large && len([]rune(child.text)) == 1 {
+            r := []rune(child.text)[0]
+            switch {
+            case strings.ContainsRune(formulaRelations, r):
+                space = 0.28
+            case strings.ContainsRune(formulaBinaries, r) && previous != "" && previous != "op" && i < len(children)-1:
+                // Минус в начале или после другого знака - унарный, без отступов
+                space = 0.22
+            }
+            if size < 1 {
+                space /= 2
+            }
+        }
+
+        x += space * size
+        part := l.layout(child, size, display)
+        box.place(part, x, 0)
+        x += part.width + space*size
+        previous = child.kind
+        if child.kind == "op" && strings.ContainsAny(child.text, formulaPunct) {
+            x += 0.17 * size
+            previous = ""
+        }
+        if child.kind == "func" {
+            x += 0.17 * size
+        }
+    }
+    box.width = x
+    return box
+}
+
+func (l *formulaLayout) fraction(node *mathNode, size float64, display bool) *formulaBox {
+    partSize := size
+    if !display {
+        partSize = scriptSize(size)
+    }
+    numerator := l.layout(node.children[0], partSize, false)
+    denominator := l.layout(node.children[1], partSize, false)
+
+    axis, thickness, gap := formulaAxis*size, 0.05*size, 0.12*size
+    box := &formulaBox{width: math.Max(numerator.width, denominator.width) + 0.2*size}
+    box.place(numerator, (box.width-numerator.width)/2, axis+thickness/2+gap+numerator.depth)
+    box.place(denominator, (box.width-denominator.width)/2, axis-thickness/2-gap-denominator.height)
+    if node.text != "binom" {
+        box.items = append(box.items, formulaItem{kind: "rule", x: 0.05 * size, y: axis - thickness/2,
+            width: box.width - 0.1*size, height: thickness})
+    }
+    return box
+}
+
+func (l *formulaLayout) sqrt(node *mathNode, size float64, display bool) *formulaBox {
+    radicand := l.layout(node.children[0], size, display)
+    radicand.height = math.Max(radicand.height, 0.72*size)
+
+    thickness, gap := 0.05*size, 0.12*size
+    top := radicand.height + gap + thickness/2
+    bottom := -math.Max(radicand.depth, 0.1*size)
+    middle := bottom + (top-bottom)*0.45
+
+    offset := 0.0
+    var index *formulaBox
+    if node.children[1] != nil {
+        index = l.layout(node.children[1], math.Max(size*0.5, 0.4), false)
+        offset = math.Max(0, index.width-0.25*size)
+    }
+
+    sign := 0.6 * size
+    box := &formulaBox{width: offset + sign + radicand.width + 0.1*size}
+    box.items = append(box.items, formulaItem{kind: "path", height: thickness, points: []float64{
+        offset + 0.03*size, middle,
+        offset + 0.15*size, middle + 0.08*size,
+        offset + 0.3*size, bottom,
+        offset + 0.55*size, top,
+        box.width, top,
+    }})
+    box.place(radicand, offset+sign, 0)
+    box.height = math.Max(box.height, top+thickness/2)
+    box.depth = math.Max(box.depth, -bottom)
+    if index != nil {
+        box.place(index, 0, middle+0.1*size+index.depth)
+    }
+    return box
+}
+
+func (l *formulaLayout) scripts(node *mathNode, size float64, display bool) *formulaBox {
+    base := l.layout(node.children[0], size, display)
+    var sub, sup *formulaBox
+    if node.children[1] != nil {
+        sub = l.layout(node.children[1], scriptSize(size), false)
+    }
+    if node.children[2] != nil {
+        sup = l.layout(node.children[2], scriptSize(size), false)
+    }
+
+    box := &formulaBox{}
+    if node.limits && display {
+        // Пределы суммы и lim - над и под оператором
+        width := base.width
+        if sub != nil {
+            width = math.Max(width, sub.width)
+        }
+        if sup != nil {
+            width = math.Max(width, sup.width)
+        }
+        box.width = width
+        box.place(base, (width-base.width)/2, 0)
+        if sup != nil {
+            box.place(sup, (width-sup.width)/2, base.height+0.1*size+sup.depth)
+        }
+        if sub != nil {
+            box.place(sub, (width-sub.width)/2, -base.depth-0.1*size-sub.height)
+        }
+        return box
+    }
+
+    box.place(base, 0, 0)
+    width := 0.0
+    if sup != nil {
+        shift := math.Max(0.4*size, base.height-0.3*size)
+        box.place(sup, base.width+0.04*size, shift)
+        width = sup.width
+    }
+    if sub != nil {
+        shift := math.Max(0.18*size, base.depth+0.05*size)
+        if sup != nil {
+            shift = math.Max(shift, 0.25*size)
+        }
+        box.place(sub, base.width+0.02*size, -shift)
+        width = math.Max(width, sub.width)
+    }
+    box.width = base.width + width + 0.06*size
+    return box
+}
+
+// fence окружает бокс скобками, растянутыми по высоте содержимого симметрично оси
+func (l *formulaLayout) fence(body *formulaBox, open, close string, size float64) *formulaBox {
+    if open == "" && close == "" {
+        return body
+    }
+    axis := formulaAxis * size
+    half := math.Max(math.Max(body.height-axis, body.depth+axis), 0.5*size) + 0.05*size
+    stretch := math.Max(1, 2*half/size)
+
+    box := &formulaBox{}
+    x := 0.0
+    delimiter := func(text string) {
+        if text == "" {
+            return
+        }
+        width, _, _ := formulaCharMetrics([]rune(text)[0])
+        box.items = append(box.items, formulaItem{kind: "text", x: x, y: axis - half + 0.25*size*stretch, size: size,
+            text: text, width: width * size, stretch: stretch})
+        x += width * size
+    }
+
+    delimiter(open)
+    box.place(body, x, 0)
+    x += body.width
+    delimiter(close)
+
+    box.width = x
+    box.height = math.Max(box.height, axis+half)
+    box.depth = math.Max(box.depth, half-axis)
+    return box
+}
+
+func (l *formulaLayout) accent(node *mathNode, size float64, display bool) *formulaBox {
+    base := l.layout(node.children[0], size, display)
+    box := &formulaBox{width: base.width}
+    box.place(base, 0, 0)
+    mark := mathAccentMarks[node.text]
+
+    switch node.text {
+    case "overline":
+        box.items = append(box.items, formulaItem{kind: "rule", y: base.height + 0.08*size, width: base.width, height: 0.05 * size})
+        box.height = base.height + 0.13*size
+    case "underline":
+        box.items = append(box.items, formulaItem{kind: "rule", y: -base.depth - 0.13*size, width: base.width, height: 0.05 * size})
+        box.depth = base.depth + 0.13*size
+    case "overbrace", "underbrace":
+        braceSize := 0.8 * size
+        if node.under {
+            box.items = append(box.items, formulaItem{kind: "text", y: -base.depth - 0.3*size, size: braceSize, text: mark, width: base.width})
+            box.depth = base.depth + 0.35*size
+        } else {
+            box.items = append(box.items, formulaItem{kind: "text", y: base.height - 0.25*size, size: braceSize, text: mark, width: base.width})
+            box.height = base.height + 0.35*size
+        }
+    case "⃗":
+        width := math.Max(base.width, 0.5*size)
+        box.items = append(box.items, formulaItem{kind: "text", x: (base.width - width) / 2, y: base.height - 0.12*size,
+            size: 0.7 * size, text: mark, width: width})
+        box.height = base.height + 0.35*size
+    default:
+        width, _, _ := formulaCharMetrics([]rune(mark)[0])
+        box.items = append(box.items, formulaItem{kind: "text", x: (base.width - width*size) / 2, y: base.height - 0.45*size,
+            size: size, text: mark, width: width * size})
+        box.height = base.height + 0.3*size
+    }
+    return box
+}
+
+func (l *formulaLayout) table(node *mathNode, size float64) *formulaBox {
+    columns := 0
+    cells := make([][]*formulaBox, len(node.rows))
+    for i, row := range node.rows {
+        for _, cell := range row {
+            cells[i] = append(cells[i], l.layout(cell, size, false))
+        }
+        if len(row) > columns {
+            columns = len(row)
+        }
+    }
+
+    widths := make([]float64, columns)
+    heights := make([]float64, len(cells))
+    depths := make([]float64, len(cells))
+    for i, row := range cells {
+        heights[i], depths[i] = 0.72*size, 0.22*size
+        for j, cell := range row {
+            widths[j] = math.Max(widths[j], cell.width)
+            heights[i] = math.Max(heights[i], cell.height)
+            depths[i] = math.Max(depths[i], cell.depth)
+        }
+    }
+
+    gap := func(column int) float64 {
+        switch {
+        case column == 0:
+            return 0
+        case node.align != "aligned":
+            return 0.8 * size
+        case column%2 == 1:
+            // В aligned пары столбцов "правый & левый" идут без промежутка
+            return 0
+        }
+        return 1.0 * size
+    }
+
+    total := 0.0
+    for i := range cells {
+        total += heights[i] + depths[i]
+        if i > 0 {
+            total += 0.25 * size
+        }
+    }
+
+    box := &formulaBox{}
+    y := formulaAxis*size + total/2
+    for i, row := range cells {
+        if i > 0 {
+            y -= 0.25 * size
+        }
+        y -= heights[i]
+        x := 0.15 * size
+        for j := 0; j < columns; j++ {
+            x += gap(j)
+            if j < len(row) {
+                cell := row[j]
+                offset := (widths[j] - cell.width) / 2
+                switch {
+                case node.align == "left", node.align == "aligned" && j%2 == 1:
+                    offset = 0
+                case node.align == "aligned":
+                    offset = widths[j] - cell.width
+                }
+                box.place(cell, x+offset, y)
+            }
+            x += widths[j]
+        }
+        box.width = math.Max(box.width, x+0.15*size)
+        y -= depths[i]
+    }
+    box.height = math.Max(box.height, formulaAxis*size+total/2)
+    box.depth = math.Max(box.depth, total/2-formulaAxis*size)
+    return box
+}
+
+// formulaSVG записывает раскладку в SVG. 1 em = 1000 единиц viewBox; размеры самого SVG в em,
+// поэтому формула масштабируется вместе с окружающим текстом
+func formulaSVG(box *formulaBox) string {
+    const unit = 1000.0
+    coord := func(v float64) int { return int(math.Round(v * unit)) }
+    top := box.height
+
+    var b strings.Builder
+    fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%sem" height="%sem" viewBox="0 0 %d %d" style="vertical-align:-%sem" fill="currentColor" font-family="DejaVu Sans, STIX Two Math, Cambria Math, serif">`,
+        formatEm(box.width), formatEm(box.height+box.depth), coord(box.width), coord(box.height+box.depth), formatEm(box.depth))
+    for _, item := range box.items {
+        switch item.kind {
+        case "text":
+            attrs := ""
+            if item.italic {
+                attrs += ` font-style="italic"`
+            }
+            if item.bold {
+                attrs += ` font-weight="bold"`
+            }
+            position := fmt.Sprintf(`x="%d" y="%d"`, coord(item.x), coord(top-item.y))
+            if item.stretch > 1 {
+                position = fmt.Sprintf(`x="0" y="0" transform="translate(%d %d) scale(1 %s)"`,
+                    coord(item.x), coord(top-item.y), formatEm(item.stretch))
+            }
+            fmt.Fprintf(&b, `<text %s font-size="%d" textLength="%d" lengthAdjust="spacingAndGlyphs"%s>%s</text>`,
+                position, coord(item.size), coord(item.width), attrs, html.EscapeString(item.text))
+        case "rule":
+            fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d"/>`,
+                coord(item.x), coord(top-item.y-item.height), coord(item.width), coord(item.height))
+        case "path":
+            points := make([]string, 0, len(item.points)/2)
+            for i := 0; i+1 < len(item.points); i += 2 {
+                points = append(points, fmt.Sprintf("%d,%d", coord(item.points[i]), coord(top-item.points[i+1])))
+            }
+            fmt.Fprintf(&b, `<polyline points="%s" fill="none" stroke="currentColor" stroke-width="%d" stroke-linejoin="round"/>`,
+                strings.Join(points, " "), coord(item.height))
+        }
+    }
+    b.WriteString("</svg>")
+    return b.String()
+}
+
+// formatEm округляет размер до тысячных и убирает лишние нули
+func formatEm(v float64) string {
+    s := fmt.Sprintf("%.3f", v)
+    s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+    if s == "-0" {
+        return "0"
+    }
+    return s
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+    "fmt"
+    "strings"
+    "sync"
+
+    "paydeya-backend/internal/models"
+)
+
+// Кэш отрисованных формул: одни и те же формулы сохраняются при каждой правке материала
+const formulaCacheSize = 5000
+
+// FormulaValidationError - синтаксические ошибки в формулах блоков
+type FormulaValidationError struct {
+    Errors []models.FormulaError
+}
+
+func (e *FormulaValidationError) Error() string {
+    first := e.Errors[0]
+    if first.BlockID != "" {
+        return fmt.Sprintf("invalid formula in block %s at position %d: %s", first.BlockID, first.Position, first.Message)
+    }
+    return fmt.Sprintf("invalid formula at position %d: %s", first.Position, first.Message)
+}
+
+// FormulaService проверяет LaTeX в блоках и заранее отрисовывает формулы в MathML и SVG,
+// чтобы клиентам не нужен был свой движок формул
+type FormulaService struct {
+    mu    sync.Mutex
+    cache map[string]*models.FormulaRender
+}
+
+func NewFormulaService() *FormulaService {
+    return &FormulaService{cache: make(map[string]*models.FormulaRender)}
+}
+
+// Render проверяет формулу и отрисовывает ее. display - формула отдельной строкой, иначе внутри текста
+func (s *FormulaService) Render(latex string, display bool) (*models.FormulaRender, []models.FormulaError) {
+    if strings.TrimSpace(latex) == "" {
+        return nil, []models.FormulaError{{Position: 0, Message: "formula is empty"}}
+    }
+
+    key := fmt.Sprintf("%t:%s", display, latex)
+    s.mu.Lock()
+    cached, ok := s.cache[key]
+    s.mu.Unlock()
+    if ok {
+        return cached, nil
+    }
+
+    root, errs := parseLatex(latex)
+    if len(errs) > 0 {
+        return nil, errs
+    }
+
+    box := (&formulaLayout{}).layout(root, 1, display)
+    rendered := &models.FormulaRender{
+        MathML: formulaMathML(root, latex, display),
+        SVG:    formulaSVG(box),
+        Width:  roundEm(box.width),
+        Height: roundEm(box.height),
+        Depth:  roundEm(box.depth),
+    }
+
+    s.mu.Lock()
+    if len(s.cache) >= formulaCacheSize {
+        s.cache = make(map[string]*models.FormulaRender)
+    }
+    s.cache[key] = rendered
+    s.mu.Unlock()
+
+    return rendered, nil
+}
+
+// PrepareBlock проверяет формулы блока и сохраняет отрисовку в его содержимом:
+// у формулы - content.rendered, у текста - attrs.rendered каждого узла math.
+// Эти поля всегда пересчитываются сервером, присланные клиентом значения отбрасываются
+func (s *FormulaService) PrepareBlock(block *models.Block) []models.FormulaError {
+    if block == nil || block.Content == nil {
+        return nil
+    }
+
+    var errs []models.FormulaError
+    switch block.Type {
+    case "formula":
+        delete(block.Content, "rendered")
+        field := "latex"
+        if _, ok := block.Content["latex"].(string); !ok {
+            if _, ok := block.Content["formula"].(string); ok {
+                field = "formula"
+            }
+        }
+        rendered, formulaErrs := s.Render(contentString(block.Content, "latex", "formula"), true)
+        for _, formulaErr := range formulaErrs {
+            formulaErr.BlockID, formulaErr.Field = block.ID, field
+            errs = append(errs, formulaErr)
+        }
+        if rendered != nil {
+            block.Content["rendered"] = rendered
+        }
+
+    case "text":
+        // Текстовый блок уже приведен к документу (normalizeTextBlock), формулы - его узлы math
+        doc, ok := block.Content["doc"].(*models.RichTextNode)
+        if !ok {
+            return nil
+        }
+        var walk func(nodes []models.RichTextNode)
+        walk = func(nodes []models.RichTextNode) {
+            for i := range nodes {
+                node := &nodes[i]
+                if node.Type != "math" || node.Attrs == nil {
+                    walk(node.Content)
+                    continue
+                }
+                rendered, formulaErrs := s.Render(node.Attrs.Latex, false)
+                for _, formulaErr := range formulaErrs {
+                    formulaErr.BlockID, formulaErr.Field, formulaErr.Latex = block.ID, "doc", node.Attrs.Latex
+                    errs = append(errs, formulaErr)
+                }
+                node.Attrs.Rendered = rendered
+            }
+        }
+        walk(doc.Content)
+    }
+    return errs
+}
+
+// PrepareBlocks готовит формулы всех блоков. Ошибки собираются по всем блокам сразу;
+// блоки с корректными формулами отрисовываются в любом случае
+func (s *FormulaService) PrepareBlocks(blocks []models.Block) error {
+    var errs []models.FormulaError
+    for i := range blocks {
+        errs = append(errs, s.PrepareBlock(&blocks[i])...)
+    }
+    if len(errs) > 0 {
+        return &FormulaValidationError{Errors: errs}
+    }
+    return nil
+}
+
+func roundEm(v float64) float64 {
+    return float64(int(v*1000+0.5)) / 1000
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+    "strings"
+    "unicode"
+)
+
+// latexCommandSymbols - обратная к latexSymbols таблица (\alpha -> α) с дополнительными командами
+var latexCommandSymbols = func() map[string]string {
+    symbols := map[string]string{
+        "leq": "≤", "geq": "≥", "neq": "≠", "lt": "<", "gt": ">", "sim": "∼", "simeq": "≃", "cong": "≅",
+        "cdot": "·", "propto": "∝", "circ": "∘", "bullet": "•", "star": "⋆", "ast": "∗", "prime": "′", "deg": "°",
+        "sum": "∑", "prod": "∏", "int": "∫", "iint": "∬", "iiint": "∭", "oint": "∮",
+        "bigcup": "⋃", "bigcap": "⋂", "setminus": "∖", "emptyset": "∅", "supset": "⊃", "supseteq": "⊇",
+        "ni": "∋", "land": "∧", "lor": "∨", "wedge": "∧", "vee": "∨", "oplus": "⊕", "otimes": "⊗",
+        "rightarrow": "→", "Leftarrow": "⇐", "longrightarrow": "⟶", "mapsto": "↦", "uparrow": "↑", "downarrow": "↓",
+        "iff": "⇔", "implies": "⇒", "therefore": "∴", "because": "∵", "triangle": "△", "square": "□",
+        "hbar": "ℏ", "ell": "ℓ", "Re": "ℜ", "Im": "ℑ", "aleph": "ℵ", "epsilon": "ϵ", "phi": "ϕ",
+        "vartheta": "ϑ", "varpi": "ϖ", "varrho": "ϱ", "varsigma": "ς", "Upsilon": "Υ",
+        "langle": "⟨", "rangle": "⟩", "lfloor": "⌊", "rfloor": "⌋", "lceil": "⌈", "rceil": "⌉",
+        "vdots": "⋮", "ddots": "⋱", "dots": "…", "mid": "|", "|": "‖", "backslash": "\\",
+    }
+    for r, command := range latexSymbols {
+        name := strings.TrimSpace(strings.TrimPrefix(command, `\`))
+        if strings.HasPrefix(command, `\`) && name != "" {
+            if _, ok := symbols[name]; !ok {
+                symbols[name] = string(r)
+            }
+        }
+    }
+    return symbols
+}()
+
+var (
+    superscripts = map[rune]rune{
+        '0': '⁰', '1': '¹', '2': '²', '3': '³', '4': '⁴', '5': '⁵', '6': '⁶', '7': '⁷', '8': '⁸', '9': '⁹',
+        '+': '⁺', '-': '⁻', '−': '⁻', '=': '⁼', '(': '⁽', ')': '⁾', 'n': 'ⁿ', 'i': 'ⁱ', 'x': 'ˣ', 'k': 'ᵏ',
+        'm': 'ᵐ', 'a': 'ᵃ', 'b': 'ᵇ', 'c': 'ᶜ', 'd': 'ᵈ', 'e': 'ᵉ', 't': 'ᵗ', 'T': 'ᵀ', '∘': '°', '′': '′',
+    }
+    subscripts = map[rune]rune{
+        '0': '₀', '1': '₁', '2': '₂', '3': '₃', '4': '₄', '5': '₅', '6': '₆', '7': '₇', '8': '₈', '9': '₉',
+        '+': '₊', '-': '₋', '−': '₋', '=': '₌', '(': '₍', ')': '₎', 'a': 'ₐ', 'e': 'ₑ', 'o': 'ₒ', 'x': 'ₓ',
+        'i': 'ᵢ', 'j': 'ⱼ', 'k': 'ₖ', 'n': 'ₙ', 'm': 'ₘ', 'p': 'ₚ', 't': 'ₜ',
+    }
+    latexAccents = map[string]string{
+        "vec": "⃗", "overrightarrow": "⃗", "hat": "̂", "widehat": "̂", "bar": "̄",
+        "overline": "̅", "dot": "̇", "ddot": "̈", "tilde": "̃", "widetilde": "̃",
+    }
+)
+
+// latexPlainText переводит LaTeX в строку Unicode для вывода без движка формул:
+// греческие буквы и операторы - символами, степени и индексы - надстрочными знаками,
+// дроби - через косую черту
+func latexPlainText(latex string) string {
+    reader := &latexReader{text: []rune(latex)}
+    return strings.Join(strings.Fields(reader.expression(0)), " ")
+}
+
+type latexReader struct {
+    text []rune
+    pos  int
+}
+
+// expression читает формулу до символа stop (0 - до конца строки)
+func (r *latexReader) expression(stop rune) string {
+    var result strings.Builder
+    for r.pos < len(r.text) {
+        c := r.text[r.pos]
+        if c == stop {
+            return result.String()
+        }
+        switch c {
+        case '{':
+            r.pos++
+            result.WriteString(r.expression('}'))
+            r.pos++
+        case '}':
+            r.pos++
+        case '^', '_':
+            r.pos++
+            result.WriteString(latexScript(r.argument(), c == '^'))
+        case '\\':
+            result.WriteString(r.command())
+        case '&', '~':
+            r.pos++
+            result.WriteString(" ")
+        default:
+            r.pos++
+            result.WriteRune(c)
+        }
+    }
+    return result.String()
+}
+
+// argument читает аргумент команды: группу в фигурных скобках, команду или один символ
+func (r *latexReader) argument() string {
+    for r.pos < len(r.text) && unicode.IsSpace(r.text[r.pos]) {
+        r.pos++
+    }
+    if r.pos >= len(r.text) {
+        return ""
+    }
+    switch r.text[r.pos] {
+    case '{':
+        r.pos++
+        value := r.expression('}')
+        r.pos++
+        return value
+    case '\\':
+        return r.command()
+    }
+    r.pos++
+    return string(r.text[r.pos-1])
+}
+
+// optional читает необязательный аргумент в квадратных скобках
+func (r *latexReader) optional() string {
+    if r.pos < len(r.text) && r.text[r.pos] == '[' {
+        r.pos++
+        value := r.expression(']')
+        r.pos++
+        return value
+    }
+    return ""
+}
+
+func (r *latexReader) command() string {
+    r.pos++ // обратная косая черта
+    start := r.pos
+    for r.pos < len(r.text) && unicode.IsLetter(r.text[r.pos]) && r.text[r.pos] < unicode.MaxASCII {
+        r.pos++
+    }
+    if r.pos == start && r.pos < len(r.text) {
+        r.pos++
+    }
+    name := string(r.text[start:r.pos])
+
+    switch name {
+    case "frac", "dfrac", "tfrac", "cfrac":
+        numerator, denominator := r.argument(), r.argument()
+        return latexOperand(numerator) + "/" + latexOperand(denominator)
+    case "sqrt":
+        degree := r.optional()
+        value := "√" + latexOperand(r.argument())
+        if degree != "" {
+            return latexScript(degree, true) + value
+        }
+        return value
+    case "text", "textrm", "textbf", "textit", "mathrm", "mathbf", "mathit", "mathsf", "mathtt", "mathcal",
+        "mathbb", "boldsymbol", "operatorname", "mbox":
+        return r.argument()
+    case "binom":
+        n, k := r.argument(), r.argument()
+        return "C(" + n + ", " + k + ")"
+    case "begin", "end":
+        r.argument()
+        return " "
+    case "left", "right", "big", "Big", "bigg", "Bigg", "bigl", "bigr", "Bigl", "Bigr", "displaystyle", "textstyle",
+        "limits", "nolimits", "!":
+        if r.pos < len(r.text) && r.text[r.pos] == '.' {
+            r.pos++
+        }
+        return ""
+    case ",", ";", ":", " ", "quad", "qquad", "enspace":
+        return " "
+    case `\`, "newline", "cr":
+        return "; "
+    case "{", "}", "%", "$", "#", "&", "_":
+        return name
+    }
+
+    if accent, ok := latexAccents[name]; ok {
+        return r.argument() + accent
+    }
+    if symbol, ok := latexCommandSymbols[name]; ok {
+        return symbol
+    }
+    if latexFunctions[name] {
+        return name + " "
+    }
+    return name
+}
+
+// latexOperand берет составной операнд дроби или корня в скобки
+func latexOperand(value string) string {
+    value = strings.TrimSpace(value)
+    simple := true
+    for _, c := range value {
+        if !unicode.IsLetter(c) && !unicode.IsDigit(c) && c != '.' && c != ',' && !strings.ContainsRune("⁰¹²³⁴⁵⁶⁷⁸⁹₀₁₂₃₄₅₆₇₈₉", c) {
+            simple = false
+            break
+        }
+    }
+    if simple {
+        return value
+    }
+    return "(" + value + ")"
+}
+
+// latexScript записывает степень или индекс надстрочными/подстрочными символами,
+// а если для какого-то символа их нет - через ^ или _
+func latexScript(value string, superscript bool) string {
+    value = strings.TrimSpace(value)
+    table, marker := subscripts, "_"
+    if superscript {
+        table, marker = superscripts, "^"
+    }
+
+    var result strings.Builder
+    for _, c := range value {
+        mapped, ok := table[c]
+        if !ok {
+            if len([]rune(value)) == 1 {
+                return marker + value
+            }
+            return marker + "(" + value + ")"
+        }
+        result.WriteRune(mapped)
+    }
+    return result.String()
+}
@@ -0,0 +1,371 @@
+package services
+
+import (
+    "context"
+    "fmt"
+    "strings"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/repositories"
+)
+
+const maxLibraryBlocks = 100
+
+type LibraryService struct {
+    libraryRepo     *repositories.LibraryRepository
+    materialRepo    *repositories.MaterialRepository
+    materialService *MaterialService
+    formulaService  *FormulaService
+}
+
+func NewLibraryService(libraryRepo *repositories.LibraryRepository, materialRepo *repositories.MaterialRepository, materialService *MaterialService, formulaService *FormulaService) *LibraryService {
+    return &LibraryService{
+        libraryRepo:     libraryRepo,
+        materialRepo:    materialRepo,
+        materialService: materialService,
+        formulaService:  formulaService,
+    }
+}
+
+// SaveLibraryBlock сохраняет фрагмент в библиотеку пользователя
+func (s *LibraryService) SaveLibraryBlock(ctx context.Context, userID int, req *models.SaveLibraryBlockRequest) (*models.LibraryBlock, error) {
+    if err := s.checkSubject(ctx, req.Subject); err != nil {
+        return nil, err
+    }
+
+    blocks, err := s.sourceBlocks(ctx, userID, req.Blocks, req.MaterialID, req.BlockIDs)
+    if err != nil {
+        return nil, err
+    }
+    if blocks, err = s.prepareBlocks(blocks); err != nil {
+        return nil, err
+    }
+
+    item := &models.LibraryBlock{
+        OwnerID:     userID,
+        Title:       strings.TrimSpace(req.Title),
+        Description: req.Description,
+        Subject:     req.Subject,
+        Shared:      req.Shared,
+        Blocks:      blocks,
+    }
+    if err := s.libraryRepo.CreateLibraryBlock(ctx, item); err != nil {
+        return nil, fmt.Errorf("failed to save library block: %w", err)
+    }
+
+    return item, nil
+}
+
+// GetLibraryBlocks возвращает свои и общие фрагменты
+func (s *LibraryService) GetLibraryBlocks(ctx context.Context, userID int, filters models.LibraryFilters) ([]models.LibraryBlock, int, error) {
+    return s.libraryRepo.GetLibraryBlocks(ctx, userID, filters)
+}
+
+// GetLibraryBlock возвращает фрагмент с блоками. Чужой фрагмент доступен, только если он общий
+func (s *LibraryService) GetLibraryBlock(ctx context.Context, userID, id int) (*models.LibraryBlock, error) {
+    item, err := s.libraryRepo.GetLibraryBlock(ctx, id)
+    if err != nil || item == nil {
+        return nil, fmt.Errorf("library block not found")
+    }
+
+    if item.OwnerID != userID && !item.Shared {
+        return nil, fmt.Errorf("access denied")
+    }
+
+    return item, nil
+}
+
+// UpdateLibraryBlock изменяет фрагмент (только владелец)
+func (s *LibraryService) UpdateLibraryBlock(ctx context.Context, userID, id int, req *models.UpdateLibraryBlockRequest) (*models.LibraryBlock, error) {
+    item, err := s.GetLibraryBlock(ctx, userID, id)
+    if err != nil {
+        return nil, err
+    }
+    if item.OwnerID != userID {
+        return nil, fmt.Errorf("access denied")
+    }
+
+    if req.Title != nil {
+        item.Title = strings.TrimSpace(*req.Title)
+    }
+    if req.Description != nil {
+        item.Description = *req.Description
+    }
+    if req.Subject != nil {
+        if err := s.checkSubject(ctx, *req.Subject); err != nil {
+            return nil, err
+        }
+        item.Subject = *req.Subject
+    }
+    if req.Shared != nil {
+        item.Shared = *req.Shared
+    }
+    if req.Blocks != nil {
+        if item.Blocks, err = s.prepareBlocks(req.Blocks); err != nil {
+            return nil, err
+        }
+    }
+
+    if err := s.libraryRepo.UpdateLibraryBlock(ctx, item); err != nil {
+        return nil, fmt.Errorf("failed to update library block: %w", err)
+    }
+
+    return item, nil
+}
+
+// DeleteLibraryBlock удаляет фрагмент. Общие фрагменты могут удалять и администраторы
+func (s *LibraryService) DeleteLibraryBlock(ctx context.Context, userID int, isAdmin bool, id int) error {
+    item, err := s.libraryRepo.GetLibraryBlock(ctx, id)
+    if err != nil || item == nil {
+        return fmt.Errorf("library block not found")
+    }
+
+    if item.OwnerID != userID && !(isAdmin && item.Shared) {
+        return fmt.Errorf("access denied")
+    }
+
+    return s.libraryRepo.DeleteLibraryBlock(ctx, id)
+}
+
+// InsertLibraryBlock вставляет блоки фрагмента в материал
+func (s *LibraryService) InsertLibraryBlock(ctx context.Context, userID, id int, req *models.InsertBlocksRequest) (*models.InsertBlocksResult, error) {
+    item, err := s.GetLibraryBlock(ctx, userID, id)
+    if err != nil {
+        return nil, err
+    }
+
+    return s.insertBlocks(ctx, userID, req, item.Blocks)
+}
+
+// CreateTemplate создает шаблон материала. Официальный шаблон создает администратор,
+// для него обязателен предмет
+func (s *LibraryService) CreateTemplate(ctx context.Context, userID int, official bool, req *models.SaveTemplateRequest) (*models.MaterialTemplate, error) {
+    if official && req.Subject == "" {
+        return nil, fmt.Errorf("subject is required for official template")
+    }
+    if err := s.checkSubject(ctx, req.Subject); err != nil {
+        return nil, err
+    }
+
+    blocks, err := s.sourceBlocks(ctx, userID, req.Blocks, req.MaterialID, nil)
+    if err != nil {
+        return nil, err
+    }
+    if blocks, err = s.prepareBlocks(blocks); err != nil {
+        return nil, err
+    }
+
+    template := &models.MaterialTemplate{
+        AuthorID:    userID,
+        Title:       strings.TrimSpace(req.Title),
+        Description: req.Description,
+        Subject:     req.Subject,
+        Official:    official,
+        Blocks:      blocks,
+    }
+    if err := s.libraryRepo.CreateTemplate(ctx, template); err != nil {
+        return nil, fmt.Errorf("failed to create template: %w", err)
+    }
+
+    return template, nil
+}
+
+// GetTemplates возвращает свои и официальные шаблоны
+func (s *LibraryService) GetTemplates(ctx context.Context, userID int, filters models.TemplateFilters) ([]models.MaterialTemplate, int, error) {
+    return s.libraryRepo.GetTemplates(ctx, userID, filters)
+}
+
+// GetTemplate возвращает шаблон с блоками: официальный или свой
+func (s *LibraryService) GetTemplate(ctx context.Context, userID, id int) (*models.MaterialTemplate, error) {
+    template, err := s.libraryRepo.GetTemplate(ctx, id)
+    if err != nil || template == nil {
+        return nil, fmt.Errorf("template not found")
+    }
+
+    if template.AuthorID != userID && !template.Official {
+        return nil, fmt.Errorf("access denied")
+    }
+
+    return template, nil
+}
+
+// UpdateTemplate изменяет шаблон: личный - автор, официальный - администратор
+func (s *LibraryService) UpdateTemplate(ctx context.Context, userID int, isAdmin bool, id int, req *models.UpdateTemplateRequest) (*models.MaterialTemplate, error) {
+    template, err := s.getEditableTemplate(ctx, userID, isAdmin, id)
+    if err != nil {
+        return nil, err
+    }
+
+    if req.Title != nil {
+        template.Title = strings.TrimSpace(*req.Title)
+    }
+    if req.Description != nil {
+        template.Description = *req.Description
+    }
+    if req.Subject != nil {
+        if template.Official && *req.Subject == "" {
+            return nil, fmt.Errorf("subject is required for official template")
+        }
+        if err := s.checkSubject(ctx, *req.Subject); err != nil {
+            return nil, err
+        }
+        template.Subject = *req.Subject
+    }
+    if req.Blocks != nil {
+        if template.Blocks, err = s.prepareBlocks(req.Blocks); err != nil {
+            return nil, err
+        }
+    }
+
+    if err := s.libraryRepo.UpdateTemplate(ctx, template); err != nil {
+        return nil, fmt.Errorf("failed to update template: %w", err)
+    }
+
+    return template, nil
+}
+
+// DeleteTemplate удаляет шаблон: личный - автор, официальный - администратор
+func (s *LibraryService) DeleteTemplate(ctx context.Context, userID int, isAdmin bool, id int) error {
+    if _, err := s.getEditableTemplate(ctx, userID, isAdmin, id); err != nil {
+        return err
+    }
+
+    return s.libraryRepo.DeleteTemplate(ctx, id)
+}
+
+// InsertTemplate вставляет блоки шаблона в материал
+func (s *LibraryService) InsertTemplate(ctx context.Context, userID, id int, req *models.InsertBlocksRequest) (*models.InsertBlocksResult, error) {
+    template, err := s.GetTemplate(ctx, userID, id)
+    if err != nil {
+        return nil, err
+    }
+
+    return s.insertBlocks(ctx, userID, req, template.Blocks)
+}
+
+func (s *LibraryService) getEditableTemplate(ctx context.Context, userID int, isAdmin bool, id int) (*models.MaterialTemplate, error) {
+    template, err := s.libraryRepo.GetTemplate(ctx, id)
+    if err != nil || template == nil {
+        return nil, fmt.Errorf("template not found")
+    }
+
+    if template.Official && !isAdmin || !template.Official && template.AuthorID != userID {
+        return nil, fmt.Errorf("access denied")
+    }
+
+    return template, nil
+}
+
+// insertBlocks добавляет блоки в материал по одному через операцию add, как AddBlock:
+// каждый блок получает новый ID, проходит проверку и рассылается соавторам.
+// Если вставка сорвется на середине, уже добавленные блоки остаются в материале
+func (s *LibraryService) insertBlocks(ctx context.Context, userID int, req *models.InsertBlocksRequest, blocks []models.Block) (*models.InsertBlocksResult, error) {
+    result := &models.InsertBlocksResult{MaterialID: req.MaterialID, BlockIDs: make([]string, 0, len(blocks))}
+
+    for i := range blocks {
+        block := blocks[i]
+        block.ID = ""
+
+        op := &models.BlockOperation{
+            MaterialID: req.MaterialID,
+            Type:       "add",
+            Block:      &block,
+        }
+        if req.Position != nil && *req.Position >= 0 {
+            position := *req.Position + i
+            op.Position = &position
+        }
+
+        if _, err := s.materialService.ApplyBlockOperation(ctx, userID, op); err != nil {
+            return nil, err
+        }
+        result.BlockIDs = append(result.BlockIDs, block.ID)
+    }
+
+    return result, nil
+}
+
+// sourceBlocks возвращает блоки из запроса или из материала, который пользователь может
+// редактировать: перечисленные blockIDs в заданном порядке или все блоки материала
+func (s *LibraryService) sourceBlocks(ctx context.Context, userID int, blocks []models.Block, materialID int, blockIDs []string) ([]models.Block, error) {
+    if materialID == 0 {
+        return blocks, nil
+    }
+
+    if _, _, err := s.materialService.CheckAccess(ctx, userID, materialID, "editor"); err != nil {
+        return nil, err
+    }
+    material, err := s.materialService.GetMaterial(ctx, userID, materialID)
+    if err != nil {
+        return nil, err
+    }
+    if material == nil {
+        return nil, fmt.Errorf("material not found")
+    }
+    if len(blockIDs) == 0 {
+        return material.Blocks, nil
+    }
+
+    selected := make([]models.Block, 0, len(blockIDs))
+    for _, id := range blockIDs {
+        found := false
+        for _, block := range material.Blocks {
+            if block.ID == id {
+                selected = append(selected, block)
+                found = true
+                break
+            }
+        }
+        if !found {
+            return nil, fmt.Errorf("block not found")
+        }
+    }
+    return selected, nil
+}
+
+// prepareBlocks проверяет блоки так же, как при сохранении в материал, и убирает ID:
+// при вставке блоки получают новые
+func (s *LibraryService) prepareBlocks(blocks []models.Block) ([]models.Block, error) {
+    if len(blocks) == 0 {
+        return nil, fmt.Errorf("no blocks")
+    }
+    if len(blocks) > maxLibraryBlocks {
+        return nil, fmt.Errorf("too many blocks")
+    }
+    for _, block := range blocks {
+        if !blockTypes[block.Type] {
+            return nil, fmt.Errorf("unsupported block type")
+        }
+    }
+
+    if err := normalizeBlocks(blocks); err != nil {
+        return nil, err
+    }
+    if errs := normalizeBlockAnimations(blocks); len(errs) > 0 {
+        return nil, &AnimationValidationError{Errors: errs}
+    }
+    if err := s.formulaService.PrepareBlocks(blocks); err != nil {
+        return nil, err
+    }
+
+    for i := range blocks {
+        blocks[i].ID = ""
+        blocks[i].Position = i
+    }
+    return blocks, nil
+}
+
+// checkSubject проверяет, что предмет существует (пустой предмет допустим)
+func (s *LibraryService) checkSubject(ctx context.Context, subject string) error {
+    if subject == "" {
+        return nil
+    }
+    exists, err := s.materialRepo.SubjectExists(ctx, subject)
+    if err != nil {
+        return err
+    }
+    if !exists {
+        return fmt.Errorf("unknown subject")
+    }
+    return nil
+}
@@ -0,0 +1,1000 @@
+package services
+
+import (
+    "bytes"
+    "context"
+    "crypto/rand"
+    "crypto/rsa"
+    "crypto/sha256"
+    "crypto/subtle"
+    "crypto/x509"
+    "encoding/base64"
+    "encoding/hex"
+    "encoding/json"
+    "encoding/pem"
+    "fmt"
+    "io"
+    "log"
+    "math/big"
+    "net/http"
+    "net/url"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/repositories"
+
+    "github.com/golang-jwt/jwt/v5"
+    "github.com/google/uuid"
+    "golang.org/x/crypto/bcrypt"
+)
+
+const (
+    ltiVersion             = "1.3.0"
+    ltiClaim               = "https://purl.imsglobal.org/spec/lti/claim/"
+    ltiDeepLinkingClaim    = "https://purl.imsglobal.org/spec/lti-dl/claim/"
+    ltiMessageResourceLink = "LtiResourceLinkRequest"
+    ltiMessageDeepLinking  = "LtiDeepLinkingRequest"
+    ltiScopeScore          = "https://purl.imsglobal.org/spec/lti-ags/scope/score"
+    ltiMaxScore            = 5 // оценки в material_completions - от 1 до 5
+)
+
+// LTILoginTTL - время от инициации входа до запуска
+const LTILoginTTL = 10 * time.Minute
+
+// LTIStateCookie - имя cookie, которой state входа привязан к браузеру, начавшему вход.
+// В имени есть state, чтобы параллельные запуски в разных вкладках не мешали друг другу
+func LTIStateCookie(state string) string {
+    return "lti_state_" + state
+}
+
+// LTIConfig - настройки LTI-инструмента
+type LTIConfig struct {
+    ToolURL     string // публичный адрес API, например https://api.paydeya.ru
+    FrontendURL string // куда перенаправлять пользователя после запуска
+}
+
+// ltiLaunchClaims - id_token запуска от платформы
+type ltiLaunchClaims struct {
+    jwt.RegisteredClaims
+    Nonce           string                 `json:"nonce"`
+    AuthorizedParty string                 `json:"azp"`
+    Name            string                 `json:"name"`
+    GivenName       string                 `json:"given_name"`
+    FamilyName      string                 `json:"family_name"`
+    Email           string                 `json:"email"`
+    MessageType     string                 `json:"https://purl.imsglobal.org/spec/lti/claim/message_type"`
+    Version         string                 `json:"https://purl.imsglobal.org/spec/lti/claim/version"`
+    DeploymentID    string                 `json:"https://purl.imsglobal.org/spec/lti/claim/deployment_id"`
+    TargetLinkURI   string                 `json:"https://purl.imsglobal.org/spec/lti/claim/target_link_uri"`
+    Roles           []string               `json:"https://purl.imsglobal.org/spec/lti/claim/roles"`
+    Custom          map[string]interface{} `json:"https://purl.imsglobal.org/spec/lti/claim/custom"`
+    ResourceLink    *struct {
+        ID string `json:"id"`
+    } `json:"https://purl.imsglobal.org/spec/lti/claim/resource_link"`
+    Context *struct {
+        ID string `json:"id"`
+    } `json:"https://purl.imsglobal.org/spec/lti/claim/context"`
+    Endpoint *struct {
+        Scope    []string `json:"scope"`
+        LineItem string   `json:"lineitem"`
+    } `json:"https://purl.imsglobal.org/spec/lti-ags/claim/endpoint"`
+    DeepLinking *struct {
+        ReturnURL      string   `json:"deep_link_return_url"`
+        AcceptTypes    []string `json:"accept_types"`
+        AcceptMultiple *bool    `json:"accept_multiple"`
+        Data           string   `json:"data"`
+    } `json:"https://purl.imsglobal.org/spec/lti-dl/claim/deep_linking_settings"`
+}
+
+// ltiToolKey - ключ инструмента с разобранным приватным ключом
+type ltiToolKey struct {
+    kid string
+    key *rsa.PrivateKey
+}
+
+// ltiPlatformKeys - закэшированные публичные ключи платформы
+type ltiPlatformKeys struct {
+    keys      map[string]*rsa.PublicKey
+    fetchedAt time.Time
+}
+
+// ltiAccessToken - закэшированный токен Assignment and Grade Services
+type ltiAccessToken struct {
+    token     string
+    expiresAt time.Time
+}
+
+// ltiStore - хранилище LTI (repositories.LTIRepository)
+type ltiStore interface {
+    CreatePlatform(ctx context.Context, p *models.LTIPlatform) (bool, error)
+    GetPlatforms(ctx context.Context) ([]models.LTIPlatform, error)
+    GetPlatform(ctx context.Context, id int) (*models.LTIPlatform, error)
+    FindPlatform(ctx context.Context, issuer, clientID string) (*models.LTIPlatform, error)
+    DeletePlatform(ctx context.Context, id int) (bool, error)
+    GetToolKeys(ctx context.Context) ([]repositories.LTIToolKey, error)
+    CreateToolKey(ctx context.Context, key repositories.LTIToolKey) error
+    SaveLoginState(ctx context.Context, state, nonce string, platformID int, expiresAt time.Time) error
+    ConsumeLoginState(ctx context.Context, state string) (*repositories.LTILoginState, error)
+    GetLinkedUserID(ctx context.Context, platformID int, sub string) (int, error)
+    CreateLinkedUser(ctx context.Context, platformID int, sub string, user *models.User) (bool, error)
+    SaveLaunch(ctx context.Context, platformID int, resourceLinkID string, materialID int, contextID, lineItemURL *string, userID int) error
+    GetGradeTargets(ctx context.Context, userID, materialID int) ([]models.LTIGradeTarget, error)
+    CreateDeepLinkSession(ctx context.Context, s *models.LTIDeepLinkSession) error
+    GetDeepLinkSession(ctx context.Context, id string, userID int) (*models.LTIDeepLinkSession, error)
+    DeleteDeepLinkSession(ctx context.Context, id string) error
+}
+
+// ltiUserStore - пользователи, которых заводит запуск (repositories.UserRepository)
+type ltiUserStore interface {
+    GetUserByID(ctx context.Context, id int) (*models.User, error)
+    EmailExists(ctx context.Context, email string) (bool, error)
+}
+
+// ltiMaterialStore - материалы, на которые ссылаются запуски (repositories.MaterialRepository)
+type ltiMaterialStore interface {
+    GetMaterial(ctx context.Context, id int) (*models.Material, error)
+}
+
+type LTIService struct {
+    ltiRepo        ltiStore
+    userRepo       ltiUserStore
+    materialRepo   ltiMaterialStore
+    authService    *AuthService
+    catalogService *CatalogService
+    config         LTIConfig
+    client         *http.Client
+
+    mu           sync.Mutex
+    toolKeys     []ltiToolKey
+    platformKeys map[int]ltiPlatformKeys
+    accessTokens map[int]ltiAccessToken
+}
+
+func NewLTIService(
+    ltiRepo *repositories.LTIRepository,
+    userRepo *repositories.UserRepository,
+    materialRepo *repositories.MaterialRepository,
+    authService *AuthService,
+    catalogService *CatalogService,
+    config LTIConfig,
+) *LTIService {
+    config.ToolURL = strings.TrimRight(config.ToolURL, "/")
+    config.FrontendURL = strings.TrimRight(config.FrontendURL, "/")
+
+    return &LTIService{
+        ltiRepo:        ltiRepo,
+        userRepo:       userRepo,
+        materialRepo:   materialRepo,
+        authService:    authService,
+        catalogService: catalogService,
+        config:         config,
+        client:         &http.Client{Timeout: 10 * time.Second},
+        platformKeys:   make(map[int]ltiPlatformKeys),
+        accessTokens:   make(map[int]ltiAccessToken),
+    }
+}
+
+// CreatePlatform регистрирует LMS-платформу
+func (s *LTIService) CreatePlatform(ctx context.Context, req *models.CreateLTIPlatformRequest) (*models.LTIPlatform, error) {
+    platform := &models.LTIPlatform{
+        Name:          req.Name,
+        Issuer:        req.Issuer,
+        ClientID:      req.ClientID,
+        DeploymentIDs: req.DeploymentIDs,
+        AuthLoginURL:  req.AuthLoginURL,
+        AuthTokenURL:  req.AuthTokenURL,
+        JWKSURL:       req.JWKSURL,
+    }
+
+    created, err := s.ltiRepo.CreatePlatform(ctx, platform)
+    if err != nil {
+        return nil, err
+    }
+    if !created {
+        return nil, fmt.Errorf("platform already registered")
+    }
+
+    return platform, nil
+}
+
+// GetPlatforms возвращает зарегистрированные платформы
+func (s *LTIService) GetPlatforms(ctx context.Context) ([]models.LTIPlatform, error) {
+    return s.ltiRepo.GetPlatforms(ctx)
+}
+
+// DeletePlatform удаляет платформу
+func (s *LTIService) DeletePlatform(ctx context.Context, id int) error {
+    deleted, err := s.ltiRepo.DeletePlatform(ctx, id)
+    if err != nil {
+        return err
+    }
+    if !deleted {
+        return fmt.Errorf("platform not found")
+    }
+
+    s.mu.Lock()
+    delete(s.platformKeys, id)
+    delete(s.accessTokens, id)
+    s.mu.Unlock()
+
+    return nil
+}
+
+// Login обрабатывает OIDC-инициацию входа и возвращает адрес авторизации платформы и state,
+// который нужно сохранить в cookie браузера (LTIStateCookie) для проверки при запуске
+func (s *LTIService) Login(ctx context.Context, req *models.LTILoginRequest) (string, string, error) {
+    platform, err := s.ltiRepo.FindPlatform(ctx, req.Issuer, req.ClientID)
+    if err != nil {
+        return "", "", err
+    }
+    if platform == nil {
+        return "", "", fmt.Errorf("platform not registered")
+    }
+    if req.LTIDeploymentID != "" && !containsString(platform.DeploymentIDs, req.LTIDeploymentID) {
+        return "", "", fmt.Errorf("deployment not registered")
+    }
+
+    state, err := ltiRandomToken()
+    if err != nil {
+        return "", "", err
+    }
+    nonce, err := ltiRandomToken()
+    if err != nil {
+        return "", "", err
+    }
+
+    if err := s.ltiRepo.SaveLoginState(ctx, state, nonce, platform.ID, time.Now().Add(LTILoginTTL)); err != nil {
+        return "", "", err
+    }
+
+    params := url.Values{
+        "scope":         {"openid"},
+        "response_type": {"id_token"},
+        "response_mode": {"form_post"},
+        "prompt":        {"none"},
+        "client_id":     {platform.ClientID},
+        "redirect_uri":  {s.config.ToolURL + "/api/v1/lti/launch"},
+        "login_hint":    {req.LoginHint},
+        "state":         {state},
+        "nonce":         {nonce},
+    }
+    if req.LTIMessageHint != "" {
+        params.Set("lti_message_hint", req.LTIMessageHint)
+    }
+
+    separator := "?"
+    if strings.Contains(platform.AuthLoginURL, "?") {
+        separator = "&"
+    }
+
+    return platform.AuthLoginURL + separator + params.Encode(), state, nil
+}
+
+// Launch проверяет подписанный запуск от платформы, заводит пользователя и возвращает
+// адрес фронтенда, куда его перенаправить. Токены передаются во фрагменте адреса.
+// browserState - значение cookie LTIStateCookie: запуск принимается только в браузере,
+// который начал вход, иначе чужой id_token можно было бы подсунуть жертве (login CSRF)
+func (s *LTIService) Launch(ctx context.Context, idToken, state, browserState string) (string, error) {
+    if browserState == "" || subtle.ConstantTimeCompare([]byte(browserState), []byte(state)) != 1 {
+        return "", fmt.Errorf("invalid state")
+    }
+
+    loginState, err := s.ltiRepo.ConsumeLoginState(ctx, state)
+    if err != nil {
+        return "", err
+    }
+    if loginState == nil {
+        return "", fmt.Errorf("invalid state")
+    }
+
+    platform, err := s.ltiRepo.GetPlatform(ctx, loginState.PlatformID)
+    if err != nil {
+        return "", err
+    }
+    if platform == nil {
+        return "", fmt.Errorf("platform not registered")
+    }
+
+    claims := &ltiLaunchClaims{}
+    _, err = jwt.ParseWithClaims(idToken, claims,
+        func(token *jwt.Token) (interface{}, error) {
+            kid, _ := token.Header["kid"].(string)
+            return s.platformKey(ctx, platform, kid)
+        },
+        jwt.WithValidMethods([]string{"RS256"}),
+        jwt.WithIssuer(platform.Issuer),
+        jwt.WithAudience(platform.ClientID),
+        jwt.WithExpirationRequired(),
+        jwt.WithIssuedAt(),
+        jwt.WithLeeway(time.Minute),
+    )
+    if err != nil {
+        log.Printf("⚠️ LTI: rejected id_token from %s: %v", platform.Issuer, err)
+        return "", fmt.Errorf("invalid id_token")
+    }
+
+    // При нескольких получателях токен должен быть выдан именно нам
+    if len(claims.Audience) > 1 && claims.AuthorizedParty != platform.ClientID {
+        return "", fmt.Errorf("invalid id_token")
+    }
+    if claims.Nonce != loginState.Nonce {
+        return "", fmt.Errorf("invalid nonce")
+    }
+    if claims.Version != ltiVersion {
+        return "", fmt.Errorf("unsupported LTI version")
+    }
+    if !containsString(platform.DeploymentIDs, claims.DeploymentID) {
+        return "", fmt.Errorf("deployment not registered")
+    }
+    if claims.Subject == "" {
+        return "", fmt.Errorf("anonymous launch not supported")
+    }
+
+    user, err := s.provisionUser(ctx, platform, claims)
+    if err != nil {
+        return "", err
+    }
+    if user.IsBlocked {
+        return "", fmt.Errorf("account is blocked")
+    }
+
+    var path string
+    switch claims.MessageType {
+    case ltiMessageResourceLink:
+        path, err = s.resourceLinkLaunch(ctx, platform, claims, user)
+    case ltiMessageDeepLinking:
+        path, err = s.deepLinkingLaunch(ctx, platform, claims, user)
+    default:
+        err = fmt.Errorf("unsupported message type")
+    }
+    if err != nil {
+        return "", err
+    }
+
+    accessToken, refreshToken, err := s.authService.GenerateTokens(user)
+    if err != nil {
+        return "", err
+    }
+
+    fragment := url.Values{
+        "accessToken":  {accessToken},
+        "refreshToken": {refreshToken},
+    }
+
+    return s.config.FrontendURL + path + "#" + fragment.Encode(), nil
+}
+
+// resourceLinkLaunch открывает материал, на который указывает ссылка в курсе
+func (s *LTIService) resourceLinkLaunch(ctx context.Context, platform *models.LTIPlatform, claims *ltiLaunchClaims, user *models.User) (string, error) {
+    if claims.ResourceLink == nil || claims.ResourceLink.ID == "" {
+        return "", fmt.Errorf("invalid id_token")
+    }
+
+    materialID := ltiMaterialID(claims)
+    if materialID == 0 {
+        return "", fmt.Errorf("material not linked")
+    }
+
+    material, err := s.materialRepo.GetMaterial(ctx, materialID)
+    if err != nil {
+        return "", err
+    }
+    if material == nil || !isMaterialLive(material, time.Now()) {
+        return "", fmt.Errorf("material not found")
+    }
+
+    var contextID, lineItemURL *string
+    if claims.Context != nil && claims.Context.ID != "" {
+        contextID = &claims.Context.ID
+    }
+    if claims.Endpoint != nil && claims.Endpoint.LineItem != "" && containsString(claims.Endpoint.Scope, ltiScopeScore) {
+        lineItemURL = &claims.Endpoint.LineItem
+    }
+
+    if err := s.ltiRepo.SaveLaunch(ctx, platform.ID, claims.ResourceLink.ID, materialID, contextID, lineItemURL, user.ID); err != nil {
+        return "", err
+    }
+
+    // Материал мог быть пройден до того, как его добавили в курс - сразу выставляем оценку
+    if lineItemURL != nil {
+        s.PassbackGrade(user.ID, materialID)
+    }
+
+    return fmt.Sprintf("/materials/%d", materialID), nil
+}
+
+// deepLinkingLaunch открывает выбор материалов для вставки в курс
+func (s *LTIService) deepLinkingLaunch(ctx context.Context, platform *models.LTIPlatform, claims *ltiLaunchClaims, user *models.User) (string, error) {
+    if user.Role != "teacher" && user.Role != "admin" {
+        return "", fmt.Errorf("access denied")
+    }
+
+    settings := claims.DeepLinking
+    if settings == nil || settings.ReturnURL == "" {
+        return "", fmt.Errorf("invalid id_token")
+    }
+    if !containsString(settings.AcceptTypes, "ltiResourceLink") {
+        return "", fmt.Errorf("resource links not accepted")
+    }
+
+    session := &models.LTIDeepLinkSession{
+        ID:             uuid.NewString(),
+        PlatformID:     platform.ID,
+        DeploymentID:   claims.DeploymentID,
+        UserID:         user.ID,
+        ReturnURL:      settings.ReturnURL,
+        Data:           settings.Data,
+        AcceptMultiple: settings.AcceptMultiple == nil || *settings.AcceptMultiple,
+        ExpiresAt:      time.Now().Add(time.Hour),
+    }
+
+    if err := s.ltiRepo.CreateDeepLinkSession(ctx, session); err != nil {
+        return "", err
+    }
+
+    return "/lti/deep-linking/" + session.ID, nil
+}
+
+// provisionUser возвращает пользователя, связанного с sub платформы, и заводит нового при первом запуске.
+// С существующими аккаунтами по email не связываем: платформа не подтверждает владение адресом
+func (s *LTIService) provisionUser(ctx context.Context, platform *models.LTIPlatform, claims *ltiLaunchClaims) (*models.User, error) {
+    userID, err := s.ltiRepo.GetLinkedUserID(ctx, platform.ID, claims.Subject)
+    if err != nil {
+        return nil, err
+    }
+    if userID != 0 {
+        return s.linkedUser(ctx, userID)
+    }
+
+    email := strings.ToLower(strings.TrimSpace(claims.Email))
+    if email != "" {
+        exists, err := s.userRepo.EmailExists(ctx, email)
+        if err != nil {
+            return nil, err
+        }
+        if exists {
+            email = ""
+        }
+    }
+    if email == "" {
+        subHash := sha256.Sum256([]byte(claims.Subject))
+        email = fmt.Sprintf("lti-%d-%s@lti.invalid", platform.ID, hex.EncodeToString(subHash[:8]))
+    }
+
+    fullName := strings.TrimSpace(claims.Name)
+    if fullName == "" {
+        fullName = strings.TrimSpace(claims.GivenName + " " + claims.FamilyName)
+    }
+    if fullName == "" {
+        fullName = "Пользователь " + platform.Name
+    }
+
+    role := "student"
+    if ltiIsInstructor(claims.Roles) {
+        role = "teacher"
+    }
+
+    // Вход по паролю для таких пользователей не предусмотрен - пароль случайный
+    password, err := ltiRandomToken()
+    if err != nil {
+        return nil, err
+    }
+    passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+    if err != nil {
+        return nil, err
+    }
+
+    user := &models.User{
+        Email:        email,
+        PasswordHash: string(passwordHash),
+        FullName:     fullName,
+        Role:         role,
+        IsVerified:   true,
+    }
+
+    created, err := s.ltiRepo.CreateLinkedUser(ctx, platform.ID, claims.Subject, user)
+    if err != nil {
+        return nil, err
+    }
+    if created {
+        return user, nil
+    }
+
+    // Пользователя успел завести параллельный запуск
+    userID, err = s.ltiRepo.GetLinkedUserID(ctx, platform.ID, claims.Subject)
+    if err != nil {
+        return nil, err
+    }
+    return s.linkedUser(ctx, userID)
+}
+
+func (s *LTIService) linkedUser(ctx context.Context, userID int) (*models.User, error) {
+    user, err := s.userRepo.GetUserByID(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+    if user == nil {
+        return nil, fmt.Errorf("user not found")
+    }
+    return user, nil
+}
+
+// GetDeepLinkSession возвращает сессию выбора материалов
+func (s *LTIService) GetDeepLinkSession(ctx context.Context, userID int, sessionID string) (*models.LTIDeepLinkSession, error) {
+    session, err := s.ltiRepo.GetDeepLinkSession(ctx, sessionID, userID)
+    if err != nil {
+        return nil, err
+    }
+    if session == nil {
+        return nil, fmt.Errorf("deep linking session not found")
+    }
+    return session, nil
+}
+
+// SearchDeepLinkMaterials возвращает сессию выбора и материалы каталога для вставки в курс
+func (s *LTIService) SearchDeepLinkMaterials(ctx context.Context, userID int, sessionID string, filters models.CatalogFilters) (*models.LTIDeepLinkSession, []models.CatalogMaterial, int, error) {
+    session, err := s.GetDeepLinkSession(ctx, userID, sessionID)
+    if err != nil {
+        return nil, nil, 0, err
+    }
+
+    materials, total, err := s.catalogService.SearchMaterials(ctx, filters)
+    if err != nil {
+        return nil, nil, 0, err
+    }
+
+    return session, materials, total, nil
+}
+
+// CompleteDeepLinking формирует подписанный ответ deep linking с выбранными материалами
+func (s *LTIService) CompleteDeepLinking(ctx context.Context, userID int, sessionID string, materialIDs []int) (*models.LTIDeepLinkResponse, error) {
+    session, err := s.GetDeepLinkSession(ctx, userID, sessionID)
+    if err != nil {
+        return nil, err
+    }
+    if !session.AcceptMultiple && len(materialIDs) > 1 {
+        return nil, fmt.Errorf("multiple materials not accepted")
+    }
+
+    platform, err := s.ltiRepo.GetPlatform(ctx, session.PlatformID)
+    if err != nil {
+        return nil, err
+    }
+    if platform == nil {
+        return nil, fmt.Errorf("platform not registered")
+    }
+
+    items := make([]map[string]interface{}, 0, len(materialIDs))
+    for _, materialID := range materialIDs {
+        material, err := s.materialRepo.GetMaterial(ctx, materialID)
+        if err != nil {
+            return nil, err
+        }
+        if material == nil || !isMaterialLive(material, time.Now()) {
+            return nil, fmt.Errorf("material not found")
+        }
+
+        items = append(items, map[string]interface{}{
+            "type":   "ltiResourceLink",
+            "title":  material.Title,
+            "text":   material.Description,
+            "url":    s.config.ToolURL + "/api/v1/lti/launch",
+            "custom": map[string]string{"material_id": strconv.Itoa(material.ID)},
+            "lineItem": map[string]interface{}{
+                "scoreMaximum": ltiMaxScore,
+                "label":        material.Title,
+                "resourceId":   fmt.Sprintf("material-%d", material.ID),
+            },
+        })
+    }
+
+    nonce, err := ltiRandomToken()
+    if err != nil {
+        return nil, err
+    }
+
+    now := time.Now()
+    claims := jwt.MapClaims{
+        "iss":                                 platform.ClientID,
+        "aud":                                 platform.Issuer,
+        "iat":                                 now.Unix(),
+        "exp":                                 now.Add(5 * time.Minute).Unix(),
+        "nonce":                               nonce,
+        ltiClaim + "message_type":             "LtiDeepLinkingResponse",
+        ltiClaim + "version":                  ltiVersion,
+        ltiClaim + "deployment_id":            session.DeploymentID,
+        ltiDeepLinkingClaim + "content_items": items,
+    }
+    if session.Data != "" {
+        claims[ltiDeepLinkingClaim+"data"] = session.Data
+    }
+
+    signed, err := s.sign(ctx, claims)
+    if err != nil {
+        return nil, err
+    }
+
+    if err := s.ltiRepo.DeleteDeepLinkSession(ctx, session.ID); err != nil {
+        return nil, err
+    }
+
+    return &models.LTIDeepLinkResponse{ReturnURL: session.ReturnURL, JWT: signed}, nil
+}
+
+// PassbackGrade отправляет оценку за материал из material_completions в журналы LMS,
+// из которых ученик запускал материал. Отправка выполняется в фоне
+func (s *LTIService) PassbackGrade(userID, materialID int) {
+    go func() {
+        ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+        defer cancel()
+
+        targets, err := s.ltiRepo.GetGradeTargets(ctx, userID, materialID)
+        if err != nil {
+            log.Printf("⚠️ LTI: failed to get grade targets for material %d: %v", materialID, err)
+            return
+        }
+
+        for _, target := range targets {
+            if err := s.sendScore(ctx, target); err != nil {
+                log.Printf("⚠️ LTI: failed to pass grade for material %d to %s: %v", materialID, target.LineItemURL, err)
+            }
+        }
+    }()
+}
+
+// sendScore публикует результат в колонку журнала (AGS Score Publish Service)
+func (s *LTIService) sendScore(ctx context.Context, target models.LTIGradeTarget) error {
+    platform, err := s.ltiRepo.GetPlatform(ctx, target.PlatformID)
+    if err != nil {
+        return err
+    }
+    if platform == nil {
+        return fmt.Errorf("platform not registered")
+    }
+
+    score := map[string]interface{}{
+        "userId":           target.Sub,
+        "activityProgress": "Completed",
+        "gradingProgress":  "Pending",
+        "timestamp":        target.CompletedAt.Format(time.RFC3339Nano),
+    }
+    if target.Grade != nil {
+        score["scoreGiven"] = *target.Grade
+        score["scoreMaximum"] = ltiMaxScore
+        score["gradingProgress"] = "FullyGraded"
+    }
+
+    body, err := json.Marshal(score)
+    if err != nil {
+        return err
+    }
+
+    // Адрес колонки может содержать параметры - /scores добавляется к пути
+    scoresURL, err := url.Parse(target.LineItemURL)
+    if err != nil {
+        return err
+    }
+    scoresURL.Path = strings.TrimRight(scoresURL.Path, "/") + "/scores"
+
+    token, err := s.accessToken(ctx, platform)
+    if err != nil {
+        return err
+    }
+
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, scoresURL.String(), bytes.NewReader(body))
+    if err != nil {
+        return err
+    }
+    req.Header.Set("Content-Type", "application/vnd.ims.lis.v1.score+json")
+    req.Header.Set("Authorization", "Bearer "+token)
+
+    resp, err := s.client.Do(req)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+        return nil
+    }
+
+    respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+    return fmt.Errorf("platform responded %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+}
+
+// accessToken получает токен AGS по client_credentials с подписанным client assertion
+func (s *LTIService) accessToken(ctx context.Context, platform *models.LTIPlatform) (string, error) {
+    s.mu.Lock()
+    cached, ok := s.accessTokens[platform.ID]
+    s.mu.Unlock()
+    if ok && time.Now().Before(cached.expiresAt) {
+        return cached.token, nil
+    }
+
+    now := time.Now()
+    assertion, err := s.sign(ctx, jwt.MapClaims{
+        "iss": platform.ClientID,
+        "sub": platform.ClientID,
+        "aud": platform.AuthTokenURL,
+        "iat": now.Unix(),
+        "exp": now.Add(5 * time.Minute).Unix(),
+        "jti": uuid.NewString(),
+    })
+    if err != nil {
+        return "", err
+    }
+
+    form := url.Values{
+        "grant_type":            {"client_credentials"},
+        "client_assertion_type": {"urn:ietf:params:oauth:client-assertion-type:jwt-bearer"},
+        "client_assertion":      {assertion},
+        "scope":                 {ltiScopeScore},
+    }
+
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, platform.AuthTokenURL, strings.NewReader(form.Encode()))
+    if err != nil {
+        return "", err
+    }
+    req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+    resp, err := s.client.Do(req)
+    if err != nil {
+        return "", err
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode != http.StatusOK {
+        respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+        return "", fmt.Errorf("token endpoint responded %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+    }
+
+    var result struct {
+        AccessToken string `json:"access_token"`
+        ExpiresIn   int    `json:"expires_in"`
+    }
+    if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+        return "", err
+    }
+    if result.AccessToken == "" {
+        return "", fmt.Errorf("token endpoint returned no access token")
+    }
+    if result.ExpiresIn <= 0 {
+        result.ExpiresIn = 3600
+    }
+
+    // Обновляем токен заранее, чтобы он не истек в процессе запроса
+    s.mu.Lock()
+    s.accessTokens[platform.ID] = ltiAccessToken{
+        token:     result.AccessToken,
+        expiresAt: now.Add(time.Duration(result.ExpiresIn)*time.Second - time.Minute),
+    }
+    s.mu.Unlock()
+
+    return result.AccessToken, nil
+}
+
+// platformKey возвращает публичный ключ платформы по kid. Набор ключей кэшируется на час
+// и перезапрашивается при неизвестном kid, но не чаще раза в минуту
+func (s *LTIService) platformKey(ctx context.Context, platform *models.LTIPlatform, kid string) (*rsa.PublicKey, error) {
+    s.mu.Lock()
+    cached, ok := s.platformKeys[platform.ID]
+    s.mu.Unlock()
+
+    if ok {
+        key, found := cached.keys[kid]
+        age := time.Since(cached.fetchedAt)
+        if found && age < time.Hour {
+            return key, nil
+        }
+        if !found && age < time.Minute {
+            return nil, fmt.Errorf("unknown key %q", kid)
+        }
+    }
+
+    keys, err := s.fetchPlatformKeys(ctx, platform.JWKSURL)
+    if err != nil {
+        return nil, err
+    }
+
+    s.mu.Lock()
+    s.platformKeys[platform.ID] = ltiPlatformKeys{keys: keys, fetchedAt: time.Now()}
+    s.mu.Unlock()
+
+    key, found := keys[kid]
+    if !found {
+        return nil, fmt.Errorf("unknown key %q", kid)
+    }
+    return key, nil
+}
+
+func (s *LTIService) fetchPlatformKeys(ctx context.Context, jwksURL string) (map[string]*rsa.PublicKey, error) {
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, jwksURL, nil)
+    if err != nil {
+        return nil, err
+    }
+
+    resp, err := s.client.Do(req)
+    if err != nil {
+        return nil, err
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode != http.StatusOK {
+        return nil, fmt.Errorf("JWKS endpoint responded %d", resp.StatusCode)
+    }
+
+    var jwks models.LTIJWKS
+    if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&jwks); err != nil {
+        return nil, err
+    }
+
+    keys := make(map[string]*rsa.PublicKey)
+    for _, jwk := range jwks.Keys {
+        if jwk.Kty != "RSA" || (jwk.Use != "" && jwk.Use != "sig") {
+            continue
+        }
+
+        n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+        if err != nil {
+            continue
+        }
+        e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+        if err != nil {
+            continue
+        }
+
+        keys[jwk.Kid] = &rsa.PublicKey{
+            N: new(big.Int).SetBytes(n),
+            E: int(new(big.Int).SetBytes(e).Int64()),
+        }
+    }
+
+    return keys, nil
+}
+
+// GetJWKS возвращает публичные ключи инструмента
+func (s *LTIService) GetJWKS(ctx context.Context) (*models.LTIJWKS, error) {
+    keys, err := s.loadToolKeys(ctx)
+    if err != nil {
+        return nil, err
+    }
+
+    jwks := &models.LTIJWKS{Keys: []models.LTIJWK{}}
+    for _, k := range keys {
+        jwks.Keys = append(jwks.Keys, models.LTIJWK{
+            Kty: "RSA",
+            Kid: k.kid,
+            Use: "sig",
+            Alg: "RS256",
+            N:   base64.RawURLEncoding.EncodeToString(k.key.N.Bytes()),
+            E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.key.E)).Bytes()),
+        })
+    }
+
+    return jwks, nil
+}
+
+// sign подписывает JWT новейшим ключом инструмента
+func (s *LTIService) sign(ctx context.Context, claims jwt.MapClaims) (string, error) {
+    keys, err := s.loadToolKeys(ctx)
+    if err != nil {
+        return "", err
+    }
+
+    token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+    token.Header["kid"] = keys[0].kid
+
+    return token.SignedString(keys[0].key)
+}
+
+// loadToolKeys загружает ключи инструмента из БД и создает ключ при первом обращении.
+// Если два инстанса создадут ключи одновременно, в JWKS попадут оба, подпись - новейшим
+func (s *LTIService) loadToolKeys(ctx context.Context) ([]ltiToolKey, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    if len(s.toolKeys) > 0 {
+        return s.toolKeys, nil
+    }
+
+    stored, err := s.ltiRepo.GetToolKeys(ctx)
+    if err != nil {
+        return nil, err
+    }
+
+    if len(stored) == 0 {
+        key, err := rsa.GenerateKey(rand.Reader, 2048)
+        if err != nil {
+            return nil, err
+        }
+        der, err := x509.MarshalPKCS8PrivateKey(key)
+        if err != nil {
+            return nil, err
+        }
+        kid, err := ltiRandomToken()
+        if err != nil {
+            return nil, err
+        }
+
+        newKey := repositories.LTIToolKey{
+            KID:           kid[:16],
+            PrivateKeyPEM: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+        }
+        if err := s.ltiRepo.CreateToolKey(ctx, newKey); err != nil {
+            return nil, err
+        }
+
+        if stored, err = s.ltiRepo.GetToolKeys(ctx); err != nil {
+            return nil, err
+        }
+    }
+
+    keys := make([]ltiToolKey, 0, len(stored))
+    for _, k := range stored {
+        block, _ := pem.Decode([]byte(k.PrivateKeyPEM))
+        if block == nil {
+            return nil, fmt.Errorf("invalid tool key %s", k.KID)
+        }
+        parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+        if err != nil {
+            return nil, err
+        }
+        rsaKey, ok := parsed.(*rsa.PrivateKey)
+        if !ok {
+            return nil, fmt.Errorf("invalid tool key %s", k.KID)
+        }
+        keys = append(keys, ltiToolKey{kid: k.KID, key: rsaKey})
+    }
+
+    s.toolKeys = keys
+    return keys, nil
+}
+
+// ltiMaterialID берет ID материала из custom-параметра или из target_link_uri (?material_id=)
+func ltiMaterialID(claims *ltiLaunchClaims) int {
+    if value, ok := claims.Custom["material_id"]; ok {
+        if id, err := strconv.Atoi(fmt.Sprint(value)); err == nil {
+            return id
+        }
+    }
+
+    if target, err := url.Parse(claims.TargetLinkURI); err == nil {
+        if id, err := strconv.Atoi(target.Query().Get("material_id")); err == nil {
+            return id
+        }
+    }
+
+    return 0
+}
+
+// ltiIsInstructor - преподаватель, разработчик контента или администратор в LMS
+func ltiIsInstructor(roles []string) bool {
+    for _, role := range roles {
+        for _, suffix := range []string{"#Instructor", "#ContentDeveloper", "#Administrator"} {
+            if strings.HasSuffix(role, suffix) {
+                return true
+            }
+        }
+    }
+    return false
+}
+
+func ltiRandomToken() (string, error) {
+    b := make([]byte, 32)
+    if _, err := rand.Read(b); err != nil {
+        return "", err
+    }
+    return hex.EncodeToString(b), nil
+}
+
+func containsString(values []string, value string) bool {
+    for _, v := range values {
+        if v == value {
+            return true
+        }
+    }
+    return false
+}
@@ -0,0 +1,766 @@
+package services
+
+import (
+    "context"
+    "crypto/rand"
+    "crypto/rsa"
+    "encoding/base64"
+    "encoding/json"
+    "fmt"
+    "math/big"
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/repositories"
+
+    "github.com/golang-jwt/jwt/v5"
+)
+
+const (
+    testLTIClientID     = "paydeya-tool"
+    testLTIDeploymentID = "deployment-1"
+    testLTIPlatformKID  = "platform-key"
+    testLTIMaterialID   = 42
+    testLTIAccessToken  = "ags-access-token"
+)
+
+// fakeLTIDB - хранилище LTI, пользователей и материалов в памяти вместо PostgreSQL.
+// Оценки берутся из completions, как в LTIRepository.GetGradeTargets
+type fakeLTIDB struct {
+    mu          sync.Mutex
+    platforms   map[int]*models.LTIPlatform
+    states      map[string]fakeLoginState
+    toolKeys    []repositories.LTIToolKey
+    links       map[string]int
+    users       map[int]*models.User
+    materials   map[int]*models.Material
+    launches    []fakeLaunch
+    completions map[[2]int]fakeCompletion
+    sessions    map[string]*models.LTIDeepLinkSession
+}
+
+type fakeLoginState struct {
+    nonce      string
+    platformID int
+    expiresAt  time.Time
+}
+
+type fakeLaunch struct {
+    platformID  int
+    sub         string
+    materialID  int
+    lineItemURL *string
+    userID      int
+}
+
+type fakeCompletion struct {
+    grade       *float64
+    completedAt time.Time
+}
+
+func newFakeLTIDB() *fakeLTIDB {
+    return &fakeLTIDB{
+        platforms:   make(map[int]*models.LTIPlatform),
+        states:      make(map[string]fakeLoginState),
+        links:       make(map[string]int),
+        users:       make(map[int]*models.User),
+        materials:   make(map[int]*models.Material),
+        completions: make(map[[2]int]fakeCompletion),
+        sessions:    make(map[string]*models.LTIDeepLinkSession),
+    }
+}
+
+func (db *fakeLTIDB) CreatePlatform(ctx context.Context, p *models.LTIPlatform) (bool, error) {
+    db.mu.Lock()
+    defer db.mu.Unlock()
+    p.ID = len(db.platforms) + 1
+    db.platforms[p.ID] = p
+    return true, nil
+}
+
+func (db *fakeLTIDB) GetPlatforms(ctx context.Context) ([]models.LTIPlatform, error) {
+    db.mu.Lock()
+    defer db.mu.Unlock()
+    platforms := make([]models.LTIPlatform, 0, len(db.platforms))
+    for _, p := range db.platforms {
+        platforms = append(platforms, *p)
+    }
+    return platforms, nil
+}
+
+func (db *fakeLTIDB) GetPlatform(ctx context.Context, id int) (*models.LTIPlatform, error) {
+    db.mu.Lock()
+    defer db.mu.Unlock()
+    return db.platforms[id], nil
+}
+
+func (db *fakeLTIDB) FindPlatform(ctx context.Context, issuer, clientID string) (*models.LTIPlatform, error) {
+    db.mu.Lock()
+    defer db.mu.Unlock()
+    for _, p := range db.platforms {
+        if p.Issuer == issuer && (clientID == "" || p.ClientID == clientID) {
+            return p, nil
+        }
+    }
+    return nil, nil
+}
+
+func (db *fakeLTIDB) DeletePlatform(ctx context.Context, id int) (bool, error) {
+    db.mu.Lock()
+    defer db.mu.Unlock()
+    _, found := db.platforms[id]
+    delete(db.platforms, id)
+    return found, nil
+}
+
+func (db *fakeLTIDB) GetToolKeys(ctx context.Context) ([]repositories.LTIToolKey, error) {
+    db.mu.Lock()
+    defer db.mu.Unlock()
+    return append([]repositories.LTIToolKey(nil), db.toolKeys...), nil
+}
+
+func (db *fakeLTIDB) CreateToolKey(ctx context.Context, key repositories.LTIToolKey) error {
+    db.mu.Lock()
+    defer db.mu.Unlock()
+    db.toolKeys = append([]repositories.LTIToolKey{key}, db.toolKeys...)
+    return nil
+}
+
+func (db *fakeLTIDB) SaveLoginState(ctx context.Context, state, nonce string, platformID int, expiresAt time.Time) error {
+    db.mu.Lock()
+    defer db.mu.Unlock()
+    db.states[state] = fakeLoginState{nonce: nonce, platformID: platformID, expiresAt: expiresAt}
+    return nil
+}
+
+func (db *fakeLTIDB) ConsumeLoginState(ctx context.Context, state string) (*repositories.LTILoginState, error) {
+    db.mu.Lock()
+    defer db.mu.Unlock()
+    s, found := db.states[state]
+    delete(db.states, state)
+    if !found || s.expiresAt.Before(time.Now()) {
+        return nil, nil
+    }
+    return &repositories.LTILoginState{Nonce: s.nonce, PlatformID: s.platformID}, nil
+}
+
+func (db *fakeLTIDB) GetLinkedUserID(ctx context.Context, platformID int, sub string) (int, error) {
+    db.mu.Lock()
+    defer db.mu.Unlock()
+    return db.links[fakeLinkKey(platformID, sub)], nil
+}
+
+func (db *fakeLTIDB) CreateLinkedUser(ctx context.Context, platformID int, sub string, user *models.User) (bool, error) {
+    db.mu.Lock()
+    defer db.mu.Unlock()
+    if _, found := db.links[fakeLinkKey(platformID, sub)]; found {
+        return false, nil
+    }
+    user.ID = len(db.users) + 1
+    db.users[user.ID] = user
+    db.links[fakeLinkKey(platformID, sub)] = user.ID
+    return true, nil
+}
+
+func (db *fakeLTIDB) SaveLaunch(ctx context.Context, platformID int, resourceLinkID string, materialID int, contextID, lineItemURL *string, userID int) error {
+    db.mu.Lock()
+    defer db.mu.Unlock()
+    var sub string
+    for key, id := range db.links {
+        if id == userID {
+            sub = strings.SplitN(key, "/", 2)[1]
+        }
+    }
+    db.launches = append(db.launches, fakeLaunch{platformID: platformID, sub: sub, materialID: materialID, lineItemURL: lineItemURL, userID: userID})
+    return nil
+}
+
+func (db *fakeLTIDB) GetGradeTargets(ctx context.Context, userID, materialID int) ([]models.LTIGradeTarget, error) {
+    db.mu.Lock()
+    defer db.mu.Unlock()
+    completion, completed := db.completions[[2]int{userID, materialID}]
+    if !completed {
+        return nil, nil
+    }
+    var targets []models.LTIGradeTarget
+    for _, launch := range db.launches {
+        if launch.userID == userID && launch.materialID == materialID && launch.lineItemURL != nil {
+            targets = append(targets, models.LTIGradeTarget{
+                PlatformID:  launch.platformID,
+                Sub:         launch.sub,
+                LineItemURL: *launch.lineItemURL,
+                Grade:       completion.grade,
+                CompletedAt: completion.completedAt,
+            })
+        }
+    }
+    return targets, nil
+}
+
+func (db *fakeLTIDB) CreateDeepLinkSession(ctx context.Context, s *models.LTIDeepLinkSession) error {
+    db.mu.Lock()
+    defer db.mu.Unlock()
+    db.sessions[s.ID] = s
+    return nil
+}
+
+func (db *fakeLTIDB) GetDeepLinkSession(ctx context.Context, id string, userID int) (*models.LTIDeepLinkSession, error) {
+    db.mu.Lock()
+    defer db.mu.Unlock()
+    s, found := db.sessions[id]
+    if !found || s.UserID != userID || s.ExpiresAt.Before(time.Now()) {
+        return nil, nil
+    }
+    return s, nil
+}
+
+func (db *fakeLTIDB) DeleteDeepLinkSession(ctx context.Context, id string) error {
+    db.mu.Lock()
+    defer db.mu.Unlock()
+    delete(db.sessions, id)
+    return nil
+}
+
+func (db *fakeLTIDB) GetUserByID(ctx context.Context, id int) (*models.User, error) {
+    db.mu.Lock()
+    defer db.mu.Unlock()
+    return db.users[id], nil
+}
+
+func (db *fakeLTIDB) EmailExists(ctx context.Context, email string) (bool, error) {
+    db.mu.Lock()
+    defer db.mu.Unlock()
+    for _, u := range db.users {
+        if u.Email == email {
+            return true, nil
+        }
+    }
+    return false, nil
+}
+
+func (db *fakeLTIDB) GetMaterial(ctx context.Context, id int) (*models.Material, error) {
+    db.mu.Lock()
+    defer db.mu.Unlock()
+    return db.materials[id], nil
+}
+
+func fakeLinkKey(platformID int, sub string) string {
+    return fmt.Sprintf("%d/%s", platformID, sub)
+}
+
+// fakeLMS - платформа (LMS): отдает JWKS, выдает токены AGS по client assertion инструмента
+// и принимает оценки в колонку журнала
+type fakeLMS struct {
+    server  *httptest.Server
+    key     *rsa.PrivateKey
+    service *LTIService
+    scores  chan fakeScore
+}
+
+type fakeScore struct {
+    authorization string
+    contentType   string
+    body          map[string]interface{}
+}
+
+func newFakeLMS(t *testing.T) *fakeLMS {
+    t.Helper()
+
+    key, err := rsa.GenerateKey(rand.Reader, 2048)
+    if err != nil {
+        t.Fatal(err)
+    }
+    lms := &fakeLMS{key: key, scores: make(chan fakeScore, 4)}
+
+    mux := http.NewServeMux()
+    mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+        json.NewEncoder(w).Encode(models.LTIJWKS{Keys: []models.LTIJWK{{
+            Kty: "RSA",
+            Kid: testLTIPlatformKID,
+            Use: "sig",
+            Alg: "RS256",
+            N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+            E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+        }}})
+    })
+    mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+        if err := lms.checkClientAssertion(r); err != nil {
+            http.Error(w, err.Error(), http.StatusUnauthorized)
+            return
+        }
+        json.NewEncoder(w).Encode(map[string]interface{}{
+            "access_token": testLTIAccessToken,
+            "token_type":   "Bearer",
+            "expires_in":   3600,
+        })
+    })
+    mux.HandleFunc("/lineitems/1/lineitem/scores", func(w http.ResponseWriter, r *http.Request) {
+        score := fakeScore{authorization: r.Header.Get("Authorization"), contentType: r.Header.Get("Content-Type")}
+        if err := json.NewDecoder(r.Body).Decode(&score.body); err != nil {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+        lms.scores <- score
+        w.WriteHeader(http.StatusNoContent)
+    })
+
+    lms.server = httptest.NewServer(mux)
+    t.Cleanup(lms.server.Close)
+    return lms
+}
+
+// checkClientAssertion проверяет запрос токена так же, как платформа: подпись ключом из JWKS инструмента
+func (lms *fakeLMS) checkClientAssertion(r *http.Request) error {
+    if err := r.ParseForm(); err != nil {
+        return err
+    }
+    if r.PostForm.Get("grant_type") != "client_credentials" || r.PostForm.Get("scope") != ltiScopeScore {
+        return jwt.ErrTokenMalformed
+    }
+
+    toolKeys, err := lms.toolKeys(r.Context())
+    if err != nil {
+        return err
+    }
+    claims := jwt.MapClaims{}
+    _, err = jwt.ParseWithClaims(r.PostForm.Get("client_assertion"), claims,
+        func(token *jwt.Token) (interface{}, error) {
+            kid, _ := token.Header["kid"].(string)
+            return toolKeys[kid], nil
+        },
+        jwt.WithValidMethods([]string{"RS256"}),
+        jwt.WithIssuer(testLTIClientID),
+        jwt.WithSubject(testLTIClientID),
+        jwt.WithAudience(lms.server.URL+"/token"),
+        jwt.WithExpirationRequired(),
+    )
+    return err
+}
+
+// toolKeys - публичные ключи инструмента, как их получает платформа из /lti/jwks
+func (lms *fakeLMS) toolKeys(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+    jwks, err := lms.service.GetJWKS(ctx)
+    if err != nil {
+        return nil, err
+    }
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        json.NewEncoder(w).Encode(jwks)
+    }))
+    defer server.Close()
+    return lms.service.fetchPlatformKeys(ctx, server.URL)
+}
+
+// idToken подписывает запуск ключом платформы; modify меняет утверждения для проверки отказов
+func (lms *fakeLMS) idToken(t *testing.T, nonce string, modify func(jwt.MapClaims)) string {
+    t.Helper()
+
+    now := time.Now()
+    claims := jwt.MapClaims{
+        "iss":                      lms.server.URL,
+        "aud":                      testLTIClientID,
+        "sub":                      "moodle-user-7",
+        "iat":                      now.Unix(),
+        "exp":                      now.Add(5 * time.Minute).Unix(),
+        "nonce":                    nonce,
+        "name":                     "Иван Петров",
+        "email":                    "ivan@school57.ru",
+        ltiClaim + "message_type":  ltiMessageResourceLink,
+        ltiClaim + "version":       ltiVersion,
+        ltiClaim + "deployment_id": testLTIDeploymentID,
+        ltiClaim + "roles":         []string{"http://purl.imsglobal.org/vocab/lis/v2/membership#Learner"},
+        ltiClaim + "resource_link": map[string]string{"id": "resource-link-1"},
+        ltiClaim + "context":       map[string]string{"id": "course-1"},
+        ltiClaim + "custom":        map[string]string{"material_id": "42"},
+        "https://purl.imsglobal.org/spec/lti-ags/claim/endpoint": map[string]interface{}{
+            "scope":    []string{ltiScopeScore},
+            "lineitem": lms.server.URL + "/lineitems/1/lineitem?type_id=1",
+        },
+    }
+    if modify != nil {
+        modify(claims)
+    }
+
+    token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+    token.Header["kid"] = testLTIPlatformKID
+    signed, err := token.SignedString(lms.key)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return signed
+}
+
+func newTestLTIService(t *testing.T) (*LTIService, *fakeLTIDB, *fakeLMS) {
+    t.Helper()
+
+    db := newFakeLTIDB()
+    lms := newFakeLMS(t)
+
+    service := NewLTIService(nil, nil, nil, NewAuthService(nil, "test-secret"), nil, LTIConfig{
+        ToolURL:     "https://api.paydeya.test/",
+        FrontendURL: "https://paydeya.test",
+    })
+    service.ltiRepo, service.userRepo, service.materialRepo = db, db, db
+    lms.service = service
+
+    db.platforms[1] = &models.LTIPlatform{
+        ID:            1,
+        Name:          "Moodle",
+        Issuer:        lms.server.URL,
+        ClientID:      testLTIClientID,
+        DeploymentIDs: []string{testLTIDeploymentID},
+        AuthLoginURL:  lms.server.URL + "/auth",
+        AuthTokenURL:  lms.server.URL + "/token",
+        JWKSURL:       lms.server.URL + "/jwks",
+    }
+    db.materials[testLTIMaterialID] = &models.Material{ID: testLTIMaterialID, Title: "Основы алгебры", Description: "Уравнения", Status: "published"}
+
+    return service, db, lms
+}
+
+// login проходит инициацию входа и возвращает state и nonce из адреса авторизации платформы
+func login(t *testing.T, service *LTIService, lms *fakeLMS) (string, string) {
+    t.Helper()
+
+    redirectURL, state, err := service.Login(context.Background(), &models.LTILoginRequest{
+        Issuer:    lms.server.URL,
+        LoginHint: "moodle-user-7",
+        ClientID:  testLTIClientID,
+    })
+    if err != nil {
+        t.Fatalf("Login: %v", err)
+    }
+
+    redirect, err := url.Parse(redirectURL)
+    if err != nil {
+        t.Fatal(err)
+    }
+    query := redirect.Query()
+    if !strings.HasPrefix(redirectURL, lms.server.URL+"/auth?") {
+        t.Fatalf("redirect to %s, want platform auth endpoint", redirectURL)
+    }
+    if query.Get("state") != state || query.Get("client_id") != testLTIClientID ||
+        query.Get("redirect_uri") != "https://api.paydeya.test/api/v1/lti/launch" || query.Get("response_mode") != "form_post" {
+        t.Fatalf("unexpected auth request %v", query)
+    }
+
+    return state, query.Get("nonce")
+}
+
+func TestLTILaunch(t *testing.T) {
+    ctx := context.Background()
+    service, db, lms := newTestLTIService(t)
+
+    state, nonce := login(t, service, lms)
+    redirectURL, err := service.Launch(ctx, lms.idToken(t, nonce, nil), state, state)
+    if err != nil {
+        t.Fatalf("Launch: %v", err)
+    }
+
+    redirect, err := url.Parse(redirectURL)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if redirect.Host != "paydeya.test" || redirect.Path != "/materials/42" {
+        t.Fatalf("redirect to %s, want material page", redirectURL)
+    }
+    fragment, _ := url.ParseQuery(redirect.Fragment)
+    if fragment.Get("accessToken") == "" || fragment.Get("refreshToken") == "" {
+        t.Fatalf("redirect %s has no tokens", redirectURL)
+    }
+
+    if len(db.users) != 1 || db.users[1].Role != "student" || db.users[1].Email != "ivan@school57.ru" {
+        t.Fatalf("unexpected provisioned users %+v", db.users)
+    }
+    if len(db.launches) != 1 || db.launches[0].lineItemURL == nil {
+        t.Fatalf("launch with line item not saved: %+v", db.launches)
+    }
+
+    // Повторный запуск того же пользователя не заводит нового
+    state, nonce = login(t, service, lms)
+    if _, err := service.Launch(ctx, lms.idToken(t, nonce, nil), state, state); err != nil {
+        t.Fatalf("second Launch: %v", err)
+    }
+    if len(db.users) != 1 {
+        t.Fatalf("second launch provisioned another user: %d users", len(db.users))
+    }
+}
+
+func TestLTILaunchRejected(t *testing.T) {
+    otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    tests := []struct {
+        name string
+        // launch подписывает и отправляет запуск после входа со state и nonce
+        launch  func(t *testing.T, service *LTIService, lms *fakeLMS, state, nonce string) error
+        wantErr string
+    }{
+        {
+            name: "bad signature",
+            launch: func(t *testing.T, service *LTIService, lms *fakeLMS, state, nonce string) error {
+                // Верные утверждения и kid платформы, но подпись чужим ключом
+                platformKey := lms.key
+                lms.key = otherKey
+                forged := lms.idToken(t, nonce, nil)
+                lms.key = platformKey
+                return launchWith(service, forged, state)
+            },
+            wantErr: "invalid id_token",
+        },
+        {
+            name: "wrong audience",
+            launch: func(t *testing.T, service *LTIService, lms *fakeLMS, state, nonce string) error {
+                return launchWith(service, lms.idToken(t, nonce, func(c jwt.MapClaims) { c["aud"] = "another-tool" }), state)
+            },
+            wantErr: "invalid id_token",
+        },
+        {
+            name: "foreign azp",
+            launch: func(t *testing.T, service *LTIService, lms *fakeLMS, state, nonce string) error {
+                return launchWith(service, lms.idToken(t, nonce, func(c jwt.MapClaims) {
+                    c["aud"] = []string{testLTIClientID, "another-tool"}
+                    c["azp"] = "another-tool"
+                }), state)
+            },
+            wantErr: "invalid id_token",
+        },
+        {
+            name: "wrong issuer",
+            launch: func(t *testing.T, service *LTIService, lms *fakeLMS, state, nonce string) error {
+                return launchWith(service, lms.idToken(t, nonce, func(c jwt.MapClaims) { c["iss"] = "https://evil.example" }), state)
+            },
+            wantErr: "invalid id_token",
+        },
+        {
+            name: "expired token",
+            launch: func(t *testing.T, service *LTIService, lms *fakeLMS, state, nonce string) error {
+                return launchWith(service, lms.idToken(t, nonce, func(c jwt.MapClaims) {
+                    c["iat"] = time.Now().Add(-time.Hour).Unix()
+                    c["exp"] = time.Now().Add(-30 * time.Minute).Unix()
+                }), state)
+            },
+            wantErr: "invalid id_token",
+        },
+        {
+            name: "unknown nonce",
+            launch: func(t *testing.T, service *LTIService, lms *fakeLMS, state, nonce string) error {
+                return launchWith(service, lms.idToken(t, "forged-nonce", nil), state)
+            },
+            wantErr: "invalid nonce",
+        },
+        {
+            name: "replayed state",
+            launch: func(t *testing.T, service *LTIService, lms *fakeLMS, state, nonce string) error {
+                token := lms.idToken(t, nonce, nil)
+                if err := launchWith(service, token, state); err != nil {
+                    t.Fatalf("first Launch: %v", err)
+                }
+                return launchWith(service, token, state)
+            },
+            wantErr: "invalid state",
+        },
+        {
+            name: "replayed id_token with new login",
+            launch: func(t *testing.T, service *LTIService, lms *fakeLMS, state, nonce string) error {
+                token := lms.idToken(t, nonce, nil)
+                if err := launchWith(service, token, state); err != nil {
+                    t.Fatalf("first Launch: %v", err)
+                }
+                newState, _ := login(t, service, lms)
+                return launchWith(service, token, newState)
+            },
+            wantErr: "invalid nonce",
+        },
+        {
+            name: "unknown deployment",
+            launch: func(t *testing.T, service *LTIService, lms *fakeLMS, state, nonce string) error {
+                return launchWith(service, lms.idToken(t, nonce, func(c jwt.MapClaims) { c[ltiClaim+"deployment_id"] = "deployment-2" }), state)
+            },
+            wantErr: "deployment not registered",
+        },
+        {
+            name: "missing state cookie",
+            launch: func(t *testing.T, service *LTIService, lms *fakeLMS, state, nonce string) error {
+                _, err := service.Launch(context.Background(), lms.idToken(t, nonce, nil), state, "")
+                return err
+            },
+            wantErr: "invalid state",
+        },
+        {
+            name: "state cookie of another login",
+            launch: func(t *testing.T, service *LTIService, lms *fakeLMS, state, nonce string) error {
+                attackerState, _ := login(t, service, lms)
+                _, err := service.Launch(context.Background(), lms.idToken(t, nonce, nil), state, attackerState)
+                return err
+            },
+            wantErr: "invalid state",
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            service, _, lms := newTestLTIService(t)
+            state, nonce := login(t, service, lms)
+
+            err := tt.launch(t, service, lms, state, nonce)
+            if err == nil || err.Error() != tt.wantErr {
+                t.Fatalf("Launch error = %v, want %q", err, tt.wantErr)
+            }
+        })
+    }
+}
+
+func TestLTILoginUnknownDeployment(t *testing.T) {
+    service, _, lms := newTestLTIService(t)
+
+    _, _, err := service.Login(context.Background(), &models.LTILoginRequest{
+        Issuer:          lms.server.URL,
+        LoginHint:       "moodle-user-7",
+        ClientID:        testLTIClientID,
+        LTIDeploymentID: "deployment-2",
+    })
+    if err == nil || err.Error() != "deployment not registered" {
+        t.Fatalf("Login error = %v, want deployment not registered", err)
+    }
+}
+
+func TestLTIDeepLinking(t *testing.T) {
+    ctx := context.Background()
+    service, db, lms := newTestLTIService(t)
+
+    state, nonce := login(t, service, lms)
+    redirectURL, err := service.Launch(ctx, lms.idToken(t, nonce, func(c jwt.MapClaims) {
+        c[ltiClaim+"message_type"] = ltiMessageDeepLinking
+        c[ltiClaim+"roles"] = []string{"http://purl.imsglobal.org/vocab/lis/v2/membership#Instructor"}
+        delete(c, ltiClaim+"resource_link")
+        c[ltiDeepLinkingClaim+"deep_linking_settings"] = map[string]interface{}{
+            "deep_link_return_url": lms.server.URL + "/deeplink/return",
+            "accept_types":         []string{"ltiResourceLink"},
+            "accept_multiple":      false,
+            "data":                 "opaque-platform-data",
+        }
+    }), state, state)
+    if err != nil {
+        t.Fatalf("Launch: %v", err)
+    }
+
+    redirect, _ := url.Parse(redirectURL)
+    sessionID := strings.TrimPrefix(redirect.Path, "/lti/deep-linking/")
+    if sessionID == redirect.Path || db.sessions[sessionID] == nil {
+        t.Fatalf("redirect to %s, want deep linking session", redirectURL)
+    }
+    teacher := db.users[1]
+    if teacher.Role != "teacher" {
+        t.Fatalf("instructor provisioned as %s", teacher.Role)
+    }
+
+    if _, err := service.CompleteDeepLinking(ctx, teacher.ID, sessionID, []int{testLTIMaterialID, testLTIMaterialID}); err == nil || err.Error() != "multiple materials not accepted" {
+        t.Fatalf("CompleteDeepLinking with two materials error = %v", err)
+    }
+
+    response, err := service.CompleteDeepLinking(ctx, teacher.ID, sessionID, []int{testLTIMaterialID})
+    if err != nil {
+        t.Fatalf("CompleteDeepLinking: %v", err)
+    }
+    if response.ReturnURL != lms.server.URL+"/deeplink/return" {
+        t.Fatalf("return URL %s", response.ReturnURL)
+    }
+
+    // Платформа проверяет ответ ключом из JWKS инструмента
+    toolKeys, err := lms.toolKeys(ctx)
+    if err != nil {
+        t.Fatal(err)
+    }
+    claims := jwt.MapClaims{}
+    _, err = jwt.ParseWithClaims(response.JWT, claims,
+        func(token *jwt.Token) (interface{}, error) {
+            kid, _ := token.Header["kid"].(string)
+            return toolKeys[kid], nil
+        },
+        jwt.WithValidMethods([]string{"RS256"}),
+        jwt.WithIssuer(testLTIClientID),
+        jwt.WithAudience(lms.server.URL),
+        jwt.WithExpirationRequired(),
+    )
+    if err != nil {
+        t.Fatalf("deep linking response signature: %v", err)
+    }
+
+    if claims[ltiClaim+"message_type"] != "LtiDeepLinkingResponse" || claims[ltiClaim+"deployment_id"] != testLTIDeploymentID ||
+        claims[ltiDeepLinkingClaim+"data"] != "opaque-platform-data" {
+        t.Fatalf("unexpected deep linking claims %v", claims)
+    }
+    items, _ := claims[ltiDeepLinkingClaim+"content_items"].([]interface{})
+    if len(items) != 1 {
+        t.Fatalf("content items %v", claims[ltiDeepLinkingClaim+"content_items"])
+    }
+    item := items[0].(map[string]interface{})
+    custom, _ := item["custom"].(map[string]interface{})
+    if item["type"] != "ltiResourceLink" || item["url"] != "https://api.paydeya.test/api/v1/lti/launch" || custom["material_id"] != "42" {
+        t.Fatalf("unexpected content item %v", item)
+    }
+
+    // Сессия одноразовая
+    if _, err := service.CompleteDeepLinking(ctx, teacher.ID, sessionID, []int{testLTIMaterialID}); err == nil || err.Error() != "deep linking session not found" {
+        t.Fatalf("second CompleteDeepLinking error = %v", err)
+    }
+}
+
+func TestLTIGradePassback(t *testing.T) {
+    ctx := context.Background()
+    service, db, lms := newTestLTIService(t)
+
+    state, nonce := login(t, service, lms)
+    if _, err := service.Launch(ctx, lms.idToken(t, nonce, nil), state, state); err != nil {
+        t.Fatalf("Launch: %v", err)
+    }
+    userID := db.users[1].ID
+
+    grade := 4.0
+    completedAt := time.Date(2026, 5, 12, 10, 30, 0, 0, time.UTC)
+    db.mu.Lock()
+    db.completions[[2]int{userID, testLTIMaterialID}] = fakeCompletion{grade: &grade, completedAt: completedAt}
+    db.mu.Unlock()
+
+    service.PassbackGrade(userID, testLTIMaterialID)
+
+    var score fakeScore
+    select {
+    case score = <-lms.scores:
+    case <-time.After(10 * time.Second):
+        t.Fatal("platform received no score")
+    }
+
+    if score.authorization != "Bearer "+testLTIAccessToken {
+        t.Fatalf("score sent with authorization %q", score.authorization)
+    }
+    if score.contentType != "application/vnd.ims.lis.v1.score+json" {
+        t.Fatalf("score sent as %q", score.contentType)
+    }
+    want := map[string]interface{}{
+        "userId":           "moodle-user-7",
+        "scoreGiven":       4.0,
+        "scoreMaximum":     float64(ltiMaxScore),
+        "activityProgress": "Completed",
+        "gradingProgress":  "FullyGraded",
+        "timestamp":        completedAt.Format(time.RFC3339Nano),
+    }
+    for field, value := range want {
+        if score.body[field] != value {
+            t.Fatalf("score %s = %v, want %v", field, score.body[field], value)
+        }
+    }
+}
+
+func launchWith(service *LTIService, idToken, state string) error {
+    _, err := service.Launch(context.Background(), idToken, state, state)
+    return err
+}
@@ -0,0 +1,235 @@
+package services
+
+import (
+    "fmt"
+    "html/template"
+    "io"
+    "path"
+    "sort"
+    "strconv"
+    "strings"
+
+    "paydeya-backend/internal/models"
+)
+
+// materialPage - данные статической HTML-страницы материала для экспорта
+type materialPage struct {
+    Title       string
+    Description string
+    Language    string
+    CoverURL    string
+    Blocks      []pageBlock
+    HasQuiz     bool
+    Scripts     []string // скрипты рядом со страницей, например scorm.js
+}
+
+// pageBlock - блок, подготовленный к выводу в шаблон
+type pageBlock struct {
+    ID        string
+    Type      string
+    HTML      template.HTML // текст из документа rich text
+    URL       string
+    Alt       string
+    Caption   string
+    VideoFile bool // загруженный файл, а не встраиваемый плеер
+    Latex     string
+    MathML    template.HTML // формула, отрисованная на сервере; без нее выводится исходный LaTeX
+    Question  string
+    Options   []string
+    Correct   string // индексы правильных вариантов через запятую
+    Multiple  bool
+    Language  string // блок с кодом: код выводится без запуска
+    Code      string
+}
+
+// buildMaterialPage готовит материал к выводу. mediaURL подменяет ссылки на файлы,
+// например на пути внутри архива
+func buildMaterialPage(material *models.Material, mediaURL func(string) string) materialPage {
+    page := materialPage{
+        Title:       material.Title,
+        Description: material.Description,
+        Language:    material.Language,
+        Blocks:      make([]pageBlock, 0, len(material.Blocks)),
+    }
+    if page.Language == "" {
+        page.Language = "ru"
+    }
+    if material.CoverURL != "" {
+        page.CoverURL = mediaURL(material.CoverURL)
+    }
+
+    blocks := append([]models.Block(nil), material.Blocks...)
+    sort.SliceStable(blocks, func(i, j int) bool { return blocks[i].Position < blocks[j].Position })
+
+    for _, block := range blocks {
+        b := pageBlock{ID: block.ID, Type: block.Type}
+
+        switch block.Type {
+        case "text":
+            b.HTML = renderRichTextHTML(richTextDocument(block.Content), 1)
+        case "image":
+            b.URL = mediaURL(contentString(block.Content, "url", "src"))
+            b.Alt = contentString(block.Content, "alt")
+            b.Caption = contentString(block.Content, "caption")
+        case "video":
+            source := contentString(block.Content, "url", "src")
+            b.URL = mediaURL(source)
+            b.VideoFile = b.URL != source || isVideoFile(source)
+            if !b.VideoFile {
+                if embed := contentString(block.Content, "embedUrl"); embed != "" {
+                    b.URL = embed
+                }
+            }
+            b.Caption = contentString(block.Content, "caption", "title")
+        case "formula":
+            b.Latex = contentString(block.Content, "latex", "formula")
+            if root, errs := parseLatex(b.Latex); b.Latex != "" && len(errs) == 0 {
+                b.MathML = template.HTML(formulaMathML(root, b.Latex, true))
+            }
+        case "quiz":
+            b.Question = contentString(block.Content, "question")
+            options, correct := quizOptions(block.Content)
+            b.Options = options
+            b.Multiple = len(correct) > 1
+            indexes := make([]string, len(correct))
+            for i, index := range correct {
+                indexes[i] = strconv.Itoa(index)
+            }
+            b.Correct = strings.Join(indexes, ",")
+            page.HasQuiz = true
+        case "code":
+            b.Language, _ = block.Content["language"].(string)
+            b.Question = contentString(block.Content, "instructions")
+            b.Code = contentString(block.Content, "starterCode")
+        }
+
+        page.Blocks = append(page.Blocks, b)
+    }
+
+    return page
+}
+
+// renderMaterialHTML выводит страницу материала
+func renderMaterialHTML(w io.Writer, page materialPage) error {
+    return materialPageTemplate.Execute(w, page)
+}
+
+// contentString возвращает первое непустое строковое поле содержимого блока
+func contentString(content map[string]interface{}, keys ...string) string {
+    for _, key := range keys {
+        if value, ok := content[key].(string); ok && value != "" {
+            return value
+        }
+    }
+    return ""
+}
+
+// quizOptions разбирает варианты ответа и правильные индексы. Варианты бывают строками
+// или объектами {text, correct}; правильные ответы - в correctAnswer/correct (число или массив)
+func quizOptions(content map[string]interface{}) ([]string, []int) {
+    var options []string
+    var correct []int
+
+    items, _ := content["options"].([]interface{})
+    for i, item := range items {
+        switch v := item.(type) {
+        case string:
+            options = append(options, v)
+        case map[string]interface{}:
+            options = append(options, contentString(v, "text", "label"))
+            if isCorrect, _ := v["correct"].(bool); isCorrect {
+                correct = append(correct, i)
+            } else if isCorrect, _ := v["isCorrect"].(bool); isCorrect {
+                correct = append(correct, i)
+            }
+        default:
+            options = append(options, fmt.Sprint(v))
+        }
+    }
+
+    if len(correct) == 0 {
+        for _, key := range []string{"correctAnswer", "correct"} {
+            switch v := content[key].(type) {
+            case float64:
+                correct = []int{int(v)}
+            case []interface{}:
+                for _, index := range v {
+                    if n, ok := index.(float64); ok {
+                        correct = append(correct, int(n))
+                    }
+                }
+            }
+            if len(correct) > 0 {
+                break
+            }
+        }
+    }
+
+    return options, correct
+}
+
+func isVideoFile(url string) bool {
+    switch strings.ToLower(path.Ext(url)) {
+    case ".mp4", ".webm", ".ogg", ".mov", ".m4v":
+        return true
+    }
+    return false
+}
+
+var materialPageTemplate = template.Must(template.New("material").Parse(`<!DOCTYPE html>
+<html lang="{{.Language}}">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Roboto, Arial, sans-serif; line-height: 1.6; color: #1f2933; max-width: 820px; margin: 0 auto; padding: 24px; }
+h1 { font-size: 28px; margin-bottom: 8px; }
+.description { color: #52606d; }
+.cover, .block img, .block video { max-width: 100%; border-radius: 8px; }
+.block { margin: 24px 0; }
+.block iframe { width: 100%; aspect-ratio: 16 / 9; border: 0; border-radius: 8px; }
+.caption { font-size: 14px; color: #7b8794; }
+.formula { font-family: "Cambria Math", "STIX Two Math", serif; font-size: 20px; text-align: center; overflow-x: auto; }
+.quiz { border: 1px solid #d9e2ec; border-radius: 8px; padding: 16px; }
+.quiz label { display: block; margin: 6px 0; }
+.quiz.correct { border-color: #3ebd93; background: #effcf6; }
+.quiz.incorrect { border-color: #e66a6a; background: #fff5f5; }
+.finish { font-size: 16px; padding: 10px 24px; border: 0; border-radius: 6px; background: #2680c2; color: #fff; cursor: pointer; }
+.result { margin-top: 12px; font-weight: 600; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{if .Description}}<p class="description">{{.Description}}</p>{{end}}
+{{if .CoverURL}}<img class="cover" src="{{.CoverURL}}" alt="">{{end}}
+{{range .Blocks}}
+<section class="block block-{{.Type}}" id="block-{{.ID}}">
+{{- if eq .Type "text"}}
+{{.HTML}}
+{{- else if eq .Type "image"}}
+<figure><img src="{{.URL}}" alt="{{.Alt}}">{{if .Caption}}<figcaption class="caption">{{.Caption}}</figcaption>{{end}}</figure>
+{{- else if eq .Type "video"}}
+{{if .VideoFile}}<video src="{{.URL}}" controls preload="metadata"></video>{{else}}<iframe src="{{.URL}}" allowfullscreen></iframe>{{end}}
+{{if .Caption}}<p class="caption">{{.Caption}}</p>{{end}}
+{{- else if eq .Type "formula"}}
+<div class="formula">{{if .MathML}}{{.MathML}}{{else}}\[{{.Latex}}\]{{end}}</div>
+{{- else if eq .Type "quiz"}}
+<div class="quiz" data-block="{{.ID}}" data-correct="{{.Correct}}">
+<p><strong>{{.Question}}</strong></p>
+{{$block := .}}{{range $i, $option := .Options}}<label><input type="{{if $block.Multiple}}checkbox{{else}}radio{{end}}" name="quiz-{{$block.ID}}" value="{{$i}}"> {{$option}}</label>
+{{end}}</div>
+{{- else if eq .Type "code"}}
+{{if .Question}}<p>{{.Question}}</p>{{end}}
+<pre><code class="language-{{.Language}}">{{.Code}}</code></pre>
+{{- end}}
+</section>
+{{end}}
+{{if .Scripts}}
+<p><button type="button" class="finish" id="finish">{{if .HasQuiz}}Проверить и завершить{{else}}Завершить{{end}}</button></p>
+<p class="result" id="result"></p>
+{{range .Scripts}}<script src="{{.}}"></script>
+{{end}}{{end}}
+</body>
+</html>
+`))
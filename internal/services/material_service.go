@@ -5,33 +5,108 @@ import (
     "crypto/rand"
     "encoding/hex"
     "fmt"
+    "log"
     "strconv"
+    "strings"
+    "time"
 
     "paydeya-backend/internal/models"
     "paydeya-backend/internal/repositories"
 )
 
 type MaterialService struct {
-    materialRepo *repositories.MaterialRepository
-    blockRepo    *repositories.BlockRepository
+    materialRepo      *repositories.MaterialRepository
+    blockRepo         *repositories.BlockRepository
+    collaboratorRepo  *repositories.CollaboratorRepository
+    collaborationRepo *repositories.CollaborationRepository
+    userRepo          *repositories.UserRepository
+    fileService       *FileService
+    formulaService    *FormulaService
+    moderationRepo    *repositories.ModerationRepository
+    trashRetention    time.Duration
+    requireReview     bool // публикация через модерацию
 }
 
-func NewMaterialService(materialRepo *repositories.MaterialRepository, blockRepo *repositories.BlockRepository) *MaterialService {
+func NewMaterialService(
+    materialRepo *repositories.MaterialRepository,
+    blockRepo *repositories.BlockRepository,
+    collaboratorRepo *repositories.CollaboratorRepository,
+    collaborationRepo *repositories.CollaborationRepository,
+    userRepo *repositories.UserRepository,
+    fileService *FileService,
+    formulaService *FormulaService,
+    moderationRepo *repositories.ModerationRepository,
+    trashRetention time.Duration,
+    requireReview bool,
+) *MaterialService {
     return &MaterialService{
-        materialRepo: materialRepo,
-        blockRepo:    blockRepo,
+        materialRepo:      materialRepo,
+        blockRepo:         blockRepo,
+        collaboratorRepo:  collaboratorRepo,
+        collaborationRepo: collaborationRepo,
+        userRepo:          userRepo,
+        fileService:       fileService,
+        formulaService:    formulaService,
+        moderationRepo:    moderationRepo,
+        trashRetention:    trashRetention,
+        requireReview:     requireReview,
     }
 }
 
+// Роли в материале: чем больше ранг, тем больше прав
+var materialRoleRank = map[string]int{
+    "viewer":   1, // просмотр черновиков
+    "reviewer": 2, // просмотр и рецензирование
+    "editor":   3, // редактирование содержимого
+    "owner":    4, // публикация и управление соавторами
+}
+
+// GetMaterialRole возвращает роль пользователя в материале (пустая строка - нет доступа)
+func (s *MaterialService) GetMaterialRole(ctx context.Context, userID int, material *models.Material) (string, error) {
+    if material.AuthorID == userID {
+        return "owner", nil
+    }
+    return s.collaboratorRepo.GetCollaboratorRole(ctx, material.ID, userID)
+}
+
+// CheckAccess проверяет, что роль пользователя в материале не ниже minRole
+func (s *MaterialService) CheckAccess(ctx context.Context, userID, materialID int, minRole string) (*models.Material, string, error) {
+    material, err := s.materialRepo.GetMaterial(ctx, materialID)
+    if err != nil || material == nil {
+        return nil, "", fmt.Errorf("material not found")
+    }
+
+    role, err := s.GetMaterialRole(ctx, userID, material)
+    if err != nil {
+        return nil, "", err
+    }
+
+    if materialRoleRank[role] < materialRoleRank[minRole] {
+        return nil, role, fmt.Errorf("access denied")
+    }
+
+    return material, role, nil
+}
+
 // CreateMaterial создает новый материал
 func (s *MaterialService) CreateMaterial(ctx context.Context, userID int, req *models.CreateMaterialRequest) (*models.Material, error) {
     material := &models.Material{
-        Title:    req.Title,
-        Subject:  req.Subject,
-        AuthorID: userID,
-        Status:   "draft",
-        Access:   "open",
-        Blocks:   []models.Block{},
+        Title:       req.Title,
+        Subject:     req.Subject,
+        Description: req.Description,
+        Level:       req.Level,
+        Tags:        normalizeTags(req.Tags),
+        CoverURL:    req.CoverURL,
+        Language:    req.Language,
+        Duration:    req.Duration,
+        AuthorID:    userID,
+        Status:      "draft",
+        Access:      "open",
+        Blocks:      []models.Block{},
+    }
+
+    if material.Language == "" {
+        material.Language = "ru"
     }
 
     if err := s.materialRepo.CreateMaterial(ctx, material); err != nil {
@@ -42,50 +117,134 @@ func (s *MaterialService) CreateMaterial(ctx context.Context, userID int, req *m
 }
 
 // GetMaterial возвращает материал с блоками
-func (s *MaterialService) GetMaterial(ctx context.Context, materialID int) (*models.Material, error) {
+// Опубликованный материал доступен всем, остальные - только владельцу и соавторам
+func (s *MaterialService) GetMaterial(ctx context.Context, userID, materialID int) (*models.Material, error) {
     material, err := s.materialRepo.GetMaterial(ctx, materialID)
     if err != nil || material == nil {
         return nil, err
     }
 
+    role, err := s.GetMaterialRole(ctx, userID, material)
+    if err != nil {
+        return nil, err
+    }
+    if !isMaterialLive(material, time.Now()) && role == "" {
+        // Снятый с публикации материал остается доступен ученикам, которые его прошли
+        completed := false
+        if material.Status == "archived" || material.Status == "published" {
+            if completed, err = s.materialRepo.HasCompleted(ctx, userID, materialID); err != nil {
+                return nil, err
+            }
+        }
+        if !completed {
+            return nil, fmt.Errorf("access denied")
+        }
+    }
+
     // Загружаем блоки
     blocks, err := s.blockRepo.GetBlocks(ctx, materialID)
     if err != nil {
         return nil, err
     }
 
+    // Скрытые тесты упражнений видят только автор и соавторы
+    if role == "" {
+        hideCodeTests(blocks)
+    }
+
     material.Blocks = blocks
     return material, nil
 }
 
+// PreviewAnimation возвращает анимацию блока, разложенную по кадрам, по тем же правилам,
+// что и в плеере. Доступна всем, кто может открыть материал
+func (s *MaterialService) PreviewAnimation(ctx context.Context, userID, materialID int, blockID string) (*models.AnimationTimeline, error) {
+    material, err := s.GetMaterial(ctx, userID, materialID)
+    if err != nil {
+        return nil, err
+    }
+    if material == nil {
+        return nil, fmt.Errorf("material not found")
+    }
+
+    for i := range material.Blocks {
+        if material.Blocks[i].ID == blockID {
+            return animationTimeline(&material.Blocks[i]), nil
+        }
+    }
+    return nil, fmt.Errorf("block not found")
+}
+
 // GetUserMaterials возвращает материалы пользователя
 func (s *MaterialService) GetUserMaterials(ctx context.Context, userID int, status string) ([]*models.Material, error) {
     return s.materialRepo.GetUserMaterials(ctx, userID, status)
 }
 
+// GetSharedMaterials возвращает материалы, к которым пользователь приглашен соавтором
+func (s *MaterialService) GetSharedMaterials(ctx context.Context, userID int) ([]*models.SharedMaterial, error) {
+    return s.collaboratorRepo.GetSharedMaterials(ctx, userID)
+}
+
 // UpdateMaterial обновляет материал и блоки
 func (s *MaterialService) UpdateMaterial(ctx context.Context, userID int, materialID int, req *models.UpdateMaterialRequest) error {
-    // Получаем текущий материал для проверки прав
-    material, err := s.materialRepo.GetMaterial(ctx, materialID)
-    if err != nil || material == nil {
-        return fmt.Errorf("material not found")
-    }
-
-    if material.AuthorID != userID {
-        return fmt.Errorf("access denied")
+    // Редактировать могут владелец и редакторы
+    material, _, err := s.CheckAccess(ctx, userID, materialID, "editor")
+    if err != nil {
+        return err
     }
 
-    // Обновляем заголовок если передан
+    // Обновляем заголовок и метаданные если переданы
+    changed := false
     if req.Title != "" {
         material.Title = req.Title
+        changed = true
+    }
+    if req.Description != nil {
+        material.Description = *req.Description
+        changed = true
+    }
+    if req.Level != nil {
+        // Пустая строка сбрасывает уровень
+        material.Level = req.Level
+        if *req.Level == "" {
+            material.Level = nil
+        }
+        changed = true
+    }
+    if req.Tags != nil {
+        material.Tags = normalizeTags(req.Tags)
+        changed = true
+    }
+    if req.CoverURL != nil {
+        material.CoverURL = *req.CoverURL
+        changed = true
+    }
+    if req.Language != nil && *req.Language != "" {
+        material.Language = *req.Language
+        changed = true
+    }
+    if req.Duration != nil {
+        material.Duration = *req.Duration
+        changed = true
+    }
+
+    if changed {
         if err := s.materialRepo.UpdateMaterial(ctx, material); err != nil {
             return err
         }
+        if err := s.resubmitEdited(ctx, userID, material); err != nil {
+            return err
+        }
     }
 
     // Сохраняем блоки если переданы
     if req.Blocks != nil {
-        if err := s.blockRepo.SaveBlocks(ctx, materialID, req.Blocks); err != nil {
+        _, err := s.ApplyBlockOperation(ctx, userID, &models.BlockOperation{
+            MaterialID: materialID,
+            Type:       "replace",
+            Blocks:     req.Blocks,
+        })
+        if err != nil {
             return fmt.Errorf("failed to save blocks: %w", err)
         }
     }
@@ -94,20 +253,49 @@ func (s *MaterialService) UpdateMaterial(ctx context.Context, userID int, materi
 }
 // PublishMaterial публикует материал
 func (s *MaterialService) PublishMaterial(ctx context.Context, userID, materialID int, req *models.PublishMaterialRequest) (*models.Material, error) {
-    // Получаем материал
-    material, err := s.materialRepo.GetMaterial(ctx, materialID)
-    if err != nil || material == nil {
-        return nil, fmt.Errorf("material not found")
+    // Публиковать может только владелец
+    material, _, err := s.CheckAccess(ctx, userID, materialID, "owner")
+    if err != nil {
+        return nil, err
     }
 
-    // Проверяем права
-    if material.AuthorID != userID {
-        return nil, fmt.Errorf("access denied")
+    now := time.Now()
+    if req.UnpublishAt != nil && !req.UnpublishAt.After(now) {
+        return nil, fmt.Errorf("unpublishAt must be in the future")
+    }
+    if req.PublishAt != nil && req.UnpublishAt != nil && !req.UnpublishAt.After(*req.PublishAt) {
+        return nil, fmt.Errorf("unpublishAt must be after publishAt")
     }
 
-    // Обновляем статус и доступ
+    // Обновляем статус и доступ. С publishAt в будущем материал остается черновиком
+    // до наступления времени публикации, статус переведет планировщик
+    previousStatus := material.Status
     material.Status = req.Visibility
     material.Access = req.Access
+    material.PublishAt = nil
+    if req.PublishAt != nil && req.PublishAt.After(now) {
+        material.Status = "draft"
+        material.PublishAt = req.PublishAt
+    }
+    // Окно доступа имеет смысл только для опубликованного или запланированного материала
+    material.UnpublishAt = nil
+    if material.Status == "published" || material.PublishAt != nil {
+        material.UnpublishAt = req.UnpublishAt
+    }
+
+    // При модерации неопубликованный материал сначала ждет одобрения; расписание
+    // сохраняется и начинает действовать после него. Доверенные авторы публикуют сразу
+    submitForReview := false
+    if s.requireReview && previousStatus != "published" && (material.Status == "published" || material.PublishAt != nil) {
+        trusted, err := s.moderationRepo.IsTrustedAuthor(ctx, userID)
+        if err != nil {
+            return nil, err
+        }
+        if !trusted {
+            material.Status = "pending_review"
+            submitForReview = true
+        }
+    }
 
     // Генерируем share URL если нужно
     if req.Access == "link" {
@@ -121,97 +309,651 @@ func (s *MaterialService) PublishMaterial(ctx context.Context, userID, materialI
         return nil, fmt.Errorf("failed to publish material: %w", err)
     }
 
+    if submitForReview {
+        if err := s.moderationRepo.SubmitReview(ctx, materialID, userID); err != nil {
+            return nil, fmt.Errorf("failed to submit material for review: %w", err)
+        }
+    } else if previousStatus == "pending_review" {
+        if err := s.moderationRepo.WithdrawReview(ctx, materialID); err != nil {
+            return nil, err
+        }
+    }
+
     return material, nil
 }
 
 
 
-// generateShareURL генерирует уникальный URL для доступа по ссылке
-func (s *MaterialService) generateShareURL() string {
-    bytes := make([]byte, 8)
-    rand.Read(bytes)
-    return hex.EncodeToString(bytes)
+// ArchiveMaterial переносит материал в архив: он пропадает из каталога, но сохраняется для прошедших его учеников
+func (s *MaterialService) ArchiveMaterial(ctx context.Context, userID, materialID int) (*models.Material, error) {
+    material, _, err := s.CheckAccess(ctx, userID, materialID, "owner")
+    if err != nil {
+        return nil, err
+    }
+
+    if material.Status == "archived" {
+        return material, nil
+    }
+
+    previousStatus := material.Status
+    material.Status = "archived"
+    material.PublishAt, material.UnpublishAt = nil, nil
+    if err := s.materialRepo.UpdateMaterial(ctx, material); err != nil {
+        return nil, fmt.Errorf("failed to archive material: %w", err)
+    }
+    if previousStatus == "pending_review" {
+        if err := s.moderationRepo.WithdrawReview(ctx, materialID); err != nil {
+            return nil, err
+        }
+    }
+
+    return material, nil
 }
 
-// AddBlock добавляет блок к материалу
-func (s *MaterialService) AddBlock(ctx context.Context, userID, materialID int, block *models.Block) error {
-    // Проверяем права
+// UnarchiveMaterial возвращает материал из архива в черновики
+func (s *MaterialService) UnarchiveMaterial(ctx context.Context, userID, materialID int) (*models.Material, error) {
+    material, _, err := s.CheckAccess(ctx, userID, materialID, "owner")
+    if err != nil {
+        return nil, err
+    }
+
+    if material.Status != "archived" {
+        return nil, fmt.Errorf("material is not archived")
+    }
+
+    material.Status = "draft"
+    material.PublishAt, material.UnpublishAt = nil, nil
+    if err := s.materialRepo.UpdateMaterial(ctx, material); err != nil {
+        return nil, fmt.Errorf("failed to unarchive material: %w", err)
+    }
+
+    return material, nil
+}
+
+// UnpublishMaterial снимает материал с публикации по решению модератора: опубликованный,
+// запланированный или ожидающий проверки материал возвращается автору в черновики
+func (s *MaterialService) UnpublishMaterial(ctx context.Context, materialID int) (*models.Material, error) {
+    material, err := s.materialRepo.GetMaterial(ctx, materialID)
+    if err != nil || material == nil {
+        return nil, fmt.Errorf("material not found")
+    }
+
+    if material.Status == "archived" || (material.Status == "draft" && material.PublishAt == nil) {
+        return material, nil
+    }
+
+    previousStatus := material.Status
+    material.Status = "draft"
+    material.PublishAt, material.UnpublishAt = nil, nil
+    if err := s.materialRepo.UpdateMaterial(ctx, material); err != nil {
+        return nil, fmt.Errorf("failed to unpublish material: %w", err)
+    }
+    if previousStatus == "pending_review" {
+        if err := s.moderationRepo.WithdrawReview(ctx, materialID); err != nil {
+            return nil, err
+        }
+    }
+
+    return material, nil
+}
+
+// DeleteMaterial перемещает материал в корзину, а при permanent удаляет окончательно
+// (в том числе материал, уже находящийся в корзине). Пройденные учениками материалы
+// удалить нельзя - их можно только архивировать
+func (s *MaterialService) DeleteMaterial(ctx context.Context, userID, materialID int, permanent bool) error {
     material, err := s.materialRepo.GetMaterial(ctx, materialID)
+    if err == nil && material == nil && permanent {
+        material, err = s.materialRepo.GetTrashedMaterial(ctx, materialID)
+    }
     if err != nil || material == nil {
         return fmt.Errorf("material not found")
     }
+
+    // Удалять может только владелец
     if material.AuthorID != userID {
         return fmt.Errorf("access denied")
     }
 
-    // Получаем текущие блоки
-    blocks, err := s.blockRepo.GetBlocks(ctx, materialID)
+    hasCompletions, err := s.materialRepo.HasCompletions(ctx, materialID)
     if err != nil {
         return err
     }
+    if hasCompletions {
+        return fmt.Errorf("material has completions")
+    }
 
-    // Добавляем новый блок
-    blocks = append(blocks, *block)
+    if permanent {
+        return s.purgeMaterial(ctx, materialID)
+    }
 
-    // Сохраняем все блоки
-    return s.blockRepo.SaveBlocks(ctx, materialID, blocks)
+    return s.materialRepo.MoveToTrash(ctx, materialID)
 }
 
-// UpdateBlock обновляет блок
-func (s *MaterialService) UpdateBlock(ctx context.Context, userID, materialID int, blockID string, block *models.Block) error {
+// GetTrash возвращает материалы пользователя в корзине с датой окончательного удаления
+func (s *MaterialService) GetTrash(ctx context.Context, userID int) ([]*models.TrashedMaterial, error) {
+    materials, err := s.materialRepo.GetTrashedMaterials(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
 
-    // Проверяем права
-    material, err := s.materialRepo.GetMaterial(ctx, materialID)
+    trash := make([]*models.TrashedMaterial, 0, len(materials))
+    for _, material := range materials {
+        trash = append(trash, &models.TrashedMaterial{
+            Material: *material,
+            PurgeAt:  material.DeletedAt.Add(s.trashRetention),
+        })
+    }
+
+    return trash, nil
+}
+
+// RestoreMaterial возвращает материал из корзины
+func (s *MaterialService) RestoreMaterial(ctx context.Context, userID, materialID int) error {
+    material, err := s.materialRepo.GetTrashedMaterial(ctx, materialID)
     if err != nil || material == nil {
         return fmt.Errorf("material not found")
     }
+
     if material.AuthorID != userID {
         return fmt.Errorf("access denied")
     }
 
-    // Получаем текущие блоки
-    blocks, err := s.blockRepo.GetBlocks(ctx, materialID)
+    restored, err := s.materialRepo.RestoreFromTrash(ctx, materialID)
     if err != nil {
         return err
     }
+    if !restored {
+        return fmt.Errorf("material not found")
+    }
+
+    return nil
+}
+
+// isMaterialLive проверяет, что материал опубликован и окно доступа не закончилось.
+// Планировщик переводит статусы с задержкой до минуты, поэтому окно проверяется и здесь
+func isMaterialLive(material *models.Material, now time.Time) bool {
+    if material.Status != "published" {
+        return false
+    }
+    return material.UnpublishAt == nil || material.UnpublishAt.After(now)
+}
+
+// StartPublishScheduler раз в минуту применяет расписание публикации: публикует черновики,
+// время которых наступило, и переносит в архив материалы с закончившимся окном доступа.
+// На нескольких инстансах проход выполняет один - под блокировкой в базе
+func (s *MaterialService) StartPublishScheduler(ctx context.Context) {
+    go func() {
+        ticker := time.NewTicker(time.Minute)
+        defer ticker.Stop()
 
-    // Находим и обновляем блок
-    for i, b := range blocks {
-        if b.ID == blockID {
-            blocks[i] = *block
-            return s.blockRepo.SaveBlocks(ctx, materialID, blocks)
+        for {
+            s.applyPublishSchedule(ctx)
+
+            select {
+            case <-ctx.Done():
+                return
+            case <-ticker.C:
+            }
         }
+    }()
+}
+
+func (s *MaterialService) applyPublishSchedule(ctx context.Context) {
+    published, archived, err := s.materialRepo.ApplyPublishSchedule(ctx, time.Now())
+    if err != nil {
+        log.Printf("⚠️ Failed to apply publish schedule: %v", err)
+        return
     }
 
-    return fmt.Errorf("block not found")
+    for _, id := range published {
+        log.Printf("📢 Material %d published on schedule", id)
+    }
+    for _, id := range archived {
+        log.Printf("📦 Material %d archived on schedule", id)
+    }
 }
 
-// DeleteBlock удаляет блок
-func (s *MaterialService) DeleteBlock(ctx context.Context, userID, materialID int, blockID string) error {
-    // Проверяем права
+// StartTrashCleanup периодически окончательно удаляет материалы, срок хранения которых в корзине истек
+func (s *MaterialService) StartTrashCleanup(ctx context.Context) {
+    go func() {
+        ticker := time.NewTicker(time.Hour)
+        defer ticker.Stop()
+
+        for {
+            s.purgeExpiredTrash(ctx)
+
+            select {
+            case <-ctx.Done():
+                return
+            case <-ticker.C:
+            }
+        }
+    }()
+}
+
+func (s *MaterialService) purgeExpiredTrash(ctx context.Context) {
+    ids, err := s.materialRepo.GetExpiredTrash(ctx, time.Now().Add(-s.trashRetention))
+    if err != nil {
+        log.Printf("⚠️ Failed to get expired trash: %v", err)
+        return
+    }
+
+    for _, id := range ids {
+        // Материал мог начать проходить ученик по старой ссылке до удаления
+        hasCompletions, err := s.materialRepo.HasCompletions(ctx, id)
+        if err != nil || hasCompletions {
+            continue
+        }
+
+        if err := s.purgeMaterial(ctx, id); err != nil {
+            log.Printf("⚠️ Failed to purge material %d: %v", id, err)
+            continue
+        }
+        log.Printf("🗑️ Material %d purged from trash", id)
+    }
+}
+
+// purgeMaterial окончательно удаляет материал и загруженные файлы, на которые больше никто не ссылается
+func (s *MaterialService) purgeMaterial(ctx context.Context, materialID int) error {
+    material, err := s.materialRepo.PurgeMaterial(ctx, materialID)
+    if err != nil {
+        return fmt.Errorf("failed to delete material: %w", err)
+    }
+    if material == nil || s.fileService == nil {
+        return nil
+    }
+
+    for _, url := range s.collectMediaURLs(material) {
+        // Копии материалов ссылаются на те же файлы
+        referenced, err := s.materialRepo.IsMediaReferenced(ctx, url)
+        if err != nil || referenced {
+            continue
+        }
+
+        if err := s.fileService.DeleteMedia(ctx, url); err != nil {
+            log.Printf("⚠️ Failed to delete media %s: %v", url, err)
+        }
+    }
+
+    return nil
+}
+
+// collectMediaURLs собирает ссылки на загруженные файлы из обложки и содержимого блоков
+func (s *MaterialService) collectMediaURLs(material *models.Material) []string {
+    seen := make(map[string]bool)
+    var urls []string
+
+    var walk func(value interface{})
+    walk = func(value interface{}) {
+        switch v := value.(type) {
+        case string:
+            if !seen[v] && s.fileService.IsManagedMedia(v) {
+                seen[v] = true
+                urls = append(urls, v)
+            }
+        case map[string]interface{}:
+            for _, item := range v {
+                walk(item)
+            }
+        case []interface{}:
+            for _, item := range v {
+                walk(item)
+            }
+        }
+    }
+
+    walk(material.CoverURL)
+    for _, block := range material.Blocks {
+        walk(block.Content)
+    }
+
+    return urls
+}
+
+// DuplicateMaterial создает копию собственного материала (черновик с новыми ID блоков)
+func (s *MaterialService) DuplicateMaterial(ctx context.Context, userID, materialID int) (*models.Material, error) {
+    material, _, err := s.CheckAccess(ctx, userID, materialID, "editor")
+    if err != nil {
+        return nil, err
+    }
+
+    return s.copyMaterial(ctx, userID, material, material.Title+" (копия)", false)
+}
+
+// ForkMaterial создает собственную копию опубликованного открытого материала другого автора
+func (s *MaterialService) ForkMaterial(ctx context.Context, userID, materialID int) (*models.Material, error) {
     material, err := s.materialRepo.GetMaterial(ctx, materialID)
     if err != nil || material == nil {
-        return fmt.Errorf("material not found")
+        return nil, fmt.Errorf("material not found")
     }
-    if material.AuthorID != userID {
-        return fmt.Errorf("access denied")
+
+    if material.AuthorID == userID {
+        return nil, fmt.Errorf("use duplicate for own materials")
     }
 
-    // Получаем текущие блоки
-    blocks, err := s.blockRepo.GetBlocks(ctx, materialID)
+    if !isMaterialLive(material, time.Now()) || material.Access != "open" {
+        return nil, fmt.Errorf("material cannot be forked")
+    }
+
+    // Скрытые тесты упражнений не копируются тем, кто их не видит
+    role, err := s.GetMaterialRole(ctx, userID, material)
     if err != nil {
-        return err
+        return nil, err
+    }
+
+    return s.copyMaterial(ctx, userID, material, material.Title, role == "")
+}
+
+// copyMaterial копирует материал и его блоки под новым автором.
+// Медиа не перезагружаются: блоки копии ссылаются на те же файлы
+func (s *MaterialService) copyMaterial(ctx context.Context, userID int, source *models.Material, title string, hideTests bool) (*models.Material, error) {
+    blocks, err := s.blockRepo.GetBlocks(ctx, source.ID)
+    if err != nil {
+        return nil, err
+    }
+    if hideTests {
+        hideCodeTests(blocks)
     }
 
-    // Удаляем блок
-    var newBlocks []models.Block
+    newBlocks := make([]models.Block, 0, len(blocks))
     for _, block := range blocks {
-        if block.ID != blockID {
+        block.ID = newBlockID()
+        newBlocks = append(newBlocks, block)
+    }
+
+    forkedFrom := source.ID
+    material := &models.Material{
+        Title:       title,
+        Subject:     source.Subject,
+        Description: source.Description,
+        Level:       source.Level,
+        Tags:        source.Tags,
+        CoverURL:    source.CoverURL,
+        Language:    source.Language,
+        Duration:    source.Duration,
+        AuthorID:    userID,
+        Status:      "draft",
+        Access:      "open",
+        ForkedFrom:  &forkedFrom,
+        Blocks:      newBlocks,
+    }
+
+    if err := s.materialRepo.CreateMaterialWithBlocks(ctx, material, newBlocks); err != nil {
+        return nil, fmt.Errorf("failed to copy material: %w", err)
+    }
+
+    return material, nil
+}
+
+// normalizeTags приводит теги к нижнему регистру и убирает пустые и повторяющиеся
+func normalizeTags(tags []string) []string {
+    normalized := []string{}
+    seen := make(map[string]bool)
+
+    for _, tag := range tags {
+        tag = strings.ToLower(strings.TrimSpace(tag))
+        if tag == "" || seen[tag] {
+            continue
+        }
+        if len([]rune(tag)) > 50 {
+            tag = string([]rune(tag)[:50])
+        }
+        seen[tag] = true
+        normalized = append(normalized, tag)
+    }
+
+    return normalized
+}
+
+// blockTypes - типы блоков, которые допускает material_blocks
+var blockTypes = map[string]bool{"text": true, "image": true, "video": true, "formula": true, "quiz": true, "code": true}
+
+// normalizeBlock приводит содержимое блока к каноническому виду: текст - к документу
+// rich text, блок с кодом - к проверенным полям и тестам
+func normalizeBlock(block *models.Block) error {
+    if block == nil {
+        return nil
+    }
+    switch block.Type {
+    case "text":
+        return normalizeTextBlock(block)
+    case "code":
+        return normalizeCodeBlock(block)
+    }
+    return nil
+}
+
+// normalizeBlocks приводит к каноническому виду все блоки списка
+func normalizeBlocks(blocks []models.Block) error {
+    for i := range blocks {
+        if err := normalizeBlock(&blocks[i]); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// newBlockID генерирует ID блока
+func newBlockID() string {
+    bytes := make([]byte, 8)
+    rand.Read(bytes)
+    return "block_" + hex.EncodeToString(bytes)
+}
+
+// generateShareURL генерирует уникальный URL для доступа по ссылке
+func (s *MaterialService) generateShareURL() string {
+    bytes := make([]byte, 8)
+    rand.Read(bytes)
+    return hex.EncodeToString(bytes)
+}
+
+// AddBlock добавляет блок к материалу
+func (s *MaterialService) AddBlock(ctx context.Context, userID, materialID int, block *models.Block) error {
+    _, err := s.ApplyBlockOperation(ctx, userID, &models.BlockOperation{
+        MaterialID: materialID,
+        Type:       "add",
+        BlockID:    block.ID,
+        Block:      block,
+    })
+    return err
+}
+
+// UpdateBlock обновляет блок
+func (s *MaterialService) UpdateBlock(ctx context.Context, userID, materialID int, blockID string, block *models.Block) error {
+    _, err := s.ApplyBlockOperation(ctx, userID, &models.BlockOperation{
+        MaterialID: materialID,
+        Type:       "update",
+        BlockID:    blockID,
+        Block:      block,
+    })
+    return err
+}
+
+// DeleteBlock удаляет блок
+func (s *MaterialService) DeleteBlock(ctx context.Context, userID, materialID int, blockID string) error {
+    _, err := s.ApplyBlockOperation(ctx, userID, &models.BlockOperation{
+        MaterialID: materialID,
+        Type:       "delete",
+        BlockID:    blockID,
+    })
+    return err
+}
+
+// ReorderBlocks изменяет порядок блоков
+func (s *MaterialService) ReorderBlocks(ctx context.Context, userID, materialID int, blockIDs []string) error {
+    _, err := s.ApplyBlockOperation(ctx, userID, &models.BlockOperation{
+        MaterialID: materialID,
+        Type:       "reorder",
+        BlockIDs:   blockIDs,
+    })
+    return err
+}
+
+// ApplyBlockOperation проверяет права и применяет операцию над блоками.
+// Все изменения блоков (REST и WebSocket) проходят через журнал операций,
+// поэтому получают общий порядковый номер и рассылаются редакторам материала
+func (s *MaterialService) ApplyBlockOperation(ctx context.Context, userID int, op *models.BlockOperation) (*models.BlockOperation, error) {
+    material, _, err := s.CheckAccess(ctx, userID, op.MaterialID, "editor")
+    if err != nil {
+        return nil, err
+    }
+
+    op.UserID = userID
+
+    // Новому блоку без ID назначаем его на сервере
+    if op.Type == "add" && op.Block != nil && op.Block.ID == "" {
+        op.Block.ID = newBlockID()
+        op.BlockID = op.Block.ID
+    }
+
+    // Текст приводится к документу rich text, блоки с кодом и анимации проверяются, формулы отрисовываются
+    // до сохранения: соавторы получают блок уже в каноническом виде с MathML и SVG
+    switch op.Type {
+    case "add", "update":
+        if op.Block != nil {
+            if op.Type == "update" {
+                op.Block.ID = op.BlockID
+            }
+            if err := normalizeBlock(op.Block); err != nil {
+                return nil, err
+            }
+            if errs := normalizeBlockAnimation(op.Block); len(errs) > 0 {
+                return nil, &AnimationValidationError{Errors: errs}
+            }
+            if errs := s.formulaService.PrepareBlock(op.Block); len(errs) > 0 {
+                return nil, &FormulaValidationError{Errors: errs}
+            }
+        }
+    case "replace":
+        if err := normalizeBlocks(op.Blocks); err != nil {
+            return nil, err
+        }
+        if errs := normalizeBlockAnimations(op.Blocks); len(errs) > 0 {
+            return nil, &AnimationValidationError{Errors: errs}
+        }
+        if err := s.formulaService.PrepareBlocks(op.Blocks); err != nil {
+            return nil, err
+        }
+    }
+
+    err = s.collaborationRepo.ApplyOperation(ctx, op, func(blocks []models.Block) ([]models.Block, error) {
+        return applyBlockOperation(blocks, op)
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    if err := s.resubmitEdited(ctx, userID, material); err != nil {
+        return nil, err
+    }
+
+    return op, nil
+}
+
+// resubmitEdited при модерации возвращает на проверку опубликованный или запланированный материал
+// после изменения содержимого: одобрение относилось к прежней версии. До нового решения модератора
+// материал недоступен ученикам. Правки доверенных авторов и администраторов проверки не требуют
+func (s *MaterialService) resubmitEdited(ctx context.Context, userID int, material *models.Material) error {
+    if !s.requireReview || !(material.Status == "published" || (material.Status == "draft" && material.PublishAt != nil)) {
+        return nil
+    }
+
+    trusted, err := s.moderationRepo.IsTrustedAuthor(ctx, userID)
+    if err != nil {
+        return err
+    }
+    if trusted {
+        return nil
+    }
+
+    resubmitted, err := s.moderationRepo.ResubmitEdited(ctx, material.ID, userID)
+    if err != nil {
+        return fmt.Errorf("failed to submit material for review: %w", err)
+    }
+    if resubmitted {
+        material.Status = "pending_review"
+        log.Printf("🔁 Material %d returned to review after edit by user %d", material.ID, userID)
+    }
+    return nil
+}
+
+// applyBlockOperation возвращает новый список блоков после операции
+func applyBlockOperation(blocks []models.Block, op *models.BlockOperation) ([]models.Block, error) {
+    switch op.Type {
+    case "add":
+        if op.Block == nil {
+            return nil, fmt.Errorf("block is required")
+        }
+        for _, b := range blocks {
+            if b.ID == op.Block.ID {
+                return nil, fmt.Errorf("block already exists")
+            }
+        }
+
+        // Вставляем на указанную позицию или в конец
+        position := len(blocks)
+        if op.Position != nil && *op.Position >= 0 && *op.Position < len(blocks) {
+            position = *op.Position
+        }
+        newBlocks := make([]models.Block, 0, len(blocks)+1)
+        newBlocks = append(newBlocks, blocks[:position]...)
+        newBlocks = append(newBlocks, *op.Block)
+        return append(newBlocks, blocks[position:]...), nil
+
+    case "update":
+        if op.Block == nil {
+            return nil, fmt.Errorf("block is required")
+        }
+        for i, b := range blocks {
+            if b.ID == op.BlockID {
+                blocks[i] = *op.Block
+                blocks[i].ID = op.BlockID
+                return blocks, nil
+            }
+        }
+        return nil, fmt.Errorf("block not found")
+
+    case "delete":
+        newBlocks := make([]models.Block, 0, len(blocks))
+        for _, b := range blocks {
+            if b.ID != op.BlockID {
+                newBlocks = append(newBlocks, b)
+            }
+        }
+        if len(newBlocks) == len(blocks) {
+            return nil, fmt.Errorf("block not found")
+        }
+        return newBlocks, nil
+
+    case "reorder":
+        // Создаем мапу для быстрого поиска блоков по ID
+        blockMap := make(map[string]models.Block)
+        for _, block := range blocks {
+            blockMap[block.ID] = block
+        }
+
+        if len(op.BlockIDs) != len(blocks) {
+            return nil, fmt.Errorf("block order must list every block")
+        }
+
+        // Создаем новые блоки в указанном порядке
+        newBlocks := make([]models.Block, 0, len(blocks))
+        for position, blockID := range op.BlockIDs {
+            block, exists := blockMap[blockID]
+            if !exists {
+                return nil, fmt.Errorf("block not found")
+            }
+            delete(blockMap, blockID)
+            block.Position = position
             newBlocks = append(newBlocks, block)
         }
+        return newBlocks, nil
+
+    case "replace":
+        return op.Blocks, nil
     }
 
-    return s.blockRepo.SaveBlocks(ctx, materialID, newBlocks)
+    return nil, fmt.Errorf("unknown operation: %s", op.Type)
 }
 
 // Вспомогательные функции
@@ -231,40 +973,133 @@ func getBlockIDs(blocks []models.Block) []string {
     return ids
 }
 
-// ReorderBlocks изменяет порядок блоков
-func (s *MaterialService) ReorderBlocks(ctx context.Context, userID, materialID int, blockIDs []string) error {
-    // Проверяем права
-    material, err := s.materialRepo.GetMaterial(ctx, materialID)
-    if err != nil || material == nil {
-        return fmt.Errorf("material not found")
+// GetCollaborators возвращает соавторов материала
+func (s *MaterialService) GetCollaborators(ctx context.Context, userID, materialID int) ([]models.Collaborator, error) {
+    if _, _, err := s.CheckAccess(ctx, userID, materialID, "viewer"); err != nil {
+        return nil, err
     }
-    if material.AuthorID != userID {
-        return fmt.Errorf("access denied")
+
+    return s.collaboratorRepo.GetCollaborators(ctx, materialID)
+}
+
+// AddCollaborator приглашает соавтора по ID или email
+func (s *MaterialService) AddCollaborator(ctx context.Context, userID, materialID int, req *models.AddCollaboratorRequest) (*models.User, error) {
+    material, _, err := s.CheckAccess(ctx, userID, materialID, "owner")
+    if err != nil {
+        return nil, err
     }
 
-    // Получаем текущие блоки
-    blocks, err := s.blockRepo.GetBlocks(ctx, materialID)
+    invitee, err := s.resolveUser(ctx, req.UserID, req.Email)
+    if err != nil {
+        return nil, err
+    }
+
+    if invitee.ID == material.AuthorID {
+        return nil, fmt.Errorf("owner cannot be a collaborator")
+    }
+
+    if err := s.collaboratorRepo.AddCollaborator(ctx, materialID, invitee.ID, req.Role, userID); err != nil {
+        return nil, fmt.Errorf("failed to add collaborator: %w", err)
+    }
+
+    return invitee, nil
+}
+
+// UpdateCollaborator меняет роль соавтора
+func (s *MaterialService) UpdateCollaborator(ctx context.Context, userID, materialID, collaboratorID int, role string) error {
+    if _, _, err := s.CheckAccess(ctx, userID, materialID, "owner"); err != nil {
+        return err
+    }
+
+    updated, err := s.collaboratorRepo.UpdateCollaboratorRole(ctx, materialID, collaboratorID, role)
     if err != nil {
+        return fmt.Errorf("failed to update collaborator: %w", err)
+    }
+    if !updated {
+        return fmt.Errorf("collaborator not found")
+    }
+
+    s.notifyAccessChanged(ctx, materialID, collaboratorID)
+    return nil
+}
+
+// RemoveCollaborator удаляет соавтора. Соавтор может сам покинуть материал
+func (s *MaterialService) RemoveCollaborator(ctx context.Context, userID, materialID, collaboratorID int) error {
+    minRole := "owner"
+    if userID == collaboratorID {
+        minRole = "viewer"
+    }
+
+    if _, _, err := s.CheckAccess(ctx, userID, materialID, minRole); err != nil {
         return err
     }
 
-    // Создаем мапу для быстрого поиска блоков по ID
-    blockMap := make(map[string]models.Block)
-    for _, block := range blocks {
-        blockMap[block.ID] = block
+    removed, err := s.collaboratorRepo.RemoveCollaborator(ctx, materialID, collaboratorID)
+    if err != nil {
+        return fmt.Errorf("failed to remove collaborator: %w", err)
+    }
+    if !removed {
+        return fmt.Errorf("collaborator not found")
+    }
+
+    s.notifyAccessChanged(ctx, materialID, collaboratorID)
+    return nil
+}
+
+// TransferOwnership передает владение материалом другому пользователю
+func (s *MaterialService) TransferOwnership(ctx context.Context, userID, materialID int, req *models.TransferOwnershipRequest) (*models.User, error) {
+    if _, _, err := s.CheckAccess(ctx, userID, materialID, "owner"); err != nil {
+        return nil, err
+    }
+
+    newOwner, err := s.resolveUser(ctx, req.UserID, req.Email)
+    if err != nil {
+        return nil, err
+    }
+
+    if newOwner.ID == userID {
+        return nil, fmt.Errorf("user already owns material")
+    }
+
+    if err := s.collaboratorRepo.TransferOwnership(ctx, materialID, userID, newOwner.ID); err != nil {
+        return nil, fmt.Errorf("failed to transfer ownership: %w", err)
     }
 
-    // Создаем новые блоки в указанном порядке
-    var newBlocks []models.Block
-    for position, blockID := range blockIDs {
-        block, exists := blockMap[blockID]
-        if !exists {
-            return fmt.Errorf("block not found: %s", blockID)
+    s.notifyAccessChanged(ctx, materialID, userID, newOwner.ID)
+    return newOwner, nil
+}
+
+// notifyAccessChanged сообщает всем инстансам, что роль пользователей в материале изменилась:
+// их подключения к совместному редактированию закрываются, и клиент переподключается с новой ролью
+func (s *MaterialService) notifyAccessChanged(ctx context.Context, materialID int, userIDs ...int) {
+    for _, userID := range userIDs {
+        event := &models.CollaborationEvent{Type: "access_changed", MaterialID: materialID, UserID: userID}
+        if err := s.collaborationRepo.Notify(ctx, event); err != nil {
+            log.Printf("⚠️ Failed to publish access change of user %d in material %d: %v", userID, materialID, err)
         }
-        block.Position = position
-        newBlocks = append(newBlocks, block)
+    }
+}
+
+// resolveUser находит пользователя по ID или email
+func (s *MaterialService) resolveUser(ctx context.Context, userID int, email string) (*models.User, error) {
+    var user *models.User
+    var err error
+
+    switch {
+    case userID > 0:
+        user, err = s.userRepo.GetUserByID(ctx, userID)
+    case email != "":
+        user, err = s.userRepo.GetUserByEmail(ctx, email)
+    default:
+        return nil, fmt.Errorf("userId or email is required")
+    }
+
+    if err != nil {
+        return nil, err
+    }
+    if user == nil {
+        return nil, fmt.Errorf("user not found")
     }
 
-    // Сохраняем новый порядок
-    return s.blockRepo.SaveBlocks(ctx, materialID, newBlocks)
+    return user, nil
 }
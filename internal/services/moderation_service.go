@@ -0,0 +1,135 @@
+package services
+
+import (
+    "context"
+    "fmt"
+    "strings"
+    "time"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/repositories"
+)
+
+type ModerationService struct {
+    moderationRepo  *repositories.ModerationRepository
+    materialRepo    *repositories.MaterialRepository
+    blockRepo       *repositories.BlockRepository
+    materialService *MaterialService
+}
+
+func NewModerationService(moderationRepo *repositories.ModerationRepository, materialRepo *repositories.MaterialRepository, blockRepo *repositories.BlockRepository, materialService *MaterialService) *ModerationService {
+    return &ModerationService{
+        moderationRepo:  moderationRepo,
+        materialRepo:    materialRepo,
+        blockRepo:       blockRepo,
+        materialService: materialService,
+    }
+}
+
+// GetQueue возвращает очередь модерации
+func (s *ModerationService) GetQueue(ctx context.Context, filters models.ModerationQueueFilters) ([]models.MaterialReview, int, error) {
+    return s.moderationRepo.GetQueue(ctx, filters)
+}
+
+// GetReview возвращает заявку вместе с материалом и его блоками в текущем виде
+func (s *ModerationService) GetReview(ctx context.Context, reviewID int) (*models.MaterialReview, *models.Material, error) {
+    review, err := s.moderationRepo.GetReview(ctx, reviewID)
+    if err != nil || review == nil {
+        return nil, nil, fmt.Errorf("review not found")
+    }
+
+    material, err := s.materialRepo.GetMaterial(ctx, review.MaterialID)
+    if err != nil || material == nil {
+        return nil, nil, fmt.Errorf("material not found")
+    }
+    if material.Blocks, err = s.blockRepo.GetBlocks(ctx, material.ID); err != nil {
+        return nil, nil, err
+    }
+
+    return review, material, nil
+}
+
+// ApproveReview одобряет материал: он публикуется, а если время публикации по расписанию
+// еще не наступило - остается запланированным черновиком
+func (s *ModerationService) ApproveReview(ctx context.Context, moderatorID, reviewID int, req *models.ReviewDecisionRequest) (*models.MaterialReview, error) {
+    material, err := s.pendingMaterial(ctx, reviewID, req)
+    if err != nil {
+        return nil, err
+    }
+
+    status := "published"
+    if material.PublishAt != nil && material.PublishAt.After(time.Now()) {
+        status = "draft"
+    }
+
+    return s.decide(ctx, moderatorID, reviewID, "approved", req, status)
+}
+
+// RequestChanges возвращает материал автору в черновики с общим комментарием и замечаниями к блокам
+func (s *ModerationService) RequestChanges(ctx context.Context, moderatorID, reviewID int, req *models.ReviewDecisionRequest) (*models.MaterialReview, error) {
+    if strings.TrimSpace(req.Comment) == "" && len(req.BlockComments) == 0 {
+        return nil, fmt.Errorf("comment is required")
+    }
+    if _, err := s.pendingMaterial(ctx, reviewID, req); err != nil {
+        return nil, err
+    }
+
+    return s.decide(ctx, moderatorID, reviewID, "changes_requested", req, "draft")
+}
+
+// GetMaterialReviews возвращает автору и соавторам историю модерации материала
+func (s *ModerationService) GetMaterialReviews(ctx context.Context, userID, materialID int) ([]models.MaterialReview, error) {
+    if _, _, err := s.materialService.CheckAccess(ctx, userID, materialID, "viewer"); err != nil {
+        return nil, err
+    }
+
+    return s.moderationRepo.GetMaterialReviews(ctx, materialID)
+}
+
+// SetTrustedAuthor изменяет доверие к автору
+func (s *ModerationService) SetTrustedAuthor(ctx context.Context, userID int, trusted bool) error {
+    found, err := s.moderationRepo.SetTrustedAuthor(ctx, userID, trusted)
+    if err != nil {
+        return err
+    }
+    if !found {
+        return fmt.Errorf("user not found")
+    }
+    return nil
+}
+
+// pendingMaterial проверяет, что заявка ждет решения, и замечания относятся к блокам материала
+func (s *ModerationService) pendingMaterial(ctx context.Context, reviewID int, req *models.ReviewDecisionRequest) (*models.Material, error) {
+    review, material, err := s.GetReview(ctx, reviewID)
+    if err != nil {
+        return nil, err
+    }
+    if review.Status != "pending" || material.Status != "pending_review" {
+        return nil, fmt.Errorf("review is not pending")
+    }
+
+    blockIDs := make(map[string]bool, len(material.Blocks))
+    for _, block := range material.Blocks {
+        blockIDs[block.ID] = true
+    }
+    for _, comment := range req.BlockComments {
+        if !blockIDs[comment.BlockID] {
+            return nil, fmt.Errorf("block not found")
+        }
+    }
+
+    return material, nil
+}
+
+func (s *ModerationService) decide(ctx context.Context, moderatorID, reviewID int, status string, req *models.ReviewDecisionRequest, materialStatus string) (*models.MaterialReview, error) {
+    decided, err := s.moderationRepo.DecideReview(ctx, reviewID, moderatorID, status, req, materialStatus)
+    if err != nil {
+        return nil, fmt.Errorf("failed to save review decision: %w", err)
+    }
+    // Автор успел отозвать заявку или другой модератор уже принял решение
+    if !decided {
+        return nil, fmt.Errorf("review is not pending")
+    }
+
+    return s.moderationRepo.GetReview(ctx, reviewID)
+}
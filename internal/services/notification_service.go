@@ -0,0 +1,48 @@
+package services
+
+import (
+    "context"
+    "fmt"
+    "log"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/repositories"
+)
+
+type NotificationService struct {
+    notificationRepo *repositories.NotificationRepository
+}
+
+func NewNotificationService(notificationRepo *repositories.NotificationRepository) *NotificationService {
+    return &NotificationService{notificationRepo: notificationRepo}
+}
+
+// Notify отправляет уведомление пользователю. Ошибка только логируется:
+// уведомление не должно срывать действие, которое его вызвало
+func (s *NotificationService) Notify(ctx context.Context, userID int, kind, message string, materialID *int) {
+    if err := s.notificationRepo.CreateNotification(ctx, userID, kind, message, materialID); err != nil {
+        log.Printf("⚠️ Failed to notify user %d (%s): %v", userID, kind, err)
+    }
+}
+
+// GetNotifications возвращает уведомления пользователя и количество непрочитанных
+func (s *NotificationService) GetNotifications(ctx context.Context, userID int, filters models.NotificationFilters) ([]models.Notification, int, int, error) {
+    return s.notificationRepo.GetNotifications(ctx, userID, filters)
+}
+
+// MarkRead отмечает уведомление прочитанным
+func (s *NotificationService) MarkRead(ctx context.Context, userID, notificationID int) error {
+    found, err := s.notificationRepo.MarkRead(ctx, userID, notificationID)
+    if err != nil {
+        return err
+    }
+    if !found {
+        return fmt.Errorf("notification not found")
+    }
+    return nil
+}
+
+// MarkAllRead отмечает прочитанными все уведомления пользователя
+func (s *NotificationService) MarkAllRead(ctx context.Context, userID int) (int, error) {
+    return s.notificationRepo.MarkAllRead(ctx, userID)
+}
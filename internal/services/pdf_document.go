@@ -0,0 +1,633 @@
+package services
+
+import (
+    "bytes"
+    "compress/zlib"
+    "crypto/sha1"
+    "fmt"
+    "image"
+    "image/draw"
+    _ "image/gif"
+    "image/jpeg"
+    _ "image/png"
+    "io"
+    "math"
+    "sort"
+    "strings"
+    "unicode"
+)
+
+// Размеры страницы A4 и поля в пунктах
+const (
+    pdfPageWidth  = 595.28
+    pdfPageHeight = 841.89
+    pdfMargin     = 56.0
+    pdfTextWidth  = pdfPageWidth - 2*pdfMargin
+)
+
+// pdfFontSet - шрифты документа: обычный, жирный и моноширинный
+type pdfFontSet struct {
+    regular *ttfFont
+    bold    *ttfFont
+    mono    *ttfFont
+}
+
+func loadPDFFonts(dir string) (*pdfFontSet, error) {
+    fonts := &pdfFontSet{}
+    for _, item := range []struct {
+        file string
+        font **ttfFont
+    }{
+        {"DejaVuSans.ttf", &fonts.regular},
+        {"DejaVuSans-Bold.ttf", &fonts.bold},
+        {"DejaVuSansMono.ttf", &fonts.mono},
+    } {
+        font, err := loadTTFFont(dir + "/" + item.file)
+        if err != nil {
+            return nil, err
+        }
+        *item.font = font
+    }
+    return fonts, nil
+}
+
+// pdfFont - шрифт в конкретном документе: ресурс /F1... и использованные глифы
+type pdfFont struct {
+    resource string
+    font     *ttfFont
+    used     map[uint16]rune
+}
+
+// pdfImage - картинка документа, ресурс /Im1...
+type pdfImage struct {
+    resource string
+    width    int
+    height   int
+    filter   string // DCTDecode для JPEG как есть, FlateDecode для остальных
+    color    string
+    data     []byte
+    alpha    []byte
+}
+
+type pdfLink struct {
+    rect [4]float64
+    url  string
+}
+
+type pdfPage struct {
+    content bytes.Buffer
+    links   []pdfLink
+}
+
+// pdfDocument раскладывает текст и картинки по страницам A4 сверху вниз
+type pdfDocument struct {
+    title   string
+    regular *pdfFont
+    bold    *pdfFont
+    mono    *pdfFont
+    images  []*pdfImage
+    pages   []*pdfPage
+    page    *pdfPage
+    y       float64 // текущая позиция от нижнего края страницы
+    footer  string
+}
+
+func newPDFDocument(fonts *pdfFontSet, title string) *pdfDocument {
+    doc := &pdfDocument{
+        title:   title,
+        regular: &pdfFont{resource: "F1", font: fonts.regular, used: make(map[uint16]rune)},
+        bold:    &pdfFont{resource: "F2", font: fonts.bold, used: make(map[uint16]rune)},
+        mono:    &pdfFont{resource: "F3", font: fonts.mono, used: make(map[uint16]rune)},
+    }
+    doc.newPage()
+    return doc
+}
+
+func (d *pdfDocument) newPage() {
+    d.page = &pdfPage{}
+    d.pages = append(d.pages, d.page)
+    d.y = pdfPageHeight - pdfMargin
+}
+
+// ensure переносит вывод на новую страницу, если до нижнего поля меньше height
+func (d *pdfDocument) ensure(height float64) {
+    if d.y-height < pdfMargin && d.y < pdfPageHeight-pdfMargin {
+        d.newPage()
+    }
+}
+
+func (d *pdfDocument) space(height float64) {
+    d.y -= height
+    if d.y < pdfMargin {
+        d.newPage()
+    }
+}
+
+func (d *pdfDocument) pageBreak() {
+    if d.y < pdfPageHeight-pdfMargin {
+        d.newPage()
+    }
+}
+
+// textWidth возвращает ширину строки в пунктах
+func (f *pdfFont) textWidth(text string, size float64) float64 {
+    var width float64
+    for _, r := range text {
+        width += f.font.width(f.font.glyph(r))
+    }
+    return width * size / 1000
+}
+
+// encode переводит строку в номера глифов для оператора Tj и запоминает глифы для подмножества
+func (f *pdfFont) encode(text string) string {
+    var buf strings.Builder
+    buf.WriteByte('<')
+    for _, r := range text {
+        gid := f.font.glyph(r)
+        if _, ok := f.used[gid]; !ok {
+            f.used[gid] = r
+        }
+        fmt.Fprintf(&buf, "%04X", gid)
+    }
+    buf.WriteByte('>')
+    return buf.String()
+}
+
+// wrap разбивает текст на строки не шире width. Явные переводы строк сохраняются,
+// слишком длинные слова режутся по символам
+func (f *pdfFont) wrap(text string, size, width float64) []string {
+    var lines []string
+    for _, paragraph := range strings.Split(text, "\n") {
+        line := ""
+        for _, word := range strings.FieldsFunc(paragraph, unicode.IsSpace) {
+            candidate := word
+            if line != "" {
+                candidate = line + " " + word
+            }
+            if f.textWidth(candidate, size) <= width {
+                line = candidate
+                continue
+            }
+            if line != "" {
+                lines = append(lines, line)
+            }
+            line = ""
+            for f.textWidth(word, size) > width {
+                runes := []rune(word)
+                cut := len(runes) - 1
+                for cut > 1 && f.textWidth(string(runes[:cut]), size) > width {
+                    cut--
+                }
+                lines = append(lines, string(runes[:cut]))
+                word = string(runes[cut:])
+            }
+            line = word
+        }
+        lines = append(lines, line)
+    }
+    return lines
+}
+
+// textLine выводит строку в точке x на текущей базовой линии
+func (d *pdfDocument) textLine(font *pdfFont, size, x float64, text string, color [3]float64) {
+    if text == "" {
+        return
+    }
+    fmt.Fprintf(&d.page.content, "BT %.3f %.3f %.3f rg /%s %.2f Tf %.2f %.2f Td %s Tj ET\n",
+        color[0], color[1], color[2], font.resource, size, x, d.y, font.encode(text))
+}
+
+// paragraph выводит текст с переносом строк. indent - отступ слева, align - left или center
+func (d *pdfDocument) paragraph(font *pdfFont, size float64, text string, indent float64, align string, color [3]float64) {
+    lineHeight := size * 1.45
+    width := pdfTextWidth - indent
+    for _, line := range font.wrap(text, size, width) {
+        d.ensure(lineHeight)
+        d.y -= lineHeight
+        x := pdfMargin + indent
+        if align == "center" {
+            x = pdfMargin + indent + (width-font.textWidth(line, size))/2
+        }
+        d.textLine(font, size, x, line, color)
+    }
+}
+
+// codeBlock выводит моноширинный текст на сером фоне; строки не склеиваются
+func (d *pdfDocument) codeBlock(text string) {
+    const size = 9.5
+    lineHeight := size * 1.4
+    lines := d.mono.wrap(strings.ReplaceAll(text, "\t", "    "), size, pdfTextWidth-16)
+    for len(lines) > 0 {
+        d.ensure(lineHeight + 12)
+        fit := int((d.y - pdfMargin - 12) / lineHeight)
+        if fit < 1 {
+            fit = 1
+        }
+        if fit > len(lines) {
+            fit = len(lines)
+        }
+        height := float64(fit)*lineHeight + 12
+        fmt.Fprintf(&d.page.content, "q 0.953 0.957 0.965 rg %.2f %.2f %.2f %.2f re f Q\n",
+            pdfMargin, d.y-height, pdfTextWidth, height)
+        d.y -= 6
+        for _, line := range lines[:fit] {
+            d.y -= lineHeight
+            d.textLine(d.mono, size, pdfMargin+8, line, pdfColorText)
+        }
+        d.y -= 6
+        lines = lines[fit:]
+    }
+}
+
+// checkbox рисует квадрат (или круг для одного ответа) для отметки варианта в рабочем листе
+func (d *pdfDocument) checkbox(x, size float64, round bool) {
+    if round {
+        r := size / 2
+        cx, cy := x+r, d.y+r
+        const k = 0.5523
+        fmt.Fprintf(&d.page.content, "q 0.6 w 0.3 0.35 0.4 RG %.2f %.2f m %.2f %.2f %.2f %.2f %.2f %.2f c %.2f %.2f %.2f %.2f %.2f %.2f c %.2f %.2f %.2f %.2f %.2f %.2f c %.2f %.2f %.2f %.2f %.2f %.2f c S Q\n",
+            cx+r, cy,
+            cx+r, cy+r*k, cx+r*k, cy+r, cx, cy+r,
+            cx-r*k, cy+r, cx-r, cy+r*k, cx-r, cy,
+            cx-r, cy-r*k, cx-r*k, cy-r, cx, cy-r,
+            cx+r*k, cy-r, cx+r, cy-r*k, cx+r, cy)
+        return
+    }
+    fmt.Fprintf(&d.page.content, "q 0.6 w 0.3 0.35 0.4 RG %.2f %.2f %.2f %.2f re S Q\n", x, d.y, size, size)
+}
+
+// rule рисует горизонтальную линию на текущей высоте
+func (d *pdfDocument) rule(x1, x2 float64) {
+    fmt.Fprintf(&d.page.content, "q 0.5 w 0.8 0.82 0.85 RG %.2f %.2f m %.2f %.2f l S Q\n", x1, d.y, x2, d.y)
+}
+
+// formula рисует раскладку формулы по центру строки. size - кегль для 1 em; широкие формулы
+// уменьшаются до ширины текста. Знаки подгоняются под ширину раскладки так же, как textLength в SVG
+func (d *pdfDocument) formula(box *formulaBox, size float64) {
+    if box.width*size > pdfTextWidth {
+        size = pdfTextWidth / box.width
+    }
+    d.ensure((box.height + box.depth) * size)
+    d.y -= box.height * size
+    x := pdfMargin + (pdfTextWidth-box.width*size)/2
+    color := pdfColorText
+
+    for _, item := range box.items {
+        switch item.kind {
+        case "text":
+            font := d.regular
+            if item.bold {
+                font = d.bold
+            }
+            natural := font.textWidth(item.text, item.size*size)
+            if natural == 0 {
+                continue
+            }
+            stretch, slant := math.Max(item.stretch, 1), 0.0
+            if item.italic {
+                // Курсивного шрифта нет, наклон задается матрицей текста
+                slant = 0.2
+            }
+            // q/Q - чтобы масштаб Tz не достался следующему тексту страницы
+            fmt.Fprintf(&d.page.content, "q BT %.3f %.3f %.3f rg /%s %.2f Tf %.2f Tz 1 0 %.3f %.3f %.2f %.2f Tm %s Tj ET Q\n",
+                color[0], color[1], color[2], font.resource, item.size*size, 100*item.width*size/natural,
+                slant*stretch, stretch, x+item.x*size, d.y+item.y*size, font.encode(item.text))
+        case "rule":
+            fmt.Fprintf(&d.page.content, "q %.3f %.3f %.3f rg %.2f %.2f %.2f %.2f re f Q\n",
+                color[0], color[1], color[2], x+item.x*size, d.y+item.y*size, item.width*size, item.height*size)
+        case "path":
+            var path strings.Builder
+            for i := 0; i+1 < len(item.points); i += 2 {
+                operator := "l"
+                if i == 0 {
+                    operator = "m"
+                }
+                fmt.Fprintf(&path, "%.2f %.2f %s ", x+item.points[i]*size, d.y+item.points[i+1]*size, operator)
+            }
+            fmt.Fprintf(&d.page.content, "q %.3f %.3f %.3f RG %.2f w 1 j %sS Q\n",
+                color[0], color[1], color[2], item.height*size, path.String())
+        }
+    }
+    d.y -= box.depth * size
+}
+
+// link делает прямоугольник над последней строкой ссылкой
+func (d *pdfDocument) link(x, width, height float64, url string) {
+    d.page.links = append(d.page.links, pdfLink{rect: [4]float64{x, d.y - 2, x + width, d.y + height}, url: url})
+}
+
+// image вписывает картинку в ширину текста и не выше половины страницы
+func (d *pdfDocument) image(img *pdfImage) {
+    width := float64(img.width) * 0.75
+    height := float64(img.height) * 0.75
+    if width > pdfTextWidth {
+        height = height * pdfTextWidth / width
+        width = pdfTextWidth
+    }
+    if maxHeight := (pdfPageHeight - 2*pdfMargin) / 2; height > maxHeight {
+        width = width * maxHeight / height
+        height = maxHeight
+    }
+
+    d.ensure(height)
+    d.y -= height
+    x := pdfMargin + (pdfTextWidth-width)/2
+    fmt.Fprintf(&d.page.content, "q %.2f 0 0 %.2f %.2f %.2f cm /%s Do Q\n", width, height, x, d.y, img.resource)
+}
+
+// addImage готовит картинку для документа: JPEG встраивается как есть, остальное
+// декодируется и сжимается заново. Неподдерживаемые форматы (например, WebP) возвращают ошибку
+func (d *pdfDocument) addImage(data []byte) (*pdfImage, error) {
+    config, format, err := image.DecodeConfig(bytes.NewReader(data))
+    if err != nil {
+        return nil, err
+    }
+    if config.Width == 0 || config.Height == 0 || config.Width*config.Height > 40_000_000 {
+        return nil, fmt.Errorf("unsupported image size")
+    }
+
+    img := &pdfImage{resource: fmt.Sprintf("Im%d", len(d.images)+1), width: config.Width, height: config.Height}
+
+    if format == "jpeg" {
+        decoded, err := jpeg.Decode(bytes.NewReader(data))
+        if err != nil {
+            return nil, err
+        }
+        switch decoded.(type) {
+        case *image.Gray:
+            img.filter, img.color, img.data = "DCTDecode", "DeviceGray", data
+        case *image.YCbCr:
+            img.filter, img.color, img.data = "DCTDecode", "DeviceRGB", data
+        }
+        if img.data != nil {
+            d.images = append(d.images, img)
+            return img, nil
+        }
+    }
+
+    decoded, _, err := image.Decode(bytes.NewReader(data))
+    if err != nil {
+        return nil, err
+    }
+    bounds := decoded.Bounds()
+    rgba := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+    draw.Draw(rgba, rgba.Bounds(), decoded, bounds.Min, draw.Src)
+
+    rgb := make([]byte, 0, bounds.Dx()*bounds.Dy()*3)
+    alpha := make([]byte, 0, bounds.Dx()*bounds.Dy())
+    opaque := true
+    for i := 0; i < len(rgba.Pix); i += 4 {
+        rgb = append(rgb, rgba.Pix[i], rgba.Pix[i+1], rgba.Pix[i+2])
+        alpha = append(alpha, rgba.Pix[i+3])
+        if rgba.Pix[i+3] != 0xFF {
+            opaque = false
+        }
+    }
+
+    img.filter, img.color, img.data = "FlateDecode", "DeviceRGB", deflate(rgb)
+    if !opaque {
+        img.alpha = deflate(alpha)
+    }
+    d.images = append(d.images, img)
+    return img, nil
+}
+
+var (
+    pdfColorText  = [3]float64{0.122, 0.161, 0.2}
+    pdfColorMuted = [3]float64{0.482, 0.529, 0.58}
+    pdfColorLink  = [3]float64{0.149, 0.502, 0.761}
+)
+
+// WriteTo собирает объекты PDF: каталог, страницы с нижним колонтитулом, шрифты
+// (подмножества TrueType с картой ToUnicode для копирования текста) и картинки
+func (d *pdfDocument) WriteTo(w io.Writer) (int64, error) {
+    objects := &pdfObjects{}
+    catalogID := objects.reserve()
+    pagesID := objects.reserve()
+    resourcesID := objects.reserve()
+
+    // Колонтитул с номером страницы добавляется, когда известно число страниц
+    for i, page := range d.pages {
+        d.page = page
+        d.y = pdfMargin / 2
+        footer := fmt.Sprintf("%d / %d", i+1, len(d.pages))
+        if d.footer != "" {
+            d.textLine(d.regular, 8, pdfMargin, d.footer, pdfColorMuted)
+        }
+        d.textLine(d.regular, 8, pdfPageWidth-pdfMargin-d.regular.textWidth(footer, 8), footer, pdfColorMuted)
+    }
+
+    fonts := make([]string, 0, 3)
+    for _, font := range []*pdfFont{d.regular, d.bold, d.mono} {
+        if len(font.used) > 0 {
+            fonts = append(fonts, fmt.Sprintf("/%s %d 0 R", font.resource, objects.font(font)))
+        }
+    }
+
+    images := make([]string, 0, len(d.images))
+    for _, img := range d.images {
+        images = append(images, fmt.Sprintf("/%s %d 0 R", img.resource, objects.image(img)))
+    }
+
+    objects.set(resourcesID, fmt.Sprintf("<< /ProcSet [/PDF /Text /ImageC /ImageB] /Font << %s >> /XObject << %s >> >>",
+        strings.Join(fonts, " "), strings.Join(images, " ")))
+
+    kids := make([]string, 0, len(d.pages))
+    for _, page := range d.pages {
+        pageID := objects.reserve()
+        contentID := objects.reserve()
+        objects.stream(contentID, "", page.content.Bytes(), true)
+
+        annotations := make([]string, 0, len(page.links))
+        for _, link := range page.links {
+            annotations = append(annotations, fmt.Sprintf("<< /Type /Annot /Subtype /Link /Rect [%.2f %.2f %.2f %.2f] /Border [0 0 0] /A << /S /URI /URI %s >> >>",
+                link.rect[0], link.rect[1], link.rect[2], link.rect[3], pdfString(link.url)))
+        }
+
+        objects.set(pageID, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.2f %.2f] /Resources %d 0 R /Contents %d 0 R /Annots [%s] >>",
+            pagesID, pdfPageWidth, pdfPageHeight, resourcesID, contentID, strings.Join(annotations, " ")))
+        kids = append(kids, fmt.Sprintf("%d 0 R", pageID))
+    }
+
+    objects.set(pagesID, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids)))
+    objects.set(catalogID, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesID))
+    infoID := objects.reserve()
+    objects.set(infoID, fmt.Sprintf("<< /Title %s /Producer (Paydeya) >>", pdfTextString(d.title)))
+
+    return objects.writeTo(w, catalogID, infoID)
+}
+
+// pdfObjects - нумерованные объекты PDF и запись файла с таблицей xref
+type pdfObjects struct {
+    objects [][]byte
+}
+
+func (o *pdfObjects) reserve() int {
+    o.objects = append(o.objects, nil)
+    return len(o.objects)
+}
+
+func (o *pdfObjects) set(id int, body string) {
+    o.objects[id-1] = []byte(body)
+}
+
+func (o *pdfObjects) stream(id int, dict string, data []byte, compress bool) {
+    if compress {
+        data = deflate(data)
+        dict += " /Filter /FlateDecode"
+    }
+    var buf bytes.Buffer
+    fmt.Fprintf(&buf, "<< %s /Length %d >>\nstream\n", strings.TrimSpace(dict), len(data))
+    buf.Write(data)
+    buf.WriteString("\nendstream")
+    o.objects[id-1] = buf.Bytes()
+}
+
+// font записывает шрифт Type0 с CIDFontType2 и возвращает номер объекта
+func (o *pdfObjects) font(f *pdfFont) int {
+    fontID := o.reserve()
+    cidFontID := o.reserve()
+    descriptorID := o.reserve()
+    fileID := o.reserve()
+    toUnicodeID := o.reserve()
+
+    glyphs := make([]int, 0, len(f.used))
+    used := make(map[uint16]bool, len(f.used))
+    for gid := range f.used {
+        glyphs = append(glyphs, int(gid))
+        used[gid] = true
+    }
+    sort.Ints(glyphs)
+
+    // Префикс подмножества - шесть заглавных букв, зависящих от набора глифов
+    hash := sha1.New()
+    for _, gid := range glyphs {
+        fmt.Fprintf(hash, "%d,", gid)
+    }
+    sum := hash.Sum(nil)
+    tag := make([]byte, 6)
+    for i := range tag {
+        tag[i] = 'A' + sum[i]%26
+    }
+    baseFont := string(tag) + "+" + f.font.name
+
+    widths := make([]string, 0, len(glyphs))
+    for _, gid := range glyphs {
+        widths = append(widths, fmt.Sprintf("%d [%.0f]", gid, f.font.width(uint16(gid))))
+    }
+
+    scale := 1000 / f.font.unitsPerEm
+    o.set(fontID, fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>",
+        baseFont, cidFontID, toUnicodeID))
+    o.set(cidFontID, fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor %d 0 R /DW 1000 /W [%s] /CIDToGIDMap /Identity >>",
+        baseFont, descriptorID, strings.Join(widths, " ")))
+    o.set(descriptorID, fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags 32 /FontBBox [%.0f %.0f %.0f %.0f] /ItalicAngle 0 /Ascent %.0f /Descent %.0f /CapHeight %.0f /StemV 80 /FontFile2 %d 0 R >>",
+        baseFont, f.font.bbox[0]*scale, f.font.bbox[1]*scale, f.font.bbox[2]*scale, f.font.bbox[3]*scale,
+        f.font.ascent*scale, f.font.descent*scale, f.font.capHeight*scale, fileID))
+
+    fontFile := f.font.subset(used)
+    o.stream(fileID, fmt.Sprintf("/Length1 %d", len(fontFile)), fontFile, true)
+    o.stream(toUnicodeID, "", toUnicodeCMap(f.used, glyphs), true)
+
+    return fontID
+}
+
+func (o *pdfObjects) image(img *pdfImage) int {
+    id := o.reserve()
+    dict := fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /%s /BitsPerComponent 8 /Filter /%s",
+        img.width, img.height, img.color, img.filter)
+    if img.alpha != nil {
+        maskID := o.reserve()
+        o.stream(maskID, fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceGray /BitsPerComponent 8 /Filter /FlateDecode",
+            img.width, img.height), img.alpha, false)
+        dict += fmt.Sprintf(" /SMask %d 0 R", maskID)
+    }
+    o.stream(id, dict, img.data, false)
+    return id
+}
+
+func (o *pdfObjects) writeTo(w io.Writer, rootID, infoID int) (int64, error) {
+    var buf bytes.Buffer
+    buf.WriteString("%PDF-1.7\n%\xE2\xE3\xCF\xD3\n")
+
+    offsets := make([]int, len(o.objects))
+    for i, body := range o.objects {
+        offsets[i] = buf.Len()
+        fmt.Fprintf(&buf, "%d 0 obj\n", i+1)
+        buf.Write(body)
+        buf.WriteString("\nendobj\n")
+    }
+
+    xref := buf.Len()
+    fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(o.objects)+1)
+    for _, offset := range offsets {
+        fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+    }
+    fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+        len(o.objects)+1, rootID, infoID, xref)
+
+    return buf.WriteTo(w)
+}
+
+// toUnicodeCMap сопоставляет глифы с символами, чтобы текст из PDF можно было копировать и искать
+func toUnicodeCMap(used map[uint16]rune, glyphs []int) []byte {
+    var buf bytes.Buffer
+    buf.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n")
+    buf.WriteString("/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n")
+    buf.WriteString("/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n")
+    buf.WriteString("1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+
+    for start := 0; start < len(glyphs); start += 100 {
+        end := start + 100
+        if end > len(glyphs) {
+            end = len(glyphs)
+        }
+        fmt.Fprintf(&buf, "%d beginbfchar\n", end-start)
+        for _, gid := range glyphs[start:end] {
+            fmt.Fprintf(&buf, "<%04X> <", gid)
+            for _, unit := range utf16Units(used[uint16(gid)]) {
+                fmt.Fprintf(&buf, "%04X", unit)
+            }
+            buf.WriteString(">\n")
+        }
+        buf.WriteString("endbfchar\n")
+    }
+
+    buf.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+    return buf.Bytes()
+}
+
+func utf16Units(r rune) []uint16 {
+    if r < 0x10000 {
+        return []uint16{uint16(r)}
+    }
+    r -= 0x10000
+    return []uint16{uint16(0xD800 + (r >> 10)), uint16(0xDC00 + (r & 0x3FF))}
+}
+
+func deflate(data []byte) []byte {
+    var buf bytes.Buffer
+    writer := zlib.NewWriter(&buf)
+    writer.Write(data)
+    writer.Close()
+    return buf.Bytes()
+}
+
+// pdfString записывает ASCII-строку PDF в скобках с экранированием
+func pdfString(value string) string {
+    replacer := strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`, "\r", "", "\n", "")
+    return "(" + replacer.Replace(value) + ")"
+}
+
+// pdfTextString записывает произвольный текст в UTF-16BE для метаданных документа
+func pdfTextString(value string) string {
+    var buf strings.Builder
+    buf.WriteString("<FEFF")
+    for _, r := range value {
+        for _, unit := range utf16Units(r) {
+            fmt.Fprintf(&buf, "%04X", unit)
+        }
+    }
+    buf.WriteString(">")
+    return buf.String()
+}
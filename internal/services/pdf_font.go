@@ -0,0 +1,378 @@
+package services
+
+import (
+    "encoding/binary"
+    "fmt"
+    "os"
+    "path/filepath"
+    "sort"
+)
+
+// ttfFont - шрифт TrueType для PDF: метрики, таблица символов и исходные таблицы для подмножества
+type ttfFont struct {
+    name        string
+    unitsPerEm  float64
+    ascent      float64
+    descent     float64
+    capHeight   float64
+    bbox        [4]float64
+    numGlyphs   int
+    advances    []uint16
+    cmap        map[rune]uint16
+    tables      map[string][]byte
+    glyphOffset []uint32 // смещения глифов в glyf из loca, numGlyphs+1 элементов
+}
+
+func loadTTFFont(path string) (*ttfFont, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, err
+    }
+    font, err := parseTTFFont(data)
+    if err != nil {
+        return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+    }
+    font.name = pdfFontName(filepath.Base(path))
+    return font, nil
+}
+
+func parseTTFFont(data []byte) (*ttfFont, error) {
+    if len(data) < 12 {
+        return nil, fmt.Errorf("invalid font")
+    }
+
+    font := &ttfFont{tables: make(map[string][]byte), cmap: make(map[rune]uint16)}
+    numTables := int(binary.BigEndian.Uint16(data[4:]))
+    for i := 0; i < numTables; i++ {
+        record := 12 + i*16
+        if record+16 > len(data) {
+            return nil, fmt.Errorf("invalid font")
+        }
+        tag := string(data[record : record+4])
+        offset := binary.BigEndian.Uint32(data[record+8:])
+        length := binary.BigEndian.Uint32(data[record+12:])
+        if uint64(offset)+uint64(length) > uint64(len(data)) {
+            return nil, fmt.Errorf("invalid font table %s", tag)
+        }
+        font.tables[tag] = data[offset : offset+length]
+    }
+
+    for _, tag := range []string{"head", "hhea", "maxp", "hmtx", "loca", "glyf", "cmap"} {
+        if _, ok := font.tables[tag]; !ok {
+            return nil, fmt.Errorf("font table %s is missing", tag)
+        }
+    }
+
+    head := font.tables["head"]
+    hhea := font.tables["hhea"]
+    if len(head) < 54 || len(hhea) < 36 || len(font.tables["maxp"]) < 6 {
+        return nil, fmt.Errorf("invalid font")
+    }
+    font.unitsPerEm = float64(binary.BigEndian.Uint16(head[18:]))
+    for i := range font.bbox {
+        font.bbox[i] = float64(int16(binary.BigEndian.Uint16(head[36+i*2:])))
+    }
+    font.ascent = float64(int16(binary.BigEndian.Uint16(hhea[4:])))
+    font.descent = float64(int16(binary.BigEndian.Uint16(hhea[6:])))
+    font.capHeight = font.ascent
+    if os2 := font.tables["OS/2"]; len(os2) >= 90 && binary.BigEndian.Uint16(os2) >= 2 {
+        font.capHeight = float64(int16(binary.BigEndian.Uint16(os2[88:])))
+    }
+    font.numGlyphs = int(binary.BigEndian.Uint16(font.tables["maxp"][4:]))
+
+    // Ширины глифов: последняя ширина в hmtx повторяется для оставшихся глифов
+    numberOfHMetrics := int(binary.BigEndian.Uint16(hhea[34:]))
+    hmtx := font.tables["hmtx"]
+    font.advances = make([]uint16, font.numGlyphs)
+    var last uint16
+    for gid := 0; gid < font.numGlyphs; gid++ {
+        if gid < numberOfHMetrics && gid*4+2 <= len(hmtx) {
+            last = binary.BigEndian.Uint16(hmtx[gid*4:])
+        }
+        font.advances[gid] = last
+    }
+
+    // loca: короткий формат хранит смещения, деленные на 2
+    loca := font.tables["loca"]
+    longFormat := binary.BigEndian.Uint16(head[50:]) == 1
+    font.glyphOffset = make([]uint32, font.numGlyphs+1)
+    for gid := 0; gid <= font.numGlyphs; gid++ {
+        if longFormat {
+            if gid*4+4 > len(loca) {
+                return nil, fmt.Errorf("invalid font")
+            }
+            font.glyphOffset[gid] = binary.BigEndian.Uint32(loca[gid*4:])
+        } else {
+            if gid*2+2 > len(loca) {
+                return nil, fmt.Errorf("invalid font")
+            }
+            font.glyphOffset[gid] = uint32(binary.BigEndian.Uint16(loca[gid*2:])) * 2
+        }
+    }
+
+    if err := font.parseCmap(); err != nil {
+        return nil, err
+    }
+    return font, nil
+}
+
+// parseCmap читает юникодную таблицу символов: формат 12 (вся плоскость Unicode) или формат 4 (BMP)
+func (f *ttfFont) parseCmap() error {
+    cmap := f.tables["cmap"]
+    if len(cmap) < 4 {
+        return fmt.Errorf("invalid font cmap")
+    }
+
+    var format4, format12 []byte
+    numTables := int(binary.BigEndian.Uint16(cmap[2:]))
+    for i := 0; i < numTables; i++ {
+        record := 4 + i*8
+        if record+8 > len(cmap) {
+            break
+        }
+        platform := binary.BigEndian.Uint16(cmap[record:])
+        encoding := binary.BigEndian.Uint16(cmap[record+2:])
+        offset := int(binary.BigEndian.Uint32(cmap[record+4:]))
+        if offset+4 > len(cmap) || platform == 1 || platform == 3 && encoding != 1 && encoding != 10 {
+            continue
+        }
+        switch binary.BigEndian.Uint16(cmap[offset:]) {
+        case 4:
+            format4 = cmap[offset:]
+        case 12:
+            format12 = cmap[offset:]
+        }
+    }
+
+    switch {
+    case len(format12) >= 16:
+        groups := int(binary.BigEndian.Uint32(format12[12:]))
+        for i := 0; i < groups && 16+i*12+12 <= len(format12); i++ {
+            group := format12[16+i*12:]
+            start := binary.BigEndian.Uint32(group)
+            end := binary.BigEndian.Uint32(group[4:])
+            glyph := binary.BigEndian.Uint32(group[8:])
+            for code := start; code <= end && code <= 0x10FFFF; code++ {
+                if gid := glyph + code - start; gid < uint32(f.numGlyphs) {
+                    f.cmap[rune(code)] = uint16(gid)
+                }
+            }
+        }
+    case len(format4) >= 14:
+        segments := int(binary.BigEndian.Uint16(format4[6:])) / 2
+        if 16+segments*8 > len(format4) {
+            return fmt.Errorf("invalid font cmap")
+        }
+        endCodes := 14
+        startCodes := endCodes + segments*2 + 2
+        deltas := startCodes + segments*2
+        rangeOffsets := deltas + segments*2
+        for i := 0; i < segments; i++ {
+            end := binary.BigEndian.Uint16(format4[endCodes+i*2:])
+            start := binary.BigEndian.Uint16(format4[startCodes+i*2:])
+            delta := binary.BigEndian.Uint16(format4[deltas+i*2:])
+            rangeOffset := int(binary.BigEndian.Uint16(format4[rangeOffsets+i*2:]))
+            for code := uint32(start); code <= uint32(end) && code != 0xFFFF; code++ {
+                var gid uint16
+                if rangeOffset == 0 {
+                    gid = uint16(code) + delta
+                } else {
+                    index := rangeOffsets + i*2 + rangeOffset + int(code-uint32(start))*2
+                    if index+2 > len(format4) {
+                        continue
+                    }
+                    if gid = binary.BigEndian.Uint16(format4[index:]); gid != 0 {
+                        gid += delta
+                    }
+                }
+                if gid != 0 && int(gid) < f.numGlyphs {
+                    f.cmap[rune(code)] = gid
+                }
+            }
+        }
+    default:
+        return fmt.Errorf("font has no unicode cmap")
+    }
+
+    return nil
+}
+
+// width возвращает ширину глифа в тысячных долях кегля
+func (f *ttfFont) width(gid uint16) float64 {
+    if int(gid) >= len(f.advances) {
+        return 0
+    }
+    return float64(f.advances[gid]) * 1000 / f.unitsPerEm
+}
+
+func (f *ttfFont) glyph(r rune) uint16 {
+    return f.cmap[r]
+}
+
+func (f *ttfFont) glyphData(gid uint16) []byte {
+    glyf := f.tables["glyf"]
+    start, end := f.glyphOffset[gid], f.glyphOffset[gid+1]
+    if start >= end || int(end) > len(glyf) {
+        return nil
+    }
+    return glyf[start:end]
+}
+
+// subset собирает шрифт только с использованными глифами. Номера глифов сохраняются,
+// неиспользованные глифы становятся пустыми, поэтому CIDToGIDMap остается Identity
+func (f *ttfFont) subset(used map[uint16]bool) []byte {
+    glyphs := map[uint16]bool{0: true}
+    pending := make([]uint16, 0, len(used))
+    for gid := range used {
+        pending = append(pending, gid)
+    }
+    // Составные глифы ссылаются на другие глифы - добавляем их тоже
+    for len(pending) > 0 {
+        gid := pending[len(pending)-1]
+        pending = pending[:len(pending)-1]
+        if glyphs[gid] || int(gid) >= f.numGlyphs {
+            continue
+        }
+        glyphs[gid] = true
+        pending = append(pending, compositeComponents(f.glyphData(gid))...)
+    }
+
+    var glyf []byte
+    loca := make([]byte, (f.numGlyphs+1)*4)
+    for gid := 0; gid < f.numGlyphs; gid++ {
+        binary.BigEndian.PutUint32(loca[gid*4:], uint32(len(glyf)))
+        if glyphs[uint16(gid)] {
+            glyf = append(glyf, f.glyphData(uint16(gid))...)
+            for len(glyf)%4 != 0 {
+                glyf = append(glyf, 0)
+            }
+        }
+    }
+    binary.BigEndian.PutUint32(loca[f.numGlyphs*4:], uint32(len(glyf)))
+
+    head := append([]byte(nil), f.tables["head"]...)
+    binary.BigEndian.PutUint32(head[8:], 0)
+    binary.BigEndian.PutUint16(head[50:], 1)
+
+    tables := map[string][]byte{
+        "head": head,
+        "hhea": f.tables["hhea"],
+        "maxp": f.tables["maxp"],
+        "hmtx": f.tables["hmtx"],
+        "loca": loca,
+        "glyf": glyf,
+    }
+    for _, tag := range []string{"cvt ", "fpgm", "prep", "OS/2"} {
+        if table, ok := f.tables[tag]; ok {
+            tables[tag] = table
+        }
+    }
+
+    font := writeTTF(tables)
+
+    // Контрольная сумма всего шрифта записывается в head.checkSumAdjustment
+    headOffset := ttfTableOffset(font, "head")
+    binary.BigEndian.PutUint32(font[headOffset+8:], 0xB1B0AFBA-ttfChecksum(font))
+    return font
+}
+
+// compositeComponents возвращает глифы, из которых собран составной глиф
+func compositeComponents(glyph []byte) []uint16 {
+    if len(glyph) < 10 || int16(binary.BigEndian.Uint16(glyph)) >= 0 {
+        return nil
+    }
+
+    var components []uint16
+    offset := 10
+    for offset+4 <= len(glyph) {
+        flags := binary.BigEndian.Uint16(glyph[offset:])
+        components = append(components, binary.BigEndian.Uint16(glyph[offset+2:]))
+        offset += 4
+        if flags&0x0001 != 0 {
+            offset += 4
+        } else {
+            offset += 2
+        }
+        switch {
+        case flags&0x0008 != 0:
+            offset += 2
+        case flags&0x0040 != 0:
+            offset += 4
+        case flags&0x0080 != 0:
+            offset += 8
+        }
+        if flags&0x0020 == 0 {
+            break
+        }
+    }
+    return components
+}
+
+func writeTTF(tables map[string][]byte) []byte {
+    tags := make([]string, 0, len(tables))
+    for tag := range tables {
+        tags = append(tags, tag)
+    }
+    sort.Strings(tags)
+
+    numTables := len(tags)
+    entrySelector := 0
+    for 1<<(entrySelector+1) <= numTables {
+        entrySelector++
+    }
+    searchRange := (1 << entrySelector) * 16
+
+    header := make([]byte, 12+numTables*16)
+    binary.BigEndian.PutUint32(header, 0x00010000)
+    binary.BigEndian.PutUint16(header[4:], uint16(numTables))
+    binary.BigEndian.PutUint16(header[6:], uint16(searchRange))
+    binary.BigEndian.PutUint16(header[8:], uint16(entrySelector))
+    binary.BigEndian.PutUint16(header[10:], uint16(numTables*16-searchRange))
+
+    font := header
+    for i, tag := range tags {
+        table := tables[tag]
+        record := 12 + i*16
+        copy(font[record:], tag)
+        binary.BigEndian.PutUint32(font[record+4:], ttfChecksum(table))
+        binary.BigEndian.PutUint32(font[record+8:], uint32(len(font)))
+        binary.BigEndian.PutUint32(font[record+12:], uint32(len(table)))
+        font = append(font, table...)
+        for len(font)%4 != 0 {
+            font = append(font, 0)
+        }
+    }
+    return font
+}
+
+func ttfTableOffset(font []byte, tag string) int {
+    numTables := int(binary.BigEndian.Uint16(font[4:]))
+    for i := 0; i < numTables; i++ {
+        record := 12 + i*16
+        if string(font[record:record+4]) == tag {
+            return int(binary.BigEndian.Uint32(font[record+8:]))
+        }
+    }
+    return 0
+}
+
+func ttfChecksum(data []byte) uint32 {
+    var sum uint32
+    for i := 0; i < len(data); i += 4 {
+        var word [4]byte
+        copy(word[:], data[i:])
+        sum += binary.BigEndian.Uint32(word[:])
+    }
+    return sum
+}
+
+// pdfFontName превращает имя файла шрифта в имя PDF без пробелов и расширения
+func pdfFontName(fileName string) string {
+    name := []rune{}
+    for _, r := range fileName[:len(fileName)-len(filepath.Ext(fileName))] {
+        if r > 32 && r < 127 && r != '/' && r != '(' && r != ')' && r != '<' && r != '>' && r != '[' && r != ']' && r != '%' {
+            name = append(name, r)
+        }
+    }
+    return string(name)
+}
@@ -0,0 +1,581 @@
+package services
+
+import (
+    "bytes"
+    "context"
+    "encoding/base64"
+    "fmt"
+    "html/template"
+    "io"
+    "log"
+    "net/http"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+
+    "paydeya-backend/internal/models"
+)
+
+// printDocument - материал, подготовленный к печати: шапка, блоки и ключ ответов
+type printDocument struct {
+    Title       string
+    Description string
+    Language    string
+    Subject     string
+    Author      string
+    Link        string
+    Date        string
+    Cover       *printImage
+    Blocks      []printBlock
+    Answers     []printAnswer // только если автор запросил ключ ответов
+}
+
+type printBlock struct {
+    Type     string        // text, image, video, formula, quiz, code
+    HTML     template.HTML // текст, выведенный из документа rich text
+    Lines    []printLine   // тот же текст по строкам для PDF
+    Text     string
+    MathML   template.HTML // формула, отрисованная на сервере; Text - запасной текстовый вид
+    Formula  *formulaBox
+    Image    *printImage
+    URL      string
+    Caption  string
+    Number   int
+    Question string
+    Options  []printOption
+    Multiple bool
+    Language string
+    Examples []printExample // открытые тесты упражнения с кодом
+}
+
+// printExample - пример ввода и вывода программы из открытого теста
+type printExample struct {
+    Number int
+    Input  string
+    Output string
+}
+
+// printLine - абзац текстового блока для PDF: heading, paragraph или code
+type printLine struct {
+    Kind   string
+    Level  int
+    Text   string
+    Indent float64
+}
+
+type printOption struct {
+    Label string
+    Text  string
+}
+
+// printImage - картинка для печати. Загруженные файлы встраиваются в документ,
+// внешние ссылки остаются ссылками
+type printImage struct {
+    Data []byte
+    Type string
+    URL  string
+    Alt  string
+}
+
+type printAnswer struct {
+    Number   int
+    Question string
+    Answers  []printOption
+}
+
+// Картинки больше этого размера в печатную версию не встраиваются
+const maxPrintImageSize = 20 << 20
+
+// RenderPrintable выгружает материал в PDF или HTML для печати и чтения офлайн. Формулы
+// рисуются сервером (в HTML - MathML), тесты выводятся рабочим листом. Ключ ответов (answers) доступен редакторам и автору
+func (s *ExportService) RenderPrintable(ctx context.Context, userID int, material *models.Material, format string, answers bool) ([]byte, error) {
+    if answers {
+        role, err := s.materialService.GetMaterialRole(ctx, userID, material)
+        if err != nil {
+            return nil, err
+        }
+        if materialRoleRank[role] < materialRoleRank["editor"] {
+            return nil, fmt.Errorf("access denied")
+        }
+    }
+
+    var fonts *pdfFontSet
+    if format == "pdf" {
+        s.fontsOnce.Do(func() {
+            s.fonts, s.fontsErr = loadPDFFonts(s.config.FontDir)
+            if s.fontsErr != nil {
+                log.Printf("⚠️ Export: failed to load PDF fonts from %s: %v", s.config.FontDir, s.fontsErr)
+            }
+        })
+        if s.fontsErr != nil {
+            return nil, fmt.Errorf("pdf export unavailable")
+        }
+        fonts = s.fonts
+    }
+
+    doc, err := s.buildPrintDocument(ctx, material, answers)
+    if err != nil {
+        return nil, err
+    }
+
+    var buf bytes.Buffer
+    if format == "pdf" {
+        _, err = renderPrintPDF(fonts, doc).WriteTo(&buf)
+    } else {
+        err = printPageTemplate.Execute(&buf, doc)
+    }
+    if err != nil {
+        return nil, err
+    }
+    return buf.Bytes(), nil
+}
+
+func (s *ExportService) buildPrintDocument(ctx context.Context, material *models.Material, answers bool) (*printDocument, error) {
+    subject, author, err := s.materialRepo.GetMaterialAttribution(ctx, material.ID)
+    if err != nil {
+        return nil, err
+    }
+
+    link := material.ShareURL
+    if link == "" {
+        link = "/material/" + strconv.Itoa(material.ID)
+    }
+
+    doc := &printDocument{
+        Title:       material.Title,
+        Description: material.Description,
+        Language:    material.Language,
+        Subject:     subject,
+        Author:      author,
+        Link:        s.config.FrontendURL + link,
+        Date:        time.Now().Format("02.01.2006"),
+        Blocks:      make([]printBlock, 0, len(material.Blocks)),
+    }
+    if doc.Language == "" {
+        doc.Language = "ru"
+    }
+    if material.CoverURL != "" {
+        doc.Cover = s.printImage(ctx, material.CoverURL, "")
+    }
+
+    blocks := append([]models.Block(nil), material.Blocks...)
+    sort.SliceStable(blocks, func(i, j int) bool { return blocks[i].Position < blocks[j].Position })
+
+    quizNumber := 0
+    for _, block := range blocks {
+        b := printBlock{Type: block.Type}
+
+        switch block.Type {
+        case "text":
+            doc := richTextDocument(block.Content)
+            b.Lines = printRichTextLines(doc.Content, 0)
+            if len(b.Lines) == 0 {
+                continue
+            }
+            b.HTML = renderRichTextHTML(doc, 1)
+        case "image":
+            b.Image = s.printImage(ctx, contentString(block.Content, "url", "src"), contentString(block.Content, "alt"))
+            b.Caption = contentString(block.Content, "caption")
+            if b.Image == nil {
+                continue
+            }
+        case "video":
+            b.URL = contentString(block.Content, "url", "src", "embedUrl")
+            if b.URL != "" && !strings.Contains(b.URL, "://") {
+                b.URL = s.config.FrontendURL + b.URL
+            }
+            b.Caption = contentString(block.Content, "caption", "title")
+        case "formula":
+            latex := contentString(block.Content, "latex", "formula")
+            b.Text = latexPlainText(latex)
+            if root, errs := parseLatex(latex); latex != "" && len(errs) == 0 {
+                b.MathML = template.HTML(formulaMathML(root, latex, true))
+                b.Formula = (&formulaLayout{}).layout(root, 1, true)
+            }
+        case "quiz":
+            quizNumber++
+            b.Number = quizNumber
+            b.Question = printInlineMath(contentString(block.Content, "question"))
+            options, correct := quizOptions(block.Content)
+            for i, option := range options {
+                b.Options = append(b.Options, printOption{Label: printOptionLabel(i), Text: printInlineMath(option)})
+            }
+            b.Multiple = len(correct) > 1
+
+            if answers {
+                answer := printAnswer{Number: b.Number, Question: b.Question}
+                for _, index := range correct {
+                    if index >= 0 && index < len(b.Options) {
+                        answer.Answers = append(answer.Answers, b.Options[index])
+                    }
+                }
+                doc.Answers = append(doc.Answers, answer)
+            }
+        case "code":
+            b.Language, _ = block.Content["language"].(string)
+            b.Question = contentString(block.Content, "instructions")
+            b.Text = contentString(block.Content, "starterCode")
+            tests, _ := codeTests(block.Content)
+            // Упражнение с тестами нумеруется вместе с вопросами; скрытые тесты не печатаются
+            if len(tests) > 0 {
+                quizNumber++
+                b.Number = quizNumber
+            }
+            for _, test := range tests {
+                if !test.Hidden {
+                    b.Examples = append(b.Examples, printExample{
+                        Number: len(b.Examples) + 1,
+                        Input:  strings.TrimRight(test.Input, "\n"),
+                        Output: strings.TrimRight(test.ExpectedOutput, "\n"),
+                    })
+                }
+            }
+            if b.Number == 0 && b.Question == "" && b.Text == "" {
+                continue
+            }
+        default:
+            continue
+        }
+
+        doc.Blocks = append(doc.Blocks, b)
+    }
+
+    return doc, nil
+}
+
+// printImage загружает файл картинки для встраивания. Внешние картинки не скачиваются:
+// экспорт не обращается к сторонним сервисам
+func (s *ExportService) printImage(ctx context.Context, url, alt string) *printImage {
+    if url == "" {
+        return nil
+    }
+    img := &printImage{URL: url, Alt: alt}
+    if !s.fileService.IsManagedMedia(url) {
+        return img
+    }
+
+    file, err := s.fileService.OpenMedia(ctx, url)
+    if err != nil {
+        log.Printf("⚠️ Export: failed to open media %s: %v", url, err)
+        return img
+    }
+    defer file.Close()
+
+    data, err := io.ReadAll(io.LimitReader(file, maxPrintImageSize+1))
+    if err != nil || len(data) > maxPrintImageSize {
+        return img
+    }
+
+    img.Data = data
+    img.Type = http.DetectContentType(data)
+    return img
+}
+
+// DataURL возвращает картинку для HTML: встроенную или исходную ссылку
+func (img *printImage) DataURL() template.URL {
+    if img.Data != nil && strings.HasPrefix(img.Type, "image/") {
+        return template.URL("data:" + img.Type + ";base64," + base64.StdEncoding.EncodeToString(img.Data))
+    }
+    return template.URL(img.URL)
+}
+
+// printInlineMath заменяет формулы $...$ внутри текста их текстовой записью
+func printInlineMath(text string) string {
+    return mdInlineMath.ReplaceAllStringFunc(text, func(math string) string {
+        return latexPlainText(strings.Trim(math, "$"))
+    })
+}
+
+// printRichTextLines раскладывает документ по строкам для PDF: формулы - текстом,
+// пункты списков - с маркером и отступом
+func printRichTextLines(nodes []models.RichTextNode, indent float64) []printLine {
+    var lines []printLine
+    for _, node := range nodes {
+        switch node.Type {
+        case "paragraph", "heading":
+            line := printLine{Kind: node.Type, Text: richTextInlinePlain(node.Content, latexPlainText), Indent: indent}
+            if node.Attrs != nil {
+                line.Level = node.Attrs.Level
+            }
+            lines = append(lines, line)
+        case "code_block":
+            lines = append(lines, printLine{Kind: "code", Text: node.Text, Indent: indent})
+        case "bullet_list", "ordered_list":
+            for i, item := range node.Content {
+                itemLines := printRichTextLines(item.Content, indent+14)
+                if len(itemLines) > 0 && itemLines[0].Kind == "paragraph" {
+                    marker := "• "
+                    if node.Type == "ordered_list" {
+                        marker = strconv.Itoa(richTextListStart(node)+i) + ". "
+                    }
+                    itemLines[0].Text = marker + itemLines[0].Text
+                }
+                lines = append(lines, itemLines...)
+            }
+        }
+    }
+    return lines
+}
+
+// printOptionLabel - буква варианта ответа: А, Б, В...
+func printOptionLabel(index int) string {
+    letters := []rune("АБВГДЕЖЗИКЛМНОПРСТУФХЦЧШЭЮЯ")
+    if index < len(letters) {
+        return string(letters[index])
+    }
+    return strconv.Itoa(index + 1)
+}
+
+// renderPrintPDF раскладывает печатную версию материала по страницам PDF
+func renderPrintPDF(fonts *pdfFontSet, doc *printDocument) *pdfDocument {
+    pdf := newPDFDocument(fonts, doc.Title)
+    pdf.footer = doc.Title
+
+    // Шапка: название, предмет и автор, ссылка на материал
+    pdf.paragraph(pdf.bold, 20, doc.Title, 0, "left", pdfColorText)
+    pdf.space(4)
+    meta := make([]string, 0, 3)
+    for _, item := range []string{doc.Subject, doc.Author, doc.Date} {
+        if item != "" {
+            meta = append(meta, item)
+        }
+    }
+    pdf.paragraph(pdf.regular, 10, strings.Join(meta, " · "), 0, "left", pdfColorMuted)
+    pdf.paragraph(pdf.regular, 10, doc.Link, 0, "left", pdfColorLink)
+    pdf.link(pdfMargin, pdf.regular.textWidth(doc.Link, 10), 10, doc.Link)
+    pdf.space(8)
+    pdf.rule(pdfMargin, pdfPageWidth-pdfMargin)
+    pdf.space(6)
+
+    if doc.Description != "" {
+        pdf.paragraph(pdf.regular, 11, doc.Description, 0, "left", pdfColorMuted)
+        pdf.space(6)
+    }
+    if doc.Cover != nil {
+        renderPrintImagePDF(pdf, doc.Cover, "")
+    }
+
+    for _, block := range doc.Blocks {
+        switch block.Type {
+        case "text":
+            for _, line := range block.Lines {
+                switch line.Kind {
+                case "heading":
+                    size := map[int]float64{1: 17, 2: 15, 3: 13}[line.Level]
+                    if size == 0 {
+                        size = 12
+                    }
+                    pdf.space(size * 0.6)
+                    pdf.ensure(size * 4)
+                    pdf.paragraph(pdf.bold, size, line.Text, line.Indent, "left", pdfColorText)
+                    pdf.space(2)
+                case "code":
+                    pdf.space(4)
+                    pdf.codeBlock(line.Text)
+                    pdf.space(8)
+                default:
+                    pdf.paragraph(pdf.regular, 11, line.Text, line.Indent, "left", pdfColorText)
+                    pdf.space(4)
+                }
+            }
+        case "image":
+            renderPrintImagePDF(pdf, block.Image, block.Caption)
+        case "video":
+            title := "Видео"
+            if block.Caption != "" {
+                title += ": " + block.Caption
+            }
+            pdf.paragraph(pdf.bold, 11, "▶ "+title, 0, "left", pdfColorText)
+            if block.URL != "" {
+                pdf.paragraph(pdf.regular, 10, block.URL, 14, "left", pdfColorLink)
+                pdf.link(pdfMargin+14, pdf.regular.textWidth(block.URL, 10), 10, block.URL)
+            }
+            pdf.space(6)
+        case "formula":
+            pdf.space(8)
+            if block.Formula != nil {
+                pdf.formula(block.Formula, 13)
+            } else {
+                pdf.paragraph(pdf.regular, 13, block.Text, 0, "center", pdfColorText)
+            }
+            pdf.space(8)
+        case "quiz":
+            pdf.space(6)
+            pdf.ensure(60)
+            pdf.paragraph(pdf.bold, 11, fmt.Sprintf("Задание %d. %s", block.Number, block.Question), 0, "left", pdfColorText)
+            if block.Multiple {
+                pdf.paragraph(pdf.regular, 9, "Отметьте все верные варианты", 0, "left", pdfColorMuted)
+            }
+            pdf.space(2)
+            for _, option := range block.Options {
+                lines := pdf.regular.wrap(option.Label+") "+option.Text, 11, pdfTextWidth-40)
+                pdf.ensure(16)
+                for i, line := range lines {
+                    pdf.ensure(16)
+                    pdf.y -= 16
+                    if i == 0 {
+                        pdf.checkbox(pdfMargin+16, 8, !block.Multiple)
+                    }
+                    pdf.textLine(pdf.regular, 11, pdfMargin+30, line, pdfColorText)
+                }
+            }
+            if len(block.Options) == 0 {
+                pdf.space(18)
+                pdf.rule(pdfMargin+16, pdfPageWidth-pdfMargin)
+                pdf.space(18)
+                pdf.rule(pdfMargin+16, pdfPageWidth-pdfMargin)
+            }
+            pdf.space(10)
+        case "code":
+            pdf.space(6)
+            pdf.ensure(60)
+            if block.Number > 0 {
+                pdf.paragraph(pdf.bold, 11, fmt.Sprintf("Задание %d (%s). %s", block.Number, block.Language, block.Question), 0, "left", pdfColorText)
+            } else if block.Question != "" {
+                pdf.paragraph(pdf.regular, 11, block.Question, 0, "left", pdfColorText)
+            }
+            if block.Text != "" {
+                pdf.space(4)
+                pdf.codeBlock(block.Text)
+            }
+            for _, example := range block.Examples {
+                pdf.space(4)
+                pdf.paragraph(pdf.regular, 9, fmt.Sprintf("Пример %d", example.Number), 0, "left", pdfColorMuted)
+                pdf.codeBlock("Ввод:\n" + example.Input + "\nВывод:\n" + example.Output)
+            }
+            pdf.space(8)
+        }
+    }
+
+    if doc.Answers != nil {
+        pdf.pageBreak()
+        pdf.paragraph(pdf.bold, 17, "Ключ ответов", 0, "left", pdfColorText)
+        pdf.paragraph(pdf.regular, 10, doc.Title, 0, "left", pdfColorMuted)
+        pdf.space(8)
+        for _, answer := range doc.Answers {
+            pdf.paragraph(pdf.bold, 11, fmt.Sprintf("%d. %s", answer.Number, answer.Question), 0, "left", pdfColorText)
+            pdf.paragraph(pdf.regular, 11, printAnswerText(answer), 14, "left", pdfColorText)
+            pdf.space(6)
+        }
+    }
+
+    return pdf
+}
+
+func renderPrintImagePDF(pdf *pdfDocument, img *printImage, caption string) {
+    pdf.space(4)
+    embedded := false
+    if img.Data != nil {
+        if image, err := pdf.addImage(img.Data); err == nil {
+            pdf.image(image)
+            embedded = true
+        }
+    }
+    if !embedded {
+        label := "Изображение"
+        if img.Alt != "" {
+            label += ": " + img.Alt
+        }
+        pdf.paragraph(pdf.regular, 10, "["+label+"]", 0, "center", pdfColorMuted)
+        if strings.Contains(img.URL, "://") {
+            pdf.paragraph(pdf.regular, 9, img.URL, 0, "center", pdfColorLink)
+        }
+    }
+    if caption != "" {
+        pdf.paragraph(pdf.regular, 9, caption, 0, "center", pdfColorMuted)
+    }
+    pdf.space(8)
+}
+
+// printAnswerText - правильные варианты задания для ключа ответов
+func printAnswerText(answer printAnswer) string {
+    if len(answer.Answers) == 0 {
+        return "Правильный ответ не задан"
+    }
+    parts := make([]string, len(answer.Answers))
+    for i, option := range answer.Answers {
+        parts[i] = option.Label + ") " + option.Text
+    }
+    return strings.Join(parts, "; ")
+}
+
+var printPageTemplate = template.Must(template.New("print").Funcs(template.FuncMap{
+    "answerText": printAnswerText,
+}).Parse(`<!DOCTYPE html>
+<html lang="{{.Language}}">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+@page { size: A4; margin: 20mm; }
+body { font-family: "DejaVu Sans", -apple-system, "Segoe UI", Roboto, Arial, sans-serif; font-size: 11pt; line-height: 1.5; color: #1f2933; max-width: 780px; margin: 0 auto; padding: 24px; }
+header { border-bottom: 1px solid #d9e2ec; margin-bottom: 16px; padding-bottom: 8px; }
+h1 { font-size: 22pt; margin: 0 0 4px; }
+.meta { color: #7b8794; font-size: 10pt; margin: 2px 0; }
+.meta a { color: #2680c2; }
+.description { color: #52606d; }
+img { max-width: 100%; max-height: 120mm; }
+figure { margin: 16px 0; text-align: center; break-inside: avoid; }
+figcaption, .caption { font-size: 9pt; color: #7b8794; }
+pre { background: #f3f4f6; padding: 8px 12px; border-radius: 4px; font-size: 9.5pt; white-space: pre-wrap; break-inside: avoid; }
+.formula { font-family: "DejaVu Serif", "Cambria Math", serif; font-size: 13pt; text-align: center; margin: 12px 0; }
+.video { margin: 12px 0; }
+.quiz { margin: 16px 0; break-inside: avoid; }
+.quiz ol { list-style: none; padding-left: 16px; }
+.quiz li { margin: 4px 0; }
+.box { display: inline-block; width: 10px; height: 10px; border: 1px solid #52606d; margin-right: 8px; vertical-align: middle; }
+.box.round { border-radius: 50%; }
+.hint { font-size: 9pt; color: #7b8794; }
+.line { border-bottom: 1px solid #9aa5b1; height: 24px; margin-left: 16px; }
+.answers { break-before: page; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Title}}</h1>
+<p class="meta">{{if .Subject}}{{.Subject}}{{end}}{{if .Author}} · {{.Author}}{{end}} · {{.Date}}</p>
+<p class="meta"><a href="{{.Link}}">{{.Link}}</a></p>
+</header>
+{{if .Description}}<p class="description">{{.Description}}</p>{{end}}
+{{with .Cover}}<figure><img src="{{.DataURL}}" alt="{{.Alt}}"></figure>{{end}}
+{{range .Blocks}}
+{{- if eq .Type "text"}}
+{{.HTML}}
+{{- else if eq .Type "image"}}
+<figure><img src="{{.Image.DataURL}}" alt="{{.Image.Alt}}">{{if .Caption}}<figcaption>{{.Caption}}</figcaption>{{end}}</figure>
+{{- else if eq .Type "video"}}
+<p class="video">▶ <strong>Видео{{if .Caption}}: {{.Caption}}{{end}}</strong>{{if .URL}}<br><a href="{{.URL}}">{{.URL}}</a>{{end}}</p>
+{{- else if eq .Type "formula"}}
+{{if .MathML}}<div class="formula">{{.MathML}}</div>{{else}}<p class="formula">{{.Text}}</p>{{end}}
+{{- else if eq .Type "quiz"}}
+<section class="quiz">
+<p><strong>Задание {{.Number}}.</strong> {{.Question}}</p>
+{{if .Multiple}}<p class="hint">Отметьте все верные варианты</p>{{end}}
+{{if .Options}}<ol>
+{{$multiple := .Multiple}}{{range .Options}}<li><span class="box{{if not $multiple}} round{{end}}"></span>{{.Label}}) {{.Text}}</li>
+{{end}}</ol>{{else}}<div class="line"></div><div class="line"></div>{{end}}
+</section>
+{{- else if eq .Type "code"}}
+<section class="quiz">
+{{if .Number}}<p><strong>Задание {{.Number}}.</strong> {{.Question}} <span class="hint">{{.Language}}</span></p>{{else if .Question}}<p>{{.Question}}</p>{{end}}
+{{if .Text}}<pre><code>{{.Text}}</code></pre>{{end}}
+{{range .Examples}}<p class="hint">Пример {{.Number}}</p>
+<pre>Ввод:
+{{.Input}}
+Вывод:
+{{.Output}}</pre>
+{{end}}</section>
+{{- end}}
+{{end}}
+{{if .Answers}}
+<section class="answers">
+<h2>Ключ ответов</h2>
+{{range .Answers}}<p><strong>{{.Number}}. {{.Question}}</strong><br>{{answerText .}}</p>
+{{end}}
+</section>
+{{end}}
+</body>
+</html>
+`))
@@ -2,17 +2,29 @@ package services
 
 import (
     "context"
+    "fmt"
+    "math"
 
     "paydeya-backend/internal/models"
     "paydeya-backend/internal/repositories"
 )
 
 type ProgressService struct {
-    progressRepo *repositories.ProgressRepository
+    progressRepo    *repositories.ProgressRepository
+    blockRepo       *repositories.BlockRepository
+    materialService *MaterialService
+    xapiService     *XAPIService
+    ltiService      *LTIService
 }
 
-func NewProgressService(progressRepo *repositories.ProgressRepository) *ProgressService {
-    return &ProgressService{progressRepo: progressRepo}
+func NewProgressService(progressRepo *repositories.ProgressRepository, blockRepo *repositories.BlockRepository, materialService *MaterialService, xapiService *XAPIService, ltiService *LTIService) *ProgressService {
+    return &ProgressService{
+        progressRepo:    progressRepo,
+        blockRepo:       blockRepo,
+        materialService: materialService,
+        xapiService:     xapiService,
+        ltiService:      ltiService,
+    }
 }
 
 // GetStudentProgress возвращает прогресс ученика
@@ -20,9 +32,155 @@ func (s *ProgressService) GetStudentProgress(ctx context.Context, userID int) (*
     return s.progressRepo.GetStudentProgress(ctx, userID)
 }
 
-// MarkMaterialComplete отмечает материал как завершенный
-func (s *ProgressService) MarkMaterialComplete(ctx context.Context, userID, materialID int, timeSpent int, grade float64) error {
-    return s.progressRepo.MarkMaterialComplete(ctx, userID, materialID, timeSpent, grade)
+// MarkMaterialComplete отмечает материал как завершенный. Материал с упражнениями по коду
+// можно завершить только после того, как решения прошли тесты всех упражнений.
+// Ответы на вопросы проверяются по правильным вариантам quiz-блоков материала
+func (s *ProgressService) MarkMaterialComplete(ctx context.Context, userID, materialID int, timeSpent int, answers []models.QuizAnswer) error {
+    // Завершить можно только материал, который пользователь может открыть
+    if err := s.checkMaterialVisible(ctx, userID, materialID); err != nil {
+        return err
+    }
+
+    unpassed, err := s.progressRepo.CountUnpassedExercises(ctx, userID, materialID)
+    if err != nil {
+        return err
+    }
+    if unpassed > 0 {
+        return fmt.Errorf("exercises not passed")
+    }
+
+    blocks, err := s.blockRepo.GetBlocks(ctx, materialID)
+    if err != nil {
+        return err
+    }
+    results := gradeQuizAnswers(blocks, answers)
+    grade := materialGrade(blocks, results)
+
+    if err := s.progressRepo.MarkMaterialComplete(ctx, userID, materialID, timeSpent, grade, results); err != nil {
+        return err
+    }
+
+    s.xapiService.QuestionsAnswered(ctx, userID, materialID, results)
+    s.xapiService.MaterialCompleted(ctx, userID, materialID, timeSpent, grade)
+    s.ltiService.PassbackGrade(userID, materialID)
+    return nil
+}
+
+// RecordBlockProgress сохраняет просмотренные блоки материала, время на них и позицию для продолжения
+func (s *ProgressService) RecordBlockProgress(ctx context.Context, userID, materialID int, lastBlockID string, blocks []models.BlockTime) (*models.MaterialProgress, error) {
+    if err := s.checkMaterialVisible(ctx, userID, materialID); err != nil {
+        return nil, err
+    }
+
+    recorded, err := s.progressRepo.RecordBlockProgress(ctx, userID, materialID, lastBlockID, blocks)
+    if err != nil {
+        return nil, err
+    }
+    if !recorded {
+        return nil, fmt.Errorf("block not found")
+    }
+
+    if len(blocks) == 0 {
+        blocks = []models.BlockTime{{BlockID: lastBlockID}}
+    }
+    s.xapiService.MaterialExperienced(ctx, userID, materialID, blocks)
+
+    return s.progressRepo.GetMaterialProgress(ctx, userID, materialID)
+}
+
+// GetMaterialProgress возвращает прогресс ученика внутри материала и позицию для продолжения
+func (s *ProgressService) GetMaterialProgress(ctx context.Context, userID, materialID int) (*models.MaterialProgress, error) {
+    if err := s.checkMaterialVisible(ctx, userID, materialID); err != nil {
+        return nil, err
+    }
+
+    return s.progressRepo.GetMaterialProgress(ctx, userID, materialID)
+}
+
+// checkMaterialVisible проверяет, что пользователь может открыть материал
+func (s *ProgressService) checkMaterialVisible(ctx context.Context, userID, materialID int) error {
+    material, err := s.materialService.GetMaterial(ctx, userID, materialID)
+    if err != nil {
+        return err
+    }
+    if material == nil {
+        return fmt.Errorf("material not found")
+    }
+    return nil
+}
+
+// gradeQuizAnswers проверяет ответы по правильным вариантам quiz-блоков: ответ верный, если выбраны
+// ровно правильные варианты. Ответы на другие блоки и на вопросы без правильных вариантов пропускаются
+func gradeQuizAnswers(blocks []models.Block, answers []models.QuizAnswer) []models.QuizResult {
+    correctByBlock := make(map[string][]int)
+    for _, block := range blocks {
+        if block.Type != "quiz" {
+            continue
+        }
+        if _, correct := quizOptions(block.Content); len(correct) > 0 {
+            correctByBlock[block.ID] = correct
+        }
+    }
+
+    results := make([]models.QuizResult, 0, len(answers))
+    for _, answer := range answers {
+        correct, ok := correctByBlock[answer.BlockID]
+        if !ok {
+            continue
+        }
+
+        selected := make(map[int]bool, len(answer.Selected))
+        for _, index := range answer.Selected {
+            selected[index] = true
+        }
+        expected := make(map[int]bool, len(correct))
+        for _, index := range correct {
+            expected[index] = true
+        }
+
+        matches := len(selected) == len(expected)
+        for index := range expected {
+            matches = matches && selected[index]
+        }
+        results = append(results, models.QuizResult{BlockID: answer.BlockID, Correct: matches})
+    }
+    return results
+}
+
+// materialGrade вычисляет оценку за материал по шкале 1-5 из доли решенных заданий:
+// quiz-блоков с правильными вариантами и упражнений с кодом (к моменту завершения они все решены).
+// Неотвеченный вопрос считается неверным. Без проверяемых заданий оценки нет (nil)
+func materialGrade(blocks []models.Block, results []models.QuizResult) *float64 {
+    correct := make(map[string]bool, len(results))
+    for _, result := range results {
+        correct[result.BlockID] = correct[result.BlockID] || result.Correct
+    }
+
+    total, passed := 0, 0
+    for _, block := range blocks {
+        switch block.Type {
+        case "quiz":
+            if _, options := quizOptions(block.Content); len(options) == 0 {
+                continue
+            }
+            total++
+            if correct[block.ID] {
+                passed++
+            }
+        case "code":
+            if tests, err := codeTests(block.Content); err != nil || len(tests) == 0 {
+                continue
+            }
+            total++
+            passed++
+        }
+    }
+    if total == 0 {
+        return nil
+    }
+
+    grade := math.Round((1+4*float64(passed)/float64(total))*100) / 100
+    return &grade
 }
 
 // GetFavoriteMaterials возвращает избранные материалы
@@ -32,5 +190,12 @@ func (s *ProgressService) GetFavoriteMaterials(ctx context.Context, userID int)
 
 // ToggleFavorite добавляет/удаляет материал из избранного
 func (s *ProgressService) ToggleFavorite(ctx context.Context, userID, materialID int, action string) error {
-    return s.progressRepo.ToggleFavorite(ctx, userID, materialID, action)
+    if err := s.progressRepo.ToggleFavorite(ctx, userID, materialID, action); err != nil {
+        return err
+    }
+
+    if action == "add" {
+        s.xapiService.MaterialFavorited(ctx, userID, materialID)
+    }
+    return nil
 }
\ No newline at end of file
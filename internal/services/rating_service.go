@@ -0,0 +1,117 @@
+package services
+
+import (
+    "context"
+    "fmt"
+    "strings"
+    "time"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/repositories"
+)
+
+type RatingService struct {
+    ratingRepo          *repositories.RatingRepository
+    materialRepo        *repositories.MaterialRepository
+    materialService     *MaterialService
+    notificationService *NotificationService
+}
+
+func NewRatingService(
+    ratingRepo *repositories.RatingRepository,
+    materialRepo *repositories.MaterialRepository,
+    materialService *MaterialService,
+    notificationService *NotificationService,
+) *RatingService {
+    return &RatingService{
+        ratingRepo:          ratingRepo,
+        materialRepo:        materialRepo,
+        materialService:     materialService,
+        notificationService: notificationService,
+    }
+}
+
+// RateMaterial сохраняет оценку и отзыв ученика. Оценить можно только завершенный материал
+func (s *RatingService) RateMaterial(ctx context.Context, userID, materialID int, req *models.RateMaterialRequest) (*models.MaterialRating, *models.RatingSummary, error) {
+    material, err := s.materialRepo.GetMaterial(ctx, materialID)
+    if err != nil || material == nil {
+        return nil, nil, fmt.Errorf("material not found")
+    }
+    if material.AuthorID == userID {
+        return nil, nil, fmt.Errorf("cannot rate own material")
+    }
+
+    completed, err := s.materialRepo.HasCompleted(ctx, userID, materialID)
+    if err != nil {
+        return nil, nil, err
+    }
+    if !completed {
+        return nil, nil, fmt.Errorf("material is not completed")
+    }
+
+    rating := &models.MaterialRating{
+        MaterialID: materialID,
+        UserID:     userID,
+        Rating:     req.Rating,
+        Review:     strings.TrimSpace(req.Review),
+    }
+    if err := s.ratingRepo.SaveRating(ctx, rating); err != nil {
+        return nil, nil, fmt.Errorf("failed to save rating: %w", err)
+    }
+
+    summary, err := s.ratingRepo.GetSummary(ctx, materialID)
+    if err != nil {
+        return nil, nil, err
+    }
+    return rating, summary, nil
+}
+
+// GetReviews возвращает отзывы об опубликованном материале и сводку оценок
+func (s *RatingService) GetReviews(ctx context.Context, materialID int, filters models.ReviewFilters) ([]models.MaterialRating, int, *models.RatingSummary, error) {
+    material, err := s.materialRepo.GetMaterial(ctx, materialID)
+    if err != nil || material == nil || !isMaterialLive(material, time.Now()) {
+        return nil, 0, nil, fmt.Errorf("material not found")
+    }
+
+    reviews, total, err := s.ratingRepo.GetReviews(ctx, materialID, filters)
+    if err != nil {
+        return nil, 0, nil, err
+    }
+    summary, err := s.ratingRepo.GetSummary(ctx, materialID)
+    if err != nil {
+        return nil, 0, nil, err
+    }
+    return reviews, total, summary, nil
+}
+
+// RespondToReview сохраняет ответ владельца материала на отзыв; ученик получает уведомление
+func (s *RatingService) RespondToReview(ctx context.Context, userID, materialID, ratingID int, req *models.AuthorResponseRequest) (*models.MaterialRating, error) {
+    material, _, err := s.materialService.CheckAccess(ctx, userID, materialID, "owner")
+    if err != nil {
+        return nil, err
+    }
+
+    rating, err := s.ratingRepo.GetRating(ctx, ratingID)
+    if err != nil {
+        return nil, err
+    }
+    if rating == nil || rating.MaterialID != materialID {
+        return nil, fmt.Errorf("rating not found")
+    }
+    if rating.Review == "" {
+        return nil, fmt.Errorf("rating has no review")
+    }
+
+    response := strings.TrimSpace(req.Response)
+    if response == "" {
+        return nil, fmt.Errorf("response is required")
+    }
+    if err := s.ratingRepo.SetAuthorResponse(ctx, ratingID, response); err != nil {
+        return nil, fmt.Errorf("failed to save response: %w", err)
+    }
+
+    s.notificationService.Notify(ctx, rating.UserID, "review_response",
+        fmt.Sprintf("Автор ответил на ваш отзыв о материале «%s»", material.Title), &materialID)
+
+    return s.ratingRepo.GetRating(ctx, ratingID)
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+    "context"
+    "fmt"
+    "strings"
+    "time"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/repositories"
+)
+
+type ReportService struct {
+    reportRepo          *repositories.ReportRepository
+    materialService     *MaterialService
+    adminService        *AdminService
+    notificationService *NotificationService
+    hourlyLimit         int // жалоб от одного пользователя в час
+}
+
+func NewReportService(
+    reportRepo *repositories.ReportRepository,
+    materialService *MaterialService,
+    adminService *AdminService,
+    notificationService *NotificationService,
+    hourlyLimit int,
+) *ReportService {
+    return &ReportService{
+        reportRepo:          reportRepo,
+        materialService:     materialService,
+        adminService:        adminService,
+        notificationService: notificationService,
+        hourlyLimit:         hourlyLimit,
+    }
+}
+
+// CreateReport сохраняет жалобу на материал или его блок. Пожаловаться можно на любой
+// материал, доступный пользователю, кроме своего
+func (s *ReportService) CreateReport(ctx context.Context, userID, materialID int, req *models.CreateReportRequest) (*models.MaterialReport, error) {
+    material, err := s.materialService.GetMaterial(ctx, userID, materialID)
+    if err != nil {
+        return nil, err
+    }
+    if material == nil {
+        return nil, fmt.Errorf("material not found")
+    }
+    if material.AuthorID == userID {
+        return nil, fmt.Errorf("cannot report own material")
+    }
+
+    if req.BlockID != nil && *req.BlockID == "" {
+        req.BlockID = nil
+    }
+    if req.BlockID != nil && !hasBlock(material.Blocks, *req.BlockID) {
+        return nil, fmt.Errorf("block not found")
+    }
+
+    // Ограничение частоты считается по базе, поэтому действует на всех инстансах
+    if s.hourlyLimit > 0 {
+        count, err := s.reportRepo.CountReportsSince(ctx, userID, time.Now().Add(-time.Hour))
+        if err != nil {
+            return nil, err
+        }
+        if count >= s.hourlyLimit {
+            return nil, fmt.Errorf("too many reports")
+        }
+    }
+
+    report := &models.MaterialReport{
+        MaterialID:    materialID,
+        MaterialTitle: material.Title,
+        AuthorID:      material.AuthorID,
+        BlockID:       req.BlockID,
+        ReporterID:    userID,
+        Category:      req.Category,
+        Comment:       strings.TrimSpace(req.Comment),
+    }
+    created, err := s.reportRepo.CreateReport(ctx, report)
+    if err != nil {
+        return nil, fmt.Errorf("failed to create report: %w", err)
+    }
+    if !created {
+        return nil, fmt.Errorf("report already exists")
+    }
+
+    return report, nil
+}
+
+// GetReports возвращает очередь жалоб, по умолчанию - открытые
+func (s *ReportService) GetReports(ctx context.Context, filters models.ReportFilters) ([]models.MaterialReport, int, error) {
+    if filters.Status == "" {
+        filters.Status = "open"
+    }
+    return s.reportRepo.GetReports(ctx, filters)
+}
+
+// GetReport возвращает жалобу
+func (s *ReportService) GetReport(ctx context.Context, reportID int) (*models.MaterialReport, error) {
+    report, err := s.reportRepo.GetReport(ctx, reportID)
+    if err != nil || report == nil {
+        return nil, fmt.Errorf("report not found")
+    }
+    return report, nil
+}
+
+// ResolveReport выполняет действие модератора. Снятие с публикации и блокировка автора
+// закрывают все открытые жалобы на материал, отклонение и предупреждение - только эту.
+// Авторы закрытых жалоб получают уведомление о решении
+func (s *ReportService) ResolveReport(ctx context.Context, moderatorID, reportID int, req *models.ResolveReportRequest) (*models.MaterialReport, error) {
+    report, err := s.GetReport(ctx, reportID)
+    if err != nil {
+        return nil, err
+    }
+    if report.Status != "open" {
+        return nil, fmt.Errorf("report is not open")
+    }
+    req.Comment = strings.TrimSpace(req.Comment)
+    if req.Action == "warn_author" && req.Comment == "" {
+        return nil, fmt.Errorf("comment is required")
+    }
+
+    materialID := report.MaterialID
+    switch req.Action {
+    case "unpublish":
+        if _, err := s.materialService.UnpublishMaterial(ctx, materialID); err != nil {
+            return nil, err
+        }
+        s.notificationService.Notify(ctx, report.AuthorID, "material_unpublished",
+            withComment(fmt.Sprintf("Материал «%s» снят с публикации по жалобе", report.MaterialTitle), req.Comment), &materialID)
+    case "warn_author":
+        s.notificationService.Notify(ctx, report.AuthorID, "author_warning",
+            withComment(fmt.Sprintf("Предупреждение по материалу «%s»", report.MaterialTitle), req.Comment), &materialID)
+    case "block_author":
+        reason := req.Comment
+        if reason == "" {
+            reason = fmt.Sprintf("Жалоба на материал «%s»", report.MaterialTitle)
+        }
+        if err := s.adminService.BlockUser(ctx, report.AuthorID, reason); err != nil {
+            return nil, fmt.Errorf("failed to block author: %w", err)
+        }
+    }
+
+    status := "resolved"
+    if req.Action == "dismiss" {
+        status = "dismissed"
+    }
+    allForMaterial := req.Action == "unpublish" || req.Action == "block_author"
+    reporters, err := s.reportRepo.ResolveReports(ctx, reportID, moderatorID, status, req, allForMaterial)
+    if err != nil {
+        return nil, fmt.Errorf("failed to resolve report: %w", err)
+    }
+
+    message := fmt.Sprintf("Ваша жалоба на материал «%s» рассмотрена, приняты меры", report.MaterialTitle)
+    if status == "dismissed" {
+        message = fmt.Sprintf("Ваша жалоба на материал «%s» рассмотрена, нарушений не найдено", report.MaterialTitle)
+    }
+    for _, reporterID := range reporters {
+        s.notificationService.Notify(ctx, reporterID, "report_resolved", message, &materialID)
+    }
+
+    return s.GetReport(ctx, reportID)
+}
+
+func hasBlock(blocks []models.Block, blockID string) bool {
+    for _, block := range blocks {
+        if block.ID == blockID {
+            return true
+        }
+    }
+    return false
+}
+
+func withComment(message, comment string) string {
+    if comment == "" {
+        return message
+    }
+    return message + ": " + comment
+}
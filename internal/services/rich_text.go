@@ -0,0 +1,714 @@
+package services
+
+import (
+    "encoding/json"
+    "fmt"
+    "html"
+    "html/template"
+    "net/url"
+    "regexp"
+    "sort"
+    "strconv"
+    "strings"
+    "unicode"
+
+    "paydeya-backend/internal/models"
+
+    nethtml "golang.org/x/net/html"
+    "golang.org/x/net/html/atom"
+)
+
+// Текстовые блоки хранятся как документ rich text в content.doc и его текстовая запись
+// в content.text (для старых клиентов и поиска). Все остальные поля содержимого отбрасываются.
+// Старый формат {text, level, language} и HTML переводятся в документ при сохранении
+
+const (
+    maxRichTextDepth   = 8
+    maxRichTextHrefLen = 2048
+)
+
+var (
+    richTextMarkOrder = map[string]int{"link": 0, "bold": 1, "italic": 2, "underline": 3, "strike": 4,
+        "code": 5, "subscript": 6, "superscript": 7}
+    richTextLinkSchemes = map[string]bool{"http": true, "https": true, "mailto": true, "tel": true}
+    richTextLanguage    = regexp.MustCompile(`^[a-z0-9+#_-]{1,30}$`)
+    looksLikeHTML       = regexp.MustCompile(`(?i)</?(p|div|br|b|strong|i|em|u|s|a|ul|ol|li|h[1-6]|span|code|pre|sub|sup|table|script|style|img|iframe|svg)(\s[^<>]*)?/?>|<!--`)
+)
+
+// normalizeTextBlock приводит содержимое текстового блока к каноническому документу
+func normalizeTextBlock(block *models.Block) error {
+    if block == nil || block.Type != "text" {
+        return nil
+    }
+
+    var doc *models.RichTextNode
+    if raw, ok := block.Content["doc"]; ok && raw != nil {
+        parsed, err := parseRichText(raw)
+        if err != nil || parsed.Type != "doc" {
+            return fmt.Errorf("invalid rich text")
+        }
+        doc = sanitizeRichText(parsed)
+    } else {
+        doc = legacyRichText(block.Content)
+    }
+
+    block.Content = map[string]interface{}{"doc": doc, "text": richTextPlain(doc)}
+    return nil
+}
+
+// richTextDocument возвращает документ текстового блока для вывода. Блоки, сохраненные
+// до появления документа, переводятся на лету; сохраненный документ очищается повторно
+func richTextDocument(content map[string]interface{}) *models.RichTextNode {
+    if raw, ok := content["doc"]; ok && raw != nil {
+        if doc, err := parseRichText(raw); err == nil && doc.Type == "doc" {
+            return sanitizeRichText(doc)
+        }
+    }
+    return legacyRichText(content)
+}
+
+// parseRichText читает документ из содержимого блока: структуры после сохранения или JSON из базы и запросов
+func parseRichText(raw interface{}) (*models.RichTextNode, error) {
+    if doc, ok := raw.(*models.RichTextNode); ok {
+        return doc, nil
+    }
+    data, err := json.Marshal(raw)
+    if err != nil {
+        return nil, err
+    }
+    var doc models.RichTextNode
+    if err := json.Unmarshal(data, &doc); err != nil {
+        return nil, err
+    }
+    return &doc, nil
+}
+
+// legacyRichText переводит старое содержимое {text, level, language} или {html} в документ
+func legacyRichText(content map[string]interface{}) *models.RichTextNode {
+    if source := contentString(content, "html"); source != "" {
+        return richTextFromHTML(source)
+    }
+    text := contentString(content, "text")
+    if looksLikeHTML.MatchString(text) {
+        return richTextFromHTML(text)
+    }
+
+    level, _ := content["level"].(string)
+    doc := &models.RichTextNode{Type: "doc"}
+    switch {
+    case level == "code":
+        language, _ := content["language"].(string)
+        doc.Content = append(doc.Content, models.RichTextNode{Type: "code_block", Text: text})
+        if language = strings.ToLower(strings.TrimSpace(language)); language != "" {
+            doc.Content[0].Attrs = &models.RichTextAttrs{Language: language}
+        }
+    case len(level) == 2 && level[0] == 'h' && level[1] >= '1' && level[1] <= '6':
+        var inline []models.RichTextNode
+        for i, line := range strings.Split(strings.TrimSpace(text), "\n") {
+            if i > 0 {
+                inline = append(inline, models.RichTextNode{Type: "hard_break"})
+            }
+            inline = append(inline, splitInlineMath(line, nil)...)
+        }
+        doc.Content = append(doc.Content, models.RichTextNode{Type: "heading",
+            Attrs: &models.RichTextAttrs{Level: int(level[1] - '0')}, Content: inline})
+    default:
+        for _, line := range strings.Split(text, "\n") {
+            if line = strings.TrimSpace(line); line != "" {
+                doc.Content = append(doc.Content, models.RichTextNode{Type: "paragraph", Content: splitInlineMath(line, nil)})
+            }
+        }
+    }
+    return sanitizeRichText(doc)
+}
+
+// splitInlineMath разбивает текст на куски текста и формулы $...$
+func splitInlineMath(text string, marks []models.RichTextMark) []models.RichTextNode {
+    var nodes []models.RichTextNode
+    last := 0
+    for _, match := range mdInlineMath.FindAllStringIndex(text, -1) {
+        if match[0] > last {
+            nodes = append(nodes, models.RichTextNode{Type: "text", Text: text[last:match[0]], Marks: marks})
+        }
+        nodes = append(nodes, models.RichTextNode{Type: "math", Attrs: &models.RichTextAttrs{Latex: text[match[0]+1 : match[1]-1]}})
+        last = match[1]
+    }
+    if last < len(text) {
+        nodes = append(nodes, models.RichTextNode{Type: "text", Text: text[last:], Marks: marks})
+    }
+    return nodes
+}
+
+// sanitizeRichText строит чистый документ: только известные узлы, оформление и атрибуты,
+// правильная вложенность, ссылки только с разрешенными схемами
+func sanitizeRichText(doc *models.RichTextNode) *models.RichTextNode {
+    return &models.RichTextNode{Type: "doc", Content: sanitizeRichTextBlocks(doc.Content, 0)}
+}
+
+func sanitizeRichTextBlocks(nodes []models.RichTextNode, depth int) []models.RichTextNode {
+    var blocks, inline []models.RichTextNode
+    flush := func() {
+        if content := sanitizeRichTextInline(inline, true); len(content) > 0 {
+            blocks = append(blocks, models.RichTextNode{Type: "paragraph", Content: content})
+        }
+        inline = nil
+    }
+
+    for _, node := range nodes {
+        switch node.Type {
+        case "text", "math", "hard_break":
+            // Строчные узлы вне абзаца собираются в абзац
+            inline = append(inline, node)
+            continue
+        }
+        flush()
+
+        switch node.Type {
+        case "paragraph":
+            if content := sanitizeRichTextInline(node.Content, true); len(content) > 0 {
+                blocks = append(blocks, models.RichTextNode{Type: "paragraph", Content: content})
+            }
+        case "heading":
+            level := 2
+            if node.Attrs != nil && node.Attrs.Level >= 1 && node.Attrs.Level <= 6 {
+                level = node.Attrs.Level
+            }
+            if content := sanitizeRichTextInline(node.Content, true); len(content) > 0 {
+                blocks = append(blocks, models.RichTextNode{Type: "heading", Attrs: &models.RichTextAttrs{Level: level}, Content: content})
+            }
+        case "bullet_list", "ordered_list":
+            if depth >= maxRichTextDepth {
+                blocks = append(blocks, sanitizeRichTextBlocks(flattenListItems(node.Content), depth)...)
+                continue
+            }
+            items := sanitizeListItems(node.Content, depth+1)
+            if len(items) == 0 {
+                continue
+            }
+            list := models.RichTextNode{Type: node.Type, Content: items}
+            if node.Type == "ordered_list" && node.Attrs != nil && node.Attrs.Start > 1 {
+                list.Attrs = &models.RichTextAttrs{Start: node.Attrs.Start}
+            }
+            blocks = append(blocks, list)
+        case "code_block":
+            code := models.RichTextNode{Type: "code_block", Text: richTextRawText(node)}
+            if node.Attrs != nil {
+                if language := strings.ToLower(strings.TrimSpace(node.Attrs.Language)); richTextLanguage.MatchString(language) {
+                    code.Attrs = &models.RichTextAttrs{Language: language}
+                }
+            }
+            blocks = append(blocks, code)
+        default:
+            // Неизвестные контейнеры и list_item вне списка раскрываются, текст сохраняется
+            if depth < maxRichTextDepth {
+                blocks = append(blocks, sanitizeRichTextBlocks(node.Content, depth+1)...)
+            }
+        }
+    }
+    flush()
+    return blocks
+}
+
+// sanitizeListItems оставляет в списке только пункты; в пункте - абзацы, код и вложенные списки
+func sanitizeListItems(nodes []models.RichTextNode, depth int) []models.RichTextNode {
+    var items []models.RichTextNode
+    for _, node := range nodes {
+        content := node.Content
+        if node.Type != "list_item" {
+            content = []models.RichTextNode{node}
+        }
+        blocks := sanitizeRichTextBlocks(content, depth)
+        for i := range blocks {
+            if blocks[i].Type == "heading" {
+                blocks[i] = models.RichTextNode{Type: "paragraph", Content: blocks[i].Content}
+            }
+        }
+        if len(blocks) > 0 {
+            items = append(items, models.RichTextNode{Type: "list_item", Content: blocks})
+        }
+    }
+    return items
+}
+
+// flattenListItems превращает слишком глубоко вложенный список в последовательность блоков
+func flattenListItems(nodes []models.RichTextNode) []models.RichTextNode {
+    var blocks []models.RichTextNode
+    for _, node := range nodes {
+        if node.Type == "list_item" {
+            blocks = append(blocks, node.Content...)
+        } else {
+            blocks = append(blocks, node)
+        }
+    }
+    return blocks
+}
+
+// sanitizeRichTextInline чистит строчные узлы: объединяет соседний текст с одинаковым оформлением,
+// убирает пробелы по краям абзаца (trim) и раскрывает блочные узлы, попавшие внутрь строки
+func sanitizeRichTextInline(nodes []models.RichTextNode, trim bool) []models.RichTextNode {
+    var result []models.RichTextNode
+    var walk func(nodes []models.RichTextNode, depth int)
+    walk = func(nodes []models.RichTextNode, depth int) {
+        for _, node := range nodes {
+            switch node.Type {
+            case "text":
+                if node.Text == "" {
+                    continue
+                }
+                marks := sanitizeRichTextMarks(node.Marks)
+                if n := len(result); n > 0 && result[n-1].Type == "text" && sameRichTextMarks(result[n-1].Marks, marks) {
+                    result[n-1].Text += node.Text
+                    continue
+                }
+                result = append(result, models.RichTextNode{Type: "text", Text: node.Text, Marks: marks})
+            case "math":
+                if node.Attrs == nil || strings.TrimSpace(node.Attrs.Latex) == "" {
+                    continue
+                }
+                result = append(result, models.RichTextNode{Type: "math", Attrs: &models.RichTextAttrs{Latex: strings.TrimSpace(node.Attrs.Latex)}})
+            case "hard_break":
+                result = append(result, models.RichTextNode{Type: "hard_break"})
+            default:
+                if depth < maxRichTextDepth {
+                    walk(node.Content, depth+1)
+                }
+            }
+        }
+    }
+    walk(nodes, 0)
+
+    if trim {
+        for len(result) > 0 && result[0].Type == "hard_break" {
+            result = result[1:]
+        }
+        for len(result) > 0 && result[len(result)-1].Type == "hard_break" {
+            result = result[:len(result)-1]
+        }
+        if len(result) > 0 && result[0].Type == "text" {
+            result[0].Text = strings.TrimLeftFunc(result[0].Text, unicode.IsSpace)
+        }
+        if n := len(result); n > 0 && result[n-1].Type == "text" {
+            result[n-1].Text = strings.TrimRightFunc(result[n-1].Text, unicode.IsSpace)
+        }
+        cleaned := result[:0]
+        for _, node := range result {
+            if node.Type != "text" || node.Text != "" {
+                cleaned = append(cleaned, node)
+            }
+        }
+        result = cleaned
+    }
+    return result
+}
+
+// sanitizeRichTextMarks оставляет известное оформление по одному разу в постоянном порядке
+func sanitizeRichTextMarks(marks []models.RichTextMark) []models.RichTextMark {
+    var result []models.RichTextMark
+    seen := map[string]bool{}
+    for _, mark := range marks {
+        if _, ok := richTextMarkOrder[mark.Type]; !ok || seen[mark.Type] {
+            continue
+        }
+        clean := models.RichTextMark{Type: mark.Type}
+        if mark.Type == "link" {
+            if mark.Attrs == nil {
+                continue
+            }
+            href, ok := safeLinkHref(mark.Attrs.Href)
+            if !ok {
+                continue
+            }
+            clean.Attrs = &models.RichTextAttrs{Href: href}
+        }
+        seen[mark.Type] = true
+        result = append(result, clean)
+    }
+    sort.Slice(result, func(i, j int) bool { return richTextMarkOrder[result[i].Type] < richTextMarkOrder[result[j].Type] })
+    return result
+}
+
+func sameRichTextMarks(a, b []models.RichTextMark) bool {
+    if len(a) != len(b) {
+        return false
+    }
+    for i := range a {
+        if a[i].Type != b[i].Type || a[i].Type == "link" && a[i].Attrs.Href != b[i].Attrs.Href {
+            return false
+        }
+    }
+    return true
+}
+
+// safeLinkHref пропускает ссылки http, https, mailto, tel и относительные пути на сайте.
+// javascript:, data: и прочие схемы отбрасываются
+func safeLinkHref(href string) (string, bool) {
+    href = strings.TrimSpace(href)
+    if href == "" || len(href) > maxRichTextHrefLen || strings.HasPrefix(href, "//") {
+        return "", false
+    }
+    parsed, err := url.Parse(href)
+    if err != nil {
+        return "", false
+    }
+    if parsed.Scheme == "" {
+        // Относительная ссылка не должна содержать ":" до первого "/", иначе браузер может принять начало за схему
+        if colon := strings.IndexByte(href, ':'); colon >= 0 && !strings.ContainsAny(href[:colon], "/?#") {
+            return "", false
+        }
+        return href, true
+    }
+    if !richTextLinkSchemes[strings.ToLower(parsed.Scheme)] {
+        return "", false
+    }
+    if (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host == "" {
+        return "", false
+    }
+    return parsed.String(), true
+}
+
+// richTextRawText - весь текст узла без оформления (для блока кода)
+func richTextRawText(node models.RichTextNode) string {
+    if node.Type == "text" || node.Type == "code_block" && len(node.Content) == 0 {
+        return node.Text
+    }
+    if node.Type == "hard_break" {
+        return "\n"
+    }
+    var b strings.Builder
+    for _, child := range node.Content {
+        b.WriteString(richTextRawText(child))
+    }
+    return b.String()
+}
+
+// richTextPlain - текстовая запись документа: абзацы с новой строки, пункты списков
+// с маркерами, формулы в $...$. Из нее же строится поиск по содержимому материала
+func richTextPlain(doc *models.RichTextNode) string {
+    var lines []string
+    var walk func(nodes []models.RichTextNode, indent string)
+    walk = func(nodes []models.RichTextNode, indent string) {
+        for _, node := range nodes {
+            switch node.Type {
+            case "paragraph", "heading":
+                lines = append(lines, indent+richTextInlinePlain(node.Content, func(latex string) string { return "$" + latex + "$" }))
+            case "code_block":
+                lines = append(lines, node.Text)
+            case "bullet_list", "ordered_list":
+                for i, item := range node.Content {
+                    marker := "• "
+                    if node.Type == "ordered_list" {
+                        marker = strconv.Itoa(richTextListStart(node)+i) + ". "
+                    }
+                    itemLines := len(lines)
+                    walk(item.Content, indent+"  ")
+                    if len(lines) > itemLines {
+                        lines[itemLines] = indent + marker + strings.TrimPrefix(lines[itemLines], indent+"  ")
+                    }
+                }
+            }
+        }
+    }
+    walk(doc.Content, "")
+    return strings.Join(lines, "\n")
+}
+
+func richTextListStart(list models.RichTextNode) int {
+    if list.Attrs != nil && list.Attrs.Start > 1 {
+        return list.Attrs.Start
+    }
+    return 1
+}
+
+// richTextInlinePlain - строка абзаца; math задает запись формул
+func richTextInlinePlain(nodes []models.RichTextNode, math func(latex string) string) string {
+    var b strings.Builder
+    for _, node := range nodes {
+        switch node.Type {
+        case "text":
+            b.WriteString(node.Text)
+        case "math":
+            b.WriteString(math(node.Attrs.Latex))
+        case "hard_break":
+            b.WriteString("\n")
+        }
+    }
+    return b.String()
+}
+
+// renderRichTextHTML выводит документ в HTML. headingShift сдвигает уровни заголовков,
+// если выше на странице уже есть свои (h1 материала). Формулы выводятся в MathML
+func renderRichTextHTML(doc *models.RichTextNode, headingShift int) template.HTML {
+    var b strings.Builder
+    writeRichTextHTML(&b, doc.Content, headingShift)
+    return template.HTML(b.String())
+}
+
+func writeRichTextHTML(b *strings.Builder, nodes []models.RichTextNode, headingShift int) {
+    for _, node := range nodes {
+        switch node.Type {
+        case "paragraph":
+            b.WriteString("<p>")
+            writeRichTextInlineHTML(b, node.Content)
+            b.WriteString("</p>\n")
+        case "heading":
+            level := node.Attrs.Level + headingShift
+            if level > 6 {
+                level = 6
+            }
+            fmt.Fprintf(b, "<h%d>", level)
+            writeRichTextInlineHTML(b, node.Content)
+            fmt.Fprintf(b, "</h%d>\n", level)
+        case "bullet_list":
+            b.WriteString("<ul>\n")
+            writeRichTextHTML(b, node.Content, headingShift)
+            b.WriteString("</ul>\n")
+        case "ordered_list":
+            if start := richTextListStart(node); start > 1 {
+                fmt.Fprintf(b, `<ol start="%d">`+"\n", start)
+            } else {
+                b.WriteString("<ol>\n")
+            }
+            writeRichTextHTML(b, node.Content, headingShift)
+            b.WriteString("</ol>\n")
+        case "list_item":
+            b.WriteString("<li>")
+            writeRichTextHTML(b, node.Content, headingShift)
+            b.WriteString("</li>\n")
+        case "code_block":
+            if node.Attrs != nil && node.Attrs.Language != "" {
+                fmt.Fprintf(b, `<pre><code class="language-%s">`, html.EscapeString(node.Attrs.Language))
+            } else {
+                b.WriteString("<pre><code>")
+            }
+            b.WriteString(html.EscapeString(node.Text))
+            b.WriteString("</code></pre>\n")
+        }
+    }
+}
+
+var richTextMarkTags = map[string]string{"bold": "strong", "italic": "em", "underline": "u", "strike": "s",
+    "code": "code", "subscript": "sub", "superscript": "sup"}
+
+func writeRichTextInlineHTML(b *strings.Builder, nodes []models.RichTextNode) {
+    for _, node := range nodes {
+        switch node.Type {
+        case "text":
+            for _, mark := range node.Marks {
+                if mark.Type == "link" {
+                    fmt.Fprintf(b, `<a href="%s" rel="noopener noreferrer nofollow">`, html.EscapeString(mark.Attrs.Href))
+                } else {
+                    fmt.Fprintf(b, "<%s>", richTextMarkTags[mark.Type])
+                }
+            }
+            b.WriteString(html.EscapeString(node.Text))
+            for i := len(node.Marks) - 1; i >= 0; i-- {
+                if node.Marks[i].Type == "link" {
+                    b.WriteString("</a>")
+                } else {
+                    fmt.Fprintf(b, "</%s>", richTextMarkTags[node.Marks[i].Type])
+                }
+            }
+        case "math":
+            if root, errs := parseLatex(node.Attrs.Latex); len(errs) == 0 {
+                b.WriteString(formulaMathML(root, node.Attrs.Latex, false))
+            } else {
+                fmt.Fprintf(b, "<code>%s</code>", html.EscapeString(node.Attrs.Latex))
+            }
+        case "hard_break":
+            b.WriteString("<br>")
+        }
+    }
+}
+
+// richTextFromHTML переводит HTML в документ. Скрипты, стили, встраиваемые объекты
+// и все атрибуты, кроме href и data-latex, отбрасываются
+func richTextFromHTML(source string) *models.RichTextNode {
+    body := &nethtml.Node{Type: nethtml.ElementNode, DataAtom: atom.Body, Data: "body"}
+    nodes, err := nethtml.ParseFragment(strings.NewReader(source), body)
+    if err != nil {
+        return sanitizeRichText(&models.RichTextNode{Type: "doc", Content: []models.RichTextNode{{Type: "text", Text: source}}})
+    }
+
+    var content []models.RichTextNode
+    for _, node := range nodes {
+        content = append(content, htmlToRichText(node, nil, 0)...)
+    }
+    return sanitizeRichText(&models.RichTextNode{Type: "doc", Content: wrapRichTextInline(content)})
+}
+
+var (
+    htmlDropped = map[string]bool{"script": true, "style": true, "iframe": true, "object": true, "embed": true,
+        "noscript": true, "template": true, "svg": true, "head": true, "title": true, "meta": true, "link": true,
+        "form": true, "input": true, "button": true, "select": true, "textarea": true, "canvas": true,
+        "video": true, "audio": true, "img": true, "frame": true, "frameset": true}
+    htmlMarks = map[string]string{"strong": "bold", "b": "bold", "em": "italic", "i": "italic", "u": "underline",
+        "ins": "underline", "s": "strike", "strike": "strike", "del": "strike", "code": "code", "kbd": "code",
+        "samp": "code", "tt": "code", "sub": "subscript", "sup": "superscript"}
+    htmlBlocks = map[string]bool{"p": true, "div": true, "section": true, "article": true, "header": true,
+        "footer": true, "main": true, "aside": true, "blockquote": true, "figure": true, "figcaption": true,
+        "center": true, "address": true, "dl": true, "dt": true, "dd": true, "table": true, "thead": true,
+        "tbody": true, "tfoot": true, "caption": true}
+)
+
+func htmlAttr(node *nethtml.Node, name string) string {
+    for _, attr := range node.Attr {
+        if attr.Key == name {
+            return attr.Val
+        }
+    }
+    return ""
+}
+
+// htmlText - текст элемента без разметки
+func htmlText(node *nethtml.Node) string {
+    if node.Type == nethtml.TextNode {
+        return node.Data
+    }
+    var b strings.Builder
+    for child := node.FirstChild; child != nil; child = child.NextSibling {
+        if child.Type == nethtml.ElementNode && child.Data == "br" {
+            b.WriteString("\n")
+            continue
+        }
+        b.WriteString(htmlText(child))
+    }
+    return b.String()
+}
+
+var htmlSpaces = regexp.MustCompile(`\s+`)
+
+// htmlToRichText переводит узел HTML в узлы документа. Результат может смешивать блочные
+// и строчные узлы: блочные элементы сами собирают свои строчные узлы в абзацы
+func htmlToRichText(node *nethtml.Node, marks []models.RichTextMark, depth int) []models.RichTextNode {
+    if depth > 64 {
+        return nil
+    }
+    if node.Type == nethtml.TextNode {
+        return splitInlineMath(htmlSpaces.ReplaceAllString(node.Data, " "), marks)
+    }
+    if node.Type != nethtml.ElementNode && node.Type != nethtml.DocumentNode {
+        return nil
+    }
+
+    children := func(marks []models.RichTextMark) []models.RichTextNode {
+        var result []models.RichTextNode
+        for child := node.FirstChild; child != nil; child = child.NextSibling {
+            result = append(result, htmlToRichText(child, marks, depth+1)...)
+        }
+        return result
+    }
+
+    tag := strings.ToLower(node.Data)
+    switch {
+    case htmlDropped[tag]:
+        return nil
+    case tag == "math":
+        // MathML с исходным LaTeX в аннотации
+        if latex := htmlMathLatex(node); latex != "" {
+            return []models.RichTextNode{{Type: "math", Attrs: &models.RichTextAttrs{Latex: latex}}}
+        }
+        return nil
+    case tag == "br":
+        return []models.RichTextNode{{Type: "hard_break"}}
+    case len(tag) == 2 && tag[0] == 'h' && tag[1] >= '1' && tag[1] <= '6':
+        return []models.RichTextNode{{Type: "heading", Attrs: &models.RichTextAttrs{Level: int(tag[1] - '0')}, Content: children(marks)}}
+    case tag == "ul" || tag == "ol":
+        list := models.RichTextNode{Type: "bullet_list"}
+        if tag == "ol" {
+            list.Type = "ordered_list"
+            if start, err := strconv.Atoi(htmlAttr(node, "start")); err == nil && start > 1 {
+                list.Attrs = &models.RichTextAttrs{Start: start}
+            }
+        }
+        for _, child := range children(marks) {
+            if child.Type == "list_item" {
+                list.Content = append(list.Content, child)
+            } else if len(list.Content) > 0 {
+                // Текст между пунктами относится к предыдущему пункту
+                last := &list.Content[len(list.Content)-1]
+                last.Content = wrapRichTextInline(append(last.Content, child))
+            }
+        }
+        return []models.RichTextNode{list}
+    case tag == "li":
+        return []models.RichTextNode{{Type: "list_item", Content: wrapRichTextInline(children(marks))}}
+    case tag == "pre":
+        code := models.RichTextNode{Type: "code_block", Text: strings.TrimSuffix(htmlText(node), "\n")}
+        classes := htmlAttr(node, "class")
+        if first := node.FirstChild; first != nil && first.Type == nethtml.ElementNode && first.Data == "code" {
+            classes += " " + htmlAttr(first, "class")
+        }
+        for _, class := range strings.Fields(classes) {
+            if language := strings.TrimPrefix(strings.TrimPrefix(class, "language-"), "lang-"); language != class {
+                code.Attrs = &models.RichTextAttrs{Language: language}
+            }
+        }
+        return []models.RichTextNode{code}
+    case tag == "tr":
+        // Строка таблицы - абзац с ячейками через " | "
+        var content []models.RichTextNode
+        for cell := node.FirstChild; cell != nil; cell = cell.NextSibling {
+            if cell.Type != nethtml.ElementNode {
+                continue
+            }
+            if len(content) > 0 {
+                content = append(content, models.RichTextNode{Type: "text", Text: " | "})
+            }
+            content = append(content, htmlToRichText(cell, marks, depth+1)...)
+        }
+        return []models.RichTextNode{{Type: "paragraph", Content: content}}
+    case tag == "a":
+        if href := htmlAttr(node, "href"); href != "" {
+            marks = append(append([]models.RichTextMark(nil), marks...), models.RichTextMark{Type: "link", Attrs: &models.RichTextAttrs{Href: href}})
+        }
+        return children(marks)
+    case tag == "span" && (htmlAttr(node, "data-latex") != "" || strings.Contains(" "+htmlAttr(node, "class")+" ", " math")):
+        latex := htmlAttr(node, "data-latex")
+        if latex == "" {
+            latex = strings.TrimSpace(htmlText(node))
+            latex = strings.TrimSuffix(strings.TrimPrefix(latex, `\(`), `\)`)
+            latex = strings.Trim(latex, "$")
+        }
+        return []models.RichTextNode{{Type: "math", Attrs: &models.RichTextAttrs{Latex: latex}}}
+    case htmlMarks[tag] != "":
+        return children(append(append([]models.RichTextMark(nil), marks...), models.RichTextMark{Type: htmlMarks[tag]}))
+    case htmlBlocks[tag]:
+        return wrapRichTextInline(children(marks))
+    }
+    return children(marks)
+}
+
+// htmlMathLatex достает LaTeX из <annotation encoding="application/x-tex">
+func htmlMathLatex(node *nethtml.Node) string {
+    for child := node.FirstChild; child != nil; child = child.NextSibling {
+        if child.Type == nethtml.ElementNode && child.Data == "annotation" && htmlAttr(child, "encoding") == "application/x-tex" {
+            return strings.TrimSpace(htmlText(child))
+        }
+        if latex := htmlMathLatex(child); latex != "" {
+            return latex
+        }
+    }
+    return ""
+}
+
+// wrapRichTextInline собирает подряд идущие строчные узлы в абзацы
+func wrapRichTextInline(nodes []models.RichTextNode) []models.RichTextNode {
+    var result, inline []models.RichTextNode
+    flush := func() {
+        if len(inline) > 0 {
+            result = append(result, models.RichTextNode{Type: "paragraph", Content: inline})
+            inline = nil
+        }
+    }
+    for _, node := range nodes {
+        switch node.Type {
+        case "text", "math", "hard_break":
+            inline = append(inline, node)
+        default:
+            flush()
+            result = append(result, node)
+        }
+    }
+    flush()
+    return result
+}
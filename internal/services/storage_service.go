@@ -150,4 +150,23 @@ func (s *StorageService) DeleteFile(ctx context.Context, fileName string) error
         Key:    aws.String(fileName),
     })
     return err
-}
\ No newline at end of file
+}
+// OpenFile открывает объект бакета на чтение
+func (s *StorageService) OpenFile(ctx context.Context, fileName string) (io.ReadCloser, error) {
+    output, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+        Bucket: aws.String(s.bucket),
+        Key:    aws.String(fileName),
+    })
+    if err != nil {
+        return nil, err
+    }
+    return output.Body, nil
+}
+// KeyFromURL возвращает ключ объекта по публичному URL, если файл хранится в этом бакете
+func (s *StorageService) KeyFromURL(url string) (string, bool) {
+    prefix := s.cdnURL + "/"
+    if !strings.HasPrefix(url, prefix) {
+        return "", false
+    }
+    return strings.TrimPrefix(url, prefix), true
+}
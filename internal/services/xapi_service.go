@@ -0,0 +1,337 @@
+package services
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "io"
+    "log"
+    "math"
+    "net/http"
+    "net/url"
+    "strconv"
+    "strings"
+    "time"
+
+    "paydeya-backend/internal/models"
+    "paydeya-backend/internal/repositories"
+
+    "github.com/google/uuid"
+)
+
+const xapiVersion = "1.0.3"
+
+// Глаголы xAPI, которые выпускает платформа
+var (
+    xapiVerbExperienced = models.XAPIVerb{ID: "http://adlnet.gov/expapi/verbs/experienced", Display: map[string]string{"en-US": "experienced", "ru-RU": "изучил"}}
+    xapiVerbAnswered    = models.XAPIVerb{ID: "http://adlnet.gov/expapi/verbs/answered", Display: map[string]string{"en-US": "answered", "ru-RU": "ответил"}}
+    xapiVerbCompleted   = models.XAPIVerb{ID: "http://adlnet.gov/expapi/verbs/completed", Display: map[string]string{"en-US": "completed", "ru-RU": "завершил"}}
+    xapiVerbFavorited   = models.XAPIVerb{ID: "http://activitystrea.ms/schema/1.0/favorite", Display: map[string]string{"en-US": "favorited", "ru-RU": "добавил в избранное"}}
+)
+
+const (
+    xapiActivityLesson      = "http://adlnet.gov/expapi/activities/lesson"
+    xapiActivityInteraction = "http://adlnet.gov/expapi/activities/cmi.interaction"
+    xapiActivityMedia       = "http://adlnet.gov/expapi/activities/media"
+)
+
+// XAPIConfig - настройки выпуска xAPI-выражений
+type XAPIConfig struct {
+    ActivityBase string // префикс ID объектов, например https://paydeya.ru
+    LRSEndpoint  string // внешний LRS; пусто - выражения хранятся только локально
+    LRSUsername  string
+    LRSPassword  string
+    MaxAttempts  int
+}
+
+type XAPIService struct {
+    xapiRepo     *repositories.XAPIRepository
+    userRepo     *repositories.UserRepository
+    materialRepo *repositories.MaterialRepository
+    blockRepo    *repositories.BlockRepository
+    config       XAPIConfig
+    client       *http.Client
+}
+
+func NewXAPIService(
+    xapiRepo *repositories.XAPIRepository,
+    userRepo *repositories.UserRepository,
+    materialRepo *repositories.MaterialRepository,
+    blockRepo *repositories.BlockRepository,
+    config XAPIConfig,
+) *XAPIService {
+    config.ActivityBase = strings.TrimRight(config.ActivityBase, "/")
+    config.LRSEndpoint = strings.TrimRight(config.LRSEndpoint, "/")
+    if config.MaxAttempts <= 0 {
+        config.MaxAttempts = 10
+    }
+
+    return &XAPIService{
+        xapiRepo:     xapiRepo,
+        userRepo:     userRepo,
+        materialRepo: materialRepo,
+        blockRepo:    blockRepo,
+        config:       config,
+        client:       &http.Client{Timeout: 10 * time.Second},
+    }
+}
+
+// MaterialExperienced выпускает выражения о просмотре блоков материала
+func (s *XAPIService) MaterialExperienced(ctx context.Context, userID, materialID int, blocks []models.BlockTime) {
+    s.emitBlocks(ctx, userID, materialID, func(blocksByID map[string]models.Block, emit blockEmitter) {
+        for _, viewed := range blocks {
+            block, ok := blocksByID[viewed.BlockID]
+            if !ok {
+                continue
+            }
+            emit(xapiVerbExperienced, &block, &models.XAPIResult{Duration: xapiDuration(viewed.TimeSpent)})
+        }
+    })
+}
+
+// QuestionsAnswered выпускает выражения об ответах на вопросы и решениях упражнений с кодом
+func (s *XAPIService) QuestionsAnswered(ctx context.Context, userID, materialID int, answers []models.QuizResult) {
+    if len(answers) == 0 {
+        return
+    }
+
+    s.emitBlocks(ctx, userID, materialID, func(blocksByID map[string]models.Block, emit blockEmitter) {
+        for _, answer := range answers {
+            block, ok := blocksByID[answer.BlockID]
+            if !ok || (block.Type != "quiz" && block.Type != "code") {
+                continue
+            }
+            correct := answer.Correct
+            emit(xapiVerbAnswered, &block, &models.XAPIResult{Success: &correct})
+        }
+    })
+}
+
+// MaterialCompleted выпускает выражение о завершении материала и оценку, если она есть
+func (s *XAPIService) MaterialCompleted(ctx context.Context, userID, materialID, timeSpent int, grade *float64) {
+    completion := true
+    result := &models.XAPIResult{
+        Completion: &completion,
+        Duration:   xapiDuration(timeSpent),
+    }
+    if grade != nil {
+        result.Score = &models.XAPIScore{Scaled: *grade / 5, Raw: *grade, Min: 1, Max: 5}
+    }
+
+    s.emitBlocks(ctx, userID, materialID, func(_ map[string]models.Block, emit blockEmitter) {
+        emit(xapiVerbCompleted, nil, result)
+    })
+}
+
+// MaterialFavorited выпускает выражение о добавлении материала в избранное
+func (s *XAPIService) MaterialFavorited(ctx context.Context, userID, materialID int) {
+    s.emitBlocks(ctx, userID, materialID, func(_ map[string]models.Block, emit blockEmitter) {
+        emit(xapiVerbFavorited, nil, nil)
+    })
+}
+
+// QueryStatements возвращает выражения встроенного LRS.
+// Администратор видит все, остальные - свои и о своих материалах
+func (s *XAPIService) QueryStatements(ctx context.Context, filter *models.XAPIStatementFilter) ([]json.RawMessage, error) {
+    if filter.Limit <= 0 || filter.Limit > 500 {
+        filter.Limit = 100
+    }
+    return s.xapiRepo.QueryStatements(ctx, filter)
+}
+
+// blockEmitter выпускает выражение о материале (block == nil) или его блоке
+type blockEmitter func(verb models.XAPIVerb, block *models.Block, result *models.XAPIResult)
+
+// emitBlocks загружает ученика, материал и его блоки и выпускает выражения.
+// Ошибки выпуска не прерывают действие ученика и только логируются
+func (s *XAPIService) emitBlocks(ctx context.Context, userID, materialID int, build func(map[string]models.Block, blockEmitter)) {
+    user, err := s.userRepo.GetUserByID(ctx, userID)
+    if err != nil || user == nil {
+        log.Printf("⚠️ xAPI: failed to load user %d: %v", userID, err)
+        return
+    }
+
+    material, err := s.materialRepo.GetMaterial(ctx, materialID)
+    if err != nil || material == nil {
+        log.Printf("⚠️ xAPI: failed to load material %d: %v", materialID, err)
+        return
+    }
+
+    blocks, err := s.blockRepo.GetBlocks(ctx, materialID)
+    if err != nil {
+        log.Printf("⚠️ xAPI: failed to load blocks of material %d: %v", materialID, err)
+        return
+    }
+
+    blocksByID := make(map[string]models.Block, len(blocks))
+    for _, block := range blocks {
+        blocksByID[block.ID] = block
+    }
+
+    actor := models.XAPIActor{ObjectType: "Agent", Name: user.FullName, Mbox: "mailto:" + user.Email}
+    materialActivity := s.materialActivity(material)
+
+    build(blocksByID, func(verb models.XAPIVerb, block *models.Block, result *models.XAPIResult) {
+        now := time.Now().UTC()
+        statement := &models.XAPIStatement{
+            ID:        uuid.New().String(),
+            Actor:     actor,
+            Verb:      verb,
+            Object:    materialActivity,
+            Result:    result,
+            Context:   &models.XAPIContext{Platform: "Paydeya", Language: xapiLanguageTag(material.Language)},
+            Timestamp: now,
+            Stored:    &now,
+            Version:   xapiVersion,
+        }
+
+        if block != nil {
+            statement.Object = s.blockActivity(material, block)
+            statement.Context.ContextActivities = &models.XAPIContextActivities{
+                Parent: []models.XAPIActivity{materialActivity},
+            }
+        }
+
+        if err := s.xapiRepo.SaveStatement(ctx, userID, materialID, statement, s.config.LRSEndpoint != ""); err != nil {
+            log.Printf("⚠️ xAPI: failed to save statement: %v", err)
+        }
+    })
+}
+
+func (s *XAPIService) materialActivity(material *models.Material) models.XAPIActivity {
+    return models.XAPIActivity{
+        ObjectType: "Activity",
+        ID:         s.config.ActivityBase + "/materials/" + strconv.Itoa(material.ID),
+        Definition: &models.XAPIActivityDefinition{
+            Name: map[string]string{xapiLanguageTag(material.Language): material.Title},
+            Type: xapiActivityLesson,
+        },
+    }
+}
+
+func (s *XAPIService) blockActivity(material *models.Material, block *models.Block) models.XAPIActivity {
+    activityType := xapiActivityMedia
+    if block.Type == "quiz" || block.Type == "code" {
+        activityType = xapiActivityInteraction
+    }
+
+    activity := models.XAPIActivity{
+        ObjectType: "Activity",
+        ID:         s.config.ActivityBase + "/materials/" + strconv.Itoa(material.ID) + "/blocks/" + url.PathEscape(block.ID),
+        Definition: &models.XAPIActivityDefinition{Type: activityType},
+    }
+    if question, ok := block.Content["question"].(string); ok && question != "" {
+        activity.Definition.Name = map[string]string{xapiLanguageTag(material.Language): question}
+    }
+
+    return activity
+}
+
+// StartDelivery периодически отправляет выражения из очереди во внешний LRS
+func (s *XAPIService) StartDelivery(ctx context.Context) {
+    if s.config.LRSEndpoint == "" {
+        return
+    }
+
+    go func() {
+        ticker := time.NewTicker(30 * time.Second)
+        defer ticker.Stop()
+
+        for {
+            s.deliverOutbox(ctx)
+
+            select {
+            case <-ctx.Done():
+                return
+            case <-ticker.C:
+            }
+        }
+    }()
+}
+
+func (s *XAPIService) deliverOutbox(ctx context.Context) {
+    for {
+        statements, err := s.xapiRepo.ClaimOutbox(ctx, 50, 5*time.Minute)
+        if err != nil {
+            log.Printf("⚠️ xAPI: failed to claim outbox: %v", err)
+            return
+        }
+        if len(statements) == 0 {
+            return
+        }
+
+        for _, statement := range statements {
+            if err := s.sendStatement(ctx, statement.StatementID, statement.Statement); err != nil {
+                s.retryLater(ctx, statement, err)
+                continue
+            }
+            if err := s.xapiRepo.MarkDelivered(ctx, statement.ID); err != nil {
+                log.Printf("⚠️ xAPI: failed to mark statement %s delivered: %v", statement.StatementID, err)
+            }
+        }
+    }
+}
+
+// retryLater откладывает повторную отправку с экспоненциальной задержкой (не больше 6 часов)
+func (s *XAPIService) retryLater(ctx context.Context, statement repositories.OutboxStatement, sendErr error) {
+    var nextAttemptAt *time.Time
+    if statement.Attempts+1 < s.config.MaxAttempts {
+        delay := time.Duration(math.Min(math.Pow(2, float64(statement.Attempts)), 360)) * time.Minute
+        next := time.Now().Add(delay)
+        nextAttemptAt = &next
+    } else {
+        log.Printf("⚠️ xAPI: giving up on statement %s: %v", statement.StatementID, sendErr)
+    }
+
+    if err := s.xapiRepo.MarkAttemptFailed(ctx, statement.ID, nextAttemptAt, sendErr.Error()); err != nil {
+        log.Printf("⚠️ xAPI: failed to reschedule statement %s: %v", statement.StatementID, err)
+    }
+}
+
+// sendStatement отправляет выражение во внешний LRS. PUT с statementId идемпотентен,
+// поэтому повторная отправка уже принятого выражения безопасна
+func (s *XAPIService) sendStatement(ctx context.Context, statementID string, statement []byte) error {
+    endpoint := s.config.LRSEndpoint + "/statements?statementId=" + url.QueryEscape(statementID)
+
+    req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint, bytes.NewReader(statement))
+    if err != nil {
+        return err
+    }
+    req.Header.Set("Content-Type", "application/json")
+    req.Header.Set("X-Experience-API-Version", xapiVersion)
+    if s.config.LRSUsername != "" {
+        req.SetBasicAuth(s.config.LRSUsername, s.config.LRSPassword)
+    }
+
+    resp, err := s.client.Do(req)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+
+    // 409 - выражение с таким ID уже сохранено в LRS
+    if resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusConflict {
+        return nil
+    }
+
+    body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+    return fmt.Errorf("LRS responded %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+// xapiDuration переводит секунды в длительность ISO 8601
+func xapiDuration(seconds int) string {
+    return "PT" + strconv.Itoa(seconds) + "S"
+}
+
+// xapiLanguageTag переводит язык материала в тег RFC 5646 для language map
+func xapiLanguageTag(language string) string {
+    switch language {
+    case "", "ru":
+        return "ru-RU"
+    case "en":
+        return "en-US"
+    default:
+        return language
+    }
+}
@@ -42,6 +42,53 @@ func getEnvAsInt(key string, defaultValue int) int {
     return defaultValue
 }
 
+func getCodeRunnerConfig() services.CodeRunnerConfig {
+    return services.CodeRunnerConfig{
+        Workers:     getEnvAsInt("CODE_RUNNER_WORKERS", 2),
+        Timeout:     time.Duration(getEnvAsInt("CODE_RUNNER_TIMEOUT_SECONDS", 5)) * time.Second,
+        MemoryMB:    getEnvAsInt("CODE_RUNNER_MEMORY_MB", 256),
+        OutputLimit: getEnvAsInt("CODE_RUNNER_OUTPUT_LIMIT", 64<<10),
+        Python:      getEnv("CODE_RUNNER_PYTHON", "python3"),
+        Node:        getEnv("CODE_RUNNER_NODE", "node"),
+        Java:        getEnv("CODE_RUNNER_JAVA", "java"),
+        UID:         getEnvAsInt("CODE_RUNNER_UID", 61000),
+        RootPaths:   strings.Split(getEnv("CODE_RUNNER_ROOT_PATHS", "/usr,/lib,/lib64,/bin,/etc/alternatives,/etc/ld.so.cache"), ","),
+        Token:       os.Getenv("CODE_RUNNER_TOKEN"),
+    }
+}
+
+// runCodeRunnerService - отдельный сервис запуска кода: интерпретаторы и песочница есть только
+// в его образе, API обращается к нему по CODE_RUNNER_URL с общим токеном CODE_RUNNER_TOKEN
+func runCodeRunnerService() {
+    if err := godotenv.Load(); err != nil {
+        log.Println("⚠️  No .env file found, using environment variables")
+    }
+
+    config := getCodeRunnerConfig()
+    if config.Token == "" {
+        log.Fatalf("❌ CODE_RUNNER_TOKEN is required for the code runner service")
+    }
+    runner := services.NewCodeRunner(config)
+    log.Printf("🧪 Code runner languages: %v", runner.Languages())
+
+    if os.Getenv("GIN_MODE") != "debug" {
+        gin.SetMode(gin.ReleaseMode)
+    }
+    router := gin.Default()
+    codeRunnerHandler := handlers.NewCodeRunnerHandler(runner)
+    router.Use(middleware.ServiceTokenMiddleware(config.Token))
+    router.POST("/run", codeRunnerHandler.Run)
+    router.GET("/languages", codeRunnerHandler.GetLanguages)
+
+    port := getEnv("CODE_RUNNER_PORT", "8090")
+    log.Printf("🚀 Code runner service starting on :%s", port)
+    log.Printf("   POST /run")
+    log.Printf("   GET /languages")
+    if err := router.Run(":" + port); err != nil {
+        log.Fatalf("❌ Failed to start code runner service: %v", err)
+    }
+}
+
 func runMigrations() error {
     migrationFiles := []string{
         "migrations/001_create_users_table.sql",
@@ -50,6 +97,25 @@ func runMigrations() error {
         "migrations/004_add_ratings_table.sql",
         "migrations/005_create_progress_tables.sql",
         "migrations/006_sample_data.sql",
+        "migrations/007_create_material_collaborators.sql",
+        "migrations/008_create_collaboration_tables.sql",
+        "migrations/009_add_material_forks.sql",
+        "migrations/010_add_material_metadata.sql",
+        "migrations/011_add_material_trash.sql",
+        "migrations/012_create_courses.sql",
+        "migrations/013_create_classes.sql",
+        "migrations/014_create_quiz_answers.sql",
+        "migrations/015_create_block_progress.sql",
+        "migrations/016_create_xapi_statements.sql",
+        "migrations/017_create_lti_tables.sql",
+        "migrations/018_add_code_blocks.sql",
+        "migrations/019_create_block_library.sql",
+        "migrations/020_add_material_schedule.sql",
+        "migrations/021_create_material_reviews.sql",
+        "migrations/022_create_material_reports.sql",
+        "migrations/023_create_material_comments.sql",
+        "migrations/024_add_rating_reviews.sql",
+        "migrations/025_add_operation_log_index.sql",
     }
 
     for _, file := range migrationFiles {
@@ -127,11 +193,21 @@ func dynamicSwaggerHandler() gin.HandlerFunc {
 // @tag.name admin
 // @tag.description Эндпоинты для администраторов
 // @tag.name catalog
-// @tag.description Поиск материалов и преподавателей
+// @tag.description Поиск материалов, курсов и преподавателей
 // @tag.name auth
 // @tag.description Авторизация и работа с паролями
 // @tag.name materials
 // @tag.description Управление учебными материалами
+// @tag.name courses
+// @tag.description Курсы из модулей с материалами
+// @tag.name classes
+// @tag.description Классы преподавателей, составы и задания
+// @tag.name teacher
+// @tag.description Аналитика преподавателя по материалам и ученикам
+// @tag.name xapi
+// @tag.description Встроенное хранилище xAPI-выражений (LRS)
+// @tag.name lti
+// @tag.description Запуск материалов из LMS по LTI 1.3 и deep linking
 // @tag.name student
 // @tag.description Отслеживание прогресса обучения и избранное
 // @tag.name profile
@@ -139,6 +215,17 @@ func dynamicSwaggerHandler() gin.HandlerFunc {
 // @tag.name media
 // @tag.description Загрузка и управление медиафайлами
 func main() {
+    // Процесс песочницы для запуска кода учеников: ставит лимиты и запускает интерпретатор
+    if len(os.Args) > 1 && os.Args[1] == services.CodeSandboxCommand {
+        services.RunCodeSandbox(os.Args[2:])
+        return
+    }
+    // Отдельный сервис запуска кода (образ runner)
+    if len(os.Args) > 1 && os.Args[1] == services.CodeRunnerCommand {
+        runCodeRunnerService()
+        return
+    }
+
  // Загружаем .env файл локально
     if err := godotenv.Load(); err != nil {
         log.Println("⚠️  No .env file found, using environment variables")
@@ -188,15 +275,72 @@ func main() {
     catalogRepo := repositories.NewCatalogRepository(database.DB)
     progressRepo := repositories.NewProgressRepository(database.DB)
     adminRepo := repositories.NewAdminRepository(database.DB)
+    collaboratorRepo := repositories.NewCollaboratorRepository(database.DB)
+    collaborationRepo := repositories.NewCollaborationRepository(database.DB)
+    courseRepo := repositories.NewCourseRepository(database.DB)
+    classRepo := repositories.NewClassRepository(database.DB)
+    analyticsRepo := repositories.NewAnalyticsRepository(database.DB)
+    xapiRepo := repositories.NewXAPIRepository(database.DB)
+    ltiRepo := repositories.NewLTIRepository(database.DB)
+    codeRepo := repositories.NewCodeRepository(database.DB)
+    libraryRepo := repositories.NewLibraryRepository(database.DB)
+    moderationRepo := repositories.NewModerationRepository(database.DB)
+    reportRepo := repositories.NewReportRepository(database.DB)
+    notificationRepo := repositories.NewNotificationRepository(database.DB)
+    commentRepo := repositories.NewCommentRepository(database.DB)
+    ratingRepo := repositories.NewRatingRepository(database.DB)
 
     // Создаем сервисы
     authService := services.NewAuthService(userRepo, os.Getenv("JWT_SECRET"))
     //fileService := services.NewFileService("uploads")
     fileService := services.NewFileService("uploads", storageService)
-    materialService := services.NewMaterialService(materialRepo, blockRepo)
+    formulaService := services.NewFormulaService()
+    materialService := services.NewMaterialService(
+        materialRepo, blockRepo, collaboratorRepo, collaborationRepo, userRepo, fileService, formulaService, moderationRepo,
+        time.Duration(getEnvAsInt("TRASH_RETENTION_DAYS", 30))*24*time.Hour,
+        getEnv("MODERATION_ENABLED", "false") == "true",
+    )
+    collaborationService := services.NewCollaborationService(materialService, collaborationRepo, userRepo)
+    courseService := services.NewCourseService(courseRepo, materialService)
+    classService := services.NewClassService(classRepo, materialRepo, courseRepo, userRepo)
+    analyticsService := services.NewAnalyticsService(analyticsRepo, userRepo, materialService)
     catalogService := services.NewCatalogService(catalogRepo)
-    progressService := services.NewProgressService(progressRepo)
+    xapiService := services.NewXAPIService(xapiRepo, userRepo, materialRepo, blockRepo, services.XAPIConfig{
+        ActivityBase: getEnv("XAPI_ACTIVITY_BASE", "https://paydeya.ru"),
+        LRSEndpoint:  os.Getenv("XAPI_LRS_ENDPOINT"),
+        LRSUsername:  os.Getenv("XAPI_LRS_USERNAME"),
+        LRSPassword:  os.Getenv("XAPI_LRS_PASSWORD"),
+        MaxAttempts:  getEnvAsInt("XAPI_LRS_MAX_ATTEMPTS", 10),
+    })
+    ltiService := services.NewLTIService(ltiRepo, userRepo, materialRepo, authService, catalogService, services.LTIConfig{
+        ToolURL:     getEnv("LTI_TOOL_URL", "http://localhost:8080"),
+        FrontendURL: getEnv("FRONTEND_URL", "http://localhost:3000"),
+    })
+    progressService := services.NewProgressService(progressRepo, blockRepo, materialService, xapiService, ltiService)
     adminService := services.NewAdminService(adminRepo)
+    exportService := services.NewExportService(materialService, materialRepo, fileService, formulaService, services.ExportConfig{
+        FrontendURL: getEnv("FRONTEND_URL", "http://localhost:3000"),
+        FontDir:     getEnv("PDF_FONT_DIR", "/usr/share/fonts/dejavu"),
+    })
+
+    // С CODE_RUNNER_URL код запускает отдельный сервис, интерпретаторы серверу API не нужны
+    codeRunnerConfig := getCodeRunnerConfig()
+    codeRunnerConfig.URL = os.Getenv("CODE_RUNNER_URL")
+    codeRunner := services.NewCodeRunner(codeRunnerConfig)
+    codeService := services.NewCodeService(codeRepo, blockRepo, materialService, xapiService, codeRunner, services.CodeLimitsConfig{
+        RunsPerMinute:     getEnvAsInt("CODE_RUNNER_RUNS_PER_MINUTE", 10),
+        SubmissionTimeout: time.Duration(getEnvAsInt("CODE_RUNNER_SUBMISSION_SECONDS", 60)) * time.Second,
+    })
+    libraryService := services.NewLibraryService(libraryRepo, materialRepo, materialService, formulaService)
+    moderationService := services.NewModerationService(moderationRepo, materialRepo, blockRepo, materialService)
+    notificationService := services.NewNotificationService(notificationRepo)
+    reportService := services.NewReportService(
+        reportRepo, materialService, adminService, notificationService,
+        getEnvAsInt("REPORTS_PER_HOUR", 10),
+    )
+    commentService := services.NewCommentService(commentRepo, materialRepo, blockRepo, materialService, notificationService)
+    ratingService := services.NewRatingService(ratingRepo, materialRepo, materialService, notificationService)
+    log.Printf("🧪 Code runner languages: %v", codeService.Languages())
 
     // Создаем обработчики
     authHandler := handlers.NewAuthHandler(authService)
@@ -206,6 +350,32 @@ func main() {
     progressHandler := handlers.NewProgressHandler(progressService)
     adminHandler := handlers.NewAdminHandler(adminService)
     mediaHandler := handlers.NewMediaHandler(fileService)
+    collaborationHandler := handlers.NewCollaborationHandler(collaborationService, strings.Split(getEnv("WS_ALLOWED_ORIGINS", getEnv("FRONTEND_URL", "http://localhost:3000")), ","))
+    courseHandler := handlers.NewCourseHandler(courseService)
+    classHandler := handlers.NewClassHandler(classService)
+    analyticsHandler := handlers.NewAnalyticsHandler(analyticsService)
+    xapiHandler := handlers.NewXAPIHandler(xapiService)
+    ltiHandler := handlers.NewLTIHandler(ltiService)
+    exportHandler := handlers.NewExportHandler(exportService)
+    formulaHandler := handlers.NewFormulaHandler(formulaService)
+    codeHandler := handlers.NewCodeHandler(codeService)
+    libraryHandler := handlers.NewLibraryHandler(libraryService)
+    moderationHandler := handlers.NewModerationHandler(moderationService)
+    reportHandler := handlers.NewReportHandler(reportService)
+    notificationHandler := handlers.NewNotificationHandler(notificationService)
+    commentHandler := handlers.NewCommentHandler(commentService)
+    ratingHandler := handlers.NewRatingHandler(ratingService)
+
+    // Подписка на события совместного редактирования других инстансов, очистка журнала операций и корзины
+    // и отправка xAPI-выражений во внешний LRS
+    if database.DB != nil {
+        collaborationService.Start(context.Background())
+        collaborationService.StartOperationLogCleanup(context.Background(),
+            time.Duration(getEnvAsInt("OPERATION_LOG_RETENTION_DAYS", 7))*24*time.Hour)
+        materialService.StartTrashCleanup(context.Background())
+        materialService.StartPublishScheduler(context.Background())
+        xapiService.StartDelivery(context.Background())
+    }
 
     // Настраиваем Gin
     if os.Getenv("GIN_MODE") != "debug" {
@@ -267,24 +437,141 @@ func main() {
 
         protected.POST("/materials", materialHandler.CreateMaterial)
         protected.GET("/materials/my", materialHandler.GetUserMaterials)
+        protected.GET("/materials/shared", materialHandler.GetSharedMaterials)
+        protected.GET("/materials/trash", materialHandler.GetTrash)
+        protected.POST("/materials/import", exportHandler.ImportBundle)
+        protected.POST("/materials/import/document", exportHandler.ImportDocument)
+        protected.POST("/formulas/render", formulaHandler.RenderFormula)
+        protected.GET("/code/languages", codeHandler.GetLanguages)
         protected.GET("/materials/:id", materialHandler.GetMaterial)
         protected.PUT("/materials/:id", materialHandler.UpdateMaterial)
+        protected.DELETE("/materials/:id", materialHandler.DeleteMaterial)
+        protected.POST("/materials/:id/archive", materialHandler.ArchiveMaterial)
+        protected.POST("/materials/:id/unarchive", materialHandler.UnarchiveMaterial)
+        protected.POST("/materials/:id/restore", materialHandler.RestoreMaterial)
         protected.POST("/materials/:id/publish", materialHandler.PublishMaterial)
+        protected.GET("/materials/:id/reviews", moderationHandler.GetMaterialReviews)
+        protected.POST("/materials/:id/reports", reportHandler.CreateReport)
+        protected.GET("/materials/:id/comments", commentHandler.GetComments)
+        protected.POST("/materials/:id/comments", commentHandler.CreateComment)
+        protected.DELETE("/comments/:id", commentHandler.DeleteComment)
+        protected.PUT("/comments/:id/vote", commentHandler.UpvoteComment)
+        protected.DELETE("/comments/:id/vote", commentHandler.RemoveCommentVote)
+        protected.PUT("/comments/:id/resolved", commentHandler.SetCommentResolved)
+        protected.PUT("/comments/:id/hidden", commentHandler.SetCommentHidden)
+        protected.PUT("/materials/:id/ratings/:ratingId/response", ratingHandler.RespondToReview)
+        protected.POST("/materials/:id/duplicate", materialHandler.DuplicateMaterial)
+        protected.POST("/materials/:id/fork", materialHandler.ForkMaterial)
+        protected.GET("/materials/:id/export", exportHandler.ExportPrintable)
+        protected.GET("/materials/:id/export/scorm", exportHandler.ExportSCORM)
+        protected.GET("/materials/:id/export/bundle", exportHandler.ExportBundle)
         protected.POST("/materials/:id/blocks", materialHandler.AddBlock)
         protected.PUT("/materials/:id/blocks/:blockId", materialHandler.UpdateBlock)
         protected.DELETE("/materials/:id/blocks/:blockId", materialHandler.DeleteBlock)
+        protected.GET("/materials/:id/blocks/:blockId/animation/preview", materialHandler.GetAnimationPreview)
         protected.POST("/materials/:id/blocks/reorder", materialHandler.ReorderBlocks)
+        protected.POST("/materials/:id/blocks/:blockId/run", codeHandler.RunCode)
+        protected.GET("/materials/:id/collaborators", materialHandler.GetCollaborators)
+        protected.POST("/materials/:id/collaborators", materialHandler.AddCollaborator)
+        protected.PUT("/materials/:id/collaborators/:userId", materialHandler.UpdateCollaborator)
+        protected.DELETE("/materials/:id/collaborators/:userId", materialHandler.RemoveCollaborator)
+        protected.POST("/materials/:id/transfer", materialHandler.TransferOwnership)
+        protected.GET("/materials/:id/live", collaborationHandler.LiveEdit)
+        protected.GET("/materials/:id/operations", collaborationHandler.GetOperations)
+
+        protected.GET("/notifications", notificationHandler.GetNotifications)
+        protected.POST("/notifications/read-all", notificationHandler.MarkAllNotificationsRead)
+        protected.POST("/notifications/:id/read", notificationHandler.MarkNotificationRead)
+
+        protected.POST("/courses", courseHandler.CreateCourse)
+        protected.GET("/courses/my", courseHandler.GetUserCourses)
+        protected.GET("/courses/:id", courseHandler.GetCourse)
+        protected.PUT("/courses/:id", courseHandler.UpdateCourse)
+        protected.DELETE("/courses/:id", courseHandler.DeleteCourse)
+        protected.POST("/courses/:id/publish", courseHandler.PublishCourse)
+        protected.POST("/courses/:id/modules", courseHandler.CreateModule)
+        protected.POST("/courses/:id/modules/reorder", courseHandler.ReorderModules)
+        protected.PUT("/courses/:id/modules/:moduleId", courseHandler.UpdateModule)
+        protected.DELETE("/courses/:id/modules/:moduleId", courseHandler.DeleteModule)
+        protected.POST("/courses/:id/modules/:moduleId/items", courseHandler.AddItem)
+        protected.POST("/courses/:id/modules/:moduleId/items/reorder", courseHandler.ReorderItems)
+        protected.PUT("/courses/:id/items/:itemId/prerequisites", courseHandler.SetPrerequisites)
+        protected.DELETE("/courses/:id/items/:itemId", courseHandler.DeleteItem)
+
+        classes := protected.Group("/classes")
+        classes.Use(middleware.TeacherMiddleware())
+        {
+            classes.POST("", classHandler.CreateClass)
+            classes.GET("", classHandler.GetTeacherClasses)
+            classes.GET("/:id", classHandler.GetClass)
+            classes.PUT("/:id", classHandler.UpdateClass)
+            classes.DELETE("/:id", classHandler.DeleteClass)
+            classes.POST("/:id/join-code", classHandler.RegenerateJoinCode)
+            classes.GET("/:id/students", classHandler.GetStudents)
+            classes.POST("/:id/students", classHandler.AddStudent)
+            classes.DELETE("/:id/students/:userId", classHandler.RemoveStudent)
+            classes.GET("/:id/assignments", classHandler.GetAssignments)
+            classes.POST("/:id/assignments", classHandler.CreateAssignment)
+            classes.PUT("/:id/assignments/:assignmentId", classHandler.UpdateAssignment)
+            classes.DELETE("/:id/assignments/:assignmentId", classHandler.DeleteAssignment)
+            classes.GET("/:id/completion", classHandler.GetCompletion)
+        }
+
+        library := protected.Group("/library")
+        library.Use(middleware.TeacherMiddleware())
+        {
+            library.GET("/blocks", libraryHandler.GetLibraryBlocks)
+            library.POST("/blocks", libraryHandler.SaveLibraryBlock)
+            library.GET("/blocks/:id", libraryHandler.GetLibraryBlock)
+            library.PUT("/blocks/:id", libraryHandler.UpdateLibraryBlock)
+            library.DELETE("/blocks/:id", libraryHandler.DeleteLibraryBlock)
+            library.POST("/blocks/:id/insert", libraryHandler.InsertLibraryBlock)
+        }
+
+        templates := protected.Group("/templates")
+        templates.Use(middleware.TeacherMiddleware())
+        {
+            templates.GET("", libraryHandler.GetTemplates)
+            templates.POST("", libraryHandler.CreateTemplate)
+            templates.GET("/:id", libraryHandler.GetTemplate)
+            templates.PUT("/:id", libraryHandler.UpdateTemplate)
+            templates.DELETE("/:id", libraryHandler.DeleteTemplate)
+            templates.POST("/:id/insert", libraryHandler.InsertTemplate)
+        }
+
+        teacher := protected.Group("/teacher")
+        teacher.Use(middleware.TeacherMiddleware())
+        {
+            teacher.GET("/analytics/materials", analyticsHandler.GetMaterialsOverview)
+            teacher.GET("/analytics/materials/:id", analyticsHandler.GetMaterialAnalytics)
+            teacher.GET("/analytics/students/:id", analyticsHandler.GetStudentAnalytics)
+        }
 
         protected.POST("/upload/image", mediaHandler.UploadImage)
         protected.POST("/upload/video", mediaHandler.UploadVideo)
         protected.POST("/embed/video", mediaHandler.EmbedVideo)
 
+        protected.GET("/xapi/statements", xapiHandler.GetStatements)
+
+        protected.GET("/lti/deep-linking/:sessionId", ltiHandler.GetDeepLinkMaterials)
+        protected.POST("/lti/deep-linking/:sessionId", ltiHandler.CompleteDeepLinking)
+
         student := protected.Group("/student")
         {
             student.GET("/progress", progressHandler.GetProgress)
             student.GET("/favorites", progressHandler.GetFavorites)
             student.POST("/materials/:id/complete", progressHandler.MarkMaterialComplete)
+            student.GET("/materials/:id/progress", progressHandler.GetMaterialProgress)
+            student.POST("/materials/:id/progress", progressHandler.RecordBlockProgress)
             student.POST("/materials/:id/favorite", progressHandler.ToggleFavorite)
+            student.PUT("/materials/:id/rating", ratingHandler.RateMaterial)
+            student.POST("/materials/:id/blocks/:blockId/submissions", codeHandler.SubmitCode)
+            student.GET("/materials/:id/blocks/:blockId/submissions/last", codeHandler.GetLastSubmission)
+            student.GET("/courses/:id/progress", courseHandler.GetCourseProgress)
+            student.POST("/classes/join", classHandler.JoinClass)
+            student.GET("/classes", classHandler.GetStudentClasses)
+            student.DELETE("/classes/:id", classHandler.LeaveClass)
+            student.GET("/assignments", classHandler.GetStudentAssignments)
         }
 
         admin := protected.Group("/admin")
@@ -293,17 +580,41 @@ func main() {
             admin.GET("/statistics", adminHandler.GetStatistics)
             admin.GET("/users", adminHandler.GetUsers)
             admin.POST("/users/:id/block", adminHandler.BlockUser)
+            admin.PUT("/users/:id/trusted", moderationHandler.SetTrustedAuthor)
             admin.POST("/subjects", adminHandler.CreateSubject)
+            admin.POST("/templates", libraryHandler.CreateOfficialTemplate)
+            admin.GET("/lti/platforms", ltiHandler.GetPlatforms)
+            admin.POST("/lti/platforms", ltiHandler.CreatePlatform)
+            admin.DELETE("/lti/platforms/:id", ltiHandler.DeletePlatform)
+            admin.GET("/moderation/queue", moderationHandler.GetQueue)
+            admin.GET("/moderation/reviews/:id", moderationHandler.GetReview)
+            admin.POST("/moderation/reviews/:id/approve", moderationHandler.ApproveReview)
+            admin.POST("/moderation/reviews/:id/request-changes", moderationHandler.RequestChanges)
+            admin.GET("/reports", reportHandler.GetReports)
+            admin.GET("/reports/:id", reportHandler.GetReport)
+            admin.POST("/reports/:id/resolve", reportHandler.ResolveReport)
         }
     }
 
     catalog := router.Group("/api/v1/catalog")
     {
         catalog.GET("/materials", catalogHandler.SearchMaterials)
+        catalog.GET("/materials/:id/reviews", ratingHandler.GetReviews)
+        catalog.GET("/courses", catalogHandler.SearchCourses)
         catalog.GET("/subjects", catalogHandler.GetSubjects)
+        catalog.GET("/tags", catalogHandler.GetTags)
         catalog.GET("/teachers", catalogHandler.SearchTeachers)
     }
 
+    // LTI 1.3: вход и запуск вызываются браузером со стороны платформы, без нашей авторизации
+    lti := router.Group("/api/v1/lti")
+    {
+        lti.GET("/login", ltiHandler.Login)
+        lti.POST("/login", ltiHandler.Login)
+        lti.POST("/launch", ltiHandler.Launch)
+        lti.GET("/jwks", ltiHandler.GetJWKS)
+    }
+
     router.GET("/swagger.json", dynamicSwaggerHandler())
 
     //router.GET("/docs/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, ginSwagger.URL("/swagger.json")))
@@ -383,25 +694,137 @@ func main() {
     log.Printf("   GET /api/v1/materials")
     log.Printf("   GET /api/v1/materials/:id")
     log.Printf("   PUT /api/v1/materials/:id")
+    log.Printf("   DELETE /api/v1/materials/:id")
+    log.Printf("   POST /api/v1/materials/:id/archive")
+    log.Printf("   POST /api/v1/materials/:id/unarchive")
+    log.Printf("   GET /api/v1/materials/trash")
+    log.Printf("   POST /api/v1/materials/:id/restore")
     log.Printf("   POST /api/v1/materials/:id/publish")
+    log.Printf("   GET /api/v1/materials/:id/reviews")
+    log.Printf("   POST /api/v1/materials/:id/reports")
+    log.Printf("   GET /api/v1/materials/:id/comments")
+    log.Printf("   POST /api/v1/materials/:id/comments")
+    log.Printf("   DELETE /api/v1/comments/:id")
+    log.Printf("   PUT /api/v1/comments/:id/vote")
+    log.Printf("   DELETE /api/v1/comments/:id/vote")
+    log.Printf("   PUT /api/v1/comments/:id/resolved")
+    log.Printf("   PUT /api/v1/comments/:id/hidden")
+    log.Printf("   PUT /api/v1/materials/:id/ratings/:ratingId/response")
+    log.Printf("   POST /api/v1/materials/:id/duplicate")
+    log.Printf("   POST /api/v1/materials/:id/fork")
+    log.Printf("   GET /api/v1/materials/:id/export")
+    log.Printf("   GET /api/v1/materials/:id/export/scorm")
+    log.Printf("   GET /api/v1/materials/:id/export/bundle")
+    log.Printf("   POST /api/v1/materials/import")
+    log.Printf("   POST /api/v1/materials/import/document")
+    log.Printf("   POST /api/v1/formulas/render")
+    log.Printf("   GET /api/v1/code/languages")
     log.Printf("   POST /api/v1/materials/:id/blocks")
     log.Printf("   PUT /api/v1/materials/:id/blocks/:blockId")
     log.Printf("   DELETE /api/v1/materials/:id/blocks/:blockId")
+    log.Printf("   GET /api/v1/materials/:id/blocks/:blockId/animation/preview")
     log.Printf("   POST /api/v1/materials/:id/blocks/reorder")
+    log.Printf("   POST /api/v1/materials/:id/blocks/:blockId/run")
+    log.Printf("   GET /api/v1/materials/shared")
+    log.Printf("   GET /api/v1/materials/:id/collaborators")
+    log.Printf("   POST /api/v1/materials/:id/collaborators")
+    log.Printf("   PUT /api/v1/materials/:id/collaborators/:userId")
+    log.Printf("   DELETE /api/v1/materials/:id/collaborators/:userId")
+    log.Printf("   POST /api/v1/materials/:id/transfer")
+    log.Printf("   GET /api/v1/materials/:id/live (WebSocket)")
+    log.Printf("   GET /api/v1/materials/:id/operations")
+    log.Printf("   GET /api/v1/notifications")
+    log.Printf("   POST /api/v1/notifications/read-all")
+    log.Printf("   POST /api/v1/notifications/:id/read")
+    log.Printf("   POST /api/v1/courses")
+    log.Printf("   GET /api/v1/courses/my")
+    log.Printf("   GET /api/v1/courses/:id")
+    log.Printf("   PUT /api/v1/courses/:id")
+    log.Printf("   DELETE /api/v1/courses/:id")
+    log.Printf("   POST /api/v1/courses/:id/publish")
+    log.Printf("   POST /api/v1/courses/:id/modules")
+    log.Printf("   POST /api/v1/courses/:id/modules/reorder")
+    log.Printf("   PUT /api/v1/courses/:id/modules/:moduleId")
+    log.Printf("   DELETE /api/v1/courses/:id/modules/:moduleId")
+    log.Printf("   POST /api/v1/courses/:id/modules/:moduleId/items")
+    log.Printf("   POST /api/v1/courses/:id/modules/:moduleId/items/reorder")
+    log.Printf("   PUT /api/v1/courses/:id/items/:itemId/prerequisites")
+    log.Printf("   DELETE /api/v1/courses/:id/items/:itemId")
+    log.Printf("   POST /api/v1/classes")
+    log.Printf("   GET /api/v1/classes")
+    log.Printf("   GET /api/v1/classes/:id")
+    log.Printf("   PUT /api/v1/classes/:id")
+    log.Printf("   DELETE /api/v1/classes/:id")
+    log.Printf("   POST /api/v1/classes/:id/join-code")
+    log.Printf("   GET /api/v1/classes/:id/students")
+    log.Printf("   POST /api/v1/classes/:id/students")
+    log.Printf("   DELETE /api/v1/classes/:id/students/:userId")
+    log.Printf("   GET /api/v1/classes/:id/assignments")
+    log.Printf("   POST /api/v1/classes/:id/assignments")
+    log.Printf("   PUT /api/v1/classes/:id/assignments/:assignmentId")
+    log.Printf("   DELETE /api/v1/classes/:id/assignments/:assignmentId")
+    log.Printf("   GET /api/v1/classes/:id/completion")
+    log.Printf("   GET /api/v1/library/blocks")
+    log.Printf("   POST /api/v1/library/blocks")
+    log.Printf("   GET /api/v1/library/blocks/:id")
+    log.Printf("   PUT /api/v1/library/blocks/:id")
+    log.Printf("   DELETE /api/v1/library/blocks/:id")
+    log.Printf("   POST /api/v1/library/blocks/:id/insert")
+    log.Printf("   GET /api/v1/templates")
+    log.Printf("   POST /api/v1/templates")
+    log.Printf("   GET /api/v1/templates/:id")
+    log.Printf("   PUT /api/v1/templates/:id")
+    log.Printf("   DELETE /api/v1/templates/:id")
+    log.Printf("   POST /api/v1/templates/:id/insert")
+    log.Printf("   GET /api/v1/teacher/analytics/materials")
+    log.Printf("   GET /api/v1/teacher/analytics/materials/:id")
+    log.Printf("   GET /api/v1/teacher/analytics/students/:id")
     log.Printf("   GET /api/v1/catalog/materials")
+    log.Printf("   GET /api/v1/catalog/materials/:id/reviews")
+    log.Printf("   GET /api/v1/catalog/courses")
     log.Printf("   GET /api/v1/catalog/subjects")
+    log.Printf("   GET /api/v1/catalog/tags")
     log.Printf("   GET /api/v1/catalog/teachers")
     log.Printf("   GET /api/v1/student/progress")
     log.Printf("   GET /api/v1/student/favorites")
     log.Printf("   POST /api/v1/student/materials/:id/complete")
+    log.Printf("   GET /api/v1/student/materials/:id/progress")
+    log.Printf("   POST /api/v1/student/materials/:id/progress")
     log.Printf("   POST /api/v1/student/materials/:id/favorite")
+    log.Printf("   PUT /api/v1/student/materials/:id/rating")
+    log.Printf("   POST /api/v1/student/materials/:id/blocks/:blockId/submissions")
+    log.Printf("   GET /api/v1/student/materials/:id/blocks/:blockId/submissions/last")
+    log.Printf("   GET /api/v1/student/courses/:id/progress")
+    log.Printf("   POST /api/v1/student/classes/join")
+    log.Printf("   GET /api/v1/student/classes")
+    log.Printf("   DELETE /api/v1/student/classes/:id")
+    log.Printf("   GET /api/v1/student/assignments")
     log.Printf("   GET /api/v1/admin/statistics")
     log.Printf("   GET /api/v1/admin/users")
     log.Printf("   POST /api/v1/admin/users/:id/block")
+    log.Printf("   PUT /api/v1/admin/users/:id/trusted")
     log.Printf("   POST /api/v1/admin/subjects")
+    log.Printf("   POST /api/v1/admin/templates")
+    log.Printf("   GET /api/v1/admin/lti/platforms")
+    log.Printf("   POST /api/v1/admin/lti/platforms")
+    log.Printf("   DELETE /api/v1/admin/lti/platforms/:id")
+    log.Printf("   GET /api/v1/admin/moderation/queue")
+    log.Printf("   GET /api/v1/admin/moderation/reviews/:id")
+    log.Printf("   POST /api/v1/admin/moderation/reviews/:id/approve")
+    log.Printf("   POST /api/v1/admin/moderation/reviews/:id/request-changes")
+    log.Printf("   GET /api/v1/admin/reports")
+    log.Printf("   GET /api/v1/admin/reports/:id")
+    log.Printf("   POST /api/v1/admin/reports/:id/resolve")
     log.Printf("   POST /api/v1/upload/image")
     log.Printf("   POST /api/v1/upload/video")
     log.Printf("   POST /api/v1/embed/video")
+    log.Printf("   GET /api/v1/xapi/statements")
+    log.Printf("   GET /api/v1/lti/login")
+    log.Printf("   POST /api/v1/lti/login")
+    log.Printf("   POST /api/v1/lti/launch")
+    log.Printf("   GET /api/v1/lti/jwks")
+    log.Printf("   GET /api/v1/lti/deep-linking/:sessionId")
+    log.Printf("   POST /api/v1/lti/deep-linking/:sessionId")
 
 
     defer func() {
@@ -0,0 +1,19 @@
+-- migrations/007_create_material_collaborators.sql
+
+-- Соавторы материалов (совместная работа над материалом)
+CREATE TABLE IF NOT EXISTS material_collaborators (
+    id SERIAL PRIMARY KEY,
+    material_id INTEGER NOT NULL REFERENCES materials(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL DEFAULT 'viewer'
+        CHECK (role IN ('editor', 'reviewer', 'viewer')),
+    invited_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE(material_id, user_id) -- один пользователь имеет одну роль в материале
+);
+
+-- Индексы
+CREATE INDEX IF NOT EXISTS idx_material_collaborators_material_id ON material_collaborators(material_id);
+CREATE INDEX IF NOT EXISTS idx_material_collaborators_user_id ON material_collaborators(user_id);
@@ -0,0 +1,32 @@
+-- migrations/008_create_collaboration_tables.sql
+
+-- Порядковый номер последней операции над блоками материала
+ALTER TABLE materials ADD COLUMN IF NOT EXISTS op_seq BIGINT NOT NULL DEFAULT 0;
+
+-- Журнал операций над блоками (совместное редактирование)
+CREATE TABLE IF NOT EXISTS material_operations (
+    id BIGSERIAL PRIMARY KEY,
+    material_id INTEGER NOT NULL REFERENCES materials(id) ON DELETE CASCADE,
+    seq BIGINT NOT NULL,
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('add', 'update', 'delete', 'reorder', 'replace')),
+    block_id VARCHAR(50),
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE(material_id, seq)
+);
+
+-- Блокировки блоков на время редактирования
+CREATE TABLE IF NOT EXISTS material_block_locks (
+    material_id INTEGER NOT NULL REFERENCES materials(id) ON DELETE CASCADE,
+    block_id VARCHAR(50) NOT NULL,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    acquired_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+
+    PRIMARY KEY (material_id, block_id)
+);
+
+-- Индексы
+CREATE INDEX IF NOT EXISTS idx_material_block_locks_user_id ON material_block_locks(material_id, user_id);
@@ -0,0 +1,6 @@
+-- migrations/009_add_material_forks.sql
+
+-- Происхождение материала: из какого материала он скопирован
+ALTER TABLE materials ADD COLUMN IF NOT EXISTS forked_from INTEGER REFERENCES materials(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_materials_forked_from ON materials(forked_from);
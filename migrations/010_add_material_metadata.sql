@@ -0,0 +1,30 @@
+-- migrations/010_add_material_metadata.sql
+
+-- Метаданные материала для каталога
+ALTER TABLE materials ADD COLUMN IF NOT EXISTS description TEXT NOT NULL DEFAULT '';
+ALTER TABLE materials ADD COLUMN IF NOT EXISTS level VARCHAR(20)
+    CHECK (level IN ('beginner', 'intermediate', 'advanced'));
+ALTER TABLE materials ADD COLUMN IF NOT EXISTS cover_url VARCHAR(500) NOT NULL DEFAULT '';
+ALTER TABLE materials ADD COLUMN IF NOT EXISTS language VARCHAR(10) NOT NULL DEFAULT 'ru';
+ALTER TABLE materials ADD COLUMN IF NOT EXISTS duration_minutes INTEGER NOT NULL DEFAULT 0
+    CHECK (duration_minutes >= 0);
+
+CREATE INDEX IF NOT EXISTS idx_materials_level ON materials(level);
+CREATE INDEX IF NOT EXISTS idx_materials_language ON materials(language);
+
+-- Теги (нормализованные: в нижнем регистре, без повторов)
+CREATE TABLE IF NOT EXISTS tags (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) UNIQUE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Связь материалов и тегов (многие-ко-многим)
+CREATE TABLE IF NOT EXISTS material_tags (
+    material_id INTEGER NOT NULL REFERENCES materials(id) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+
+    PRIMARY KEY (material_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_material_tags_tag_id ON material_tags(tag_id);
@@ -0,0 +1,6 @@
+-- migrations/011_add_material_trash.sql
+
+-- Корзина: удаленный материал хранится до окончания срока хранения, затем удаляется окончательно
+ALTER TABLE materials ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_materials_deleted_at ON materials(deleted_at) WHERE deleted_at IS NOT NULL;
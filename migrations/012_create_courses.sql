@@ -0,0 +1,57 @@
+-- migrations/012_create_courses.sql
+
+-- Курсы: упорядоченные наборы материалов, разбитые на модули
+CREATE TABLE IF NOT EXISTS courses (
+    id SERIAL PRIMARY KEY,
+    title VARCHAR(1000) NOT NULL,
+    subject_id VARCHAR(200) NOT NULL REFERENCES subjects(id),
+    description TEXT NOT NULL DEFAULT '',
+    level VARCHAR(20) CHECK (level IN ('beginner', 'intermediate', 'advanced')),
+    cover_url VARCHAR(500) NOT NULL DEFAULT '',
+    author_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'draft'
+        CHECK (status IN ('draft', 'published', 'archived')),
+    access VARCHAR(20) NOT NULL DEFAULT 'open'
+        CHECK (access IN ('open', 'link')),
+    share_url VARCHAR(500),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_courses_author_id ON courses(author_id);
+CREATE INDEX IF NOT EXISTS idx_courses_status ON courses(status);
+
+-- Модули курса
+CREATE TABLE IF NOT EXISTS course_modules (
+    id SERIAL PRIMARY KEY,
+    course_id INTEGER NOT NULL REFERENCES courses(id) ON DELETE CASCADE,
+    title VARCHAR(500) NOT NULL,
+    position INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_course_modules_position ON course_modules(course_id, position);
+
+-- Материалы модулей. Материал входит в курс не более одного раза
+CREATE TABLE IF NOT EXISTS course_items (
+    id SERIAL PRIMARY KEY,
+    course_id INTEGER NOT NULL REFERENCES courses(id) ON DELETE CASCADE,
+    module_id INTEGER NOT NULL REFERENCES course_modules(id) ON DELETE CASCADE,
+    material_id INTEGER NOT NULL REFERENCES materials(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE(course_id, material_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_course_items_position ON course_items(module_id, position);
+CREATE INDEX IF NOT EXISTS idx_course_items_material_id ON course_items(material_id);
+
+-- Условия доступа: элемент открывается после прохождения всех его предварительных элементов
+CREATE TABLE IF NOT EXISTS course_item_prerequisites (
+    item_id INTEGER NOT NULL REFERENCES course_items(id) ON DELETE CASCADE,
+    prerequisite_id INTEGER NOT NULL REFERENCES course_items(id) ON DELETE CASCADE,
+
+    PRIMARY KEY (item_id, prerequisite_id),
+    CHECK (item_id <> prerequisite_id)
+);
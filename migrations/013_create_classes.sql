@@ -0,0 +1,42 @@
+-- migrations/013_create_classes.sql
+
+-- Классы (учебные группы) преподавателей
+CREATE TABLE IF NOT EXISTS classes (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(200) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    teacher_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    join_code VARCHAR(20) NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_classes_teacher_id ON classes(teacher_id);
+
+-- Ученики класса
+CREATE TABLE IF NOT EXISTS class_members (
+    class_id INTEGER NOT NULL REFERENCES classes(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    joined_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (class_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_class_members_user_id ON class_members(user_id);
+
+-- Задания класса: материал или курс со сроком сдачи
+CREATE TABLE IF NOT EXISTS class_assignments (
+    id SERIAL PRIMARY KEY,
+    class_id INTEGER NOT NULL REFERENCES classes(id) ON DELETE CASCADE,
+    material_id INTEGER REFERENCES materials(id) ON DELETE CASCADE,
+    course_id INTEGER REFERENCES courses(id) ON DELETE CASCADE,
+    note TEXT NOT NULL DEFAULT '',
+    due_at TIMESTAMP WITH TIME ZONE,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CHECK ((material_id IS NULL) <> (course_id IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_class_assignments_class_id ON class_assignments(class_id);
+CREATE INDEX IF NOT EXISTS idx_class_assignments_due_at ON class_assignments(due_at);
@@ -0,0 +1,14 @@
+-- migrations/014_create_quiz_answers.sql
+
+-- Ответы учеников на вопросы (quiz-блоки) материалов. Хранится последний ответ
+CREATE TABLE IF NOT EXISTS quiz_answers (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    material_id INTEGER NOT NULL REFERENCES materials(id) ON DELETE CASCADE,
+    block_id VARCHAR(50) NOT NULL,
+    correct BOOLEAN NOT NULL,
+    answered_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (user_id, material_id, block_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_quiz_answers_material_id ON quiz_answers(material_id, block_id);
@@ -0,0 +1,29 @@
+-- migrations/015_create_block_progress.sql
+
+-- Прогресс ученика внутри материала: позиция для продолжения изучения
+CREATE TABLE IF NOT EXISTS material_progress (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    material_id INTEGER NOT NULL REFERENCES materials(id) ON DELETE CASCADE,
+    last_block_id VARCHAR(50) NOT NULL,
+    time_spent INTEGER NOT NULL DEFAULT 0, -- время в секундах
+    started_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    last_activity TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (user_id, material_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_material_progress_last_activity ON material_progress(user_id, last_activity DESC);
+
+-- Просмотренные учеником блоки материала и время на каждом
+CREATE TABLE IF NOT EXISTS material_block_views (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    material_id INTEGER NOT NULL REFERENCES materials(id) ON DELETE CASCADE,
+    block_id VARCHAR(50) NOT NULL,
+    time_spent INTEGER NOT NULL DEFAULT 0, -- время в секундах
+    first_viewed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    last_viewed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (user_id, material_id, block_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_material_block_views_material_id ON material_block_views(material_id, block_id);
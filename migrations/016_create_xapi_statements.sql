@@ -0,0 +1,30 @@
+-- migrations/016_create_xapi_statements.sql
+
+-- xAPI (Tin Can) выражения о действиях учеников - встроенное хранилище (LRS)
+CREATE TABLE IF NOT EXISTS xapi_statements (
+    id UUID PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    material_id INTEGER REFERENCES materials(id) ON DELETE SET NULL,
+    verb VARCHAR(255) NOT NULL,
+    activity_id TEXT NOT NULL,
+    statement JSONB NOT NULL,
+    timestamp TIMESTAMP WITH TIME ZONE NOT NULL,
+    stored TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_xapi_statements_stored ON xapi_statements(stored DESC);
+CREATE INDEX IF NOT EXISTS idx_xapi_statements_user_id ON xapi_statements(user_id);
+CREATE INDEX IF NOT EXISTS idx_xapi_statements_material_id ON xapi_statements(material_id);
+
+-- Очередь отправки выражений во внешний LRS
+CREATE TABLE IF NOT EXISTS xapi_outbox (
+    id SERIAL PRIMARY KEY,
+    statement_id UUID NOT NULL REFERENCES xapi_statements(id) ON DELETE CASCADE,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    last_error TEXT,
+    failed BOOLEAN NOT NULL DEFAULT false, -- попытки исчерпаны
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_xapi_outbox_next_attempt ON xapi_outbox(next_attempt_at) WHERE NOT failed;
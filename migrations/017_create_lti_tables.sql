@@ -0,0 +1,79 @@
+-- migrations/017_create_lti_tables.sql
+
+-- Зарегистрированные LTI 1.3 платформы (Moodle и другие LMS)
+CREATE TABLE IF NOT EXISTS lti_platforms (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(200) NOT NULL,
+    issuer TEXT NOT NULL,
+    client_id VARCHAR(255) NOT NULL,
+    deployment_ids TEXT[] NOT NULL DEFAULT '{}',
+    auth_login_url TEXT NOT NULL,  -- OIDC authorization endpoint платформы
+    auth_token_url TEXT NOT NULL,  -- OAuth2 token endpoint для Assignment and Grade Services
+    jwks_url TEXT NOT NULL,        -- публичные ключи платформы
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE(issuer, client_id)
+);
+
+-- Ключи инструмента для подписи ответов deep linking и client assertion
+CREATE TABLE IF NOT EXISTS lti_tool_keys (
+    kid VARCHAR(64) PRIMARY KEY,
+    private_key_pem TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Одноразовые state/nonce OIDC-входа
+CREATE TABLE IF NOT EXISTS lti_login_states (
+    state VARCHAR(64) PRIMARY KEY,
+    nonce VARCHAR(64) NOT NULL,
+    platform_id INTEGER NOT NULL REFERENCES lti_platforms(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Пользователи платформ, заведенные при запуске
+CREATE TABLE IF NOT EXISTS lti_users (
+    platform_id INTEGER NOT NULL REFERENCES lti_platforms(id) ON DELETE CASCADE,
+    sub VARCHAR(255) NOT NULL,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (platform_id, sub)
+);
+
+CREATE INDEX IF NOT EXISTS idx_lti_users_user_id ON lti_users(user_id);
+
+-- Ссылки на материалы в курсах платформы и колонки журнала оценок
+CREATE TABLE IF NOT EXISTS lti_resource_links (
+    id SERIAL PRIMARY KEY,
+    platform_id INTEGER NOT NULL REFERENCES lti_platforms(id) ON DELETE CASCADE,
+    resource_link_id VARCHAR(255) NOT NULL,
+    material_id INTEGER NOT NULL REFERENCES materials(id) ON DELETE CASCADE,
+    context_id VARCHAR(255),
+    lineitem_url TEXT,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE(platform_id, resource_link_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_lti_resource_links_material_id ON lti_resource_links(material_id);
+
+-- Запуски ссылок пользователями - кому передавать оценки
+CREATE TABLE IF NOT EXISTS lti_launches (
+    resource_link_id INTEGER NOT NULL REFERENCES lti_resource_links(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    last_launch_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (resource_link_id, user_id)
+);
+
+-- Сессии выбора материалов для deep linking
+CREATE TABLE IF NOT EXISTS lti_deep_link_sessions (
+    id UUID PRIMARY KEY,
+    platform_id INTEGER NOT NULL REFERENCES lti_platforms(id) ON DELETE CASCADE,
+    deployment_id VARCHAR(255) NOT NULL,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    return_url TEXT NOT NULL,
+    data TEXT NOT NULL DEFAULT '',
+    accept_multiple BOOLEAN NOT NULL DEFAULT true,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
@@ -0,0 +1,24 @@
+-- migrations/018_add_code_blocks.sql
+
+-- Блоки с программным кодом: язык, заготовка решения и тесты (content.tests)
+ALTER TABLE material_blocks DROP CONSTRAINT IF EXISTS material_blocks_type_check;
+ALTER TABLE material_blocks ADD CONSTRAINT material_blocks_type_check
+    CHECK (type IN ('text', 'image', 'video', 'formula', 'quiz', 'code'));
+
+-- Решения учеников, проверенные тестами блока. Итог последнего решения
+-- записывается в quiz_answers наравне с ответами на вопросы
+CREATE TABLE IF NOT EXISTS code_submissions (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    material_id INTEGER NOT NULL REFERENCES materials(id) ON DELETE CASCADE,
+    block_id VARCHAR(50) NOT NULL,
+    language VARCHAR(20) NOT NULL,
+    code TEXT NOT NULL,
+    passed BOOLEAN NOT NULL,
+    tests_passed INTEGER NOT NULL DEFAULT 0,
+    tests_total INTEGER NOT NULL DEFAULT 0,
+    results JSONB NOT NULL DEFAULT '[]', -- результаты по тестам
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_code_submissions_user ON code_submissions(user_id, material_id, block_id, created_at DESC);
@@ -0,0 +1,37 @@
+-- migrations/019_create_block_library.sql
+
+-- Библиотека блоков: сохраненные фрагменты из одного или нескольких блоков.
+-- Фрагмент видит только владелец, общий (shared) - все преподаватели
+CREATE TABLE IF NOT EXISTS library_blocks (
+    id SERIAL PRIMARY KEY,
+    owner_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    title VARCHAR(500) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    subject_id VARCHAR(200) REFERENCES subjects(id),
+    shared BOOLEAN NOT NULL DEFAULT FALSE,
+    blocks JSONB NOT NULL DEFAULT '[]', -- блоки без ID, ID назначаются при вставке в материал
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_library_blocks_owner_id ON library_blocks(owner_id, updated_at DESC);
+CREATE INDEX IF NOT EXISTS idx_library_blocks_shared ON library_blocks(subject_id) WHERE shared;
+
+-- Шаблоны материалов: личные шаблоны преподавателей и официальные шаблоны
+-- по предметам, которые ведут администраторы
+CREATE TABLE IF NOT EXISTS material_templates (
+    id SERIAL PRIMARY KEY,
+    author_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    title VARCHAR(500) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    subject_id VARCHAR(200) REFERENCES subjects(id),
+    official BOOLEAN NOT NULL DEFAULT FALSE,
+    blocks JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CHECK (NOT official OR subject_id IS NOT NULL)
+);
+
+CREATE INDEX IF NOT EXISTS idx_material_templates_author_id ON material_templates(author_id, updated_at DESC);
+CREATE INDEX IF NOT EXISTS idx_material_templates_official ON material_templates(subject_id) WHERE official;
@@ -0,0 +1,14 @@
+-- migrations/020_add_material_schedule.sql
+
+-- Расписание публикации: publish_at - когда черновик станет опубликованным,
+-- unpublish_at - когда опубликованный материал уйдет в архив (окно экзамена).
+-- Статусы переводит планировщик; каталог дополнительно проверяет окно сам
+ALTER TABLE materials ADD COLUMN IF NOT EXISTS publish_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE materials ADD COLUMN IF NOT EXISTS unpublish_at TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE materials DROP CONSTRAINT IF EXISTS materials_schedule_check;
+ALTER TABLE materials ADD CONSTRAINT materials_schedule_check
+    CHECK (publish_at IS NULL OR unpublish_at IS NULL OR unpublish_at > publish_at);
+
+CREATE INDEX IF NOT EXISTS idx_materials_publish_at ON materials(publish_at) WHERE publish_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_materials_unpublish_at ON materials(unpublish_at) WHERE unpublish_at IS NOT NULL;
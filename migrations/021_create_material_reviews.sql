@@ -0,0 +1,38 @@
+-- migrations/021_create_material_reviews.sql
+
+-- Модерация: при включенной модерации публикация переводит материал в pending_review,
+-- в каталог он попадает только после одобрения модератором
+ALTER TABLE materials DROP CONSTRAINT IF EXISTS materials_status_check;
+ALTER TABLE materials ADD CONSTRAINT materials_status_check
+    CHECK (status IN ('draft', 'pending_review', 'published', 'archived'));
+
+-- Доверенные авторы публикуют без модерации
+ALTER TABLE users ADD COLUMN IF NOT EXISTS trusted_author BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Заявки на публикацию и решения модераторов
+CREATE TABLE IF NOT EXISTS material_reviews (
+    id SERIAL PRIMARY KEY,
+    material_id INTEGER NOT NULL REFERENCES materials(id) ON DELETE CASCADE,
+    submitted_by INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    submitted_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'approved', 'changes_requested', 'withdrawn')),
+    moderator_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    decided_at TIMESTAMP WITH TIME ZONE,
+    comment TEXT NOT NULL DEFAULT ''
+);
+
+-- У материала не больше одной заявки на рассмотрении
+CREATE UNIQUE INDEX IF NOT EXISTS idx_material_reviews_pending ON material_reviews(material_id) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_material_reviews_material_id ON material_reviews(material_id, submitted_at DESC);
+
+-- Замечания модератора к отдельным блокам
+CREATE TABLE IF NOT EXISTS material_review_comments (
+    id SERIAL PRIMARY KEY,
+    review_id INTEGER NOT NULL REFERENCES material_reviews(id) ON DELETE CASCADE,
+    block_id VARCHAR(50) NOT NULL,
+    comment TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_material_review_comments_review_id ON material_review_comments(review_id);
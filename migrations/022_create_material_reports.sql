@@ -0,0 +1,40 @@
+-- migrations/022_create_material_reports.sql
+
+-- Уведомления пользователей (решения по жалобам, предупреждения авторам)
+CREATE TABLE IF NOT EXISTS notifications (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type VARCHAR(50) NOT NULL,
+    message TEXT NOT NULL,
+    material_id INTEGER REFERENCES materials(id) ON DELETE SET NULL,
+    read_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user_id ON notifications(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_notifications_unread ON notifications(user_id) WHERE read_at IS NULL;
+
+-- Жалобы на материалы
+CREATE TABLE IF NOT EXISTS material_reports (
+    id SERIAL PRIMARY KEY,
+    material_id INTEGER NOT NULL REFERENCES materials(id) ON DELETE CASCADE,
+    block_id VARCHAR(50),
+    reporter_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    category VARCHAR(20) NOT NULL
+        CHECK (category IN ('error', 'inappropriate', 'copyright', 'spam', 'other')),
+    comment TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'open'
+        CHECK (status IN ('open', 'dismissed', 'resolved')),
+    action VARCHAR(20)
+        CHECK (action IN ('dismiss', 'unpublish', 'warn_author', 'block_author')),
+    moderator_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    resolution TEXT NOT NULL DEFAULT '',
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Одна открытая жалоба пользователя на материал
+CREATE UNIQUE INDEX IF NOT EXISTS idx_material_reports_open ON material_reports(material_id, reporter_id) WHERE status = 'open';
+CREATE INDEX IF NOT EXISTS idx_material_reports_status ON material_reports(status, created_at);
+-- Для ограничения частоты жалоб
+CREATE INDEX IF NOT EXISTS idx_material_reports_reporter ON material_reports(reporter_id, created_at DESC);
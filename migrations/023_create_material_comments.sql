@@ -0,0 +1,30 @@
+-- migrations/023_create_material_comments.sql
+
+-- Обсуждения материалов: вопросы к материалу или к отдельному блоку и ответы на них.
+-- block_id не ссылается на material_blocks: после удаления блока его обсуждения
+-- остаются у материала
+CREATE TABLE IF NOT EXISTS material_comments (
+    id SERIAL PRIMARY KEY,
+    material_id INTEGER NOT NULL REFERENCES materials(id) ON DELETE CASCADE,
+    block_id VARCHAR(50),
+    parent_id INTEGER REFERENCES material_comments(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    content TEXT NOT NULL,
+    is_author BOOLEAN NOT NULL DEFAULT FALSE, -- написан автором или соавтором материала
+    upvotes INTEGER NOT NULL DEFAULT 0,
+    resolved BOOLEAN NOT NULL DEFAULT FALSE,
+    hidden BOOLEAN NOT NULL DEFAULT FALSE,
+    moderated_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_material_comments_threads ON material_comments(material_id, created_at) WHERE parent_id IS NULL;
+CREATE INDEX IF NOT EXISTS idx_material_comments_parent_id ON material_comments(parent_id);
+
+CREATE TABLE IF NOT EXISTS material_comment_votes (
+    comment_id INTEGER NOT NULL REFERENCES material_comments(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (comment_id, user_id)
+);
@@ -0,0 +1,9 @@
+-- migrations/024_add_rating_reviews.sql
+
+-- Отзывы учеников к оценкам материалов и ответы авторов на них
+ALTER TABLE material_ratings ADD COLUMN IF NOT EXISTS review TEXT NOT NULL DEFAULT '';
+ALTER TABLE material_ratings ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE material_ratings ADD COLUMN IF NOT EXISTS author_response TEXT NOT NULL DEFAULT '';
+ALTER TABLE material_ratings ADD COLUMN IF NOT EXISTS responded_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_material_ratings_reviews ON material_ratings(material_id, updated_at DESC) WHERE review <> '';
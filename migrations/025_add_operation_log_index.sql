@@ -0,0 +1,4 @@
+-- migrations/025_add_operation_log_index.sql
+
+-- Журнал операций совместного редактирования регулярно чистится по возрасту записей
+CREATE INDEX IF NOT EXISTS idx_material_operations_created ON material_operations(created_at);